@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// toolName matches the names MCP clients accept for tools
+var toolName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// registerAliases registers each configured alias as a tool with the schema
+// and handler of the tool it stands for, so that prompts written for other
+// bridges or editor shortcuts, such as gd for definition, work unchanged.
+// Aliases may stand for macros but not for other aliases.
+func (s *mcpServer) registerAliases() error {
+	names := make([]string, 0, len(s.config.aliases))
+	for name := range s.config.aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		target := s.config.aliases[name]
+		if !toolName.MatchString(name) {
+			return fmt.Errorf("invalid alias name %q", name)
+		}
+		if _, ok := s.toolHandlers[name]; ok {
+			return fmt.Errorf("alias %s has the same name as a tool", name)
+		}
+		if _, ok := s.config.aliases[target]; ok {
+			return fmt.Errorf("alias %s refers to another alias, %s", name, target)
+		}
+		handler, ok := s.toolHandlers[target]
+		if !ok {
+			return fmt.Errorf("alias %s refers to unknown tool %s", name, target)
+		}
+
+		var tool mcp.Tool
+		for _, t := range s.tools {
+			if t.Name == target {
+				tool = t
+			}
+		}
+		tool.Name = name
+		tool.Description = fmt.Sprintf("Alias of %s. %s", target, tool.Description)
+		s.toolMethods[name] = s.toolMethods[target]
+		s.addTool(tool, handler)
+	}
+
+	return nil
+}
+
+// toolAliases returns the configured aliases of a tool, sorted
+func (s *mcpServer) toolAliases(target string) []string {
+	var aliases []string
+	for name, t := range s.config.aliases {
+		if t == target {
+			aliases = append(aliases, name)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
+}
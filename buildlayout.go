@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/koonwen/mcp-language-server/internal/buildsystem"
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/tools"
+	"github.com/koonwen/mcp-language-server/internal/watcher"
+)
+
+// detectBuildLayout detects a Bazel-style build system in the workspace so
+// that its output trees are left out of watching and indexing, and generated
+// files are mapped back to their sources. It must be called before the
+// language servers are started.
+func (s *mcpServer) detectBuildLayout() {
+	layout := buildsystem.Detect(s.config.workspaceDir)
+	s.buildLayout = layout
+	tools.SetBuildLayout(layout)
+	if layout == nil {
+		return
+	}
+	coreLogger.Info("Detected %s workspace, excluding %v", layout.Kind, layout.OutputDirs)
+
+	// gopls cannot load packages built by Bazel with go list
+	if layout.GoPackagesDriver != "" && filepath.Base(s.config.lspCommand) == "gopls" && os.Getenv("GOPACKAGESDRIVER") == "" {
+		coreLogger.Info("Using %s as GOPACKAGESDRIVER", layout.GoPackagesDriver)
+		os.Setenv("GOPACKAGESDRIVER", layout.GoPackagesDriver)
+	}
+}
+
+// configureBuildLayout keeps a language server from indexing the output trees
+// of the build system and, with a scope, everything outside it.
+// directoryFilters is a gopls setting that other servers ignore.
+func (s *mcpServer) configureBuildLayout(client *lsp.Client) {
+	filters := s.scopeFilters()
+	if s.buildLayout != nil {
+		filters = append(filters, "-**/node_modules")
+		for _, dir := range s.buildLayout.OutputDirs {
+			filters = append(filters, "-"+dir)
+		}
+	}
+	if len(filters) > 0 {
+		client.SetInitializationOption("directoryFilters", filters)
+	}
+	s.configureScope(client)
+	s.configureWorkspaceFolders(client)
+}
+
+// watcherConfig returns the configuration of the workspace watchers, which
+// skip the output trees of the build system, the directories outside the
+// scope and the directory of report files
+func (s *mcpServer) watcherConfig() *watcher.WatcherConfig {
+	config := watcher.DefaultWatcherConfig()
+	if s.buildLayout != nil {
+		for _, dir := range s.buildLayout.OutputDirs {
+			config.ExcludedDirs[dir] = true
+		}
+	}
+	for _, dir := range s.outOfScopeDirs() {
+		config.ExcludedPaths[filepath.Join(s.config.workspaceDir, filepath.FromSlash(dir))] = true
+	}
+	if sink, ok := s.resultSink.(*fileSink); ok {
+		config.ExcludedPaths[sink.dir] = true
+	}
+	return config
+}
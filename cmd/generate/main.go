@@ -36,6 +36,7 @@ var lspGitRef = "release/protocol/3.17.6-next.9"
 var (
 	repodir   = flag.String("d", "", "directory containing clone of "+vscodeRepo)
 	outputdir = flag.String("o", ".", "output directory")
+	gitref    = flag.String("r", "", "branch or tag of "+vscodeRepo+" to generate from (default "+lspGitRef+")")
 	// PJW: not for real code
 	cmpdir      = flag.String("c", "", "directory of earlier code")
 	doboth      = flag.String("b", "", "generate and compare")
@@ -52,6 +53,10 @@ func main() {
 func processinline() {
 	// A local repository may be specified during debugging.
 	// The default behavior is to download the canonical version.
+	if *gitref != "" {
+		lspGitRef = *gitref
+	}
+
 	if *repodir == "" {
 		tmpdir, err := os.MkdirTemp("", "")
 		if err != nil {
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/tools"
+	"gopkg.in/yaml.v3"
+)
+
+// fileConfig is the optional JSON or YAML configuration file passed with
+// -config
+type fileConfig struct {
+	// Workspace is the workspace directory, used when -workspace is not
+	// given. A relative path is relative to the config file.
+	Workspace string `json:"workspace"`
+
+	// Command and Args start the main language server when -lsp is not
+	// given
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+
+	// InitializationOptions are sent to the main language server, merged
+	// with the defaults
+	InitializationOptions map[string]any `json:"initializationOptions"`
+
+	// Settings are returned to the main language server when it asks for
+	// its configuration with workspace/configuration, e.g. {"python":
+	// {"venvPath": ".venv"}} for pyright
+	Settings map[string]any `json:"settings"`
+
+	// Env sets environment variables for the main language server
+	Env map[string]string `json:"env"`
+
+	// ContextLines is the number of lines shown around locations in tool
+	// results (default: 5). It takes precedence over the LSP_CONTEXT_LINES
+	// environment variable.
+	ContextLines *int `json:"contextLines"`
+
+	// Tools enables or disables tools by name. Tools are enabled unless set
+	// to false.
+	Tools map[string]bool `json:"tools"`
+
+	// SecurityWatchlist lists the symbols reported by the security_watchlist tool
+	SecurityWatchlist []string `json:"securityWatchlist"`
+
+	// Messages overrides the boilerplate text of tool output, keyed by message ID
+	Messages map[string]string `json:"messages"`
+
+	// SoftTimeout is how long a tool may spend formatting results before it
+	// returns what it has along with a continuation token, e.g. "30s". "0"
+	// disables the deadline.
+	SoftTimeout string `json:"softTimeout"`
+
+	// Concurrency limits the number of parallel requests to the language server
+	Concurrency *concurrencyConfig `json:"concurrency"`
+
+	// Templates replaces the output format of tools with Go text/template
+	// templates, keyed by tool name
+	Templates map[string]string `json:"templates"`
+
+	// Macros defines tools that call a sequence of existing tools, keyed by
+	// tool name
+	Macros map[string]macroConfig `json:"macros"`
+
+	// Aliases registers additional names for tools, keyed by alias, e.g.
+	// "gd" for definition
+	Aliases map[string]string `json:"aliases"`
+
+	// DebugDir is where forensic bundles are written when the language
+	// server crashes
+	DebugDir string `json:"debugDir"`
+
+	// MaxRestarts is how many times a crashed language server is restarted
+	// within five minutes before it is left down (default: 3). 0 disables
+	// restarts.
+	MaxRestarts *int `json:"maxRestarts"`
+
+	// MessageRequests answers the prompts of the language servers
+	MessageRequests messageRequestsConfig `json:"messageRequests"`
+
+	// WorkspaceFingerprint adds an anonymized fingerprint of the workspace
+	// to the manifest, for clients to key caches with
+	WorkspaceFingerprint bool `json:"workspaceFingerprint"`
+
+	// DedupeSnippets replaces large snippets that were already returned in
+	// the session by their content hash, to be fetched with fetch_snippet
+	DedupeSnippets bool `json:"dedupeSnippets"`
+
+	// TrimSnippets removes comments and extra blank lines from returned
+	// snippets
+	TrimSnippets tools.SnippetTrimming `json:"trimSnippets"`
+
+	// PrefetchDefinitions fetches the definitions of identifiers in returned
+	// snippets in the background, to answer later definition requests from
+	// a cache
+	PrefetchDefinitions bool `json:"prefetchDefinitions"`
+
+	// Servers are additional language servers started alongside the main
+	// one, e.g. for other languages in the workspace. Federated symbol
+	// searches query all of them.
+	Servers []serverConfig `json:"servers"`
+
+	// Embedded maps the languages of code embedded in string literals, such
+	// as "sql", to the name of the server in Servers that analyzes them
+	Embedded map[string]string `json:"embedded"`
+
+	// Hooks are commands run before and after tools that change files
+	Hooks hooksConfig `json:"hooks"`
+
+	// Scope restricts indexing to these subdirectories of the workspace, for
+	// workspaces too large to index as a whole
+	Scope []string `json:"scope"`
+
+	// WorkspaceFolders are more directories, absolute or relative to the
+	// workspace, passed to the language servers as workspace folders, such
+	// as sibling repositories or the roots of a monorepo
+	WorkspaceFolders []string `json:"workspaceFolders"`
+
+	// LargeResults stores results above a size threshold in a report file
+	// or resource and returns their first lines instead
+	LargeResults largeResultsConfig `json:"largeResults"`
+
+	// OneIndexed is whether tools take 1-indexed lines and columns when a
+	// call does not say (default: true). Set it to false for clients that
+	// pass 0-indexed LSP positions.
+	OneIndexed *bool `json:"oneIndexed"`
+
+	// RelocateRadius is how many lines above and below a position tools
+	// search for the line given as lineText when the line at the position
+	// does not match it (default: 10)
+	RelocateRadius *int `json:"relocateRadius"`
+
+	// OutputFormat is the format of the results of the tools that support
+	// JSON when a call does not say: text (default) or json
+	OutputFormat string `json:"outputFormat"`
+}
+
+// serverConfig describes an additional language server
+type serverConfig struct {
+	// Name labels the server's results, the base name of the command by
+	// default
+	Name    string   `json:"name"`
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	// InitializationOptions, Settings and Env are passed to this server as
+	// for the main one
+	InitializationOptions map[string]any    `json:"initializationOptions"`
+	Settings              map[string]any    `json:"settings"`
+	Env                   map[string]string `json:"env"`
+	// Extensions and Languages route the tools called on files with these
+	// extensions, such as ".ts", or language IDs, such as "python", to this
+	// server instead of the main one
+	Extensions []string `json:"extensions"`
+	Languages  []string `json:"languages"`
+}
+
+// concurrencyConfig overrides the default request concurrency limits. A limit
+// of 0 means unlimited.
+type concurrencyConfig struct {
+	Global    *int           `json:"global"`
+	PerMethod map[string]int `json:"perMethod"`
+}
+
+// concurrencyLimits merges the configured limits with the defaults
+func (c *concurrencyConfig) concurrencyLimits() lsp.ConcurrencyLimits {
+	limits := lsp.ConcurrencyLimits{
+		Global:    lsp.DefaultConcurrencyLimits.Global,
+		PerMethod: make(map[string]int),
+	}
+	for method, limit := range lsp.DefaultConcurrencyLimits.PerMethod {
+		limits.PerMethod[method] = limit
+	}
+	if c == nil {
+		return limits
+	}
+
+	if c.Global != nil {
+		limits.Global = *c.Global
+	}
+	for method, limit := range c.PerMethod {
+		limits.PerMethod[method] = limit
+	}
+	return limits
+}
+
+// loadConfigFile reads and parses a JSON or YAML configuration file
+func loadConfigFile(path string) (*fileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	// YAML is converted to JSON, so that both are read with the same field
+	// names and validation
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+		}
+	}
+
+	var fc fileConfig
+	if err := json.Unmarshal(data, &fc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+
+	return &fc, nil
+}
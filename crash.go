@@ -0,0 +1,191 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultDebugDir is where crash bundles are written when the config file
+// does not set debugDir
+var defaultDebugDir = filepath.Join(os.TempDir(), "mcp-language-server")
+
+// handleCrash writes a forensic bundle for a language server crash and tells
+// the MCP client where to find it
+func (s *mcpServer) handleCrash(report lsp.CrashReport) {
+	path, err := s.writeCrashBundle(report)
+	if err != nil {
+		coreLogger.Error("Failed to write crash bundle: %v", err)
+		return
+	}
+	coreLogger.Error("Language server crashed, forensic bundle written to %s", path)
+
+	s.crashMu.Lock()
+	s.crashBundle = path
+	s.crashMu.Unlock()
+
+	if s.mcpServer != nil {
+		s.mcpServer.SendNotificationToAllClients("notifications/message", map[string]any{
+			"level":  "error",
+			"logger": "mcp-language-server",
+			"data":   crashNote(path),
+		})
+	}
+}
+
+// crashNote tells the user where to find the crash bundle
+func crashNote(path string) string {
+	return fmt.Sprintf("The language server crashed. A forensic bundle for bug reports was written to %s", path)
+}
+
+// restartPolicy is how often crashed language servers are restarted
+func (s *mcpServer) restartPolicy() lsp.RestartPolicy {
+	policy := lsp.DefaultRestartPolicy
+	policy.MaxRestarts = s.config.maxRestarts
+	return policy
+}
+
+// restartHandler returns the function told whether a crashed server was
+// restarted. The outcome is sent to the MCP client as a log message and
+// added to the next tool result.
+func (s *mcpServer) restartHandler(name string) lsp.RestartHandler {
+	return func(err error) {
+		note := restartNote(name, err)
+		s.crashMu.Lock()
+		s.restartNote = note
+		s.crashMu.Unlock()
+
+		if s.mcpServer != nil {
+			s.mcpServer.SendNotificationToAllClients("notifications/message", map[string]any{
+				"level":  "warning",
+				"logger": "mcp-language-server",
+				"data":   note,
+			})
+		}
+	}
+}
+
+// restartNote tells the user that a crashed server was restarted, or why it
+// was not
+func restartNote(name string, err error) string {
+	if err != nil {
+		return fmt.Sprintf("Warning: the language server %s crashed and could not be restarted: %v. Restart the MCP server to recover.", name, err)
+	}
+	return fmt.Sprintf("Warning: the language server %s crashed and was restarted. Open documents were reopened, but results may be incomplete until it has finished loading the workspace.", name)
+}
+
+// withCrashNote adds the outcome of the last restart of a crashed server, if
+// it has not been reported yet, to a tool result, and the location of the
+// crash bundle, if there is one, to a failed tool result
+func (s *mcpServer) withCrashNote(result *mcp.CallToolResult) *mcp.CallToolResult {
+	if result == nil {
+		return result
+	}
+
+	s.crashMu.Lock()
+	path := s.crashBundle
+	note := s.restartNote
+	s.restartNote = ""
+	s.crashMu.Unlock()
+
+	if note != "" {
+		result.Content = append(result.Content, mcp.NewTextContent(note))
+	}
+	if path != "" && result.IsError {
+		result.Content = append(result.Content, mcp.NewTextContent(crashNote(path)))
+	}
+	return result
+}
+
+// writeCrashBundle writes a zip file with the recent JSON-RPC messages, the
+// tail of the server's stderr, the open documents, versions and configuration
+func (s *mcpServer) writeCrashBundle(report lsp.CrashReport) (string, error) {
+	dir := s.config.debugDir
+	if dir == "" {
+		dir = defaultDebugDir
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create debug directory: %v", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("crash-%s-%d.zip", report.Time.Format("20060102-150405"), os.Getpid()))
+	file, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create crash bundle: %v", err)
+	}
+	defer file.Close()
+
+	files := map[string]string{
+		"summary.txt":        s.crashSummary(report),
+		"messages.jsonl":     formatCrashMessages(report.Messages),
+		"stderr.log":         strings.Join(report.Stderr, "\n") + "\n",
+		"open-documents.txt": strings.Join(report.OpenDocuments, "\n") + "\n",
+	}
+	if s.config.configFile != "" {
+		if data, err := os.ReadFile(s.config.configFile); err == nil {
+			files["config.json"] = string(data)
+		}
+	}
+
+	archive := zip.NewWriter(file)
+	for name, content := range files {
+		w, err := archive.Create(name)
+		if err != nil {
+			return "", fmt.Errorf("failed to write %s to crash bundle: %v", name, err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			return "", fmt.Errorf("failed to write %s to crash bundle: %v", name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return "", fmt.Errorf("failed to write crash bundle: %v", err)
+	}
+
+	return path, nil
+}
+
+// crashSummary describes the crash and the versions involved
+func (s *mcpServer) crashSummary(report lsp.CrashReport) string {
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf("Time: %s\n", report.Time.Format(time.RFC3339)))
+	summary.WriteString(fmt.Sprintf("Error: %v\n", report.Err))
+	summary.WriteString(fmt.Sprintf("MCP Language Server: %s (%s, %s/%s)\n", serverVersion, runtime.Version(), runtime.GOOS, runtime.GOARCH))
+	summary.WriteString(fmt.Sprintf("Language server command: %s %s\n", s.config.lspCommand, strings.Join(s.config.lspArgs, " ")))
+	if s.lspClient != nil {
+		if result := s.lspClient.InitializeResult(); result != nil && result.ServerInfo != nil {
+			summary.WriteString(fmt.Sprintf("Language server: %s %s\n", result.ServerInfo.Name, result.ServerInfo.Version))
+		}
+	}
+	summary.WriteString(fmt.Sprintf("Workspace: %s\n", s.config.workspaceDir))
+	return summary.String()
+}
+
+// formatCrashMessages renders JSON-RPC messages as JSON lines
+func formatCrashMessages(entries []lsp.TraceEntry) string {
+	var result strings.Builder
+	for _, entry := range entries {
+		direction := "received"
+		if entry.Sent {
+			direction = "sent"
+		}
+		line, err := json.Marshal(map[string]any{
+			"time":      entry.Time.Format(time.RFC3339Nano),
+			"direction": direction,
+			"message":   entry.Message,
+		})
+		if err != nil {
+			continue
+		}
+		result.Write(line)
+		result.WriteString("\n")
+	}
+	return result.String()
+}
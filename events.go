@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/koonwen/mcp-language-server/internal/utilities"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	eventsURI = "mcp-language-server://events"
+	// maxWorkspaceEvents bounds the events kept. The oldest are dropped.
+	maxWorkspaceEvents = 1000
+	// duplicateFileChangeWindow is how long after a file change the same
+	// change reported by the watcher of another server is ignored
+	duplicateFileChangeWindow = time.Second
+)
+
+// Kinds of workspace events
+const (
+	eventFile        = "file"
+	eventDiagnostics = "diagnostics"
+	eventEdit        = "edit"
+)
+
+// workspaceEvent is something that happened in the workspace during the
+// session: a file changed on disk, the diagnostics of a file changed, or a
+// tool edited files
+type workspaceEvent struct {
+	// Seq numbers events in the order they happened, from 1
+	Seq  int64     `json:"seq"`
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"`
+	// Path is the file that changed or whose diagnostics changed
+	Path string `json:"path,omitempty"`
+	// Change is "created", "changed" or "deleted" for file events
+	Change string `json:"change,omitempty"`
+	// Diagnostics is the change in the diagnostics of the file
+	Diagnostics *diagnosticsDelta `json:"diagnostics,omitempty"`
+	// Edit is the tool call that made an edit, and Files the files it
+	// changed
+	Edit  string   `json:"edit,omitempty"`
+	Files []string `json:"files,omitempty"`
+}
+
+// diagnosticsDelta is how the diagnostics of a file changed
+type diagnosticsDelta struct {
+	Errors   int `json:"errors"`
+	Warnings int `json:"warnings"`
+	Added    int `json:"added"`
+	Removed  int `json:"removed"`
+}
+
+// eventsPage is the content of the events resource
+type eventsPage struct {
+	// Cursor is the sequence number of the last event, to pass as since to
+	// read the events that follow
+	Cursor int64 `json:"cursor"`
+	// Missed is set if events after since were dropped to bound memory
+	Missed bool             `json:"missed,omitempty"`
+	Events []workspaceEvent `json:"events"`
+}
+
+// eventJournal is the chronological journal of workspace events
+type eventJournal struct {
+	mu     sync.Mutex
+	seq    int64
+	events []workspaceEvent
+}
+
+func (j *eventJournal) add(event workspaceEvent) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.addLocked(event)
+}
+
+// addLocked adds an event to the journal. j.mu must be held.
+func (j *eventJournal) addLocked(event workspaceEvent) {
+	j.seq++
+	event.Seq = j.seq
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	j.events = append(j.events, event)
+	if len(j.events) > maxWorkspaceEvents {
+		j.events = j.events[len(j.events)-maxWorkspaceEvents:]
+	}
+}
+
+// since returns the events after a sequence number
+func (j *eventJournal) since(seq int64) eventsPage {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	page := eventsPage{Cursor: j.seq, Events: []workspaceEvent{}}
+	for _, event := range j.events {
+		if event.Seq > seq {
+			page.Events = append(page.Events, event)
+		}
+	}
+	page.Missed = len(j.events) > 0 && j.events[0].Seq > seq+1
+	return page
+}
+
+// recordFileChange adds a change to a file on disk to the journal, unless
+// the same change was just recorded because the watchers of several servers
+// saw it. It reports whether the change was added.
+func (j *eventJournal) recordFileChange(path string, changeType protocol.FileChangeType) bool {
+	change := "changed"
+	switch changeType {
+	case protocol.Created:
+		change = "created"
+	case protocol.Deleted:
+		change = "deleted"
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	for i := len(j.events) - 1; i >= 0 && now.Sub(j.events[i].Time) < duplicateFileChangeWindow; i-- {
+		event := j.events[i]
+		if event.Kind == eventFile && event.Path == path && event.Change == change {
+			return false
+		}
+	}
+	j.addLocked(workspaceEvent{Kind: eventFile, Path: path, Change: change, Time: now})
+	return true
+}
+
+// recordDiagnostics adds a change in the published diagnostics of a file to
+// the journal. Publications that change nothing are left out.
+func (j *eventJournal) recordDiagnostics(uri protocol.DocumentUri, before, after []protocol.Diagnostic) {
+	delta := diffDiagnostics(before, after)
+	if delta.Added == 0 && delta.Removed == 0 {
+		return
+	}
+	j.add(workspaceEvent{Kind: eventDiagnostics, Path: lsp.DocumentPath(uri), Diagnostics: &delta})
+}
+
+// recordEdit adds the files changed by a tool call to the journal
+func (j *eventJournal) recordEdit(entry *utilities.JournalEntry) {
+	if entry == nil {
+		return
+	}
+	files := make([]string, len(entry.Files))
+	for i, file := range entry.Files {
+		files[i] = file.Path
+	}
+	j.add(workspaceEvent{Kind: eventEdit, Edit: entry.Label, Files: files})
+}
+
+// diffDiagnostics counts the diagnostics of a file after a publication and
+// those added and removed by it, matching them by severity, range and
+// message
+func diffDiagnostics(before, after []protocol.Diagnostic) diagnosticsDelta {
+	type key struct {
+		severity protocol.DiagnosticSeverity
+		rng      protocol.Range
+		message  string
+	}
+	counts := make(map[key]int)
+	for _, diag := range before {
+		counts[key{diag.Severity, diag.Range, diag.Message}]++
+	}
+
+	var delta diagnosticsDelta
+	for _, diag := range after {
+		switch diag.Severity {
+		case protocol.SeverityError:
+			delta.Errors++
+		case protocol.SeverityWarning:
+			delta.Warnings++
+		}
+		k := key{diag.Severity, diag.Range, diag.Message}
+		if counts[k] > 0 {
+			counts[k]--
+		} else {
+			delta.Added++
+		}
+	}
+	for _, n := range counts {
+		delta.Removed += n
+	}
+	return delta
+}
+
+// registerEventsResource registers the resource listing workspace events,
+// read from a cursor with mcp-language-server://events?since=<cursor>
+func (s *mcpServer) registerEventsResource() {
+	description := fmt.Sprintf("Chronological journal of workspace events in this session: files changed on disk, changes in the diagnostics of a file, and edits made by tools, with the files they changed. Read %s?since=<cursor> with the cursor of the previous read to catch up on what happened since. The last %d events are kept.", eventsURI, maxWorkspaceEvents)
+
+	handler := func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		var since int64
+		var value string
+		switch v := request.Params.Arguments["since"].(type) {
+		case string:
+			value = v
+		case []string:
+			if len(v) > 0 {
+				value = v[0]
+			}
+		}
+		if value != "" {
+			var err error
+			if since, err = strconv.ParseInt(value, 10, 64); err != nil || since < 0 {
+				return nil, fmt.Errorf("invalid since cursor: %s", value)
+			}
+		}
+
+		data, err := json.MarshalIndent(s.events.since(since), "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal events: %v", err)
+		}
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: "application/json",
+				Text:     string(data),
+			},
+		}, nil
+	}
+
+	s.mcpServer.AddResource(mcp.NewResource(eventsURI, "Workspace events",
+		mcp.WithResourceDescription(description),
+		mcp.WithMIMEType("application/json"),
+	), handler)
+	s.mcpServer.AddResourceTemplate(mcp.NewResourceTemplate(eventsURI+"{?since}", "Workspace events since a cursor",
+		mcp.WithTemplateDescription(description),
+		mcp.WithTemplateMIMEType("application/json"),
+	), handler)
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordFileChangeIgnoresDuplicates(t *testing.T) {
+	var j eventJournal
+
+	assert.True(t, j.recordFileChange("/ws/a.go", protocol.Changed))
+	// The watcher of another server reports the same change
+	assert.False(t, j.recordFileChange("/ws/a.go", protocol.Changed))
+	assert.True(t, j.recordFileChange("/ws/a.go", protocol.Deleted))
+	assert.True(t, j.recordFileChange("/ws/b.go", protocol.Changed))
+
+	j.events[0].Time = time.Now().Add(-duplicateFileChangeWindow)
+	j.events[1].Time = time.Now().Add(-duplicateFileChangeWindow)
+	j.events[2].Time = time.Now().Add(-duplicateFileChangeWindow)
+	assert.True(t, j.recordFileChange("/ws/a.go", protocol.Changed))
+
+	page := j.since(0)
+	assert.Len(t, page.Events, 4)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	heatmapURI = "mcp-language-server://heatmap"
+	// heatHalfLife is how long it takes the heat of a file or symbol to
+	// halve when it is not queried again
+	heatHalfLife = 10 * time.Minute
+	// maxHeatmapEntries is the number of files and of symbols listed in the
+	// heatmap resource, the hottest first
+	maxHeatmapEntries = 50
+	// minHeat is the heat below which entries are left out of the resource
+	minHeat = 0.05
+)
+
+// heatmap records the files and symbols queried in the session, with a heat
+// that rises with each query and decays over time, so that agents can tell
+// what has already been explored
+type heatmap struct {
+	mu      sync.Mutex
+	files   map[string]*heatEntry
+	symbols map[string]*heatEntry
+}
+
+// heatEntry is the heat of a file or symbol as of its last query
+type heatEntry struct {
+	heat    float64
+	queries int
+	last    time.Time
+	tools   map[string]bool
+}
+
+// heatmapEntry is a file or symbol in the heatmap resource
+type heatmapEntry struct {
+	Name        string    `json:"name"`
+	Heat        float64   `json:"heat"`
+	Queries     int       `json:"queries"`
+	LastQueried time.Time `json:"lastQueried"`
+	Tools       []string  `json:"tools"`
+}
+
+// heatmapResource is the JSON of the heatmap resource
+type heatmapResource struct {
+	HalfLifeSeconds int            `json:"halfLifeSeconds"`
+	Files           []heatmapEntry `json:"files"`
+	Symbols         []heatmapEntry `json:"symbols"`
+}
+
+// record adds a query by a tool to the heat of the file and symbol in its
+// arguments
+func (h *heatmap) record(toolName string, arguments map[string]any, now time.Time) {
+	filePath, _ := arguments["filePath"].(string)
+	symbolName, _ := arguments["symbolName"].(string)
+	if filePath == "" && symbolName == "" {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.files == nil {
+		h.files = make(map[string]*heatEntry)
+		h.symbols = make(map[string]*heatEntry)
+	}
+	if filePath != "" {
+		touchHeat(h.files, filePath, toolName, now)
+	}
+	if symbolName != "" {
+		touchHeat(h.symbols, symbolName, toolName, now)
+	}
+}
+
+func touchHeat(entries map[string]*heatEntry, name, toolName string, now time.Time) {
+	entry, ok := entries[name]
+	if !ok {
+		entry = &heatEntry{tools: make(map[string]bool)}
+		entries[name] = entry
+	}
+	entry.heat = decayedHeat(entry.heat, entry.last, now) + 1
+	entry.queries++
+	entry.last = now
+	entry.tools[toolName] = true
+}
+
+// decayedHeat is the heat recorded at last, decayed until now
+func decayedHeat(heat float64, last, now time.Time) float64 {
+	if last.IsZero() {
+		return heat
+	}
+	return heat * math.Pow(0.5, float64(now.Sub(last))/float64(heatHalfLife))
+}
+
+// snapshot returns the heatmap as of now, hottest first. Entries that have
+// cooled down are dropped.
+func (h *heatmap) snapshot(now time.Time) heatmapResource {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	resource := heatmapResource{
+		HalfLifeSeconds: int(heatHalfLife.Seconds()),
+		Files:           heatmapEntries(h.files, now),
+		Symbols:         heatmapEntries(h.symbols, now),
+	}
+	return resource
+}
+
+func heatmapEntries(entries map[string]*heatEntry, now time.Time) []heatmapEntry {
+	result := []heatmapEntry{}
+	for name, entry := range entries {
+		heat := decayedHeat(entry.heat, entry.last, now)
+		if heat < minHeat {
+			delete(entries, name)
+			continue
+		}
+		tools := make([]string, 0, len(entry.tools))
+		for tool := range entry.tools {
+			tools = append(tools, tool)
+		}
+		sort.Strings(tools)
+		result = append(result, heatmapEntry{
+			Name:        name,
+			Heat:        math.Round(heat*100) / 100,
+			Queries:     entry.queries,
+			LastQueried: entry.last,
+			Tools:       tools,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Heat != result[j].Heat {
+			return result[i].Heat > result[j].Heat
+		}
+		return result[i].Name < result[j].Name
+	})
+	if len(result) > maxHeatmapEntries {
+		result = result[:maxHeatmapEntries]
+	}
+	return result
+}
+
+// registerHeatmapResource registers the resource listing the files and
+// symbols queried in the session
+func (s *mcpServer) registerHeatmapResource() {
+	resource := mcp.NewResource(heatmapURI, "Query heatmap",
+		mcp.WithResourceDescription(fmt.Sprintf("The files and symbols queried by tools in this session, hottest first. Heat rises by one with each query and halves every %s, so it reflects both how often and how recently something was looked at. Use it to avoid exploring the same code again or to summarize what has been looked at so far.", heatHalfLife)),
+		mcp.WithMIMEType("application/json"),
+	)
+
+	s.mcpServer.AddResource(resource, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		data, err := json.MarshalIndent(s.heatmap.snapshot(time.Now()), "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal heatmap: %v", err)
+		}
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      heatmapURI,
+				MIMEType: "application/json",
+				Text:     string(data),
+			},
+		}, nil
+	})
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/koonwen/mcp-language-server/internal/utilities"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// defaultHookTimeout bounds how long an edit hook may run
+	defaultHookTimeout = time.Minute
+	// maxHookOutput is the number of bytes of a hook's output attached to
+	// the tool result. Longer output is cut from the start, since errors are
+	// usually printed last.
+	maxHookOutput = 4000
+	// undoToolName is the tool that reverts the last journaled edit
+	undoToolName = "undo_last_edit"
+)
+
+// mutatingTools are the tools that change files. The edit hooks run around
+// all of them but undo_last_edit, which is not journaled either, so that
+// undoing again reverts the edit before.
+var mutatingTools = map[string]bool{
+	"edit_file":         true,
+	"apply_code_action": true,
+	"execute_codelens":  true,
+	"format_document":   true,
+	"format_range":      true,
+	"add_import":        true,
+	"rename_symbol":     true,
+	"rename_package":    true,
+	"rename_file":       true,
+	undoToolName:        true,
+}
+
+// hooksConfig lists the commands run before and after tools that change
+// files, such as a formatter, a license header check or a pre-commit hook
+type hooksConfig struct {
+	PreEdit  []hookConfig `json:"preEdit"`
+	PostEdit []hookConfig `json:"postEdit"`
+}
+
+// hookConfig is a command run in the workspace directory around edits. The
+// name of the tool is passed in the MCP_TOOL environment variable.
+type hookConfig struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	// Timeout bounds how long the command may run, e.g. "30s" (default: 1m)
+	Timeout string `json:"timeout"`
+	// Required pre-edit hooks stop the tool from running when they fail
+	Required bool `json:"required"`
+}
+
+// hookResult is the outcome of running an edit hook
+type hookResult struct {
+	hook   hookConfig
+	output string
+	err    error
+}
+
+// withEditJournal wraps the handler of a tool that changes files so that the
+// files it touches are recorded in the edit journal, for undo_last_edit. The
+// entry is read back after the post-edit hooks, so undoing also reverts what
+// they changed in the same files. Calls that overlap share an entry, see
+// utilities.BeginJournalEntry. Edits are also added to the workspace events.
+func (s *mcpServer) withEditJournal(name string, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if dryRun, _ := request.Params.Arguments["dryRun"].(bool); dryRun {
+			return handler(ctx, request)
+		}
+
+		label := name
+		if filePath, ok := request.Params.Arguments["filePath"].(string); ok {
+			label += " " + filePath
+		}
+		end := utilities.BeginJournalEntry(label)
+		defer func() { s.events.recordEdit(end()) }()
+		return handler(ctx, request)
+	}
+}
+
+// withEditHooks wraps the handler of a tool that changes files so that the
+// configured pre-edit hooks run before it and the post-edit hooks after it,
+// with their output attached to the result. Dry runs, which change nothing,
+// run no hooks, and the post-edit hooks are skipped when the tool fails.
+func (s *mcpServer) withEditHooks(name string, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	hooks := s.config.hooks
+	if len(hooks.PreEdit) == 0 && len(hooks.PostEdit) == 0 {
+		return handler
+	}
+
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if dryRun, _ := request.Params.Arguments["dryRun"].(bool); dryRun {
+			return handler(ctx, request)
+		}
+
+		pre := s.runHooks(ctx, name, hooks.PreEdit)
+		for _, r := range pre {
+			if r.err != nil && r.hook.Required {
+				coreLogger.Warn("Pre-edit hook %s failed, not running %s: %v", r.hook.Command, name, r.err)
+				return mcp.NewToolResultError(fmt.Sprintf("pre-edit hook failed, %s was not run\n%s", name, formatHookResults("Pre-edit hooks", pre))), nil
+			}
+		}
+
+		result, err := handler(ctx, request)
+		if err != nil || result == nil {
+			return result, err
+		}
+		if len(pre) > 0 {
+			result.Content = append(result.Content, mcp.NewTextContent(formatHookResults("Pre-edit hooks", pre)))
+		}
+		// A tool that failed has not made the edit the hooks check
+		if result.IsError {
+			return result, nil
+		}
+		if post := s.runHooks(ctx, name, hooks.PostEdit); len(post) > 0 {
+			result.Content = append(result.Content, mcp.NewTextContent(formatHookResults("Post-edit hooks", post)))
+		}
+		return result, nil
+	}
+}
+
+// runHooks runs hooks in order in the workspace directory
+func (s *mcpServer) runHooks(ctx context.Context, toolName string, hooks []hookConfig) []hookResult {
+	var results []hookResult
+	for _, hook := range hooks {
+		timeout := defaultHookTimeout
+		if hook.Timeout != "" {
+			// Validated when the configuration is loaded
+			timeout, _ = time.ParseDuration(hook.Timeout)
+		}
+		hookCtx, cancel := context.WithTimeout(ctx, timeout)
+
+		cmd := exec.CommandContext(hookCtx, hook.Command, hook.Args...)
+		cmd.Dir = s.config.workspaceDir
+		cmd.Env = append(os.Environ(), "MCP_TOOL="+toolName, "MCP_WORKSPACE="+s.config.workspaceDir)
+		output, err := cmd.CombinedOutput()
+		if hookCtx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		cancel()
+
+		if err != nil {
+			coreLogger.Warn("Edit hook %s failed: %v", hook.Command, err)
+		}
+		results = append(results, hookResult{hook: hook, output: string(output), err: err})
+	}
+	return results
+}
+
+// formatHookResults describes the outcome and output of each hook
+func formatHookResults(title string, results []hookResult) string {
+	var out strings.Builder
+	out.WriteString(title + ":\n")
+	for _, r := range results {
+		status := "ok"
+		if r.err != nil {
+			status = "failed: " + r.err.Error()
+		}
+		out.WriteString(fmt.Sprintf("$ %s (%s)\n", strings.Join(append([]string{r.hook.Command}, r.hook.Args...), " "), status))
+
+		output := strings.TrimRight(r.output, "\n")
+		if len(output) > maxHookOutput {
+			output = "..." + output[len(output)-maxHookOutput:]
+		}
+		if output != "" {
+			out.WriteString(output + "\n")
+		}
+	}
+	return out.String()
+}
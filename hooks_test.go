@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+// hookTestServer returns a server running hooks in a temporary workspace, and
+// a tool handler counting its calls
+func hookTestServer(t *testing.T, hooks hooksConfig, result *mcp.CallToolResult) (*mcpServer, func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), *int) {
+	s := &mcpServer{config: config{workspaceDir: t.TempDir(), hooks: hooks}}
+	calls := 0
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		calls++
+		return result, nil
+	}
+	return s, handler, &calls
+}
+
+func TestEditHooks(t *testing.T) {
+	echo := func(text string) hookConfig {
+		return hookConfig{Command: "sh", Args: []string{"-c", "echo " + text}}
+	}
+
+	t.Run("runs hooks around the tool", func(t *testing.T) {
+		s, handler, calls := hookTestServer(t, hooksConfig{
+			PreEdit:  []hookConfig{echo("before")},
+			PostEdit: []hookConfig{echo("after")},
+		}, mcp.NewToolResultText("edited"))
+		result, err := s.withEditHooks("edit_file", handler)(context.Background(), mcp.CallToolRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, *calls)
+		assert.Equal(t, "edited\nPre-edit hooks:\n$ sh -c echo before (ok)\nbefore\n\nPost-edit hooks:\n$ sh -c echo after (ok)\nafter\n", toolResultText(result))
+	})
+
+	t.Run("required pre-edit hook fails", func(t *testing.T) {
+		s, handler, calls := hookTestServer(t, hooksConfig{
+			PreEdit: []hookConfig{{Command: "sh", Args: []string{"-c", "echo license header missing; exit 1"}, Required: true}},
+		}, mcp.NewToolResultText("edited"))
+		result, err := s.withEditHooks("edit_file", handler)(context.Background(), mcp.CallToolRequest{})
+		assert.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Equal(t, 0, *calls)
+		assert.Contains(t, toolResultText(result), "pre-edit hook failed, edit_file was not run")
+		assert.Contains(t, toolResultText(result), "license header missing")
+	})
+
+	t.Run("optional pre-edit hook fails", func(t *testing.T) {
+		s, handler, calls := hookTestServer(t, hooksConfig{
+			PreEdit: []hookConfig{{Command: "false"}},
+		}, mcp.NewToolResultText("edited"))
+		result, err := s.withEditHooks("edit_file", handler)(context.Background(), mcp.CallToolRequest{})
+		assert.NoError(t, err)
+		assert.False(t, result.IsError)
+		assert.Equal(t, 1, *calls)
+		assert.Contains(t, toolResultText(result), "$ false (failed: exit status 1)")
+	})
+
+	t.Run("skips post-edit hooks when the tool fails", func(t *testing.T) {
+		s, handler, calls := hookTestServer(t, hooksConfig{
+			PostEdit: []hookConfig{echo("after")},
+		}, mcp.NewToolResultError("failed to edit"))
+		result, err := s.withEditHooks("edit_file", handler)(context.Background(), mcp.CallToolRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, *calls)
+		assert.Equal(t, "failed to edit", toolResultText(result))
+	})
+
+	t.Run("dry runs run no hooks", func(t *testing.T) {
+		s, handler, _ := hookTestServer(t, hooksConfig{
+			PreEdit: []hookConfig{echo("before")},
+		}, mcp.NewToolResultText("preview"))
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"dryRun": true}
+		result, err := s.withEditHooks("edit_file", handler)(context.Background(), request)
+		assert.NoError(t, err)
+		assert.Equal(t, "preview", toolResultText(result))
+	})
+}
+
+func TestRunHooksTimeout(t *testing.T) {
+	s := &mcpServer{config: config{workspaceDir: t.TempDir()}}
+	results := s.runHooks(context.Background(), "edit_file", []hookConfig{
+		{Command: "sleep", Args: []string{"10"}, Timeout: "50ms"},
+	})
+	assert.Len(t, results, 1)
+	assert.EqualError(t, results[0].err, "timed out after 50ms")
+}
+
+func TestRunHooksEnvironment(t *testing.T) {
+	s := &mcpServer{config: config{workspaceDir: t.TempDir()}}
+	results := s.runHooks(context.Background(), "rename_file", []hookConfig{
+		{Command: "sh", Args: []string{"-c", "echo $MCP_TOOL; pwd"}},
+	})
+	assert.NoError(t, results[0].err)
+	assert.Equal(t, "rename_file\n"+s.config.workspaceDir+"\n", results[0].output)
+}
+
+func TestFormatHookResultsTruncatesOutput(t *testing.T) {
+	output := strings.Repeat("a", maxHookOutput) + "the error"
+	text := formatHookResults("Post-edit hooks", []hookResult{
+		{hook: hookConfig{Command: "make", Args: []string{"lint"}}, output: output + "\n"},
+	})
+	assert.Equal(t, "Post-edit hooks:\n$ make lint (ok)\n..."+output[len(output)-maxHookOutput:]+"\n", text)
+	assert.True(t, strings.HasSuffix(text, "the error\n"))
+}
@@ -75,7 +75,7 @@ func TestCodeLens(t *testing.T) {
 		t.Logf("Code lenses: %s", result)
 
 		// Execute the code lens (use index 2 which should be the tidy lens)
-		execResult, err := tools.ExecuteCodeLens(ctx, suite.Client, filePath, 2)
+		execResult, err := tools.ExecuteCodeLens(ctx, suite.Client, filePath, 2, "")
 		if err != nil {
 			t.Fatalf("ExecuteCodeLens failed: %v", err)
 		}
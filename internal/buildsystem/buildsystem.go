@@ -0,0 +1,186 @@
+package buildsystem
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Kind is a build system with a Bazel-style layout: a workspace root marked
+// by a configuration file and generated files kept in output trees
+type Kind string
+
+const (
+	Bazel  Kind = "bazel"
+	Please Kind = "please"
+	Buck   Kind = "buck"
+)
+
+// markers are the files at the root of a workspace that identify its build
+// system
+var markers = []struct {
+	kind  Kind
+	files []string
+}{
+	{Bazel, []string{"MODULE.bazel", "WORKSPACE.bazel", "WORKSPACE"}},
+	{Please, []string{".plzconfig"}},
+	{Buck, []string{".buckconfig"}},
+}
+
+// goPackagesDrivers are the usual locations of the wrapper scripts that let
+// gopls load packages through the build system instead of go list
+var goPackagesDrivers = map[Kind][]string{
+	Bazel: {"tools/gopackagesdriver.sh", "tools/gopackagesdriver", "scripts/gopackagesdriver.sh"},
+}
+
+// templateExtensions are appended to the path of a generated file to find
+// the template it was expanded from
+var templateExtensions = []string{".tmpl", ".tpl", ".template", ".in"}
+
+// generatedSuffixes map the suffixes of files generated by code generators to
+// the suffix of their source
+var generatedSuffixes = []struct {
+	generated, source string
+}{
+	{".pb.go", ".proto"},
+	{"_grpc.pb.go", ".proto"},
+	{".pb.cc", ".proto"},
+	{".pb.h", ".proto"},
+	{"_pb2.py", ".proto"},
+	{"_pb2_grpc.py", ".proto"},
+	{"_pb.js", ".proto"},
+	{"_pb.d.ts", ".proto"},
+}
+
+// Layout describes the build system of a workspace
+type Layout struct {
+	Kind Kind
+	Root string
+	// OutputDirs are the names of the output trees at the root, such as
+	// bazel-bin. They are symlinks for Bazel.
+	OutputDirs []string
+	// GoPackagesDriver is the path of a gopackagesdriver wrapper for gopls,
+	// if the workspace has one
+	GoPackagesDriver string
+
+	// Paths of the output trees, both as found in the workspace and with
+	// symlinks resolved, since language servers report either
+	outputRoots []string
+}
+
+// Detect returns the layout of the build system used by a workspace, or nil
+// if it does not use one of the supported build systems
+func Detect(root string) *Layout {
+	for _, marker := range markers {
+		for _, file := range marker.files {
+			if _, err := os.Stat(filepath.Join(root, file)); err == nil {
+				return newLayout(marker.kind, root)
+			}
+		}
+	}
+	return nil
+}
+
+func newLayout(kind Kind, root string) *Layout {
+	layout := &Layout{Kind: kind, Root: root}
+
+	switch kind {
+	case Bazel:
+		// bazel-bin, bazel-out, bazel-testlogs and bazel-<workspace name>
+		layout.OutputDirs = []string{"bazel-bin", "bazel-out", "bazel-testlogs", "bazel-" + filepath.Base(root)}
+		if entries, err := os.ReadDir(root); err == nil {
+			for _, entry := range entries {
+				if strings.HasPrefix(entry.Name(), "bazel-") && !slices.Contains(layout.OutputDirs, entry.Name()) {
+					layout.OutputDirs = append(layout.OutputDirs, entry.Name())
+				}
+			}
+		}
+	case Please:
+		layout.OutputDirs = []string{"plz-out"}
+	case Buck:
+		layout.OutputDirs = []string{"buck-out"}
+	}
+
+	for _, dir := range layout.OutputDirs {
+		path := filepath.Join(root, dir)
+		layout.outputRoots = append(layout.outputRoots, path)
+		if resolved, err := filepath.EvalSymlinks(path); err == nil && resolved != path {
+			layout.outputRoots = append(layout.outputRoots, resolved)
+		}
+	}
+
+	for _, driver := range goPackagesDrivers[kind] {
+		path := filepath.Join(root, driver)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			layout.GoPackagesDriver = path
+			break
+		}
+	}
+
+	return layout
+}
+
+// IsGenerated reports whether a path is in one of the output trees
+func (l *Layout) IsGenerated(path string) bool {
+	_, ok := l.outputPath(path)
+	return ok
+}
+
+// outputPath returns the elements of a path below the output tree that
+// contains it
+func (l *Layout) outputPath(path string) ([]string, bool) {
+	path = filepath.Clean(path)
+	for _, root := range l.outputRoots {
+		if rel, err := filepath.Rel(root, path); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			return strings.Split(rel, string(filepath.Separator)), true
+		}
+	}
+	// Paths in Bazel's output base outside of the workspace's symlinks
+	if l.Kind == Bazel {
+		if _, rel, ok := strings.Cut(filepath.ToSlash(path), "/bazel-out/"); ok {
+			return strings.Split(rel, "/"), true
+		}
+	}
+	return nil, false
+}
+
+// SourceFor maps a generated file back to the source it was generated from:
+// a checked in file at the same package path, a template for it, or the
+// definition a code generator compiled, such as a .proto file. Output trees
+// prefix package paths with configuration directories such as
+// k8-fastbuild/bin, so leading elements are dropped until a source is found.
+func (l *Layout) SourceFor(path string) (string, bool) {
+	parts, ok := l.outputPath(path)
+	if !ok {
+		return "", false
+	}
+
+	for i := 0; i < len(parts); i++ {
+		candidate := filepath.Join(append([]string{l.Root}, parts[i:]...)...)
+		if l.IsGenerated(candidate) {
+			continue
+		}
+		for _, source := range sourceCandidates(candidate) {
+			if info, err := os.Stat(source); err == nil && !info.IsDir() {
+				return source, true
+			}
+		}
+	}
+	return "", false
+}
+
+// sourceCandidates lists the possible sources of a generated file, given the
+// path it would have in the workspace
+func sourceCandidates(path string) []string {
+	candidates := []string{path}
+	for _, ext := range templateExtensions {
+		candidates = append(candidates, path+ext)
+	}
+	for _, suffix := range generatedSuffixes {
+		if base, ok := strings.CutSuffix(path, suffix.generated); ok {
+			candidates = append(candidates, base+suffix.source)
+		}
+	}
+	return candidates
+}
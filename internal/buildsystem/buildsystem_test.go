@@ -0,0 +1,66 @@
+package buildsystem
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, path string) {
+	t.Helper()
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.NoError(t, os.WriteFile(path, []byte("x"), 0644))
+}
+
+func TestDetect(t *testing.T) {
+	root := t.TempDir()
+	assert.Nil(t, Detect(root))
+
+	writeFile(t, filepath.Join(root, ".plzconfig"))
+	layout := Detect(root)
+	assert.Equal(t, Please, layout.Kind)
+	assert.Equal(t, []string{"plz-out"}, layout.OutputDirs)
+
+	root = t.TempDir()
+	writeFile(t, filepath.Join(root, "MODULE.bazel"))
+	writeFile(t, filepath.Join(root, "tools", "gopackagesdriver.sh"))
+	layout = Detect(root)
+	assert.Equal(t, Bazel, layout.Kind)
+	assert.Contains(t, layout.OutputDirs, "bazel-bin")
+	assert.Contains(t, layout.OutputDirs, "bazel-"+filepath.Base(root))
+	assert.Equal(t, filepath.Join(root, "tools", "gopackagesdriver.sh"), layout.GoPackagesDriver)
+}
+
+func TestSourceFor(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "WORKSPACE"))
+	writeFile(t, filepath.Join(root, "pkg", "api", "service.proto"))
+	writeFile(t, filepath.Join(root, "pkg", "version", "version.go.tmpl"))
+	writeFile(t, filepath.Join(root, "pkg", "copied", "data.json"))
+
+	// bazel-bin is a symlink into the output base
+	outputBase := t.TempDir()
+	bin := filepath.Join(outputBase, "execroot", "_main", "bazel-out", "k8-fastbuild", "bin")
+	assert.NoError(t, os.MkdirAll(bin, 0755))
+	assert.NoError(t, os.Symlink(bin, filepath.Join(root, "bazel-bin")))
+	layout := Detect(root)
+
+	source, ok := layout.SourceFor(filepath.Join(root, "bazel-bin", "pkg", "api", "service_go_proto_", "example.com", "pkg", "api", "service.pb.go"))
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join(root, "pkg", "api", "service.proto"), source)
+
+	source, ok = layout.SourceFor(filepath.Join(bin, "pkg", "version", "version.go"))
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join(root, "pkg", "version", "version.go.tmpl"), source)
+
+	source, ok = layout.SourceFor(filepath.Join(outputBase, "execroot", "_main", "bazel-out", "k8-opt", "bin", "pkg", "copied", "data.json"))
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join(root, "pkg", "copied", "data.json"), source)
+
+	_, ok = layout.SourceFor(filepath.Join(root, "pkg", "api", "service.proto"))
+	assert.False(t, ok)
+	assert.False(t, layout.IsGenerated(filepath.Join(root, "pkg", "api", "service.proto")))
+	assert.True(t, layout.IsGenerated(filepath.Join(root, "bazel-bin", "pkg", "x.go")))
+}
@@ -0,0 +1,164 @@
+// Package embedded finds code in one language embedded in the source of
+// another, such as SQL queries in Go or Python string literals, so that it can
+// be analyzed by a language server for the embedded language as a virtual
+// document.
+package embedded
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// Region is embedded code found in a host file. Text is the code exactly as
+// written in the host file, so that positions map between the two by an
+// offset.
+type Region struct {
+	// Language is the language ID of the embedded code, e.g. "sql"
+	Language string
+	// Start is the 0-indexed position in the host file of the first
+	// character of Text, in UTF-16 code units as in LSP positions
+	Start protocol.Position
+	Text  string
+}
+
+// End returns the position in the host file just after the region
+func (r Region) End() protocol.Position {
+	lines := strings.Split(r.Text, "\n")
+	last := lines[len(lines)-1]
+	return r.ToHost(protocol.Position{
+		Line:      uint32(len(lines) - 1),
+		Character: utf16Len(last),
+	})
+}
+
+// Contains reports whether a position in the host file is inside the region
+func (r Region) Contains(pos protocol.Position) bool {
+	return !before(pos, r.Start) && !before(r.End(), pos)
+}
+
+// ToHost maps a position in the embedded code to the host file
+func (r Region) ToHost(pos protocol.Position) protocol.Position {
+	if pos.Line == 0 {
+		pos.Character += r.Start.Character
+	}
+	pos.Line += r.Start.Line
+	return pos
+}
+
+// ToEmbedded maps a position in the host file to the embedded code. ok is
+// false if the position is outside the region.
+func (r Region) ToEmbedded(pos protocol.Position) (protocol.Position, bool) {
+	if !r.Contains(pos) {
+		return protocol.Position{}, false
+	}
+	if pos.Line == r.Start.Line {
+		pos.Character -= r.Start.Character
+	}
+	pos.Line -= r.Start.Line
+	return pos, true
+}
+
+// URI returns the URI of the virtual document holding the region's code. It
+// identifies the region by its host file and start, and ends in an extension
+// for the embedded language so that servers recognize it.
+func (r Region) URI(hostPath string) protocol.DocumentUri {
+	return protocol.DocumentUri(fmt.Sprintf("embedded:%s.L%dC%d%s", hostPath, r.Start.Line+1, r.Start.Character+1, extension(r.Language)))
+}
+
+// languageExtensions are the file extensions of embedded languages whose
+// extension differs from their language ID
+var languageExtensions = map[string]string{
+	"javascript": ".js",
+	"typescript": ".ts",
+	"python":     ".py",
+}
+
+func extension(language string) string {
+	if ext, ok := languageExtensions[language]; ok {
+		return ext
+	}
+	return "." + language
+}
+
+// Detector finds embedded code in a host file
+type Detector interface {
+	Detect(path, content string) []Region
+}
+
+// DetectorFunc adapts a function to the Detector interface
+type DetectorFunc func(path, content string) []Region
+
+// Detect calls f
+func (f DetectorFunc) Detect(path, content string) []Region {
+	return f(path, content)
+}
+
+// detectors are the registered detectors, in order of precedence
+var detectors []Detector
+
+// Register adds a detector for embedded code, e.g. for an annotation used by
+// a framework or another embedded language. Detectors registered earlier take
+// precedence where regions overlap. Register is not safe to call
+// concurrently with Detect and is meant to be called from init functions.
+func Register(d Detector) {
+	detectors = append(detectors, d)
+}
+
+// Detect finds the embedded code in a host file with every registered
+// detector, ordered by position. Where regions found by different detectors
+// overlap, the one found by the earlier detector is kept.
+func Detect(path, content string) []Region {
+	var regions []Region
+	for _, d := range detectors {
+		for _, region := range d.Detect(path, content) {
+			if !overlapsAny(region, regions) {
+				regions = append(regions, region)
+			}
+		}
+	}
+	sort.Slice(regions, func(i, j int) bool {
+		return before(regions[i].Start, regions[j].Start)
+	})
+	return regions
+}
+
+func overlapsAny(region Region, regions []Region) bool {
+	for _, other := range regions {
+		if before(region.Start, other.End()) && before(other.Start, region.End()) {
+			return true
+		}
+	}
+	return false
+}
+
+// before reports whether a is before b
+func before(a, b protocol.Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+}
+
+// positionAt converts a byte offset in content to an LSP position
+func positionAt(content string, offset int) protocol.Position {
+	prefix := content[:offset]
+	line := strings.Count(prefix, "\n")
+	lineStart := strings.LastIndex(prefix, "\n") + 1
+	return protocol.Position{
+		Line:      uint32(line),
+		Character: utf16Len(prefix[lineStart:]),
+	}
+}
+
+// utf16Len returns the length of s in UTF-16 code units
+func utf16Len(s string) uint32 {
+	n := uint32(0)
+	for _, r := range s {
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,196 @@
+package embedded
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+func init() {
+	Register(DetectorFunc(detectSQL))
+}
+
+var (
+	// sqlStatement matches string literals that start like a SQL statement.
+	// Keywords must be upper case, as prose such as "select a value from the
+	// list" would otherwise match; lower case queries can be annotated.
+	sqlStatement = regexp.MustCompile(`(?s)^\s*(SELECT\s.+\sFROM\s|INSERT\s+INTO\s|UPDATE\s+\S+\s+SET\s|DELETE\s+FROM\s|WITH\s+(RECURSIVE\s+)?\w+\s+AS\s*\(|CREATE\s+(TABLE|INDEX|UNIQUE\s+INDEX|VIEW)\s|ALTER\s+TABLE\s|DROP\s+(TABLE|INDEX|VIEW)\s)`)
+	// sqlAnnotation matches comments that mark the next string literal as
+	// SQL: the language=sql injection comment understood by JetBrains IDEs,
+	// or a comment that is just "sql"
+	sqlAnnotation = regexp.MustCompile(`(?i)language\s*=\s*sql\b|^\W*sql\W*$`)
+)
+
+// syntax describes the string literals and comments of a host language
+type syntax struct {
+	// quotes are the string and character literal delimiters, longest first
+	quotes []string
+	// raw are the delimiters of literals without escape sequences
+	raw map[string]bool
+	// multiline are the delimiters of literals that may span lines
+	multiline    map[string]bool
+	lineComments []string
+	blockComment bool
+}
+
+var (
+	cLike = syntax{
+		quotes:       []string{`"`, `'`},
+		lineComments: []string{"//"},
+		blockComment: true,
+	}
+	javaLike = syntax{
+		quotes:       []string{`"""`, `"`, `'`},
+		multiline:    map[string]bool{`"""`: true},
+		lineComments: []string{"//"},
+		blockComment: true,
+	}
+	jsLike = syntax{
+		quotes:       []string{`"`, `'`, "`"},
+		multiline:    map[string]bool{"`": true},
+		lineComments: []string{"//"},
+		blockComment: true,
+	}
+	python = syntax{
+		quotes:       []string{`"""`, `'''`, `"`, `'`},
+		multiline:    map[string]bool{`"""`: true, `'''`: true},
+		lineComments: []string{"#"},
+	}
+)
+
+// hostSyntaxes maps the extensions of host files to their syntax
+var hostSyntaxes = map[string]syntax{
+	".go": {
+		quotes:       []string{`"`, `'`, "`"},
+		raw:          map[string]bool{"`": true},
+		multiline:    map[string]bool{"`": true},
+		lineComments: []string{"//"},
+		blockComment: true,
+	},
+	".py":    python,
+	".java":  javaLike,
+	".kt":    javaLike,
+	".scala": javaLike,
+	".js":    jsLike,
+	".jsx":   jsLike,
+	".ts":    jsLike,
+	".tsx":   jsLike,
+	".cs":    cLike,
+	".c":     cLike,
+	".cpp":   cLike,
+	".rb": {
+		quotes:       []string{`"`, `'`},
+		multiline:    map[string]bool{`"`: true, `'`: true},
+		lineComments: []string{"#"},
+	},
+	".php": {
+		quotes:       []string{`"`, `'`},
+		multiline:    map[string]bool{`"`: true, `'`: true},
+		lineComments: []string{"//", "#"},
+		blockComment: true,
+	},
+}
+
+// detectSQL finds SQL in the string literals of a host file: literals that
+// start like a SQL statement, and literals annotated as SQL by a comment on
+// the same line or the line before
+func detectSQL(path, content string) []Region {
+	syn, ok := hostSyntaxes[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return nil
+	}
+
+	var regions []Region
+	annotatedLine := -2
+	line := 0
+	for i := 0; i < len(content); {
+		switch {
+		case content[i] == '\n':
+			line++
+			i++
+
+		case syn.blockComment && strings.HasPrefix(content[i:], "/*"):
+			end := strings.Index(content[i+2:], "*/")
+			if end < 0 {
+				return regions
+			}
+			comment := content[i+2 : i+2+end]
+			if sqlAnnotation.MatchString(comment) {
+				annotatedLine = line + strings.Count(comment, "\n")
+			}
+			line += strings.Count(comment, "\n")
+			i += end + 4
+
+		case hasAnyPrefix(content[i:], syn.lineComments):
+			end := strings.IndexByte(content[i:], '\n')
+			if end < 0 {
+				end = len(content) - i
+			}
+			if sqlAnnotation.MatchString(content[i : i+end]) {
+				annotatedLine = line
+			}
+			i += end
+
+		default:
+			quote := literalQuote(content[i:], syn.quotes)
+			if quote == "" {
+				i++
+				continue
+			}
+			start := i + len(quote)
+			end, ok := literalEnd(content, start, quote, syn.raw[quote], syn.multiline[quote])
+			if !ok {
+				i = start
+				continue
+			}
+			text := content[start:end]
+			if annotatedLine >= line-1 || sqlStatement.MatchString(text) {
+				regions = append(regions, Region{
+					Language: "sql",
+					Start:    positionAt(content, start),
+					Text:     text,
+				})
+			}
+			line += strings.Count(text, "\n")
+			i = end + len(quote)
+		}
+	}
+	return regions
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// literalQuote returns the delimiter of the literal starting s, or an empty
+// string if s does not start with one
+func literalQuote(s string, quotes []string) string {
+	for _, quote := range quotes {
+		if strings.HasPrefix(s, quote) {
+			return quote
+		}
+	}
+	return ""
+}
+
+// literalEnd returns the offset of the closing delimiter of a literal whose
+// text starts at start. ok is false if the literal is not closed, or not
+// closed on its line when it cannot span lines.
+func literalEnd(content string, start int, quote string, raw, multiline bool) (int, bool) {
+	for i := start; i < len(content); i++ {
+		switch {
+		case content[i] == '\\' && !raw:
+			i++
+		case content[i] == '\n' && !multiline:
+			return 0, false
+		case strings.HasPrefix(content[i:], quote):
+			return i, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,112 @@
+package embedded
+
+import (
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectSQL(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		want    []Region
+	}{
+		{
+			name:    "Go string",
+			path:    "main.go",
+			content: "package main\n\nvar q = \"SELECT id FROM users WHERE name = ?\"\n",
+			want: []Region{{
+				Language: "sql",
+				Start:    protocol.Position{Line: 2, Character: 9},
+				Text:     "SELECT id FROM users WHERE name = ?",
+			}},
+		},
+		{
+			name:    "Go raw string spanning lines",
+			path:    "main.go",
+			content: "db.Exec(`\n\tUPDATE users\n\tSET name = $1`, name)\n",
+			want: []Region{{
+				Language: "sql",
+				Start:    protocol.Position{Line: 0, Character: 9},
+				Text:     "\n\tUPDATE users\n\tSET name = $1",
+			}},
+		},
+		{
+			name:    "annotated on the previous line",
+			path:    "query.py",
+			content: "# language=sql\nq = 'count(*)'\nr = 'count(*)'\n",
+			want: []Region{{
+				Language: "sql",
+				Start:    protocol.Position{Line: 1, Character: 5},
+				Text:     "count(*)",
+			}},
+		},
+		{
+			name:    "annotated inline",
+			path:    "Repo.java",
+			content: "String q = /* sql */ \"id, name\";\n",
+			want: []Region{{
+				Language: "sql",
+				Start:    protocol.Position{Line: 0, Character: 22},
+				Text:     "id, name",
+			}},
+		},
+		{
+			name:    "Python triple quotes",
+			path:    "query.py",
+			content: "q = \"\"\"\nINSERT INTO users VALUES (1)\n\"\"\"\n",
+			want: []Region{{
+				Language: "sql",
+				Start:    protocol.Position{Line: 0, Character: 7},
+				Text:     "\nINSERT INTO users VALUES (1)\n",
+			}},
+		},
+		{
+			name:    "not SQL",
+			path:    "main.go",
+			content: "var s = \"select a value from the list\"\nvar r = '\"'\n",
+		},
+		{
+			name:    "SQL in a comment",
+			path:    "main.go",
+			content: "// \"SELECT id FROM users\"\n",
+		},
+		{
+			name:    "unsupported host",
+			path:    "notes.txt",
+			content: "\"SELECT id FROM users\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, detectSQL(tt.path, tt.content))
+		})
+	}
+}
+
+func TestRegionPositions(t *testing.T) {
+	region := Region{
+		Language: "sql",
+		Start:    protocol.Position{Line: 4, Character: 10},
+		Text:     "SELECT *\n  FROM users",
+	}
+
+	assert.Equal(t, protocol.Position{Line: 5, Character: 12}, region.End())
+	assert.Equal(t, protocol.Position{Line: 4, Character: 17}, region.ToHost(protocol.Position{Line: 0, Character: 7}))
+	assert.Equal(t, protocol.Position{Line: 5, Character: 2}, region.ToHost(protocol.Position{Line: 1, Character: 2}))
+
+	pos, ok := region.ToEmbedded(protocol.Position{Line: 5, Character: 4})
+	assert.True(t, ok)
+	assert.Equal(t, protocol.Position{Line: 1, Character: 4}, pos)
+
+	_, ok = region.ToEmbedded(protocol.Position{Line: 4, Character: 9})
+	assert.False(t, ok)
+	_, ok = region.ToEmbedded(protocol.Position{Line: 6, Character: 0})
+	assert.False(t, ok)
+
+	assert.Equal(t, protocol.DocumentUri("embedded:/src/repo.go.L5C11.sql"), region.URI("/src/repo.go"))
+}
@@ -0,0 +1,193 @@
+package fingerprint
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Fingerprint identifies a workspace checkout for keying persistent caches.
+// Every component is hashed, so the fingerprint does not reveal the location,
+// history or file names of the workspace.
+type Fingerprint struct {
+	// RootHash is a hash of the absolute workspace path
+	RootHash string `json:"rootHash"`
+	// VCSHead is a hash of the checked out commit, or empty outside of git
+	VCSHead string `json:"vcsHead,omitempty"`
+	// FileCount is the number of files in the workspace
+	FileCount int `json:"fileCount"`
+	// FileSignature is a hash of the relative paths of the files
+	FileSignature string `json:"fileSignature"`
+}
+
+// Key identifies the checkout: the same workspace at the same commit. Files
+// may have changed since; use Stale to find out.
+func (f Fingerprint) Key() string {
+	return hash(f.RootHash + "\x00" + f.VCSHead)
+}
+
+// Stale reports whether a cache keyed by an earlier fingerprint no longer
+// matches the workspace, because it belongs to a different checkout or files
+// have been added, removed or renamed since
+func (f Fingerprint) Stale(cached Fingerprint) bool {
+	return f != cached
+}
+
+// Compute fingerprints the workspace at root. Directories whose names are in
+// excludedDirs are skipped, as are files that cannot be read.
+func Compute(root string, excludedDirs map[string]bool) (Fingerprint, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return Fingerprint{}, err
+	}
+
+	f := Fingerprint{RootHash: hash(root)}
+	if head := gitHead(root); head != "" {
+		f.VCSHead = hash(head)
+	}
+
+	var paths []string
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() && path != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && excludedDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return Fingerprint{}, err
+	}
+
+	sort.Strings(paths)
+	f.FileCount = len(paths)
+	f.FileSignature = hash(strings.Join(paths, "\n"))
+
+	return f, nil
+}
+
+// GitCommit returns the commit checked out in the git repository containing
+// dir, unhashed, or an empty string if there is none. Unlike a Fingerprint it
+// reveals the checkout, so it is only meant for records kept locally.
+func GitCommit(dir string) string {
+	return gitHead(dir)
+}
+
+// gitHead returns the commit checked out in the git repository containing
+// dir, or an empty string if there is none. It reads the repository files
+// directly rather than depending on a git executable.
+func gitHead(dir string) string {
+	for {
+		gitDir := filepath.Join(dir, ".git")
+		if info, err := os.Stat(gitDir); err == nil {
+			if !info.IsDir() {
+				// Worktrees and submodules point to the real git directory
+				gitDir = gitDirFromFile(gitDir, dir)
+			}
+			if gitDir != "" {
+				return resolveGitHead(gitDir)
+			}
+			return ""
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// gitDirFromFile reads a .git file of the form "gitdir: <path>"
+func gitDirFromFile(path, dir string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return ""
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(dir, gitDir)
+	}
+	return gitDir
+}
+
+// resolveGitHead resolves HEAD in a git directory to a commit hash
+func resolveGitHead(gitDir string) string {
+	data, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return ""
+	}
+	head := strings.TrimSpace(string(data))
+	ref, ok := strings.CutPrefix(head, "ref:")
+	if !ok {
+		// Detached HEAD
+		return head
+	}
+	ref = strings.TrimSpace(ref)
+
+	// Worktrees keep branch refs in the main repository
+	refDirs := []string{gitDir}
+	if common, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir := strings.TrimSpace(string(common))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+		refDirs = append(refDirs, commonDir)
+	}
+
+	for _, refDir := range refDirs {
+		if data, err := os.ReadFile(filepath.Join(refDir, filepath.FromSlash(ref))); err == nil {
+			return strings.TrimSpace(string(data))
+		}
+		if commit := packedRef(filepath.Join(refDir, "packed-refs"), ref); commit != "" {
+			return commit
+		}
+	}
+
+	// A branch without commits
+	return ref
+}
+
+// packedRef looks up a ref in a packed-refs file
+func packedRef(path, ref string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		commit, name, ok := strings.Cut(scanner.Text(), " ")
+		if ok && name == ref {
+			return commit
+		}
+	}
+	return ""
+}
+
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,79 @@
+package fingerprint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestCompute(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "main.go"), "package main\n")
+	writeFile(t, filepath.Join(root, "pkg", "lib.go"), "package pkg\n")
+	writeFile(t, filepath.Join(root, "node_modules", "dep", "index.js"), "")
+	excluded := map[string]bool{"node_modules": true}
+
+	f, err := Compute(root, excluded)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, f.FileCount)
+	assert.Empty(t, f.VCSHead)
+	assert.NotContains(t, f.RootHash, root)
+
+	// Fingerprints are stable
+	again, err := Compute(root, excluded)
+	assert.NoError(t, err)
+	assert.Equal(t, f, again)
+	assert.False(t, again.Stale(f))
+
+	// Editing a file does not change the fingerprint, adding one does
+	writeFile(t, filepath.Join(root, "main.go"), "package main\n\nfunc main() {}\n")
+	again, _ = Compute(root, excluded)
+	assert.False(t, again.Stale(f))
+
+	writeFile(t, filepath.Join(root, "pkg", "more.go"), "package pkg\n")
+	again, _ = Compute(root, excluded)
+	assert.True(t, again.Stale(f))
+	assert.Equal(t, f.Key(), again.Key())
+
+	// Another workspace has another key
+	other := t.TempDir()
+	writeFile(t, filepath.Join(other, "main.go"), "package main\n")
+	otherFingerprint, _ := Compute(other, excluded)
+	assert.NotEqual(t, f.Key(), otherFingerprint.Key())
+}
+
+func TestGitHead(t *testing.T) {
+	const commit = "0123456789abcdef0123456789abcdef01234567"
+
+	// Branch ref
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".git", "HEAD"), "ref: refs/heads/main\n")
+	writeFile(t, filepath.Join(root, ".git", "refs", "heads", "main"), commit+"\n")
+	assert.Equal(t, commit, gitHead(filepath.Join(root, "sub", "dir")))
+
+	// Packed ref
+	root = t.TempDir()
+	writeFile(t, filepath.Join(root, ".git", "HEAD"), "ref: refs/heads/main\n")
+	writeFile(t, filepath.Join(root, ".git", "packed-refs"), "# pack-refs with: peeled\n"+commit+" refs/heads/main\n")
+	assert.Equal(t, commit, gitHead(root))
+
+	// Detached HEAD in a worktree
+	root = t.TempDir()
+	writeFile(t, filepath.Join(root, "repo", ".git", "worktrees", "wt", "HEAD"), commit+"\n")
+	writeFile(t, filepath.Join(root, "wt", ".git"), "gitdir: ../repo/.git/worktrees/wt\n")
+	assert.Equal(t, commit, gitHead(filepath.Join(root, "wt")))
+
+	// Branch in a worktree
+	writeFile(t, filepath.Join(root, "repo", ".git", "worktrees", "wt", "HEAD"), "ref: refs/heads/feature\n")
+	writeFile(t, filepath.Join(root, "repo", ".git", "worktrees", "wt", "commondir"), "../..\n")
+	writeFile(t, filepath.Join(root, "repo", ".git", "refs", "heads", "feature"), commit+"\n")
+	assert.Equal(t, commit, gitHead(filepath.Join(root, "wt")))
+}
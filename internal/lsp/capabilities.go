@@ -0,0 +1,193 @@
+package lsp
+
+import (
+	"encoding/json"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// InitializeResult returns the result of the initialize request, or nil if the
+// client has not been initialized yet
+func (c *Client) InitializeResult() *protocol.InitializeResult {
+	c.initializeResultMu.RLock()
+	defer c.initializeResultMu.RUnlock()
+	return c.initializeResult
+}
+
+// SupportsMethod reports whether the server advertised support for an LSP
+// method in the capabilities it returned from initialize. Methods without an
+// associated server capability are always reported as supported. Capabilities
+// registered dynamically after initialization are not taken into account.
+func (c *Client) SupportsMethod(method string) bool {
+	result := c.InitializeResult()
+	if result == nil {
+		return false
+	}
+	return MethodSupported(result.Capabilities, method)
+}
+
+// CapabilityMethods are the LSP methods whose support MethodSupported reads
+// from the server capabilities
+var CapabilityMethods = []string{
+	"textDocument/hover",
+	"textDocument/completion",
+	"completionItem/resolve",
+	"textDocument/signatureHelp",
+	"textDocument/declaration",
+	"textDocument/definition",
+	"textDocument/typeDefinition",
+	"textDocument/implementation",
+	"textDocument/references",
+	"textDocument/documentHighlight",
+	"textDocument/documentSymbol",
+	"textDocument/codeAction",
+	"codeAction/resolve",
+	"textDocument/codeLens",
+	"codeLens/resolve",
+	"textDocument/documentLink",
+	"documentLink/resolve",
+	"workspace/symbol",
+	"workspaceSymbol/resolve",
+	"textDocument/formatting",
+	"textDocument/rangeFormatting",
+	"textDocument/onTypeFormatting",
+	"textDocument/rename",
+	"textDocument/prepareRename",
+	"textDocument/foldingRange",
+	"textDocument/selectionRange",
+	"workspace/executeCommand",
+	"textDocument/prepareCallHierarchy",
+	"callHierarchy/incomingCalls",
+	"callHierarchy/outgoingCalls",
+	"textDocument/semanticTokens/full",
+	"textDocument/semanticTokens/full/delta",
+	"textDocument/semanticTokens/range",
+	"textDocument/moniker",
+	"textDocument/prepareTypeHierarchy",
+	"typeHierarchy/supertypes",
+	"typeHierarchy/subtypes",
+	"textDocument/inlineValue",
+	"textDocument/inlayHint",
+	"inlayHint/resolve",
+	"textDocument/diagnostic",
+	"workspace/diagnostic",
+	"textDocument/linkedEditingRange",
+	"workspace/willRenameFiles",
+	"workspace/didRenameFiles",
+	"workspace/didChangeWorkspaceFolders",
+}
+
+// MethodSupported reports whether the given server capabilities cover an LSP method
+func MethodSupported(caps protocol.ServerCapabilities, method string) bool {
+	switch method {
+	case "textDocument/hover":
+		return caps.HoverProvider != nil && providerEnabled(caps.HoverProvider.Value)
+	case "textDocument/completion":
+		return caps.CompletionProvider != nil
+	case "completionItem/resolve":
+		return caps.CompletionProvider != nil && caps.CompletionProvider.ResolveProvider
+	case "textDocument/signatureHelp":
+		return caps.SignatureHelpProvider != nil
+	case "textDocument/declaration":
+		return caps.DeclarationProvider != nil && providerEnabled(caps.DeclarationProvider.Value)
+	case "textDocument/definition":
+		return caps.DefinitionProvider != nil && providerEnabled(caps.DefinitionProvider.Value)
+	case "textDocument/typeDefinition":
+		return caps.TypeDefinitionProvider != nil && providerEnabled(caps.TypeDefinitionProvider.Value)
+	case "textDocument/implementation":
+		return caps.ImplementationProvider != nil && providerEnabled(caps.ImplementationProvider.Value)
+	case "textDocument/references":
+		return caps.ReferencesProvider != nil && providerEnabled(caps.ReferencesProvider.Value)
+	case "textDocument/documentHighlight":
+		return caps.DocumentHighlightProvider != nil && providerEnabled(caps.DocumentHighlightProvider.Value)
+	case "textDocument/documentSymbol":
+		return caps.DocumentSymbolProvider != nil && providerEnabled(caps.DocumentSymbolProvider.Value)
+	case "textDocument/codeAction":
+		return providerEnabled(caps.CodeActionProvider)
+	case "codeAction/resolve":
+		options, ok := caps.CodeActionProvider.(map[string]any)
+		return ok && options["resolveProvider"] == true
+	case "textDocument/codeLens", "codeLens/resolve":
+		return caps.CodeLensProvider != nil
+	case "textDocument/documentLink", "documentLink/resolve":
+		return caps.DocumentLinkProvider != nil
+	case "workspace/symbol", "workspaceSymbol/resolve":
+		return caps.WorkspaceSymbolProvider != nil && providerEnabled(caps.WorkspaceSymbolProvider.Value)
+	case "textDocument/formatting":
+		return caps.DocumentFormattingProvider != nil && providerEnabled(caps.DocumentFormattingProvider.Value)
+	case "textDocument/rangeFormatting":
+		return caps.DocumentRangeFormattingProvider != nil && providerEnabled(caps.DocumentRangeFormattingProvider.Value)
+	case "textDocument/onTypeFormatting":
+		return caps.DocumentOnTypeFormattingProvider != nil
+	case "textDocument/rename":
+		return providerEnabled(caps.RenameProvider)
+	case "textDocument/prepareRename":
+		options, ok := caps.RenameProvider.(map[string]any)
+		return ok && options["prepareProvider"] == true
+	case "textDocument/foldingRange":
+		return caps.FoldingRangeProvider != nil && providerEnabled(caps.FoldingRangeProvider.Value)
+	case "textDocument/selectionRange":
+		return caps.SelectionRangeProvider != nil && providerEnabled(caps.SelectionRangeProvider.Value)
+	case "workspace/executeCommand":
+		return caps.ExecuteCommandProvider != nil
+	case "textDocument/prepareCallHierarchy", "callHierarchy/incomingCalls", "callHierarchy/outgoingCalls":
+		return caps.CallHierarchyProvider != nil && providerEnabled(caps.CallHierarchyProvider.Value)
+	case "textDocument/semanticTokens/full", "textDocument/semanticTokens/full/delta":
+		options, ok := SemanticTokensOptions(caps)
+		return ok && (options.Full == nil || providerEnabled(options.Full.Value))
+	case "textDocument/semanticTokens/range":
+		options, ok := SemanticTokensOptions(caps)
+		return ok && options.Range != nil && providerEnabled(options.Range.Value)
+	case "textDocument/moniker":
+		return caps.MonikerProvider != nil && providerEnabled(caps.MonikerProvider.Value)
+	case "textDocument/prepareTypeHierarchy", "typeHierarchy/supertypes", "typeHierarchy/subtypes":
+		return caps.TypeHierarchyProvider != nil && providerEnabled(caps.TypeHierarchyProvider.Value)
+	case "textDocument/inlineValue":
+		return caps.InlineValueProvider != nil && providerEnabled(caps.InlineValueProvider.Value)
+	case "textDocument/inlayHint", "inlayHint/resolve":
+		return providerEnabled(caps.InlayHintProvider)
+	case "textDocument/diagnostic", "workspace/diagnostic":
+		return caps.DiagnosticProvider != nil && caps.DiagnosticProvider.Value != nil
+	case "textDocument/linkedEditingRange":
+		return caps.LinkedEditingRangeProvider != nil && providerEnabled(caps.LinkedEditingRangeProvider.Value)
+	case "workspace/willRenameFiles":
+		return caps.Workspace != nil && caps.Workspace.FileOperations != nil && caps.Workspace.FileOperations.WillRename != nil
+	case "workspace/didRenameFiles":
+		return caps.Workspace != nil && caps.Workspace.FileOperations != nil && caps.Workspace.FileOperations.DidRename != nil
+	case "workspace/didChangeWorkspaceFolders":
+		return caps.Workspace != nil && caps.Workspace.WorkspaceFolders != nil &&
+			caps.Workspace.WorkspaceFolders.ChangeNotifications != nil &&
+			providerEnabled(caps.Workspace.WorkspaceFolders.ChangeNotifications.Value)
+	default:
+		return true
+	}
+}
+
+// providerEnabled interprets a capability that may be a boolean or an options object
+func providerEnabled(value any) bool {
+	if value == nil {
+		return false
+	}
+	if enabled, ok := value.(bool); ok {
+		return enabled
+	}
+	return true
+}
+
+// SemanticTokensOptions returns the semantic tokens options of a server,
+// including the legend needed to decode its tokens. The provider is not
+// decoded when the capabilities are, since it may be either of two types.
+func SemanticTokensOptions(caps protocol.ServerCapabilities) (protocol.SemanticTokensOptions, bool) {
+	var options protocol.SemanticTokensOptions
+	if !providerEnabled(caps.SemanticTokensProvider) {
+		return options, false
+	}
+	data, err := json.Marshal(caps.SemanticTokensProvider)
+	if err != nil {
+		return options, false
+	}
+	if err := json.Unmarshal(data, &options); err != nil {
+		return options, false
+	}
+	return options, true
+}
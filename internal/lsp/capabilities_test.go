@@ -0,0 +1,24 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSemanticTokensOptions(t *testing.T) {
+	var caps protocol.ServerCapabilities
+	err := json.Unmarshal([]byte(`{"semanticTokensProvider":{"legend":{"tokenTypes":["type","parameter"],"tokenModifiers":["readonly"]},"full":true}}`), &caps)
+	assert.NoError(t, err)
+
+	options, ok := SemanticTokensOptions(caps)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"type", "parameter"}, options.Legend.TokenTypes)
+	assert.True(t, MethodSupported(caps, "textDocument/semanticTokens/full"))
+	assert.False(t, MethodSupported(caps, "textDocument/semanticTokens/range"))
+
+	_, ok = SemanticTokensOptions(protocol.ServerCapabilities{})
+	assert.False(t, ok)
+}
@@ -21,6 +21,13 @@ type Client struct {
 	stdin  io.WriteCloser
 	stdout *bufio.Reader
 	stderr io.ReadCloser
+	// Guards Cmd and the pipes, which are replaced when the server restarts
+	processMu sync.RWMutex
+
+	// How the server was started, to start it again after a crash
+	command string
+	args    []string
+	env     map[string]string
 
 	// Request ID counter
 	nextID atomic.Int32
@@ -28,6 +35,10 @@ type Client struct {
 	// Response handlers
 	handlers   map[string]chan *Message
 	handlersMu sync.RWMutex
+	// Set while the server is not running, guarded by handlersMu
+	disconnected bool
+	// Time of the last successful response, in Unix nanoseconds
+	lastSuccess atomic.Int64
 
 	// Server request handlers
 	serverRequestHandlers map[string]ServerRequestHandler
@@ -41,54 +52,169 @@ type Client struct {
 	diagnostics   map[protocol.DocumentUri][]protocol.Diagnostic
 	diagnosticsMu sync.RWMutex
 
+	// Result IDs of pulled diagnostics, guarded by diagnosticsMu
+	diagnosticResultIDs map[protocol.DocumentUri]string
+
+	// Published diagnostics by document, and a channel closed on the next
+	// publication, guarded by diagnosticsMu
+	publications       map[protocol.DocumentUri]diagnosticPublication
+	diagnosticsUpdated chan struct{}
+	// Time diagnostics were last published for any document, guarded by
+	// diagnosticsMu
+	lastPublication time.Time
+	// Called with the diagnostics of a document before and after each
+	// publication, guarded by diagnosticsMu
+	diagnosticsHandler DiagnosticsHandler
+
+	// Titles of the work done progress in flight by token, and the function
+	// called on each report, guarded by progressMu
+	activeProgress  map[string]string
+	progressHandler ProgressHandler
+	progressMu      sync.Mutex
+
 	// Files are currently opened by the LSP
 	openFiles   map[string]*OpenFileInfo
 	openFilesMu sync.RWMutex
+	// Locks serializing changes to documents with overlays of them, guarded
+	// by openFilesMu
+	documentLocks map[string]*sync.Mutex
+
+	// Result of the initialize request, including the server capabilities
+	initializeResult   *protocol.InitializeResult
+	initializeResultMu sync.RWMutex
+
+	// Active JSON-RPC traces
+	traces   []*Trace
+	tracesMu sync.RWMutex
+
+	// Concurrency limits for outgoing requests
+	limiter   *requestLimiter
+	limiterMu sync.RWMutex
+
+	// Recent activity of the server, for crash reports
+	forensics      *forensics
+	crashHandler   CrashHandler
+	crashHandlerMu sync.Mutex
+
+	// Set once the server has been asked to shut down, after which it is
+	// expected to exit
+	shuttingDown atomic.Bool
+
+	// Initialization options added to the defaults, by key
+	initializationOptions map[string]any
+
+	// Settings returned for workspace/configuration requests
+	settings map[string]any
+
+	// Directories passed as the workspace folders instead of the workspace,
+	// and the workspace the server was initialized with, guarded by
+	// workspaceFoldersMu
+	workspaceFolders   []string
+	workspaceDir       string
+	workspaceFoldersMu sync.RWMutex
+
+	// Called with the messages the server shows, and choosing the answer to
+	// its prompts, guarded by messagesMu
+	messageHandler       MessageHandler
+	messageRequestPolicy MessageRequestPolicy
+	messagesMu           sync.RWMutex
+
+	// File watchers registered by the server by registration ID, and the
+	// functions told about them, guarded by fileWatchMu
+	fileWatchers      map[string][]protocol.FileSystemWatcher
+	fileWatchHandlers []FileWatchHandler
+	fileWatchMu       sync.Mutex
+
+	// Restarts of the server after it crashed
+	restartPolicy  RestartPolicy
+	restartHandler RestartHandler
+	restarts       []time.Time
+	restartMu      sync.Mutex
 }
 
 func NewClient(command string, args ...string) (*Client, error) {
-	cmd := exec.Command(command, args...)
+	return NewClientWithEnv(command, nil, args...)
+}
+
+// NewClientWithEnv starts a language server with environment variables set
+// in addition to those of this process
+func NewClientWithEnv(command string, env map[string]string, args ...string) (*Client, error) {
+	client := &Client{
+		command:               command,
+		args:                  args,
+		env:                   env,
+		handlers:              make(map[string]chan *Message),
+		notificationHandlers:  make(map[string]NotificationHandler),
+		serverRequestHandlers: make(map[string]ServerRequestHandler),
+		diagnostics:           make(map[protocol.DocumentUri][]protocol.Diagnostic),
+		diagnosticResultIDs:   make(map[protocol.DocumentUri]string),
+		openFiles:             make(map[string]*OpenFileInfo),
+		limiter:               newRequestLimiter(DefaultConcurrencyLimits),
+		forensics:             newForensics(),
+		restartPolicy:         DefaultRestartPolicy,
+	}
+	if err := client.start(); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// start starts the language server process and the goroutines reading its
+// output
+func (c *Client) start() error {
+	cmd := exec.Command(c.command, c.args...)
 	// Copy env
 	cmd.Env = os.Environ()
+	for key, value := range c.env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
 
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+		return fmt.Errorf("failed to create stdin pipe: %w", err)
 	}
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+		return fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
 
 	stderr, err := cmd.StderrPipe()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
-	}
-
-	client := &Client{
-		Cmd:                   cmd,
-		stdin:                 stdin,
-		stdout:                bufio.NewReader(stdout),
-		stderr:                stderr,
-		handlers:              make(map[string]chan *Message),
-		notificationHandlers:  make(map[string]NotificationHandler),
-		serverRequestHandlers: make(map[string]ServerRequestHandler),
-		diagnostics:           make(map[protocol.DocumentUri][]protocol.Diagnostic),
-		openFiles:             make(map[string]*OpenFileInfo),
+		return fmt.Errorf("failed to create stderr pipe: %w", err)
 	}
 
 	// Start the LSP server process
 	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start LSP server: %w", err)
+		return fmt.Errorf("failed to start LSP server: %w", err)
 	}
 
+	reader := bufio.NewReader(stdout)
+	stderrDone := make(chan struct{})
+
+	c.processMu.Lock()
+	c.Cmd = cmd
+	c.stdin = stdin
+	c.stdout = reader
+	c.stderr = stderr
+	c.processMu.Unlock()
+
+	c.forensics.mu.Lock()
+	c.forensics.stderrDone = stderrDone
+	c.forensics.mu.Unlock()
+
+	c.handlersMu.Lock()
+	c.disconnected = false
+	c.handlersMu.Unlock()
+
 	// Handle stderr in a separate goroutine with proper logging
 	go func() {
+		defer close(stderrDone)
 		scanner := bufio.NewScanner(stderr)
 		for scanner.Scan() {
 			line := scanner.Text()
 			processLogger.Info("%s", line)
+			c.forensics.addStderr(line)
 		}
 		if err := scanner.Err(); err != nil {
 			lspLogger.Error("Error reading LSP server stderr: %v", err)
@@ -96,9 +222,16 @@ func NewClient(command string, args ...string) (*Client, error) {
 	}()
 
 	// Start message handling loop
-	go client.handleMessages()
+	go c.handleMessages(reader)
 
-	return client, nil
+	return nil
+}
+
+// process returns the running server process and its stdin
+func (c *Client) process() (*exec.Cmd, io.WriteCloser) {
+	c.processMu.RLock()
+	defer c.processMu.RUnlock()
+	return c.Cmd, c.stdin
 }
 
 func (c *Client) RegisterNotificationHandler(method string, handler NotificationHandler) {
@@ -113,15 +246,105 @@ func (c *Client) RegisterServerRequestHandler(method string, handler ServerReque
 	c.serverRequestHandlers[method] = handler
 }
 
+// SetInitializationOption sets an initialization option sent to the server,
+// replacing any set before for the key. An object is merged with the default
+// for the key, if there is one. It must be called before InitializeLSPClient.
+func (c *Client) SetInitializationOption(key string, value any) {
+	if c.initializationOptions == nil {
+		c.initializationOptions = make(map[string]any)
+	}
+	c.initializationOptions[key] = value
+}
+
+// MergeInitializationOptions merges options into the initialization options
+// sent to the server. Objects are merged key by key with the defaults and the
+// options set before, so that setting one nested option keeps the others;
+// other values replace them. It must be called before InitializeLSPClient.
+func (c *Client) MergeInitializationOptions(options map[string]any) {
+	if c.initializationOptions == nil {
+		c.initializationOptions = make(map[string]any)
+	}
+	MergeOptions(c.initializationOptions, options)
+}
+
+// MergeOptions merges src into dst, recursively for values that are objects
+// in both
+func MergeOptions(dst, src map[string]any) {
+	for key, value := range src {
+		nested, ok := value.(map[string]any)
+		existing, isObject := dst[key].(map[string]any)
+		if ok && isObject {
+			merged := make(map[string]any, len(existing)+len(nested))
+			MergeOptions(merged, existing)
+			MergeOptions(merged, nested)
+			dst[key] = merged
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// SetSettings sets the settings returned to the server when it asks for its
+// configuration with workspace/configuration, which servers such as pyright
+// read instead of initialization options. They are also sent with
+// workspace/didChangeConfiguration once the server is initialized. It must be
+// called before InitializeLSPClient.
+func (c *Client) SetSettings(settings map[string]any) {
+	c.settings = settings
+}
+
+// SetWorkspaceFolders sets the directories passed to the server as the
+// workspace folders, so that it only indexes them rather than the whole
+// workspace. It must be called before InitializeLSPClient; use
+// AddWorkspaceFolder and RemoveWorkspaceFolder once the server is running.
+func (c *Client) SetWorkspaceFolders(dirs []string) {
+	c.workspaceFoldersMu.Lock()
+	defer c.workspaceFoldersMu.Unlock()
+	c.workspaceFolders = dirs
+}
+
+// initOptions returns the initialization options: settings for gopls, which
+// other servers ignore, and any set with SetInitializationOption or
+// MergeInitializationOptions
+func (c *Client) initOptions() map[string]any {
+	options := map[string]any{
+		"codelenses": map[string]any{
+			"generate":           true,
+			"regenerate_cgo":     true,
+			"test":               true,
+			"tidy":               true,
+			"upgrade_dependency": true,
+			"vendor":             true,
+			"vulncheck":          false,
+		},
+		"semanticTokens": true,
+		// gopls only computes the inlay hints that are enabled
+		"hints": map[string]any{
+			"assignVariableTypes":    true,
+			"compositeLiteralFields": true,
+			"compositeLiteralTypes":  true,
+			"constantValues":         true,
+			"functionTypeParameters": true,
+			"parameterNames":         true,
+			"rangeVariableTypes":     true,
+		},
+	}
+	MergeOptions(options, c.initializationOptions)
+	return options
+}
+
 func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (*protocol.InitializeResult, error) {
+	c.workspaceFoldersMu.Lock()
+	c.workspaceDir = workspaceDir
+	c.workspaceFoldersMu.Unlock()
+	abortOnFailure := protocol.Abort
+	var folders []protocol.WorkspaceFolder
+	for _, dir := range c.WorkspaceFolders() {
+		folders = append(folders, workspaceFolder(dir))
+	}
 	initParams := &protocol.InitializeParams{
 		WorkspaceFoldersInitializeParams: protocol.WorkspaceFoldersInitializeParams{
-			WorkspaceFolders: []protocol.WorkspaceFolder{
-				{
-					URI:  protocol.URI("file://" + workspaceDir),
-					Name: workspaceDir,
-				},
-			},
+			WorkspaceFolders: folders,
 		},
 
 		XInitializeParams: protocol.XInitializeParams{
@@ -134,7 +357,16 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 			RootURI:  protocol.DocumentUri("file://" + workspaceDir),
 			Capabilities: protocol.ClientCapabilities{
 				Workspace: protocol.WorkspaceClientCapabilities{
-					Configuration: true,
+					Configuration:    true,
+					WorkspaceFolders: true,
+					// Workspace edits are planned as a whole before anything
+					// is written, see utilities.PlanWorkspaceEdit
+					WorkspaceEdit: &protocol.WorkspaceEditClientCapabilities{
+						DocumentChanges:         true,
+						ResourceOperations:      []protocol.ResourceOperationKind{protocol.Create, protocol.Rename, protocol.Delete},
+						FailureHandling:         &abortOnFailure,
+						ChangeAnnotationSupport: &protocol.ChangeAnnotationsSupportOptions{},
+					},
 					DidChangeConfiguration: protocol.DidChangeConfigurationClientCapabilities{
 						DynamicRegistration: true,
 					},
@@ -146,48 +378,90 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 				TextDocument: protocol.TextDocumentClientCapabilities{
 					Synchronization: &protocol.TextDocumentSyncClientCapabilities{
 						DynamicRegistration: true,
+						WillSave:            true,
+						WillSaveWaitUntil:   true,
 						DidSave:             true,
 					},
 					Completion: protocol.CompletionClientCapabilities{
-						CompletionItem: protocol.ClientCompletionItemOptions{},
+						CompletionItem: protocol.ClientCompletionItemOptions{
+							// Auto-import edits are often only computed on resolve
+							ResolveSupport: &protocol.ClientCompletionItemResolveOptions{
+								Properties: []string{"additionalTextEdits", "detail", "documentation"},
+							},
+							LabelDetailsSupport: true,
+						},
 					},
 					CodeLens: &protocol.CodeLensClientCapabilities{
 						DynamicRegistration: true,
 					},
 					DocumentSymbol: protocol.DocumentSymbolClientCapabilities{},
+					TypeHierarchy:  &protocol.TypeHierarchyClientCapabilities{},
+					InlineValue:    &protocol.InlineValueClientCapabilities{},
+					InlayHint:      &protocol.InlayHintClientCapabilities{},
+					DocumentLink: &protocol.DocumentLinkClientCapabilities{
+						TooltipSupport: true,
+					},
+					FoldingRange: &protocol.FoldingRangeClientCapabilities{
+						LineFoldingOnly: true,
+					},
+					Rename: &protocol.RenameClientCapabilities{
+						PrepareSupport: true,
+					},
 					CodeAction: protocol.CodeActionClientCapabilities{
 						CodeActionLiteralSupport: protocol.ClientCodeActionLiteralOptions{
 							CodeActionKind: protocol.ClientCodeActionKindOptions{
-								ValueSet: []protocol.CodeActionKind{},
+								ValueSet: []protocol.CodeActionKind{
+									protocol.QuickFix,
+									protocol.Refactor,
+									protocol.RefactorExtract,
+									protocol.RefactorInline,
+									protocol.RefactorRewrite,
+									protocol.Source,
+									protocol.SourceOrganizeImports,
+									protocol.SourceFixAll,
+								},
 							},
 						},
+						IsPreferredSupport: true,
+						DisabledSupport:    true,
+						DataSupport:        true,
+						ResolveSupport: &protocol.ClientCodeActionResolveOptions{
+							Properties: []string{"edit"},
+						},
 					},
+					Formatting:      &protocol.DocumentFormattingClientCapabilities{},
+					RangeFormatting: &protocol.DocumentRangeFormattingClientCapabilities{},
 					PublishDiagnostics: protocol.PublishDiagnosticsClientCapabilities{
 						VersionSupport: true,
 					},
+					Diagnostic: &protocol.DiagnosticClientCapabilities{
+						RelatedDocumentSupport: true,
+					},
 					SemanticTokens: protocol.SemanticTokensClientCapabilities{
 						Requests: protocol.ClientSemanticTokensRequestOptions{
 							Range: &protocol.Or_ClientSemanticTokensRequestOptions_range{},
 							Full:  &protocol.Or_ClientSemanticTokensRequestOptions_full{},
 						},
-						TokenTypes:     []string{},
-						TokenModifiers: []string{},
-						Formats:        []protocol.TokenFormat{},
+						TokenTypes: []string{
+							"namespace", "type", "class", "enum", "interface", "struct",
+							"typeParameter", "parameter", "variable", "property", "enumMember",
+							"event", "function", "method", "macro", "keyword", "modifier",
+							"comment", "string", "number", "regexp", "operator", "decorator",
+						},
+						TokenModifiers: []string{
+							"declaration", "definition", "readonly", "static", "deprecated",
+							"abstract", "async", "modification", "documentation", "defaultLibrary",
+						},
+						Formats: []protocol.TokenFormat{protocol.Relative},
 					},
 				},
-				Window: protocol.WindowClientCapabilities{},
-			},
-			InitializationOptions: map[string]any{
-				"codelenses": map[string]bool{
-					"generate":           true,
-					"regenerate_cgo":     true,
-					"test":               true,
-					"tidy":               true,
-					"upgrade_dependency": true,
-					"vendor":             true,
-					"vulncheck":          false,
+				// Lets long running commands, such as running tests from a
+				// code lens, report when they are done
+				Window: protocol.WindowClientCapabilities{
+					WorkDoneProgress: true,
 				},
 			},
+			InitializationOptions: c.initOptions(),
 		},
 	}
 
@@ -196,17 +470,39 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 		return nil, fmt.Errorf("initialize failed: %w", err)
 	}
 
+	c.initializeResultMu.Lock()
+	c.initializeResult = &result
+	c.initializeResultMu.Unlock()
+
 	if err := c.Notify(ctx, "initialized", struct{}{}); err != nil {
 		return nil, fmt.Errorf("initialized notification failed: %w", err)
 	}
 
 	// Register handlers
 	c.RegisterServerRequestHandler("workspace/applyEdit", HandleApplyEdit)
-	c.RegisterServerRequestHandler("workspace/configuration", HandleWorkspaceConfiguration)
-	c.RegisterServerRequestHandler("client/registerCapability", HandleRegisterCapability)
-	c.RegisterNotificationHandler("window/showMessage", HandleServerMessage)
+	c.RegisterServerRequestHandler("workspace/configuration",
+		func(params json.RawMessage) (any, error) { return HandleWorkspaceConfiguration(c, params) })
+	c.RegisterServerRequestHandler("workspace/workspaceFolders",
+		func(params json.RawMessage) (any, error) { return HandleWorkspaceFolders(c, params) })
+	c.RegisterServerRequestHandler("client/registerCapability",
+		func(params json.RawMessage) (any, error) { return HandleRegisterCapability(c, params) })
+	c.RegisterServerRequestHandler("client/unregisterCapability",
+		func(params json.RawMessage) (any, error) { return HandleUnregisterCapability(c, params) })
+	c.RegisterServerRequestHandler("workspace/diagnostic/refresh",
+		func(params json.RawMessage) (any, error) { return HandleDiagnosticRefresh(c, params) })
+	c.RegisterServerRequestHandler("window/workDoneProgress/create", HandleWorkDoneProgressCreate)
+	c.RegisterServerRequestHandler("window/showMessageRequest",
+		func(params json.RawMessage) (any, error) { return HandleShowMessageRequest(c, params) })
+	c.RegisterNotificationHandler("window/showMessage",
+		func(params json.RawMessage) { HandleServerMessage(c, params) })
+	c.RegisterNotificationHandler("window/logMessage",
+		func(params json.RawMessage) { HandleLogMessage(c, params) })
+	c.RegisterNotificationHandler("$/logTrace",
+		func(params json.RawMessage) { HandleLogTrace(c, params) })
 	c.RegisterNotificationHandler("textDocument/publishDiagnostics",
 		func(params json.RawMessage) { HandleDiagnostics(c, params) })
+	c.RegisterNotificationHandler("$/progress",
+		func(params json.RawMessage) { HandleProgress(c, params) })
 
 	// Notify the LSP server
 	err := c.Initialized(ctx, protocol.InitializedParams{})
@@ -214,8 +510,16 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 		return nil, fmt.Errorf("initialization failed: %w", err)
 	}
 
+	// Servers that do not ask for their configuration get it pushed
+	if len(c.settings) > 0 {
+		if err := c.DidChangeConfiguration(ctx, protocol.DidChangeConfigurationParams{Settings: c.settings}); err != nil {
+			return nil, fmt.Errorf("failed to send settings: %w", err)
+		}
+	}
+
 	// LSP sepecific Initialization
-	path := strings.ToLower(c.Cmd.Path)
+	cmd, _ := c.process()
+	path := strings.ToLower(cmd.Path)
 	switch {
 	case strings.Contains(path, "typescript-language-server"):
 		err := initializeTypescriptLanguageServer(ctx, c, workspaceDir)
@@ -228,6 +532,9 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 }
 
 func (c *Client) Close() error {
+	c.shuttingDown.Store(true)
+	cmd, stdin := c.process()
+
 	// Try to close all open files first
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -241,8 +548,8 @@ func (c *Client) Close() error {
 		select {
 		case <-time.After(2 * time.Second):
 			lspLogger.Warn("LSP process did not exit within timeout, forcing kill")
-			if c.Cmd.Process != nil {
-				if err := c.Cmd.Process.Kill(); err != nil {
+			if cmd.Process != nil {
+				if err := cmd.Process.Kill(); err != nil {
 					lspLogger.Error("Failed to kill process: %v", err)
 				} else {
 					lspLogger.Info("Process killed successfully")
@@ -256,12 +563,12 @@ func (c *Client) Close() error {
 	}()
 
 	// Close stdin to signal the server
-	if err := c.stdin.Close(); err != nil {
+	if err := stdin.Close(); err != nil {
 		lspLogger.Error("Failed to close stdin: %v", err)
 	}
 
 	// Wait for process to exit
-	err := c.Cmd.Wait()
+	err := cmd.Wait()
 	close(forcedKill) // Stop the force kill goroutine
 
 	return err
@@ -284,10 +591,21 @@ func (c *Client) WaitForServerReady(ctx context.Context) error {
 type OpenFileInfo struct {
 	Version int32
 	URI     protocol.DocumentUri
+
+	// Content last sent to the server, used to compute incremental changes
+	content string
+
+	// Set while WithOverlay has the server analyze other content, along
+	// with the actual content of the document, and the version of the last
+	// overlay
+	overlaid       bool
+	underlay       string
+	overlayVersion int32
 }
 
 func (c *Client) OpenFile(ctx context.Context, filepath string) error {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := string(DocumentURI(filepath))
+	c.waitForOverlay(protocol.DocumentUri(uri))
 
 	c.openFilesMu.Lock()
 	if _, exists := c.openFiles[uri]; exists {
@@ -296,8 +614,13 @@ func (c *Client) OpenFile(ctx context.Context, filepath string) error {
 	}
 	c.openFilesMu.Unlock()
 
+	// Documents in other schemes have no file to read
+	if !IsFileURI(protocol.DocumentUri(uri)) {
+		return openDocumentError(protocol.DocumentUri(uri))
+	}
+
 	// Skip files that do not exist or cannot be read
-	content, err := os.ReadFile(filepath)
+	content, err := os.ReadFile(DocumentPath(protocol.DocumentUri(uri)))
 	if err != nil {
 		return fmt.Errorf("error reading file: %w", err)
 	}
@@ -319,6 +642,7 @@ func (c *Client) OpenFile(ctx context.Context, filepath string) error {
 	c.openFiles[uri] = &OpenFileInfo{
 		Version: 1,
 		URI:     protocol.DocumentUri(uri),
+		content: string(content),
 	}
 	c.openFilesMu.Unlock()
 
@@ -328,46 +652,28 @@ func (c *Client) OpenFile(ctx context.Context, filepath string) error {
 }
 
 func (c *Client) NotifyChange(ctx context.Context, filepath string) error {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := string(DocumentURI(filepath))
+	if !IsFileURI(protocol.DocumentUri(uri)) {
+		return fmt.Errorf("cannot notify change for %s: only files can be reloaded", uri)
+	}
 
-	content, err := os.ReadFile(filepath)
+	content, err := os.ReadFile(DocumentPath(protocol.DocumentUri(uri)))
 	if err != nil {
 		return fmt.Errorf("error reading file: %w", err)
 	}
 
-	c.openFilesMu.Lock()
-	fileInfo, isOpen := c.openFiles[uri]
-	if !isOpen {
-		c.openFilesMu.Unlock()
+	if !c.IsFileOpen(filepath) {
 		return fmt.Errorf("cannot notify change for unopened file: %s", filepath)
 	}
 
-	// Increment version
-	fileInfo.Version++
-	version := fileInfo.Version
-	c.openFilesMu.Unlock()
-
-	params := protocol.DidChangeTextDocumentParams{
-		TextDocument: protocol.VersionedTextDocumentIdentifier{
-			TextDocumentIdentifier: protocol.TextDocumentIdentifier{
-				URI: protocol.DocumentUri(uri),
-			},
-			Version: version,
-		},
-		ContentChanges: []protocol.TextDocumentContentChangeEvent{
-			{
-				Value: protocol.TextDocumentContentChangeWholeDocument{
-					Text: string(content),
-				},
-			},
-		},
-	}
-
-	return c.Notify(ctx, "textDocument/didChange", params)
+	return c.ChangeDocument(ctx, protocol.DocumentUri(uri), string(content))
 }
 
 func (c *Client) CloseFile(ctx context.Context, filepath string) error {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := string(DocumentURI(filepath))
+	lock := c.documentLock(protocol.DocumentUri(uri))
+	lock.Lock()
+	defer lock.Unlock()
 
 	c.openFilesMu.Lock()
 	if _, exists := c.openFiles[uri]; !exists {
@@ -394,7 +700,7 @@ func (c *Client) CloseFile(ctx context.Context, filepath string) error {
 }
 
 func (c *Client) IsFileOpen(filepath string) bool {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := string(DocumentURI(filepath))
 	c.openFilesMu.RLock()
 	defer c.openFilesMu.RUnlock()
 	_, exists := c.openFiles[uri]
@@ -408,8 +714,8 @@ func (c *Client) CloseAllFiles(ctx context.Context) {
 
 	// First collect all URIs that need to be closed
 	for uri := range c.openFiles {
-		// Convert URI back to file path by trimming "file://" prefix
-		filePath := strings.TrimPrefix(uri, "file://")
+		// Convert URI back to the path it was opened with
+		filePath := DocumentPath(protocol.DocumentUri(uri))
 		filesToClose = append(filesToClose, filePath)
 	}
 	c.openFilesMu.Unlock()
@@ -431,3 +737,18 @@ func (c *Client) GetFileDiagnostics(uri protocol.DocumentUri) []protocol.Diagnos
 
 	return c.diagnostics[uri]
 }
+
+// GetAllDiagnostics returns the cached diagnostics of every document that has
+// any, whether they were published by the server or pulled
+func (c *Client) GetAllDiagnostics() map[protocol.DocumentUri][]protocol.Diagnostic {
+	c.diagnosticsMu.RLock()
+	defer c.diagnosticsMu.RUnlock()
+
+	all := make(map[protocol.DocumentUri][]protocol.Diagnostic, len(c.diagnostics))
+	for uri, diagnostics := range c.diagnostics {
+		if len(diagnostics) > 0 {
+			all[uri] = diagnostics
+		}
+	}
+	return all
+}
@@ -0,0 +1,33 @@
+package lsp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitOptions(t *testing.T) {
+	c := &Client{}
+	c.SetInitializationOption("directoryFilters", []string{"-", "+app"})
+	c.MergeInitializationOptions(map[string]any{
+		"hints":   map[string]any{"parameterNames": false},
+		"cargo":   map[string]any{"features": []any{"full"}},
+		"gofumpt": true,
+	})
+	c.MergeInitializationOptions(map[string]any{
+		"cargo": map[string]any{"buildScripts": map[string]any{"enable": true}},
+	})
+
+	options := c.initOptions()
+	hints := options["hints"].(map[string]any)
+	assert.Equal(t, false, hints["parameterNames"])
+	// Defaults for the other keys of an object are kept
+	assert.Equal(t, true, hints["rangeVariableTypes"])
+	assert.Equal(t, map[string]any{
+		"features":     []any{"full"},
+		"buildScripts": map[string]any{"enable": true},
+	}, options["cargo"])
+	assert.Equal(t, true, options["gofumpt"])
+	assert.Equal(t, []string{"-", "+app"}, options["directoryFilters"])
+	assert.Equal(t, true, options["semanticTokens"])
+}
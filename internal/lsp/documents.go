@@ -0,0 +1,215 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// DocumentURI converts a path passed to a tool into a document URI. Paths that
+// already carry a URI scheme, such as untitled:Untitled-1 or
+// deno:/https/deno.land/std/path/mod.ts, are used as they are, so that URIs
+// reported by the server can be passed back to position based tools.
+func DocumentURI(path string) protocol.DocumentUri {
+	if hasURIScheme(path) {
+		return protocol.DocumentUri(path)
+	}
+	return protocol.DocumentUri("file://" + path)
+}
+
+// DocumentPath is the inverse of DocumentURI: file URIs are converted to paths
+// and URIs in other schemes are returned unchanged.
+func DocumentPath(uri protocol.DocumentUri) string {
+	if !IsFileURI(uri) {
+		return string(uri)
+	}
+	path := strings.TrimPrefix(string(uri), "file://")
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		return unescaped
+	}
+	return path
+}
+
+// IsFileURI reports whether a URI refers to a file on disk
+func IsFileURI(uri protocol.DocumentUri) bool {
+	return strings.HasPrefix(string(uri), "file://")
+}
+
+// hasURIScheme reports whether s starts with an RFC 3986 scheme. Single letter
+// schemes are not accepted, so that Windows drive letters are treated as paths.
+func hasURIScheme(s string) bool {
+	scheme, _, found := strings.Cut(s, ":")
+	if !found || len(scheme) < 2 {
+		return false
+	}
+	for i, r := range scheme {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '+' || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// OpenDocument opens a document that is not a file on disk with the given
+// content, such as a virtual document provided by the server or an untitled
+// buffer. Documents are tracked by URI alongside files opened with OpenFile.
+func (c *Client) OpenDocument(ctx context.Context, uri protocol.DocumentUri, content string) error {
+	if IsFileURI(uri) {
+		return c.OpenFile(ctx, DocumentPath(uri))
+	}
+
+	c.openFilesMu.Lock()
+	if _, exists := c.openFiles[string(uri)]; exists {
+		c.openFilesMu.Unlock()
+		return nil // Already open
+	}
+	c.openFilesMu.Unlock()
+
+	params := protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:        uri,
+			LanguageID: DetectLanguageID(string(uri)),
+			Version:    1,
+			Text:       content,
+		},
+	}
+	if err := c.Notify(ctx, "textDocument/didOpen", params); err != nil {
+		return err
+	}
+
+	c.openFilesMu.Lock()
+	c.openFiles[string(uri)] = &OpenFileInfo{
+		Version: 1,
+		URI:     uri,
+		content: content,
+	}
+	c.openFilesMu.Unlock()
+
+	lspLogger.Debug("Opened document: %s", uri)
+
+	return nil
+}
+
+// Document is an open document as the server sees it: the text last sent to
+// the server and the version it was sent with. The text may differ from the
+// file on disk while a tool has in-flight changes to it. While the document
+// is overlaid, its actual text is returned.
+type Document struct {
+	URI     protocol.DocumentUri
+	Version int32
+	Text    string
+}
+
+// Document returns an open document, or false if it is not open
+func (c *Client) Document(uri protocol.DocumentUri) (Document, bool) {
+	c.openFilesMu.RLock()
+	defer c.openFilesMu.RUnlock()
+	fileInfo, ok := c.openFiles[string(uri)]
+	if !ok {
+		return Document{}, false
+	}
+	text := fileInfo.content
+	if fileInfo.overlaid {
+		text = fileInfo.underlay
+	}
+	return Document{URI: fileInfo.URI, Version: fileInfo.Version, Text: text}, true
+}
+
+// DocumentContent returns the content last sent to the server for an open
+// document, or false if the document is not open
+func (c *Client) DocumentContent(uri protocol.DocumentUri) (string, bool) {
+	doc, ok := c.Document(uri)
+	return doc.Text, ok
+}
+
+// ReadDocument returns the text of a document as the server sees it, so that
+// the context shown around locations matches the positions the server
+// reported. Open documents are read from the text last sent to the server
+// and other files from disk.
+func (c *Client) ReadDocument(uri protocol.DocumentUri) (string, error) {
+	if doc, ok := c.Document(uri); ok {
+		return doc.Text, nil
+	}
+	if !IsFileURI(uri) {
+		return "", openDocumentError(uri)
+	}
+	content, err := os.ReadFile(DocumentPath(uri))
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// openDocumentError is returned when a document in another scheme is used
+// before it has been opened with OpenDocument
+func openDocumentError(uri protocol.DocumentUri) error {
+	return fmt.Errorf("%s is not a file and has not been opened", uri)
+}
+
+// ChangeDocument replaces the content of an open document as seen by the
+// server, without touching the file on disk. NotifyChange uses it to sync a
+// file after it was written; it can also be used to let the server analyze a
+// temporary version of a document. It waits for any overlay of the document
+// to be removed.
+func (c *Client) ChangeDocument(ctx context.Context, uri protocol.DocumentUri, content string) error {
+	lock := c.documentLock(uri)
+	lock.Lock()
+	defer lock.Unlock()
+	return c.changeDocument(ctx, uri, content)
+}
+
+// changeDocument is ChangeDocument for a caller holding the document lock
+func (c *Client) changeDocument(ctx context.Context, uri protocol.DocumentUri, content string) error {
+	c.openFilesMu.Lock()
+	fileInfo, isOpen := c.openFiles[string(uri)]
+	if !isOpen {
+		c.openFilesMu.Unlock()
+		return fmt.Errorf("cannot change unopened document: %s", uri)
+	}
+
+	// Nothing to do if the server already has this content
+	oldContent := fileInfo.content
+	if oldContent == content {
+		c.openFilesMu.Unlock()
+		return nil
+	}
+
+	// Increment version
+	fileInfo.Version++
+	version := fileInfo.Version
+	fileInfo.content = content
+	c.openFilesMu.Unlock()
+
+	// Send only the changed ranges if the server supports it, rather than the
+	// whole document. Servers that do not sync documents are not told.
+	var changes []protocol.TextDocumentContentChangeEvent
+	switch c.documentSyncOptions().change {
+	case protocol.None:
+		return nil
+	case protocol.Incremental:
+		changes = incrementalChanges(oldContent, content)
+	default:
+		changes = []protocol.TextDocumentContentChangeEvent{{
+			Value: protocol.TextDocumentContentChangeWholeDocument{Text: content},
+		}}
+	}
+
+	params := protocol.DidChangeTextDocumentParams{
+		TextDocument: protocol.VersionedTextDocumentIdentifier{
+			TextDocumentIdentifier: protocol.TextDocumentIdentifier{
+				URI: uri,
+			},
+			Version: version,
+		},
+		ContentChanges: changes,
+	}
+
+	return c.Notify(ctx, "textDocument/didChange", params)
+}
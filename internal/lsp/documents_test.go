@@ -0,0 +1,64 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentURI(t *testing.T) {
+	tests := []struct {
+		path string
+		uri  protocol.DocumentUri
+	}{
+		{"/src/main.go", "file:///src/main.go"},
+		{"file:///src/main.go", "file:///src/main.go"},
+		{"untitled:Untitled-1", "untitled:Untitled-1"},
+		{"deno:/https/deno.land/std/path/mod.ts", "deno:/https/deno.land/std/path/mod.ts"},
+		{"git-show:/src/main.go", "git-show:/src/main.go"},
+		{"C:/src/main.go", "file://C:/src/main.go"},
+		{"/src/a:b.go", "file:///src/a:b.go"},
+	}
+
+	for _, tt := range tests {
+		uri := DocumentURI(tt.path)
+		assert.Equal(t, tt.uri, uri, tt.path)
+	}
+}
+
+func TestDocumentPath(t *testing.T) {
+	assert.Equal(t, "/src/main.go", DocumentPath("file:///src/main.go"))
+	assert.Equal(t, "/src/my file.go", DocumentPath("file:///src/my%20file.go"))
+	assert.Equal(t, "untitled:Untitled-1", DocumentPath("untitled:Untitled-1"))
+
+	// Non-file URIs round trip through DocumentURI unchanged
+	uri := protocol.DocumentUri("deno:/https/deno.land/std/path/mod.ts")
+	assert.Equal(t, uri, DocumentURI(DocumentPath(uri)))
+}
+
+func TestReadDocument(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.go")
+	require.NoError(t, os.WriteFile(path, []byte("on disk"), 0644))
+	uri := DocumentURI(path)
+	c := &Client{openFiles: make(map[string]*OpenFileInfo)}
+
+	text, err := c.ReadDocument(uri)
+	require.NoError(t, err)
+	assert.Equal(t, "on disk", text, "files that are not open are read from disk")
+
+	c.openFiles[string(uri)] = &OpenFileInfo{Version: 3, URI: uri, content: "in flight"}
+	text, err = c.ReadDocument(uri)
+	require.NoError(t, err)
+	assert.Equal(t, "in flight", text, "open files are read from the text sent to the server")
+
+	doc, ok := c.Document(uri)
+	assert.True(t, ok)
+	assert.Equal(t, Document{URI: uri, Version: 3, Text: "in flight"}, doc)
+
+	_, err = c.ReadDocument("untitled:Untitled-1")
+	assert.Error(t, err)
+}
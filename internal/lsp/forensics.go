@@ -0,0 +1,179 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// recentMessageCount is the number of JSON-RPC messages kept for crash reports
+	recentMessageCount = 100
+	// stderrTailLines is the number of lines of server stderr kept for crash reports
+	stderrTailLines = 200
+	// serverLogCount is the number of log and trace messages from the server kept
+	serverLogCount = 1000
+	// stderrDrainTimeout is how long to wait for the rest of the server's
+	// stderr after it closes the connection
+	stderrDrainTimeout = time.Second
+)
+
+// CrashReport describes the state of the client when the language server
+// exited without being asked to
+type CrashReport struct {
+	Time time.Time
+	// Err is the error that ended the connection with the server
+	Err error
+	// Messages are the most recent JSON-RPC messages, oldest first
+	Messages []TraceEntry
+	// Stderr is the tail of the server's stderr
+	Stderr []string
+	// OpenDocuments are the URIs of the documents open in the server
+	OpenDocuments []string
+}
+
+// CrashHandler is called when the language server exits unexpectedly
+type CrashHandler func(report CrashReport)
+
+// ring keeps the most recent items added to it
+type ring[T any] struct {
+	items []T
+	next  int
+	size  int
+}
+
+func (r *ring[T]) add(item T) {
+	if len(r.items) < r.size {
+		r.items = append(r.items, item)
+		return
+	}
+	r.items[r.next] = item
+	r.next = (r.next + 1) % r.size
+}
+
+// list returns the items, oldest first
+func (r *ring[T]) list() []T {
+	result := make([]T, 0, len(r.items))
+	result = append(result, r.items[r.next:]...)
+	return append(result, r.items[:r.next]...)
+}
+
+// recentMessage is a message kept for crash reports. Messages are only
+// marshaled if a report is made.
+type recentMessage struct {
+	time time.Time
+	sent bool
+	msg  *Message
+}
+
+// forensics keeps the recent activity of the server for crash reports and
+// its recent log messages
+type forensics struct {
+	messages ring[recentMessage]
+	stderr   ring[string]
+	logs     ring[LogEntry]
+	mu       sync.Mutex
+
+	// stderrDone is closed when the server's stderr is closed
+	stderrDone chan struct{}
+}
+
+func newForensics() *forensics {
+	return &forensics{
+		messages:   ring[recentMessage]{size: recentMessageCount},
+		stderr:     ring[string]{size: stderrTailLines},
+		logs:       ring[LogEntry]{size: serverLogCount},
+		stderrDone: make(chan struct{}),
+	}
+}
+
+func (f *forensics) addMessage(sent bool, msg *Message) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages.add(recentMessage{time: time.Now(), sent: sent, msg: msg})
+}
+
+func (f *forensics) addStderr(line string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stderr.add(line)
+}
+
+// SetCrashHandler sets the function called when the server exits without
+// a shutdown request. It is called once for each crash.
+func (c *Client) SetCrashHandler(handler CrashHandler) {
+	c.crashHandlerMu.Lock()
+	defer c.crashHandlerMu.Unlock()
+	c.crashHandler = handler
+}
+
+// handleServerExit is called when the connection to the server is closed. If
+// the server was not asked to shut down, pending requests are failed, the
+// crash handler is called with a report and the server is restarted if the
+// restart policy allows it.
+func (c *Client) handleServerExit(err error) {
+	if c.shuttingDown.Load() {
+		return
+	}
+	lspLogger.Error("Language server exited unexpectedly: %v", err)
+
+	// Pending requests would otherwise never get a response, and requests
+	// made until the server is restarted are failed right away
+	c.handlersMu.Lock()
+	c.disconnected = true
+	for _, ch := range c.handlers {
+		select {
+		case ch <- &Message{Error: &ResponseError{Code: -32099, Message: "language server exited unexpectedly"}}:
+		default:
+		}
+	}
+	c.handlersMu.Unlock()
+
+	c.crashHandlerMu.Lock()
+	handler := c.crashHandler
+	c.crashHandlerMu.Unlock()
+	if handler != nil {
+		c.forensics.mu.Lock()
+		stderrDone := c.forensics.stderrDone
+		c.forensics.mu.Unlock()
+
+		// The server's last words are often still in the stderr pipe
+		select {
+		case <-stderrDone:
+		case <-time.After(stderrDrainTimeout):
+		}
+
+		handler(c.crashReport(err))
+	}
+
+	c.scheduleRestart()
+}
+
+// crashReport collects the recent activity of the server
+func (c *Client) crashReport(err error) CrashReport {
+	report := CrashReport{Time: time.Now(), Err: err}
+
+	c.forensics.mu.Lock()
+	recent := c.forensics.messages.list()
+	report.Stderr = c.forensics.stderr.list()
+	c.forensics.mu.Unlock()
+
+	for _, m := range recent {
+		data, err := json.Marshal(m.msg)
+		if err != nil {
+			data, _ = json.Marshal(fmt.Sprintf("failed to marshal message: %v", err))
+		}
+		report.Messages = append(report.Messages, TraceEntry{Time: m.time, Sent: m.sent, Message: data})
+	}
+
+	c.openFilesMu.RLock()
+	for uri := range c.openFiles {
+		report.OpenDocuments = append(report.OpenDocuments, uri)
+	}
+	c.openFilesMu.RUnlock()
+	sort.Strings(report.OpenDocuments)
+
+	return report
+}
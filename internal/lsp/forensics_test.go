@@ -0,0 +1,68 @@
+package lsp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRing(t *testing.T) {
+	r := ring[int]{size: 3}
+	assert.Empty(t, r.list())
+
+	r.add(1)
+	r.add(2)
+	assert.Equal(t, []int{1, 2}, r.list())
+
+	r.add(3)
+	r.add(4)
+	r.add(5)
+	assert.Equal(t, []int{3, 4, 5}, r.list())
+}
+
+func TestHandleServerExit(t *testing.T) {
+	newClient := func() *Client {
+		c := &Client{
+			handlers:  make(map[string]chan *Message),
+			openFiles: map[string]*OpenFileInfo{"file:///ws/b.go": {}, "file:///ws/a.go": {}},
+			forensics: newForensics(),
+		}
+		close(c.forensics.stderrDone)
+		return c
+	}
+
+	c := newClient()
+	pending := make(chan *Message, 1)
+	c.handlers["1"] = pending
+	c.recordTrace(true, &Message{JSONRPC: "2.0", Method: "textDocument/hover"})
+	c.forensics.addStderr("panic: boom")
+
+	var reports []CrashReport
+	c.SetCrashHandler(func(report CrashReport) { reports = append(reports, report) })
+	c.handleServerExit(errors.New("EOF"))
+
+	if assert.Len(t, reports, 1) {
+		report := reports[0]
+		assert.EqualError(t, report.Err, "EOF")
+		assert.Equal(t, []string{"panic: boom"}, report.Stderr)
+		assert.Equal(t, []string{"file:///ws/a.go", "file:///ws/b.go"}, report.OpenDocuments)
+		if assert.Len(t, report.Messages, 1) {
+			assert.True(t, report.Messages[0].Sent)
+			assert.JSONEq(t, `{"jsonrpc":"2.0","method":"textDocument/hover"}`, string(report.Messages[0].Message))
+		}
+	}
+
+	// Pending requests are failed rather than left waiting
+	if assert.Len(t, pending, 1) {
+		assert.NotNil(t, (<-pending).Error)
+	}
+
+	// Exiting after a shutdown request is not a crash
+	c = newClient()
+	reports = nil
+	c.SetCrashHandler(func(report CrashReport) { reports = append(reports, report) })
+	c.recordTrace(true, &Message{JSONRPC: "2.0", Method: "shutdown"})
+	c.handleServerExit(errors.New("EOF"))
+	assert.Empty(t, reports)
+}
@@ -0,0 +1,44 @@
+package lsp
+
+import (
+	"time"
+)
+
+// Health is a snapshot of the state of the connection to a language server,
+// read without sending it anything so that it can be taken while the server
+// is hung
+type Health struct {
+	// Running is whether the server process is connected. It is false
+	// after a crash until the server has been restarted.
+	Running bool
+	// Initialized is whether the initialize request completed
+	Initialized bool
+	// OpenDocuments is the number of documents open in the server
+	OpenDocuments int
+	// PendingRequests is the number of requests waiting for a response
+	PendingRequests int
+	// LastSuccess is when a request last got a successful response, zero if
+	// none has
+	LastSuccess time.Time
+}
+
+// Health returns the state of the connection to the server
+func (c *Client) Health() Health {
+	var health Health
+
+	c.handlersMu.RLock()
+	health.Running = !c.disconnected && !c.shuttingDown.Load()
+	health.PendingRequests = len(c.handlers)
+	c.handlersMu.RUnlock()
+
+	health.Initialized = c.InitializeResult() != nil
+
+	c.openFilesMu.RLock()
+	health.OpenDocuments = len(c.openFiles)
+	c.openFilesMu.RUnlock()
+
+	if nanos := c.lastSuccess.Load(); nanos != 0 {
+		health.LastSuccess = time.Unix(0, nanos)
+	}
+	return health
+}
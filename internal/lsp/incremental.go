@@ -0,0 +1,129 @@
+package lsp
+
+import (
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// maxDiffLines bounds the number of changed lines diffed line by line. Larger
+// changes are sent as a single range.
+const maxDiffLines = 5000
+
+// incrementalChanges computes the changes that turn oldText into newText, one
+// per run of changed lines, so that edits far apart in a large file are not
+// sent as one range spanning everything between them. Changes are applied by
+// the server in order, so they are listed from the end of the document to
+// its start, keeping each range valid after the ones before it. When the
+// changes would not be smaller than the document, the whole document is
+// sent instead.
+func incrementalChanges(oldText, newText string) []protocol.TextDocumentContentChangeEvent {
+	a := splitLines(oldText)
+	b := splitLines(newText)
+
+	// Lines shared at the start and end are not diffed
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	opcodes := []difflib.OpCode{{Tag: 'r', I1: 0, I2: len(a), J1: 0, J2: len(b)}}
+	if len(a)+len(b) <= maxDiffLines {
+		opcodes = difflib.NewMatcher(a, b).GetOpCodes()
+	}
+
+	var changes []protocol.TextDocumentContentChangeEvent
+	size := 0
+	for i := len(opcodes) - 1; i >= 0; i-- {
+		op := opcodes[i]
+		if op.Tag == 'e' {
+			continue
+		}
+		// Hunks start at the beginning of a line, so positions within them
+		// only need their line shifting
+		change := incrementalChange(strings.Join(a[op.I1:op.I2], ""), strings.Join(b[op.J1:op.J2], ""))
+		change.Range.Start.Line += uint32(prefix + op.I1)
+		change.Range.End.Line += uint32(prefix + op.I1)
+		size += len(change.Text)
+		changes = append(changes, protocol.TextDocumentContentChangeEvent{Value: change})
+	}
+
+	if size >= len(newText) {
+		return []protocol.TextDocumentContentChangeEvent{{
+			Value: protocol.TextDocumentContentChangeWholeDocument{Text: newText},
+		}}
+	}
+	return changes
+}
+
+// splitLines splits text after each newline, keeping the newlines so that
+// joining the lines gives back the text
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// incrementalChange computes a single range edit that turns oldText into
+// newText, replacing the span between their common prefix and suffix. The
+// range is expressed in UTF-16 code units, as required by the protocol.
+func incrementalChange(oldText, newText string) protocol.TextDocumentContentChangePartial {
+	// Length of the common prefix, backed up to a rune boundary
+	prefix := 0
+	for prefix < len(oldText) && prefix < len(newText) && oldText[prefix] == newText[prefix] {
+		prefix++
+	}
+	for prefix > 0 && prefix < len(oldText) && !utf8.RuneStart(oldText[prefix]) {
+		prefix--
+	}
+
+	// Length of the common suffix, not overlapping the prefix and backed up to
+	// a rune boundary
+	suffix := 0
+	for suffix < len(oldText)-prefix && suffix < len(newText)-prefix &&
+		oldText[len(oldText)-1-suffix] == newText[len(newText)-1-suffix] {
+		suffix++
+	}
+	for suffix > 0 && !utf8.RuneStart(oldText[len(oldText)-suffix]) {
+		suffix--
+	}
+
+	start := positionAt(oldText, prefix)
+	end := positionAt(oldText, len(oldText)-suffix)
+
+	return protocol.TextDocumentContentChangePartial{
+		Range: &protocol.Range{
+			Start: start,
+			End:   end,
+		},
+		Text: newText[prefix : len(newText)-suffix],
+	}
+}
+
+// positionAt converts a byte offset in text to an LSP position
+func positionAt(text string, offset int) protocol.Position {
+	var line, character uint32
+	for _, r := range text[:offset] {
+		if r == '\n' {
+			line++
+			character = 0
+			continue
+		}
+		if utf16.RuneLen(r) == 2 {
+			character += 2
+		} else {
+			character++
+		}
+	}
+	return protocol.Position{Line: line, Character: character}
+}
@@ -0,0 +1,149 @@
+package lsp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncrementalChange(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldText  string
+		newText  string
+		expected protocol.TextDocumentContentChangePartial
+	}{
+		{
+			name:    "insert line",
+			oldText: "line1\nline3\n",
+			newText: "line1\nline2\nline3\n",
+			expected: protocol.TextDocumentContentChangePartial{
+				Range: &protocol.Range{
+					Start: protocol.Position{Line: 1, Character: 4},
+					End:   protocol.Position{Line: 1, Character: 4},
+				},
+				Text: "2\nline",
+			},
+		},
+		{
+			name:    "delete",
+			oldText: "func a() {}\nfunc b() {}\n",
+			newText: "func a() {}\n",
+			expected: protocol.TextDocumentContentChangePartial{
+				Range: &protocol.Range{
+					Start: protocol.Position{Line: 1, Character: 0},
+					End:   protocol.Position{Line: 2, Character: 0},
+				},
+				Text: "",
+			},
+		},
+		{
+			name:    "replace after surrogate pair",
+			oldText: "x := \"😀\" + a",
+			newText: "x := \"😀\" + b",
+			expected: protocol.TextDocumentContentChangePartial{
+				Range: &protocol.Range{
+					Start: protocol.Position{Line: 0, Character: 12},
+					End:   protocol.Position{Line: 0, Character: 13},
+				},
+				Text: "b",
+			},
+		},
+		{
+			name:    "replace multibyte rune sharing leading bytes",
+			oldText: "aéb",
+			newText: "aèb",
+			expected: protocol.TextDocumentContentChangePartial{
+				Range: &protocol.Range{
+					Start: protocol.Position{Line: 0, Character: 1},
+					End:   protocol.Position{Line: 0, Character: 2},
+				},
+				Text: "è",
+			},
+		},
+		{
+			name:    "repeated text",
+			oldText: "aaa",
+			newText: "aaaa",
+			expected: protocol.TextDocumentContentChangePartial{
+				Range: &protocol.Range{
+					Start: protocol.Position{Line: 0, Character: 3},
+					End:   protocol.Position{Line: 0, Character: 3},
+				},
+				Text: "a",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, incrementalChange(tt.oldText, tt.newText))
+		})
+	}
+}
+
+func TestIncrementalChanges(t *testing.T) {
+	oldText := "package main\n\nimport \"fmt\"\n\nfunc a() {\n\tfmt.Println(\"a\")\n}\n\nfunc b() {\n\tfmt.Println(\"b\")\n}\n"
+	tests := []struct {
+		name    string
+		newText string
+		changes int
+	}{
+		{"one edit", strings.Replace(oldText, `"a"`, `"A"`, 1), 1},
+		{"edits far apart", strings.Replace(strings.Replace(oldText, "main", "other", 1), `"b"`, `"B"`, 1), 2},
+		{"append", oldText + "\nfunc c() {}\n", 1},
+		{"no trailing newline", strings.TrimSuffix(oldText, "\n"), 1},
+		{"rewrite", "x", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := incrementalChanges(oldText, tt.newText)
+			assert.Len(t, changes, tt.changes)
+			assert.Equal(t, tt.newText, applyChanges(t, oldText, changes))
+		})
+	}
+}
+
+// applyChanges applies content changes in order, as a server would
+func applyChanges(t *testing.T, text string, changes []protocol.TextDocumentContentChangeEvent) string {
+	for _, change := range changes {
+		switch value := change.Value.(type) {
+		case protocol.TextDocumentContentChangeWholeDocument:
+			text = value.Text
+		case protocol.TextDocumentContentChangePartial:
+			start, end := offsetAt(text, value.Range.Start), offsetAt(text, value.Range.End)
+			text = text[:start] + value.Text + text[end:]
+		default:
+			t.Fatalf("unexpected change %T", value)
+		}
+	}
+	return text
+}
+
+// offsetAt converts an LSP position in ASCII text to a byte offset
+func offsetAt(text string, position protocol.Position) int {
+	offset := 0
+	for line := uint32(0); line < position.Line; line++ {
+		offset += strings.Index(text[offset:], "\n") + 1
+	}
+	return offset + int(position.Character)
+}
+
+func TestChangeDocumentSyncNone(t *testing.T) {
+	stdin := &nopWriteCloser{}
+	uri := protocol.DocumentUri("file:///tmp/a.go")
+	c := &Client{
+		stdin:            stdin,
+		initializeResult: &protocol.InitializeResult{Capabilities: protocol.ServerCapabilities{TextDocumentSync: float64(protocol.None)}},
+		openFiles:        map[string]*OpenFileInfo{string(uri): {Version: 1, URI: uri, content: "a"}},
+	}
+
+	assert.NoError(t, c.ChangeDocument(context.Background(), uri, "b"))
+	assert.Zero(t, stdin.Len(), "no didChange is sent")
+	content, _ := c.DocumentContent(uri)
+	assert.Equal(t, "b", content)
+}
@@ -0,0 +1,96 @@
+package lsp
+
+import (
+	"context"
+)
+
+// ConcurrencyLimits bounds the number of requests in flight to the language
+// server. A limit of zero or less means unlimited.
+type ConcurrencyLimits struct {
+	// Global bounds the total number of outstanding requests
+	Global int
+	// PerMethod bounds the number of outstanding requests for each LSP method
+	PerMethod map[string]int
+}
+
+// DefaultConcurrencyLimits are applied to new clients. Servers such as gopls
+// degrade badly with more than a handful of parallel reference searches.
+var DefaultConcurrencyLimits = ConcurrencyLimits{
+	Global: 16,
+	PerMethod: map[string]int{
+		"textDocument/references": 8,
+	},
+}
+
+// requestLimiter implements ConcurrencyLimits with counting semaphores
+type requestLimiter struct {
+	global    chan struct{}
+	perMethod map[string]chan struct{}
+}
+
+func newRequestLimiter(limits ConcurrencyLimits) *requestLimiter {
+	l := &requestLimiter{
+		perMethod: make(map[string]chan struct{}),
+	}
+	if limits.Global > 0 {
+		l.global = make(chan struct{}, limits.Global)
+	}
+	for method, limit := range limits.PerMethod {
+		if limit > 0 {
+			l.perMethod[method] = make(chan struct{}, limit)
+		}
+	}
+	return l
+}
+
+// acquire waits for a slot for the given method. The returned function must be
+// called to release the slot once the request has completed.
+func (l *requestLimiter) acquire(ctx context.Context, method string) (func(), error) {
+	// Take the per-method slot first so that requests queued behind a busy
+	// method do not hold global slots needed by other methods
+	methodSem := l.perMethod[method]
+	if err := acquireSlot(ctx, methodSem); err != nil {
+		return nil, err
+	}
+	if err := acquireSlot(ctx, l.global); err != nil {
+		releaseSlot(methodSem)
+		return nil, err
+	}
+
+	return func() {
+		releaseSlot(l.global)
+		releaseSlot(methodSem)
+	}, nil
+}
+
+func acquireSlot(ctx context.Context, sem chan struct{}) error {
+	if sem == nil {
+		return nil
+	}
+	select {
+	case sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func releaseSlot(sem chan struct{}) {
+	if sem != nil {
+		<-sem
+	}
+}
+
+// SetConcurrencyLimits replaces the concurrency limits for outgoing requests.
+// Requests already waiting for a slot keep using the previous limits.
+func (c *Client) SetConcurrencyLimits(limits ConcurrencyLimits) {
+	c.limiterMu.Lock()
+	defer c.limiterMu.Unlock()
+	c.limiter = newRequestLimiter(limits)
+}
+
+func (c *Client) requestLimiter() *requestLimiter {
+	c.limiterMu.RLock()
+	defer c.limiterMu.RUnlock()
+	return c.limiter
+}
@@ -0,0 +1,65 @@
+package lsp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestLimiterPerMethod(t *testing.T) {
+	l := newRequestLimiter(ConcurrencyLimits{
+		PerMethod: map[string]int{"textDocument/references": 1},
+	})
+
+	release, err := l.acquire(context.Background(), "textDocument/references")
+	assert.NoError(t, err)
+
+	// Other methods are not limited
+	releaseHover, err := l.acquire(context.Background(), "textDocument/hover")
+	assert.NoError(t, err)
+	releaseHover()
+
+	// A second request for the same method waits until the context expires
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = l.acquire(ctx, "textDocument/references")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release()
+	release, err = l.acquire(context.Background(), "textDocument/references")
+	assert.NoError(t, err)
+	release()
+}
+
+func TestRequestLimiterGlobal(t *testing.T) {
+	l := newRequestLimiter(ConcurrencyLimits{
+		Global:    1,
+		PerMethod: map[string]int{"textDocument/references": 2},
+	})
+
+	release, err := l.acquire(context.Background(), "textDocument/hover")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = l.acquire(ctx, "textDocument/references")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The per-method slot taken by the failed request was released
+	release()
+	for range 2 {
+		release, err = l.acquire(context.Background(), "textDocument/references")
+		assert.NoError(t, err)
+		release()
+	}
+}
+
+func TestRequestLimiterUnlimited(t *testing.T) {
+	l := newRequestLimiter(ConcurrencyLimits{})
+	for range 100 {
+		_, err := l.acquire(context.Background(), "textDocument/references")
+		assert.NoError(t, err)
+	}
+}
@@ -0,0 +1,89 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// documentLock returns the lock serializing changes to a document with
+// overlays of it
+func (c *Client) documentLock(uri protocol.DocumentUri) *sync.Mutex {
+	c.openFilesMu.Lock()
+	defer c.openFilesMu.Unlock()
+	if c.documentLocks == nil {
+		c.documentLocks = make(map[string]*sync.Mutex)
+	}
+	lock, ok := c.documentLocks[string(uri)]
+	if !ok {
+		lock = &sync.Mutex{}
+		c.documentLocks[string(uri)] = lock
+	}
+	return lock
+}
+
+// WithOverlay lets the server analyze other content in place of an open
+// document while fn runs, e.g. the document at a past revision, and then
+// restores its content. Meanwhile changes to the document, including those
+// of tools writing the file, wait for the overlay to be removed so that the
+// restore does not undo them, tools opening the document wait so that they
+// do not query the overlay, and reading the document returns its actual
+// content. Diagnostics published for the overlay are dropped.
+func (c *Client) WithOverlay(ctx context.Context, uri protocol.DocumentUri, content string, fn func() error) error {
+	lock := c.documentLock(uri)
+	lock.Lock()
+	defer lock.Unlock()
+
+	c.openFilesMu.Lock()
+	fileInfo, ok := c.openFiles[string(uri)]
+	if !ok {
+		c.openFilesMu.Unlock()
+		return fmt.Errorf("cannot overlay unopened document: %s", uri)
+	}
+	current := fileInfo.content
+	fileInfo.overlaid = true
+	fileInfo.underlay = current
+	c.openFilesMu.Unlock()
+
+	defer func() {
+		// Restore the document even if the query was cancelled
+		if err := c.changeDocument(context.WithoutCancel(ctx), uri, current); err != nil {
+			lspLogger.Error("Error restoring document %s: %v", uri, err)
+		}
+		c.openFilesMu.Lock()
+		fileInfo.overlaid = false
+		fileInfo.underlay = ""
+		c.openFilesMu.Unlock()
+	}()
+
+	if err := c.changeDocument(ctx, uri, content); err != nil {
+		return fmt.Errorf("failed to load overlay: %w", err)
+	}
+	c.openFilesMu.Lock()
+	fileInfo.overlayVersion = fileInfo.Version
+	c.openFilesMu.Unlock()
+
+	return fn()
+}
+
+// waitForOverlay waits until a document is not overlaid, by taking and
+// releasing its lock
+func (c *Client) waitForOverlay(uri protocol.DocumentUri) {
+	lock := c.documentLock(uri)
+	lock.Lock()
+	defer lock.Unlock()
+}
+
+// isOverlayDiagnostics reports whether published diagnostics are for the
+// overlay of a document rather than its content
+func (c *Client) isOverlayDiagnostics(params protocol.PublishDiagnosticsParams) bool {
+	c.openFilesMu.RLock()
+	defer c.openFilesMu.RUnlock()
+	fileInfo, ok := c.openFiles[string(params.URI)]
+	if !ok {
+		return false
+	}
+	return fileInfo.overlaid || (params.Version != 0 && params.Version == fileInfo.overlayVersion)
+}
@@ -0,0 +1,62 @@
+package lsp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithOverlay(t *testing.T) {
+	uri := protocol.DocumentUri("file:///tmp/a.go")
+	c := &Client{
+		stdin:       &nopWriteCloser{},
+		forensics:   newForensics(),
+		openFiles:   map[string]*OpenFileInfo{string(uri): {Version: 1, URI: uri, content: "current"}},
+		diagnostics: make(map[protocol.DocumentUri][]protocol.Diagnostic),
+	}
+	ctx := context.Background()
+
+	changed := make(chan error, 1)
+	err := c.WithOverlay(ctx, uri, "historical", func() error {
+		c.openFilesMu.RLock()
+		assert.Equal(t, "historical", c.openFiles[string(uri)].content, "the server has the overlay")
+		c.openFilesMu.RUnlock()
+		doc, _ := c.Document(uri)
+		assert.Equal(t, "current", doc.Text, "readers see the actual content")
+
+		// Diagnostics for the overlay are not cached
+		c.storePublishedDiagnostics(protocol.PublishDiagnosticsParams{URI: uri, Diagnostics: []protocol.Diagnostic{{Message: "old"}}})
+		assert.Empty(t, c.GetFileDiagnostics(uri))
+
+		// A change made during the overlay waits for it
+		go func() { changed <- c.ChangeDocument(ctx, uri, "edited") }()
+		select {
+		case <-changed:
+			t.Fatal("the change did not wait for the overlay")
+		case <-time.After(20 * time.Millisecond):
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, <-changed)
+
+	doc, _ := c.Document(uri)
+	assert.Equal(t, "edited", doc.Text, "the change is not undone by the restore")
+	assert.Equal(t, int32(4), doc.Version)
+
+	// Late diagnostics for the overlay version are dropped too
+	c.storePublishedDiagnostics(protocol.PublishDiagnosticsParams{URI: uri, Version: 2, Diagnostics: []protocol.Diagnostic{{Message: "old"}}})
+	assert.Empty(t, c.GetFileDiagnostics(uri))
+	c.storePublishedDiagnostics(protocol.PublishDiagnosticsParams{URI: uri, Version: 4, Diagnostics: []protocol.Diagnostic{{Message: "new"}}})
+	assert.Len(t, c.GetFileDiagnostics(uri), 1)
+}
+
+func TestWithOverlayUnopened(t *testing.T) {
+	c := &Client{openFiles: map[string]*OpenFileInfo{}}
+	err := c.WithOverlay(context.Background(), "file:///tmp/a.go", "x", func() error { return nil })
+	assert.ErrorContains(t, err, "cannot overlay unopened document")
+}
@@ -0,0 +1,26 @@
+package lsp
+
+// Progress is a work done progress report from the server, such as a step of
+// indexing the workspace
+type Progress struct {
+	// Token identifies the work the report is about, as raw JSON
+	Token string
+	// Kind is "begin", "report" or "end"
+	Kind string
+	// Title describes the work, as given when it began
+	Title   string
+	Message string
+	// Percentage is from 0 to 100, nil if the server cannot tell
+	Percentage *uint32
+}
+
+// ProgressHandler is called with each work done progress report
+type ProgressHandler func(progress Progress)
+
+// SetProgressHandler sets the function called with the work done progress
+// the server reports
+func (c *Client) SetProgressHandler(handler ProgressHandler) {
+	c.progressMu.Lock()
+	defer c.progressMu.Unlock()
+	c.progressHandler = handler
+}
@@ -0,0 +1,31 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressHandler(t *testing.T) {
+	c := &Client{}
+	var reports []Progress
+	c.SetProgressHandler(func(progress Progress) { reports = append(reports, progress) })
+
+	HandleProgress(c, json.RawMessage(`{"token": "index-1", "value": {"kind": "begin", "title": "Indexing", "percentage": 0}}`))
+	HandleProgress(c, json.RawMessage(`{"token": "index-1", "value": {"kind": "report", "message": "crate foo", "percentage": 50}}`))
+	HandleProgress(c, json.RawMessage(`{"token": "index-1", "value": {"kind": "end"}}`))
+
+	if assert.Len(t, reports, 3) {
+		// Reports after the beginning carry its title
+		assert.Equal(t, "Indexing", reports[1].Title)
+		assert.Equal(t, "crate foo", reports[1].Message)
+		if assert.NotNil(t, reports[1].Percentage) {
+			assert.Equal(t, uint32(50), *reports[1].Percentage)
+		}
+		assert.Equal(t, `"index-1"`, reports[2].Token)
+		assert.Equal(t, "end", reports[2].Kind)
+		assert.Equal(t, "Indexing", reports[2].Title)
+	}
+	assert.False(t, c.analyzing())
+}
@@ -0,0 +1,109 @@
+package lsp
+
+import (
+	"context"
+	"time"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// diagnosticPublication records the latest textDocument/publishDiagnostics
+// notification for a document
+type diagnosticPublication struct {
+	// count is the number of notifications received for the document
+	count int
+	// version is the document version the diagnostics are for, or 0 if the
+	// server did not say
+	version int32
+}
+
+// DiagnosticsHandler is called when the server publishes diagnostics for a
+// document, with the diagnostics it had published before
+type DiagnosticsHandler func(uri protocol.DocumentUri, before, after []protocol.Diagnostic)
+
+// SetDiagnosticsHandler sets the function called on each publication of
+// diagnostics
+func (c *Client) SetDiagnosticsHandler(handler DiagnosticsHandler) {
+	c.diagnosticsMu.Lock()
+	defer c.diagnosticsMu.Unlock()
+	c.diagnosticsHandler = handler
+}
+
+// storePublishedDiagnostics replaces the cached diagnostics of a document
+// with those published by the server and wakes WaitForDiagnostics
+func (c *Client) storePublishedDiagnostics(params protocol.PublishDiagnosticsParams) {
+	if c.isOverlayDiagnostics(params) {
+		lspLogger.Debug("Dropping diagnostics for the overlay of %s", params.URI)
+		return
+	}
+	c.diagnosticsMu.Lock()
+	before := c.diagnostics[params.URI]
+	handler := c.diagnosticsHandler
+	defer func() {
+		c.diagnosticsMu.Unlock()
+		if handler != nil {
+			handler(params.URI, before, params.Diagnostics)
+		}
+	}()
+
+	c.diagnostics[params.URI] = params.Diagnostics
+	if c.publications == nil {
+		c.publications = make(map[protocol.DocumentUri]diagnosticPublication)
+	}
+	publication := c.publications[params.URI]
+	publication.count++
+	publication.version = params.Version
+	c.publications[params.URI] = publication
+	c.lastPublication = time.Now()
+
+	if c.diagnosticsUpdated != nil {
+		close(c.diagnosticsUpdated)
+		c.diagnosticsUpdated = nil
+	}
+}
+
+// WaitForDiagnostics waits until the server has published diagnostics for
+// the open version of a document, so that GetFileDiagnostics reflects the
+// latest edit. Servers that do not say which version their diagnostics are
+// for are waited on until they next publish diagnostics for the document.
+// It reports false if the timeout passed or the context ended first.
+func (c *Client) WaitForDiagnostics(ctx context.Context, uri protocol.DocumentUri, timeout time.Duration) bool {
+	version := int32(0)
+	c.openFilesMu.RLock()
+	if fileInfo, ok := c.openFiles[string(uri)]; ok {
+		version = fileInfo.Version
+	}
+	c.openFilesMu.RUnlock()
+
+	c.diagnosticsMu.RLock()
+	start := c.publications[uri].count
+	c.diagnosticsMu.RUnlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		c.diagnosticsMu.Lock()
+		publication := c.publications[uri]
+		if publication.version != 0 && version != 0 && publication.version >= version {
+			c.diagnosticsMu.Unlock()
+			return true
+		}
+		if publication.version == 0 && publication.count > start {
+			c.diagnosticsMu.Unlock()
+			return true
+		}
+		if c.diagnosticsUpdated == nil {
+			c.diagnosticsUpdated = make(chan struct{})
+		}
+		updated := c.diagnosticsUpdated
+		c.diagnosticsMu.Unlock()
+
+		select {
+		case <-updated:
+		case <-timer.C:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package lsp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitForDiagnostics(t *testing.T) {
+	uri := protocol.DocumentUri("file:///src/main.go")
+	c := &Client{
+		diagnostics: make(map[protocol.DocumentUri][]protocol.Diagnostic),
+		openFiles: map[string]*OpenFileInfo{
+			string(uri): {Version: 2, URI: uri},
+		},
+	}
+	ctx := context.Background()
+
+	// Diagnostics for an older version do not count
+	c.storePublishedDiagnostics(protocol.PublishDiagnosticsParams{URI: uri, Version: 1})
+	assert.False(t, c.WaitForDiagnostics(ctx, uri, 10*time.Millisecond))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		c.storePublishedDiagnostics(protocol.PublishDiagnosticsParams{
+			URI:         uri,
+			Version:     2,
+			Diagnostics: []protocol.Diagnostic{{Message: "unused"}},
+		})
+	}()
+	assert.True(t, c.WaitForDiagnostics(ctx, uri, time.Second))
+	assert.Len(t, c.GetFileDiagnostics(uri), 1)
+
+	// Diagnostics for the open version are returned without waiting
+	assert.True(t, c.WaitForDiagnostics(ctx, uri, 0))
+
+	// Without versions, the next publication is waited for
+	unversioned := protocol.DocumentUri("file:///src/util.go")
+	c.storePublishedDiagnostics(protocol.PublishDiagnosticsParams{URI: unversioned})
+	assert.False(t, c.WaitForDiagnostics(ctx, unversioned, 10*time.Millisecond))
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		c.storePublishedDiagnostics(protocol.PublishDiagnosticsParams{URI: unversioned})
+	}()
+	assert.True(t, c.WaitForDiagnostics(ctx, unversioned, time.Second))
+}
+
+func TestDiagnosticsHandler(t *testing.T) {
+	uri := protocol.DocumentUri("file:///src/main.go")
+	c := &Client{diagnostics: make(map[protocol.DocumentUri][]protocol.Diagnostic)}
+
+	var calls [][2]int
+	c.SetDiagnosticsHandler(func(got protocol.DocumentUri, before, after []protocol.Diagnostic) {
+		assert.Equal(t, uri, got)
+		// The cache is not locked while the handler runs
+		assert.Equal(t, after, c.GetFileDiagnostics(uri))
+		calls = append(calls, [2]int{len(before), len(after)})
+	})
+
+	c.storePublishedDiagnostics(protocol.PublishDiagnosticsParams{URI: uri, Diagnostics: []protocol.Diagnostic{{Message: "unused"}}})
+	c.storePublishedDiagnostics(protocol.PublishDiagnosticsParams{URI: uri})
+	assert.Equal(t, [][2]int{{0, 1}, {1, 0}}, calls)
+}
@@ -0,0 +1,132 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// diagnosticReport is a full or unchanged diagnostic report, as returned by
+// textDocument/diagnostic and, with a URI, by workspace/diagnostic. The
+// protocol union types cannot tell the two kinds apart, so they are decoded
+// by hand.
+type diagnosticReport struct {
+	Kind             string                                    `json:"kind"`
+	ResultID         string                                    `json:"resultId,omitempty"`
+	Items            []protocol.Diagnostic                     `json:"items,omitempty"`
+	RelatedDocuments map[protocol.DocumentUri]diagnosticReport `json:"relatedDocuments,omitempty"`
+	URI              protocol.DocumentUri                      `json:"uri,omitempty"`
+}
+
+// PullDiagnostics requests the diagnostics for a document from servers that
+// support pull diagnostics, updating the diagnostics returned by
+// GetFileDiagnostics. The result ID of the previous pull is sent along, so
+// servers can answer that nothing changed. It reports false if the server
+// does not support pull diagnostics, in which case only diagnostics published
+// by the server are available.
+func (c *Client) PullDiagnostics(ctx context.Context, uri protocol.DocumentUri) (bool, error) {
+	if !c.SupportsMethod("textDocument/diagnostic") {
+		return false, nil
+	}
+
+	c.diagnosticsMu.RLock()
+	previousResultID := c.diagnosticResultIDs[uri]
+	c.diagnosticsMu.RUnlock()
+
+	var report diagnosticReport
+	err := c.Call(ctx, "textDocument/diagnostic", protocol.DocumentDiagnosticParams{
+		TextDocument:     protocol.TextDocumentIdentifier{URI: uri},
+		PreviousResultID: previousResultID,
+	}, &report)
+	if err != nil {
+		return true, fmt.Errorf("failed to pull diagnostics: %w", err)
+	}
+
+	c.diagnosticsMu.Lock()
+	c.storeDiagnosticReport(uri, report)
+	for relatedURI, related := range report.RelatedDocuments {
+		c.storeDiagnosticReport(relatedURI, related)
+	}
+	c.diagnosticsMu.Unlock()
+
+	return true, nil
+}
+
+// PullWorkspaceDiagnostics requests the diagnostics for the whole workspace
+// from servers that support workspace pull diagnostics, updating the
+// diagnostics returned by GetFileDiagnostics. It reports false if the server
+// does not support workspace diagnostics.
+func (c *Client) PullWorkspaceDiagnostics(ctx context.Context) (bool, error) {
+	if !c.supportsWorkspaceDiagnostics() {
+		return false, nil
+	}
+
+	c.diagnosticsMu.RLock()
+	previousResultIDs := make([]protocol.PreviousResultId, 0, len(c.diagnosticResultIDs))
+	for uri, resultID := range c.diagnosticResultIDs {
+		previousResultIDs = append(previousResultIDs, protocol.PreviousResultId{URI: uri, Value: resultID})
+	}
+	c.diagnosticsMu.RUnlock()
+
+	var result struct {
+		Items []diagnosticReport `json:"items"`
+	}
+	err := c.Call(ctx, "workspace/diagnostic", protocol.WorkspaceDiagnosticParams{
+		PreviousResultIds: previousResultIDs,
+	}, &result)
+	if err != nil {
+		return true, fmt.Errorf("failed to pull workspace diagnostics: %w", err)
+	}
+
+	c.diagnosticsMu.Lock()
+	for _, report := range result.Items {
+		c.storeDiagnosticReport(report.URI, report)
+	}
+	c.diagnosticsMu.Unlock()
+
+	return true, nil
+}
+
+// storeDiagnosticReport updates the diagnostics of a document from a pulled
+// report. Unchanged reports keep the diagnostics from the previous pull.
+// The caller must hold diagnosticsMu.
+func (c *Client) storeDiagnosticReport(uri protocol.DocumentUri, report diagnosticReport) {
+	if report.Kind == string(protocol.DiagnosticFull) {
+		c.diagnostics[uri] = report.Items
+	}
+	if report.ResultID != "" {
+		c.diagnosticResultIDs[uri] = report.ResultID
+	} else {
+		delete(c.diagnosticResultIDs, uri)
+	}
+}
+
+// ForgetDiagnosticResults drops the result IDs of previous pulls for a
+// document or, if uri is empty, for every document, so that the next pull
+// returns a full report rather than one saying nothing changed
+func (c *Client) ForgetDiagnosticResults(uri protocol.DocumentUri) {
+	c.diagnosticsMu.Lock()
+	defer c.diagnosticsMu.Unlock()
+	if uri == "" {
+		clear(c.diagnosticResultIDs)
+		return
+	}
+	delete(c.diagnosticResultIDs, uri)
+}
+
+// supportsWorkspaceDiagnostics reports whether the server advertised support
+// for workspace/diagnostic in its diagnostic provider options
+func (c *Client) supportsWorkspaceDiagnostics() bool {
+	result := c.InitializeResult()
+	if result == nil || result.Capabilities.DiagnosticProvider == nil {
+		return false
+	}
+	switch v := result.Capabilities.DiagnosticProvider.Value.(type) {
+	case protocol.DiagnosticOptions:
+		return v.WorkspaceDiagnostics
+	case protocol.DiagnosticRegistrationOptions:
+		return v.WorkspaceDiagnostics
+	}
+	return false
+}
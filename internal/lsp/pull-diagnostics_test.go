@@ -0,0 +1,67 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreDiagnosticReport(t *testing.T) {
+	c := &Client{
+		diagnostics:         make(map[protocol.DocumentUri][]protocol.Diagnostic),
+		diagnosticResultIDs: make(map[protocol.DocumentUri]string),
+	}
+	uri := protocol.DocumentUri("file:///src/main.go")
+	related := protocol.DocumentUri("file:///src/util.go")
+
+	var full diagnosticReport
+	err := json.Unmarshal([]byte(`{
+		"kind": "full",
+		"resultId": "1",
+		"items": [{"range": {"start": {"line": 1, "character": 0}, "end": {"line": 1, "character": 4}}, "message": "unused"}],
+		"relatedDocuments": {
+			"file:///src/util.go": {"kind": "full", "items": []}
+		}
+	}`), &full)
+	assert.NoError(t, err)
+
+	c.storeDiagnosticReport(uri, full)
+	for relatedURI, report := range full.RelatedDocuments {
+		c.storeDiagnosticReport(relatedURI, report)
+	}
+	assert.Len(t, c.diagnostics[uri], 1)
+	assert.Equal(t, "unused", c.diagnostics[uri][0].Message)
+	assert.Equal(t, "1", c.diagnosticResultIDs[uri])
+	assert.Empty(t, c.diagnostics[related])
+	assert.NotContains(t, c.diagnosticResultIDs, related)
+
+	// An unchanged report keeps the previous diagnostics
+	var unchanged diagnosticReport
+	err = json.Unmarshal([]byte(`{"kind": "unchanged", "resultId": "2"}`), &unchanged)
+	assert.NoError(t, err)
+
+	c.storeDiagnosticReport(uri, unchanged)
+	assert.Len(t, c.diagnostics[uri], 1)
+	assert.Equal(t, "2", c.diagnosticResultIDs[uri])
+}
+
+func TestForgetDiagnosticResults(t *testing.T) {
+	main := protocol.DocumentUri("file:///src/main.go")
+	util := protocol.DocumentUri("file:///src/util.go")
+	c := &Client{
+		diagnostics: make(map[protocol.DocumentUri][]protocol.Diagnostic),
+		diagnosticResultIDs: map[protocol.DocumentUri]string{
+			main: "1",
+			util: "2",
+		},
+	}
+
+	c.ForgetDiagnosticResults(main)
+	assert.Equal(t, map[protocol.DocumentUri]string{util: "2"}, c.diagnosticResultIDs)
+
+	_, err := HandleDiagnosticRefresh(c, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, c.diagnosticResultIDs)
+}
@@ -0,0 +1,89 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// quiescencePollInterval is how often WaitForQuiescence checks whether the
+// server has gone quiet
+const quiescencePollInterval = 50 * time.Millisecond
+
+// HandleProgress follows the work done progress the server reports, such as
+// indexing or type checking after an edit, so that tools can wait for it to
+// end with WaitForQuiescence, and passes it on to the progress handler
+func HandleProgress(client *Client, params json.RawMessage) {
+	var progress struct {
+		Token json.RawMessage `json:"token"`
+		Value struct {
+			Kind       string  `json:"kind"`
+			Title      string  `json:"title"`
+			Message    string  `json:"message"`
+			Percentage *uint32 `json:"percentage"`
+		} `json:"value"`
+	}
+	if err := json.Unmarshal(params, &progress); err != nil {
+		lspLogger.Error("Error unmarshaling progress params: %v", err)
+		return
+	}
+
+	token := string(progress.Token)
+	client.progressMu.Lock()
+	if client.activeProgress == nil {
+		client.activeProgress = make(map[string]string)
+	}
+	// Only the beginning of the work carries its title
+	title := client.activeProgress[token]
+	switch progress.Value.Kind {
+	case "begin":
+		title = progress.Value.Title
+		client.activeProgress[token] = title
+	case "end":
+		delete(client.activeProgress, token)
+	}
+	handler := client.progressHandler
+	client.progressMu.Unlock()
+
+	if handler != nil {
+		handler(Progress{
+			Token:      token,
+			Kind:       progress.Value.Kind,
+			Title:      title,
+			Message:    progress.Value.Message,
+			Percentage: progress.Value.Percentage,
+		})
+	}
+}
+
+// analyzing reports whether the server has work done progress in flight
+func (c *Client) analyzing() bool {
+	c.progressMu.Lock()
+	defer c.progressMu.Unlock()
+	return len(c.activeProgress) > 0
+}
+
+// WaitForQuiescence waits until the server has finished re-analyzing the
+// workspace after a change: no work done progress is in flight and no
+// diagnostics have been published for the settle period. It reports false if
+// the timeout passed or the context ended first.
+func (c *Client) WaitForQuiescence(ctx context.Context, settle, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		c.diagnosticsMu.RLock()
+		lastPublished := c.lastPublication
+		c.diagnosticsMu.RUnlock()
+		if !c.analyzing() && time.Since(lastPublished) >= settle {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(quiescencePollInterval):
+		}
+	}
+}
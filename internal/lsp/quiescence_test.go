@@ -0,0 +1,37 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitForQuiescence(t *testing.T) {
+	c := &Client{diagnostics: make(map[protocol.DocumentUri][]protocol.Diagnostic)}
+	ctx := context.Background()
+
+	// An idle server is quiet at once
+	assert.True(t, c.WaitForQuiescence(ctx, 20*time.Millisecond, 0))
+
+	// Progress in flight is waited for
+	HandleProgress(c, json.RawMessage(`{"token": "index-1", "value": {"kind": "begin", "title": "Indexing"}}`))
+	HandleProgress(c, json.RawMessage(`{"token": 7, "value": {"kind": "begin", "title": "Type checking"}}`))
+	assert.False(t, c.WaitForQuiescence(ctx, 0, 60*time.Millisecond))
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		HandleProgress(c, json.RawMessage(`{"token": "index-1", "value": {"kind": "report", "percentage": 50}}`))
+		HandleProgress(c, json.RawMessage(`{"token": "index-1", "value": {"kind": "end"}}`))
+		HandleProgress(c, json.RawMessage(`{"token": 7, "value": {"kind": "end"}}`))
+	}()
+	assert.True(t, c.WaitForQuiescence(ctx, 0, time.Second))
+
+	// Recently published diagnostics must settle
+	c.storePublishedDiagnostics(protocol.PublishDiagnosticsParams{URI: "file:///src/main.go"})
+	assert.False(t, c.WaitForQuiescence(ctx, time.Second, 60*time.Millisecond))
+	assert.True(t, c.WaitForQuiescence(ctx, 20*time.Millisecond, time.Second))
+}
@@ -0,0 +1,82 @@
+package lsp
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// Router picks the language server for a file when several run for the same
+// workspace, by file extension or language ID. Files that no route matches
+// go to the fallback server.
+type Router struct {
+	mu       sync.RWMutex
+	fallback *Client
+	routes   []route
+}
+
+type route struct {
+	name       string
+	client     *Client
+	extensions map[string]bool
+	languages  map[protocol.LanguageKind]bool
+}
+
+// NewRouter creates a router that sends files to fallback until routes are
+// added
+func NewRouter(fallback *Client) *Router {
+	return &Router{fallback: fallback}
+}
+
+// Add routes files with one of the extensions, with or without the leading
+// dot, or whose detected language ID is one of the languages to a client.
+// Routes added first take precedence.
+func (r *Router) Add(name string, client *Client, extensions, languages []string) {
+	rt := route{
+		name:       name,
+		client:     client,
+		extensions: make(map[string]bool, len(extensions)),
+		languages:  make(map[protocol.LanguageKind]bool, len(languages)),
+	}
+	for _, ext := range extensions {
+		rt.extensions["."+strings.TrimPrefix(strings.ToLower(ext), ".")] = true
+	}
+	for _, language := range languages {
+		rt.languages[protocol.LanguageKind(language)] = true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes = append(r.routes, rt)
+}
+
+// ClientFor returns the client for a file
+func (r *Router) ClientFor(path string) *Client {
+	client, _ := r.Route(path)
+	return client
+}
+
+// Route returns the client for a file and the name of its route, which is
+// empty for the fallback server
+func (r *Router) Route(path string) (*Client, string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, rt := range r.routes {
+		if rt.extensions[ext] {
+			return rt.client, rt.name
+		}
+	}
+	if len(r.routes) > 0 {
+		language := DetectLanguageID(path)
+		for _, rt := range r.routes {
+			if rt.languages[language] {
+				return rt.client, rt.name
+			}
+		}
+	}
+	return r.fallback, ""
+}
@@ -0,0 +1,34 @@
+package lsp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouter(t *testing.T) {
+	gopls, tsserver, pyright := &Client{}, &Client{}, &Client{}
+	router := NewRouter(gopls)
+	assert.Same(t, gopls, router.ClientFor("/ws/app.ts"))
+
+	router.Add("typescript", tsserver, []string{"ts", ".TSX"}, []string{"javascript"})
+	router.Add("pyright", pyright, nil, []string{"python"})
+
+	tests := []struct {
+		path   string
+		client *Client
+		name   string
+	}{
+		{"/ws/main.go", gopls, ""},
+		{"/ws/web/app.ts", tsserver, "typescript"},
+		{"/ws/web/App.tsx", tsserver, "typescript"},
+		{"/ws/web/legacy.js", tsserver, "typescript"},
+		{"/ws/scripts/build.py", pyright, "pyright"},
+		{"/ws/README.md", gopls, ""},
+	}
+	for _, tt := range tests {
+		client, name := router.Route(tt.path)
+		assert.Same(t, tt.client, client, tt.path)
+		assert.Equal(t, tt.name, name, tt.path)
+	}
+}
@@ -0,0 +1,125 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/koonwen/mcp-language-server/internal/utilities"
+)
+
+// willSaveWaitUntilTimeout bounds how long a save waits for the server's
+// on-save edits. As in editors, the save goes ahead without them if the
+// server is too slow.
+const willSaveWaitUntilTimeout = 5 * time.Second
+
+// documentSync describes how the server wants documents to be synchronized
+type documentSync struct {
+	change            protocol.TextDocumentSyncKind
+	willSave          bool
+	willSaveWaitUntil bool
+	save              bool
+	saveIncludeText   bool
+}
+
+// documentSyncOptions returns the document synchronization options from the
+// server capabilities
+func (c *Client) documentSyncOptions() documentSync {
+	result := c.InitializeResult()
+	if result == nil {
+		return documentSync{change: protocol.Full}
+	}
+
+	// The capability is either a TextDocumentSyncKind or TextDocumentSyncOptions.
+	// Like VS Code, we send save notifications when only the kind is given.
+	switch sync := result.Capabilities.TextDocumentSync.(type) {
+	case float64:
+		return documentSync{change: protocol.TextDocumentSyncKind(sync), save: true}
+	case map[string]any:
+		options := documentSync{change: protocol.Full}
+		if change, ok := sync["change"].(float64); ok {
+			options.change = protocol.TextDocumentSyncKind(change)
+		}
+		options.willSave, _ = sync["willSave"].(bool)
+		options.willSaveWaitUntil, _ = sync["willSaveWaitUntil"].(bool)
+
+		// Save is either a boolean or SaveOptions
+		switch save := sync["save"].(type) {
+		case bool:
+			options.save = save
+		case map[string]any:
+			options.save = true
+			options.saveIncludeText, _ = save["includeText"].(bool)
+		}
+		return options
+	}
+	return documentSync{change: protocol.Full}
+}
+
+// SaveFile tells the server that a file has been written to disk, as an editor
+// would when saving. If the server asks for textDocument/willSaveWaitUntil,
+// the edits it returns within willSaveWaitUntilTimeout (e.g. formatting or
+// import fixes) are applied to the file first, so on-save behaviour also
+// applies to agent-driven edits. It returns the number of edits applied on
+// save, which the caller reports since they change the file it wrote.
+func (c *Client) SaveFile(ctx context.Context, filepath string) (int, error) {
+	if err := c.OpenFile(ctx, filepath); err != nil {
+		return 0, err
+	}
+	// Make sure the server has the content being saved
+	if err := c.NotifyChange(ctx, filepath); err != nil {
+		return 0, err
+	}
+
+	uri := protocol.DocumentUri("file://" + filepath)
+	sync := c.documentSyncOptions()
+
+	willSaveParams := protocol.WillSaveTextDocumentParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Reason:       protocol.Manual,
+	}
+	if sync.willSave {
+		if err := c.WillSave(ctx, willSaveParams); err != nil {
+			return 0, err
+		}
+	}
+
+	applied := 0
+	if sync.willSaveWaitUntil {
+		waitCtx, cancel := context.WithTimeout(ctx, willSaveWaitUntilTimeout)
+		edits, err := c.WillSaveWaitUntil(waitCtx, willSaveParams)
+		cancel()
+		if err != nil {
+			lspLogger.Error("willSaveWaitUntil failed for %s: %v", filepath, err)
+		} else if len(edits) > 0 {
+			if err := utilities.ApplyTextEdits(uri, edits); err != nil {
+				return 0, fmt.Errorf("failed to apply willSaveWaitUntil edits: %w", err)
+			}
+			applied = len(edits)
+			if err := c.NotifyChange(ctx, filepath); err != nil {
+				return applied, err
+			}
+			lspLogger.Debug("Applied %d willSaveWaitUntil edits to %s", len(edits), filepath)
+		}
+	}
+
+	if !sync.save {
+		return applied, nil
+	}
+
+	params := protocol.DidSaveTextDocumentParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+	}
+	if sync.saveIncludeText {
+		content, err := os.ReadFile(filepath)
+		if err != nil {
+			return applied, fmt.Errorf("error reading file: %w", err)
+		}
+		text := string(content)
+		params.Text = &text
+	}
+
+	return applied, c.DidSave(ctx, params)
+}
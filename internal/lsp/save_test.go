@@ -0,0 +1,94 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentSyncOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		sync     string
+		expected documentSync
+	}{
+		{"missing", `{}`, documentSync{change: protocol.Full}},
+		{"kind only", `{"textDocumentSync": 2}`, documentSync{change: protocol.Incremental, save: true}},
+		{
+			"options with save flag",
+			`{"textDocumentSync": {"change": 1, "save": true, "willSaveWaitUntil": true}}`,
+			documentSync{change: protocol.Full, save: true, willSaveWaitUntil: true},
+		},
+		{
+			"options with save options",
+			`{"textDocumentSync": {"change": 2, "save": {"includeText": true}}}`,
+			documentSync{change: protocol.Incremental, save: true, saveIncludeText: true},
+		},
+		{"options without save", `{"textDocumentSync": {"change": 2}}`, documentSync{change: protocol.Incremental}},
+		{
+			"options with willSave",
+			`{"textDocumentSync": {"change": 2, "willSave": true}}`,
+			documentSync{change: protocol.Incremental, willSave: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var caps protocol.ServerCapabilities
+			assert.NoError(t, json.Unmarshal([]byte(tt.sync), &caps))
+
+			client := &Client{initializeResult: &protocol.InitializeResult{Capabilities: caps}}
+			assert.Equal(t, tt.expected, client.documentSyncOptions())
+		})
+	}
+}
+
+func TestSaveFileReportsWillSaveWaitUntilEdits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.go")
+	require.NoError(t, os.WriteFile(path, []byte("package a\nfunc  f() {}\n"), 0644))
+
+	var caps protocol.ServerCapabilities
+	require.NoError(t, json.Unmarshal([]byte(`{"textDocumentSync": {"change": 1, "willSaveWaitUntil": true}}`), &caps))
+	c := &Client{
+		stdin:            &nopWriteCloser{},
+		handlers:         make(map[string]chan *Message),
+		limiter:          newRequestLimiter(DefaultConcurrencyLimits),
+		forensics:        newForensics(),
+		initializeResult: &protocol.InitializeResult{Capabilities: caps},
+		openFiles:        make(map[string]*OpenFileInfo),
+	}
+
+	// Answer the willSaveWaitUntil request with a formatting edit
+	go func() {
+		edits, _ := json.Marshal([]protocol.TextEdit{{
+			Range:   protocol.Range{Start: protocol.Position{Line: 1, Character: 4}, End: protocol.Position{Line: 1, Character: 6}},
+			NewText: " ",
+		}})
+		for range 200 {
+			c.handlersMu.Lock()
+			for _, ch := range c.handlers {
+				ch <- &Message{Result: edits}
+			}
+			answered := len(c.handlers) > 0
+			c.handlersMu.Unlock()
+			if answered {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+
+	applied, err := c.SaveFile(context.Background(), path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, applied)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "package a\nfunc f() {}\n", string(content))
+}
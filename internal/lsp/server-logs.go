@@ -0,0 +1,55 @@
+package lsp
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// LogEntry is a message the server logged with window/logMessage or traced
+// with $/logTrace
+type LogEntry struct {
+	Time time.Time
+	// Type is the severity of a log message
+	Type protocol.MessageType
+	// Trace is set for $/logTrace messages, which have no severity
+	Trace   bool
+	Message string
+	// Verbose is the additional detail of a trace, if any
+	Verbose string
+}
+
+// HandleLogMessage keeps a window/logMessage notification for ServerLogs
+func HandleLogMessage(client *Client, params json.RawMessage) {
+	var msg protocol.LogMessageParams
+	if err := json.Unmarshal(params, &msg); err != nil {
+		lspLogger.Error("Error unmarshaling log message: %v", err)
+		return
+	}
+	client.addServerLog(LogEntry{Time: time.Now(), Type: msg.Type, Message: msg.Message})
+}
+
+// HandleLogTrace keeps a $/logTrace notification for ServerLogs
+func HandleLogTrace(client *Client, params json.RawMessage) {
+	var msg protocol.LogTraceParams
+	if err := json.Unmarshal(params, &msg); err != nil {
+		lspLogger.Error("Error unmarshaling log trace: %v", err)
+		return
+	}
+	client.addServerLog(LogEntry{Time: time.Now(), Trace: true, Message: msg.Message, Verbose: msg.Verbose})
+}
+
+func (c *Client) addServerLog(entry LogEntry) {
+	c.forensics.mu.Lock()
+	defer c.forensics.mu.Unlock()
+	c.forensics.logs.add(entry)
+}
+
+// ServerLogs returns the most recent log and trace messages of the server,
+// oldest first
+func (c *Client) ServerLogs() []LogEntry {
+	c.forensics.mu.Lock()
+	defer c.forensics.mu.Unlock()
+	return c.forensics.logs.list()
+}
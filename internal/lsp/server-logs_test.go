@@ -0,0 +1,24 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerLogs(t *testing.T) {
+	c := &Client{forensics: newForensics()}
+	HandleLogMessage(c, json.RawMessage(`{"type": 1, "message": "go list failed"}`))
+	HandleLogTrace(c, json.RawMessage(`{"message": "Sending request", "verbose": "params: {}"}`))
+	HandleLogMessage(c, json.RawMessage(`not json`))
+
+	logs := c.ServerLogs()
+	if assert.Len(t, logs, 2) {
+		assert.Equal(t, protocol.Error, logs[0].Type)
+		assert.Equal(t, "go list failed", logs[0].Message)
+		assert.True(t, logs[1].Trace)
+		assert.Equal(t, "params: {}", logs[1].Verbose)
+	}
+}
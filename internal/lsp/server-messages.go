@@ -0,0 +1,86 @@
+package lsp
+
+import (
+	"encoding/json"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// ServerMessage is a message the server asked to show to the user, with
+// window/showMessage, or a prompt with window/showMessageRequest
+type ServerMessage struct {
+	Type    protocol.MessageType
+	Message string
+	// Actions are the titles of the answers offered by a prompt
+	Actions []string
+	// Answer is the title of the action chosen for a prompt, empty if it was
+	// dismissed
+	Answer string
+}
+
+// MessageHandler is called with each message the server shows
+type MessageHandler func(msg ServerMessage)
+
+// MessageRequestPolicy chooses the answer to a prompt of the server, nil to
+// dismiss it
+type MessageRequestPolicy func(params protocol.ShowMessageRequestParams) *protocol.MessageActionItem
+
+// SetMessageHandler sets the function called with the messages and prompts
+// the server shows
+func (c *Client) SetMessageHandler(handler MessageHandler) {
+	c.messagesMu.Lock()
+	defer c.messagesMu.Unlock()
+	c.messageHandler = handler
+}
+
+// SetMessageRequestPolicy sets how prompts of the server are answered. There
+// is no user to ask, so without a policy they are dismissed.
+func (c *Client) SetMessageRequestPolicy(policy MessageRequestPolicy) {
+	c.messagesMu.Lock()
+	defer c.messagesMu.Unlock()
+	c.messageRequestPolicy = policy
+}
+
+func (c *Client) showMessage(msg ServerMessage) {
+	c.messagesMu.RLock()
+	handler := c.messageHandler
+	c.messagesMu.RUnlock()
+	if handler != nil {
+		handler(msg)
+	}
+}
+
+// HandleShowMessageRequest answers a window/showMessageRequest prompt with
+// the action chosen by the message request policy, so that a server waiting
+// for the user is not left waiting forever
+func HandleShowMessageRequest(client *Client, params json.RawMessage) (any, error) {
+	var request protocol.ShowMessageRequestParams
+	if err := json.Unmarshal(params, &request); err != nil {
+		lspLogger.Error("Error unmarshaling message request: %v", err)
+		return nil, err
+	}
+
+	client.messagesMu.RLock()
+	policy := client.messageRequestPolicy
+	client.messagesMu.RUnlock()
+
+	var answer *protocol.MessageActionItem
+	if policy != nil {
+		answer = policy(request)
+	}
+
+	msg := ServerMessage{Type: request.Type, Message: request.Message}
+	for _, action := range request.Actions {
+		msg.Actions = append(msg.Actions, action.Title)
+	}
+	if answer != nil {
+		msg.Answer = answer.Title
+	}
+	lspLogger.Info("Server prompt %q answered with %q", request.Message, msg.Answer)
+	client.showMessage(msg)
+
+	if answer == nil {
+		return nil, nil
+	}
+	return answer, nil
+}
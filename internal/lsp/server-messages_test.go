@@ -0,0 +1,37 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleShowMessageRequest(t *testing.T) {
+	c := &Client{}
+	var shown []ServerMessage
+	c.SetMessageHandler(func(msg ServerMessage) { shown = append(shown, msg) })
+	params := json.RawMessage(`{"type": 2, "message": "Build tags changed, reload?", "actions": [{"title": "Reload"}, {"title": "Ignore"}]}`)
+
+	// Prompts are dismissed without a policy
+	answer, err := HandleShowMessageRequest(c, params)
+	require.NoError(t, err)
+	assert.Nil(t, answer)
+
+	c.SetMessageRequestPolicy(func(params protocol.ShowMessageRequestParams) *protocol.MessageActionItem {
+		return &params.Actions[0]
+	})
+	answer, err = HandleShowMessageRequest(c, params)
+	require.NoError(t, err)
+	assert.Equal(t, &protocol.MessageActionItem{Title: "Reload"}, answer)
+
+	HandleServerMessage(c, json.RawMessage(`{"type": 1, "message": "go list failed"}`))
+
+	assert.Equal(t, []ServerMessage{
+		{Type: protocol.Warning, Message: "Build tags changed, reload?", Actions: []string{"Reload", "Ignore"}},
+		{Type: protocol.Warning, Message: "Build tags changed, reload?", Actions: []string{"Reload", "Ignore"}, Answer: "Reload"},
+		{Type: protocol.Error, Message: "go list failed"},
+	}, shown)
+}
@@ -2,29 +2,136 @@ package lsp
 
 import (
 	"encoding/json"
+	"sort"
 
 	"github.com/koonwen/mcp-language-server/internal/protocol"
 	"github.com/koonwen/mcp-language-server/internal/utilities"
 )
 
-// FileWatchHandler is called when file watchers are registered by the server
+// FileWatchHandler is called when the server registers file watchers, and
+// with nil watchers when it unregisters them
 type FileWatchHandler func(id string, watchers []protocol.FileSystemWatcher)
 
-// fileWatchHandler holds the current file watch handler
-var fileWatchHandler FileWatchHandler
+// AddFileWatchHandler adds a function told about the file watchers the
+// server registers and unregisters, such as the watcher of each workspace
+// folder. It is called right away with those registered before it was
+// added.
+func (c *Client) AddFileWatchHandler(handler FileWatchHandler) {
+	c.fileWatchMu.Lock()
+	defer c.fileWatchMu.Unlock()
+	c.fileWatchHandlers = append(c.fileWatchHandlers, handler)
+	ids := make([]string, 0, len(c.fileWatchers))
+	for id := range c.fileWatchers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		handler(id, c.fileWatchers[id])
+	}
+}
+
+// setFileWatchers records the file watchers of a registration, or removes
+// it if watchers is nil, and tells the file watch handlers
+func (c *Client) setFileWatchers(id string, watchers []protocol.FileSystemWatcher) {
+	c.fileWatchMu.Lock()
+	defer c.fileWatchMu.Unlock()
+	if c.fileWatchers == nil {
+		c.fileWatchers = make(map[string][]protocol.FileSystemWatcher)
+	}
+	if watchers == nil {
+		if _, ok := c.fileWatchers[id]; !ok {
+			return
+		}
+		delete(c.fileWatchers, id)
+	} else {
+		c.fileWatchers[id] = watchers
+	}
+	for _, handler := range c.fileWatchHandlers {
+		handler(id, watchers)
+	}
+}
 
-// RegisterFileWatchHandler registers a handler for file watcher registrations
-func RegisterFileWatchHandler(handler FileWatchHandler) {
-	fileWatchHandler = handler
+// clearFileWatchers removes the file watchers registered by a server that
+// is no longer running
+func (c *Client) clearFileWatchers() {
+	c.fileWatchMu.Lock()
+	ids := make([]string, 0, len(c.fileWatchers))
+	for id := range c.fileWatchers {
+		ids = append(ids, id)
+	}
+	c.fileWatchMu.Unlock()
+	for _, id := range ids {
+		c.setFileWatchers(id, nil)
+	}
 }
 
 // Requests
 
-func HandleWorkspaceConfiguration(params json.RawMessage) (any, error) {
-	return []map[string]any{{}}, nil
+// HandleWorkspaceConfiguration answers a configuration request with the
+// settings of each section asked for, or an empty object for sections that
+// are not set
+func HandleWorkspaceConfiguration(client *Client, params json.RawMessage) (any, error) {
+	var configParams protocol.ConfigurationParams
+	if err := json.Unmarshal(params, &configParams); err != nil {
+		lspLogger.Error("Error unmarshaling configuration params: %v", err)
+		return nil, err
+	}
+
+	results := make([]any, len(configParams.Items))
+	for i, item := range configParams.Items {
+		results[i] = configurationSection(client.settings, item.Section)
+		if results[i] == nil {
+			results[i] = map[string]any{}
+		}
+	}
+	return results, nil
+}
+
+// configurationSection returns the value of a dotted section, such as
+// "python.analysis", in settings that are nested or have dotted keys as in
+// VS Code's settings.json. The whole settings are returned for an empty
+// section.
+func configurationSection(settings map[string]any, section string) any {
+	if section == "" {
+		if len(settings) == 0 {
+			return nil
+		}
+		return settings
+	}
+	if value, ok := settings[section]; ok {
+		return value
+	}
+	for i := len(section) - 1; i > 0; i-- {
+		if section[i] != '.' {
+			continue
+		}
+		if nested, ok := settings[section[:i]].(map[string]any); ok {
+			if value := configurationSection(nested, section[i+1:]); value != nil {
+				return value
+			}
+		}
+	}
+	return nil
+}
+
+// HandleDiagnosticRefresh handles a diagnostic refresh, sent when the server
+// recomputed diagnostics, e.g. after a configuration change. Diagnostics are
+// pulled whenever a tool needs them, so the previous results are only
+// forgotten to make the next pull return a full report.
+func HandleDiagnosticRefresh(client *Client, params json.RawMessage) (any, error) {
+	client.ForgetDiagnosticResults("")
+	return nil, nil
+}
+
+// HandleWorkDoneProgressCreate accepts a progress token. The progress itself
+// is followed by HandleProgress.
+func HandleWorkDoneProgressCreate(params json.RawMessage) (any, error) {
+	return nil, nil
 }
 
-func HandleRegisterCapability(params json.RawMessage) (any, error) {
+// HandleRegisterCapability records the file watchers the server registers.
+// Other registrations are accepted and ignored.
+func HandleRegisterCapability(client *Client, params json.RawMessage) (any, error) {
 	var registerParams protocol.RegistrationParams
 	if err := json.Unmarshal(params, &registerParams); err != nil {
 		lspLogger.Error("Error unmarshaling registration params: %v", err)
@@ -51,9 +158,29 @@ func HandleRegisterCapability(params json.RawMessage) (any, error) {
 			}
 
 			// Notify file watchers
-			if fileWatchHandler != nil {
-				fileWatchHandler(reg.ID, opts.Watchers)
+			if opts.Watchers == nil {
+				opts.Watchers = []protocol.FileSystemWatcher{}
 			}
+			client.setFileWatchers(reg.ID, opts.Watchers)
+		}
+	}
+
+	return nil, nil
+}
+
+// HandleUnregisterCapability removes the file watchers the server
+// unregisters
+func HandleUnregisterCapability(client *Client, params json.RawMessage) (any, error) {
+	var unregisterParams protocol.UnregistrationParams
+	if err := json.Unmarshal(params, &unregisterParams); err != nil {
+		lspLogger.Error("Error unmarshaling unregistration params: %v", err)
+		return nil, err
+	}
+
+	for _, unreg := range unregisterParams.Unregisterations {
+		lspLogger.Info("Unregistration received for method: %s, id: %s", unreg.Method, unreg.ID)
+		if unreg.Method == "workspace/didChangeWatchedFiles" {
+			client.setFileWatchers(unreg.ID, nil)
 		}
 	}
 
@@ -90,13 +217,15 @@ func workspaceEditFailure(err error) string {
 
 // Notifications
 
-// HandleServerMessage processes window/showMessage notifications from the server
-func HandleServerMessage(params json.RawMessage) {
+// HandleServerMessage processes window/showMessage notifications from the
+// server, which are logged and passed on to the message handler
+func HandleServerMessage(client *Client, params json.RawMessage) {
 	var msg protocol.ShowMessageParams
 	if err := json.Unmarshal(params, &msg); err != nil {
 		lspLogger.Error("Error unmarshaling server message: %v", err)
 		return
 	}
+	defer client.showMessage(ServerMessage{Type: msg.Type, Message: msg.Message})
 
 	// Log the message with appropriate level
 	switch msg.Type {
@@ -119,10 +248,7 @@ func HandleDiagnostics(client *Client, params json.RawMessage) {
 		return
 	}
 
-	// Save diagnostics in client
-	client.diagnosticsMu.Lock()
-	client.diagnostics[diagParams.URI] = diagParams.Diagnostics
-	client.diagnosticsMu.Unlock()
+	client.storePublishedDiagnostics(diagParams)
 
 	lspLogger.Info("Received diagnostics for %s: %d items", diagParams.URI, len(diagParams.Diagnostics))
 }
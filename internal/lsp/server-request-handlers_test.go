@@ -0,0 +1,66 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleWorkspaceConfiguration(t *testing.T) {
+	c := &Client{}
+	c.SetSettings(map[string]any{
+		"python": map[string]any{
+			"venvPath": ".",
+			"analysis": map[string]any{"typeCheckingMode": "strict"},
+		},
+		"rust-analyzer.cargo": map[string]any{"features": []any{"full"}},
+	})
+
+	params := json.RawMessage(`{"items": [
+		{"section": "python"},
+		{"section": "python.analysis"},
+		{"section": "python.venvPath"},
+		{"section": "rust-analyzer.cargo"},
+		{"section": "gopls"}
+	]}`)
+	result, err := HandleWorkspaceConfiguration(c, params)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{
+		c.settings["python"],
+		map[string]any{"typeCheckingMode": "strict"},
+		".",
+		map[string]any{"features": []any{"full"}},
+		map[string]any{},
+	}, result)
+
+	// Without settings every section is empty, one result per item
+	result, err = HandleWorkspaceConfiguration(&Client{}, json.RawMessage(`{"items": [{}, {"section": "gopls"}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []any{map[string]any{}, map[string]any{}}, result)
+}
+
+func TestFileWatchRegistrations(t *testing.T) {
+	c := &Client{}
+	register := json.RawMessage(`{"registrations": [{"id": "w1", "method": "workspace/didChangeWatchedFiles", "registerOptions": {"watchers": [{"globPattern": "**/*.go"}]}}]}`)
+	_, err := HandleRegisterCapability(c, register)
+	require.NoError(t, err)
+
+	// Watchers registered before the handler is set are replayed to it
+	got := make(map[string]int)
+	c.AddFileWatchHandler(func(id string, watchers []protocol.FileSystemWatcher) {
+		if watchers == nil {
+			got[id] = -1
+			return
+		}
+		got[id] = len(watchers)
+	})
+	assert.Equal(t, map[string]int{"w1": 1}, got)
+
+	unregister := json.RawMessage(`{"unregisterations": [{"id": "w1", "method": "workspace/didChangeWatchedFiles"}, {"id": "other", "method": "textDocument/formatting"}]}`)
+	_, err = HandleUnregisterCapability(c, unregister)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"w1": -1}, got)
+}
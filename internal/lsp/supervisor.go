@@ -0,0 +1,158 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+const (
+	// restartBackoff is how long to wait before the first restart of a
+	// crashed server. It doubles with each recent restart.
+	restartBackoff = time.Second
+	// restartTimeout bounds starting and initializing the server again
+	restartTimeout = time.Minute
+)
+
+// ErrServerNotRunning is returned for requests made while the language
+// server is not running, such as between a crash and its restart
+var ErrServerNotRunning = errors.New("the language server is not running, it may be restarting after a crash")
+
+// RestartPolicy limits how often a crashed server is restarted, so that a
+// server that crashes on startup is not restarted forever
+type RestartPolicy struct {
+	// MaxRestarts is the number of restarts allowed within Window. Zero
+	// disables restarts.
+	MaxRestarts int
+	Window      time.Duration
+}
+
+// DefaultRestartPolicy is applied to new clients
+var DefaultRestartPolicy = RestartPolicy{MaxRestarts: 3, Window: 5 * time.Minute}
+
+// RestartHandler is called after an attempt to restart a crashed server,
+// with the error if the server could not be restarted
+type RestartHandler func(err error)
+
+// SetRestartPolicy sets how often the server is restarted after a crash and
+// the function called after each attempt. Without a policy, or with
+// MaxRestarts of zero, a crashed server stays down.
+func (c *Client) SetRestartPolicy(policy RestartPolicy, handler RestartHandler) {
+	c.restartMu.Lock()
+	defer c.restartMu.Unlock()
+	c.restartPolicy = policy
+	c.restartHandler = handler
+}
+
+// scheduleRestart restarts the server after a crash, after a delay that
+// grows with the number of recent restarts, unless it has been restarted
+// too often
+func (c *Client) scheduleRestart() {
+	c.restartMu.Lock()
+	policy := c.restartPolicy
+	handler := c.restartHandler
+	if policy.MaxRestarts <= 0 {
+		c.restartMu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	var recent []time.Time
+	for _, t := range c.restarts {
+		if now.Sub(t) < policy.Window {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= policy.MaxRestarts {
+		c.restarts = recent
+		c.restartMu.Unlock()
+		err := fmt.Errorf("not restarting the language server, it crashed %d times within %s", len(recent)+1, policy.Window)
+		lspLogger.Error("%v", err)
+		if handler != nil {
+			handler(err)
+		}
+		return
+	}
+	c.restarts = append(recent, now)
+	c.restartMu.Unlock()
+
+	delay := restartBackoff << len(recent)
+	go func() {
+		time.Sleep(delay)
+		ctx, cancel := context.WithTimeout(context.Background(), restartTimeout)
+		defer cancel()
+		err := c.Restart(ctx)
+		if err != nil {
+			lspLogger.Error("Failed to restart language server: %v", err)
+		} else {
+			lspLogger.Info("Restarted language server")
+		}
+		if handler != nil {
+			handler(err)
+		}
+	}()
+}
+
+// Restart starts the server again after it exited, initializes it with the
+// same options and reopens the documents that were open: files as they are
+// on disk and other documents with the content last sent to the server
+func (c *Client) Restart(ctx context.Context) error {
+	if c.shuttingDown.Load() {
+		return errors.New("the language server is shutting down")
+	}
+
+	// The process may still be running if only the connection broke
+	cmd, _ := c.process()
+	if cmd != nil && cmd.Process != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}
+
+	// The new server knows nothing of the documents, result IDs or progress
+	// of the old one
+	c.openFilesMu.Lock()
+	documents := make(map[string]string, len(c.openFiles))
+	for uri, info := range c.openFiles {
+		documents[uri] = info.content
+	}
+	c.openFiles = make(map[string]*OpenFileInfo)
+	c.openFilesMu.Unlock()
+
+	c.diagnosticsMu.Lock()
+	c.diagnosticResultIDs = make(map[protocol.DocumentUri]string)
+	c.diagnosticsMu.Unlock()
+
+	c.progressMu.Lock()
+	c.activeProgress = nil
+	c.progressMu.Unlock()
+
+	c.initializeResultMu.Lock()
+	c.initializeResult = nil
+	c.initializeResultMu.Unlock()
+
+	// The new server registers the file watchers it needs again
+	c.clearFileWatchers()
+
+	if err := c.start(); err != nil {
+		return err
+	}
+	if _, err := c.InitializeLSPClient(ctx, c.workspaceDir); err != nil {
+		return err
+	}
+
+	uris := make([]string, 0, len(documents))
+	for uri := range documents {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	for _, uri := range uris {
+		if err := c.OpenDocument(ctx, protocol.DocumentUri(uri), documents[uri]); err != nil {
+			lspLogger.Error("Failed to reopen %s after restart: %v", uri, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleRestart(t *testing.T) {
+	var outcomes []error
+	handler := func(err error) { outcomes = append(outcomes, err) }
+
+	// Restarts are disabled without a policy
+	c := &Client{}
+	c.SetRestartPolicy(RestartPolicy{}, handler)
+	c.scheduleRestart()
+	assert.Empty(t, outcomes)
+
+	// A server that crashed too often within the window is left down
+	c.SetRestartPolicy(RestartPolicy{MaxRestarts: 2, Window: time.Minute}, handler)
+	c.restarts = []time.Time{time.Now().Add(-2 * time.Minute), time.Now().Add(-time.Second), time.Now()}
+	c.scheduleRestart()
+	if assert.Len(t, outcomes, 1) {
+		assert.ErrorContains(t, outcomes[0], "crashed 3 times within 1m0s")
+	}
+	assert.Len(t, c.restarts, 2, "restarts outside the window are forgotten")
+}
+
+func TestCallAfterServerExit(t *testing.T) {
+	c := &Client{
+		handlers:  make(map[string]chan *Message),
+		openFiles: make(map[string]*OpenFileInfo),
+		limiter:   newRequestLimiter(DefaultConcurrencyLimits),
+		forensics: newForensics(),
+	}
+	close(c.forensics.stderrDone)
+	c.handleServerExit(errors.New("EOF"))
+
+	// Requests fail until the server is restarted rather than hang
+	err := c.Call(context.Background(), "textDocument/hover", nil, nil)
+	assert.ErrorIs(t, err, ErrServerNotRunning)
+}
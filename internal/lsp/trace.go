@@ -0,0 +1,106 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceEntry is a single JSON-RPC message captured by a Trace
+type TraceEntry struct {
+	Time time.Time
+	// Sent is true for messages sent to the server and false for messages received from it
+	Sent    bool
+	Message json.RawMessage
+}
+
+// Trace captures the JSON-RPC messages exchanged with the server while it is active
+type Trace struct {
+	start   time.Time
+	entries []TraceEntry
+	mu      sync.Mutex
+}
+
+// StartTrace starts capturing JSON-RPC messages. Call StopTrace to stop capturing.
+func (c *Client) StartTrace() *Trace {
+	trace := &Trace{start: time.Now()}
+
+	c.tracesMu.Lock()
+	c.traces = append(c.traces, trace)
+	c.tracesMu.Unlock()
+
+	return trace
+}
+
+// StopTrace stops capturing messages for the given trace
+func (c *Client) StopTrace(trace *Trace) {
+	c.tracesMu.Lock()
+	defer c.tracesMu.Unlock()
+
+	for i, t := range c.traces {
+		if t == trace {
+			c.traces = append(c.traces[:i], c.traces[i+1:]...)
+			return
+		}
+	}
+}
+
+// recordTrace keeps a message for crash reports and adds it to all active traces
+func (c *Client) recordTrace(sent bool, msg *Message) {
+	c.forensics.addMessage(sent, msg)
+	if sent && (msg.Method == "shutdown" || msg.Method == "exit") {
+		c.shuttingDown.Store(true)
+	}
+
+	c.tracesMu.RLock()
+	defer c.tracesMu.RUnlock()
+
+	if len(c.traces) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		lspLogger.Error("Failed to marshal message for trace: %v", err)
+		return
+	}
+
+	entry := TraceEntry{
+		Time:    time.Now(),
+		Sent:    sent,
+		Message: data,
+	}
+	for _, trace := range c.traces {
+		trace.mu.Lock()
+		trace.entries = append(trace.entries, entry)
+		trace.mu.Unlock()
+	}
+}
+
+// Entries returns the messages captured so far
+func (t *Trace) Entries() []TraceEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entries := make([]TraceEntry, len(t.entries))
+	copy(entries, t.entries)
+	return entries
+}
+
+// String formats the trace with one message per line and times relative to the start of the trace
+func (t *Trace) String() string {
+	var result strings.Builder
+	for _, entry := range t.Entries() {
+		direction := "<-"
+		if entry.Sent {
+			direction = "->"
+		}
+		result.WriteString(fmt.Sprintf("[+%dms] %s %s\n",
+			entry.Time.Sub(t.start).Milliseconds(),
+			direction,
+			string(entry.Message)))
+	}
+	return result.String()
+}
@@ -6,9 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"runtime/debug"
 	"strings"
+	"time"
 
 	"github.com/koonwen/mcp-language-server/internal/logging"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
 )
 
 // Create component-specific loggers
@@ -94,10 +97,11 @@ func ReadMessage(r *bufio.Reader) (*Message, error) {
 	return &msg, nil
 }
 
-// handleMessages reads and dispatches messages in a loop
-func (c *Client) handleMessages() {
+// handleMessages reads and dispatches messages from the server's stdout in a
+// loop until the connection is closed
+func (c *Client) handleMessages(stdout *bufio.Reader) {
 	for {
-		msg, err := ReadMessage(c.stdout)
+		msg, err := ReadMessage(stdout)
 		if err != nil {
 			// Check if this is due to normal shutdown (EOF when closing connection)
 			if strings.Contains(err.Error(), "EOF") {
@@ -105,9 +109,12 @@ func (c *Client) handleMessages() {
 			} else {
 				lspLogger.Error("Error reading message: %v", err)
 			}
+			c.handleServerExit(err)
 			return
 		}
 
+		c.recordTrace(false, msg)
+
 		// Handle server->client request (has both Method and ID)
 		if msg.Method != "" && msg.ID != nil && msg.ID.Value != nil {
 			response := &Message{
@@ -122,7 +129,7 @@ func (c *Client) handleMessages() {
 
 			if ok {
 				lspLogger.Debug("Processing server request: method=%s id=%v", msg.Method, msg.ID)
-				result, err := handler(msg.Params)
+				result, err := callServerRequestHandler(msg.Method, handler, msg.Params)
 				if err != nil {
 					lspLogger.Error("Error handling server request %s: %v", msg.Method, err)
 					response.Error = &ResponseError{
@@ -150,9 +157,10 @@ func (c *Client) handleMessages() {
 			}
 
 			// Send response back to server
-			if err := WriteMessage(c.stdin, response); err != nil {
+			if err := c.write(response); err != nil {
 				lspLogger.Error("Error sending response to server: %v", err)
 			}
+			c.recordTrace(true, response)
 
 			continue
 		}
@@ -165,7 +173,7 @@ func (c *Client) handleMessages() {
 
 			if ok {
 				lspLogger.Debug("Handling notification: %s", msg.Method)
-				go handler(msg.Params)
+				go callNotificationHandler(msg.Method, handler, msg.Params)
 			} else {
 				lspLogger.Debug("No handler for notification: %s", msg.Method)
 			}
@@ -191,8 +199,16 @@ func (c *Client) handleMessages() {
 	}
 }
 
-// Call makes a request and waits for the response
+// Call makes a request and waits for the response. If ctx is done first, the
+// server is told with $/cancelRequest to stop working on the request and the
+// context's error is returned.
 func (c *Client) Call(ctx context.Context, method string, params any, result any) error {
+	release, err := c.requestLimiter().acquire(ctx, method)
+	if err != nil {
+		return fmt.Errorf("failed waiting to send %s request: %w", method, err)
+	}
+	defer release()
+
 	id := c.nextID.Add(1)
 
 	lspLogger.Debug("Making call: method=%s id=%v", method, id)
@@ -207,6 +223,11 @@ func (c *Client) Call(ctx context.Context, method string, params any, result any
 	// Convert ID to string for map lookup
 	idStr := msg.ID.String()
 	c.handlersMu.Lock()
+	if c.disconnected {
+		// Nothing would answer the request
+		c.handlersMu.Unlock()
+		return ErrServerNotRunning
+	}
 	c.handlers[idStr] = ch
 	c.handlersMu.Unlock()
 
@@ -217,14 +238,21 @@ func (c *Client) Call(ctx context.Context, method string, params any, result any
 	}()
 
 	// Send request
-	if err := WriteMessage(c.stdin, msg); err != nil {
+	if err := c.write(msg); err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
 	}
+	c.recordTrace(true, msg)
 
 	lspLogger.Debug("Waiting for response to request ID: %v", msg.ID)
 
 	// Wait for response
-	resp := <-ch
+	var resp *Message
+	select {
+	case resp = <-ch:
+	case <-ctx.Done():
+		c.cancelRequest(msg.ID)
+		return fmt.Errorf("%s request abandoned: %w", method, ctx.Err())
+	}
 
 	lspLogger.Debug("Received response for request ID: %v", msg.ID)
 
@@ -232,6 +260,7 @@ func (c *Client) Call(ctx context.Context, method string, params any, result any
 		lspLogger.Error("Request failed: %s (code: %d)", resp.Error.Message, resp.Error.Code)
 		return fmt.Errorf("request failed: %s (code: %d)", resp.Error.Message, resp.Error.Code)
 	}
+	c.lastSuccess.Store(time.Now().UnixNano())
 
 	if result != nil {
 		// If result is a json.RawMessage, just copy the raw bytes
@@ -249,6 +278,15 @@ func (c *Client) Call(ctx context.Context, method string, params any, result any
 	return nil
 }
 
+// cancelRequest tells the server that the response to a request is no longer
+// needed. The server may still answer it, and the answer is dropped.
+func (c *Client) cancelRequest(id *MessageID) {
+	lspLogger.Debug("Cancelling request ID: %v", id)
+	if err := c.Notify(context.Background(), "$/cancelRequest", protocol.CancelParams{ID: id.Value}); err != nil {
+		lspLogger.Error("Failed to cancel request %v: %v", id, err)
+	}
+}
+
 // Notify sends a notification (a request without an ID that doesn't expect a response)
 func (c *Client) Notify(ctx context.Context, method string, params any) error {
 	lspLogger.Debug("Sending notification: method=%s", method)
@@ -258,12 +296,43 @@ func (c *Client) Notify(ctx context.Context, method string, params any) error {
 		return fmt.Errorf("failed to create notification: %w", err)
 	}
 
-	if err := WriteMessage(c.stdin, msg); err != nil {
+	if err := c.write(msg); err != nil {
 		return fmt.Errorf("failed to send notification: %w", err)
 	}
+	c.recordTrace(true, msg)
 
 	return nil
 }
 
+// write sends a message to the running server
+func (c *Client) write(msg *Message) error {
+	_, stdin := c.process()
+	return WriteMessage(stdin, msg)
+}
+
 type NotificationHandler func(params json.RawMessage)
 type ServerRequestHandler func(params json.RawMessage) (any, error)
+
+// callServerRequestHandler calls the handler of a server request, turning a
+// panic, e.g. on malformed parameters, into an error response so that it
+// cannot stop the message loop
+func callServerRequestHandler(method string, handler ServerRequestHandler, params json.RawMessage) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			lspLogger.Error("Panic handling server request %s: %v\n%s", method, r, debug.Stack())
+			err = fmt.Errorf("internal error: %v", r)
+		}
+	}()
+	return handler(params)
+}
+
+// callNotificationHandler calls the handler of a notification, logging
+// rather than propagating a panic
+func callNotificationHandler(method string, handler NotificationHandler, params json.RawMessage) {
+	defer func() {
+		if r := recover(); r != nil {
+			lspLogger.Error("Panic handling notification %s: %v\n%s", method, r, debug.Stack())
+		}
+	}()
+	handler(params)
+}
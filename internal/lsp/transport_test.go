@@ -0,0 +1,51 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nopWriteCloser records what is written to a server's stdin
+type nopWriteCloser struct {
+	bytes.Buffer
+}
+
+func (*nopWriteCloser) Close() error { return nil }
+
+func TestCallCancelled(t *testing.T) {
+	stdin := &nopWriteCloser{}
+	c := &Client{
+		stdin:     stdin,
+		handlers:  make(map[string]chan *Message),
+		limiter:   newRequestLimiter(DefaultConcurrencyLimits),
+		forensics: newForensics(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := c.Call(ctx, "workspace/symbol", map[string]string{"query": "Foo"}, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, c.handlers, "the response handler is removed")
+
+	// The request is followed by a cancellation with its ID
+	reader := bufio.NewReader(&stdin.Buffer)
+	request, err := ReadMessage(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "workspace/symbol", request.Method)
+
+	cancellation, err := ReadMessage(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "$/cancelRequest", cancellation.Method)
+	var params struct {
+		ID json.Number `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(cancellation.Params, &params))
+	assert.Equal(t, request.ID.String(), params.ID.String())
+}
@@ -0,0 +1,98 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// workspaceFolder describes a directory as a workspace folder
+func workspaceFolder(dir string) protocol.WorkspaceFolder {
+	return protocol.WorkspaceFolder{
+		URI:  protocol.URI("file://" + dir),
+		Name: dir,
+	}
+}
+
+// WorkspaceFolders returns the directories the server has as its workspace
+// folders: those set with SetWorkspaceFolders and changed since, or else the
+// workspace directory
+func (c *Client) WorkspaceFolders() []string {
+	c.workspaceFoldersMu.RLock()
+	defer c.workspaceFoldersMu.RUnlock()
+	return c.currentWorkspaceFolders()
+}
+
+// currentWorkspaceFolders returns the workspace folders. The caller must
+// hold workspaceFoldersMu.
+func (c *Client) currentWorkspaceFolders() []string {
+	if len(c.workspaceFolders) == 0 {
+		if c.workspaceDir == "" {
+			return nil
+		}
+		return []string{c.workspaceDir}
+	}
+	return slices.Clone(c.workspaceFolders)
+}
+
+// AddWorkspaceFolder adds a directory to the workspace folders of a running
+// server with workspace/didChangeWorkspaceFolders. Adding a folder the
+// server already has does nothing.
+func (c *Client) AddWorkspaceFolder(ctx context.Context, dir string) error {
+	return c.changeWorkspaceFolder(ctx, dir, true)
+}
+
+// RemoveWorkspaceFolder removes a directory from the workspace folders of a
+// running server with workspace/didChangeWorkspaceFolders
+func (c *Client) RemoveWorkspaceFolder(ctx context.Context, dir string) error {
+	return c.changeWorkspaceFolder(ctx, dir, false)
+}
+
+func (c *Client) changeWorkspaceFolder(ctx context.Context, dir string, add bool) error {
+	if !c.SupportsMethod("workspace/didChangeWorkspaceFolders") {
+		return fmt.Errorf("the server does not support changing workspace folders")
+	}
+
+	c.workspaceFoldersMu.Lock()
+	folders := c.currentWorkspaceFolders()
+	index := slices.Index(folders, dir)
+	event := protocol.WorkspaceFoldersChangeEvent{
+		Added:   []protocol.WorkspaceFolder{},
+		Removed: []protocol.WorkspaceFolder{},
+	}
+	switch {
+	case add && index >= 0:
+		c.workspaceFoldersMu.Unlock()
+		return nil
+	case add:
+		folders = append(folders, dir)
+		event.Added = append(event.Added, workspaceFolder(dir))
+	case index < 0:
+		c.workspaceFoldersMu.Unlock()
+		return fmt.Errorf("%s is not a workspace folder", dir)
+	case len(folders) == 1:
+		c.workspaceFoldersMu.Unlock()
+		return fmt.Errorf("%s is the last workspace folder", dir)
+	default:
+		folders = slices.Delete(folders, index, index+1)
+		event.Removed = append(event.Removed, workspaceFolder(dir))
+	}
+	c.workspaceFolders = folders
+	c.workspaceFoldersMu.Unlock()
+
+	lspLogger.Info("Workspace folders changed to %v", folders)
+	return c.DidChangeWorkspaceFolders(ctx, protocol.DidChangeWorkspaceFoldersParams{Event: event})
+}
+
+// HandleWorkspaceFolders answers workspace/workspaceFolders requests with
+// the current workspace folders
+func HandleWorkspaceFolders(client *Client, _ json.RawMessage) (any, error) {
+	folders := []protocol.WorkspaceFolder{}
+	for _, dir := range client.WorkspaceFolders() {
+		folders = append(folders, workspaceFolder(dir))
+	}
+	return folders, nil
+}
@@ -0,0 +1,53 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeWorkspaceFolders(t *testing.T) {
+	var caps protocol.ServerCapabilities
+	require.NoError(t, json.Unmarshal([]byte(`{"workspace": {"workspaceFolders": {"supported": true, "changeNotifications": true}}}`), &caps))
+	stdin := &nopWriteCloser{}
+	c := &Client{
+		stdin:            stdin,
+		forensics:        newForensics(),
+		workspaceDir:     "/ws",
+		initializeResult: &protocol.InitializeResult{Capabilities: caps},
+	}
+	ctx := context.Background()
+
+	require.NoError(t, c.AddWorkspaceFolder(ctx, "/other"))
+	require.NoError(t, c.AddWorkspaceFolder(ctx, "/other"), "adding a folder twice does nothing")
+	assert.Equal(t, []string{"/ws", "/other"}, c.WorkspaceFolders())
+
+	require.NoError(t, c.RemoveWorkspaceFolder(ctx, "/ws"))
+	assert.Equal(t, []string{"/other"}, c.WorkspaceFolders())
+	assert.Error(t, c.RemoveWorkspaceFolder(ctx, "/ws"), "not a workspace folder")
+	assert.Error(t, c.RemoveWorkspaceFolder(ctx, "/other"), "the last workspace folder")
+
+	// One notification for each change
+	reader := bufio.NewReader(&stdin.Buffer)
+	var events []protocol.WorkspaceFoldersChangeEvent
+	for range 2 {
+		message, err := ReadMessage(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "workspace/didChangeWorkspaceFolders", message.Method)
+		var params protocol.DidChangeWorkspaceFoldersParams
+		require.NoError(t, json.Unmarshal(message.Params, &params))
+		events = append(events, params.Event)
+	}
+	assert.Equal(t, []protocol.WorkspaceFolder{{URI: "file:///other", Name: "/other"}}, events[0].Added)
+	assert.Equal(t, []protocol.WorkspaceFolder{{URI: "file:///ws", Name: "/ws"}}, events[1].Removed)
+	_, err := ReadMessage(reader)
+	assert.Error(t, err)
+
+	c.initializeResult = &protocol.InitializeResult{}
+	assert.Error(t, c.AddWorkspaceFolder(ctx, "/third"), "the server does not support changes")
+}
@@ -0,0 +1,5 @@
+package protocol
+
+// Regenerate tsprotocol.go, tsjson.go and internal/lsp/methods.go from the LSP
+// metaModel.json. See cmd/generate/README.md for the available flags.
+//go:generate go run ../../cmd/generate -o ../..
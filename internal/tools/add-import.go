@@ -0,0 +1,260 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/koonwen/mcp-language-server/internal/utilities"
+)
+
+// majorVersion matches the major version suffix of a Go module path
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// AddImport adds an import of a module to a file using the language server's
+// auto-import support rather than inserting text, so that import blocks stay
+// sorted and free of duplicates. If symbol is set, that name is imported from
+// the module, as in Python's "from module import symbol"; otherwise the
+// module itself is imported.
+//
+// Quick fixes for an unresolved use of the name are tried first. Otherwise the
+// name is completed in a temporary copy of the document, and the import edits
+// that come with the completion are applied to the file.
+func AddImport(ctx context.Context, client *lsp.Client, filePath, module, symbol string) (string, error) {
+	uri, err := openDocument(ctx, client, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	name := symbol
+	if name == "" {
+		name = importName(module)
+	}
+
+	edit, err := importQuickFix(ctx, client, uri, module, name)
+	if err != nil {
+		return "", err
+	}
+	if edit == nil {
+		var imported bool
+		edit, imported, err = importCompletion(ctx, client, uri, filePath, module, name)
+		if err != nil {
+			return "", err
+		}
+		if edit == nil && imported {
+			return msg(MsgAlreadyImported, module, filePath), nil
+		}
+	}
+	if edit == nil {
+		return "", fmt.Errorf("the language server offered no import of %s from %s", name, module)
+	}
+
+	before, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %v", err)
+	}
+	if err := utilities.ApplyWorkspaceEdit(*edit); err != nil {
+		return "", fmt.Errorf("failed to apply import edits: %v", err)
+	}
+	after, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %v", err)
+	}
+
+	// Let the server run its on-save actions
+	saved := saveFile(ctx, client, filePath)
+
+	diff, _, _ := UnifiedDiff(filePath, string(before), string(after))
+	result := msg(MsgImportAdded, module, filePath) + "\n\n" + diff
+	if saved != "" {
+		result += "\n" + saved
+	}
+	return result, nil
+}
+
+// importName guesses the name a module is referred to by, the last element
+// of its path
+func importName(module string) string {
+	module = strings.Trim(module, `"'`)
+	parts := strings.FieldsFunc(module, func(r rune) bool {
+		return r == '/' || r == '.' || r == ':'
+	})
+	if len(parts) == 0 {
+		return module
+	}
+	name := parts[len(parts)-1]
+	if majorVersion.MatchString(name) && len(parts) > 1 {
+		// Go modules such as example.com/mod/v2 are named after the
+		// element before the version
+		name = parts[len(parts)-2]
+	}
+	return name
+}
+
+// importQuickFix looks for a quick fix that imports a module among the code
+// actions for the diagnostics about an unresolved name
+func importQuickFix(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, module, name string) (*protocol.WorkspaceEdit, error) {
+	for _, diag := range client.GetFileDiagnostics(uri) {
+		if !strings.Contains(diag.Message, name) {
+			continue
+		}
+
+		results, err := client.CodeAction(ctx, protocol.CodeActionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Range:        diag.Range,
+			Context: protocol.CodeActionContext{
+				Diagnostics: []protocol.Diagnostic{diag},
+				Only:        []protocol.CodeActionKind{protocol.QuickFix},
+			},
+		})
+		if err != nil {
+			toolsLogger.Debug("Error getting code actions: %v", err)
+			continue
+		}
+
+		for _, result := range results {
+			action, ok := result.Value.(protocol.CodeAction)
+			if !ok || !strings.Contains(strings.ToLower(action.Title), "import") || !strings.Contains(action.Title, module) {
+				continue
+			}
+			if action.Edit == nil && client.SupportsMethod("codeAction/resolve") {
+				resolved, err := client.ResolveCodeAction(ctx, action)
+				if err != nil {
+					return nil, fmt.Errorf("failed to resolve code action: %v", err)
+				}
+				action = resolved
+			}
+			if action.Edit != nil {
+				return action.Edit, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// importCompletion completes the name in a temporary copy of the document and
+// returns the import edits of a completion from the module. imported is true
+// if the server offered the name from the module without any edits, because
+// it is already imported.
+func importCompletion(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, filePath, module, name string) (edit *protocol.WorkspaceEdit, imported bool, err error) {
+	content, err := documentText(ctx, client, uri)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not read document: %v", err)
+	}
+
+	probe, probeLine, position := importProbe(filePath, content, name)
+	if err := client.ChangeDocument(ctx, uri, probe); err != nil {
+		return nil, false, fmt.Errorf("failed to update document: %v", err)
+	}
+	defer func() {
+		// Restore the server's view of the document
+		if err := client.ChangeDocument(ctx, uri, content); err != nil {
+			toolsLogger.Error("Error restoring document: %v", err)
+		}
+	}()
+
+	result, err := client.Completion(ctx, protocol.CompletionParams{
+		Context: protocol.CompletionContext{TriggerKind: protocol.Invoked},
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Position:     position,
+		},
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get completions: %v", err)
+	}
+
+	var items []protocol.CompletionItem
+	switch v := result.Value.(type) {
+	case protocol.CompletionList:
+		items = v.Items
+	case []protocol.CompletionItem:
+		items = v
+	}
+
+	for _, item := range items {
+		if label, _, _ := strings.Cut(item.Label, "("); strings.TrimSpace(label) != name {
+			continue
+		}
+
+		// Servers often only compute the import edits on resolve
+		if len(item.AdditionalTextEdits) == 0 && client.SupportsMethod("completionItem/resolve") {
+			resolved, err := client.ResolveCompletionItem(ctx, item)
+			if err != nil {
+				toolsLogger.Debug("Error resolving completion item: %v", err)
+			} else {
+				item = resolved
+			}
+		}
+
+		if !completionFromModule(item, module) {
+			continue
+		}
+		if len(item.AdditionalTextEdits) == 0 {
+			imported = true
+			continue
+		}
+
+		// The import edits must not touch the temporary code
+		if !editsBefore(item.AdditionalTextEdits, probeLine) {
+			continue
+		}
+
+		return &protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentUri][]protocol.TextEdit{uri: item.AdditionalTextEdits},
+		}, false, nil
+	}
+
+	return nil, imported, nil
+}
+
+// importProbe appends a use of a name to a document, where the server will
+// complete it. It returns the new content, the line the appended code starts
+// at and the position after the name.
+func importProbe(filePath, content, name string) (string, uint32, protocol.Position) {
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	line := uint32(strings.Count(content, "\n"))
+
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".go":
+		// Go only completes expressions inside function bodies
+		return content + "func _() {\n\t" + name + "\n}\n", line, protocol.Position{Line: line + 1, Character: uint32(1 + len(name))}
+	default:
+		return content + name + "\n", line, protocol.Position{Line: line, Character: uint32(len(name))}
+	}
+}
+
+// completionFromModule reports whether a completion item refers to a module,
+// judging by its details and import edits
+func completionFromModule(item protocol.CompletionItem, module string) bool {
+	texts := []string{item.Detail}
+	if item.LabelDetails != nil {
+		texts = append(texts, item.LabelDetails.Detail, item.LabelDetails.Description)
+	}
+	for _, edit := range item.AdditionalTextEdits {
+		texts = append(texts, edit.NewText)
+	}
+	for _, text := range texts {
+		if strings.Contains(text, module) {
+			return true
+		}
+	}
+	return false
+}
+
+// editsBefore reports whether all edits end before a line
+func editsBefore(edits []protocol.TextEdit, line uint32) bool {
+	for _, edit := range edits {
+		if edit.Range.End.Line >= line {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportName(t *testing.T) {
+	assert.Equal(t, "strings", importName("strings"))
+	assert.Equal(t, "filepath", importName(`"path/filepath"`))
+	assert.Equal(t, "mcp", importName("github.com/mark3labs/mcp-go/mcp"))
+	assert.Equal(t, "yaml", importName("gopkg.in/yaml/v3"))
+	assert.Equal(t, "path", importName("os.path"))
+	assert.Equal(t, "HashMap", importName("std::collections::HashMap"))
+}
+
+func TestImportProbe(t *testing.T) {
+	probe, line, position := importProbe("/src/main.go", "package main", "strings")
+	assert.Equal(t, "package main\nfunc _() {\n\tstrings\n}\n", probe)
+	assert.Equal(t, uint32(1), line)
+	assert.Equal(t, protocol.Position{Line: 2, Character: 8}, position)
+
+	probe, line, position = importProbe("/src/app.py", "import os\n", "Path")
+	assert.Equal(t, "import os\nPath\n", probe)
+	assert.Equal(t, uint32(1), line)
+	assert.Equal(t, protocol.Position{Line: 1, Character: 4}, position)
+}
+
+func TestCompletionFromModule(t *testing.T) {
+	edit := protocol.TextEdit{NewText: "from pathlib import Path\n"}
+	assert.True(t, completionFromModule(protocol.CompletionItem{Label: "Path", AdditionalTextEdits: []protocol.TextEdit{edit}}, "pathlib"))
+	assert.True(t, completionFromModule(protocol.CompletionItem{Label: "strings", Detail: `"strings"`}, "strings"))
+	assert.True(t, completionFromModule(protocol.CompletionItem{
+		Label:        "readFile",
+		LabelDetails: &protocol.CompletionItemLabelDetails{Description: "fs/promises"},
+	}, "fs/promises"))
+	assert.False(t, completionFromModule(protocol.CompletionItem{Label: "Path", Detail: "class"}, "pathlib"))
+
+	assert.True(t, editsBefore([]protocol.TextEdit{edit}, 1))
+	edit.Range.End.Line = 1
+	assert.False(t, editsBefore([]protocol.TextEdit{edit}, 1))
+}
@@ -0,0 +1,199 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/koonwen/mcp-language-server/internal/utilities"
+)
+
+// ApplyCodeAction applies one of the code actions listed by GetCodeActions
+// for the same range and kinds, chosen by its 1-indexed position in the list
+// or by its title. The action is resolved if needed, its edit is written to
+// disk and its command is executed, and every resulting change is reported.
+// With dryRun, the action's edit is returned as a diff and nothing is run.
+func ApplyCodeAction(ctx context.Context, client *lsp.Client, filePath string, startLine, startColumn, endLine, endColumn int, kinds []string, index int, title string, dryRun bool) (string, error) {
+	_, items, err := codeActionsAt(ctx, client, filePath, startLine, startColumn, endLine, endColumn, kinds)
+	if err != nil {
+		return "", err
+	}
+
+	item, err := selectCodeAction(items, index, title)
+	if err != nil {
+		return "", err
+	}
+
+	var action protocol.CodeAction
+	switch v := item.Value.(type) {
+	case protocol.CodeAction:
+		action = v
+		if action.Disabled != nil {
+			return "", fmt.Errorf("code action '%s' is disabled: %s", action.Title, action.Disabled.Reason)
+		}
+
+		// Servers may leave out the edit until the action is chosen
+		if action.Edit == nil && client.SupportsMethod("codeAction/resolve") {
+			resolved, err := client.ResolveCodeAction(ctx, action)
+			if err != nil {
+				return "", fmt.Errorf("failed to resolve code action: %v", err)
+			}
+			action = resolved
+		}
+	case protocol.Command:
+		action = protocol.CodeAction{Title: v.Title, Command: &v}
+	}
+
+	if dryRun {
+		return previewCodeAction(action)
+	}
+
+	var edits []protocol.WorkspaceEdit
+	if action.Edit != nil {
+		if err := utilities.ApplyWorkspaceEdit(*action.Edit); err != nil {
+			return "", fmt.Errorf("failed to apply changes: %v", err)
+		}
+		edits = append(edits, *action.Edit)
+	}
+
+	if action.Command != nil {
+		// Commands usually make their changes by sending workspace/applyEdit
+		// requests back to us, so capture them to report what changed
+		trace := client.StartTrace()
+		_, err := client.ExecuteCommand(ctx, protocol.ExecuteCommandParams{
+			Command:   action.Command.Command,
+			Arguments: action.Command.Arguments,
+		})
+		client.StopTrace(trace)
+		if err != nil {
+			return "", fmt.Errorf("failed to execute command %s: %v", action.Command.Command, err)
+		}
+		edits = append(edits, serverAppliedEdits(trace)...)
+	}
+
+	changes, fileOps := summarizeWorkspaceEdit(mergeWorkspaceEdits(edits))
+
+	// Let the server run its on-save actions
+	saved := ""
+	for _, change := range changes {
+		saved += saveFile(ctx, client, change.path)
+	}
+
+	var result strings.Builder
+	result.WriteString(msg(MsgCodeActionApplied, action.Title) + "\n")
+	if action.Command != nil {
+		result.WriteString(msg(MsgCodeActionExecuted, action.Command.Command) + "\n")
+	}
+	locations, count := formatFileEdits(changes, fileOps)
+	if count == 0 && len(fileOps) == 0 {
+		result.WriteString(msg(MsgCodeActionNoChanges) + "\n")
+	} else {
+		result.WriteString(msg(MsgCodeActionEdited, count, len(changes)) + "\n" + locations)
+	}
+	result.WriteString(saved)
+
+	return result.String(), nil
+}
+
+// previewCodeAction renders the edit of a code action without applying it.
+// The changes its command would make are only known once it runs.
+func previewCodeAction(action protocol.CodeAction) (string, error) {
+	var result strings.Builder
+	result.WriteString(msg(MsgCodeActionPreview, action.Title) + "\n")
+	if action.Edit != nil {
+		preview, err := previewWorkspaceEdit(*action.Edit)
+		if err != nil {
+			return "", err
+		}
+		result.WriteString(preview)
+	} else if action.Command == nil {
+		result.WriteString(msg(MsgWorkspaceEditEmpty) + "\n")
+	}
+	if action.Command != nil {
+		result.WriteString(msg(MsgCodeActionNotExecuted, action.Command.Command) + "\n")
+	}
+	return result.String(), nil
+}
+
+// selectCodeAction picks a code action by its 1-indexed position or, if a
+// title is given, by its exact title or a unique part of it
+func selectCodeAction(items []protocol.Or_Result_textDocument_codeAction_Item0_Elem, index int, title string) (protocol.Or_Result_textDocument_codeAction_Item0_Elem, error) {
+	var none protocol.Or_Result_textDocument_codeAction_Item0_Elem
+	if len(items) == 0 {
+		return none, fmt.Errorf("no code actions available for this range")
+	}
+
+	if title == "" {
+		if index < 1 || index > len(items) {
+			return none, fmt.Errorf("invalid code action index: %d. Available range: 1-%d", index, len(items))
+		}
+		return items[index-1], nil
+	}
+
+	var matches []protocol.Or_Result_textDocument_codeAction_Item0_Elem
+	var titles []string
+	for _, item := range items {
+		itemTitle := codeActionTitle(item)
+		if itemTitle == title {
+			return item, nil
+		}
+		if strings.Contains(strings.ToLower(itemTitle), strings.ToLower(title)) {
+			matches = append(matches, item)
+			titles = append(titles, itemTitle)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return none, fmt.Errorf("no code action titled '%s'", title)
+	case 1:
+		return matches[0], nil
+	default:
+		return none, fmt.Errorf("'%s' matches %d code actions, use the full title or an index: %s", title, len(matches), strings.Join(titles, "; "))
+	}
+}
+
+func codeActionTitle(item protocol.Or_Result_textDocument_codeAction_Item0_Elem) string {
+	switch v := item.Value.(type) {
+	case protocol.CodeAction:
+		return v.Title
+	case protocol.Command:
+		return v.Title
+	}
+	return ""
+}
+
+// serverAppliedEdits returns the edits of the workspace/applyEdit requests
+// received from the server while a trace was active
+func serverAppliedEdits(trace *lsp.Trace) []protocol.WorkspaceEdit {
+	var edits []protocol.WorkspaceEdit
+	for _, entry := range trace.Entries() {
+		if entry.Sent {
+			continue
+		}
+		var request struct {
+			Method string                            `json:"method"`
+			Params protocol.ApplyWorkspaceEditParams `json:"params"`
+		}
+		if err := json.Unmarshal(entry.Message, &request); err != nil || request.Method != "workspace/applyEdit" {
+			continue
+		}
+		edits = append(edits, request.Params.Edit)
+	}
+	return edits
+}
+
+// mergeWorkspaceEdits combines workspace edits for reporting
+func mergeWorkspaceEdits(edits []protocol.WorkspaceEdit) protocol.WorkspaceEdit {
+	merged := protocol.WorkspaceEdit{Changes: make(map[protocol.DocumentUri][]protocol.TextEdit)}
+	for _, edit := range edits {
+		for uri, textEdits := range edit.Changes {
+			merged.Changes[uri] = append(merged.Changes[uri], textEdits...)
+		}
+		merged.DocumentChanges = append(merged.DocumentChanges, edit.DocumentChanges...)
+	}
+	return merged
+}
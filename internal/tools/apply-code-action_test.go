@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectCodeAction(t *testing.T) {
+	items := []protocol.Or_Result_textDocument_codeAction_Item0_Elem{
+		{Value: protocol.CodeAction{Title: "Extract function"}},
+		{Value: protocol.CodeAction{Title: "Extract variable"}},
+		{Value: protocol.Command{Title: "Organize imports", Command: "source.organizeImports"}},
+	}
+
+	item, err := selectCodeAction(items, 2, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "Extract variable", codeActionTitle(item))
+
+	_, err = selectCodeAction(items, 4, "")
+	assert.EqualError(t, err, "invalid code action index: 4. Available range: 1-3")
+
+	item, err = selectCodeAction(items, 0, "organize")
+	assert.NoError(t, err)
+	assert.Equal(t, "Organize imports", codeActionTitle(item))
+
+	item, err = selectCodeAction(items, 0, "Extract function")
+	assert.NoError(t, err)
+	assert.Equal(t, "Extract function", codeActionTitle(item))
+
+	_, err = selectCodeAction(items, 0, "extract")
+	assert.ErrorContains(t, err, "matches 2 code actions")
+
+	_, err = selectCodeAction(items, 0, "inline")
+	assert.Error(t, err)
+
+	_, err = selectCodeAction(nil, 1, "")
+	assert.Error(t, err)
+}
+
+func TestMergeWorkspaceEdits(t *testing.T) {
+	edit := func(uri protocol.DocumentUri, line uint32) protocol.WorkspaceEdit {
+		return protocol.WorkspaceEdit{Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+			uri: {{Range: protocol.Range{Start: protocol.Position{Line: line}, End: protocol.Position{Line: line}}}},
+		}}
+	}
+
+	merged := mergeWorkspaceEdits([]protocol.WorkspaceEdit{edit("file:///a.go", 1), edit("file:///a.go", 5), edit("file:///b.go", 2)})
+	changes, _ := summarizeWorkspaceEdit(merged)
+	if assert.Len(t, changes, 2) {
+		assert.Equal(t, "/a.go", changes[0].path)
+		assert.Len(t, changes[0].ranges, 2)
+		assert.Equal(t, "/b.go", changes[1].path)
+	}
+}
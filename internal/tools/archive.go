@@ -0,0 +1,176 @@
+package tools
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// maxArchiveEntrySize caps the size of files read from archives
+const maxArchiveEntrySize = 10 * 1024 * 1024
+
+// readDocumentContent returns the content of a document that is not a file on
+// disk, such as a file inside a JAR or wheel, or a virtual document provided
+// by the language server.
+func readDocumentContent(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri) (string, error) {
+	// Documents that are already open, such as untitled buffers, are read
+	// from the content last sent to the server
+	if content, ok := client.DocumentContent(uri); ok {
+		return content, nil
+	}
+
+	if archivePath, entry, ok := parseArchiveURI(string(uri)); ok {
+		content, err := readArchiveEntry(archivePath, entry)
+		if err == nil {
+			return content, nil
+		}
+		toolsLogger.Debug("Could not read %s from archive, asking the server: %v", uri, err)
+	}
+
+	return serverDocumentContent(ctx, client, uri)
+}
+
+// parseArchiveURI splits URIs such as jar:file:///lib/a.jar!/com/A.java or
+// zipfile:///lib/a.zip::pkg/a.py into the archive path and entry name
+func parseArchiveURI(uri string) (string, string, bool) {
+	var archive, entry string
+	var found bool
+	switch {
+	case strings.HasPrefix(uri, "jar:"), strings.HasPrefix(uri, "zip:"):
+		archive, entry, found = strings.Cut(uri[4:], "!/")
+	case strings.HasPrefix(uri, "zipfile:"):
+		archive, entry, found = strings.Cut(uri, "::")
+		archive = "file:" + strings.TrimPrefix(archive, "zipfile:")
+	}
+	if !found || !strings.HasPrefix(archive, "file://") {
+		return "", "", false
+	}
+
+	path, err := url.PathUnescape(strings.TrimPrefix(archive, "file://"))
+	if err != nil {
+		return "", "", false
+	}
+	entry, err = url.PathUnescape(strings.TrimPrefix(entry, "/"))
+	if err != nil {
+		return "", "", false
+	}
+	return path, entry, true
+}
+
+// readArchiveEntry reads a file from a zip based archive. For compiled Java
+// classes, the source is read from the matching -sources.jar if there is one.
+func readArchiveEntry(archivePath, entry string) (string, error) {
+	if strings.HasSuffix(entry, ".class") && strings.HasSuffix(archivePath, ".jar") {
+		sourcesPath := strings.TrimSuffix(archivePath, ".jar") + "-sources.jar"
+		// Nested classes are defined in the source file of the outer class
+		sourceEntry, _, _ := strings.Cut(strings.TrimSuffix(entry, ".class"), "$")
+		if content, err := readZipEntry(sourcesPath, sourceEntry+".java"); err == nil {
+			return content, nil
+		}
+		return "", fmt.Errorf("no source available for compiled class %s", entry)
+	}
+
+	return readZipEntry(archivePath, entry)
+}
+
+func readZipEntry(archivePath, entry string) (string, error) {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer reader.Close()
+
+	file, err := reader.Open(entry)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s in %s: %w", entry, archivePath, err)
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, maxArchiveEntrySize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s in %s: %w", entry, archivePath, err)
+	}
+	if len(content) > maxArchiveEntrySize {
+		return "", fmt.Errorf("%s in %s is too large", entry, archivePath)
+	}
+	return string(content), nil
+}
+
+// serverDocumentContent asks the language server for the content of a virtual
+// document, using the server specific request for known schemes and the
+// workspace/textDocumentContent request otherwise.
+func serverDocumentContent(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri) (string, error) {
+	scheme, _, _ := strings.Cut(string(uri), ":")
+
+	switch scheme {
+	case "jdt":
+		// Eclipse JDT language server
+		var content string
+		err := client.Call(ctx, "java/classFileContents", map[string]any{"uri": uri}, &content)
+		if err != nil {
+			return "", fmt.Errorf("failed to get class file contents: %v", err)
+		}
+		return content, nil
+	case "deno":
+		var content string
+		err := client.Call(ctx, "deno/virtualTextDocument", map[string]any{
+			"textDocument": map[string]any{"uri": uri},
+		}, &content)
+		if err != nil {
+			return "", fmt.Errorf("failed to get virtual document: %v", err)
+		}
+		return content, nil
+	}
+
+	var result json.RawMessage
+	if err := client.Call(ctx, "workspace/textDocumentContent", map[string]any{"uri": uri}, &result); err != nil {
+		return "", fmt.Errorf("failed to get content of %s: %v", uri, err)
+	}
+	var content struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(result, &content); err != nil {
+		return "", fmt.Errorf("failed to parse content of %s: %v", uri, err)
+	}
+	return content.Text, nil
+}
+
+// formatReadOnlyDefinition renders a definition in a document that is not a
+// file on disk, showing the definition range with context
+func formatReadOnlyDefinition(ctx context.Context, client *lsp.Client, loc protocol.Location, contextLines int) (string, error) {
+	content, err := readDocumentContent(ctx, client, loc.URI)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(content, "\n")
+	startLine := int(loc.Range.Start.Line)
+	endLine := int(loc.Range.End.Line)
+	if startLine >= len(lines) {
+		return "", fmt.Errorf("definition at line %d is past the end of %s", startLine+1, loc.URI)
+	}
+
+	linesToShow := make(map[int]bool)
+	for i := startLine - contextLines; i <= endLine+contextLines; i++ {
+		if i >= 0 && i < len(lines) {
+			linesToShow[i] = true
+		}
+	}
+
+	return "---\n\n" +
+		msg(MsgFileHeader, string(loc.URI)) + " " + msg(MsgReadOnly) + "\n" +
+		msg(MsgDefinitionAtHeader,
+			loc.Range.Start.Line+1,
+			loc.Range.Start.Character+1,
+			loc.Range.End.Line+1,
+			loc.Range.End.Character+1,
+		) + "\n\n" +
+		FormatLinesWithRanges(lines, ConvertLinesToRanges(linesToShow, len(lines))) + "\n", nil
+}
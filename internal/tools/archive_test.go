@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestZip(t *testing.T, path string, files map[string]string) {
+	file, err := os.Create(path)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	for name, content := range files {
+		w, err := writer.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.Close())
+}
+
+func TestParseArchiveURI(t *testing.T) {
+	tests := []struct {
+		uri     string
+		archive string
+		entry   string
+		ok      bool
+	}{
+		{"jar:file:///lib/a.jar!/com/example/A.java", "/lib/a.jar", "com/example/A.java", true},
+		{"zip:file:///lib/my%20pkg.whl!/pkg/mod.py", "/lib/my pkg.whl", "pkg/mod.py", true},
+		{"zipfile:///cache/a.zip::node_modules/a/index.d.ts", "/cache/a.zip", "node_modules/a/index.d.ts", true},
+		{"file:///lib/a.py", "", "", false},
+		{"jdt://contents/rt.jar/java.lang/String.class", "", "", false},
+		{"jar:https://example.com/a.jar!/A.java", "", "", false},
+	}
+
+	for _, tt := range tests {
+		archive, entry, ok := parseArchiveURI(tt.uri)
+		assert.Equal(t, tt.ok, ok, tt.uri)
+		assert.Equal(t, tt.archive, archive, tt.uri)
+		assert.Equal(t, tt.entry, entry, tt.uri)
+	}
+}
+
+func TestReadArchiveEntry(t *testing.T) {
+	dir := t.TempDir()
+	jar := filepath.Join(dir, "lib.jar")
+	writeTestZip(t, jar, map[string]string{"com/example/A.class": "\xca\xfe\xba\xbe"})
+	writeTestZip(t, filepath.Join(dir, "lib-sources.jar"), map[string]string{"com/example/A.java": "class A {}"})
+	wheel := filepath.Join(dir, "pkg.whl")
+	writeTestZip(t, wheel, map[string]string{"pkg/mod.py": "def f(): pass"})
+
+	content, err := readArchiveEntry(wheel, "pkg/mod.py")
+	assert.NoError(t, err)
+	assert.Equal(t, "def f(): pass", content)
+
+	// Compiled classes are read from the sources jar, including nested classes
+	content, err = readArchiveEntry(jar, "com/example/A$Inner.class")
+	assert.NoError(t, err)
+	assert.Equal(t, "class A {}", content)
+
+	_, err = readArchiveEntry(wheel, "pkg/missing.py")
+	assert.Error(t, err)
+}
@@ -0,0 +1,237 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+const (
+	// maxCallPaths caps the number of paths reported by FindCallPaths
+	maxCallPaths = 10
+	// maxCallExpansions caps the number of functions whose outgoing calls
+	// are followed while searching
+	maxCallExpansions = 500
+)
+
+// callPathStep is a single item on a call path, along with the range in the
+// previous item where it is called
+type callPathStep struct {
+	item   protocol.CallHierarchyItem
+	callAt *protocol.Range
+}
+
+// FindCallPaths searches the outgoing call hierarchy for paths from one symbol
+// to another. The search is breadth first, so the shortest paths are reported
+// first. maxDepth bounds the number of calls in a path and maxBreadth bounds the
+// number of callees followed from each function. Results are approximate: calls
+// through interfaces, function values or reflection are not seen by most servers.
+func FindCallPaths(ctx context.Context, client *lsp.Client, fromSymbol, toSymbol string, maxDepth, maxBreadth int) (string, error) {
+	sources, err := resolveSymbolLocations(ctx, client, fromSymbol)
+	if err != nil {
+		return "", err
+	}
+	if len(sources) == 0 {
+		return msg(MsgSymbolNotFound, fromSymbol), nil
+	}
+
+	// The target may be outside of the workspace (e.g. a standard library
+	// function), in which case we fall back to matching by name
+	targets, err := resolveSymbolLocations(ctx, client, toSymbol)
+	if err != nil {
+		return "", err
+	}
+
+	isTarget := func(item protocol.CallHierarchyItem) bool {
+		for _, loc := range targets {
+			if item.URI == loc.URI && containsPosition(item.Range, loc.Range.Start) {
+				return true
+			}
+		}
+		return referenceSymbolMatches(toSymbol, item.Name)
+	}
+
+	// Search from every matching source symbol
+	var roots []protocol.CallHierarchyItem
+	for _, loc := range sources {
+		items, err := prepareCallHierarchy(ctx, client, loc.URI, loc.Range.Start)
+		if err != nil {
+			toolsLogger.Error("Error preparing call hierarchy: %v", err)
+			continue
+		}
+		roots = append(roots, items...)
+	}
+	if len(roots) == 0 {
+		return msg(MsgNoCallHierarchy, fromSymbol), nil
+	}
+
+	outgoingCalls := func(item protocol.CallHierarchyItem) []protocol.CallHierarchyOutgoingCall {
+		calls, err := client.OutgoingCalls(ctx, protocol.CallHierarchyOutgoingCallsParams{
+			Item: item,
+		})
+		if err != nil {
+			toolsLogger.Error("Error getting outgoing calls for %s: %v", item.Name, err)
+			return nil
+		}
+		return calls
+	}
+	paths, truncated := searchCallPaths(roots, outgoingCalls, isTarget, maxDepth, maxBreadth)
+
+	if len(paths) == 0 {
+		result := msg(MsgNoCallPath, fromSymbol, toSymbol, maxDepth)
+		if truncated {
+			result += msg(MsgCallPathSearchTruncated)
+		}
+		return result, nil
+	}
+
+	var result strings.Builder
+	result.WriteString(msg(MsgCallPathsFound, len(paths), fromSymbol, toSymbol) + "\n")
+	for i, path := range paths {
+		result.WriteString("\n---\n\n" + msg(MsgCallPathHeader, i+1, len(path)-1) + "\n")
+		for j, step := range path {
+			location := formatCallHierarchyLocation(step.item)
+
+			if j == 0 {
+				result.WriteString(fmt.Sprintf("  %s (%s)\n", step.item.Name, location))
+				continue
+			}
+
+			result.WriteString(fmt.Sprintf("  -> %s (%s)", step.item.Name, location))
+			if step.callAt != nil {
+				result.WriteString(msg(MsgCallPathCalledAt, step.callAt.Start.Line+1, step.callAt.Start.Character+1))
+			}
+			result.WriteString("\n")
+		}
+	}
+	if truncated {
+		result.WriteString("\n" + msg(MsgCallPathResultTruncated) + "\n")
+	}
+
+	return result.String(), nil
+}
+
+// searchCallPaths searches breadth first from the roots for paths to items
+// for which isTarget is true. Each function other than a target is expanded
+// at most once, on the shortest path that reaches it, so the number of
+// partial paths kept is bounded by the number of functions seen. It stops
+// after maxCallExpansions expansions or maxCallPaths paths, and reports
+// whether callees were left out.
+func searchCallPaths(roots []protocol.CallHierarchyItem, outgoingCalls func(protocol.CallHierarchyItem) []protocol.CallHierarchyOutgoingCall, isTarget func(protocol.CallHierarchyItem) bool, maxDepth, maxBreadth int) ([][]callPathStep, bool) {
+	visited := make(map[string]bool)
+	var queue [][]callPathStep
+	for _, item := range roots {
+		key := callHierarchyItemKey(item)
+		if visited[key] {
+			continue
+		}
+		visited[key] = true
+		queue = append(queue, []callPathStep{{item: item}})
+	}
+
+	expansions := 0
+	truncated := false
+	var paths [][]callPathStep
+
+	for len(queue) > 0 && len(paths) < maxCallPaths {
+		path := queue[0]
+		queue = queue[1:]
+
+		last := path[len(path)-1].item
+		if len(path) > 1 && isTarget(last) {
+			paths = append(paths, path)
+			continue
+		}
+		if len(path) > maxDepth {
+			continue
+		}
+
+		if expansions >= maxCallExpansions {
+			truncated = true
+			break
+		}
+		expansions++
+
+		followed := 0
+		for _, call := range outgoingCalls(last) {
+			if followed >= maxBreadth {
+				truncated = true
+				break
+			}
+
+			// Targets are reached by every path, other functions only by
+			// the first, which also skips cycles
+			key := callHierarchyItemKey(call.To)
+			if visited[key] {
+				continue
+			}
+			if !isTarget(call.To) {
+				visited[key] = true
+			}
+			followed++
+
+			step := callPathStep{item: call.To}
+			if len(call.FromRanges) > 0 {
+				step.callAt = &call.FromRanges[0]
+			}
+
+			next := make([]callPathStep, len(path), len(path)+1)
+			copy(next, path)
+			queue = append(queue, append(next, step))
+		}
+	}
+
+	return paths, truncated
+}
+
+// resolveSymbolLocations finds the locations of workspace symbols matching symbolName
+func resolveSymbolLocations(ctx context.Context, client *lsp.Client, symbolName string) ([]protocol.Location, error) {
+	symbolResult, err := client.Symbol(ctx, protocol.WorkspaceSymbolParams{
+		Query: symbolName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch symbol: %v", err)
+	}
+
+	results, err := symbolResult.Results()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse results: %v", err)
+	}
+
+	var locations []protocol.Location
+	for _, symbol := range results {
+		if !referenceSymbolMatches(symbolName, symbol.GetName()) {
+			continue
+		}
+
+		loc := symbol.GetLocation()
+
+		// File is likely to be opened already, but may not be.
+		if _, err := openDocument(ctx, client, lsp.DocumentPath(loc.URI)); err != nil {
+			toolsLogger.Error("Error opening file: %v", err)
+			continue
+		}
+		locations = append(locations, loc)
+	}
+
+	return locations, nil
+}
+
+// prepareCallHierarchy returns the call hierarchy items at a position
+func prepareCallHierarchy(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, position protocol.Position) ([]protocol.CallHierarchyItem, error) {
+	return client.PrepareCallHierarchy(ctx, protocol.CallHierarchyPrepareParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{
+				URI: uri,
+			},
+			Position: position,
+		},
+	})
+}
+
+func callHierarchyItemKey(item protocol.CallHierarchyItem) string {
+	return fmt.Sprintf("%s:%d:%d", item.URI, item.SelectionRange.Start.Line, item.SelectionRange.Start.Character)
+}
@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+// callGraph maps function names to the functions they call
+type callGraph map[string][]string
+
+func callItem(name string) protocol.CallHierarchyItem {
+	return protocol.CallHierarchyItem{Name: name, URI: protocol.DocumentUri("file:///ws/" + name + ".go")}
+}
+
+// outgoing returns the outgoing calls of the graph and counts the expansions
+func (g callGraph) outgoing(expansions *int) func(protocol.CallHierarchyItem) []protocol.CallHierarchyOutgoingCall {
+	return func(item protocol.CallHierarchyItem) []protocol.CallHierarchyOutgoingCall {
+		*expansions++
+		var calls []protocol.CallHierarchyOutgoingCall
+		for _, callee := range g[item.Name] {
+			calls = append(calls, protocol.CallHierarchyOutgoingCall{To: callItem(callee)})
+		}
+		return calls
+	}
+}
+
+func pathNames(path []callPathStep) []string {
+	names := make([]string, len(path))
+	for i, step := range path {
+		names[i] = step.item.Name
+	}
+	return names
+}
+
+func TestSearchCallPaths(t *testing.T) {
+	isTarget := func(name string) func(protocol.CallHierarchyItem) bool {
+		return func(item protocol.CallHierarchyItem) bool { return item.Name == name }
+	}
+
+	t.Run("cyclic graph", func(t *testing.T) {
+		graph := callGraph{
+			"main":   {"a", "b"},
+			"a":      {"b", "main"},
+			"b":      {"a", "target"},
+			"target": {"main"},
+		}
+		expansions := 0
+		paths, truncated := searchCallPaths([]protocol.CallHierarchyItem{callItem("main")}, graph.outgoing(&expansions), isTarget("target"), 10, 10)
+		assert.False(t, truncated)
+		assert.Len(t, paths, 1)
+		assert.Equal(t, []string{"main", "b", "target"}, pathNames(paths[0]))
+		// main, a and b are each expanded once
+		assert.Equal(t, 3, expansions)
+	})
+
+	t.Run("paths through different callers", func(t *testing.T) {
+		graph := callGraph{
+			"main": {"a", "b"},
+			"a":    {"target"},
+			"b":    {"target"},
+		}
+		expansions := 0
+		paths, _ := searchCallPaths([]protocol.CallHierarchyItem{callItem("main")}, graph.outgoing(&expansions), isTarget("target"), 10, 10)
+		assert.Len(t, paths, 2)
+		assert.Equal(t, []string{"main", "a", "target"}, pathNames(paths[0]))
+		assert.Equal(t, []string{"main", "b", "target"}, pathNames(paths[1]))
+	})
+
+	t.Run("fan-out graph", func(t *testing.T) {
+		// Every function calls the same 50 functions, so the number of
+		// paths grows exponentially with their length
+		graph := callGraph{}
+		var callees []string
+		for i := 0; i < 50; i++ {
+			callees = append(callees, fmt.Sprintf("f%d", i))
+		}
+		graph["main"] = callees
+		for _, callee := range callees {
+			graph[callee] = callees
+		}
+		expansions := 0
+		paths, truncated := searchCallPaths([]protocol.CallHierarchyItem{callItem("main")}, graph.outgoing(&expansions), isTarget("missing"), 10, 100)
+		assert.Empty(t, paths)
+		assert.False(t, truncated)
+		assert.Equal(t, 51, expansions)
+	})
+
+	t.Run("expansion limit", func(t *testing.T) {
+		// A chain longer than maxCallExpansions
+		graph := callGraph{}
+		for i := 0; i < maxCallExpansions+10; i++ {
+			graph[fmt.Sprintf("f%d", i)] = []string{fmt.Sprintf("f%d", i+1)}
+		}
+		expansions := 0
+		paths, truncated := searchCallPaths([]protocol.CallHierarchyItem{callItem("f0")}, graph.outgoing(&expansions), isTarget("missing"), maxCallExpansions*2, 10)
+		assert.Empty(t, paths)
+		assert.True(t, truncated)
+		assert.Equal(t, maxCallExpansions, expansions)
+	})
+
+	t.Run("breadth limit", func(t *testing.T) {
+		graph := callGraph{"main": {"a", "b", "c"}}
+		expansions := 0
+		_, truncated := searchCallPaths([]protocol.CallHierarchyItem{callItem("main")}, graph.outgoing(&expansions), isTarget("missing"), 10, 2)
+		assert.True(t, truncated)
+	})
+}
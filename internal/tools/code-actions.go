@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/koonwen/mcp-language-server/internal/utilities"
+)
+
+// GetCodeActions lists the quick fixes and refactorings available for a
+// range, including fixes for the diagnostics that overlap it. If kinds is not
+// empty, only actions of those kinds or their sub-kinds are listed, e.g.
+// "refactor" also matches "refactor.extract".
+func GetCodeActions(ctx context.Context, client *lsp.Client, filePath string, startLine, startColumn, endLine, endColumn int, kinds []string) (string, error) {
+	rng, items, err := codeActionsAt(ctx, client, filePath, startLine, startColumn, endLine, endColumn, kinds)
+	if err != nil {
+		return "", err
+	}
+
+	location := fmt.Sprintf("%s %s", filePath, formatRange(rng))
+	if len(items) == 0 {
+		return msg(MsgNoCodeActions, location), nil
+	}
+
+	var output strings.Builder
+	output.WriteString(msg(MsgCodeActionsHeader, location, len(items)) + "\n")
+	for i, item := range items {
+		switch v := item.Value.(type) {
+		case protocol.CodeAction:
+			output.WriteString(fmt.Sprintf("%d. %s\n", i+1, formatCodeAction(v)))
+		case protocol.Command:
+			output.WriteString(fmt.Sprintf("%d. %s\n   %s\n", i+1, v.Title, msg(MsgCodeActionCommand, v.Command)))
+		}
+	}
+	return output.String(), nil
+}
+
+// codeActionsAt requests the code actions for a range, including fixes for
+// the diagnostics that overlap it, and keeps those of the given kinds. Each
+// item holds a protocol.CodeAction or a protocol.Command.
+func codeActionsAt(ctx context.Context, client *lsp.Client, filePath string, startLine, startColumn, endLine, endColumn int, kinds []string) (protocol.Range, []protocol.Or_Result_textDocument_codeAction_Item0_Elem, error) {
+	rng := protocol.Range{
+		Start: protocol.Position{Line: uint32(startLine - 1), Character: uint32(startColumn - 1)},
+		End:   protocol.Position{Line: uint32(endLine - 1), Character: uint32(endColumn - 1)},
+	}
+
+	uri, err := openDocument(ctx, client, filePath)
+	if err != nil {
+		return rng, nil, err
+	}
+
+	// Servers only offer quick fixes for the diagnostics they are given
+	var diagnostics []protocol.Diagnostic
+	for _, diag := range client.GetFileDiagnostics(uri) {
+		if utilities.RangesOverlap(diag.Range, rng) {
+			diagnostics = append(diagnostics, diag)
+		}
+	}
+
+	only := make([]protocol.CodeActionKind, len(kinds))
+	for i, kind := range kinds {
+		only[i] = protocol.CodeActionKind(kind)
+	}
+
+	results, err := client.CodeAction(ctx, protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        rng,
+		Context: protocol.CodeActionContext{
+			Diagnostics: diagnostics,
+			Only:        only,
+		},
+	})
+	if err != nil {
+		return rng, nil, fmt.Errorf("failed to get code actions: %v", err)
+	}
+
+	var items []protocol.Or_Result_textDocument_codeAction_Item0_Elem
+	for _, result := range results {
+		switch v := result.Value.(type) {
+		case protocol.CodeAction:
+			// Servers may ignore the requested kinds
+			if codeActionKindMatches(v.Kind, kinds) {
+				items = append(items, result)
+			}
+		case protocol.Command:
+			// Bare commands have no kind
+			if len(kinds) == 0 {
+				items = append(items, result)
+			}
+		}
+	}
+	return rng, items, nil
+}
+
+// formatCodeAction describes a code action: its kind, title, state, the
+// diagnostics it fixes, and what it changes
+func formatCodeAction(action protocol.CodeAction) string {
+	var result strings.Builder
+	if action.Kind != "" {
+		result.WriteString(fmt.Sprintf("[%s] ", action.Kind))
+	}
+	result.WriteString(action.Title)
+	if action.IsPreferred {
+		result.WriteString(" " + msg(MsgCodeActionPreferred))
+	}
+	if action.Disabled != nil {
+		result.WriteString(" " + msg(MsgCodeActionDisabled, action.Disabled.Reason))
+	}
+
+	for _, diag := range action.Diagnostics {
+		result.WriteString("\n   " + msg(MsgCodeActionFixes, firstLine(diag.Message)))
+	}
+	if action.Edit != nil {
+		changes, fileOps := summarizeWorkspaceEdit(*action.Edit)
+		if len(changes) > 0 {
+			paths := make([]string, len(changes))
+			for i, change := range changes {
+				paths[i] = change.path
+			}
+			result.WriteString("\n   " + msg(MsgCodeActionEdits, strings.Join(paths, ", ")))
+		}
+		for _, op := range fileOps {
+			result.WriteString("\n   " + op)
+		}
+	}
+	if action.Command != nil {
+		result.WriteString("\n   " + msg(MsgCodeActionCommand, action.Command.Command))
+	}
+
+	return result.String()
+}
+
+// codeActionKindMatches reports whether a code action kind is one of the
+// given kinds or a sub-kind of one. An empty list matches every kind.
+func codeActionKindMatches(kind protocol.CodeActionKind, kinds []string) bool {
+	if len(kinds) == 0 {
+		return true
+	}
+	for _, k := range kinds {
+		if string(kind) == k || strings.HasPrefix(string(kind), k+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// firstLine returns the first line of a possibly multi-line message
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	return line
+}
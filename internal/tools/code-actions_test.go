@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeActionKindMatches(t *testing.T) {
+	assert.True(t, codeActionKindMatches(protocol.RefactorExtract, nil))
+	assert.True(t, codeActionKindMatches(protocol.RefactorExtract, []string{"refactor"}))
+	assert.True(t, codeActionKindMatches(protocol.QuickFix, []string{"refactor", "quickfix"}))
+	assert.False(t, codeActionKindMatches(protocol.Refactor, []string{"refactor.extract"}))
+	assert.False(t, codeActionKindMatches("refactorx", []string{"refactor"}))
+	assert.False(t, codeActionKindMatches("", []string{"quickfix"}))
+}
+
+func TestFormatCodeAction(t *testing.T) {
+	action := protocol.CodeAction{
+		Title:       "Add import \"fmt\"",
+		Kind:        protocol.QuickFix,
+		IsPreferred: true,
+		Diagnostics: []protocol.Diagnostic{{Message: "undefined: fmt\nmore detail"}},
+		Edit: &protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+				"file:///ws/main.go": {{NewText: "import \"fmt\"\n"}},
+			},
+		},
+	}
+	assert.Equal(t, "[quickfix] Add import \"fmt\" (preferred)\n   Fixes: undefined: fmt\n   Edits: /ws/main.go", formatCodeAction(action))
+
+	action = protocol.CodeAction{
+		Title:    "Extract function",
+		Kind:     protocol.RefactorExtract,
+		Disabled: &protocol.CodeActionDisabled{Reason: "no statements selected"},
+		Command:  &protocol.Command{Title: "Extract function", Command: "gopls.apply_fix"},
+	}
+	assert.Equal(t, "[refactor.extract] Extract function (disabled: no statements selected)\n   Runs command: gopls.apply_fix", formatCodeAction(action))
+}
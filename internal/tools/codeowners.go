@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gitignore "github.com/sabhiram/go-gitignore"
+)
+
+// codeOwnersLocations are the places a CODEOWNERS file is looked for, in the
+// order GitHub uses
+var codeOwnersLocations = []string{
+	".github/CODEOWNERS",
+	"CODEOWNERS",
+	"docs/CODEOWNERS",
+}
+
+// codeOwnersRule is a single line of a CODEOWNERS file
+type codeOwnersRule struct {
+	pattern *gitignore.GitIgnore
+	owners  []string
+}
+
+// CodeOwners maps workspace files to their owners
+type CodeOwners struct {
+	basePath string
+	rules    []codeOwnersRule
+}
+
+// codeOwners holds the ownership rules of the workspace. It is only modified
+// at startup by SetCodeOwners, before any tool is called.
+var codeOwners *CodeOwners
+
+// SetCodeOwners sets the ownership rules used to annotate files in tool output
+func SetCodeOwners(owners *CodeOwners) {
+	codeOwners = owners
+}
+
+// LoadCodeOwners reads the CODEOWNERS file of a workspace. It returns nil if
+// the workspace has no CODEOWNERS file.
+func LoadCodeOwners(workspacePath string) (*CodeOwners, error) {
+	for _, location := range codeOwnersLocations {
+		content, err := os.ReadFile(filepath.Join(workspacePath, location))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", location, err)
+		}
+		return ParseCodeOwners(workspacePath, string(content)), nil
+	}
+	return nil, nil
+}
+
+// ParseCodeOwners parses the content of a CODEOWNERS file for the workspace
+// at basePath
+func ParseCodeOwners(basePath, content string) *CodeOwners {
+	owners := &CodeOwners{basePath: basePath}
+	for _, line := range strings.Split(content, "\n") {
+		// Strip comments
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		// Sections such as "[Docs]" are a GitLab extension
+		if strings.HasPrefix(fields[0], "[") || strings.HasPrefix(fields[0], "^[") {
+			continue
+		}
+
+		owners.rules = append(owners.rules, codeOwnersRule{
+			pattern: gitignore.CompileIgnoreLines(fields[0]),
+			owners:  fields[1:],
+		})
+	}
+	return owners
+}
+
+// Owners returns the owners of a file. As in GitHub, the last matching rule
+// takes precedence, and a matching rule without owners leaves the file unowned.
+func (c *CodeOwners) Owners(filePath string) []string {
+	if c == nil {
+		return nil
+	}
+
+	relPath := filePath
+	if filepath.IsAbs(filePath) {
+		var err error
+		relPath, err = filepath.Rel(c.basePath, filePath)
+		if err != nil || strings.HasPrefix(relPath, "..") {
+			return nil
+		}
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		if c.rules[i].pattern.MatchesPath(relPath) {
+			return c.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// formatOwners returns the owners line for a file in a file header, or an
+// empty string if the file has no known owners
+func formatOwners(filePath string) string {
+	owners := codeOwners.Owners(filePath)
+	if len(owners) == 0 {
+		return ""
+	}
+	return msg(MsgOwnersHeader, strings.Join(owners, " ")) + "\n"
+}
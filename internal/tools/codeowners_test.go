@@ -0,0 +1,38 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeOwners(t *testing.T) {
+	owners := ParseCodeOwners("/ws", `
+# Default owners
+*             @org/everyone
+
+*.go          @org/go-team   # Go code
+/docs/        @org/docs
+internal/lsp/ @org/lsp alice@example.com
+internal/lsp/generated.go
+[Section]
+`)
+
+	assert.Equal(t, []string{"@org/everyone"}, owners.Owners("/ws/README.md"))
+	assert.Equal(t, []string{"@org/go-team"}, owners.Owners("/ws/main.go"))
+	assert.Equal(t, []string{"@org/go-team"}, owners.Owners("/ws/internal/tools/hover.go"))
+	assert.Equal(t, []string{"@org/docs"}, owners.Owners("/ws/docs/guide/intro.md"))
+	assert.Equal(t, []string{"@org/lsp", "alice@example.com"}, owners.Owners("/ws/internal/lsp/client.go"))
+	assert.Equal(t, []string{"@org/lsp", "alice@example.com"}, owners.Owners("internal/lsp/client.go"))
+
+	// A rule without owners leaves matching files unowned
+	assert.Empty(t, owners.Owners("/ws/internal/lsp/generated.go"))
+
+	// Files outside the workspace have no owners
+	assert.Empty(t, owners.Owners("/other/main.go"))
+}
+
+func TestCodeOwnersNil(t *testing.T) {
+	var owners *CodeOwners
+	assert.Nil(t, owners.Owners("/ws/main.go"))
+}
@@ -0,0 +1,282 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// maxCompletionDocLines caps the documentation shown for each completion
+const maxCompletionDocLines = 3
+
+// GetCompletions lists the completions the language server offers at a
+// position, such as the members available after "foo.". Completions are
+// ranked by whether the server preselected them, how well they match the
+// partial name before the position and the server's own sort order, and only
+// the best are returned. With documentation set, completions missing a
+// detail or documentation are resolved to fill them in.
+func GetCompletions(ctx context.Context, client *lsp.Client, filePath string, line, column, limit int, documentation bool) (string, error) {
+	uri, err := openDocument(ctx, client, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := documentText(ctx, client, uri)
+	if err != nil {
+		return "", fmt.Errorf("could not read document: %v", err)
+	}
+	before := textBefore(content, line, column)
+
+	params := protocol.CompletionParams{
+		Context: protocol.CompletionContext{TriggerKind: protocol.Invoked},
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Position: protocol.Position{
+				Line:      uint32(line - 1),
+				Character: uint32(column - 1),
+			},
+		},
+	}
+	// Some servers only list members when told the completion follows a
+	// trigger character such as "."
+	if trigger := completionTrigger(client, before); trigger != "" {
+		params.Context = protocol.CompletionContext{TriggerKind: protocol.TriggerCharacter, TriggerCharacter: trigger}
+	}
+
+	result, err := client.Completion(ctx, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to get completions: %v", err)
+	}
+
+	var items []protocol.CompletionItem
+	switch v := result.Value.(type) {
+	case protocol.CompletionList:
+		items = v.Items
+	case []protocol.CompletionItem:
+		items = v
+	}
+
+	items = rankCompletions(completionPrefix(before), items)
+	total := len(items)
+	if total == 0 {
+		return msg(MsgNoCompletions, filePath, line, column), nil
+	}
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+
+	if documentation && client.SupportsMethod("completionItem/resolve") {
+		resolveCompletions(ctx, client, items)
+	}
+
+	var out strings.Builder
+	out.WriteString(msg(MsgCompletionsHeader, len(items), total, filePath, line, column) + "\n")
+	for _, item := range items {
+		out.WriteString(fmt.Sprintf("- %s %s", completionKind(item), item.Label))
+		if item.LabelDetails != nil && item.LabelDetails.Detail != "" {
+			out.WriteString(item.LabelDetails.Detail)
+		}
+		if detail := strings.TrimSpace(item.Detail); detail != "" && !strings.Contains(item.Label, detail) {
+			out.WriteString(" - " + strings.ReplaceAll(detail, "\n", " "))
+		}
+		if completionDeprecated(item) {
+			out.WriteString(" (deprecated)")
+		}
+		out.WriteString("\n")
+
+		if documentation {
+			for _, docLine := range completionDocumentation(item) {
+				out.WriteString("    " + docLine + "\n")
+			}
+		}
+	}
+	return out.String(), nil
+}
+
+// textBefore returns the text of a line up to a 1-indexed column
+func textBefore(content string, line, column int) string {
+	lines := strings.Split(content, "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	text := strings.TrimSuffix(lines[line-1], "\r")
+	return text[:min(max(column-1, 0), len(text))]
+}
+
+// completionPrefix returns the partial identifier at the end of some text
+func completionPrefix(before string) string {
+	start := len(before)
+	for start > 0 {
+		c, size := utf8.DecodeLastRuneInString(before[:start])
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' && c != '$' {
+			break
+		}
+		start -= size
+	}
+	return before[start:]
+}
+
+// completionTrigger returns the trigger character the text ends with, if the
+// server declares it as one
+func completionTrigger(client *lsp.Client, before string) string {
+	result := client.InitializeResult()
+	if result == nil || result.Capabilities.CompletionProvider == nil {
+		return ""
+	}
+	for _, trigger := range result.Capabilities.CompletionProvider.TriggerCharacters {
+		if trigger != "" && strings.HasSuffix(before, trigger) {
+			return trigger
+		}
+	}
+	return ""
+}
+
+// rankCompletions drops completions that do not match the partial name and
+// orders the rest, best first
+func rankCompletions(prefix string, items []protocol.CompletionItem) []protocol.CompletionItem {
+	type ranked struct {
+		item  protocol.CompletionItem
+		score int
+	}
+
+	var candidates []ranked
+	for _, item := range items {
+		name := item.FilterText
+		if name == "" {
+			name = item.Label
+		}
+
+		score := 0
+		switch {
+		case prefix == "":
+		case strings.HasPrefix(name, prefix):
+			score += 4
+		case strings.HasPrefix(strings.ToLower(name), strings.ToLower(prefix)):
+			score += 3
+		case isSubsequence(strings.ToLower(prefix), strings.ToLower(name)):
+		default:
+			continue
+		}
+		if item.Preselect {
+			score += 8
+		}
+		if completionDeprecated(item) {
+			score -= 2
+		}
+		// Keywords and snippets rarely answer what an API offers
+		if item.Kind == protocol.KeywordCompletion || item.Kind == protocol.SnippetCompletion {
+			score -= 1
+		}
+		candidates = append(candidates, ranked{item, score})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return completionSortKey(candidates[i].item) < completionSortKey(candidates[j].item)
+	})
+
+	result := make([]protocol.CompletionItem, len(candidates))
+	for i, c := range candidates {
+		result[i] = c.item
+	}
+	return result
+}
+
+func completionSortKey(item protocol.CompletionItem) string {
+	if item.SortText != "" {
+		return item.SortText
+	}
+	return item.Label
+}
+
+// isSubsequence reports whether the characters of sub appear in order in s
+func isSubsequence(sub, s string) bool {
+	for _, c := range s {
+		if sub == "" {
+			break
+		}
+		if strings.HasPrefix(sub, string(c)) {
+			sub = sub[len(string(c)):]
+		}
+	}
+	return sub == ""
+}
+
+// resolveCompletions resolves the completions that lack a detail or
+// documentation. Items that fail to resolve are left as they are.
+func resolveCompletions(ctx context.Context, client *lsp.Client, items []protocol.CompletionItem) {
+	var unresolved []int
+	for i, item := range items {
+		if item.Detail == "" || item.Documentation == nil {
+			unresolved = append(unresolved, i)
+		}
+	}
+
+	errs := fanOut(ctx, len(unresolved), func(ctx context.Context, i int) error {
+		resolved, err := client.ResolveCompletionItem(ctx, items[unresolved[i]])
+		if err != nil {
+			return err
+		}
+		items[unresolved[i]] = resolved
+		return nil
+	})
+	for _, err := range errs {
+		if err != nil {
+			toolsLogger.Debug("Error resolving completion item: %v", err)
+		}
+	}
+}
+
+func completionKind(item protocol.CompletionItem) string {
+	if kind, ok := protocol.CompletionKindMap[item.Kind]; ok {
+		return kind
+	}
+	return "Text"
+}
+
+func completionDeprecated(item protocol.CompletionItem) bool {
+	if item.Deprecated {
+		return true
+	}
+	for _, tag := range item.Tags {
+		if tag == protocol.ComplDeprecated {
+			return true
+		}
+	}
+	return false
+}
+
+// completionDocumentation returns the first lines of the first paragraph of
+// a completion's documentation
+func completionDocumentation(item protocol.CompletionItem) []string {
+	if item.Documentation == nil {
+		return nil
+	}
+	var text string
+	switch v := item.Documentation.Value.(type) {
+	case string:
+		text = v
+	case protocol.MarkupContent:
+		text = v.Value
+	}
+
+	paragraph, _, _ := strings.Cut(strings.TrimSpace(text), "\n\n")
+	var lines []string
+	for _, line := range strings.Split(paragraph, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > maxCompletionDocLines {
+		lines = append(lines[:maxCompletionDocLines], "...")
+	}
+	return lines
+}
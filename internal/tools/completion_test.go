@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompletionPrefix(t *testing.T) {
+	assert.Equal(t, "Spl", completionPrefix("\tstrings.Spl"))
+	assert.Equal(t, "", completionPrefix("\tstrings."))
+	assert.Equal(t, "größe", completionPrefix("x.größe"))
+	assert.Equal(t, "\tstrings.Sp", textBefore("package main\n\tstrings.Split(s)\r\n", 2, 12))
+	assert.Equal(t, "", textBefore("package main", 3, 1))
+}
+
+func TestRankCompletions(t *testing.T) {
+	items := []protocol.CompletionItem{
+		{Label: "SplitAfter", SortText: "b"},
+		{Label: "split", SortText: "c"},
+		{Label: "Split", SortText: "a"},
+		{Label: "Fields", SortText: "d"},
+		{Label: "SplitN", SortText: "e", Tags: []protocol.CompletionItemTag{protocol.ComplDeprecated}},
+		{Label: "ToSuperPlain", SortText: "f"},
+		{Label: "Preselected", SortText: "g", Preselect: true},
+	}
+
+	var labels []string
+	for _, item := range rankCompletions("Sp", items) {
+		labels = append(labels, item.Label)
+	}
+	assert.Equal(t, []string{"Split", "SplitAfter", "split", "SplitN", "ToSuperPlain"}, labels)
+
+	// Without a partial name everything is kept in the server's order
+	labels = nil
+	for _, item := range rankCompletions("", items) {
+		labels = append(labels, item.Label)
+	}
+	assert.Equal(t, []string{"Preselected", "Split", "SplitAfter", "split", "Fields", "ToSuperPlain", "SplitN"}, labels)
+}
+
+func TestCompletionDocumentation(t *testing.T) {
+	item := protocol.CompletionItem{Documentation: &protocol.Or_CompletionItem_documentation{
+		Value: protocol.MarkupContent{Kind: protocol.Markdown, Value: "Split slices s.\nInto all substrings.\n\nMore details."},
+	}}
+	assert.Equal(t, []string{"Split slices s.", "Into all substrings."}, completionDocumentation(item))
+
+	item.Documentation.Value = "one\ntwo\nthree\nfour"
+	assert.Equal(t, []string{"one", "two", "three", "..."}, completionDocumentation(item))
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// GoToDeclaration finds the declaration of the symbol at the given position.
+// For languages that separate declarations from definitions, such as a C++
+// function declared in a header and defined in a source file, this returns
+// the declaration where GoToDefinition returns the definition.
+// Line and column are 1-indexed (will be converted to 0-indexed for LSP protocol).
+func GoToDeclaration(ctx context.Context, client *lsp.Client, filePath string, line, column int) (string, error) {
+	contextLines := ContextLines()
+
+	// Open the document if not already open
+	uri, err := openDocument(ctx, client, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	// Convert 1-indexed line/column to 0-indexed for LSP protocol
+	result, err := client.Declaration(ctx, protocol.DeclarationParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{
+				URI: uri,
+			},
+			Position: protocol.Position{
+				Line:      uint32(line - 1),
+				Character: uint32(column - 1),
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get declaration: %v", err)
+	}
+
+	locations := definitionLocations(result.Value)
+	if len(locations) == 0 {
+		return msg(MsgNoDeclaration, filePath, line, column), nil
+	}
+
+	declarations := formatDefinitionLocations(ctx, client, locations, contextLines)
+	if len(declarations) == 0 {
+		return msg(MsgCouldNotReadDefinition, filePath, line, column), nil
+	}
+
+	return strings.Join(declarations, ""), nil
+}
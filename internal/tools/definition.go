@@ -2,9 +2,8 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"os"
-	"strconv"
 	"strings"
 
 	"github.com/koonwen/mcp-language-server/internal/lsp"
@@ -15,22 +14,15 @@ import (
 // This is the position-based approach that uses the LSP textDocument/definition request.
 // Line and column are 1-indexed (will be converted to 0-indexed for LSP protocol).
 func GoToDefinition(ctx context.Context, client *lsp.Client, filePath string, line, column int) (string, error) {
-	// Get context lines from environment variable
-	contextLines := 5
-	if envLines := os.Getenv("LSP_CONTEXT_LINES"); envLines != "" {
-		if val, err := strconv.Atoi(envLines); err == nil && val >= 0 {
-			contextLines = val
-		}
-	}
+	contextLines := ContextLines()
 
-	// Open the file if not already open
-	err := client.OpenFile(ctx, filePath)
+	// Open the document if not already open
+	uri, err := openDocument(ctx, client, filePath)
 	if err != nil {
-		return "", fmt.Errorf("could not open file: %v", err)
+		return "", err
 	}
 
 	// Convert 1-indexed line/column to 0-indexed for LSP protocol
-	uri := protocol.DocumentUri("file://" + filePath)
 	position := protocol.Position{
 		Line:      uint32(line - 1),
 		Character: uint32(column - 1),
@@ -51,42 +43,65 @@ func GoToDefinition(ctx context.Context, client *lsp.Client, filePath string, li
 		return "", fmt.Errorf("failed to get definition: %v", err)
 	}
 
-	// Extract locations from the result
-	// The result can be Definition (Or_Definition containing Location or []Location) or []DefinitionLink
-	var locations []protocol.Location
-	if result.Value != nil {
-		switch v := result.Value.(type) {
-		case protocol.Definition:
-			// Definition is Or_Definition which contains Location or []Location
-			if v.Value != nil {
-				switch inner := v.Value.(type) {
-				case protocol.Location:
-					locations = append(locations, inner)
-				case []protocol.Location:
-					locations = inner
-				}
-			}
-		case protocol.Location:
-			locations = append(locations, v)
-		case []protocol.Location:
-			locations = v
-		case []protocol.DefinitionLink:
-			for _, link := range v {
-				locations = append(locations, protocol.Location{
-					URI:   link.TargetURI,
-					Range: link.TargetRange,
-				})
-			}
-		}
+	locations := definitionLocations(result.Value)
+	if len(locations) == 0 {
+		return msg(MsgNoDefinitionAtPosition, filePath, line, column), nil
 	}
 
-	if len(locations) == 0 {
-		return fmt.Sprintf("No definition found at %s:%d:%d", filePath, line, column), nil
+	definitions := formatDefinitionLocations(ctx, client, locations, contextLines)
+	if len(definitions) == 0 {
+		return msg(MsgCouldNotReadDefinition, filePath, line, column), nil
+	}
+
+	text := strings.Join(definitions, "")
+	prefetchDefinitions(client, snippetCode(text), "")
+	return text, nil
+}
+
+// definitionLocations extracts the locations from the result of a definition,
+// declaration, type definition or implementation request, which may be a
+// Location, a list of Locations or a list of DefinitionLinks
+func definitionLocations(value any) []protocol.Location {
+	var locations []protocol.Location
+	switch v := value.(type) {
+	case protocol.Definition:
+		// Definition is Or_Definition which contains Location or []Location
+		return definitionLocations(v.Value)
+	case protocol.Declaration:
+		// Declaration is Or_Declaration which contains Location or []Location
+		return definitionLocations(v.Value)
+	case protocol.Location:
+		locations = append(locations, v)
+	case []protocol.Location:
+		locations = v
+	case []protocol.DefinitionLink:
+		for _, link := range v {
+			locations = append(locations, protocol.Location{
+				URI:   link.TargetURI,
+				Range: link.TargetRange,
+			})
+		}
 	}
+	return locations
+}
 
+// formatDefinitionLocations renders the full definition at each location with
+// context. Locations that cannot be read are logged and skipped.
+func formatDefinitionLocations(ctx context.Context, client *lsp.Client, locations []protocol.Location, contextLines int) []string {
 	var definitions []string
 
 	for _, loc := range locations {
+		// Definitions in archives or virtual documents cannot be opened as files
+		if !lsp.IsFileURI(loc.URI) {
+			definition, err := formatReadOnlyDefinition(ctx, client, loc, contextLines)
+			if err != nil {
+				toolsLogger.Error("Error reading definition in %s: %v", loc.URI, err)
+				continue
+			}
+			definitions = append(definitions, definition)
+			continue
+		}
+
 		defFilePath := strings.TrimPrefix(string(loc.URI), "file://")
 
 		// Open the definition file
@@ -104,13 +119,13 @@ func GoToDefinition(ctx context.Context, client *lsp.Client, filePath string, li
 		}
 
 		// Read file to get context
-		fileContent, err := os.ReadFile(defFilePath)
+		fileContent, err := client.ReadDocument(loc.URI)
 		if err != nil {
 			toolsLogger.Error("Error reading file: %v", err)
 			continue
 		}
 
-		lines := strings.Split(string(fileContent), "\n")
+		lines := strings.Split(fileContent, "\n")
 
 		// Determine lines to show with context
 		startLine := int(expandedLoc.Range.Start.Line)
@@ -127,41 +142,159 @@ func GoToDefinition(ctx context.Context, client *lsp.Client, filePath string, li
 		}
 
 		banner := "---\n\n"
-		locationInfo := fmt.Sprintf(
-			"File: %s\n"+
-				"Definition at: L%d:C%d - L%d:C%d\n\n",
-			defFilePath,
-			expandedLoc.Range.Start.Line+1,
-			expandedLoc.Range.Start.Character+1,
-			expandedLoc.Range.End.Line+1,
-			expandedLoc.Range.End.Character+1,
-		)
-
-		definition = addLineNumbers(definition, int(expandedLoc.Range.Start.Line)+1)
+		locationInfo := msg(MsgFileHeader, defFilePath) + "\n" +
+			formatGeneratedFrom(defFilePath, "") +
+			msg(MsgDefinitionAtHeader,
+				expandedLoc.Range.Start.Line+1,
+				expandedLoc.Range.Start.Character+1,
+				expandedLoc.Range.End.Line+1,
+				expandedLoc.Range.End.Character+1,
+			) + "\n\n"
+
+		definition = snippets.dedupe(trimSnippet(defFilePath, addLineNumbers(definition, int(expandedLoc.Range.Start.Line)+1), nil))
+		definitions = append(definitions, banner+locationInfo+definition+"\n")
+	}
+
+	return definitions
+}
+
+// symbolDefinition is a definition found for a symbol name
+type symbolDefinition struct {
+	Name      string
+	Kind      string
+	Container string
+	Location  protocol.Location
+	Code      string
+}
+
+// ReadDefinition finds the definitions of a symbol by name and returns their
+// full source code
+func ReadDefinition(ctx context.Context, client *lsp.Client, symbolName string) (string, error) {
+	found, err := lookupDefinitions(ctx, client, symbolName)
+	if err != nil {
+		return "", err
+	}
+
+	var definitions []string
+	for _, def := range found {
+		kind := ""
+		if def.Kind != "" {
+			kind = msg(MsgKindHeader, def.Kind) + "\n"
+		}
+		container := ""
+		if def.Container != "" {
+			container = msg(MsgContainerHeader, def.Container) + "\n"
+		}
+
+		loc := def.Location
+		banner := "---\n\n"
+		locationInfo := msg(MsgSymbolHeader, def.Name) + "\n" +
+			msg(MsgFileHeader, strings.TrimPrefix(string(loc.URI), "file://")) + "\n" +
+			formatGeneratedFrom(strings.TrimPrefix(string(loc.URI), "file://"), def.Name) +
+			kind +
+			container +
+			msg(MsgRangeHeader,
+				loc.Range.Start.Line+1,
+				loc.Range.Start.Character+1,
+				loc.Range.End.Line+1,
+				loc.Range.End.Character+1,
+			) + "\n\n"
+
+		definition := snippets.dedupe(trimSnippet(lsp.DocumentPath(loc.URI), addLineNumbers(def.Code, int(loc.Range.Start.Line)+1), nil))
+
 		definitions = append(definitions, banner+locationInfo+definition+"\n")
 	}
 
 	if len(definitions) == 0 {
-		return fmt.Sprintf("Could not read definition at %s:%d:%d", filePath, line, column), nil
+		return msg(MsgSymbolNotFound, symbolName), nil
 	}
 
+	prefetchDefinitions(client, definitionsCode(found), symbolName)
 	return strings.Join(definitions, ""), nil
 }
 
-func ReadDefinition(ctx context.Context, client *lsp.Client, symbolName string) (string, error) {
+// DefinitionJSON is a definition in the json output of ReadDefinitionJSON
+type DefinitionJSON struct {
+	Name          string `json:"name"`
+	Kind          string `json:"kind,omitempty"`
+	Container     string `json:"container,omitempty"`
+	Path          string `json:"path"`
+	GeneratedFrom string `json:"generatedFrom,omitempty"`
+	StartLine     int    `json:"startLine"`
+	StartColumn   int    `json:"startColumn"`
+	EndLine       int    `json:"endLine"`
+	EndColumn     int    `json:"endColumn"`
+	Documentation string `json:"documentation,omitempty"`
+	Code          string `json:"code"`
+}
+
+// ReadDefinitionJSON is like ReadDefinition but returns json, with the
+// documentation of each definition separated from its code
+func ReadDefinitionJSON(ctx context.Context, client *lsp.Client, symbolName string) (string, error) {
+	found, err := lookupDefinitions(ctx, client, symbolName)
+	if err != nil {
+		return "", err
+	}
+
+	definitions := make([]DefinitionJSON, 0, len(found))
+	for _, def := range found {
+		path := lsp.DocumentPath(def.Location.URI)
+		documentation := ""
+		if content, err := client.ReadDocument(def.Location.URI); err == nil {
+			documentation = ExtractDocumentation(path, strings.Split(content, "\n"), int(def.Location.Range.Start.Line))
+		}
+
+		definitions = append(definitions, DefinitionJSON{
+			Name:          def.Name,
+			Kind:          def.Kind,
+			Container:     def.Container,
+			Path:          path,
+			GeneratedFrom: generatedSource(path, def.Name),
+			StartLine:     int(def.Location.Range.Start.Line) + 1,
+			StartColumn:   int(def.Location.Range.Start.Character) + 1,
+			EndLine:       int(def.Location.Range.End.Line) + 1,
+			EndColumn:     int(def.Location.Range.End.Character) + 1,
+			Documentation: documentation,
+			Code:          def.Code,
+		})
+	}
+
+	data, err := json.MarshalIndent(struct {
+		Symbol      string           `json:"symbol"`
+		Definitions []DefinitionJSON `json:"definitions"`
+	}{symbolName, definitions}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal definitions: %v", err)
+	}
+	prefetchDefinitions(client, definitionsCode(found), symbolName)
+	return string(data), nil
+}
+
+// definitionsCode joins the code of definitions
+func definitionsCode(found []symbolDefinition) string {
+	code := make([]string, len(found))
+	for i, def := range found {
+		code[i] = def.Code
+	}
+	return strings.Join(code, "\n")
+}
+
+// findDefinitions looks up the symbols named symbolName in the workspace and
+// reads their full definitions
+func findDefinitions(ctx context.Context, client *lsp.Client, symbolName string) ([]symbolDefinition, error) {
 	symbolResult, err := client.Symbol(ctx, protocol.WorkspaceSymbolParams{
 		Query: symbolName,
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch symbol: %v", err)
+		return nil, fmt.Errorf("failed to fetch symbol: %v", err)
 	}
 
 	results, err := symbolResult.Results()
 	if err != nil {
-		return "", fmt.Errorf("failed to parse results: %v", err)
+		return nil, fmt.Errorf("failed to parse results: %v", err)
 	}
 
-	var definitions []string
+	var definitions []symbolDefinition
 	for _, symbol := range results {
 		kind := ""
 		container := ""
@@ -171,10 +304,8 @@ func ReadDefinition(ctx context.Context, client *lsp.Client, symbolName string)
 		switch v := symbol.(type) {
 		case *protocol.SymbolInformation:
 			// SymbolInformation results have richer data.
-			kind = fmt.Sprintf("Kind: %s\n", protocol.TableKindMap[v.Kind])
-			if v.ContainerName != "" {
-				container = fmt.Sprintf("Container Name: %s\n", v.ContainerName)
-			}
+			kind = protocol.TableKindMap[v.Kind]
+			container = v.ContainerName
 
 			// Handle different matching strategies based on the search term
 			if strings.Contains(symbolName, ".") {
@@ -203,41 +334,26 @@ func ReadDefinition(ctx context.Context, client *lsp.Client, symbolName string)
 		toolsLogger.Debug("Found symbol: %s", symbol.GetName())
 		loc := symbol.GetLocation()
 
-		err := client.OpenFile(ctx, loc.URI.Path())
+		_, err := openDocument(ctx, client, lsp.DocumentPath(loc.URI))
 		if err != nil {
 			toolsLogger.Error("Error opening file: %v", err)
 			continue
 		}
 
-		banner := "---\n\n"
 		definition, loc, err := GetFullDefinition(ctx, client, loc)
-		locationInfo := fmt.Sprintf(
-			"Symbol: %s\n"+
-				"File: %s\n"+
-				kind+
-				container+
-				"Range: L%d:C%d - L%d:C%d\n\n",
-			symbol.GetName(),
-			strings.TrimPrefix(string(loc.URI), "file://"),
-			loc.Range.Start.Line+1,
-			loc.Range.Start.Character+1,
-			loc.Range.End.Line+1,
-			loc.Range.End.Character+1,
-		)
-
 		if err != nil {
 			toolsLogger.Error("Error getting definition: %v", err)
 			continue
 		}
 
-		definition = addLineNumbers(definition, int(loc.Range.Start.Line)+1)
-
-		definitions = append(definitions, banner+locationInfo+definition+"\n")
-	}
-
-	if len(definitions) == 0 {
-		return fmt.Sprintf("%s not found", symbolName), nil
+		definitions = append(definitions, symbolDefinition{
+			Name:      symbol.GetName(),
+			Kind:      kind,
+			Container: container,
+			Location:  loc,
+			Code:      definition,
+		})
 	}
 
-	return strings.Join(definitions, ""), nil
+	return definitions, nil
 }
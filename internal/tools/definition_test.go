@@ -0,0 +1,26 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefinitionLocations(t *testing.T) {
+	loc := protocol.Location{
+		URI:   "file:///src/a.go",
+		Range: protocol.Range{Start: protocol.Position{Line: 3}, End: protocol.Position{Line: 5}},
+	}
+	other := protocol.Location{URI: "file:///src/b.go"}
+
+	assert.Empty(t, definitionLocations(nil))
+	assert.Equal(t, []protocol.Location{loc}, definitionLocations(protocol.Definition{Value: loc}))
+	assert.Equal(t, []protocol.Location{loc, other}, definitionLocations(protocol.Definition{Value: []protocol.Location{loc, other}}))
+	assert.Equal(t, []protocol.Location{other}, definitionLocations(protocol.Declaration{Value: other}))
+	assert.Equal(t, []protocol.Location{loc}, definitionLocations([]protocol.DefinitionLink{{
+		TargetURI:            loc.URI,
+		TargetRange:          loc.Range,
+		TargetSelectionRange: protocol.Range{Start: protocol.Position{Line: 4}},
+	}}))
+}
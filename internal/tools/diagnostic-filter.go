@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// DiagnosticFilter selects the diagnostics the diagnostics tools report, so
+// that lint noise can be left out when only compile errors matter. The zero
+// value keeps every diagnostic.
+type DiagnosticFilter struct {
+	// MinSeverity keeps diagnostics at least this severe, e.g. errors and
+	// warnings for SeverityWarning. Diagnostics without a severity are kept.
+	MinSeverity protocol.DiagnosticSeverity
+	// Sources keeps diagnostics from these sources, e.g. "compiler" or
+	// "go vet", compared case-insensitively
+	Sources []string
+	// Codes keeps diagnostics with these codes
+	Codes []string
+}
+
+// ParseSeverity converts a severity name, error, warning, info or hint, to
+// the LSP severity
+func ParseSeverity(name string) (protocol.DiagnosticSeverity, error) {
+	switch strings.ToLower(name) {
+	case "error":
+		return protocol.SeverityError, nil
+	case "warning":
+		return protocol.SeverityWarning, nil
+	case "info", "information":
+		return protocol.SeverityInformation, nil
+	case "hint":
+		return protocol.SeverityHint, nil
+	default:
+		return 0, fmt.Errorf("unknown severity %q, expected error, warning, info or hint", name)
+	}
+}
+
+// matches reports whether a diagnostic passes the filter
+func (f DiagnosticFilter) matches(diag protocol.Diagnostic) bool {
+	// Lower values are more severe
+	if f.MinSeverity != 0 && diag.Severity != 0 && diag.Severity > f.MinSeverity {
+		return false
+	}
+	if len(f.Sources) > 0 && !containsFold(f.Sources, diag.Source) {
+		return false
+	}
+	if len(f.Codes) > 0 && (diag.Code == nil || !containsFold(f.Codes, fmt.Sprintf("%v", diag.Code))) {
+		return false
+	}
+	return true
+}
+
+// apply returns the diagnostics that pass the filter and the number left out
+func (f DiagnosticFilter) apply(diagnostics []protocol.Diagnostic) ([]protocol.Diagnostic, int) {
+	var kept []protocol.Diagnostic
+	for _, diag := range diagnostics {
+		if f.matches(diag) {
+			kept = append(kept, diag)
+		}
+	}
+	return kept, len(diagnostics) - len(kept)
+}
+
+// filteredNote notes how many diagnostics the filter left out, if any
+func filteredNote(hidden int) string {
+	if hidden == 0 {
+		return ""
+	}
+	return msg(MsgDiagnosticsFiltered, hidden) + "\n"
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
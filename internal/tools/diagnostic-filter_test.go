@@ -0,0 +1,43 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnosticFilter(t *testing.T) {
+	diagnostics := []protocol.Diagnostic{
+		{Severity: protocol.SeverityError, Source: "compiler", Code: "UndeclaredName", Message: "undefined: foo"},
+		{Severity: protocol.SeverityWarning, Source: "go vet", Code: "printf", Message: "wrong format"},
+		{Severity: protocol.SeverityHint, Source: "staticcheck", Code: float64(1005), Message: "unnecessary assignment"},
+		{Message: "no severity"},
+	}
+
+	messages := func(filter DiagnosticFilter) []string {
+		kept, hidden := filter.apply(diagnostics)
+		var out []string
+		for _, diag := range kept {
+			out = append(out, diag.Message)
+		}
+		assert.Equal(t, len(diagnostics)-len(kept), hidden)
+		return out
+	}
+
+	assert.Len(t, messages(DiagnosticFilter{}), 4)
+	assert.Equal(t, []string{"undefined: foo", "no severity"}, messages(DiagnosticFilter{MinSeverity: protocol.SeverityError}))
+	assert.Equal(t, []string{"undefined: foo", "wrong format", "no severity"}, messages(DiagnosticFilter{MinSeverity: protocol.SeverityWarning}))
+	assert.Equal(t, []string{"wrong format"}, messages(DiagnosticFilter{Sources: []string{"Go Vet"}}))
+	assert.Equal(t, []string{"unnecessary assignment"}, messages(DiagnosticFilter{Codes: []string{"1005"}}))
+	assert.Empty(t, messages(DiagnosticFilter{MinSeverity: protocol.SeverityError, Sources: []string{"go vet"}}))
+}
+
+func TestParseSeverity(t *testing.T) {
+	severity, err := ParseSeverity("Warning")
+	assert.NoError(t, err)
+	assert.Equal(t, protocol.SeverityWarning, severity)
+
+	_, err = ParseSeverity("fatal")
+	assert.Error(t, err)
+}
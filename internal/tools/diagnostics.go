@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 
@@ -12,47 +11,111 @@ import (
 	"github.com/koonwen/mcp-language-server/internal/protocol"
 )
 
-// GetDiagnosticsForFile retrieves diagnostics for a specific file from the language server
-func GetDiagnosticsForFile(ctx context.Context, client *lsp.Client, filePath string, contextLines int, showLineNumbers bool) (string, error) {
-	// Override with environment variable if specified
-	if envLines := os.Getenv("LSP_CONTEXT_LINES"); envLines != "" {
-		if val, err := strconv.Atoi(envLines); err == nil && val >= 0 {
-			contextLines = val
+const (
+	// diagnosticsWait bounds how long tools wait for a server that does not
+	// support pull diagnostics to publish diagnostics for a file
+	diagnosticsWait = 3 * time.Second
+	// analysisWait bounds how long WaitForAnalysis waits for the server to
+	// finish re-analyzing after a change
+	analysisWait = 30 * time.Second
+	// analysisSettle is how long the server must go without reporting
+	// progress or publishing diagnostics to be considered done
+	analysisSettle = 500 * time.Millisecond
+)
+
+// WaitForAnalysis waits until the language server has finished re-analyzing
+// after a change, so that diagnostics requested next are not those from
+// before the change. If filePath is given, the file is first synchronized
+// with its content on disk. It returns a note to add to the diagnostics if
+// the server was still busy after analysisWait.
+func WaitForAnalysis(ctx context.Context, client *lsp.Client, filePath string) string {
+	if filePath != "" {
+		if err := syncFile(ctx, client, filePath); err != nil {
+			toolsLogger.Error("Error synchronizing file: %v", err)
 		}
 	}
+	if !client.WaitForQuiescence(ctx, analysisSettle, analysisWait) {
+		return msg(MsgAnalysisUnfinished, analysisWait)
+	}
+	return ""
+}
+
+// DiagnosticsOutput is the data passed to the diagnostics output template
+type DiagnosticsOutput struct {
+	Path        string           `json:"path"`
+	Owners      []string         `json:"owners,omitempty"`
+	Diagnostics []DiagnosticItem `json:"diagnostics"`
+	// Snippet holds the lines with diagnostics, with context and line numbers
+	Snippet string `json:"snippet,omitempty"`
+	// Error is set if the file could not be read
+	Error string `json:"error,omitempty"`
+}
+
+// DiagnosticItem is a single diagnostic, as exposed to output templates.
+// Positions are 1-indexed.
+type DiagnosticItem struct {
+	Severity string `json:"severity"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Message  string `json:"message"`
+	Source   string `json:"source,omitempty"`
+	Code     string `json:"code,omitempty"`
+	// Summary is the one line summary used in the default output
+	Summary string `json:"-"`
+}
+
+// GetDiagnosticsForFile retrieves diagnostics for a specific file from the
+// language server, keeping those that pass filter
+func GetDiagnosticsForFile(ctx context.Context, client *lsp.Client, filePath string, contextLines int, showLineNumbers bool, filter DiagnosticFilter) (string, error) {
+	// Override with the configured context lines if set
+	contextLines = configuredContextLines(contextLines)
 
 	err := client.OpenFile(ctx, filePath)
 	if err != nil {
 		return "", fmt.Errorf("could not open file: %v", err)
 	}
 
-	// Wait for diagnostics
-	// TODO: wait for notification
-	time.Sleep(time.Second * 3)
-
 	// Convert the file path to URI format
 	uri := protocol.DocumentUri("file://" + filePath)
 
-	// Request fresh diagnostics
-	diagParams := protocol.DocumentDiagnosticParams{
-		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
-	}
-	_, err = client.Diagnostic(ctx, diagParams)
+	// Pull fresh diagnostics if the server supports it, otherwise wait for
+	// the server to publish them
+	pulled, err := client.PullDiagnostics(ctx, uri)
 	if err != nil {
 		toolsLogger.Error("Failed to get diagnostics: %v", err)
 	}
+	if !pulled || err != nil {
+		client.WaitForDiagnostics(ctx, uri, diagnosticsWait)
+	}
 
 	// Get diagnostics from the cache
-	diagnostics := client.GetFileDiagnostics(uri)
+	diagnostics, hidden := filter.apply(client.GetFileDiagnostics(uri))
+
+	output := DiagnosticsOutput{
+		Path:        filePath,
+		Owners:      codeOwners.Owners(filePath),
+		Diagnostics: []DiagnosticItem{},
+	}
+	for _, diag := range diagnostics {
+		output.Diagnostics = append(output.Diagnostics, newDiagnosticItem(diag))
+	}
 
 	if len(diagnostics) == 0 {
-		return "No diagnostics found for " + filePath, nil
+		if text, ok, err := renderTemplate(ctx, "diagnostics", output); ok {
+			return text, err
+		}
+		if hidden > 0 {
+			return msg(MsgNoDiagnostics, filePath) + "\n" + filteredNote(hidden), nil
+		}
+		return msg(MsgNoDiagnostics, filePath), nil
 	}
 
 	// Format file header
-	fileInfo := fmt.Sprintf("%s\nDiagnostics in File: %d\n",
+	fileInfo := fmt.Sprintf("%s\n%s%s\n%s",
 		filePath,
-		len(diagnostics),
+		formatOwners(filePath)+formatGeneratedFrom(filePath, ""),
+		msg(MsgDiagnosticsInFile, len(diagnostics)),
+		filteredNote(hidden),
 	)
 
 	// Create a summary of all the diagnostics
@@ -60,28 +123,7 @@ func GetDiagnosticsForFile(ctx context.Context, client *lsp.Client, filePath str
 	var diagLocations []protocol.Location
 
 	for _, diag := range diagnostics {
-		severity := getSeverityString(diag.Severity)
-		location := fmt.Sprintf("L%d:C%d",
-			diag.Range.Start.Line+1,
-			diag.Range.Start.Character+1)
-
-		summary := fmt.Sprintf("%s at %s: %s",
-			severity,
-			location,
-			diag.Message)
-
-		// Add source and code if available
-		if diag.Source != "" {
-			summary += fmt.Sprintf(" (Source: %s", diag.Source)
-			if diag.Code != nil {
-				summary += fmt.Sprintf(", Code: %v", diag.Code)
-			}
-			summary += ")"
-		} else if diag.Code != nil {
-			summary += fmt.Sprintf(" (Code: %v)", diag.Code)
-		}
-
-		diagSummaries = append(diagSummaries, summary)
+		diagSummaries = append(diagSummaries, formatDiagnosticSummary(diag))
 
 		// Create a location for this diagnostic to use with line ranges
 		diagLocations = append(diagLocations, protocol.Location{
@@ -91,12 +133,16 @@ func GetDiagnosticsForFile(ctx context.Context, client *lsp.Client, filePath str
 	}
 
 	// Format content with context
-	fileContent, err := os.ReadFile(filePath)
+	fileContent, err := client.ReadDocument(uri)
 	if err != nil {
-		return fileInfo + "\nError reading file: " + err.Error(), nil
+		output.Error = err.Error()
+		if text, ok, err := renderTemplate(ctx, "diagnostics", output); ok {
+			return text, err
+		}
+		return fileInfo + "\n" + msg(MsgErrorReadingFile, err.Error()), nil
 	}
 
-	lines := strings.Split(string(fileContent), "\n")
+	lines := strings.Split(fileContent, "\n")
 
 	// Collect lines to display
 	var linesToShow map[int]bool
@@ -121,6 +167,15 @@ func GetDiagnosticsForFile(ctx context.Context, client *lsp.Client, filePath str
 	// Convert to line ranges
 	lineRanges := ConvertLinesToRanges(linesToShow, len(lines))
 
+	diagnosed := make(map[int]bool)
+	for _, diag := range diagnostics {
+		diagnosed[int(diag.Range.Start.Line)+1] = true
+	}
+	output.Snippet = trimSnippet(filePath, FormatLinesWithRanges(lines, lineRanges), diagnosed)
+	if text, ok, err := renderTemplate(ctx, "diagnostics", output); ok {
+		return text, err
+	}
+
 	// Format with diagnostics summary in header
 	result := fileInfo
 	if len(diagSummaries) > 0 {
@@ -129,12 +184,67 @@ func GetDiagnosticsForFile(ctx context.Context, client *lsp.Client, filePath str
 
 	// Format the content with ranges
 	if showLineNumbers {
-		result += "\n" + FormatLinesWithRanges(lines, lineRanges)
+		result += "\n" + output.Snippet
 	}
 
 	return result, nil
 }
 
+// syncFile opens a file on the server or, if it is open, sends its content on
+// disk if that changed, e.g. after an edit made outside of the tools
+func syncFile(ctx context.Context, client *lsp.Client, filePath string) error {
+	if !client.IsFileOpen(filePath) {
+		return client.OpenFile(ctx, filePath)
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	return client.ChangeDocument(ctx, lsp.DocumentURI(filePath), string(content))
+}
+
+// newDiagnosticItem converts a diagnostic for use in output templates
+func newDiagnosticItem(diag protocol.Diagnostic) DiagnosticItem {
+	item := DiagnosticItem{
+		Severity: getSeverityString(diag.Severity),
+		Line:     int(diag.Range.Start.Line) + 1,
+		Column:   int(diag.Range.Start.Character) + 1,
+		Message:  diag.Message,
+		Source:   diag.Source,
+		Summary:  formatDiagnosticSummary(diag),
+	}
+	if diag.Code != nil {
+		item.Code = fmt.Sprintf("%v", diag.Code)
+	}
+	return item
+}
+
+// formatDiagnosticSummary renders a one line summary of a diagnostic
+func formatDiagnosticSummary(diag protocol.Diagnostic) string {
+	severity := getSeverityString(diag.Severity)
+	location := fmt.Sprintf("L%d:C%d",
+		diag.Range.Start.Line+1,
+		diag.Range.Start.Character+1)
+
+	summary := fmt.Sprintf("%s at %s: %s",
+		severity,
+		location,
+		diag.Message)
+
+	// Add source and code if available
+	if diag.Source != "" {
+		summary += fmt.Sprintf(" (Source: %s", diag.Source)
+		if diag.Code != nil {
+			summary += fmt.Sprintf(", Code: %v", diag.Code)
+		}
+		summary += ")"
+	} else if diag.Code != nil {
+		summary += fmt.Sprintf(" (Code: %v)", diag.Code)
+	}
+
+	return summary
+}
+
 func getSeverityString(severity protocol.DiagnosticSeverity) string {
 	switch severity {
 	case protocol.SeverityError:
@@ -0,0 +1,135 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// GetDiffDiagnostics reports the diagnostics that pass filter whose ranges
+// intersect the lines added or modified by a unified diff. Relative paths in
+// the diff are resolved against the workspace directory.
+func GetDiffDiagnostics(ctx context.Context, client *lsp.Client, workspaceDir string, diff string, filter DiagnosticFilter) (string, error) {
+	changed, err := ParseUnifiedDiff(diff)
+	if err != nil {
+		return "", err
+	}
+
+	files := make([]string, 0, len(changed))
+	for file := range changed {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	// Open every changed file before waiting, so the server can work on all
+	// of them at once
+	var opened []string
+	for _, file := range files {
+		filePath := file
+		if !filepath.IsAbs(filePath) {
+			filePath = filepath.Join(workspaceDir, filePath)
+		}
+		if err := client.OpenFile(ctx, filePath); err != nil {
+			toolsLogger.Error("Error opening file: %v", err)
+			continue
+		}
+		opened = append(opened, file)
+	}
+
+	// Pull diagnostics for the whole workspace in one request if the server
+	// supports it. Otherwise files are pulled one at a time below, or we wait
+	// for the server to publish diagnostics.
+	pulledWorkspace := false
+	pullPerFile := client.SupportsMethod("textDocument/diagnostic")
+	if len(opened) > 0 {
+		var err error
+		pulledWorkspace, err = client.PullWorkspaceDiagnostics(ctx)
+		if err != nil {
+			toolsLogger.Error("Failed to get workspace diagnostics: %v", err)
+			pulledWorkspace = false
+		}
+		if !pulledWorkspace && !pullPerFile {
+			deadline := time.Now().Add(diagnosticsWait)
+			for _, file := range opened {
+				filePath := file
+				if !filepath.IsAbs(filePath) {
+					filePath = filepath.Join(workspaceDir, filePath)
+				}
+				client.WaitForDiagnostics(ctx, protocol.DocumentUri("file://"+filePath), time.Until(deadline))
+			}
+		}
+	}
+
+	var sections []string
+	total, hidden := 0, 0
+	for _, file := range opened {
+		filePath := file
+		if !filepath.IsAbs(filePath) {
+			filePath = filepath.Join(workspaceDir, filePath)
+		}
+		uri := protocol.DocumentUri("file://" + filePath)
+
+		if !pulledWorkspace && pullPerFile {
+			if _, err := client.PullDiagnostics(ctx, uri); err != nil {
+				toolsLogger.Error("Failed to get diagnostics: %v", err)
+			}
+		}
+
+		var summaries []string
+		for _, diag := range client.GetFileDiagnostics(uri) {
+			if !diagnosticOnLines(diag, changed[file]) {
+				continue
+			}
+			if !filter.matches(diag) {
+				hidden++
+				continue
+			}
+			summaries = append(summaries, formatDiagnosticSummary(diag))
+		}
+		if len(summaries) == 0 {
+			continue
+		}
+
+		total += len(summaries)
+		sections = append(sections, fmt.Sprintf("---\n\n%s\n%s%s\n%s\n",
+			filePath,
+			formatOwners(filePath)+formatGeneratedFrom(filePath, ""),
+			msg(MsgDiagnosticsInFile, len(summaries)),
+			strings.Join(summaries, "\n"),
+		))
+	}
+
+	if len(sections) == 0 {
+		if hidden > 0 {
+			return msg(MsgNoDiffDiagnostics, len(files)) + "\n" + filteredNote(hidden), nil
+		}
+		return msg(MsgNoDiffDiagnostics, len(files)), nil
+	}
+
+	return msg(MsgDiffDiagnosticsSummary, total, len(sections), len(files)) + "\n" + filteredNote(hidden) + "\n" + strings.Join(sections, "\n"), nil
+}
+
+// diagnosticOnLines reports whether a diagnostic's range covers any of the
+// given 0-indexed lines
+func diagnosticOnLines(diag protocol.Diagnostic, lines map[int]bool) bool {
+	start := int(diag.Range.Start.Line)
+	end := int(diag.Range.End.Line)
+
+	// A range ending at the start of a line does not include that line
+	if end > start && diag.Range.End.Character == 0 {
+		end--
+	}
+
+	for line := start; line <= end; line++ {
+		if lines[line] {
+			return true
+		}
+	}
+	return false
+}
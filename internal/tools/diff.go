@@ -0,0 +1,196 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// ChangedLines maps file paths, as they appear in a diff, to the set of
+// 0-indexed lines added or modified in the new version of the file
+type ChangedLines map[string]map[int]bool
+
+// ParseUnifiedDiff extracts the changed lines of each file from a unified
+// diff. Only lines present in the new version are reported, so files that were
+// deleted and hunks that only remove lines do not contribute any lines.
+func ParseUnifiedDiff(diff string) (ChangedLines, error) {
+	changed := make(ChangedLines)
+
+	var file string
+	newLine := 0
+	// Lines remaining in the current hunk on each side
+	oldRemaining, newRemaining := 0, 0
+
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		inHunk := oldRemaining > 0 || newRemaining > 0
+
+		switch {
+		case inHunk && strings.HasPrefix(line, "+"):
+			if file != "" {
+				if changed[file] == nil {
+					changed[file] = make(map[int]bool)
+				}
+				changed[file][newLine-1] = true
+			}
+			newLine++
+			newRemaining--
+		case inHunk && strings.HasPrefix(line, "-"):
+			oldRemaining--
+		case strings.HasPrefix(line, "\\"):
+			// "\ No newline at end of file" marker
+		case inHunk:
+			// Context line
+			newLine++
+			oldRemaining--
+			newRemaining--
+		case strings.HasPrefix(line, "+++ "):
+			file = diffFilePath(strings.TrimPrefix(line, "+++ "))
+		case strings.HasPrefix(line, "@@ "):
+			hunk, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, err
+			}
+			newLine = hunk.newStart
+			oldRemaining, newRemaining = hunk.oldCount, hunk.newCount
+		case strings.HasPrefix(line, "diff "):
+			file = ""
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read diff: %v", err)
+	}
+
+	return changed, nil
+}
+
+// diffFilePath returns the path of a file from a diff header, or an empty
+// string if the file does not exist on that side of the diff
+func diffFilePath(header string) string {
+	// Some tools append a timestamp after a tab
+	if i := strings.Index(header, "\t"); i >= 0 {
+		header = header[:i]
+	}
+	if header == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(header, "b/")
+}
+
+// diffHunk describes the lines covered by a hunk. Lines are 1-indexed.
+type diffHunk struct {
+	oldCount int
+	newStart int
+	newCount int
+}
+
+// parseHunkHeader parses a hunk header such as "@@ -10,7 +12,8 @@ func main() {"
+func parseHunkHeader(header string) (diffHunk, error) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return diffHunk{}, fmt.Errorf("invalid hunk header: %s", header)
+	}
+
+	_, oldCount, err := parseHunkRange(strings.TrimPrefix(fields[1], "-"))
+	if err != nil {
+		return diffHunk{}, fmt.Errorf("invalid hunk header: %s", header)
+	}
+	newStart, newCount, err := parseHunkRange(strings.TrimPrefix(fields[2], "+"))
+	if err != nil {
+		return diffHunk{}, fmt.Errorf("invalid hunk header: %s", header)
+	}
+
+	return diffHunk{oldCount: oldCount, newStart: newStart, newCount: newCount}, nil
+}
+
+// parseHunkRange parses "start,count" or "start", in which case count is 1
+func parseHunkRange(r string) (int, int, error) {
+	startStr, countStr, hasCount := strings.Cut(r, ",")
+	start, err := strconv.Atoi(startStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !hasCount {
+		return start, 1, nil
+	}
+	count, err := strconv.Atoi(countStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, count, nil
+}
+
+// GitDiff returns the unified diff for a git revision range (e.g. "main...HEAD"
+// or "HEAD~3") in the given directory, without context lines
+func GitDiff(ctx context.Context, dir, revisionRange string) (string, error) {
+	if strings.HasPrefix(revisionRange, "-") {
+		return "", fmt.Errorf("invalid git range: %s", revisionRange)
+	}
+
+	return runGit(ctx, dir, "diff", "--no-color", "--no-ext-diff", "-U0", revisionRange, "--")
+}
+
+// GitShow returns the content of a file at a git revision and the commit the
+// revision resolves to
+func GitShow(ctx context.Context, filePath, revision string) (string, string, error) {
+	if revision == "" || strings.HasPrefix(revision, "-") {
+		return "", "", fmt.Errorf("invalid git revision: %s", revision)
+	}
+
+	// Paths starting with ./ are relative to the working directory
+	dir := filepath.Dir(filePath)
+	commit, err := runGit(ctx, dir, "rev-parse", "--short", "--verify", revision+"^{commit}")
+	if err != nil {
+		return "", "", err
+	}
+	content, err := runGit(ctx, dir, "show", revision+":./"+filepath.Base(filePath))
+	if err != nil {
+		return "", "", err
+	}
+	return content, strings.TrimSpace(commit), nil
+}
+
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s failed: %v", args[0], err)
+	}
+	return string(output), nil
+}
+
+// UnifiedDiff returns a unified diff with three lines of context between two
+// versions of a file, along with the number of lines added and removed
+func UnifiedDiff(path, before, after string) (string, int, int) {
+	a := difflib.SplitLines(before)
+	b := difflib.SplitLines(after)
+
+	added, removed := 0, 0
+	for _, op := range difflib.NewMatcher(a, b).GetOpCodes() {
+		if op.Tag != 'e' {
+			removed += op.I2 - op.I1
+			added += op.J2 - op.J1
+		}
+	}
+
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        a,
+		B:        b,
+		FromFile: "a/" + strings.TrimPrefix(path, "/"),
+		ToFile:   "b/" + strings.TrimPrefix(path, "/"),
+		Context:  3,
+	})
+	return diff, added, removed
+}
@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUnifiedDiff(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -3,4 +3,5 @@ package main
+ import "fmt"
+-func old() {}
++func new() {}
++func another() {}
+ 
+@@ -20 +21,0 @@ func main() {
+-	fmt.Println("removed")
+diff --git a/gone.go b/gone.go
+deleted file mode 100644
+--- a/gone.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package main
+-
+diff --git a/new.go b/new.go
+new file mode 100644
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1,2 @@
++package main
++++ not a header
+\ No newline at end of file
+`
+
+	changed, err := ParseUnifiedDiff(diff)
+	assert.NoError(t, err)
+
+	assert.Equal(t, ChangedLines{
+		"main.go": {3: true, 4: true},
+		"new.go":  {0: true, 1: true},
+	}, changed)
+}
+
+func TestParseUnifiedDiffInvalidHunk(t *testing.T) {
+	_, err := ParseUnifiedDiff("+++ b/main.go\n@@ bogus @@\n")
+	assert.Error(t, err)
+}
+
+func TestDiagnosticOnLines(t *testing.T) {
+	lines := map[int]bool{4: true}
+	diag := func(startLine, endLine, endChar uint32) protocol.Diagnostic {
+		return protocol.Diagnostic{Range: protocol.Range{
+			Start: protocol.Position{Line: startLine},
+			End:   protocol.Position{Line: endLine, Character: endChar},
+		}}
+	}
+
+	assert.True(t, diagnosticOnLines(diag(4, 4, 10), lines))
+	assert.True(t, diagnosticOnLines(diag(2, 6, 1), lines))
+	assert.False(t, diagnosticOnLines(diag(5, 5, 3), lines))
+	assert.False(t, diagnosticOnLines(diag(3, 4, 0), lines))
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	before := "package main\n\nfunc main()  {\nfmt.Println(\"hi\")\n}\n"
+	after := "package main\n\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n"
+
+	diff, added, removed := UnifiedDiff("/src/main.go", before, after)
+	assert.Equal(t, 2, added)
+	assert.Equal(t, 2, removed)
+	assert.Contains(t, diff, "--- a/src/main.go\n+++ b/src/main.go\n")
+	assert.Contains(t, diff, "-func main()  {\n-fmt.Println(\"hi\")\n+func main() {\n+\tfmt.Println(\"hi\")\n")
+
+	// The diff can be read back
+	changed, err := ParseUnifiedDiff(diff)
+	assert.NoError(t, err)
+	assert.Equal(t, ChangedLines{"src/main.go": {2: true, 3: true}}, changed)
+
+	diff, added, removed = UnifiedDiff("main.go", before, before)
+	assert.Empty(t, diff)
+	assert.Zero(t, added)
+	assert.Zero(t, removed)
+}
+
+func TestGitShow(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(output))
+	}
+	path := filepath.Join(dir, "pkg", "lib.go")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+
+	git("init", "-q")
+	assert.NoError(t, os.WriteFile(path, []byte("package pkg\n\nfunc Old() {}\n"), 0644))
+	git("add", ".")
+	git("commit", "-q", "-m", "first")
+	assert.NoError(t, os.WriteFile(path, []byte("package pkg\n\nfunc New() {}\n"), 0644))
+	git("commit", "-q", "-am", "second")
+
+	content, commit, err := GitShow(context.Background(), path, "HEAD~1")
+	assert.NoError(t, err)
+	assert.Equal(t, "package pkg\n\nfunc Old() {}\n", content)
+	assert.NotEmpty(t, commit)
+
+	_, _, err = GitShow(context.Background(), path, "--output=x")
+	assert.Error(t, err)
+	_, _, err = GitShow(context.Background(), path, "HEAD~5")
+	assert.Error(t, err)
+}
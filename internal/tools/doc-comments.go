@@ -0,0 +1,295 @@
+package tools
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// docStyle describes how a language documents its definitions
+type docStyle struct {
+	// Line comment markers, longest first
+	lineComments []string
+	// Block comment delimiters, if the language has them
+	blockStart, blockEnd string
+	// Whether definitions are documented by a string at the start of their
+	// body, as in Python
+	docstrings bool
+}
+
+var (
+	cStyle      = docStyle{lineComments: []string{"///", "//!", "//"}, blockStart: "/*", blockEnd: "*/"}
+	hashStyle   = docStyle{lineComments: []string{"#"}}
+	dashStyle   = docStyle{lineComments: []string{"---", "--"}}
+	pythonStyle = docStyle{lineComments: []string{"#"}, docstrings: true}
+	ocamlStyle  = docStyle{blockStart: "(*", blockEnd: "*)"}
+)
+
+// docStyles maps file extensions to the documentation style of their
+// language. Other files are assumed to use C-style comments.
+var docStyles = map[string]docStyle{
+	".py":   pythonStyle,
+	".pyi":  pythonStyle,
+	".rb":   hashStyle,
+	".sh":   hashStyle,
+	".bash": hashStyle,
+	".pl":   hashStyle,
+	".r":    hashStyle,
+	".jl":   hashStyle,
+	".ex":   hashStyle,
+	".exs":  hashStyle,
+	".nim":  hashStyle,
+	".lua":  dashStyle,
+	".hs":   dashStyle,
+	".elm":  dashStyle,
+	".sql":  dashStyle,
+	".ml":   ocamlStyle,
+	".mli":  ocamlStyle,
+}
+
+// rustDocAttribute matches #[doc = "..."] attributes
+var rustDocAttribute = regexp.MustCompile(`^#\[doc\s*=\s*("(?:[^"\\]|\\.)*")\]$`)
+
+// pythonDefinition matches the first line of a Python function or class
+var pythonDefinition = regexp.MustCompile(`^\s*(?:async\s+)?(?:def|class)\s`)
+
+// ExtractDocumentation returns the documentation of the definition starting
+// at a 0-indexed line of a file: the comments immediately before it, or in
+// Python its docstring. Comment markers and attributes such as annotations,
+// decorators and Rust #[...] are left out. It returns an empty string if the
+// definition is not documented.
+func ExtractDocumentation(path string, lines []string, line int) string {
+	if line < 0 || line >= len(lines) {
+		return ""
+	}
+
+	style, ok := docStyles[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		style = cStyle
+	}
+
+	// Some servers include the documentation in the range of a definition
+	line = skipDocumentation(style, lines, line)
+
+	if style.docstrings {
+		if doc := docstring(lines, line); doc != "" {
+			return doc
+		}
+	}
+	return leadingComments(style, lines, line)
+}
+
+// skipDocumentation returns the first line at or after a line that is not a
+// comment or an attribute
+func skipDocumentation(style docStyle, lines []string, line int) int {
+	for line < len(lines) {
+		trimmed := strings.TrimSpace(lines[line])
+		switch {
+		case style.blockStart != "" && strings.HasPrefix(trimmed, style.blockStart):
+			for line < len(lines) && !strings.Contains(lines[line], style.blockEnd) {
+				line++
+			}
+			line++
+		case isAttribute(trimmed):
+			line++
+		default:
+			if _, ok := stripLineComment(style, trimmed); !ok {
+				return line
+			}
+			line++
+		}
+	}
+	return min(line, len(lines))
+}
+
+// leadingComments collects the comments directly above a line
+func leadingComments(style docStyle, lines []string, line int) string {
+	i := line - 1
+
+	// Attributes may sit between the documentation and the definition. Rust
+	// doc attributes are documentation themselves.
+	var attributeDocs []string
+	for ; i >= 0; i-- {
+		trimmed := strings.TrimSpace(lines[i])
+		if !isAttribute(trimmed) {
+			break
+		}
+		if m := rustDocAttribute.FindStringSubmatch(trimmed); m != nil {
+			if doc, err := strconv.Unquote(m[1]); err == nil {
+				attributeDocs = append([]string{strings.TrimPrefix(doc, " ")}, attributeDocs...)
+			}
+		}
+	}
+
+	var doc []string
+	if i >= 0 && style.blockEnd != "" && strings.HasSuffix(strings.TrimSpace(lines[i]), style.blockEnd) {
+		end := i
+		for i >= 0 && !strings.Contains(lines[i], style.blockStart) {
+			i--
+		}
+		if i < 0 {
+			return ""
+		}
+		doc = stripBlockComment(style, lines[i:end+1])
+	} else {
+		for ; i >= 0; i-- {
+			text, ok := stripLineComment(style, strings.TrimSpace(lines[i]))
+			if !ok {
+				break
+			}
+			doc = append([]string{text}, doc...)
+		}
+	}
+
+	return joinDocLines(append(doc, attributeDocs...))
+}
+
+// isAttribute reports whether a line is an annotation, decorator or
+// attribute rather than documentation or code
+func isAttribute(trimmed string) bool {
+	switch {
+	case strings.HasPrefix(trimmed, "#[") || strings.HasPrefix(trimmed, "#!["):
+		// Rust
+		return true
+	case strings.HasPrefix(trimmed, "@"):
+		// Java, Kotlin and TypeScript annotations, Python decorators
+		return true
+	case strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+		// C# attributes
+		return true
+	}
+	return false
+}
+
+// stripLineComment returns the text of a line comment, without compiler
+// directives such as //go:generate
+func stripLineComment(style docStyle, trimmed string) (string, bool) {
+	for _, marker := range style.lineComments {
+		text, ok := strings.CutPrefix(trimmed, marker)
+		if !ok {
+			continue
+		}
+		if marker == "//" && (strings.HasPrefix(text, "go:") || strings.HasPrefix(text, "nolint")) {
+			return "", true
+		}
+		if marker == "#" && strings.HasPrefix(text, "!") {
+			// Shebang
+			return "", false
+		}
+		return strings.TrimPrefix(text, " "), true
+	}
+	return "", false
+}
+
+// stripBlockComment removes the delimiters of a block comment and the
+// asterisks that commonly start its lines
+func stripBlockComment(style docStyle, lines []string) []string {
+	doc := make([]string, len(lines))
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if i == 0 {
+			_, line, _ = strings.Cut(line, style.blockStart)
+			// Doc comment markers such as /** and (**
+			line = strings.TrimLeft(line, "*!")
+		}
+		if i == len(lines)-1 {
+			line = strings.TrimSuffix(line, style.blockEnd)
+		}
+		if i > 0 {
+			line = strings.TrimPrefix(line, "*")
+		}
+		doc[i] = strings.TrimPrefix(strings.TrimRight(line, " "), " ")
+	}
+	return doc
+}
+
+// docstring returns the docstring at the start of the body of a Python
+// function or class defined at a line
+func docstring(lines []string, line int) string {
+	// Skip decorators
+	i := line
+	for i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "@") {
+		i++
+	}
+	if i >= len(lines) || !pythonDefinition.MatchString(lines[i]) {
+		return ""
+	}
+
+	// Skip the rest of a signature spanning several lines
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if before, _, _ := strings.Cut(trimmed, "#"); strings.HasSuffix(strings.TrimSpace(before), ":") {
+			break
+		}
+	}
+
+	// The docstring is the first statement of the body
+	i++
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	if i >= len(lines) {
+		return ""
+	}
+
+	first := strings.TrimLeft(strings.TrimSpace(lines[i]), "rRuUbB")
+	var quote string
+	for _, q := range []string{`"""`, `'''`, `"`, `'`} {
+		if strings.HasPrefix(first, q) {
+			quote = q
+			break
+		}
+	}
+	if quote == "" {
+		return ""
+	}
+
+	body := strings.TrimPrefix(first, quote)
+	if end := strings.Index(body, quote); end >= 0 {
+		return strings.TrimSpace(body[:end])
+	}
+	if len(quote) == 1 {
+		return ""
+	}
+
+	doc := []string{body}
+	for i++; i < len(lines); i++ {
+		if end := strings.Index(lines[i], quote); end >= 0 {
+			doc = append(doc, lines[i][:end])
+			break
+		}
+		doc = append(doc, lines[i])
+	}
+	return joinDocLines(dedent(doc))
+}
+
+// dedent removes the indentation shared by all but the first line, which
+// follows the opening quotes of a docstring
+func dedent(lines []string) []string {
+	indent := -1
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		width := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || width < indent {
+			indent = width
+		}
+	}
+
+	result := make([]string, len(lines))
+	result[0] = strings.TrimSpace(lines[0])
+	for i, line := range lines[1:] {
+		if len(line) >= indent && indent > 0 {
+			line = line[indent:]
+		}
+		result[i+1] = strings.TrimRight(line, " \t")
+	}
+	return result
+}
+
+// joinDocLines joins documentation lines, dropping blank lines at either end
+func joinDocLines(lines []string) string {
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractDocumentation(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		source   string
+		line     int
+		expected string
+	}{
+		{
+			name:     "Go line comments",
+			path:     "main.go",
+			source:   "package main\n\n// Add returns the sum\n// of a and b.\n//\n//go:noinline\nfunc Add(a, b int) int {\n",
+			line:     6,
+			expected: "Add returns the sum\nof a and b.",
+		},
+		{
+			name:     "Separated by a blank line",
+			path:     "main.go",
+			source:   "// Package comment\n\nfunc Add() {}\n",
+			line:     2,
+			expected: "",
+		},
+		{
+			name:     "Javadoc block with annotations",
+			path:     "Main.java",
+			source:   "class A {\n  /**\n   * Adds numbers.\n   *\n   * @return the sum\n   */\n  @Override\n  public int add() {\n",
+			line:     7,
+			expected: "Adds numbers.\n\n@return the sum",
+		},
+		{
+			name:     "Rust docs and attributes in the range",
+			path:     "lib.rs",
+			source:   "/// A point.\n#[doc = \" In 2D.\"]\n#[derive(Debug)]\npub struct Point {\n",
+			line:     0,
+			expected: "A point.\nIn 2D.",
+		},
+		{
+			name:     "Python docstring",
+			path:     "app.py",
+			source:   "@cache\ndef add(a,\n        b):  # why\n    \"\"\"Add numbers.\n\n    Returns the sum.\n    \"\"\"\n    return a + b\n",
+			line:     0,
+			expected: "Add numbers.\n\nReturns the sum.",
+		},
+		{
+			name:     "Python one line docstring",
+			path:     "app.py",
+			source:   "class Point:\n    r'''A point.'''\n",
+			line:     0,
+			expected: "A point.",
+		},
+		{
+			name:     "Python comments without docstring",
+			path:     "app.py",
+			source:   "# The answer\nANSWER = 42\n",
+			line:     1,
+			expected: "The answer",
+		},
+		{
+			name:     "OCaml doc comment",
+			path:     "lib.ml",
+			source:   "(** [add a b] is the sum\n    of [a] and [b]. *)\nlet add a b = a + b\n",
+			line:     2,
+			expected: "[add a b] is the sum\nof [a] and [b].",
+		},
+		{
+			name:     "Undocumented",
+			path:     "main.ts",
+			source:   "const a = 1;\nfunction add() {}\n",
+			line:     1,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := strings.Split(tt.source, "\n")
+			assert.Equal(t, tt.expected, ExtractDocumentation(tt.path, lines, tt.line))
+		})
+	}
+}
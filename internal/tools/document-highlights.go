@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// highlightKinds names the kinds of document highlights. Servers leave the
+// kind out for plain textual occurrences.
+var highlightKinds = map[protocol.DocumentHighlightKind]string{
+	protocol.Text:  "text",
+	protocol.Read:  "read",
+	protocol.Write: "write",
+}
+
+// GetDocumentHighlights lists the occurrences in a file of the symbol at a
+// position, each classified as a read or a write of it where the server can
+// tell, e.g. to find where a variable is assigned. Line and column are
+// 1-indexed.
+func GetDocumentHighlights(ctx context.Context, client *lsp.Client, filePath string, line, column int) (string, error) {
+	uri, err := openDocument(ctx, client, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	highlights, err := client.DocumentHighlight(ctx, protocol.DocumentHighlightParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Position: protocol.Position{
+				Line:      uint32(line - 1),
+				Character: uint32(column - 1),
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get document highlights: %v", err)
+	}
+	if len(highlights) == 0 {
+		return msg(MsgNoHighlights, filePath, line, column), nil
+	}
+
+	content, err := documentText(ctx, client, uri)
+	if err != nil {
+		return "", fmt.Errorf("could not read document: %v", err)
+	}
+
+	return formatHighlights(filePath, strings.Split(content, "\n"), highlights), nil
+}
+
+// formatHighlights lists highlights in the order they appear in the file,
+// after a summary of their kinds
+func formatHighlights(filePath string, lines []string, highlights []protocol.DocumentHighlight) string {
+	sort.SliceStable(highlights, func(i, j int) bool {
+		a, b := highlights[i].Range.Start, highlights[j].Range.Start
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Character < b.Character
+	})
+
+	counts := make(map[string]int)
+	var entries []string
+	for _, highlight := range highlights {
+		kind := highlightKind(highlight.Kind)
+		counts[kind]++
+
+		start := highlight.Range.Start
+		text := ""
+		if int(start.Line) < len(lines) {
+			text = strings.TrimSpace(strings.TrimSuffix(lines[start.Line], "\r"))
+		}
+		entries = append(entries, fmt.Sprintf("L%d:C%d %-5s %s", start.Line+1, start.Character+1, kind, text))
+	}
+
+	name := rangeText(lines, highlights[0].Range)
+	var summary []string
+	for _, kind := range []string{"write", "read", "text"} {
+		if counts[kind] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[kind], kind))
+		}
+	}
+
+	return msg(MsgHighlightsHeader, len(highlights), name, filePath, strings.Join(summary, ", ")) + "\n" +
+		strings.Join(entries, "\n") + "\n"
+}
+
+// highlightKind names the kind of a highlight, text if the server gave none
+func highlightKind(kind protocol.DocumentHighlightKind) string {
+	if name, ok := highlightKinds[kind]; ok {
+		return name
+	}
+	return "text"
+}
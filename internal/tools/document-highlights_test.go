@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatHighlights(t *testing.T) {
+	lines := strings.Split(`	count := 0
+	for range items {
+		count++
+	}
+	return count`, "\n")
+
+	highlight := func(line, start, end uint32, kind protocol.DocumentHighlightKind) protocol.DocumentHighlight {
+		return protocol.DocumentHighlight{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: line, Character: start},
+				End:   protocol.Position{Line: line, Character: end},
+			},
+			Kind: kind,
+		}
+	}
+
+	result := formatHighlights("/ws/main.go", lines, []protocol.DocumentHighlight{
+		highlight(4, 8, 13, protocol.Read),
+		highlight(0, 1, 6, protocol.Write),
+		highlight(2, 2, 7, protocol.Write),
+		highlight(3, 0, 0, 0),
+	})
+
+	assert.Equal(t, `4 occurrences of "count" in /ws/main.go (2 write, 1 read, 1 text):
+L1:C2 write count := 0
+L3:C3 write count++
+L4:C1 text  }
+L5:C9 read  return count
+`, result)
+}
@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// GetDocumentLinks lists the links in a file, such as the files or packages
+// an import refers to and URLs in comments, with the text they are attached
+// to and their targets. Links the server returns without a target are
+// resolved when the server supports it.
+func GetDocumentLinks(ctx context.Context, client *lsp.Client, filePath string) (string, error) {
+	uri, err := openDocument(ctx, client, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	links, err := client.DocumentLink(ctx, protocol.DocumentLinkParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get document links: %v", err)
+	}
+	if len(links) == 0 {
+		return msg(MsgNoDocumentLinks, filePath), nil
+	}
+
+	if client.SupportsMethod("documentLink/resolve") {
+		for i, link := range links {
+			if link.Target != nil {
+				continue
+			}
+			resolved, err := client.ResolveDocumentLink(ctx, link)
+			if err != nil {
+				toolsLogger.Debug("Failed to resolve document link: %v", err)
+				continue
+			}
+			links[i] = resolved
+		}
+	}
+
+	var lines []string
+	if content, err := documentText(ctx, client, uri); err == nil {
+		lines = strings.Split(content, "\n")
+	}
+	return formatDocumentLinks(filePath, lines, links), nil
+}
+
+// formatDocumentLinks lists links with their position, the text they are
+// attached to and their target. File targets are shown as paths so they can
+// be passed to other tools.
+func formatDocumentLinks(filePath string, lines []string, links []protocol.DocumentLink) string {
+	var out strings.Builder
+	out.WriteString(msg(MsgDocumentLinksHeader, len(links), filePath) + "\n")
+	for _, link := range links {
+		start := link.Range.Start
+		out.WriteString(fmt.Sprintf("L%d:C%d", start.Line+1, start.Character+1))
+		if text := rangeText(lines, link.Range); text != "" {
+			out.WriteString(" " + truncatePreview(text))
+		}
+
+		target := msg(MsgLinkUnresolved)
+		if link.Target != nil {
+			target = lsp.DocumentPath(protocol.DocumentUri(*link.Target))
+		}
+		out.WriteString(" -> " + target)
+		if link.Tooltip != "" {
+			out.WriteString(" (" + link.Tooltip + ")")
+		}
+		out.WriteString("\n")
+	}
+	return out.String()
+}
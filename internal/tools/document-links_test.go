@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatDocumentLinks(t *testing.T) {
+	lines := strings.Split(`import (
+	"fmt"
+)
+
+// See https://go.dev/ref/spec`, "\n")
+
+	target := "file:///usr/lib/go/src/fmt"
+	url := "https://go.dev/ref/spec"
+	links := []protocol.DocumentLink{
+		{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: 1, Character: 2},
+				End:   protocol.Position{Line: 1, Character: 5},
+			},
+			Target: &target,
+		},
+		{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: 4, Character: 7},
+				End:   protocol.Position{Line: 4, Character: 30},
+			},
+			Target:  &url,
+			Tooltip: "Open in browser",
+		},
+		{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: 9, Character: 0},
+				End:   protocol.Position{Line: 9, Character: 4},
+			},
+		},
+	}
+
+	assert.Equal(t, `3 links in /ws/main.go:
+L2:C3 fmt -> /usr/lib/go/src/fmt
+L5:C8 https://go.dev/ref/spec -> https://go.dev/ref/spec (Open in browser)
+L10:C1 -> (unresolved)
+`, formatDocumentLinks("/ws/main.go", lines, links))
+}
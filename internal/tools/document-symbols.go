@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// GetDocumentSymbols returns an outline of a file: its types, functions,
+// methods and other symbols with their line ranges, nested by containment.
+// Servers that only return flat symbol lists are shown flat, with the
+// container of each symbol.
+func GetDocumentSymbols(ctx context.Context, client *lsp.Client, filePath string) (string, error) {
+	// Open the document if not already open
+	uri, err := openDocument(ctx, client, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	symResult, err := client.DocumentSymbol(ctx, protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{
+			URI: uri,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get document symbols: %v", err)
+	}
+
+	symbols, err := symResult.Results()
+	if err != nil {
+		return "", fmt.Errorf("failed to process document symbols: %v", err)
+	}
+	if len(symbols) == 0 {
+		return msg(MsgNoDocumentSymbols, filePath), nil
+	}
+
+	var result strings.Builder
+	result.WriteString(msg(MsgOutlineHeader, filePath) + "\n")
+	writeSymbolOutline(&result, symbols, 0)
+	return result.String(), nil
+}
+
+// writeSymbolOutline renders one line per symbol, indenting children
+func writeSymbolOutline(result *strings.Builder, symbols []protocol.DocumentSymbolResult, level int) {
+	indent := strings.Repeat("  ", level)
+	for _, sym := range symbols {
+		switch v := sym.(type) {
+		case *protocol.DocumentSymbol:
+			result.WriteString(fmt.Sprintf("%s- %s %s (%s)", indent, protocol.TableKindMap[v.Kind], v.Name, formatLineRange(v.Range)))
+			if v.Detail != "" {
+				result.WriteString(" " + v.Detail)
+			}
+			result.WriteString("\n")
+
+			children := make([]protocol.DocumentSymbolResult, len(v.Children))
+			for i := range v.Children {
+				children[i] = &v.Children[i]
+			}
+			writeSymbolOutline(result, children, level+1)
+		case *protocol.SymbolInformation:
+			result.WriteString(fmt.Sprintf("%s- %s %s (%s)", indent, protocol.TableKindMap[v.Kind], v.Name, formatLineRange(v.Location.Range)))
+			if v.ContainerName != "" {
+				result.WriteString(" " + msg(MsgSymbolContainer, v.ContainerName))
+			}
+			result.WriteString("\n")
+		}
+	}
+}
+
+// formatLineRange renders the 1-indexed lines covered by a range
+func formatLineRange(r protocol.Range) string {
+	if r.Start.Line == r.End.Line {
+		return fmt.Sprintf("L%d", r.Start.Line+1)
+	}
+	return fmt.Sprintf("L%d-L%d", r.Start.Line+1, r.End.Line+1)
+}
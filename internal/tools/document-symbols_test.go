@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func lineRange(start, end uint32) protocol.Range {
+	return protocol.Range{Start: protocol.Position{Line: start}, End: protocol.Position{Line: end}}
+}
+
+func TestWriteSymbolOutline(t *testing.T) {
+	symbols := []protocol.DocumentSymbolResult{
+		&protocol.DocumentSymbol{
+			Name:  "Server",
+			Kind:  protocol.Struct,
+			Range: lineRange(9, 14),
+			Children: []protocol.DocumentSymbol{
+				{Name: "addr", Kind: protocol.Field, Detail: "string", Range: lineRange(10, 10)},
+			},
+		},
+		&protocol.DocumentSymbol{Name: "main", Kind: protocol.Function, Detail: "func()", Range: lineRange(19, 24)},
+	}
+
+	var result strings.Builder
+	writeSymbolOutline(&result, symbols, 0)
+	assert.Equal(t, "- Struct Server (L10-L15)\n"+
+		"  - Field addr (L11) string\n"+
+		"- Function main (L20-L25) func()\n", result.String())
+
+	flat := []protocol.DocumentSymbolResult{
+		&protocol.SymbolInformation{
+			Name:          "area",
+			Kind:          protocol.Method,
+			ContainerName: "Circle",
+			Location:      protocol.Location{Range: lineRange(3, 5)},
+		},
+	}
+	result.Reset()
+	writeSymbolOutline(&result, flat, 0)
+	assert.Equal(t, "- Method area (L4-L6) in Circle\n", result.String())
+}
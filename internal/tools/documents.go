@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// openDocument opens the document for a path passed to a tool and returns its
+// URI. The path may also be a URI in another scheme that was reported by the
+// server, such as deno: or git:, in which case the content is fetched from
+// the server before the document is opened.
+func openDocument(ctx context.Context, client *lsp.Client, path string) (protocol.DocumentUri, error) {
+	uri := lsp.DocumentURI(path)
+	if lsp.IsFileURI(uri) || client.IsFileOpen(path) {
+		if err := client.OpenFile(ctx, path); err != nil {
+			return "", fmt.Errorf("could not open file: %v", err)
+		}
+		return uri, nil
+	}
+
+	content, err := readDocumentContent(ctx, client, uri)
+	if err != nil {
+		return "", fmt.Errorf("could not open document: %v", err)
+	}
+	if err := client.OpenDocument(ctx, uri, content); err != nil {
+		return "", fmt.Errorf("could not open document: %v", err)
+	}
+	return uri, nil
+}
+
+// documentText returns the text of a document as the server sees it, reading
+// files from the client or disk and other documents from the client or the
+// server
+func documentText(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri) (string, error) {
+	if lsp.IsFileURI(uri) {
+		return client.ReadDocument(uri)
+	}
+	return readDocumentContent(ctx, client, uri)
+}
+
+// saveFile tells the server that a file was written, letting it run its
+// on-save actions, and returns a note on the edits it made to the file on
+// save, if any, so that the caller's view of the file is not stale
+func saveFile(ctx context.Context, client *lsp.Client, path string) string {
+	applied, err := client.SaveFile(ctx, path)
+	if err != nil {
+		toolsLogger.Error("Error saving file: %v", err)
+	}
+	if applied == 0 {
+		return ""
+	}
+	return msg(MsgOnSaveEdits, applied, path) + "\n"
+}
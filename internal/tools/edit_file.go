@@ -13,13 +13,22 @@ import (
 	"github.com/koonwen/mcp-language-server/internal/utilities"
 )
 
+// TextEdit replaces whole lines of a file or, if StartColumn is set, the
+// characters from StartLine:StartColumn up to but not including
+// EndLine:EndColumn. Positions are 1-indexed.
 type TextEdit struct {
-	StartLine int    `json:"startLine" jsonschema:"required,description=Start line to replace, inclusive"`
-	EndLine   int    `json:"endLine" jsonschema:"required,description=End line to replace, inclusive"`
-	NewText   string `json:"newText" jsonschema:"description=Replacement text. Replace with the new text. Leave blank to remove lines."`
+	StartLine   int    `json:"startLine" jsonschema:"required,description=Start line to replace, inclusive"`
+	EndLine     int    `json:"endLine" jsonschema:"required,description=End line to replace, inclusive"`
+	StartColumn int    `json:"startColumn,omitempty" jsonschema:"description=Start column to replace, inclusive"`
+	EndColumn   int    `json:"endColumn,omitempty" jsonschema:"description=End column to replace, exclusive"`
+	NewText     string `json:"newText" jsonschema:"description=Replacement text. Replace with the new text. Leave blank to remove lines."`
 }
 
-func ApplyTextEdits(ctx context.Context, client *lsp.Client, filePath string, edits []TextEdit) (string, error) {
+// ApplyTextEdits applies edits to a file. The server's copy of the document
+// is updated with didChange before the file is written, so its view of the
+// file stays in sync, and then the file is saved. With dryRun, the changes
+// are returned as a unified diff and nothing is written.
+func ApplyTextEdits(ctx context.Context, client *lsp.Client, filePath string, edits []TextEdit, dryRun bool) (string, error) {
 	err := client.OpenFile(ctx, filePath)
 	if err != nil {
 		return "", fmt.Errorf("could not open file: %v", err)
@@ -36,6 +45,14 @@ func ApplyTextEdits(ctx context.Context, client *lsp.Client, filePath string, ed
 	linesRemovedSorted := 0
 	linesAddedSorted := 0
 	for _, edit := range sortedEdits {
+		if edit.StartColumn > 0 {
+			// Edits within lines only remove and add the line breaks
+			// they replace and insert
+			linesRemovedSorted += edit.EndLine - edit.StartLine
+			linesAddedSorted += strings.Count(edit.NewText, "\n")
+			continue
+		}
+
 		// Calculate lines removed: end - start + 1
 		removedLineCount := edit.EndLine - edit.StartLine + 1
 		linesRemovedSorted += removedLineCount
@@ -59,8 +76,13 @@ func ApplyTextEdits(ctx context.Context, client *lsp.Client, filePath string, ed
 	// Convert from input format to protocol.TextEdit
 	var textEdits []protocol.TextEdit
 	for _, edit := range edits {
-		// Get the range covering the requested lines
-		rng, err := getRange(edit.StartLine, edit.EndLine, filePath)
+		var rng protocol.Range
+		if edit.StartColumn > 0 {
+			rng, err = getCharacterRange(edit)
+		} else {
+			// Get the range covering the requested lines
+			rng, err = getRange(edit.StartLine, edit.EndLine, filePath)
+		}
 		if err != nil {
 			return "", fmt.Errorf("invalid position: %v", err)
 		}
@@ -72,17 +94,54 @@ func ApplyTextEdits(ctx context.Context, client *lsp.Client, filePath string, ed
 		})
 	}
 
-	edit := protocol.WorkspaceEdit{
-		Changes: map[protocol.DocumentUri][]protocol.TextEdit{
-			protocol.DocumentUri(filePath): textEdits,
-		},
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %v", err)
 	}
-
-	if err := utilities.ApplyWorkspaceEdit(edit); err != nil {
+	edited, err := utilities.EditContent(content, textEdits)
+	if err != nil {
 		return "", fmt.Errorf("failed to apply text edits: %v", err)
 	}
 
-	return fmt.Sprintf("Successfully applied text edits. %d lines removed, %d lines added.", linesRemovedSorted, linesAddedSorted), nil
+	if dryRun {
+		diff, _, _ := UnifiedDiff(filePath, string(content), string(edited))
+		return msg(MsgEditDryRun, filePath) + "\n\n" + diff, nil
+	}
+
+	// Keep the server's view of the file in sync before writing it
+	if err := client.ChangeDocument(ctx, lsp.DocumentURI(filePath), string(edited)); err != nil {
+		toolsLogger.Error("Error updating document: %v", err)
+	}
+	if err := utilities.WriteFile(filePath, edited); err != nil {
+		return "", fmt.Errorf("failed to write file: %v", err)
+	}
+
+	// Let the server run its on-save actions
+	saved := saveFile(ctx, client, filePath)
+
+	result := msg(MsgEditsApplied, linesRemovedSorted, linesAddedSorted)
+	if saved != "" {
+		result += "\n" + saved
+	}
+	return result, nil
+}
+
+// getCharacterRange creates a protocol.Range from the 1-indexed positions of
+// an edit. The end column defaults to the start column, inserting the text.
+func getCharacterRange(edit TextEdit) (protocol.Range, error) {
+	endColumn := edit.EndColumn
+	if endColumn == 0 {
+		endColumn = edit.StartColumn
+	}
+	if edit.StartLine < 1 || endColumn < 1 || edit.EndLine < edit.StartLine ||
+		(edit.EndLine == edit.StartLine && endColumn < edit.StartColumn) {
+		return protocol.Range{}, fmt.Errorf("the range must start at or after L1:C1 and end at or after its start, got L%d:C%d - L%d:C%d",
+			edit.StartLine, edit.StartColumn, edit.EndLine, endColumn)
+	}
+	return protocol.Range{
+		Start: protocol.Position{Line: uint32(edit.StartLine - 1), Character: uint32(edit.StartColumn - 1)},
+		End:   protocol.Position{Line: uint32(edit.EndLine - 1), Character: uint32(endColumn - 1)},
+	}, nil
 }
 
 // getRange creates a protocol.Range that covers the specified start and end lines
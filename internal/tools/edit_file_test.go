@@ -0,0 +1,27 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCharacterRange(t *testing.T) {
+	rng, err := getCharacterRange(TextEdit{StartLine: 2, StartColumn: 5, EndLine: 3, EndColumn: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, protocol.Range{
+		Start: protocol.Position{Line: 1, Character: 4},
+		End:   protocol.Position{Line: 2, Character: 0},
+	}, rng)
+
+	// Without an end column the text is inserted
+	rng, err = getCharacterRange(TextEdit{StartLine: 1, StartColumn: 3, EndLine: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, rng.Start, rng.End)
+
+	_, err = getCharacterRange(TextEdit{StartLine: 4, StartColumn: 3, EndLine: 4, EndColumn: 2})
+	assert.Error(t, err)
+	_, err = getCharacterRange(TextEdit{StartLine: 4, StartColumn: 1, EndLine: 3, EndColumn: 2})
+	assert.Error(t, err)
+}
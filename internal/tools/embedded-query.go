@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/koonwen/mcp-language-server/internal/embedded"
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// EmbeddedQuery analyzes code embedded in a host file, such as a SQL query in
+// a Go or Python string literal, with the language server configured for the
+// embedded language. Each region of embedded code is sent to that server as a
+// virtual document, and positions are mapped between it and the host file.
+// action is "hover", to describe the code at a 1-indexed position, or
+// "diagnostics", to check the region at the position or, if line is 0, every
+// region in the file.
+func EmbeddedQuery(ctx context.Context, servers map[string]*lsp.Client, filePath string, line, column int, action string) (string, error) {
+	if action != "hover" && action != "diagnostics" {
+		return "", fmt.Errorf("unknown action %q, expected hover or diagnostics", action)
+	}
+	if action == "hover" && line < 1 {
+		return "", fmt.Errorf("hover needs a line and column")
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("could not read file: %v", err)
+	}
+	regions := embedded.Detect(filePath, string(content))
+
+	if line > 0 {
+		pos := protocol.Position{Line: uint32(line - 1), Character: uint32(column - 1)}
+		var found []embedded.Region
+		for _, region := range regions {
+			if region.Contains(pos) {
+				found = append(found, region)
+				break
+			}
+		}
+		if len(found) == 0 {
+			return msg(MsgNoEmbeddedCodeAt, filePath, line, column), nil
+		}
+		regions = found
+	}
+	if len(regions) == 0 {
+		return msg(MsgNoEmbeddedCode, filePath), nil
+	}
+
+	if action == "hover" {
+		region := regions[0]
+		client, uri, err := openEmbeddedRegion(ctx, servers, filePath, region)
+		if err != nil {
+			return "", err
+		}
+		pos, _ := region.ToEmbedded(protocol.Position{Line: uint32(line - 1), Character: uint32(column - 1)})
+		hover, err := GetHoverInfo(ctx, client, string(uri), int(pos.Line)+1, int(pos.Character)+1)
+		if err != nil {
+			return "", err
+		}
+		return msg(MsgEmbeddedHeader, region.Language, filePath, region.Start.Line+1, region.Start.Character+1) + "\n" + hover, nil
+	}
+
+	return embeddedDiagnostics(ctx, servers, filePath, regions)
+}
+
+// embeddedDiagnostics lists the diagnostics of embedded regions at their
+// positions in the host file
+func embeddedDiagnostics(ctx context.Context, servers map[string]*lsp.Client, filePath string, regions []embedded.Region) (string, error) {
+	clients := make([]*lsp.Client, len(regions))
+	uris := make([]protocol.DocumentUri, len(regions))
+	var unpulled []int
+	for i, region := range regions {
+		client, uri, err := openEmbeddedRegion(ctx, servers, filePath, region)
+		if err != nil {
+			return "", err
+		}
+		clients[i], uris[i] = client, uri
+
+		pulled, err := client.PullDiagnostics(ctx, uri)
+		if err != nil {
+			toolsLogger.Error("Failed to get diagnostics: %v", err)
+		}
+		if !pulled || err != nil {
+			unpulled = append(unpulled, i)
+		}
+	}
+	deadline := time.Now().Add(diagnosticsWait)
+	for _, i := range unpulled {
+		clients[i].WaitForDiagnostics(ctx, uris[i], time.Until(deadline))
+	}
+
+	var output strings.Builder
+	total := 0
+	for i, region := range regions {
+		diagnostics := clients[i].GetFileDiagnostics(uris[i])
+		if len(diagnostics) == 0 {
+			continue
+		}
+		total += len(diagnostics)
+		output.WriteString(msg(MsgEmbeddedHeader, region.Language, filePath, region.Start.Line+1, region.Start.Character+1) + "\n")
+		for _, diag := range diagnostics {
+			diag.Range = protocol.Range{
+				Start: region.ToHost(diag.Range.Start),
+				End:   region.ToHost(diag.Range.End),
+			}
+			output.WriteString(formatDiagnosticSummary(diag) + "\n")
+		}
+	}
+	if total == 0 {
+		return msg(MsgNoEmbeddedDiagnostics, len(regions), filePath), nil
+	}
+	return output.String(), nil
+}
+
+// openEmbeddedRegion opens the virtual document of an embedded region on the
+// server for its language, or updates it if the code changed since it was
+// opened
+func openEmbeddedRegion(ctx context.Context, servers map[string]*lsp.Client, filePath string, region embedded.Region) (*lsp.Client, protocol.DocumentUri, error) {
+	client, ok := servers[region.Language]
+	if !ok {
+		return nil, "", fmt.Errorf("no language server is configured for embedded %s; map %q to one of the servers with \"embedded\" in the configuration file", region.Language, region.Language)
+	}
+
+	uri := region.URI(filePath)
+	if err := client.OpenDocument(ctx, uri, region.Text); err != nil {
+		return nil, "", fmt.Errorf("could not open embedded document: %v", err)
+	}
+	if err := client.ChangeDocument(ctx, uri, region.Text); err != nil {
+		return nil, "", fmt.Errorf("could not update embedded document: %v", err)
+	}
+	return client, uri, nil
+}
@@ -0,0 +1,30 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmbeddedQueryWithoutServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "repo.go")
+	content := "package repo\n\nconst q = \"SELECT id FROM users\"\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	servers := map[string]*lsp.Client{}
+
+	text, err := EmbeddedQuery(context.Background(), servers, path, 1, 1, "hover")
+	assert.NoError(t, err)
+	assert.Equal(t, msg(MsgNoEmbeddedCodeAt, path, 1, 1), text)
+
+	_, err = EmbeddedQuery(context.Background(), servers, path, 3, 15, "hover")
+	assert.ErrorContains(t, err, "no language server is configured for embedded sql")
+
+	_, err = EmbeddedQuery(context.Background(), servers, path, 0, 0, "lint")
+	assert.ErrorContains(t, err, "unknown action")
+}
@@ -2,67 +2,203 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/koonwen/mcp-language-server/internal/lsp"
 	"github.com/koonwen/mcp-language-server/internal/protocol"
 )
 
-// ExecuteCodeLens executes a specific code lens command from a file.
-func ExecuteCodeLens(ctx context.Context, client *lsp.Client, filePath string, index int) (string, error) {
-	// Open the file
-	err := client.OpenFile(ctx, filePath)
+const (
+	// maxCommandWait bounds how long ExecuteCodeLens waits for a command
+	// that reports progress, such as running tests, to finish
+	maxCommandWait = 10 * time.Minute
+	// progressPollInterval is how often the progress of a command is checked
+	progressPollInterval = 200 * time.Millisecond
+)
+
+// ExecuteCodeLens runs the command of a code lens of a file, chosen by its
+// 1-indexed position in the GetCodeLens output or, if a title is given, by
+// its exact title or a unique part of it. Commands that report progress,
+// like running tests through gopls or rust-analyzer, are waited for, and the
+// messages the server showed while they ran are returned with any changes
+// they made.
+func ExecuteCodeLens(ctx context.Context, client *lsp.Client, filePath string, index int, title string) (string, error) {
+	uri, err := openDocument(ctx, client, filePath)
 	if err != nil {
-		return "", fmt.Errorf("could not open file: %v", err)
+		return "", err
 	}
-	// TODO: find a more appropriate way to wait
-	time.Sleep(time.Second)
 
-	// Get code lenses
-	docIdentifier := protocol.TextDocumentIdentifier{
-		URI: protocol.DocumentUri("file://" + filePath),
+	lenses, err := codeLenses(ctx, client, uri)
+	if err != nil {
+		return "", err
+	}
+	lens, err := selectCodeLens(lenses, index, title)
+	if err != nil {
+		return "", err
+	}
+	if lens.Command == nil {
+		return "", fmt.Errorf("code lens has no command after resolution")
 	}
 
-	params := protocol.CodeLensParams{
-		TextDocument: docIdentifier,
+	// Commands report their results by showing messages and make their
+	// changes by sending workspace/applyEdit requests, so capture them
+	trace := client.StartTrace()
+	result, err := client.ExecuteCommand(ctx, protocol.ExecuteCommandParams{
+		Command:   lens.Command.Command,
+		Arguments: lens.Command.Arguments,
+	})
+	if err == nil {
+		waitForProgress(ctx, trace)
 	}
-	codeLenses, err := client.CodeLens(ctx, params)
+	client.StopTrace(trace)
 	if err != nil {
-		return "", fmt.Errorf("failed to get code lenses: %v", err)
+		return "", fmt.Errorf("failed to execute code lens command: %v", err)
 	}
 
-	if len(codeLenses) == 0 {
-		return "", fmt.Errorf("no code lenses found in file")
+	var output strings.Builder
+	output.WriteString(msg(MsgCodeLensExecuted, lens.Command.Title, lens.Command.Command) + "\n")
+	if result != nil {
+		if data, err := json.Marshal(result); err == nil {
+			output.WriteString(msg(MsgCommandResult, string(data)) + "\n")
+		}
+	}
+	if messages := serverMessages(trace.Entries()); len(messages) > 0 {
+		output.WriteString(msg(MsgServerMessages) + "\n")
+		for _, message := range messages {
+			output.WriteString(message + "\n")
+		}
 	}
 
-	if index < 1 || index > len(codeLenses) {
-		return "", fmt.Errorf("invalid code lens index: %d. Available range: 1-%d", index, len(codeLenses))
+	changes, fileOps := summarizeWorkspaceEdit(mergeWorkspaceEdits(serverAppliedEdits(trace)))
+	saved := ""
+	for _, change := range changes {
+		saved += saveFile(ctx, client, change.path)
+	}
+	if locations, count := formatFileEdits(changes, fileOps); count > 0 || len(fileOps) > 0 {
+		output.WriteString(msg(MsgCodeActionEdited, count, len(changes)) + "\n" + locations)
 	}
+	output.WriteString(saved)
 
-	lens := codeLenses[index-1]
+	return output.String(), nil
+}
 
-	// Resolve the code lens if it doesn't have a command
-	if lens.Command == nil {
-		resolvedLens, err := client.ResolveCodeLens(ctx, lens)
-		if err != nil {
-			return "", fmt.Errorf("failed to resolve code lens: %v", err)
+// selectCodeLens picks a code lens by its 1-indexed position or, if a title
+// is given, by its exact title or a unique part of it
+func selectCodeLens(lenses []protocol.CodeLens, index int, title string) (protocol.CodeLens, error) {
+	if len(lenses) == 0 {
+		return protocol.CodeLens{}, fmt.Errorf("no code lenses found in file")
+	}
+
+	if title == "" {
+		if index < 1 || index > len(lenses) {
+			return protocol.CodeLens{}, fmt.Errorf("invalid code lens index: %d. Available range: 1-%d", index, len(lenses))
 		}
-		lens = resolvedLens
+		return lenses[index-1], nil
 	}
 
-	if lens.Command == nil {
-		return "", fmt.Errorf("code lens has no command after resolution")
+	var matches []protocol.CodeLens
+	var titles []string
+	for _, lens := range lenses {
+		if lens.Command == nil {
+			continue
+		}
+		if lens.Command.Title == title {
+			return lens, nil
+		}
+		if strings.Contains(strings.ToLower(lens.Command.Title), strings.ToLower(title)) {
+			matches = append(matches, lens)
+			titles = append(titles, fmt.Sprintf("%s (L%d)", lens.Command.Title, lens.Range.Start.Line+1))
+		}
 	}
 
-	// Execute the command
-	_, err = client.ExecuteCommand(ctx, protocol.ExecuteCommandParams{
-		Command:   lens.Command.Command,
-		Arguments: lens.Command.Arguments,
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to execute code lens command: %v", err)
+	switch len(matches) {
+	case 0:
+		return protocol.CodeLens{}, fmt.Errorf("no code lens titled '%s'", title)
+	case 1:
+		return matches[0], nil
+	default:
+		return protocol.CodeLens{}, fmt.Errorf("'%s' matches %d code lenses, use the full title or an index: %s", title, len(matches), strings.Join(titles, "; "))
+	}
+}
+
+// progressNotification is a $/progress notification or a window/showMessage
+// notification received while a trace was active
+type progressNotification struct {
+	Method string `json:"method"`
+	Params struct {
+		Token json.RawMessage `json:"token"`
+		Value struct {
+			Kind    string `json:"kind"`
+			Message string `json:"message"`
+		} `json:"value"`
+		// Message is set for window/showMessage
+		Message string `json:"message"`
+	} `json:"params"`
+}
+
+// traceNotifications decodes the notifications received while a trace was
+// active
+func traceNotifications(entries []lsp.TraceEntry) []progressNotification {
+	var notifications []progressNotification
+	for _, entry := range entries {
+		if entry.Sent {
+			continue
+		}
+		var notification progressNotification
+		if err := json.Unmarshal(entry.Message, &notification); err != nil || notification.Method == "" {
+			continue
+		}
+		notifications = append(notifications, notification)
 	}
+	return notifications
+}
+
+// pendingProgress reports whether work done progress begun while a trace was
+// active has not ended yet
+func pendingProgress(entries []lsp.TraceEntry) bool {
+	pending := make(map[string]bool)
+	for _, notification := range traceNotifications(entries) {
+		if notification.Method != "$/progress" {
+			continue
+		}
+		token := string(notification.Params.Token)
+		switch notification.Params.Value.Kind {
+		case "begin":
+			pending[token] = true
+		case "end":
+			delete(pending, token)
+		}
+	}
+	return len(pending) > 0
+}
 
-	return fmt.Sprintf("Successfully executed code lens command: %s", lens.Command.Title), nil
+// waitForProgress waits until the progress begun while a trace was active
+// has ended, the context is done or maxCommandWait has passed
+func waitForProgress(ctx context.Context, trace *lsp.Trace) {
+	deadline := time.Now().Add(maxCommandWait)
+	for pendingProgress(trace.Entries()) && time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(progressPollInterval):
+		}
+	}
+}
+
+// serverMessages returns the messages the server showed, and the final
+// messages of its progress, while a trace was active
+func serverMessages(entries []lsp.TraceEntry) []string {
+	var messages []string
+	for _, notification := range traceNotifications(entries) {
+		switch {
+		case notification.Method == "window/showMessage" && notification.Params.Message != "":
+			messages = append(messages, notification.Params.Message)
+		case notification.Method == "$/progress" && notification.Params.Value.Kind == "end" && notification.Params.Value.Message != "":
+			messages = append(messages, notification.Params.Value.Message)
+		}
+	}
+	return messages
 }
@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectCodeLens(t *testing.T) {
+	lens := func(line uint32, title string) protocol.CodeLens {
+		return protocol.CodeLens{
+			Range:   protocol.Range{Start: protocol.Position{Line: line}},
+			Command: &protocol.Command{Title: title, Command: "gopls.run_tests"},
+		}
+	}
+	lenses := []protocol.CodeLens{
+		lens(3, "run test"),
+		lens(3, "debug test"),
+		lens(10, "run benchmark"),
+		{Range: protocol.Range{Start: protocol.Position{Line: 12}}},
+	}
+
+	selected, err := selectCodeLens(lenses, 2, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "debug test", selected.Command.Title)
+
+	selected, err = selectCodeLens(lenses, 0, "run test")
+	assert.NoError(t, err)
+	assert.Equal(t, "run test", selected.Command.Title)
+
+	selected, err = selectCodeLens(lenses, 0, "bench")
+	assert.NoError(t, err)
+	assert.Equal(t, "run benchmark", selected.Command.Title)
+
+	_, err = selectCodeLens(lenses, 0, "test")
+	assert.EqualError(t, err, "'test' matches 2 code lenses, use the full title or an index: run test (L4); debug test (L4)")
+
+	_, err = selectCodeLens(lenses, 5, "")
+	assert.EqualError(t, err, "invalid code lens index: 5. Available range: 1-4")
+
+	_, err = selectCodeLens(nil, 1, "")
+	assert.Error(t, err)
+}
+
+func TestCodeLensProgress(t *testing.T) {
+	received := func(message string) lsp.TraceEntry {
+		return lsp.TraceEntry{Message: json.RawMessage(message)}
+	}
+	begin := received(`{"jsonrpc":"2.0","method":"$/progress","params":{"token":"1","value":{"kind":"begin","title":"Running go test"}}}`)
+	report := received(`{"jsonrpc":"2.0","method":"$/progress","params":{"token":"1","value":{"kind":"report","message":"running"}}}`)
+	shown := received(`{"jsonrpc":"2.0","method":"window/showMessage","params":{"type":3,"message":"PASS: TestAdd"}}`)
+	end := received(`{"jsonrpc":"2.0","method":"$/progress","params":{"token":"1","value":{"kind":"end","message":"Done"}}}`)
+	sent := lsp.TraceEntry{Sent: true, Message: json.RawMessage(`{"jsonrpc":"2.0","method":"window/showMessage","params":{"message":"ignored"}}`)}
+
+	assert.False(t, pendingProgress(nil))
+	assert.True(t, pendingProgress([]lsp.TraceEntry{begin, report}))
+	assert.False(t, pendingProgress([]lsp.TraceEntry{begin, report, shown, end}))
+
+	assert.Equal(t, []string{"PASS: TestAdd", "Done"}, serverMessages([]lsp.TraceEntry{sent, begin, report, shown, end}))
+}
+
+func TestCodeLensTitle(t *testing.T) {
+	assert.Equal(t, "run test (gopls.run_tests)", codeLensTitle(protocol.CodeLens{Command: &protocol.Command{Title: "run test", Command: "gopls.run_tests"}}))
+	assert.Equal(t, "3 references", codeLensTitle(protocol.CodeLens{Command: &protocol.Command{Title: "3 references"}}))
+	assert.Equal(t, "(unresolved, no command)", codeLensTitle(protocol.CodeLens{}))
+}
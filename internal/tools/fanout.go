@@ -0,0 +1,35 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// fanOut calls f concurrently for each index from 0 to n-1 and waits for all
+// of the calls to return, so that none outlives the tool call that started
+// it. f is given ctx and should stop early once it is done. A panic in f, e.g.
+// on a malformed response from the language server, is recovered and
+// returned as the error for its index instead of crashing the process.
+func fanOut(ctx context.Context, n int, f func(ctx context.Context, i int) error) []error {
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					toolsLogger.Error("Panic in concurrent request: %v\n%s", r, debug.Stack())
+					errs[i] = fmt.Errorf("internal error: %v", r)
+				}
+			}()
+			errs[i] = f(ctx, i)
+		}()
+	}
+	wg.Wait()
+
+	return errs
+}
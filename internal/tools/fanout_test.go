@@ -0,0 +1,29 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFanOut(t *testing.T) {
+	results := make([]int, 3)
+	errs := fanOut(context.Background(), 3, func(ctx context.Context, i int) error {
+		switch i {
+		case 1:
+			return errors.New("failed")
+		case 2:
+			var m map[string]int
+			m["x"] = 1
+		}
+		results[i] = i + 10
+		return nil
+	})
+
+	assert.Equal(t, []int{10, 0, 0}, results)
+	assert.NoError(t, errs[0])
+	assert.EqualError(t, errs[1], "failed")
+	assert.ErrorContains(t, errs[2], "internal error: assignment to entry in nil map")
+}
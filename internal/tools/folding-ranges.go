@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// maxPreviewLength is the length at which the previews of the text of ranges
+// are cut
+const maxPreviewLength = 80
+
+// foldingBlock is a folding range as whole 0-indexed lines, including the
+// line that closes it
+type foldingBlock struct {
+	Start, End int
+	Kind       string
+	Depth      int
+}
+
+// GetFoldingRanges returns a map of the logical blocks of a file: functions,
+// import groups, comments and regions, nested by indentation, each with its
+// line range and first line. This lets large files be read a block at a time.
+// Blocks shorter than minLines are left out.
+func GetFoldingRanges(ctx context.Context, client *lsp.Client, filePath string, minLines int) (string, error) {
+	uri, err := openDocument(ctx, client, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := documentText(ctx, client, uri)
+	if err != nil {
+		return "", fmt.Errorf("could not read document: %v", err)
+	}
+	lines := strings.Split(content, "\n")
+
+	ranges, err := client.FoldingRange(ctx, protocol.FoldingRangeParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get folding ranges: %v", err)
+	}
+
+	blocks := foldingBlocks(ranges, lines, minLines)
+	if len(blocks) == 0 {
+		return msg(MsgNoFoldingRanges, filePath), nil
+	}
+
+	var out strings.Builder
+	out.WriteString(msg(MsgFoldingRangesHeader, len(blocks), filePath, len(lines)) + "\n")
+	for _, block := range blocks {
+		preview := truncatePreview(strings.TrimSpace(lines[block.Start]))
+		kind := ""
+		if block.Kind != "" {
+			kind = " [" + block.Kind + "]"
+		}
+		out.WriteString(fmt.Sprintf("%sL%d-L%d (%d lines)%s: %s\n",
+			strings.Repeat("  ", block.Depth),
+			block.Start+1, block.End+1, block.End-block.Start+1,
+			kind, preview,
+		))
+	}
+	return out.String(), nil
+}
+
+// foldingBlocks converts folding ranges to blocks sorted by position, with
+// the depth at which each is nested in the others. Servers that fold whole
+// lines end a range before the line that closes it, which is added back so
+// that fetching a block returns it complete.
+func foldingBlocks(ranges []protocol.FoldingRange, lines []string, minLines int) []foldingBlock {
+	var blocks []foldingBlock
+	for _, r := range ranges {
+		start, end := int(r.StartLine), int(r.EndLine)
+		if start >= len(lines) || end < start {
+			continue
+		}
+		end = min(end, len(lines)-1)
+		if end+1 < len(lines) && closesBlock(lines[start], lines[end+1]) {
+			end++
+		}
+		if end-start+1 < minLines {
+			continue
+		}
+		blocks = append(blocks, foldingBlock{Start: start, End: end, Kind: r.Kind})
+	}
+
+	sort.SliceStable(blocks, func(i, j int) bool {
+		if blocks[i].Start != blocks[j].Start {
+			return blocks[i].Start < blocks[j].Start
+		}
+		return blocks[i].End > blocks[j].End
+	})
+
+	// Ends of the blocks enclosing the current one
+	var enclosing []int
+	for i := range blocks {
+		for len(enclosing) > 0 && enclosing[len(enclosing)-1] < blocks[i].End {
+			enclosing = enclosing[:len(enclosing)-1]
+		}
+		blocks[i].Depth = len(enclosing)
+		enclosing = append(enclosing, blocks[i].End)
+	}
+	return blocks
+}
+
+// closesBlock reports whether a line closes the block opened by another: it
+// only closes brackets, possibly followed by a semicolon or comma, and is
+// indented like the opening line
+func closesBlock(opening, line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.Trim(trimmed, "})];,") != "" {
+		return false
+	}
+	return indentation(line) == indentation(opening)
+}
+
+func indentation(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// truncatePreview cuts text longer than maxPreviewLength
+func truncatePreview(text string) string {
+	if len(text) > maxPreviewLength {
+		return text[:maxPreviewLength] + "..."
+	}
+	return text
+}
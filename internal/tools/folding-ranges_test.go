@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFoldingBlocks(t *testing.T) {
+	lines := strings.Split(`package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) > 1 {
+		fmt.Println(os.Args[1])
+	}
+	fmt.Println("done")
+}`, "\n")
+
+	// Ranges as gopls reports them to clients that only fold whole lines
+	ranges := []protocol.FoldingRange{
+		{StartLine: 7, EndLine: 11},
+		{StartLine: 2, EndLine: 4, Kind: "imports"},
+		{StartLine: 8, EndLine: 9},
+	}
+
+	assert.Equal(t, []foldingBlock{
+		{Start: 2, End: 5, Kind: "imports"},
+		{Start: 7, End: 12},
+		{Start: 8, End: 10, Depth: 1},
+	}, foldingBlocks(ranges, lines, 1))
+
+	// Small blocks are left out
+	assert.Equal(t, []foldingBlock{
+		{Start: 2, End: 5, Kind: "imports"},
+		{Start: 7, End: 12},
+	}, foldingBlocks(ranges, lines, 4))
+
+	// Ranges that already include their closing line are not extended
+	assert.Equal(t, []foldingBlock{{Start: 8, End: 10}}, foldingBlocks([]protocol.FoldingRange{{StartLine: 8, EndLine: 10}}, lines, 1))
+}
@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/koonwen/mcp-language-server/internal/utilities"
+)
+
+// FormatDocument formats a file with the language server and writes the
+// result back. With dryRun, the changes are returned as a unified diff
+// instead.
+func FormatDocument(ctx context.Context, client *lsp.Client, filePath string, options protocol.FormattingOptions, dryRun bool) (string, error) {
+	uri, err := openDocument(ctx, client, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	edits, err := client.Formatting(ctx, protocol.DocumentFormattingParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Options:      options,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to format document: %v", err)
+	}
+
+	return applyFormatting(ctx, client, filePath, edits, dryRun)
+}
+
+// FormatRange formats the lines from startLine to endLine (1-indexed,
+// inclusive) with the language server and leaves the rest of the file as it
+// is. Servers that cannot format ranges format the whole file, and only the
+// changes within the lines are kept. With dryRun, the changes are returned as
+// a unified diff instead of being written.
+func FormatRange(ctx context.Context, client *lsp.Client, filePath string, startLine, endLine int, options protocol.FormattingOptions, dryRun bool) (string, error) {
+	uri, err := openDocument(ctx, client, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	text, err := documentText(ctx, client, uri)
+	if err != nil {
+		return "", fmt.Errorf("could not read document: %v", err)
+	}
+	lines := strings.Split(text, "\n")
+	if startLine < 1 || endLine < startLine || startLine > len(lines) {
+		return "", fmt.Errorf("invalid line range %d-%d: the file has %d lines", startLine, endLine, len(lines))
+	}
+	endLine = min(endLine, len(lines))
+
+	rng := protocol.Range{
+		Start: protocol.Position{Line: uint32(startLine - 1)},
+		End: protocol.Position{
+			Line:      uint32(endLine - 1),
+			Character: uint32(len(utf16.Encode([]rune(lines[endLine-1])))),
+		},
+	}
+
+	var edits []protocol.TextEdit
+	if client.SupportsMethod("textDocument/rangeFormatting") {
+		edits, err = client.RangeFormatting(ctx, protocol.DocumentRangeFormattingParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Range:        rng,
+			Options:      options,
+		})
+	} else {
+		edits, err = client.Formatting(ctx, protocol.DocumentFormattingParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Options:      options,
+		})
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to format range: %v", err)
+	}
+
+	// Keep only the changes within the lines, as whole-file formatting covers
+	// everything and servers may also touch the surrounding lines. The line
+	// break after the last line belongs to the range.
+	edits = editsWithin(edits, rng.Start, protocol.Position{Line: uint32(endLine)})
+
+	return applyFormatting(ctx, client, filePath, edits, dryRun)
+}
+
+// editsWithin returns the edits that lie between two positions
+func editsWithin(edits []protocol.TextEdit, start, end protocol.Position) []protocol.TextEdit {
+	var within []protocol.TextEdit
+	for _, edit := range edits {
+		if !positionBefore(edit.Range.Start, start) && !positionBefore(end, edit.Range.End) {
+			within = append(within, edit)
+		}
+	}
+	return within
+}
+
+// applyFormatting writes formatting edits to a file, or with dryRun only
+// reports them, as a unified diff
+func applyFormatting(ctx context.Context, client *lsp.Client, filePath string, edits []protocol.TextEdit, dryRun bool) (string, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %v", err)
+	}
+
+	formatted, err := utilities.EditContent(content, edits)
+	if err != nil {
+		return "", fmt.Errorf("failed to apply formatting: %v", err)
+	}
+	if bytes.Equal(content, formatted) {
+		return msg(MsgAlreadyFormatted, filePath), nil
+	}
+
+	diff, added, removed := UnifiedDiff(filePath, string(content), string(formatted))
+	if dryRun {
+		return msg(MsgFormatDryRun, filePath) + "\n\n" + diff, nil
+	}
+
+	if err := utilities.WriteFile(filePath, formatted); err != nil {
+		return "", fmt.Errorf("failed to write file: %v", err)
+	}
+
+	// Let the server run its on-save actions
+	saved := saveFile(ctx, client, filePath)
+
+	result := msg(MsgFormatted, filePath, added, removed)
+	if saved != "" {
+		result += "\n" + saved
+	}
+	return result, nil
+}
@@ -0,0 +1,27 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditsWithin(t *testing.T) {
+	edit := func(startLine, startChar, endLine, endChar uint32) protocol.TextEdit {
+		return protocol.TextEdit{Range: protocol.Range{
+			Start: protocol.Position{Line: startLine, Character: startChar},
+			End:   protocol.Position{Line: endLine, Character: endChar},
+		}}
+	}
+	before := edit(1, 0, 1, 4)
+	first := edit(2, 0, 2, 1)
+	lineBreak := edit(4, 7, 5, 0)
+	spanning := edit(4, 0, 6, 0)
+	after := edit(5, 0, 5, 2)
+
+	// Lines 3 to 5
+	within := editsWithin([]protocol.TextEdit{before, first, lineBreak, spanning, after},
+		protocol.Position{Line: 2}, protocol.Position{Line: 5})
+	assert.Equal(t, []protocol.TextEdit{first, lineBreak}, within)
+}
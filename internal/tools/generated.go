@@ -0,0 +1,216 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/buildsystem"
+)
+
+// buildLayout is the build system of the workspace, used to map generated
+// files back to their sources. It is nil unless one was detected, and is only
+// set at startup by SetBuildLayout.
+var buildLayout *buildsystem.Layout
+
+// SetBuildLayout sets the build system used to annotate generated files in
+// tool output
+func SetBuildLayout(layout *buildsystem.Layout) {
+	buildLayout = layout
+}
+
+// maxHeaderLines is the number of lines at the start of a file searched for
+// the marker of a code generator
+const maxHeaderLines = 40
+
+var (
+	// generatedMarker matches the header of generated files, as standardized
+	// for Go and used by most generators in other languages, capturing the
+	// generator
+	generatedMarker = regexp.MustCompile(`(?i)^\W*(?:Code )?generated (?:by )?(?:the )?(.*?)[.;,]?\s+DO NOT EDIT`)
+	// generatedTag matches the @generated tag used by generators such as
+	// Thrift and Relay
+	generatedTag = regexp.MustCompile(`^\W*@generated\b`)
+	// sourceComment matches the comment naming the file a generator compiled,
+	// written by protoc plugins and sqlc among others
+	sourceComment = regexp.MustCompile(`^\W*source: (\S+)`)
+	// protoGenerator matches the names under which protoc plugins appear in
+	// markers
+	protoGenerator = regexp.MustCompile(`protoc|protobuf|protocol buffer|grpc|buf\b`)
+)
+
+// generatedOrigin describes where the code in a generated file comes from
+type generatedOrigin struct {
+	// Generator is the program that wrote the file, if the marker names it
+	Generator string
+	// Source is the file the code was generated from, if it is known
+	Source string
+	// Line and Declaration locate the declaration in the source from which a
+	// symbol was generated, such as "message GetUserRequest" in a .proto file
+	Line        int
+	Declaration string
+}
+
+// generatedFileOrigin returns where a generated file comes from, or nil if
+// the file is not generated. symbol is the name of a definition in the file,
+// used to find the declaration it was generated from, and may be empty.
+func generatedFileOrigin(filePath, symbol string) *generatedOrigin {
+	var origin *generatedOrigin
+
+	if generator, source, ok := readGeneratedHeader(filePath); ok {
+		origin = &generatedOrigin{Generator: generator}
+		if source != "" {
+			origin.Source = findGeneratorSource(filePath, source)
+		}
+	}
+
+	// Files in the output trees of the build system may lack a marker
+	if buildLayout != nil && (origin == nil || origin.Source == "") {
+		if source, ok := buildLayout.SourceFor(filePath); ok {
+			if origin == nil {
+				origin = &generatedOrigin{}
+			}
+			origin.Source = source
+		}
+	}
+
+	if origin != nil && origin.Source != "" && symbol != "" && (strings.HasSuffix(origin.Source, ".proto") || protoGenerator.MatchString(origin.Generator)) {
+		origin.Line, origin.Declaration = findProtoDeclaration(origin.Source, symbol)
+	}
+	return origin
+}
+
+// readGeneratedHeader reads the generator and source named in the header of
+// a file. ok is false if the file has no generated code marker.
+func readGeneratedHeader(filePath string) (generator, source string, ok bool) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", "", false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for i := 0; i < maxHeaderLines && scanner.Scan(); i++ {
+		line := scanner.Text()
+		if match := generatedMarker.FindStringSubmatch(line); match != nil {
+			generator, ok = strings.Trim(match[1], `"' `), true
+		} else if generatedTag.MatchString(line) {
+			ok = true
+		} else if match := sourceComment.FindStringSubmatch(line); match != nil && source == "" {
+			source = match[1]
+		}
+	}
+	return generator, source, ok
+}
+
+// sourceIncludeDirs are the directories, relative to the parents of a
+// generated file, in which include paths usually start
+var sourceIncludeDirs = []string{"", "proto", "protos", "api"}
+
+// findGeneratorSource resolves the source named in the header of a generated
+// file. Generators name it relative to their include path, so it is looked
+// up next to the generated file and below each of its parents.
+func findGeneratorSource(filePath, source string) string {
+	if filepath.IsAbs(source) {
+		if _, err := os.Stat(source); err == nil {
+			return source
+		}
+		return ""
+	}
+
+	dir := filepath.Dir(filePath)
+	if candidate := filepath.Join(dir, filepath.Base(source)); fileExists(candidate) {
+		return candidate
+	}
+	for {
+		for _, include := range sourceIncludeDirs {
+			if candidate := filepath.Join(dir, include, source); fileExists(candidate) {
+				return candidate
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// findProtoDeclaration finds the declaration in a .proto file of a message,
+// enum, service or rpc that a symbol was generated from. Generated names
+// qualify nested types with their parents, as Outer_Inner in Go or
+// Outer.Inner elsewhere, so the innermost name is tried last.
+func findProtoDeclaration(protoPath, symbol string) (int, string) {
+	content, err := os.ReadFile(protoPath)
+	if err != nil {
+		return 0, ""
+	}
+	lines := strings.Split(string(content), "\n")
+
+	var names []string
+	for _, part := range strings.FieldsFunc(symbol, func(r rune) bool { return r == '.' || r == ':' }) {
+		names = append(names, part)
+		if i := strings.LastIndex(part, "_"); i > 0 && i < len(part)-1 {
+			names = append(names, part[i+1:])
+		}
+	}
+
+	for _, name := range names {
+		declaration := regexp.MustCompile(`^\s*(message|enum|service|rpc)\s+` + regexp.QuoteMeta(name) + `\b`)
+		for i, line := range lines {
+			if match := declaration.FindStringSubmatch(line); match != nil {
+				return i + 1, match[1] + " " + name
+			}
+		}
+	}
+	return 0, ""
+}
+
+// location returns the source with the line and declaration the symbol was
+// generated from, if they are known
+func (o *generatedOrigin) location() string {
+	if o.Line > 0 {
+		return fmt.Sprintf("%s:%d (%s)", o.Source, o.Line, o.Declaration)
+	}
+	return o.Source
+}
+
+// generatedSource returns the source a generated file was generated from,
+// with the declaration of the symbol in it where known, or an empty string
+// if the file is not generated or its source is unknown
+func generatedSource(filePath, symbol string) string {
+	origin := generatedFileOrigin(filePath, symbol)
+	if origin == nil {
+		return ""
+	}
+	return origin.location()
+}
+
+// formatGeneratedFrom returns the lines for a generated file in a file
+// header, naming its generator and the authoritative source to edit instead,
+// or an empty string if the file is not generated
+func formatGeneratedFrom(filePath, symbol string) string {
+	origin := generatedFileOrigin(filePath, symbol)
+	if origin == nil {
+		return ""
+	}
+
+	var out strings.Builder
+	if origin.Generator != "" {
+		out.WriteString(msg(MsgGeneratedBy, origin.Generator) + "\n")
+	} else if origin.Source == "" {
+		out.WriteString(msg(MsgGeneratedBy, "unknown generator") + "\n")
+	}
+	if origin.Source != "" {
+		out.WriteString(msg(MsgGeneratedFrom, origin.location()) + "\n")
+	}
+	return out.String()
+}
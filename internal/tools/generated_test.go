@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/buildsystem"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatGeneratedFrom(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(root, ".plzconfig"), nil, 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "api"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "api", "service.proto"), nil, 0644))
+
+	SetBuildLayout(buildsystem.Detect(root))
+	defer SetBuildLayout(nil)
+
+	generated := filepath.Join(root, "plz-out", "gen", "api", "service.pb.go")
+	assert.Equal(t, "Generated from: "+filepath.Join(root, "api", "service.proto")+"\n", formatGeneratedFrom(generated, ""))
+	assert.Empty(t, formatGeneratedFrom(filepath.Join(root, "api", "service.proto"), ""))
+
+	SetBuildLayout(nil)
+	assert.Empty(t, formatGeneratedFrom(generated, ""))
+}
+
+func TestGeneratedFileOrigin(t *testing.T) {
+	root := t.TempDir()
+	write := func(path, content string) string {
+		path = filepath.Join(root, path)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	proto := write("proto/user/v1/user.proto", `syntax = "proto3";
+
+service UserService {
+  rpc GetUser(GetUserRequest) returns (User);
+}
+
+message GetUserRequest {
+  message Options {
+    bool full = 1;
+  }
+  string id = 1;
+}
+`)
+	pbGo := write("gen/user/v1/user.pb.go", `// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.1
+// source: user/v1/user.proto
+
+package userv1
+`)
+	pbPy := write("gen/python/user_pb2.py", `# -*- coding: utf-8 -*-
+# Generated by the protocol buffer compiler.  DO NOT EDIT!
+# source: user/v1/user.proto
+`)
+	stringer := write("pkg/pill_string.go", `// Code generated by "stringer -type=Pill"; DO NOT EDIT.
+
+package pill
+`)
+	handwritten := write("pkg/pill.go", "package pill\n")
+
+	assert.Equal(t, &generatedOrigin{Generator: "protoc-gen-go", Source: proto, Line: 7, Declaration: "message GetUserRequest"}, generatedFileOrigin(pbGo, "GetUserRequest.GetId"))
+	assert.Equal(t, &generatedOrigin{Generator: "protoc-gen-go", Source: proto, Line: 8, Declaration: "message Options"}, generatedFileOrigin(pbGo, "GetUserRequest_Options"))
+	assert.Equal(t, &generatedOrigin{Generator: "protoc-gen-go", Source: proto, Line: 4, Declaration: "rpc GetUser"}, generatedFileOrigin(pbGo, "UserServiceClient.GetUser"))
+	assert.Equal(t, &generatedOrigin{Generator: "protoc-gen-go", Source: proto}, generatedFileOrigin(pbGo, ""))
+	assert.Equal(t, &generatedOrigin{Generator: "protocol buffer compiler", Source: proto}, generatedFileOrigin(pbPy, ""))
+	assert.Equal(t, &generatedOrigin{Generator: "stringer -type=Pill"}, generatedFileOrigin(stringer, "Pill.String"))
+	assert.Nil(t, generatedFileOrigin(handwritten, ""))
+
+	assert.Equal(t, "Generated by: protoc-gen-go (do not edit)\nGenerated from: "+proto+":7 (message GetUserRequest)\n", formatGeneratedFrom(pbGo, "GetUserRequest"))
+	assert.Equal(t, "Generated by: stringer -type=Pill (do not edit)\n", formatGeneratedFrom(stringer, ""))
+}
@@ -4,75 +4,78 @@ import (
 	"context"
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/koonwen/mcp-language-server/internal/lsp"
 	"github.com/koonwen/mcp-language-server/internal/protocol"
 )
 
-// GetCodeLens retrieves code lens hints for a given file location
+// GetCodeLens lists the code lenses of a file, such as "run test" or "N
+// references", with the line each applies to and the command it runs. They
+// are numbered for ExecuteCodeLens.
 func GetCodeLens(ctx context.Context, client *lsp.Client, filePath string) (string, error) {
-	err := client.OpenFile(ctx, filePath)
+	uri, err := openDocument(ctx, client, filePath)
 	if err != nil {
-		return "", fmt.Errorf("could not open file: %v", err)
+		return "", err
 	}
-	// TODO: find a more appropriate way to wait
-	time.Sleep(time.Second)
 
-	// Create document identifier
-	docIdentifier := protocol.TextDocumentIdentifier{
-		URI: protocol.DocumentUri("file://" + filePath),
-	}
-
-	// Request code lens from LSP
-	params := protocol.CodeLensParams{
-		TextDocument: docIdentifier,
-	}
-	codeLensResult, err := client.CodeLens(ctx, params)
+	lenses, err := codeLenses(ctx, client, uri)
 	if err != nil {
-		return "", fmt.Errorf("failed to get code lens: %w", err)
+		return "", err
+	}
+	if len(lenses) == 0 {
+		return msg(MsgNoCodeLenses, filePath), nil
 	}
 
-	if codeLensResult == nil {
-		return "No code lens providers available for this file.", nil
+	var lines []string
+	if content, err := documentText(ctx, client, uri); err == nil {
+		lines = strings.Split(content, "\n")
 	}
 
-	// Format the code lens results
 	var output strings.Builder
-	output.WriteString(fmt.Sprintf("Code Lens results for %s:\n\n", filePath))
+	output.WriteString(msg(MsgCodeLensesHeader, len(lenses), filePath) + "\n")
+	for i, lens := range lenses {
+		output.WriteString(fmt.Sprintf("[%d] L%d: %s\n", i+1, lens.Range.Start.Line+1, codeLensTitle(lens)))
+		if line := int(lens.Range.Start.Line); line < len(lines) {
+			output.WriteString("    " + truncatePreview(strings.TrimSpace(lines[line])) + "\n")
+		}
+	}
+	return output.String(), nil
+}
 
-	for i, lens := range codeLensResult {
-		output.WriteString(fmt.Sprintf("[%d] Location: Lines %d-%d\n",
-			i+1,
-			lens.Range.Start.Line+1,
-			lens.Range.End.Line+1))
+// codeLenses requests the code lenses of a document and resolves those that
+// the server left without a command, when it can resolve them
+func codeLenses(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri) ([]protocol.CodeLens, error) {
+	lenses, err := client.CodeLens(ctx, protocol.CodeLensParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get code lenses: %v", err)
+	}
 
+	if !client.SupportsMethod("codeLens/resolve") {
+		return lenses, nil
+	}
+	for i, lens := range lenses {
 		if lens.Command != nil {
-			output.WriteString(fmt.Sprintf("    Title: %s\n", lens.Command.Title))
-			if lens.Command.Command != "" {
-				output.WriteString(fmt.Sprintf("    Command: %s\n", lens.Command.Command))
-			}
-			if lens.Command.Arguments != nil {
-				output.WriteString("    Arguments:\n")
-				for _, arg := range lens.Command.Arguments {
-					output.WriteString(fmt.Sprintf("%s\n", arg))
-				}
-			}
+			continue
 		}
-
-		// Print any custom data that might help identify the provider
-		if lens.Data != nil {
-			output.WriteString("    Additional Data:\n")
-			output.WriteString(fmt.Sprintf("%s\n", lens.Data))
+		resolved, err := client.ResolveCodeLens(ctx, lens)
+		if err != nil {
+			toolsLogger.Debug("Failed to resolve code lens: %v", err)
+			continue
 		}
-		output.WriteString("\n")
+		lenses[i] = resolved
 	}
+	return lenses, nil
+}
 
-	if len(codeLensResult) == 0 {
-		output.WriteString("No code lens found for this file.\n")
-	} else {
-		output.WriteString(fmt.Sprintf("Found %d code lens items.\n", len(codeLensResult)))
+// codeLensTitle describes a code lens by its title and command
+func codeLensTitle(lens protocol.CodeLens) string {
+	if lens.Command == nil {
+		return msg(MsgCodeLensUnresolved)
 	}
-
-	return output.String(), nil
+	if lens.Command.Command == "" {
+		return lens.Command.Title
+	}
+	return fmt.Sprintf("%s (%s)", lens.Command.Title, lens.Command.Command)
 }
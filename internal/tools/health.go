@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"strings"
+	"time"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+)
+
+// Health reports whether each language server is running and initialized,
+// how many documents it has open and requests it has yet to answer, and when
+// a request to it last succeeded. Nothing is sent to the servers, so a hung
+// server does not hang the report.
+func Health(servers []SymbolServer) string {
+	now := time.Now()
+	var out strings.Builder
+	for _, server := range servers {
+		out.WriteString(formatHealth(server.Name, server.Client.Health(), now))
+	}
+	return out.String()
+}
+
+// formatHealth describes the health of one server at a point in time
+func formatHealth(name string, health lsp.Health, now time.Time) string {
+	var out strings.Builder
+	switch {
+	case !health.Running:
+		out.WriteString(msg(MsgHealthDown, name) + "\n")
+	case !health.Initialized:
+		out.WriteString(msg(MsgHealthInitializing, name) + "\n")
+	default:
+		out.WriteString(msg(MsgHealthHealthy, name) + "\n")
+	}
+	out.WriteString(msg(MsgHealthDetails, health.Running, health.Initialized, health.OpenDocuments, health.PendingRequests) + "\n")
+	if health.LastSuccess.IsZero() {
+		out.WriteString(msg(MsgHealthNoSuccess) + "\n")
+	} else {
+		out.WriteString(msg(MsgHealthLastSuccess, now.Sub(health.LastSuccess).Round(time.Second)) + "\n")
+	}
+	return out.String()
+}
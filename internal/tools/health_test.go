@@ -0,0 +1,27 @@
+package tools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatHealth(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	healthy := lsp.Health{Running: true, Initialized: true, OpenDocuments: 3, PendingRequests: 1, LastSuccess: now.Add(-90 * time.Second)}
+	assert.Equal(t, "gopls: healthy\n"+
+		"  Process running: true\n"+
+		"  Initialized: true\n"+
+		"  Open documents: 3\n"+
+		"  Pending requests: 1\n"+
+		"  Last successful request: 1m30s ago\n", formatHealth("gopls", healthy, now))
+
+	down := formatHealth("gopls", lsp.Health{Initialized: true}, now)
+	assert.Contains(t, down, "gopls: not running.")
+	assert.Contains(t, down, "  Last successful request: none yet\n")
+
+	assert.Contains(t, formatHealth("pyright", lsp.Health{Running: true}, now), "pyright: running, but initialization has not completed\n")
+}
@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -11,10 +12,10 @@ import (
 
 // GetHoverInfo retrieves hover information (type, documentation) for a symbol at the specified position
 func GetHoverInfo(ctx context.Context, client *lsp.Client, filePath string, line, column int) (string, error) {
-	// Open the file if not already open
-	err := client.OpenFile(ctx, filePath)
+	// Open the document if not already open
+	uri, err := openDocument(ctx, client, filePath)
 	if err != nil {
-		return "", fmt.Errorf("could not open file: %v", err)
+		return "", err
 	}
 
 	params := protocol.HoverParams{}
@@ -24,22 +25,27 @@ func GetHoverInfo(ctx context.Context, client *lsp.Client, filePath string, line
 		Line:      uint32(line - 1),
 		Character: uint32(column - 1),
 	}
-	uri := protocol.DocumentUri("file://" + filePath)
 	params.TextDocument = protocol.TextDocumentIdentifier{
 		URI: uri,
 	}
 	params.Position = position
 
-	// Execute the hover request
-	hoverResult, err := client.Hover(ctx, params)
-	if err != nil {
+	// Execute the hover request. The result is decoded by hand because some
+	// servers still send the deprecated MarkedString forms, which
+	// protocol.Hover cannot represent.
+	var rawResult json.RawMessage
+	if err := client.Call(ctx, "textDocument/hover", params, &rawResult); err != nil {
 		return "", fmt.Errorf("failed to get hover information: %v", err)
 	}
+	contents, err := hoverContents(rawResult)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse hover information: %v", err)
+	}
 
 	var result strings.Builder
 
-	// Process the hover contents based on Markup content
-	if hoverResult.Contents.Value == "" {
+	// Process the hover contents
+	if contents == "" {
 		// Extract the line where the hover was requested
 		lineText, err := ExtractTextFromLocation(protocol.Location{
 			URI: uri,
@@ -57,10 +63,66 @@ func GetHoverInfo(ctx context.Context, client *lsp.Client, filePath string, line
 		if err != nil {
 			toolsLogger.Warn("failed to extract line at position: %v", err)
 		}
-		result.WriteString(fmt.Sprintf("No hover information available for this position on the following line:\n%s", lineText))
+		result.WriteString(msg(MsgNoHoverInfo, lineText))
 	} else {
-		result.WriteString(hoverResult.Contents.Value)
+		result.WriteString(contents)
 	}
 
 	return result.String(), nil
 }
+
+// hoverContents extracts the text of a hover result. The contents may be
+// MarkupContent, a MarkedString or an array of MarkedStrings, where a
+// MarkedString is either a markdown string or a code block with a language.
+func hoverContents(rawResult json.RawMessage) (string, error) {
+	var hover struct {
+		Contents json.RawMessage `json:"contents"`
+	}
+	if len(rawResult) == 0 || string(rawResult) == "null" {
+		return "", nil
+	}
+	if err := json.Unmarshal(rawResult, &hover); err != nil {
+		return "", err
+	}
+	if len(hover.Contents) == 0 || string(hover.Contents) == "null" {
+		return "", nil
+	}
+
+	var items []json.RawMessage
+	if hover.Contents[0] == '[' {
+		if err := json.Unmarshal(hover.Contents, &items); err != nil {
+			return "", err
+		}
+	} else {
+		items = []json.RawMessage{hover.Contents}
+	}
+
+	var parts []string
+	for _, item := range items {
+		var text string
+		if err := json.Unmarshal(item, &text); err == nil {
+			if text != "" {
+				parts = append(parts, text)
+			}
+			continue
+		}
+
+		var content struct {
+			Kind     string `json:"kind"`
+			Language string `json:"language"`
+			Value    string `json:"value"`
+		}
+		if err := json.Unmarshal(item, &content); err != nil {
+			return "", err
+		}
+		switch {
+		case content.Value == "":
+		case content.Language != "":
+			parts = append(parts, fmt.Sprintf("```%s\n%s\n```", content.Language, content.Value))
+		default:
+			parts = append(parts, content.Value)
+		}
+	}
+
+	return strings.Join(parts, "\n\n"), nil
+}
@@ -0,0 +1,38 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHoverContents(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   string
+		expected string
+	}{
+		{"null", `null`, ""},
+		{"markup content", `{"contents": {"kind": "markdown", "value": "func Foo()"}}`, "func Foo()"},
+		{"marked string", `{"contents": "Foo docs"}`, "Foo docs"},
+		{"code block", `{"contents": {"language": "go", "value": "func Foo()"}}`, "```go\nfunc Foo()\n```"},
+		{
+			"marked string array",
+			`{"contents": [{"language": "python", "value": "def foo()"}, "", "Foo docs"]}`,
+			"```python\ndef foo()\n```\n\nFoo docs",
+		},
+		{"empty", `{"contents": {"kind": "plaintext", "value": ""}}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contents, err := hoverContents(json.RawMessage(tt.result))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, contents)
+		})
+	}
+
+	_, err := hoverContents(json.RawMessage(`{"contents": 42}`))
+	assert.Error(t, err)
+}
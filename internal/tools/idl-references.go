@@ -0,0 +1,272 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// idlExtensions are the extensions of the IDL files whose generated code is
+// bridged, which are themselves left out of the results
+var idlExtensions = []string{".proto", ".thrift"}
+
+// idlDeclaration matches the declaration of a named message, type, service or
+// rpc in a .proto or .thrift file. Thrift functions are declared by return
+// type and name, and are matched by the name followed by a parenthesis.
+func idlDeclaration(name string) *regexp.Regexp {
+	quoted := regexp.QuoteMeta(name)
+	return regexp.MustCompile(`^\s*(?:(message|enum|service|rpc|struct|union|exception|typedef)\s+` + quoted + `\b|.*\b` + quoted + `\s*\()`)
+}
+
+// idlReference is a reference found by one of the servers
+type idlReference struct {
+	Server   string
+	Location protocol.Location
+}
+
+// IDLReferences answers who uses an IDL message, enum, service or RPC across
+// languages. It finds the symbols generated from it by every language server,
+// in files marked as generated, and aggregates the references to them outside
+// of generated code. symbol may qualify an RPC with its service, as in
+// UserService.GetUser, to tell apart RPCs with the same name. If idlFile is
+// given, the symbol must be declared in it, and only code generated from it
+// is considered.
+func IDLReferences(ctx context.Context, servers []SymbolServer, symbol, idlFile string) (string, error) {
+	service, name := "", symbol
+	if i := strings.LastIndex(symbol, "."); i >= 0 {
+		service, name = symbol[:i], symbol[i+1:]
+	}
+	if name == "" {
+		return "", fmt.Errorf("invalid IDL symbol %q", symbol)
+	}
+
+	if idlFile != "" {
+		idlFile = filepath.Clean(idlFile)
+		content, err := os.ReadFile(idlFile)
+		if err != nil {
+			return "", fmt.Errorf("could not read IDL file: %v", err)
+		}
+		if !idlDeclares(string(content), name) {
+			return "", fmt.Errorf("%s does not declare %s", idlFile, name)
+		}
+	}
+
+	candidates := generatedNames(name)
+	generated := make([][]SymbolMatch, len(servers))
+	references := make([][]idlReference, len(servers))
+	errs := fanOut(ctx, len(servers), func(ctx context.Context, i int) error {
+		var err error
+		generated[i], err = generatedSymbols(ctx, servers[i].Client, candidates, service, idlFile)
+		if err != nil {
+			return err
+		}
+		for _, match := range generated[i] {
+			refs, err := symbolLocationReferences(ctx, servers[i].Client, match)
+			if err != nil {
+				return err
+			}
+			for _, ref := range refs {
+				if generatedFileOrigin(lsp.DocumentPath(ref.URI), "") == nil {
+					references[i] = append(references[i], idlReference{Server: servers[i].Name, Location: ref})
+				}
+			}
+		}
+		return nil
+	})
+
+	names := make([]string, len(servers))
+	for i, server := range servers {
+		names[i] = server.Name
+	}
+	matches, failures := mergeSymbolMatches(names, generated, errs)
+	if len(failures) == len(servers) {
+		return "", fmt.Errorf("every server failed: %s", strings.Join(failures, "; "))
+	}
+
+	var refs []idlReference
+	for i := range servers {
+		if errs[i] == nil {
+			refs = append(refs, references[i]...)
+		}
+	}
+	return formatIDLReferences(symbol, matches, refs, failures), nil
+}
+
+// idlDeclares reports whether the content of an IDL file declares a name
+func idlDeclares(content, name string) bool {
+	declaration := idlDeclaration(name)
+	for _, line := range strings.Split(content, "\n") {
+		if declaration.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// generatedNames returns the names under which generators emit an IDL name:
+// as is, as Go, Python and C++ do for types and RPCs, in lowerCamelCase for
+// Java and JavaScript methods, and in snake_case for Rust and Ruby methods
+func generatedNames(name string) []string {
+	names := []string{name}
+	for _, variant := range []string{lowerFirst(name), snakeCase(name)} {
+		if !slices.Contains(names, variant) {
+			names = append(names, variant)
+		}
+	}
+	return names
+}
+
+func lowerFirst(name string) string {
+	runes := []rune(name)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+func snakeCase(name string) string {
+	var out strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				out.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
+
+// generatedSymbols searches a server for the symbols generated under any of
+// the candidate names, keeping those in generated files, in a container
+// named after the service if one is given, and generated from idlFile if it
+// is given and the generated file names its source
+func generatedSymbols(ctx context.Context, client *lsp.Client, candidates []string, service, idlFile string) ([]SymbolMatch, error) {
+	var matches []SymbolMatch
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		found, err := workspaceSymbolMatches(ctx, client, candidate, SymbolSearchOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range found {
+			qualified := match.Name
+			if match.Container != "" {
+				qualified = match.Container + "." + match.Name
+			}
+			bare := qualified[strings.LastIndex(qualified, ".")+1:]
+			if bare != candidate || (service != "" && !strings.Contains(qualified, service)) {
+				continue
+			}
+			if isIDLFile(match.Path) {
+				continue
+			}
+			origin := generatedFileOrigin(match.Path, "")
+			if origin == nil || (idlFile != "" && origin.Source != "" && filepath.Clean(origin.Source) != idlFile) {
+				continue
+			}
+
+			key := fmt.Sprintf("%s:%d:%d", match.Path, match.Line, match.Column)
+			if !seen[key] {
+				seen[key] = true
+				matches = append(matches, match)
+			}
+		}
+	}
+	return matches, nil
+}
+
+func isIDLFile(path string) bool {
+	for _, ext := range idlExtensions {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// symbolLocationReferences finds the references to a symbol found by a
+// workspace symbol search, excluding its declaration
+func symbolLocationReferences(ctx context.Context, client *lsp.Client, match SymbolMatch) ([]protocol.Location, error) {
+	uri, err := openDocument(ctx, client, match.Path)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := client.References(ctx, protocol.ReferenceParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Position: protocol.Position{
+				Line:      uint32(match.Line - 1),
+				Character: uint32(match.Column - 1),
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get references: %v", err)
+	}
+	return refs, nil
+}
+
+// formatIDLReferences lists the generated symbols, then the references to
+// them grouped by file
+func formatIDLReferences(symbol string, generated []SymbolMatch, refs []idlReference, failures []string) string {
+	var out strings.Builder
+	for _, failure := range failures {
+		out.WriteString(failure + "\n")
+	}
+	if len(generated) == 0 {
+		out.WriteString(msg(MsgNoGeneratedSymbols, symbol))
+		return out.String()
+	}
+
+	byFile := make(map[string][]idlReference)
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		path := lsp.DocumentPath(ref.Location.URI)
+		key := fmt.Sprintf("%s:%d:%d", path, ref.Location.Range.Start.Line, ref.Location.Range.Start.Character)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		byFile[path] = append(byFile[path], ref)
+	}
+	files := make([]string, 0, len(byFile))
+	total := 0
+	for path, fileRefs := range byFile {
+		files = append(files, path)
+		total += len(fileRefs)
+	}
+	sort.Strings(files)
+
+	out.WriteString(msg(MsgIDLReferencesHeader, symbol, len(generated), total, len(files)) + "\n")
+	for _, m := range generated {
+		out.WriteString(fmt.Sprintf("- %s %s (%s:L%d:C%d)", m.Kind, m.Name, m.Path, m.Line, m.Column))
+		if m.Container != "" {
+			out.WriteString(" " + msg(MsgSymbolContainer, m.Container))
+		}
+		out.WriteString(fmt.Sprintf(" [%s, %s]\n", m.Server, m.Language))
+	}
+
+	for _, path := range files {
+		fileRefs := byFile[path]
+		sort.Slice(fileRefs, func(i, j int) bool {
+			a, b := fileRefs[i].Location.Range.Start, fileRefs[j].Location.Range.Start
+			return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+		})
+		positions := make([]string, len(fileRefs))
+		for i, ref := range fileRefs {
+			positions[i] = fmt.Sprintf("L%d:C%d", ref.Location.Range.Start.Line+1, ref.Location.Range.Start.Character+1)
+		}
+		out.WriteString(fmt.Sprintf("\n%s [%s, %s]\n%s\n", path, fileRefs[0].Server, lsp.DetectLanguageID(path), strings.Join(positions, ", ")))
+	}
+	return out.String()
+}
@@ -0,0 +1,67 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratedNames(t *testing.T) {
+	assert.Equal(t, []string{"GetUser", "getUser", "get_user"}, generatedNames("GetUser"))
+	assert.Equal(t, []string{"HTTPRequest", "hTTPRequest", "http_request"}, generatedNames("HTTPRequest"))
+	assert.Equal(t, []string{"ping"}, generatedNames("ping"))
+}
+
+func TestIDLDeclares(t *testing.T) {
+	proto := `service UserService {
+  rpc GetUser(GetUserRequest) returns (User);
+}
+message GetUserRequest {}`
+	assert.True(t, idlDeclares(proto, "UserService"))
+	assert.True(t, idlDeclares(proto, "GetUser"))
+	assert.True(t, idlDeclares(proto, "GetUserRequest"))
+	assert.False(t, idlDeclares(proto, "User"))
+
+	thrift := `struct User {
+  1: string id
+}
+service UserService {
+  User getUser(1: string id)
+}`
+	assert.True(t, idlDeclares(thrift, "User"))
+	assert.True(t, idlDeclares(thrift, "getUser"))
+	assert.False(t, idlDeclares(thrift, "id"))
+}
+
+func TestFormatIDLReferences(t *testing.T) {
+	ref := func(path string, line, character uint32, server string) idlReference {
+		return idlReference{Server: server, Location: protocol.Location{
+			URI:   protocol.DocumentUri("file://" + path),
+			Range: protocol.Range{Start: protocol.Position{Line: line, Character: character}},
+		}}
+	}
+	generated := []SymbolMatch{
+		{Name: "UserServiceClient.GetUser", Kind: "Method", Path: "/ws/gen/user.pb.go", Line: 40, Column: 2, Server: "gopls", Language: "go"},
+		{Name: "GetUser", Kind: "Method", Container: "UserServiceStub", Path: "/ws/gen/user_pb2_grpc.py", Line: 12, Column: 9, Server: "pyright", Language: "python"},
+	}
+	refs := []idlReference{
+		ref("/ws/web/api.py", 30, 4, "pyright"),
+		ref("/ws/cmd/main.go", 20, 8, "gopls"),
+		ref("/ws/cmd/main.go", 10, 2, "gopls"),
+		ref("/ws/cmd/main.go", 10, 2, "gopls"),
+	}
+
+	assert.Equal(t, `UserService.GetUser: 2 generated symbols, 3 references in 2 files outside generated code
+- Method UserServiceClient.GetUser (/ws/gen/user.pb.go:L40:C2) [gopls, go]
+- Method GetUser (/ws/gen/user_pb2_grpc.py:L12:C9) in UserServiceStub [pyright, python]
+
+/ws/cmd/main.go [gopls, go]
+L11:C3, L21:C9
+
+/ws/web/api.py [pyright, python]
+L31:C5
+`, formatIDLReferences("UserService.GetUser", generated, refs, nil))
+
+	assert.Equal(t, "Server pyright failed: boom\nNo generated symbols found for GetUser", formatIDLReferences("GetUser", nil, nil, []string{"Server pyright failed: boom"}))
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// maxImplementations caps the number of implementations rendered by FindImplementations
+const maxImplementations = 50
+
+// FindImplementations lists the concrete implementations of the interface,
+// abstract method or other symbol at the given position, showing the full
+// definition of each. Line and column are 1-indexed (will be converted to
+// 0-indexed for LSP protocol).
+func FindImplementations(ctx context.Context, client *lsp.Client, filePath string, line, column int) (string, error) {
+	contextLines := ContextLines()
+
+	// Open the document if not already open
+	uri, err := openDocument(ctx, client, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	// Convert 1-indexed line/column to 0-indexed for LSP protocol
+	result, err := client.Implementation(ctx, protocol.ImplementationParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{
+				URI: uri,
+			},
+			Position: protocol.Position{
+				Line:      uint32(line - 1),
+				Character: uint32(column - 1),
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get implementations: %v", err)
+	}
+
+	locations := definitionLocations(result.Value)
+	if len(locations) == 0 {
+		return msg(MsgNoImplementations, filePath, line, column), nil
+	}
+
+	total := len(locations)
+	if total > maxImplementations {
+		locations = locations[:maxImplementations]
+	}
+
+	implementations := formatDefinitionLocations(ctx, client, locations, contextLines)
+	if len(implementations) == 0 {
+		return msg(MsgCouldNotReadDefinition, filePath, line, column), nil
+	}
+
+	var output strings.Builder
+	output.WriteString(msg(MsgImplementationsHeader, total) + "\n\n")
+	output.WriteString(strings.Join(implementations, ""))
+	if total > maxImplementations {
+		output.WriteString("\n" + msg(MsgImplementationsLimited, maxImplementations, total) + "\n")
+	}
+	return output.String(), nil
+}
@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// FindIncomingCalls lists the callers of the function at the given position,
+// grouped by file, with the call sites shown in context.
+// Line and column are 1-indexed (will be converted to 0-indexed for LSP protocol).
+func FindIncomingCalls(ctx context.Context, client *lsp.Client, filePath string, line, column int) (string, error) {
+	contextLines := ContextLines()
+
+	// Open the document if not already open
+	uri, err := openDocument(ctx, client, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	// Convert 1-indexed line/column to 0-indexed for LSP protocol
+	position := protocol.Position{
+		Line:      uint32(line - 1),
+		Character: uint32(column - 1),
+	}
+
+	items, err := prepareCallHierarchy(ctx, client, uri, position)
+	if err != nil {
+		return "", fmt.Errorf("failed to prepare call hierarchy: %v", err)
+	}
+	if len(items) == 0 {
+		return msg(MsgNoCallHierarchy, fmt.Sprintf("%s:%d:%d", filePath, line, column)), nil
+	}
+
+	var sections []string
+	for _, item := range items {
+		calls, err := client.IncomingCalls(ctx, protocol.CallHierarchyIncomingCallsParams{
+			Item: item,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to get incoming calls: %v", err)
+		}
+		if len(calls) == 0 {
+			sections = append(sections, msg(MsgNoIncomingCalls, item.Name)+"\n")
+			continue
+		}
+
+		// Call sites are in the caller's file
+		var callSites []protocol.Location
+		callers := make(map[string][]string)
+		for _, call := range calls {
+			for _, rng := range call.FromRanges {
+				callSites = append(callSites, protocol.Location{URI: call.From.URI, Range: rng})
+			}
+
+			callerPath := strings.TrimPrefix(string(call.From.URI), "file://")
+			callers[callerPath] = append(callers[callerPath], fmt.Sprintf("%s (L%d:C%d)",
+				call.From.Name,
+				call.From.SelectionRange.Start.Line+1,
+				call.From.SelectionRange.Start.Character+1))
+		}
+
+		// Sort call sites so that the locations in each file are in order
+		sort.Slice(callSites, func(i, j int) bool {
+			if callSites[i].Range.Start.Line != callSites[j].Range.Start.Line {
+				return callSites[i].Range.Start.Line < callSites[j].Range.Start.Line
+			}
+			return callSites[i].Range.Start.Character < callSites[j].Range.Start.Character
+		})
+
+		section := msg(MsgIncomingCallsHeader, item.Name, len(calls)) + "\n"
+		for _, file := range collectReferenceFiles(ctx, client, callSites, contextLines) {
+			section += "\n" + formatCallerFile(file, callers[file.Path])
+		}
+		sections = append(sections, section)
+	}
+
+	return strings.Join(sections, "\n"), nil
+}
+
+// formatCallerFile renders the callers and call sites in a file
+func formatCallerFile(file ReferenceFile, callers []string) string {
+	result := fmt.Sprintf("---\n\n%s\n%s%s\n",
+		file.Path,
+		formatOwners(file.Path),
+		msg(MsgCallersInFile, strings.Join(callers, ", ")),
+	)
+
+	if file.Error != "" {
+		return result + "\n" + msg(MsgErrorReadingFile, file.Error)
+	}
+
+	var locStrings []string
+	for _, ref := range file.References {
+		locStrings = append(locStrings, fmt.Sprintf("L%d:C%d", ref.Line, ref.Column))
+	}
+	result += msg(MsgCallsAt, strings.Join(locStrings, ", ")) + "\n"
+
+	return result + "\n" + file.Snippet
+}
@@ -0,0 +1,143 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// inlayHint is an inlay hint with its label reduced to text. The label is
+// decoded by hand because servers send it as either a string or label parts,
+// and protocol.InlayHint only accepts parts.
+type inlayHint struct {
+	Position protocol.Position
+	Label    string
+	// Whether the hint is separated from the code before or after it
+	PaddingLeft, PaddingRight bool
+}
+
+// GetInlayHints shows a range of lines of a file with the inlay hints of the
+// language server inserted where an editor would display them: inferred
+// types of variables and parameter names of calls, among others. Hints are
+// marked with « and » to tell them apart from the code.
+func GetInlayHints(ctx context.Context, client *lsp.Client, filePath string, startLine, endLine int) (string, error) {
+	uri, err := openDocument(ctx, client, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := documentText(ctx, client, uri)
+	if err != nil {
+		return "", fmt.Errorf("could not read document: %v", err)
+	}
+	lines := strings.Split(content, "\n")
+	if startLine < 1 || startLine > len(lines) || endLine < startLine {
+		return "", fmt.Errorf("invalid line range %d-%d for a file with %d lines", startLine, endLine, len(lines))
+	}
+	endLine = min(endLine, len(lines))
+
+	params := protocol.InlayHintParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range: protocol.Range{
+			Start: protocol.Position{Line: uint32(startLine - 1)},
+			End: protocol.Position{
+				Line:      uint32(endLine - 1),
+				Character: uint32(len(utf16.Encode([]rune(lines[endLine-1])))),
+			},
+		},
+	}
+	var rawHints []json.RawMessage
+	if err := client.Call(ctx, "textDocument/inlayHint", params, &rawHints); err != nil {
+		return "", fmt.Errorf("failed to get inlay hints: %v", err)
+	}
+
+	hintsByLine := make(map[uint32][]inlayHint)
+	count := 0
+	for _, raw := range rawHints {
+		hint, err := decodeInlayHint(raw)
+		if err != nil {
+			toolsLogger.Debug("Skipping malformed inlay hint: %v", err)
+			continue
+		}
+		hintsByLine[hint.Position.Line] = append(hintsByLine[hint.Position.Line], hint)
+		count++
+	}
+	if count == 0 {
+		return msg(MsgNoInlayHints, filePath, startLine, endLine), nil
+	}
+
+	annotated := make([]string, 0, endLine-startLine+1)
+	for line := startLine - 1; line < endLine; line++ {
+		text := strings.TrimSuffix(lines[line], "\r")
+		annotated = append(annotated, insertInlayHints(text, hintsByLine[uint32(line)]))
+	}
+	return msg(MsgInlayHintsHeader, count, filePath, startLine, endLine) + "\n" + addLineNumbers(strings.Join(annotated, "\n"), startLine), nil
+}
+
+// decodeInlayHint decodes an inlay hint whose label is a string or a list of
+// label parts
+func decodeInlayHint(raw json.RawMessage) (inlayHint, error) {
+	var hint struct {
+		Position     protocol.Position `json:"position"`
+		Label        json.RawMessage   `json:"label"`
+		PaddingLeft  bool              `json:"paddingLeft"`
+		PaddingRight bool              `json:"paddingRight"`
+	}
+	if err := json.Unmarshal(raw, &hint); err != nil {
+		return inlayHint{}, err
+	}
+
+	result := inlayHint{
+		Position:     hint.Position,
+		PaddingLeft:  hint.PaddingLeft,
+		PaddingRight: hint.PaddingRight,
+	}
+	if err := json.Unmarshal(hint.Label, &result.Label); err == nil {
+		return result, nil
+	}
+	var parts []protocol.InlayHintLabelPart
+	if err := json.Unmarshal(hint.Label, &parts); err != nil {
+		return inlayHint{}, fmt.Errorf("invalid label: %v", err)
+	}
+	for _, part := range parts {
+		result.Label += part.Value
+	}
+	return result, nil
+}
+
+// insertInlayHints inserts the hints for a line at their positions, which
+// are in UTF-16 code units
+func insertInlayHints(line string, hints []inlayHint) string {
+	if len(hints) == 0 {
+		return line
+	}
+	sort.SliceStable(hints, func(i, j int) bool {
+		return hints[i].Position.Character < hints[j].Position.Character
+	})
+
+	units := utf16.Encode([]rune(line))
+	var out strings.Builder
+	last := 0
+	for _, hint := range hints {
+		at := min(int(hint.Position.Character), len(units))
+		out.WriteString(string(utf16.Decode(units[last:at])))
+		last = at
+
+		label := strings.TrimSpace(hint.Label)
+		if hint.PaddingLeft {
+			label = " " + label
+		}
+		if hint.PaddingRight {
+			label += " "
+		}
+		out.WriteString("«" + label + "»")
+	}
+	out.WriteString(string(utf16.Decode(units[last:])))
+	return out.String()
+}
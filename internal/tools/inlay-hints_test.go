@@ -0,0 +1,38 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeInlayHint(t *testing.T) {
+	hint, err := decodeInlayHint(json.RawMessage(`{"position":{"line":2,"character":5},"label":": int","kind":1}`))
+	assert.NoError(t, err)
+	assert.Equal(t, inlayHint{Position: protocol.Position{Line: 2, Character: 5}, Label: ": int"}, hint)
+
+	hint, err = decodeInlayHint(json.RawMessage(`{"position":{"line":0,"character":9},"label":[{"value":"name"},{"value":":"}],"paddingRight":true}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "name:", hint.Label)
+	assert.True(t, hint.PaddingRight)
+
+	_, err = decodeInlayHint(json.RawMessage(`{"position":{"line":0,"character":0},"label":42}`))
+	assert.Error(t, err)
+}
+
+func TestInsertInlayHints(t *testing.T) {
+	hints := []inlayHint{
+		{Position: protocol.Position{Character: 18}, Label: "sep:", PaddingRight: true},
+		{Position: protocol.Position{Character: 5}, Label: "[]string"},
+		{Position: protocol.Position{Character: 15}, Label: "s:", PaddingRight: true},
+	}
+	assert.Equal(t, "parts«[]string» := split(«s: »s, «sep: »\",\")", insertInlayHints(`parts := split(s, ",")`, hints))
+
+	// Positions count UTF-16 code units
+	hints = []inlayHint{{Position: protocol.Position{Character: 4}, Label: ": string"}}
+	assert.Equal(t, "x🙂y«: string» = 1", insertInlayHints("x🙂y = 1", hints))
+
+	assert.Equal(t, "unchanged", insertInlayHints("unchanged", nil))
+}
@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// GetInlineValues lists the variables and expressions the server considers
+// relevant between startLine and endLine when execution is stopped at
+// stoppedLine, as a debugger would show them inline. frameID identifies the
+// stack frame for servers that use it. Lines are 1-indexed.
+func GetInlineValues(ctx context.Context, client *lsp.Client, filePath string, startLine, endLine, stoppedLine, frameID int) (string, error) {
+	// Open the document if not already open
+	uri, err := openDocument(ctx, client, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	// Convert 1-indexed lines to 0-indexed for LSP protocol. The range ends at
+	// the start of the line after endLine, so that endLine is included.
+	stopped := protocol.Position{Line: uint32(stoppedLine - 1)}
+	values, err := client.InlineValue(ctx, protocol.InlineValueParams{
+		TextDocument: protocol.TextDocumentIdentifier{
+			URI: uri,
+		},
+		Range: protocol.Range{
+			Start: protocol.Position{Line: uint32(startLine - 1)},
+			End:   protocol.Position{Line: uint32(endLine)},
+		},
+		Context: protocol.InlineValueContext{
+			FrameID:         int32(frameID),
+			StoppedLocation: protocol.Range{Start: stopped, End: stopped},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get inline values: %v", err)
+	}
+	if len(values) == 0 {
+		return msg(MsgNoInlineValues, filePath, startLine, endLine), nil
+	}
+
+	// Variable lookups and expressions may leave the name to be read from
+	// the document
+	var lines []string
+	if content, err := documentText(ctx, client, uri); err == nil {
+		lines = strings.Split(content, "\n")
+	} else {
+		toolsLogger.Warn("failed to read %s: %v", filePath, err)
+	}
+
+	var result strings.Builder
+	result.WriteString(msg(MsgInlineValuesHeader, filePath, startLine, endLine, stoppedLine) + "\n")
+	for _, value := range values {
+		switch v := value.Value.(type) {
+		case protocol.InlineValueText:
+			result.WriteString("- " + msg(MsgInlineValueText, formatRangeStart(v.Range), v.Text) + "\n")
+		case protocol.InlineValueVariableLookup:
+			name := v.VariableName
+			if name == "" {
+				name = rangeText(lines, v.Range)
+			}
+			result.WriteString("- " + msg(MsgInlineValueVariable, formatRangeStart(v.Range), name) + "\n")
+		case protocol.InlineValueEvaluatableExpression:
+			expression := v.Expression
+			if expression == "" {
+				expression = rangeText(lines, v.Range)
+			}
+			result.WriteString("- " + msg(MsgInlineValueExpression, formatRangeStart(v.Range), expression) + "\n")
+		}
+	}
+
+	return result.String(), nil
+}
+
+// formatRangeStart renders the 1-indexed start of a range
+func formatRangeStart(r protocol.Range) string {
+	return fmt.Sprintf("L%d:C%d", r.Start.Line+1, r.Start.Character+1)
+}
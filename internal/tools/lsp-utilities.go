@@ -3,8 +3,6 @@ package tools
 import (
 	"context"
 	"fmt"
-	"net/url"
-	"os"
 	"strings"
 
 	"github.com/koonwen/mcp-language-server/internal/lsp"
@@ -59,20 +57,14 @@ func GetFullDefinition(ctx context.Context, client *lsp.Client, startLocation pr
 	found = searchSymbols(symbols)
 
 	if found {
-		// Convert URI to filesystem path
-		filePath, err := url.PathUnescape(strings.TrimPrefix(string(startLocation.URI), "file://"))
-		if err != nil {
-			return "", protocol.Location{}, fmt.Errorf("failed to unescape URI: %w", err)
-		}
-
-		// Read the file to get the full lines of the definition
+		// Read the document to get the full lines of the definition
 		// because we may have a start and end column
-		content, err := os.ReadFile(filePath)
+		content, err := client.ReadDocument(startLocation.URI)
 		if err != nil {
 			return "", protocol.Location{}, fmt.Errorf("failed to read file: %w", err)
 		}
 
-		lines := strings.Split(string(content), "\n")
+		lines := strings.Split(content, "\n")
 
 		// Extend start to beginning of line
 		symbolRange.Start.Character = 0
@@ -0,0 +1,144 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+)
+
+// renderMarkdown renders the output of a tool as Markdown. It reports false
+// if the tool has nothing to show, so that its default message is returned.
+func renderMarkdown(data any) (string, bool) {
+	switch output := data.(type) {
+	case ReferencesOutput:
+		if len(output.Files) == 0 {
+			return "", false
+		}
+		return formatReferencesMarkdown(output), true
+	case DiagnosticsOutput:
+		if len(output.Diagnostics) == 0 {
+			return "", false
+		}
+		return formatDiagnosticsMarkdown(output), true
+	}
+	return "", false
+}
+
+// formatReferencesMarkdown renders references with a section per file
+func formatReferencesMarkdown(output ReferencesOutput) string {
+	var result strings.Builder
+	for _, file := range output.Files {
+		result.WriteString(markdownHeader(file.Path, file.Owners))
+		result.WriteString(msg(MsgReferencesInFile, len(file.References)) + "\n")
+		if file.Error != "" {
+			result.WriteString("\n" + msg(MsgErrorReadingFile, file.Error) + "\n\n")
+			continue
+		}
+
+		var locations []string
+		for _, ref := range file.References {
+			locations = append(locations, fmt.Sprintf("`L%d:C%d`", ref.Line, ref.Column))
+		}
+		if len(locations) > 0 {
+			result.WriteString(msg(MsgReferencesAt, strings.Join(locations, ", ")) + "\n")
+		}
+		result.WriteString("\n" + markdownCode(file.Path, file.Snippet))
+	}
+	return strings.TrimSuffix(result.String(), "\n") + formatTruncated(output.Continuation)
+}
+
+// formatDiagnosticsMarkdown renders the diagnostics of a file as a list
+// followed by the lines they are on
+func formatDiagnosticsMarkdown(output DiagnosticsOutput) string {
+	var result strings.Builder
+	result.WriteString(markdownHeader(output.Path, output.Owners))
+	result.WriteString(msg(MsgDiagnosticsInFile, len(output.Diagnostics)) + "\n\n")
+	for _, diag := range output.Diagnostics {
+		result.WriteString(fmt.Sprintf("- **%s** `L%d:C%d`: %s", diag.Severity, diag.Line, diag.Column, diag.Message))
+		switch {
+		case diag.Source != "" && diag.Code != "":
+			result.WriteString(fmt.Sprintf(" (%s %s)", diag.Source, diag.Code))
+		case diag.Source != "":
+			result.WriteString(fmt.Sprintf(" (%s)", diag.Source))
+		case diag.Code != "":
+			result.WriteString(fmt.Sprintf(" (%s)", diag.Code))
+		}
+		result.WriteString("\n")
+	}
+
+	if output.Error != "" {
+		result.WriteString("\n" + msg(MsgErrorReadingFile, output.Error) + "\n")
+	} else if output.Snippet != "" {
+		result.WriteString("\n" + markdownCode(output.Path, output.Snippet))
+	}
+	return result.String()
+}
+
+// ReadDefinitionMarkdown is like ReadDefinition but returns Markdown, with a
+// section per definition and its code in a fenced block
+func ReadDefinitionMarkdown(ctx context.Context, client *lsp.Client, symbolName string) (string, error) {
+	found, err := lookupDefinitions(ctx, client, symbolName)
+	if err != nil {
+		return "", err
+	}
+	if len(found) == 0 {
+		return msg(MsgSymbolNotFound, symbolName), nil
+	}
+
+	var result strings.Builder
+	for _, def := range found {
+		path := lsp.DocumentPath(def.Location.URI)
+		result.WriteString(fmt.Sprintf("## `%s`\n\n", def.Name))
+		result.WriteString(msg(MsgFileHeader, "`"+path+"`") + "\n")
+		result.WriteString(formatGeneratedFrom(path, def.Name))
+		if def.Kind != "" {
+			result.WriteString(msg(MsgKindHeader, def.Kind) + "\n")
+		}
+		if def.Container != "" {
+			result.WriteString(msg(MsgContainerHeader, def.Container) + "\n")
+		}
+		result.WriteString(msg(MsgRangeHeader,
+			def.Location.Range.Start.Line+1,
+			def.Location.Range.Start.Character+1,
+			def.Location.Range.End.Line+1,
+			def.Location.Range.End.Character+1,
+		) + "\n\n")
+		code := trimSnippet(path, addLineNumbers(def.Code, int(def.Location.Range.Start.Line)+1), nil)
+		result.WriteString(markdownCode(path, code) + "\n")
+	}
+
+	prefetchDefinitions(client, definitionsCode(found), symbolName)
+	return strings.TrimSuffix(result.String(), "\n"), nil
+}
+
+// markdownHeader renders the header of the section of a file, with its
+// owners and where it was generated from
+func markdownHeader(path string, owners []string) string {
+	header := fmt.Sprintf("## `%s`\n\n", path)
+	if len(owners) > 0 {
+		header += msg(MsgOwnersHeader, strings.Join(owners, " ")) + "\n"
+	}
+	return header + formatGeneratedFrom(path, "")
+}
+
+// markdownCode renders a snippet of a file in a fenced code block tagged with
+// the language of the file. A snippet already shown is replaced by a
+// reference to it, outside the block.
+func markdownCode(path, snippet string) string {
+	snippet = strings.TrimSuffix(snippet, "\n")
+	if snippet == "" {
+		return ""
+	}
+	if deduped := strings.TrimSuffix(snippets.dedupe(snippet+"\n"), "\n"); deduped != snippet {
+		return deduped + "\n\n"
+	}
+
+	// The fence must be longer than any run of backticks in the code
+	fence := "```"
+	for strings.Contains(snippet, fence) {
+		fence += "`"
+	}
+	return fmt.Sprintf("%s%s\n%s\n%s\n\n", fence, lsp.DetectLanguageID(path), snippet, fence)
+}
@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdownOutput(t *testing.T) {
+	ctx := WithMarkdownOutput(context.Background())
+	assert.True(t, MarkdownOutput(ctx))
+	assert.False(t, JSONOutput(ctx))
+	assert.False(t, MarkdownOutput(WithJSONOutput(context.Background())))
+
+	t.Run("references", func(t *testing.T) {
+		text, ok, err := renderTemplate(ctx, "references", ReferencesOutput{
+			Target: "Foo",
+			Files: []ReferenceFile{
+				{Path: "/ws/a.go", References: []SourcePosition{{Line: 1, Column: 2}}, Snippet: "1|foo\n"},
+				{Path: "/ws/b.py", References: []SourcePosition{{Line: 3, Column: 1}}, Error: "permission denied"},
+			},
+		})
+		assert.True(t, ok)
+		assert.NoError(t, err)
+		assert.Equal(t, "## `/ws/a.go`\n\n"+
+			"References in File: 1\n"+
+			"At: `L1:C2`\n\n"+
+			"```go\n1|foo\n```\n\n"+
+			"## `/ws/b.py`\n\n"+
+			"References in File: 1\n\n"+
+			"Error reading file: permission denied\n", text)
+	})
+
+	t.Run("no references", func(t *testing.T) {
+		_, ok, _ := renderTemplate(ctx, "references", ReferencesOutput{Target: "Foo", Files: []ReferenceFile{}})
+		assert.False(t, ok)
+	})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		text, ok, err := renderTemplate(ctx, "diagnostics", DiagnosticsOutput{
+			Path: "/ws/a.ts",
+			Diagnostics: []DiagnosticItem{
+				{Severity: "ERROR", Line: 2, Column: 5, Message: "cannot find name", Source: "ts", Code: "2304"},
+				{Severity: "WARNING", Line: 4, Column: 1, Message: "unused"},
+			},
+			Snippet: "2|x\n",
+		})
+		assert.True(t, ok)
+		assert.NoError(t, err)
+		assert.Equal(t, "## `/ws/a.ts`\n\n"+
+			"Diagnostics in File: 2\n\n"+
+			"- **ERROR** `L2:C5`: cannot find name (ts 2304)\n"+
+			"- **WARNING** `L4:C1`: unused\n\n"+
+			"```typescript\n2|x\n```\n\n", text)
+	})
+}
+
+func TestMarkdownCode(t *testing.T) {
+	assert.Equal(t, "```\nplain\n```\n\n", markdownCode("/ws/notes", "plain"))
+	assert.Equal(t, "````markdown\n1|```go\n````\n\n", markdownCode("/ws/README.md", "1|```go\n"))
+	assert.Equal(t, "", markdownCode("/ws/a.go", ""))
+}
@@ -0,0 +1,389 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MessageID identifies a piece of boilerplate prose in tool output
+type MessageID string
+
+// Message IDs for localizable tool output. Each message is a fmt format string;
+// translations may reorder arguments with explicit indexes such as %[2]d.
+const (
+	MsgFileHeader              MessageID = "fileHeader"
+	MsgReferencesInFile        MessageID = "referencesInFile"
+	MsgReferencesAt            MessageID = "referencesAt"
+	MsgErrorReadingFile        MessageID = "errorReadingFile"
+	MsgNoReferencesAtPosition  MessageID = "noReferencesAtPosition"
+	MsgNoReferencesForSymbol   MessageID = "noReferencesForSymbol"
+	MsgNoDefinitionAtPosition  MessageID = "noDefinitionAtPosition"
+	MsgCouldNotReadDefinition  MessageID = "couldNotReadDefinition"
+	MsgSymbolNotFound          MessageID = "symbolNotFound"
+	MsgSymbolHeader            MessageID = "symbolHeader"
+	MsgKindHeader              MessageID = "kindHeader"
+	MsgContainerHeader         MessageID = "containerHeader"
+	MsgRangeHeader             MessageID = "rangeHeader"
+	MsgDefinitionAtHeader      MessageID = "definitionAtHeader"
+	MsgDiagnosticsInFile       MessageID = "diagnosticsInFile"
+	MsgNoDiagnostics           MessageID = "noDiagnostics"
+	MsgNoHoverInfo             MessageID = "noHoverInfo"
+	MsgRenameSucceeded         MessageID = "renameSucceeded"
+	MsgRenameNoOccurrences     MessageID = "renameNoOccurrences"
+	MsgEditsApplied            MessageID = "editsApplied"
+	MsgWatchlistSummary        MessageID = "watchlistSummary"
+	MsgWatchlistEntry          MessageID = "watchlistEntry"
+	MsgWatchlistNotFound       MessageID = "watchlistNotFound"
+	MsgCallPathsFound          MessageID = "callPathsFound"
+	MsgCallPathHeader          MessageID = "callPathHeader"
+	MsgNoCallPath              MessageID = "noCallPath"
+	MsgNoCallHierarchy         MessageID = "noCallHierarchy"
+	MsgCallPathSearchTruncated MessageID = "callPathSearchTruncated"
+	MsgCallPathResultTruncated MessageID = "callPathResultTruncated"
+	MsgCallPathCalledAt        MessageID = "callPathCalledAt"
+	MsgResultsTruncated        MessageID = "resultsTruncated"
+	MsgNoMoreResults           MessageID = "noMoreResults"
+	MsgReferenceCountSummary   MessageID = "referenceCountSummary"
+	MsgDiffDiagnosticsSummary  MessageID = "diffDiagnosticsSummary"
+	MsgNoDiffDiagnostics       MessageID = "noDiffDiagnostics"
+	MsgOwnersHeader            MessageID = "ownersHeader"
+	MsgIncomingCallsHeader     MessageID = "incomingCallsHeader"
+	MsgNoIncomingCalls         MessageID = "noIncomingCalls"
+	MsgCallersInFile           MessageID = "callersInFile"
+	MsgCallsAt                 MessageID = "callsAt"
+	MsgRenamePackageDryRun     MessageID = "renamePackageDryRun"
+	MsgRenamePackageApplied    MessageID = "renamePackageApplied"
+	MsgRenamePackageMove       MessageID = "renamePackageMove"
+	MsgRenamePackageCreate     MessageID = "renamePackageCreate"
+	MsgRenamePackageDelete     MessageID = "renamePackageDelete"
+	MsgRenamePackageImportPath MessageID = "renamePackageImportPath"
+	MsgRenamePackageName       MessageID = "renamePackageName"
+	MsgOnSaveEdits             MessageID = "onSaveEdits"
+	MsgRenamePackageEdits      MessageID = "renamePackageEdits"
+	MsgRenameFileApplied       MessageID = "renameFileApplied"
+	MsgOutgoingCallsHeader     MessageID = "outgoingCallsHeader"
+	MsgNoOutgoingCalls         MessageID = "noOutgoingCalls"
+	MsgRecursiveCall           MessageID = "recursiveCall"
+	MsgCallTreeTruncated       MessageID = "callTreeTruncated"
+	MsgReadOnly                MessageID = "readOnly"
+	MsgTypeHierarchyHeader     MessageID = "typeHierarchyHeader"
+	MsgTypeHierarchySection    MessageID = "typeHierarchySection"
+	MsgNoTypeHierarchy         MessageID = "noTypeHierarchy"
+	MsgNoRelatedTypes          MessageID = "noRelatedTypes"
+	MsgTypeTreeTruncated       MessageID = "typeTreeTruncated"
+	MsgImplementationsHeader   MessageID = "implementationsHeader"
+	MsgNoImplementations       MessageID = "noImplementations"
+	MsgNoTypeDefinition        MessageID = "noTypeDefinition"
+	MsgNoDeclaration           MessageID = "noDeclaration"
+	MsgInlineValuesHeader      MessageID = "inlineValuesHeader"
+	MsgNoInlineValues          MessageID = "noInlineValues"
+	MsgInlineValueText         MessageID = "inlineValueText"
+	MsgInlineValueVariable     MessageID = "inlineValueVariable"
+	MsgInlineValueExpression   MessageID = "inlineValueExpression"
+	MsgOutlineHeader           MessageID = "outlineHeader"
+	MsgNoDocumentSymbols       MessageID = "noDocumentSymbols"
+	MsgSymbolContainer         MessageID = "symbolContainer"
+	MsgSymbolSearchHeader      MessageID = "symbolSearchHeader"
+	MsgImplementationsLimited  MessageID = "implementationsLimited"
+	MsgRenamable               MessageID = "renamable"
+	MsgNotRenamable            MessageID = "notRenamable"
+	MsgNoRenamableSymbol       MessageID = "noRenamableSymbol"
+	MsgCodeActionsHeader       MessageID = "codeActionsHeader"
+	MsgNoCodeActions           MessageID = "noCodeActions"
+	MsgCodeActionPreferred     MessageID = "codeActionPreferred"
+	MsgCodeActionDisabled      MessageID = "codeActionDisabled"
+	MsgCodeActionFixes         MessageID = "codeActionFixes"
+	MsgCodeActionEdits         MessageID = "codeActionEdits"
+	MsgCodeActionCommand       MessageID = "codeActionCommand"
+	MsgCodeActionApplied       MessageID = "codeActionApplied"
+	MsgCodeActionExecuted      MessageID = "codeActionExecuted"
+	MsgCodeActionEdited        MessageID = "codeActionEdited"
+	MsgCodeActionNoChanges     MessageID = "codeActionNoChanges"
+	MsgFormatted               MessageID = "formatted"
+	MsgAlreadyFormatted        MessageID = "alreadyFormatted"
+	MsgFormatDryRun            MessageID = "formatDryRun"
+	MsgImportAdded             MessageID = "importAdded"
+	MsgAlreadyImported         MessageID = "alreadyImported"
+	MsgAtRevision              MessageID = "atRevision"
+	MsgCurrentVersion          MessageID = "currentVersion"
+	MsgServerFailed            MessageID = "serverFailed"
+	MsgNoCompletions           MessageID = "noCompletions"
+	MsgCompletionsHeader       MessageID = "completionsHeader"
+	MsgNoInlayHints            MessageID = "noInlayHints"
+	MsgInlayHintsHeader        MessageID = "inlayHintsHeader"
+	MsgSnippetSeen             MessageID = "snippetSeen"
+	MsgNoSemanticTokens        MessageID = "noSemanticTokens"
+	MsgSemanticTokensHeader    MessageID = "semanticTokensHeader"
+	MsgGeneratedFrom           MessageID = "generatedFrom"
+	MsgNoFoldingRanges         MessageID = "noFoldingRanges"
+	MsgFoldingRangesHeader     MessageID = "foldingRangesHeader"
+	MsgNoHighlights            MessageID = "noHighlights"
+	MsgHighlightsHeader        MessageID = "highlightsHeader"
+	MsgGeneratedBy             MessageID = "generatedBy"
+	MsgNoGeneratedSymbols      MessageID = "noGeneratedSymbols"
+	MsgIDLReferencesHeader     MessageID = "idlReferencesHeader"
+	MsgNoSelectionRanges       MessageID = "noSelectionRanges"
+	MsgSelectionRangesHeader   MessageID = "selectionRangesHeader"
+	MsgNoCodeLenses            MessageID = "noCodeLenses"
+	MsgCodeLensesHeader        MessageID = "codeLensesHeader"
+	MsgCodeLensUnresolved      MessageID = "codeLensUnresolved"
+	MsgCodeLensExecuted        MessageID = "codeLensExecuted"
+	MsgCommandResult           MessageID = "commandResult"
+	MsgServerMessages          MessageID = "serverMessages"
+	MsgNoEmbeddedCode          MessageID = "noEmbeddedCode"
+	MsgNoEmbeddedCodeAt        MessageID = "noEmbeddedCodeAt"
+	MsgEmbeddedHeader          MessageID = "embeddedHeader"
+	MsgNoEmbeddedDiagnostics   MessageID = "noEmbeddedDiagnostics"
+	MsgNoDocumentLinks         MessageID = "noDocumentLinks"
+	MsgDocumentLinksHeader     MessageID = "documentLinksHeader"
+	MsgLinkUnresolved          MessageID = "linkUnresolved"
+	MsgSnippetTrimmed          MessageID = "snippetTrimmed"
+	MsgWorkspaceDiagnostics    MessageID = "workspaceDiagnostics"
+	MsgNoWorkspaceDiagnostics  MessageID = "noWorkspaceDiagnostics"
+	MsgPublishedOnly           MessageID = "publishedOnly"
+	MsgMoreFiles               MessageID = "moreFiles"
+	MsgDiagnosticsFiltered     MessageID = "diagnosticsFiltered"
+	MsgNoRunnables             MessageID = "noRunnables"
+	MsgRunnablesHeader         MessageID = "runnablesHeader"
+	MsgRunnableKind            MessageID = "runnableKind"
+	MsgRunnablesHint           MessageID = "runnablesHint"
+	MsgRunnableFilesLimited    MessageID = "runnableFilesLimited"
+	MsgAnalysisUnfinished      MessageID = "analysisUnfinished"
+	MsgEditDryRun              MessageID = "editDryRun"
+	MsgWorkspaceEditDryRun     MessageID = "workspaceEditDryRun"
+	MsgWorkspaceEditEmpty      MessageID = "workspaceEditEmpty"
+	MsgChangeAnnotations       MessageID = "changeAnnotations"
+	MsgNeedsConfirmation       MessageID = "needsConfirmation"
+	MsgCodeActionNotExecuted   MessageID = "codeActionNotExecuted"
+	MsgCodeActionPreview       MessageID = "codeActionPreview"
+	MsgNothingToUndo           MessageID = "nothingToUndo"
+	MsgEditUndone              MessageID = "editUndone"
+	MsgUndoFileRemoved         MessageID = "undoFileRemoved"
+	MsgUndoFileRecreated       MessageID = "undoFileRecreated"
+	MsgUndoRemaining           MessageID = "undoRemaining"
+	MsgUndoConflict            MessageID = "undoConflict"
+	MsgServerInfoName          MessageID = "serverInfoName"
+	MsgServerInfoSupported     MessageID = "serverInfoSupported"
+	MsgServerInfoUnsupported   MessageID = "serverInfoUnsupported"
+	MsgServerInfoUnavailable   MessageID = "serverInfoUnavailable"
+	MsgServerInfoCapabilities  MessageID = "serverInfoCapabilities"
+	MsgHealthHealthy           MessageID = "healthHealthy"
+	MsgHealthDown              MessageID = "healthDown"
+	MsgHealthInitializing      MessageID = "healthInitializing"
+	MsgHealthDetails           MessageID = "healthDetails"
+	MsgHealthLastSuccess       MessageID = "healthLastSuccess"
+	MsgHealthNoSuccess         MessageID = "healthNoSuccess"
+	MsgServerLogsHeader        MessageID = "serverLogsHeader"
+	MsgServerLogsEmpty         MessageID = "serverLogsEmpty"
+)
+
+// defaultMessages holds the English text for every message
+var defaultMessages = map[MessageID]string{
+	MsgFileHeader:              "File: %s",
+	MsgReferencesInFile:        "References in File: %d",
+	MsgReferencesAt:            "At: %s",
+	MsgErrorReadingFile:        "Error reading file: %s",
+	MsgNoReferencesAtPosition:  "No references found at %s:%d:%d",
+	MsgNoReferencesForSymbol:   "No references found for symbol: %s",
+	MsgNoDefinitionAtPosition:  "No definition found at %s:%d:%d",
+	MsgCouldNotReadDefinition:  "Could not read definition at %s:%d:%d",
+	MsgSymbolNotFound:          "%s not found",
+	MsgSymbolHeader:            "Symbol: %s",
+	MsgKindHeader:              "Kind: %s",
+	MsgContainerHeader:         "Container Name: %s",
+	MsgRangeHeader:             "Range: L%d:C%d - L%d:C%d",
+	MsgDefinitionAtHeader:      "Definition at: L%d:C%d - L%d:C%d",
+	MsgDiagnosticsInFile:       "Diagnostics in File: %d",
+	MsgNoDiagnostics:           "No diagnostics found for %s",
+	MsgNoHoverInfo:             "No hover information available for this position on the following line:\n%s",
+	MsgRenameSucceeded:         "Successfully renamed symbol to '%s'.\nUpdated %d occurrences across %d files:\n%s",
+	MsgRenameNoOccurrences:     "Failed to rename symbol. 0 occurrences found.",
+	MsgEditsApplied:            "Successfully applied text edits. %d lines removed, %d lines added.",
+	MsgWatchlistSummary:        "Security watchlist: %d of %d entries have usages",
+	MsgWatchlistEntry:          "=== %s (%d usages) ===",
+	MsgWatchlistNotFound:       "No usages found: %s",
+	MsgCallPathsFound:          "Found %d call paths from %s to %s",
+	MsgCallPathHeader:          "Path %d (%d calls):",
+	MsgNoCallPath:              "No call path found from %s to %s within depth %d",
+	MsgNoCallHierarchy:         "No call hierarchy available for %s",
+	MsgCallPathSearchTruncated: " (search was truncated, try a smaller breadth or a more specific symbol)",
+	MsgCallPathResultTruncated: "Search was truncated; more paths may exist.",
+	MsgCallPathCalledAt:        " called at L%d:C%d",
+	MsgResultsTruncated:        "Results truncated due to timeout, continuation token: %s",
+	MsgNoMoreResults:           "No more results.",
+	MsgReferenceCountSummary:   "%s: %d references in %d files",
+	MsgDiffDiagnosticsSummary:  "Diagnostics on changed lines: %d in %d of %d changed files",
+	MsgNoDiffDiagnostics:       "No diagnostics on changed lines in %d changed files",
+	MsgOwnersHeader:            "Owners: %s",
+	MsgIncomingCallsHeader:     "Callers of %s: %d",
+	MsgNoIncomingCalls:         "No callers found for %s",
+	MsgCallersInFile:           "Callers: %s",
+	MsgCallsAt:                 "Calls at: %s",
+	MsgRenamePackageDryRun:     "Dry run: no changes were made. Run again with dryRun set to false to apply them.",
+	MsgRenamePackageApplied:    "Package renamed.",
+	MsgRenamePackageMove:       "Move: %s -> %s",
+	MsgRenamePackageCreate:     "Create: %s",
+	MsgRenamePackageDelete:     "Delete: %s",
+	MsgRenamePackageImportPath: "Import path: %s -> %s",
+	MsgRenamePackageName:       "Package name: %s -> %s (importers keep %s as the import name)",
+	MsgOnSaveEdits:             "The server applied %d edits on save to %s. Read the file again before editing it.",
+	MsgRenamePackageEdits:      "Edits in %d files:",
+	MsgRenameFileApplied:       "File renamed.",
+	MsgOutgoingCallsHeader:     "Outgoing calls from %s (%s), depth %d:",
+	MsgNoOutgoingCalls:         "No outgoing calls found for %s",
+	MsgRecursiveCall:           "(recursive)",
+	MsgCallTreeTruncated:       "Call tree truncated after %d calls; use a smaller depth or start from a callee.",
+	MsgReadOnly:                "(read-only)",
+	MsgTypeHierarchyHeader:     "Type hierarchy of %s (%s):",
+	MsgTypeHierarchySection:    "%s:",
+	MsgNoTypeHierarchy:         "No type hierarchy available for %s",
+	MsgNoRelatedTypes:          "No %s found for %s",
+	MsgTypeTreeTruncated:       "Type tree truncated after %d types; use a smaller depth.",
+	MsgImplementationsHeader:   "Implementations: %d",
+	MsgNoImplementations:       "No implementations found at %s:%d:%d",
+	MsgNoTypeDefinition:        "No type definition found at %s:%d:%d",
+	MsgNoDeclaration:           "No declaration found at %s:%d:%d",
+	MsgInlineValuesHeader:      "Inline values in %s L%d-L%d, stopped at L%d:",
+	MsgNoInlineValues:          "No inline values found in %s L%d-L%d",
+	MsgInlineValueText:         "%s: %s",
+	MsgInlineValueVariable:     "%s: variable %s",
+	MsgInlineValueExpression:   "%s: evaluate %s",
+	MsgOutlineHeader:           "Outline of %s:",
+	MsgNoDocumentSymbols:       "No symbols found in %s",
+	MsgSymbolContainer:         "in %s",
+	MsgSymbolSearchHeader:      "Top %d of %d symbols matching %s:",
+	MsgImplementationsLimited:  "Showing the first %d of %d implementations.",
+	MsgRenamable:               "Can rename '%s' at %s",
+	MsgNotRenamable:            "Cannot rename at %s:%d:%d: %s",
+	MsgNoRenamableSymbol:       "no renamable symbol at this position",
+	MsgCodeActionsHeader:       "Code actions for %s: %d",
+	MsgNoCodeActions:           "No code actions available for %s",
+	MsgCodeActionPreferred:     "(preferred)",
+	MsgCodeActionDisabled:      "(disabled: %s)",
+	MsgCodeActionFixes:         "Fixes: %s",
+	MsgCodeActionEdits:         "Edits: %s",
+	MsgCodeActionCommand:       "Runs command: %s",
+	MsgCodeActionApplied:       "Applied code action: %s",
+	MsgCodeActionExecuted:      "Executed command: %s",
+	MsgCodeActionEdited:        "Changed %d ranges in %d files:",
+	MsgCodeActionNoChanges:     "The code action made no changes.",
+	MsgFormatted:               "Formatted %s: %d lines added, %d lines removed.",
+	MsgAlreadyFormatted:        "%s is already formatted.",
+	MsgFormatDryRun:            "Dry run: formatting %s would make these changes. Run again with dryRun set to false to apply them.",
+	MsgImportAdded:             "Imported %s in %s:",
+	MsgAlreadyImported:         "%s is already imported in %s.",
+	MsgAtRevision:              "%s at %s (%s):",
+	MsgCurrentVersion:          "(current version)",
+	MsgServerFailed:            "Server %s failed: %v",
+	MsgNoCompletions:           "No completions available at %s:%d:%d",
+	MsgCompletionsHeader:       "Top %d of %d completions at %s:%d:%d:",
+	MsgNoInlayHints:            "No inlay hints in %s lines %d-%d",
+	MsgInlayHintsHeader:        "%d inlay hints in %s lines %d-%d, marked with « and »:",
+	MsgSnippetSeen:             "[snippet %s, %d lines, unchanged since returned earlier; use fetch_snippet to see it again]",
+	MsgNoSemanticTokens:        "No semantic tokens in %s lines %d-%d",
+	MsgSemanticTokensHeader:    "%d semantic tokens in %s lines %d-%d, as text:type.modifiers:",
+	MsgGeneratedFrom:           "Generated from: %s",
+	MsgNoFoldingRanges:         "No folding ranges in %s",
+	MsgFoldingRangesHeader:     "%d blocks in %s (%d lines), nested by depth:",
+	MsgNoHighlights:            "No occurrences highlighted at %s:%d:%d",
+	MsgHighlightsHeader:        "%d occurrences of %q in %s (%s):",
+	MsgGeneratedBy:             "Generated by: %s (do not edit)",
+	MsgNoGeneratedSymbols:      "No generated symbols found for %s",
+	MsgIDLReferencesHeader:     "%s: %d generated symbols, %d references in %d files outside generated code",
+	MsgNoSelectionRanges:       "No selection ranges at %s:%d:%d",
+	MsgSelectionRangesHeader:   "%d nested ranges at %s:%d:%d, innermost first:",
+	MsgNoCodeLenses:            "No code lenses in %s",
+	MsgCodeLensesHeader:        "%d code lenses in %s:",
+	MsgCodeLensUnresolved:      "(unresolved, no command)",
+	MsgCodeLensExecuted:        "Executed code lens: %s (%s)",
+	MsgCommandResult:           "Result: %s",
+	MsgServerMessages:          "Messages from the server:",
+	MsgNoEmbeddedCode:          "No embedded code found in %s",
+	MsgNoEmbeddedCodeAt:        "No embedded code at %s:%d:%d",
+	MsgEmbeddedHeader:          "Embedded %s at %s:L%d:C%d:",
+	MsgNoEmbeddedDiagnostics:   "No diagnostics in %d embedded regions of %s",
+	MsgNoDocumentLinks:         "No links in %s",
+	MsgDocumentLinksHeader:     "%d links in %s:",
+	MsgLinkUnresolved:          "(unresolved)",
+	MsgSnippetTrimmed:          "(%d comment lines and %d blank lines removed)",
+	MsgWorkspaceDiagnostics:    "Workspace diagnostics: %s in %d files",
+	MsgNoWorkspaceDiagnostics:  "No diagnostics in the workspace",
+	MsgPublishedOnly:           "(The language server does not report diagnostics for the whole workspace; only files it has published diagnostics for, usually open files, are included.)",
+	MsgMoreFiles:               "... and %d more files",
+	MsgDiagnosticsFiltered:     "(%d diagnostics not matching the filter were left out)",
+	MsgNoRunnables:             "No runnable code lenses found in %d candidate files in %s",
+	MsgRunnablesHeader:         "%d runnables in %s, in %d files:",
+	MsgRunnableKind:            "%s:",
+	MsgRunnablesHint:           "Run one with execute_codelens, passing its file and [index].",
+	MsgRunnableFilesLimited:    "Only the first %d of %d candidate files were checked; pass a subdirectory as path to see the rest.",
+	MsgAnalysisUnfinished:      "(The language server was still analyzing after %s; these diagnostics may be stale.)",
+	MsgEditDryRun:              "Dry run: no changes were made to %s. Run again with dryRun set to false to apply these changes.",
+	MsgWorkspaceEditDryRun:     "Dry run: no changes were made. Run again with dryRun set to false to apply these changes to %d files.",
+	MsgWorkspaceEditEmpty:      "The edit makes no changes.",
+	MsgChangeAnnotations:       "Labelled changes:",
+	MsgNeedsConfirmation:       "(needs confirmation)",
+	MsgCodeActionNotExecuted:   "The action also runs the command %s, which was not executed. Its changes cannot be previewed.",
+	MsgCodeActionPreview:       "Code action: %s",
+	MsgNothingToUndo:           "No edits to undo. Only edits made by tools in this session can be undone.",
+	MsgEditUndone:              "Undid %s from %s, restoring %d files:",
+	MsgUndoFileRemoved:         "%s (removed)",
+	MsgUndoFileRecreated:       "%s (recreated)",
+	MsgUndoRemaining:           "%d earlier edits can be undone.",
+	MsgUndoConflict:            "%v. Review the changes, or run again with force set to true to discard them.",
+	MsgServerInfoName:          "Server: %s (%s)",
+	MsgServerInfoSupported:     "Supported methods (%d):",
+	MsgServerInfoUnsupported:   "Unsupported methods (%d):",
+	MsgServerInfoUnavailable:   "Tools that will fail, with the methods they need that are not supported:",
+	MsgServerInfoCapabilities:  "Capabilities returned from initialize:",
+	MsgHealthHealthy:           "%s: healthy",
+	MsgHealthDown:              "%s: not running. It may be restarting after a crash, check again shortly.",
+	MsgHealthInitializing:      "%s: running, but initialization has not completed",
+	MsgHealthDetails:           "  Process running: %t\n  Initialized: %t\n  Open documents: %d\n  Pending requests: %d",
+	MsgHealthLastSuccess:       "  Last successful request: %s ago",
+	MsgHealthNoSuccess:         "  Last successful request: none yet",
+	MsgServerLogsHeader:        "Log messages from %s at %s severity or above (last %d of %d):",
+	MsgServerLogsEmpty:         "No log messages from %s at %s severity or above.",
+}
+
+// messages holds the active message table. It is only modified at startup by
+// SetMessages, before any tool is called.
+var messages = defaultMessages
+
+// SetMessages overrides the text of the given messages, keyed by message ID.
+// Messages that are not overridden keep their English default.
+func SetMessages(overrides map[string]string) error {
+	var unknown []string
+	for id := range overrides {
+		if _, ok := defaultMessages[MessageID(id)]; !ok {
+			unknown = append(unknown, id)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown message IDs: %s", strings.Join(unknown, ", "))
+	}
+
+	merged := make(map[MessageID]string, len(defaultMessages))
+	for id, text := range defaultMessages {
+		merged[id] = text
+	}
+	for id, text := range overrides {
+		merged[MessageID(id)] = text
+	}
+	messages = merged
+
+	return nil
+}
+
+// msg formats the active text of a message with the given arguments
+func msg(id MessageID, args ...any) string {
+	text, ok := messages[id]
+	if !ok {
+		text = string(id)
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
@@ -0,0 +1,38 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetMessages(t *testing.T) {
+	t.Cleanup(func() { messages = defaultMessages })
+
+	t.Run("defaults", func(t *testing.T) {
+		require.NoError(t, SetMessages(nil))
+		assert.Equal(t, "References in File: 3", msg(MsgReferencesInFile, 3))
+	})
+
+	t.Run("override", func(t *testing.T) {
+		require.NoError(t, SetMessages(map[string]string{
+			"referencesInFile": "Referencias en el archivo: %d",
+		}))
+		assert.Equal(t, "Referencias en el archivo: 3", msg(MsgReferencesInFile, 3))
+		// Messages that are not overridden keep their default text
+		assert.Equal(t, "foo not found", msg(MsgSymbolNotFound, "foo"))
+	})
+
+	t.Run("reordered arguments", func(t *testing.T) {
+		require.NoError(t, SetMessages(map[string]string{
+			"noDefinitionAtPosition": "%[2]d:%[3]d in %[1]s has no definition",
+		}))
+		assert.Equal(t, "4:2 in main.go has no definition", msg(MsgNoDefinitionAtPosition, "main.go", 4, 2))
+	})
+
+	t.Run("unknown ID", func(t *testing.T) {
+		err := SetMessages(map[string]string{"notAMessage": "x"})
+		assert.ErrorContains(t, err, "notAMessage")
+	})
+}
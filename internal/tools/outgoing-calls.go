@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// maxCallTreeNodes caps the number of calls listed by FindOutgoingCalls
+const maxCallTreeNodes = 200
+
+// FindOutgoingCalls lists the functions called by the function at the given
+// position, recursing into each callee up to depth levels. Functions already
+// on the current branch are not expanded again, so recursion terminates.
+// Line and column are 1-indexed (will be converted to 0-indexed for LSP protocol).
+func FindOutgoingCalls(ctx context.Context, client *lsp.Client, filePath string, line, column, depth int) (string, error) {
+	// Open the document if not already open
+	uri, err := openDocument(ctx, client, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	// Convert 1-indexed line/column to 0-indexed for LSP protocol
+	position := protocol.Position{
+		Line:      uint32(line - 1),
+		Character: uint32(column - 1),
+	}
+
+	items, err := prepareCallHierarchy(ctx, client, uri, position)
+	if err != nil {
+		return "", fmt.Errorf("failed to prepare call hierarchy: %v", err)
+	}
+	if len(items) == 0 {
+		return msg(MsgNoCallHierarchy, fmt.Sprintf("%s:%d:%d", filePath, line, column)), nil
+	}
+
+	tree := &callTree{
+		ctx:      ctx,
+		client:   client,
+		outgoing: make(map[string][]protocol.CallHierarchyOutgoingCall),
+	}
+
+	var result strings.Builder
+	for i, item := range items {
+		if i > 0 {
+			result.WriteString("\n")
+		}
+		result.WriteString(msg(MsgOutgoingCallsHeader, item.Name, formatCallHierarchyLocation(item), depth) + "\n")
+
+		before := tree.nodes
+		if err := tree.write(&result, item, []string{callHierarchyItemKey(item)}, 1, depth); err != nil {
+			return "", err
+		}
+		if tree.nodes == before {
+			result.WriteString(msg(MsgNoOutgoingCalls, item.Name) + "\n")
+		}
+	}
+	if tree.truncated {
+		result.WriteString("\n" + msg(MsgCallTreeTruncated, maxCallTreeNodes) + "\n")
+	}
+
+	return result.String(), nil
+}
+
+// callTree renders the outgoing calls of functions as an indented tree
+type callTree struct {
+	ctx    context.Context
+	client *lsp.Client
+	// outgoing caches the outgoing calls of each item, keyed by callHierarchyItemKey
+	outgoing  map[string][]protocol.CallHierarchyOutgoingCall
+	nodes     int
+	truncated bool
+}
+
+// write renders the callees of item at the given level, recursing until maxDepth.
+// branch holds the keys of the items on the path from the root to item.
+func (t *callTree) write(result *strings.Builder, item protocol.CallHierarchyItem, branch []string, level, maxDepth int) error {
+	key := callHierarchyItemKey(item)
+	calls, ok := t.outgoing[key]
+	if !ok {
+		var err error
+		calls, err = t.client.OutgoingCalls(t.ctx, protocol.CallHierarchyOutgoingCallsParams{
+			Item: item,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get outgoing calls for %s: %v", item.Name, err)
+		}
+		t.outgoing[key] = calls
+	}
+
+	indent := strings.Repeat("  ", level-1)
+	for _, call := range calls {
+		if t.nodes >= maxCallTreeNodes {
+			t.truncated = true
+			return nil
+		}
+		t.nodes++
+
+		result.WriteString(fmt.Sprintf("%s- %s (%s)", indent, call.To.Name, formatCallHierarchyLocation(call.To)))
+		if len(call.FromRanges) > 0 {
+			result.WriteString(msg(MsgCallPathCalledAt, call.FromRanges[0].Start.Line+1, call.FromRanges[0].Start.Character+1))
+		}
+
+		calleeKey := callHierarchyItemKey(call.To)
+		recursive := false
+		for _, k := range branch {
+			if k == calleeKey {
+				recursive = true
+				break
+			}
+		}
+		if recursive {
+			result.WriteString(" " + msg(MsgRecursiveCall) + "\n")
+			continue
+		}
+		result.WriteString("\n")
+
+		if level < maxDepth {
+			if err := t.write(result, call.To, append(branch, calleeKey), level+1, maxDepth); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// formatCallHierarchyLocation renders the location of a call hierarchy item
+func formatCallHierarchyLocation(item protocol.CallHierarchyItem) string {
+	return fmt.Sprintf("%s:L%d:C%d",
+		strings.TrimPrefix(string(item.URI), "file://"),
+		item.SelectionRange.Start.Line+1,
+		item.SelectionRange.Start.Character+1)
+}
@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type partialResultsKey struct{}
+
+// partialResults tracks progress through a result that is built from
+// independent sections, such as the per-file blocks of a references result.
+// Once the soft deadline passes, the remaining sections are dropped and a
+// continuation token is reported so the caller can fetch them later.
+type partialResults struct {
+	// query identifies the call, so that its tokens are not accepted by
+	// another
+	query     string
+	deadline  time.Time
+	skip      int
+	index     int
+	truncated bool
+}
+
+// WithPartialResults returns a context that makes supporting tools stop
+// formatting output once timeout has elapsed, returning what they have so far
+// instead of failing. A zero timeout disables the deadline. continuation is a
+// token from a previous truncated result, or empty to start from the beginning.
+// query holds the arguments of the call other than the token: tokens are bound
+// to them and rejected by calls with other arguments.
+func WithPartialResults(ctx context.Context, timeout time.Duration, continuation string, query map[string]any) (context.Context, error) {
+	p := &partialResults{query: queryHash(query)}
+	if timeout > 0 {
+		p.deadline = time.Now().Add(timeout)
+	}
+	if continuation != "" {
+		skip, err := decodeContinuation(continuation, p.query)
+		if err != nil {
+			return nil, err
+		}
+		p.skip = skip
+	}
+	return context.WithValue(ctx, partialResultsKey{}, p), nil
+}
+
+func partialResultsFrom(ctx context.Context) *partialResults {
+	p, _ := ctx.Value(partialResultsKey{}).(*partialResults)
+	return p
+}
+
+// include reports whether the next section should be added to the result. At
+// least one section is always included so that every call makes progress.
+func (p *partialResults) include() bool {
+	if p == nil {
+		return true
+	}
+	if p.truncated {
+		return false
+	}
+	if p.index < p.skip {
+		p.index++
+		return false
+	}
+	if !p.deadline.IsZero() && p.index > p.skip && time.Now().After(p.deadline) {
+		p.truncated = true
+		return false
+	}
+	p.index++
+	return true
+}
+
+// resumed reports whether this result continues a previous truncated result
+func (p *partialResults) resumed() bool {
+	return p != nil && p.skip > 0
+}
+
+// continuation returns the token to fetch the rest of a truncated result, or
+// an empty string if the result is complete
+func (p *partialResults) continuation() string {
+	if p == nil || !p.truncated {
+		return ""
+	}
+	return encodeContinuation(p.index, p.query)
+}
+
+// formatTruncated returns the truncation notice for a result, or an empty
+// string if the result is complete
+func formatTruncated(continuation string) string {
+	if continuation == "" {
+		return ""
+	}
+	return "\n" + msg(MsgResultsTruncated, continuation) + "\n"
+}
+
+// queryHash returns a short hash of the arguments of a call. JSON objects
+// are marshaled with sorted keys, so equal arguments have equal hashes.
+func queryHash(query map[string]any) string {
+	data, err := json.Marshal(query)
+	if err != nil {
+		data = fmt.Appendf(nil, "%v", query)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+func encodeContinuation(index int, query string) string {
+	return base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, "sections:%d:%s", index, query))
+}
+
+func decodeContinuation(token, query string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, fmt.Errorf("invalid continuation token: %s", token)
+	}
+	var index int
+	var tokenQuery string
+	if _, err := fmt.Sscanf(string(data), "sections:%d:%s", &index, &tokenQuery); err != nil || index < 0 {
+		return 0, fmt.Errorf("invalid continuation token: %s", token)
+	}
+	if tokenQuery != query {
+		return 0, fmt.Errorf("continuation token %s is from a call with other arguments, repeat that call's arguments or leave out the token", token)
+	}
+	return index, nil
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartialResultsDisabled(t *testing.T) {
+	var p *partialResults
+	assert.True(t, p.include())
+	assert.False(t, p.resumed())
+	assert.Equal(t, "", p.continuation())
+}
+
+func TestPartialResultsDeadline(t *testing.T) {
+	ctx, err := WithPartialResults(context.Background(), time.Nanosecond, "", nil)
+	assert.NoError(t, err)
+	p := partialResultsFrom(ctx)
+	time.Sleep(time.Millisecond)
+
+	// The first section is always included, later ones are dropped
+	assert.True(t, p.include())
+	assert.False(t, p.include())
+	assert.False(t, p.include())
+	assert.Equal(t, encodeContinuation(1, queryHash(nil)), p.continuation())
+	assert.Contains(t, formatTruncated(p.continuation()), encodeContinuation(1, queryHash(nil)))
+}
+
+func TestPartialResultsContinuation(t *testing.T) {
+	query := map[string]any{"symbolName": "Foo"}
+	ctx, err := WithPartialResults(context.Background(), 0, encodeContinuation(2, queryHash(query)), query)
+	assert.NoError(t, err)
+	p := partialResultsFrom(ctx)
+
+	assert.True(t, p.resumed())
+	assert.False(t, p.include())
+	assert.False(t, p.include())
+	assert.True(t, p.include())
+	assert.True(t, p.include())
+	assert.Equal(t, "", p.continuation())
+}
+
+func TestPartialResultsInvalidContinuation(t *testing.T) {
+	_, err := WithPartialResults(context.Background(), 0, "not a token", nil)
+	assert.Error(t, err)
+
+	_, err = WithPartialResults(context.Background(), 0, encodeContinuation(-1, queryHash(nil)), nil)
+	assert.Error(t, err)
+}
+
+func TestPartialResultsContinuationOtherQuery(t *testing.T) {
+	token := encodeContinuation(2, queryHash(map[string]any{"symbolName": "Foo"}))
+
+	_, err := WithPartialResults(context.Background(), 0, token, map[string]any{"symbolName": "Bar"})
+	assert.ErrorContains(t, err, "from a call with other arguments")
+
+	// Tokens without a query, from before they were bound, are rejected
+	_, err = WithPartialResults(context.Background(), 0, "c2VjdGlvbnM6Mg", map[string]any{"symbolName": "Foo"})
+	assert.Error(t, err)
+
+	_, err = WithPartialResults(context.Background(), 0, token, map[string]any{"symbolName": "Foo"})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,241 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+)
+
+const (
+	// maxPrefetchSymbols is the number of identifiers in a result whose
+	// definitions are prefetched
+	maxPrefetchSymbols = 10
+	// maxCachedDefinitions is the number of symbol names whose definitions
+	// are cached, the oldest being forgotten first
+	maxCachedDefinitions = 500
+	// definitionCacheTTL bounds how long cached definitions are used, since
+	// a symbol may be declared in a file that was not part of the result
+	definitionCacheTTL = 5 * time.Minute
+)
+
+var (
+	// snippetLine matches a line of a snippet with line numbers, capturing
+	// the code
+	snippetLine = regexp.MustCompile(`(?m)^\s*\d+\|(.*)$`)
+	// identifier matches identifiers, capturing what precedes and follows
+	// them to tell calls, selectors and type names apart
+	identifier = regexp.MustCompile(`(\.?)\b([A-Za-z_][A-Za-z0-9_]*)\b(\s*\(?)`)
+)
+
+// commonKeywords are keywords and builtins of common languages, which are
+// never prefetched
+var commonKeywords = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true, "continue": true,
+	"def": true, "default": true, "defer": true, "delete": true, "else": true, "elif": true,
+	"enum": true, "err": true, "error": true, "except": true, "export": true, "extends": true,
+	"false": true, "final": true, "finally": true, "for": true, "from": true, "func": true,
+	"function": true, "go": true, "if": true, "import": true, "in": true, "interface": true,
+	"let": true, "len": true, "make": true, "map": true, "new": true, "nil": true, "None": true,
+	"null": true, "package": true, "pass": true, "print": true, "private": true, "public": true,
+	"range": true, "return": true, "self": true, "static": true, "string": true, "struct": true,
+	"super": true, "switch": true, "this": true, "throw": true, "True": true, "False": true,
+	"true": true, "try": true, "type": true, "typeof": true, "undefined": true, "var": true,
+	"void": true, "while": true, "with": true, "yield": true, "append": true, "int": true,
+	"bool": true, "float64": true, "byte": true, "any": true, "async": true, "await": true,
+}
+
+// definitionCache holds the definitions found for symbol names, so that the
+// definitions of identifiers in returned snippets can be fetched in the
+// background and later requests for them answered without a round trip to
+// the language server
+type definitionCache struct {
+	ctx     context.Context
+	mu      sync.Mutex
+	entries map[string]cachedDefinitions
+	// Keys in the order they were added
+	order []string
+	// Keys being prefetched
+	pending map[string]bool
+}
+
+// cachedDefinitions are the definitions of a symbol name with the time the
+// files they are in were last modified when they were found
+type cachedDefinitions struct {
+	definitions []symbolDefinition
+	found       time.Time
+	modTimes    map[string]time.Time
+}
+
+// definitionsCache is the cache used by ReadDefinition. It is nil unless
+// prefetching is enabled, and is only set at startup.
+var definitionsCache *definitionCache
+
+// EnableDefinitionPrefetch makes definitions and references fetch the
+// definitions of the identifiers in their snippets in the background, for
+// the lifetime of ctx, so that the likely next definition requests are
+// answered from a cache
+func EnableDefinitionPrefetch(ctx context.Context) {
+	definitionsCache = &definitionCache{
+		ctx:     ctx,
+		entries: make(map[string]cachedDefinitions),
+		pending: make(map[string]bool),
+	}
+}
+
+func definitionCacheKey(client *lsp.Client, symbolName string) string {
+	return fmt.Sprintf("%p:%s", client, symbolName)
+}
+
+// lookupDefinitions finds the definitions of a symbol by name, from the
+// cache if they were found recently and their files have not changed since
+func lookupDefinitions(ctx context.Context, client *lsp.Client, symbolName string) ([]symbolDefinition, error) {
+	if definitionsCache == nil {
+		return findDefinitions(ctx, client, symbolName)
+	}
+	if found, ok := definitionsCache.get(definitionCacheKey(client, symbolName)); ok {
+		toolsLogger.Debug("Definitions of %s served from cache", symbolName)
+		return found, nil
+	}
+	found, err := findDefinitions(ctx, client, symbolName)
+	if err != nil {
+		return nil, err
+	}
+	definitionsCache.put(definitionCacheKey(client, symbolName), found)
+	return found, nil
+}
+
+// get returns the cached definitions for a key if they are still valid
+func (c *definitionCache) get(key string) ([]symbolDefinition, bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok || time.Since(entry.found) > definitionCacheTTL {
+		return nil, false
+	}
+	for path, modTime := range entry.modTimes {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Equal(modTime) {
+			return nil, false
+		}
+	}
+	return entry.definitions, true
+}
+
+// put caches the definitions found for a key with the modification times of
+// their files
+func (c *definitionCache) put(key string, found []symbolDefinition) {
+	entry := cachedDefinitions{
+		definitions: found,
+		found:       time.Now(),
+		modTimes:    make(map[string]time.Time),
+	}
+	for _, def := range found {
+		path := lsp.DocumentPath(def.Location.URI)
+		if info, err := os.Stat(path); err == nil {
+			entry.modTimes[path] = info.ModTime()
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.entries[key]; !exists {
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = entry
+	if len(c.order) > maxCachedDefinitions {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// prefetchDefinitions fetches, in the background, the definitions of the
+// identifiers in returned code that are most likely to be asked about next,
+// other than exclude. It does nothing unless prefetching is enabled.
+func prefetchDefinitions(client *lsp.Client, code string, exclude string) {
+	if definitionsCache == nil {
+		return
+	}
+
+	var names []string
+	for _, name := range prefetchCandidates(code) {
+		if name == exclude {
+			continue
+		}
+		key := definitionCacheKey(client, name)
+		if _, ok := definitionsCache.get(key); ok {
+			continue
+		}
+		definitionsCache.mu.Lock()
+		pending := definitionsCache.pending[key]
+		definitionsCache.pending[key] = true
+		definitionsCache.mu.Unlock()
+		if !pending {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+
+	go func() {
+		for _, name := range names {
+			key := definitionCacheKey(client, name)
+			if definitionsCache.ctx.Err() == nil {
+				if found, err := findDefinitions(definitionsCache.ctx, client, name); err == nil {
+					definitionsCache.put(key, found)
+				} else {
+					toolsLogger.Debug("Failed to prefetch definitions of %s: %v", name, err)
+				}
+			}
+			definitionsCache.mu.Lock()
+			delete(definitionsCache.pending, key)
+			definitionsCache.mu.Unlock()
+		}
+	}()
+}
+
+// snippetCode returns the code in the snippets with line numbers of a result
+func snippetCode(result string) string {
+	var code []string
+	for _, match := range snippetLine.FindAllStringSubmatch(result, -1) {
+		code = append(code, match[1])
+	}
+	return strings.Join(code, "\n")
+}
+
+// prefetchCandidates picks the identifiers in code that are most likely to
+// be looked up next: called functions and methods, then type names, then
+// selected fields, each in order of appearance
+func prefetchCandidates(code string) []string {
+	var calls, types, selectors []string
+	seen := make(map[string]bool)
+	for _, match := range identifier.FindAllStringSubmatch(code, -1) {
+		name := match[2]
+		if len(name) < 3 || commonKeywords[name] || seen[name] {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(match[3], "("):
+			calls = append(calls, name)
+		case name[0] >= 'A' && name[0] <= 'Z':
+			types = append(types, name)
+		case match[1] == ".":
+			selectors = append(selectors, name)
+		default:
+			continue
+		}
+		seen[name] = true
+	}
+
+	candidates := append(append(calls, types...), selectors...)
+	if len(candidates) > maxPrefetchSymbols {
+		candidates = candidates[:maxPrefetchSymbols]
+	}
+	return candidates
+}
@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefetchCandidates(t *testing.T) {
+	result := `---
+
+File: /ws/main.go
+Definition at L10:C1 - L14:C2
+
+10|func handle(req *Request) error {
+11|	user, err := store.LoadUser(req.ID)
+12|	if err != nil {
+13|		return fmt.Errorf("load: %v", err)
+14|	}
+`
+
+	assert.Equal(t, []string{"handle", "LoadUser", "Errorf", "Request"}, prefetchCandidates(snippetCode(result)))
+	assert.Empty(t, prefetchCandidates(snippetCode("No references found for symbol: Foo")))
+}
+
+func TestDefinitionCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "user.go")
+	if err := os.WriteFile(path, []byte("type User struct{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	EnableDefinitionPrefetch(context.Background())
+	defer func() { definitionsCache = nil }()
+
+	found := []symbolDefinition{{
+		Name:     "User",
+		Location: protocol.Location{URI: protocol.DocumentUri("file://" + path)},
+		Code:     "type User struct{}",
+	}}
+	definitionsCache.put("User", found)
+
+	cached, ok := definitionsCache.get("User")
+	assert.True(t, ok)
+	assert.Equal(t, found, cached)
+
+	// Editing the file invalidates the entry
+	later := time.Now().Add(time.Second)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	_, ok = definitionsCache.get("User")
+	assert.False(t, ok)
+
+	_, ok = definitionsCache.get("Unknown")
+	assert.False(t, ok)
+}
@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// renameTarget is the symbol a rename at a position would change
+type renameTarget struct {
+	Range protocol.Range
+	// Placeholder is the text being renamed, usually the current name
+	Placeholder string
+}
+
+// PrepareRename reports whether the symbol at a position can be renamed, and
+// if so the exact range and current text of the identifier that would change
+func PrepareRename(ctx context.Context, client *lsp.Client, filePath string, line, column int) (string, error) {
+	uri, err := openDocument(ctx, client, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	position := protocol.Position{
+		Line:      uint32(line - 1),
+		Character: uint32(column - 1),
+	}
+
+	target, reason, err := prepareRename(ctx, client, uri, position)
+	if err != nil {
+		return "", err
+	}
+	if target == nil {
+		return msg(MsgNotRenamable, filePath, line, column, reason), nil
+	}
+	return msg(MsgRenamable, target.Placeholder, formatRange(target.Range)), nil
+}
+
+// prepareRename asks the server whether the symbol at a position can be
+// renamed. If it cannot, the target is nil and the reason explains why.
+func prepareRename(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, position protocol.Position) (*renameTarget, string, error) {
+	result, err := client.PrepareRename(ctx, protocol.PrepareRenameParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{
+				URI: uri,
+			},
+			Position: position,
+		},
+	})
+	if err != nil {
+		// Servers reject positions they cannot rename with an error
+		// explaining why
+		return nil, err.Error(), nil
+	}
+
+	text, err := documentText(ctx, client, uri)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not read document: %v", err)
+	}
+
+	target := renameTargetFromResult(result, strings.Split(text, "\n"), position)
+	if target == nil {
+		return nil, msg(MsgNoRenamableSymbol), nil
+	}
+	return target, "", nil
+}
+
+// renameTargetFromResult interprets the result of a prepareRename request. A
+// null result means the position cannot be renamed, and the default behavior
+// result means the identifier at the position would be renamed.
+func renameTargetFromResult(result protocol.PrepareRenameResult, lines []string, position protocol.Position) *renameTarget {
+	switch v := result.Value.(type) {
+	case protocol.Range:
+		return &renameTarget{Range: v, Placeholder: rangeText(lines, v)}
+	case protocol.PrepareRenamePlaceholder:
+		return &renameTarget{Range: v.Range, Placeholder: v.Placeholder}
+	case protocol.PrepareRenameDefaultBehavior:
+		if !v.DefaultBehavior {
+			return nil
+		}
+		r, ok := identifierRange(lines, position)
+		if !ok {
+			return nil
+		}
+		return &renameTarget{Range: r, Placeholder: rangeText(lines, r)}
+	default:
+		return nil
+	}
+}
+
+// identifierRange returns the range of the identifier touching a position
+func identifierRange(lines []string, position protocol.Position) (protocol.Range, bool) {
+	if int(position.Line) >= len(lines) {
+		return protocol.Range{}, false
+	}
+	line := lines[position.Line]
+	character := min(int(position.Character), len(line))
+
+	isIdentifier := func(r rune) bool {
+		return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+
+	start, end := character, character
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(line[:start])
+		if !isIdentifier(r) {
+			break
+		}
+		start -= size
+	}
+	for end < len(line) {
+		r, size := utf8.DecodeRuneInString(line[end:])
+		if !isIdentifier(r) {
+			break
+		}
+		end += size
+	}
+	if start == end {
+		return protocol.Range{}, false
+	}
+
+	return protocol.Range{
+		Start: protocol.Position{Line: position.Line, Character: uint32(start)},
+		End:   protocol.Position{Line: position.Line, Character: uint32(end)},
+	}, true
+}
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenameTargetFromResult(t *testing.T) {
+	lines := []string{"package main", "", "func oldName() {}"}
+	position := protocol.Position{Line: 2, Character: 7}
+	nameRange := protocol.Range{
+		Start: protocol.Position{Line: 2, Character: 5},
+		End:   protocol.Position{Line: 2, Character: 12},
+	}
+
+	target := renameTargetFromResult(protocol.PrepareRenameResult{Value: nameRange}, lines, position)
+	assert.Equal(t, &renameTarget{Range: nameRange, Placeholder: "oldName"}, target)
+
+	target = renameTargetFromResult(protocol.PrepareRenameResult{Value: protocol.PrepareRenamePlaceholder{Range: nameRange, Placeholder: "main.oldName"}}, lines, position)
+	assert.Equal(t, &renameTarget{Range: nameRange, Placeholder: "main.oldName"}, target)
+
+	target = renameTargetFromResult(protocol.PrepareRenameResult{Value: protocol.PrepareRenameDefaultBehavior{DefaultBehavior: true}}, lines, position)
+	assert.Equal(t, &renameTarget{Range: nameRange, Placeholder: "oldName"}, target)
+
+	assert.Nil(t, renameTargetFromResult(protocol.PrepareRenameResult{}, lines, position))
+}
+
+func TestIdentifierRange(t *testing.T) {
+	lines := []string{"x := größe + y_2"}
+
+	r, ok := identifierRange(lines, protocol.Position{Line: 0, Character: 6})
+	assert.True(t, ok)
+	assert.Equal(t, "größe", rangeText(lines, r))
+
+	// The end of an identifier still touches it
+	r, ok = identifierRange(lines, protocol.Position{Line: 0, Character: 18})
+	assert.True(t, ok)
+	assert.Equal(t, "y_2", rangeText(lines, r))
+
+	_, ok = identifierRange(lines, protocol.Position{Line: 0, Character: 2})
+	assert.False(t, ok)
+	_, ok = identifierRange(lines, protocol.Position{Line: 1, Character: 0})
+	assert.False(t, ok)
+}
@@ -3,9 +3,7 @@ package tools
 import (
 	"context"
 	"fmt"
-	"os"
 	"sort"
-	"strconv"
 	"strings"
 
 	"github.com/koonwen/mcp-language-server/internal/lsp"
@@ -16,22 +14,15 @@ import (
 // This is the position-based approach that directly uses the LSP textDocument/references request.
 // Line and column are 1-indexed (will be converted to 0-indexed for LSP protocol).
 func FindReferencesAtPosition(ctx context.Context, client *lsp.Client, filePath string, line, column int, includeDeclaration bool) (string, error) {
-	// Get context lines from environment variable
-	contextLines := 5
-	if envLines := os.Getenv("LSP_CONTEXT_LINES"); envLines != "" {
-		if val, err := strconv.Atoi(envLines); err == nil && val >= 0 {
-			contextLines = val
-		}
-	}
+	contextLines := ContextLines()
 
-	// Open the file if not already open
-	err := client.OpenFile(ctx, filePath)
+	// Open the document if not already open
+	uri, err := openDocument(ctx, client, filePath)
 	if err != nil {
-		return "", fmt.Errorf("could not open file: %v", err)
+		return "", err
 	}
 
 	// Convert 1-indexed line/column to 0-indexed for LSP protocol
-	uri := protocol.DocumentUri("file://" + filePath)
 	position := protocol.Position{
 		Line:      uint32(line - 1),
 		Character: uint32(column - 1),
@@ -55,116 +46,102 @@ func FindReferencesAtPosition(ctx context.Context, client *lsp.Client, filePath
 		return "", fmt.Errorf("failed to get references: %v", err)
 	}
 
-	if len(refs) == 0 {
-		return fmt.Sprintf("No references found at %s:%d:%d", filePath, line, column), nil
-	}
+	files := append([]ReferenceFile{}, collectReferenceFiles(ctx, client, refs, contextLines)...)
 
-	// Group references by file
-	refsByFile := make(map[protocol.DocumentUri][]protocol.Location)
-	for _, ref := range refs {
-		refsByFile[ref.URI] = append(refsByFile[ref.URI], ref)
+	partial := partialResultsFrom(ctx)
+	output := ReferencesOutput{
+		Target:       fmt.Sprintf("%s:%d:%d", filePath, line, column),
+		Files:        files,
+		Continuation: partial.continuation(),
 	}
-
-	// Get sorted list of URIs
-	uris := make([]string, 0, len(refsByFile))
-	for uri := range refsByFile {
-		uris = append(uris, string(uri))
+	if text, ok, err := renderTemplate(ctx, "references_at_position", output); ok {
+		return text, err
 	}
-	sort.Strings(uris)
 
-	var allReferences []string
+	if len(refs) == 0 {
+		return msg(MsgNoReferencesAtPosition, filePath, line, column), nil
+	}
+	if len(files) == 0 && partial.resumed() {
+		return msg(MsgNoMoreResults), nil
+	}
 
-	// Process each file's references in sorted order
-	for _, uriStr := range uris {
-		uri := protocol.DocumentUri(uriStr)
-		fileRefs := refsByFile[uri]
-		filePathFromUri := strings.TrimPrefix(uriStr, "file://")
+	return formatReferencesOutput(output), nil
+}
 
-		// Format file header
-		fileInfo := fmt.Sprintf("---\n\n%s\nReferences in File: %d\n",
-			filePathFromUri,
-			len(fileRefs),
-		)
+func FindReferences(ctx context.Context, client *lsp.Client, symbolName string) (string, error) {
+	contextLines := ContextLines()
 
-		// Format locations with context
-		fileContent, err := os.ReadFile(filePathFromUri)
-		if err != nil {
-			// Log error but continue with other files
-			allReferences = append(allReferences, fileInfo+"\nError reading file: "+err.Error())
-			continue
-		}
+	symbolRefs, err := symbolReferences(ctx, client, symbolName)
+	if err != nil {
+		return "", err
+	}
 
-		lines := strings.Split(string(fileContent), "\n")
+	files := []ReferenceFile{}
+	for _, refs := range symbolRefs {
+		files = append(files, collectReferenceFiles(ctx, client, refs, contextLines)...)
+	}
 
-		// Track reference locations for header display
-		var locStrings []string
-		for _, ref := range fileRefs {
-			locStr := fmt.Sprintf("L%d:C%d",
-				ref.Range.Start.Line+1,
-				ref.Range.Start.Character+1)
-			locStrings = append(locStrings, locStr)
-		}
+	partial := partialResultsFrom(ctx)
+	output := ReferencesOutput{
+		Target:       symbolName,
+		Files:        files,
+		Continuation: partial.continuation(),
+	}
+	if text, ok, err := renderTemplate(ctx, "references", output); ok {
+		return text, err
+	}
 
-		// Collect lines to display using the utility function
-		linesToShow, err := GetLineRangesToDisplay(ctx, client, fileRefs, len(lines), contextLines)
-		if err != nil {
-			// Log error but continue with other files
-			continue
+	if len(files) == 0 {
+		if partial.resumed() {
+			return msg(MsgNoMoreResults), nil
 		}
+		return msg(MsgNoReferencesForSymbol, symbolName), nil
+	}
 
-		// Convert to line ranges using the utility function
-		lineRanges := ConvertLinesToRanges(linesToShow, len(lines))
+	result := formatReferencesOutput(output)
+	prefetchDefinitions(client, snippetCode(result), symbolName)
+	return result, nil
+}
 
-		// Format with locations in header
-		formattedOutput := fileInfo
-		if len(locStrings) > 0 {
-			formattedOutput += "At: " + strings.Join(locStrings, ", ") + "\n"
-		}
+// CountReferences reports the number of references to a symbol in each file.
+// It skips reading files and formatting snippets, so it is much faster than
+// FindReferences for symbols with many references.
+func CountReferences(ctx context.Context, client *lsp.Client, symbolName string) (string, error) {
+	symbolRefs, err := symbolReferences(ctx, client, symbolName)
+	if err != nil {
+		return "", err
+	}
 
-		// Format the content with ranges
-		formattedOutput += "\n" + FormatLinesWithRanges(lines, lineRanges)
-		allReferences = append(allReferences, formattedOutput)
+	var refs []protocol.Location
+	for _, r := range symbolRefs {
+		refs = append(refs, r...)
+	}
+	if len(refs) == 0 {
+		return msg(MsgNoReferencesForSymbol, symbolName), nil
 	}
 
-	return strings.Join(allReferences, "\n"), nil
+	return formatReferenceCounts(symbolName, refs), nil
 }
 
-func FindReferences(ctx context.Context, client *lsp.Client, symbolName string) (string, error) {
-	// Get context lines from environment variable
-	contextLines := 5
-	if envLines := os.Getenv("LSP_CONTEXT_LINES"); envLines != "" {
-		if val, err := strconv.Atoi(envLines); err == nil && val >= 0 {
-			contextLines = val
-		}
-	}
-
+// symbolReferences returns the references to each workspace symbol matching
+// symbolName, excluding declarations
+func symbolReferences(ctx context.Context, client *lsp.Client, symbolName string) ([][]protocol.Location, error) {
 	// First get the symbol location like ReadDefinition does
 	symbolResult, err := client.Symbol(ctx, protocol.WorkspaceSymbolParams{
 		Query: symbolName,
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch symbol: %v", err)
+		return nil, fmt.Errorf("failed to fetch symbol: %v", err)
 	}
 
 	results, err := symbolResult.Results()
 	if err != nil {
-		return "", fmt.Errorf("failed to parse results: %v", err)
+		return nil, fmt.Errorf("failed to parse results: %v", err)
 	}
 
-	var allReferences []string
+	var symbolRefs [][]protocol.Location
 	for _, symbol := range results {
-		// Handle different matching strategies based on the search term
-		if strings.Contains(symbolName, ".") {
-			// For qualified names like "Type.Method", check for various matches
-			parts := strings.Split(symbolName, ".")
-			methodName := parts[len(parts)-1]
-
-			// Try matching the unqualified method name for languages that don't use qualified names in symbols
-			if symbol.GetName() != symbolName && symbol.GetName() != methodName {
-				continue
-			}
-		} else if symbol.GetName() != symbolName {
-			// For unqualified names, exact match only
+		if !referenceSymbolMatches(symbolName, symbol.GetName()) {
 			continue
 		}
 
@@ -184,85 +161,204 @@ func FindReferences(ctx context.Context, client *lsp.Client, symbolName string)
 			},
 		}
 		// File is likely to be opened already, but may not be.
-		err := client.OpenFile(ctx, loc.URI.Path())
+		_, err := openDocument(ctx, client, lsp.DocumentPath(loc.URI))
 		if err != nil {
 			toolsLogger.Error("Error opening file: %v", err)
 			continue
 		}
 		refs, err := client.References(ctx, refsParams)
 		if err != nil {
-			return "", fmt.Errorf("failed to get references: %v", err)
+			return nil, fmt.Errorf("failed to get references: %v", err)
 		}
 
-		// Group references by file
-		refsByFile := make(map[protocol.DocumentUri][]protocol.Location)
-		for _, ref := range refs {
-			refsByFile[ref.URI] = append(refsByFile[ref.URI], ref)
+		symbolRefs = append(symbolRefs, refs)
+	}
+
+	return symbolRefs, nil
+}
+
+// formatReferenceCounts renders the total number of references and the number
+// in each file, in sorted file order
+func formatReferenceCounts(target string, refs []protocol.Location) string {
+	counts := make(map[string]int)
+	for _, ref := range refs {
+		counts[strings.TrimPrefix(string(ref.URI), "file://")]++
+	}
+
+	files := make([]string, 0, len(counts))
+	for file := range counts {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var result strings.Builder
+	result.WriteString(msg(MsgReferenceCountSummary, target, len(refs), len(files)) + "\n\n")
+	for _, file := range files {
+		result.WriteString(fmt.Sprintf("%s: %d\n", file, counts[file]))
+	}
+	return result.String()
+}
+
+// referenceSymbolMatches reports whether a workspace symbol name matches the
+// name that references were requested for
+func referenceSymbolMatches(symbolName, name string) bool {
+	// Handle different matching strategies based on the search term
+	if strings.Contains(symbolName, ".") {
+		// For qualified names like "Type.Method", check for various matches
+		parts := strings.Split(symbolName, ".")
+		methodName := parts[len(parts)-1]
+
+		// Try matching the unqualified method name for languages that don't use qualified names in symbols
+		return name == symbolName || name == methodName
+	}
+
+	// For unqualified names, exact match only
+	return name == symbolName
+}
+
+// ReferenceFile is the set of references in a single file, as exposed to
+// output templates. Positions are 1-indexed.
+type ReferenceFile struct {
+	Path       string           `json:"path"`
+	Owners     []string         `json:"owners,omitempty"`
+	References []SourcePosition `json:"references"`
+	// Snippet holds the referencing lines with context and line numbers
+	Snippet string `json:"snippet,omitempty"`
+	// Error is set if the file could not be read
+	Error string `json:"error,omitempty"`
+}
+
+// SourcePosition is a 1-indexed position in a file
+type SourcePosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// ReferencesOutput is the data passed to the references output templates
+type ReferencesOutput struct {
+	// Target is the symbol name, or the position references were requested for
+	Target string          `json:"target"`
+	Files  []ReferenceFile `json:"files"`
+	// Continuation is set if the result was truncated due to timeout
+	Continuation string `json:"continuation,omitempty"`
+}
+
+// formatReferencesByFile groups references by file and renders each file's
+// references with surrounding context, in sorted file order. If the context
+// carries partial results, files are skipped or dropped accordingly.
+func formatReferencesByFile(ctx context.Context, client *lsp.Client, refs []protocol.Location, contextLines int) []string {
+	var allReferences []string
+	for _, file := range collectReferenceFiles(ctx, client, refs, contextLines) {
+		allReferences = append(allReferences, formatReferenceFile(file))
+	}
+	return allReferences
+}
+
+// formatReferencesOutput renders references in the default format
+func formatReferencesOutput(output ReferencesOutput) string {
+	var allReferences []string
+	for _, file := range output.Files {
+		allReferences = append(allReferences, formatReferenceFile(file))
+	}
+	return strings.Join(allReferences, "\n") + formatTruncated(output.Continuation)
+}
+
+// formatReferenceFile renders the references in a file in the default format
+func formatReferenceFile(file ReferenceFile) string {
+	// Format file header
+	formattedOutput := fmt.Sprintf("---\n\n%s\n%s%s\n",
+		file.Path,
+		formatOwners(file.Path)+formatGeneratedFrom(file.Path, ""),
+		msg(MsgReferencesInFile, len(file.References)),
+	)
+
+	if file.Error != "" {
+		return formattedOutput + "\n" + msg(MsgErrorReadingFile, file.Error)
+	}
+
+	// Format with locations in header
+	var locStrings []string
+	for _, ref := range file.References {
+		locStrings = append(locStrings, fmt.Sprintf("L%d:C%d", ref.Line, ref.Column))
+	}
+	if len(locStrings) > 0 {
+		formattedOutput += msg(MsgReferencesAt, strings.Join(locStrings, ", ")) + "\n"
+	}
+
+	// Format the content with ranges
+	return formattedOutput + "\n" + snippets.dedupe(file.Snippet)
+}
+
+// collectReferenceFiles groups references by file, in sorted file order, and
+// reads the surrounding context of each reference. If the context carries
+// partial results, files are skipped or dropped accordingly.
+func collectReferenceFiles(ctx context.Context, client *lsp.Client, refs []protocol.Location, contextLines int) []ReferenceFile {
+	partial := partialResultsFrom(ctx)
+
+	// Group references by file
+	refsByFile := make(map[protocol.DocumentUri][]protocol.Location)
+	for _, ref := range refs {
+		refsByFile[ref.URI] = append(refsByFile[ref.URI], ref)
+	}
+
+	// Get sorted list of URIs
+	uris := make([]string, 0, len(refsByFile))
+	for uri := range refsByFile {
+		uris = append(uris, string(uri))
+	}
+	sort.Strings(uris)
+
+	var files []ReferenceFile
+
+	// Process each file's references in sorted order
+	for _, uriStr := range uris {
+		if !partial.include() {
+			continue
 		}
 
-		// Get sorted list of URIs
-		uris := make([]string, 0, len(refsByFile))
-		for uri := range refsByFile {
-			uris = append(uris, string(uri))
+		uri := protocol.DocumentUri(uriStr)
+		fileRefs := refsByFile[uri]
+		filePath := lsp.DocumentPath(uri)
+
+		file := ReferenceFile{
+			Path:   filePath,
+			Owners: codeOwners.Owners(filePath),
 		}
-		sort.Strings(uris)
-
-		// Process each file's references in sorted order
-		for _, uriStr := range uris {
-			uri := protocol.DocumentUri(uriStr)
-			fileRefs := refsByFile[uri]
-			filePath := strings.TrimPrefix(uriStr, "file://")
-
-			// Format file header
-			fileInfo := fmt.Sprintf("---\n\n%s\nReferences in File: %d\n",
-				filePath,
-				len(fileRefs),
-			)
-
-			// Format locations with context
-			fileContent, err := os.ReadFile(filePath)
-			if err != nil {
-				// Log error but continue with other files
-				allReferences = append(allReferences, fileInfo+"\nError reading file: "+err.Error())
-				continue
-			}
-
-			lines := strings.Split(string(fileContent), "\n")
-
-			// Track reference locations for header display
-			var locStrings []string
-			for _, ref := range fileRefs {
-				locStr := fmt.Sprintf("L%d:C%d",
-					ref.Range.Start.Line+1,
-					ref.Range.Start.Character+1)
-				locStrings = append(locStrings, locStr)
-			}
-
-			// Collect lines to display using the utility function
-			linesToShow, err := GetLineRangesToDisplay(ctx, client, fileRefs, len(lines), contextLines)
-			if err != nil {
-				// Log error but continue with other files
-				continue
-			}
-
-			// Convert to line ranges using the utility function
-			lineRanges := ConvertLinesToRanges(linesToShow, len(lines))
-
-			// Format with locations in header
-			formattedOutput := fileInfo
-			if len(locStrings) > 0 {
-				formattedOutput += "At: " + strings.Join(locStrings, ", ") + "\n"
-			}
-
-			// Format the content with ranges
-			formattedOutput += "\n" + FormatLinesWithRanges(lines, lineRanges)
-			allReferences = append(allReferences, formattedOutput)
+		for _, ref := range fileRefs {
+			file.References = append(file.References, SourcePosition{
+				Line:   int(ref.Range.Start.Line) + 1,
+				Column: int(ref.Range.Start.Character) + 1,
+			})
 		}
-	}
 
-	if len(allReferences) == 0 {
-		return fmt.Sprintf("No references found for symbol: %s", symbolName), nil
+		// Format locations with context
+		fileContent, err := documentText(ctx, client, uri)
+		if err != nil {
+			// Log error but continue with other files
+			file.Error = err.Error()
+			files = append(files, file)
+			continue
+		}
+
+		lines := strings.Split(fileContent, "\n")
+
+		// Collect lines to display using the utility function
+		linesToShow, err := GetLineRangesToDisplay(ctx, client, fileRefs, len(lines), contextLines)
+		if err != nil {
+			// Log error but continue with other files
+			continue
+		}
+
+		// Convert to line ranges using the utility function
+		lineRanges := ConvertLinesToRanges(linesToShow, len(lines))
+
+		referenced := make(map[int]bool)
+		for _, ref := range file.References {
+			referenced[ref.Line] = true
+		}
+		file.Snippet = trimSnippet(filePath, FormatLinesWithRanges(lines, lineRanges), referenced)
+		files = append(files, file)
 	}
 
-	return strings.Join(allReferences, "\n"), nil
+	return files
 }
@@ -0,0 +1,28 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatReferenceCounts(t *testing.T) {
+	refs := []protocol.Location{
+		{URI: "file:///ws/b.go"},
+		{URI: "file:///ws/a.go"},
+		{URI: "file:///ws/b.go"},
+		{URI: "file:///ws/b.go"},
+	}
+
+	expected := "Foo: 4 references in 2 files\n\n/ws/a.go: 1\n/ws/b.go: 3\n"
+	assert.Equal(t, expected, formatReferenceCounts("Foo", refs))
+}
+
+func TestReferenceSymbolMatches(t *testing.T) {
+	assert.True(t, referenceSymbolMatches("Foo", "Foo"))
+	assert.False(t, referenceSymbolMatches("Foo", "FooBar"))
+	assert.True(t, referenceSymbolMatches("Type.Method", "Type.Method"))
+	assert.True(t, referenceSymbolMatches("Type.Method", "Method"))
+	assert.False(t, referenceSymbolMatches("Type.Method", "Type"))
+}
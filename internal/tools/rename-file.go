@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/koonwen/mcp-language-server/internal/utilities"
+)
+
+// RenameFile renames or moves a file. The language server is asked through
+// workspace/willRenameFiles for the edits to make first, such as updating
+// imports of the file, and told through workspace/didRenameFiles once the
+// file has moved. With dryRun, the changes are reported but not made.
+func RenameFile(ctx context.Context, client *lsp.Client, workspaceDir, oldPath, newPath string, dryRun bool) (string, error) {
+	oldPath, newPath, err := resolveFileRename(workspaceDir, oldPath, newPath)
+	if err != nil {
+		return "", err
+	}
+
+	renameParams := protocol.RenameFilesParams{
+		Files: []protocol.FileRename{{
+			OldURI: "file://" + oldPath,
+			NewURI: "file://" + newPath,
+		}},
+	}
+
+	// Ask the server for the edits it wants made before the move
+	var serverEdit protocol.WorkspaceEdit
+	if client.SupportsMethod("workspace/willRenameFiles") {
+		serverEdit, err = client.WillRenameFiles(ctx, renameParams)
+		if err != nil {
+			toolsLogger.Error("willRenameFiles failed: %v", err)
+			serverEdit = protocol.WorkspaceEdit{}
+		}
+	}
+
+	move := msg(MsgRenamePackageMove, oldPath, newPath)
+	if dryRun {
+		preview, err := previewWorkspaceEdit(serverEdit)
+		if err != nil {
+			return "", err
+		}
+		return msg(MsgRenamePackageDryRun) + "\n\n" + move + "\n\n" + preview, nil
+	}
+
+	changes, fileOps := summarizeWorkspaceEdit(serverEdit)
+
+	// Edits refer to the file at its old location, so apply them first
+	if err := utilities.ApplyWorkspaceEdit(serverEdit); err != nil {
+		return "", fmt.Errorf("failed to apply server edits: %v", err)
+	}
+
+	wasOpen := client.IsFileOpen(oldPath)
+	if wasOpen {
+		if err := client.CloseFile(ctx, oldPath); err != nil {
+			toolsLogger.Error("Error closing file: %v", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create parent directory: %v", err)
+	}
+	if err := utilities.RenamePath(oldPath, newPath); err != nil {
+		return "", fmt.Errorf("failed to move file: %v", err)
+	}
+
+	if client.SupportsMethod("workspace/didRenameFiles") {
+		if err := client.DidRenameFiles(ctx, renameParams); err != nil {
+			toolsLogger.Error("Error sending didRenameFiles: %v", err)
+		}
+	}
+	if wasOpen {
+		if err := client.OpenFile(ctx, newPath); err != nil {
+			toolsLogger.Error("Error opening file: %v", err)
+		}
+	}
+
+	// Let the server run its on-save actions on the updated files, including
+	// the moved file if it was edited
+	saved := ""
+	for _, change := range changes {
+		saved += saveFile(ctx, client, movedPath(change.path, oldPath, newPath))
+	}
+
+	result := msg(MsgRenameFileApplied) + "\n\n" + move + "\n"
+	locations, count := formatFileEdits(changes, fileOps)
+	if count > 0 || len(fileOps) > 0 {
+		result += "\n" + msg(MsgRenamePackageEdits, len(changes)) + "\n" + locations
+	}
+	if saved != "" {
+		result += "\n" + saved
+	}
+	return result, nil
+}
+
+// resolveFileRename returns the absolute paths of a file and its new
+// location, checking that the file exists and the new location does not
+func resolveFileRename(workspaceDir, oldPath, newPath string) (string, string, error) {
+	if !filepath.IsAbs(oldPath) {
+		oldPath = filepath.Join(workspaceDir, oldPath)
+	}
+	if !filepath.IsAbs(newPath) {
+		newPath = filepath.Join(workspaceDir, newPath)
+	}
+	oldPath = filepath.Clean(oldPath)
+	newPath = filepath.Clean(newPath)
+
+	info, err := os.Stat(oldPath)
+	if err != nil {
+		return "", "", fmt.Errorf("file does not exist: %s", oldPath)
+	}
+	if info.IsDir() {
+		return "", "", fmt.Errorf("%s is a directory, use rename_package to move directories", oldPath)
+	}
+	if oldPath == newPath {
+		return "", "", fmt.Errorf("the file is already at %s", newPath)
+	}
+	if _, err := os.Stat(newPath); err == nil {
+		return "", "", fmt.Errorf("destination already exists: %s", newPath)
+	}
+	return oldPath, newPath, nil
+}
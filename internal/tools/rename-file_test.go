@@ -0,0 +1,33 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveFileRename(t *testing.T) {
+	workspace := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(workspace, "pkg"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(workspace, "pkg", "a.go"), []byte("package pkg\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(workspace, "pkg", "b.go"), []byte("package pkg\n"), 0644))
+
+	oldPath, newPath, err := resolveFileRename(workspace, "pkg/a.go", "other/c.go")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(workspace, "pkg", "a.go"), oldPath)
+	assert.Equal(t, filepath.Join(workspace, "other", "c.go"), newPath)
+
+	_, _, err = resolveFileRename(workspace, "pkg/missing.go", "c.go")
+	assert.ErrorContains(t, err, "does not exist")
+
+	_, _, err = resolveFileRename(workspace, "pkg", "other")
+	assert.ErrorContains(t, err, "rename_package")
+
+	_, _, err = resolveFileRename(workspace, "pkg/a.go", "pkg/b.go")
+	assert.ErrorContains(t, err, "already exists")
+
+	_, _, err = resolveFileRename(workspace, "pkg/a.go", filepath.Join(workspace, "pkg", "a.go"))
+	assert.ErrorContains(t, err, "already at")
+}
@@ -0,0 +1,444 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/koonwen/mcp-language-server/internal/utilities"
+)
+
+// skippedDirs are not searched for files to update when renaming a package
+var skippedDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// RenamePackage moves a package directory and updates everything that refers
+// to it. The language server is asked for the edits through
+// workspace/willRenameFiles. If it has none, Go import paths are rewritten
+// based on go.mod. Relative references in the root go.mod and package.json
+// are always updated. With dryRun, the changes are reported but not made.
+func RenamePackage(ctx context.Context, client *lsp.Client, workspaceDir, oldDir, newDir string, dryRun bool) (string, error) {
+	if !filepath.IsAbs(oldDir) {
+		oldDir = filepath.Join(workspaceDir, oldDir)
+	}
+	if !filepath.IsAbs(newDir) {
+		newDir = filepath.Join(workspaceDir, newDir)
+	}
+	oldDir = filepath.Clean(oldDir)
+	newDir = filepath.Clean(newDir)
+
+	if info, err := os.Stat(oldDir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("not a directory: %s", oldDir)
+	}
+	if _, err := os.Stat(newDir); err == nil {
+		return "", fmt.Errorf("destination already exists: %s", newDir)
+	}
+	if strings.HasPrefix(newDir+string(filepath.Separator), oldDir+string(filepath.Separator)) {
+		return "", fmt.Errorf("cannot move a directory into itself: %s", newDir)
+	}
+
+	renameParams := protocol.RenameFilesParams{
+		Files: []protocol.FileRename{{
+			OldURI: "file://" + oldDir,
+			NewURI: "file://" + newDir,
+		}},
+	}
+
+	// Ask the server for the edits it wants made before the move
+	var serverEdit protocol.WorkspaceEdit
+	if client.SupportsMethod("workspace/willRenameFiles") {
+		var err error
+		serverEdit, err = client.WillRenameFiles(ctx, renameParams)
+		if err != nil {
+			toolsLogger.Error("willRenameFiles failed: %v", err)
+			serverEdit = protocol.WorkspaceEdit{}
+		}
+	}
+
+	var notes []string
+	localEdits := make(map[protocol.DocumentUri][]protocol.TextEdit)
+
+	// Servers such as gopls do not update imports when a directory moves
+	if len(serverEdit.Changes) == 0 && len(serverEdit.DocumentChanges) == 0 {
+		oldImport, newImport, err := goImportPaths(workspaceDir, oldDir, newDir)
+		if err != nil {
+			return "", err
+		}
+		if oldImport != "" {
+			notes = append(notes, msg(MsgRenamePackageImportPath, oldImport, newImport))
+
+			// The package clause follows the last element of the import path
+			oldName, newName := filepath.Base(oldDir), filepath.Base(newDir)
+			renamed := false
+			if oldName != newName && token.IsIdentifier(newName) {
+				entries, _ := os.ReadDir(oldDir)
+				for _, entry := range entries {
+					path := filepath.Join(oldDir, entry.Name())
+					if entry.IsDir() || filepath.Ext(path) != ".go" {
+						continue
+					}
+					content, err := os.ReadFile(path)
+					if err != nil {
+						toolsLogger.Error("Error reading file: %v", err)
+						continue
+					}
+					if edits := goPackageClauseEdits(path, string(content), oldName, newName); len(edits) > 0 {
+						localEdits[protocol.DocumentUri("file://"+path)] = edits
+						renamed = true
+					}
+				}
+			}
+			importName := ""
+			if renamed {
+				importName = oldName
+				notes = append(notes, msg(MsgRenamePackageName, oldName, newName, oldName))
+			}
+
+			err := walkWorkspaceFiles(workspaceDir, ".go", func(path string, content string) {
+				if edits := goImportEdits(path, content, oldImport, newImport, importName); len(edits) > 0 {
+					uri := protocol.DocumentUri("file://" + path)
+					localEdits[uri] = append(localEdits[uri], edits...)
+				}
+			})
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	// Relative paths to the directory in the root manifests
+	oldRel, errOld := filepath.Rel(workspaceDir, oldDir)
+	newRel, errNew := filepath.Rel(workspaceDir, newDir)
+	if errOld == nil && errNew == nil && !strings.HasPrefix(oldRel, "..") {
+		oldRel, newRel = filepath.ToSlash(oldRel), filepath.ToSlash(newRel)
+		manifests := map[string]*regexp.Regexp{
+			// replace directives such as "=> ./old"
+			"go.mod": regexp.MustCompile(`(?m)=>\s*(?:\./)?(` + regexp.QuoteMeta(oldRel) + `)(?:\s|$)`),
+			// workspaces and file: dependencies
+			"package.json": regexp.MustCompile(`"(?:file:)?(?:\./)?(` + regexp.QuoteMeta(oldRel) + `)"`),
+		}
+		for name, pattern := range manifests {
+			path := filepath.Join(workspaceDir, name)
+			content, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			if edits := patternEdits(string(content), pattern, newRel); len(edits) > 0 {
+				uri := protocol.DocumentUri("file://" + path)
+				localEdits[uri] = append(localEdits[uri], edits...)
+			}
+		}
+	}
+
+	report := formatPackageRename(oldDir, newDir, serverEdit, localEdits, notes)
+	if dryRun {
+		return msg(MsgRenamePackageDryRun) + "\n\n" + report, nil
+	}
+
+	// Edits refer to the files at their old location, so apply them first
+	if err := utilities.ApplyWorkspaceEdit(serverEdit); err != nil {
+		return "", fmt.Errorf("failed to apply server edits: %v", err)
+	}
+	if err := utilities.ApplyWorkspaceEdit(protocol.WorkspaceEdit{Changes: localEdits}); err != nil {
+		return "", fmt.Errorf("failed to apply edits: %v", err)
+	}
+
+	// Close the documents that are about to move
+	err := filepath.WalkDir(oldDir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && client.IsFileOpen(path) {
+			if err := client.CloseFile(ctx, path); err != nil {
+				toolsLogger.Error("Error closing file: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		toolsLogger.Error("Error closing files: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(newDir), 0755); err != nil {
+		return "", fmt.Errorf("failed to create parent directory: %v", err)
+	}
+	if err := utilities.RenamePath(oldDir, newDir); err != nil {
+		return "", fmt.Errorf("failed to move directory: %v", err)
+	}
+
+	if client.SupportsMethod("workspace/didRenameFiles") {
+		if err := client.DidRenameFiles(ctx, renameParams); err != nil {
+			toolsLogger.Error("Error sending didRenameFiles: %v", err)
+		}
+	}
+
+	// Let the server run its on-save actions on the updated files
+	changes, _ := summarizeWorkspaceEdit(mergeWorkspaceEdits([]protocol.WorkspaceEdit{serverEdit, {Changes: localEdits}}))
+	saved := ""
+	for _, change := range changes {
+		saved += saveFile(ctx, client, movedPath(change.path, oldDir, newDir))
+	}
+
+	return msg(MsgRenamePackageApplied) + "\n\n" + report + saved, nil
+}
+
+// movedPath returns where a path is once the file or directory at oldPath
+// has moved to newPath
+func movedPath(path, oldPath, newPath string) string {
+	rel, err := filepath.Rel(oldPath, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.Join(newPath, rel)
+}
+
+// goImportPaths returns the import paths of a package directory before and
+// after a move, based on the enclosing go.mod. It returns empty strings if the
+// directory is not in a Go module.
+func goImportPaths(workspaceDir, oldDir, newDir string) (string, string, error) {
+	// Find the closest go.mod, without leaving the workspace
+	modRoot := oldDir
+	for {
+		if _, err := os.Stat(filepath.Join(modRoot, "go.mod")); err == nil {
+			break
+		}
+		if modRoot == workspaceDir || modRoot == filepath.Dir(modRoot) {
+			return "", "", nil
+		}
+		modRoot = filepath.Dir(modRoot)
+	}
+
+	modulePath, err := goModulePath(filepath.Join(modRoot, "go.mod"))
+	if err != nil {
+		return "", "", err
+	}
+
+	oldRel, err := filepath.Rel(modRoot, oldDir)
+	if err != nil {
+		return "", "", err
+	}
+	newRel, err := filepath.Rel(modRoot, newDir)
+	if err != nil || strings.HasPrefix(newRel, "..") || oldRel == "." {
+		return "", "", fmt.Errorf("cannot move a package out of its module or move the module root: %s", oldDir)
+	}
+
+	return modulePath + "/" + filepath.ToSlash(oldRel), modulePath + "/" + filepath.ToSlash(newRel), nil
+}
+
+// goModulePath reads the module path from a go.mod file
+func goModulePath(goModPath string) (string, error) {
+	file, err := os.Open(goModPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read go.mod: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`), nil
+		}
+	}
+	return "", fmt.Errorf("no module directive in %s", goModPath)
+}
+
+// goImportEdits returns edits to the import specs of a Go file that import
+// the package at oldImport, or a package below it, to import it from
+// newImport. If importName is set, imports of the package itself that have
+// no name are given it, so that the file still refers to the package by its
+// old name once its package clause is renamed. Other string literals are not
+// changed.
+func goImportEdits(path, content, oldImport, newImport, importName string) []protocol.TextEdit {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, content, parser.ImportsOnly)
+	if err != nil {
+		toolsLogger.Error("Error parsing imports of %s: %v", path, err)
+		return nil
+	}
+
+	var edits []protocol.TextEdit
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil || (importPath != oldImport && !strings.HasPrefix(importPath, oldImport+"/")) {
+			continue
+		}
+
+		// Replace the prefix inside the quotes
+		start := fset.Position(spec.Path.Pos()).Offset + 1
+		newText := newImport
+		if importName != "" && spec.Name == nil && importPath == oldImport {
+			start--
+			newText = importName + " " + spec.Path.Value[:1] + newImport
+		}
+		edits = append(edits, protocol.TextEdit{
+			Range: protocol.Range{
+				Start: offsetPosition(content, start),
+				End:   offsetPosition(content, fset.Position(spec.Path.Pos()).Offset+1+len(oldImport)),
+			},
+			NewText: newText,
+		})
+	}
+	return edits
+}
+
+// goPackageClauseEdits returns an edit renaming the package clause of a Go
+// file from oldName to newName, or from the external test package of oldName
+// to that of newName
+func goPackageClauseEdits(path, content, oldName, newName string) []protocol.TextEdit {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, content, parser.PackageClauseOnly)
+	if err != nil {
+		toolsLogger.Error("Error parsing package clause of %s: %v", path, err)
+		return nil
+	}
+
+	var name string
+	switch file.Name.Name {
+	case oldName:
+		name = newName
+	case oldName + "_test":
+		name = newName + "_test"
+	default:
+		return nil
+	}
+	start := fset.Position(file.Name.Pos()).Offset
+	return []protocol.TextEdit{{
+		Range: protocol.Range{
+			Start: offsetPosition(content, start),
+			End:   offsetPosition(content, start+len(file.Name.Name)),
+		},
+		NewText: name,
+	}}
+}
+
+// walkWorkspaceFiles calls fn with the content of every file in the workspace
+// with the given extension
+func walkWorkspaceFiles(workspaceDir, ext string, fn func(path string, content string)) error {
+	return filepath.WalkDir(workspaceDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != workspaceDir && (skippedDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ext {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			toolsLogger.Error("Error reading file: %v", err)
+			return nil
+		}
+		fn(path, string(content))
+		return nil
+	})
+}
+
+// patternEdits returns edits replacing the first capture group of every match
+// of pattern in content with replacement
+func patternEdits(content string, pattern *regexp.Regexp, replacement string) []protocol.TextEdit {
+	var edits []protocol.TextEdit
+	for _, match := range pattern.FindAllStringSubmatchIndex(content, -1) {
+		start, end := match[2], match[3]
+		edits = append(edits, protocol.TextEdit{
+			Range: protocol.Range{
+				Start: offsetPosition(content, start),
+				End:   offsetPosition(content, end),
+			},
+			NewText: replacement,
+		})
+	}
+	return edits
+}
+
+// offsetPosition converts a byte offset in content to an LSP position
+func offsetPosition(content string, offset int) protocol.Position {
+	lineStart := strings.LastIndex(content[:offset], "\n") + 1
+	return protocol.Position{
+		Line:      uint32(strings.Count(content[:offset], "\n")),
+		Character: uint32(len(utf16.Encode([]rune(content[lineStart:offset])))),
+	}
+}
+
+// formatPackageRename describes every change made by a package rename
+func formatPackageRename(oldDir, newDir string, serverEdit protocol.WorkspaceEdit, localEdits map[protocol.DocumentUri][]protocol.TextEdit, notes []string) string {
+	edits := make(map[string][]protocol.TextEdit)
+	var fileOps []string
+
+	for uri, textEdits := range serverEdit.Changes {
+		edits[string(uri)] = append(edits[string(uri)], textEdits...)
+	}
+	for _, change := range serverEdit.DocumentChanges {
+		switch {
+		case change.TextDocumentEdit != nil:
+			uri := string(change.TextDocumentEdit.TextDocument.URI)
+			for _, edit := range change.TextDocumentEdit.Edits {
+				if textEdit, err := edit.AsTextEdit(); err == nil {
+					edits[uri] = append(edits[uri], textEdit)
+				}
+			}
+		case change.CreateFile != nil:
+			fileOps = append(fileOps, msg(MsgRenamePackageCreate, strings.TrimPrefix(string(change.CreateFile.URI), "file://")))
+		case change.RenameFile != nil:
+			fileOps = append(fileOps, msg(MsgRenamePackageMove,
+				strings.TrimPrefix(string(change.RenameFile.OldURI), "file://"),
+				strings.TrimPrefix(string(change.RenameFile.NewURI), "file://")))
+		case change.DeleteFile != nil:
+			fileOps = append(fileOps, msg(MsgRenamePackageDelete, strings.TrimPrefix(string(change.DeleteFile.URI), "file://")))
+		}
+	}
+	for uri, textEdits := range localEdits {
+		edits[string(uri)] = append(edits[string(uri)], textEdits...)
+	}
+
+	var result strings.Builder
+	result.WriteString(msg(MsgRenamePackageMove, oldDir, newDir) + "\n")
+	for _, note := range notes {
+		result.WriteString(note + "\n")
+	}
+	for _, op := range fileOps {
+		result.WriteString(op + "\n")
+	}
+
+	uris := make([]string, 0, len(edits))
+	for uri := range edits {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+
+	result.WriteString("\n" + msg(MsgRenamePackageEdits, len(uris)) + "\n")
+	for _, uri := range uris {
+		fileEdits := edits[uri]
+		sort.Slice(fileEdits, func(i, j int) bool {
+			if fileEdits[i].Range.Start.Line != fileEdits[j].Range.Start.Line {
+				return fileEdits[i].Range.Start.Line < fileEdits[j].Range.Start.Line
+			}
+			return fileEdits[i].Range.Start.Character < fileEdits[j].Range.Start.Character
+		})
+
+		var locs []string
+		for _, edit := range fileEdits {
+			locs = append(locs, fmt.Sprintf("L%d:C%d %q", edit.Range.Start.Line+1, edit.Range.Start.Character+1, edit.NewText))
+		}
+		result.WriteString(fmt.Sprintf("%s: %s\n", strings.TrimPrefix(uri, "file://"), strings.Join(locs, ", ")))
+	}
+
+	return result.String()
+}
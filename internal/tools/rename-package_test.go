@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoImportPaths(t *testing.T) {
+	workspace := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(workspace, "go.mod"), []byte("module example.com/app\n\ngo 1.24\n"), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(workspace, "internal", "old"), 0755))
+
+	oldImport, newImport, err := goImportPaths(workspace, filepath.Join(workspace, "internal", "old"), filepath.Join(workspace, "pkg", "new"))
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com/app/internal/old", oldImport)
+	assert.Equal(t, "example.com/app/pkg/new", newImport)
+
+	// The module root cannot be moved
+	_, _, err = goImportPaths(workspace, workspace, filepath.Join(workspace, "new"))
+	assert.Error(t, err)
+
+	// Directories outside a module have no import path
+	other := t.TempDir()
+	oldImport, _, err = goImportPaths(other, filepath.Join(other, "old"), filepath.Join(other, "new"))
+	assert.NoError(t, err)
+	assert.Equal(t, "", oldImport)
+}
+
+func TestPatternEdits(t *testing.T) {
+	content := "import (\n\t\"example.com/app/internal/old\"\n\tsub \"example.com/app/internal/old/sub\"\n\t\"example.com/app/internal/older\"\n)\n// é \"example.com/app/internal/old\"\n"
+	pattern := regexp.MustCompile(`"(` + regexp.QuoteMeta("example.com/app/internal/old") + `)(/[^"]*)?"`)
+
+	edits := patternEdits(content, pattern, "example.com/app/pkg/new")
+	assert.Equal(t, []protocol.TextEdit{
+		{
+			Range:   protocol.Range{Start: protocol.Position{Line: 1, Character: 2}, End: protocol.Position{Line: 1, Character: 30}},
+			NewText: "example.com/app/pkg/new",
+		},
+		{
+			Range:   protocol.Range{Start: protocol.Position{Line: 2, Character: 6}, End: protocol.Position{Line: 2, Character: 34}},
+			NewText: "example.com/app/pkg/new",
+		},
+		{
+			Range:   protocol.Range{Start: protocol.Position{Line: 5, Character: 6}, End: protocol.Position{Line: 5, Character: 34}},
+			NewText: "example.com/app/pkg/new",
+		},
+	}, edits)
+}
+
+func TestMovedPath(t *testing.T) {
+	assert.Equal(t, "/ws/pkg/new/a.go", movedPath("/ws/internal/old/a.go", "/ws/internal/old", "/ws/pkg/new"))
+	assert.Equal(t, "/ws/b.go", movedPath("/ws/internal/old/a.go", "/ws/internal/old/a.go", "/ws/b.go"))
+	assert.Equal(t, "/ws/internal/older/a.go", movedPath("/ws/internal/older/a.go", "/ws/internal/old", "/ws/pkg/new"))
+	assert.Equal(t, "/ws/main.go", movedPath("/ws/main.go", "/ws/internal/old", "/ws/pkg/new"))
+}
+
+func TestGoImportEdits(t *testing.T) {
+	content := "package main\n\nimport (\n\t\"example.com/app/internal/old\"\n\tsub \"example.com/app/internal/old/sub\"\n\t\"example.com/app/internal/older\"\n)\n\nconst path = \"example.com/app/internal/old\"\n"
+
+	edits := goImportEdits("main.go", content, "example.com/app/internal/old", "example.com/app/pkg/new", "")
+	assert.Equal(t, []protocol.TextEdit{
+		{
+			Range:   protocol.Range{Start: protocol.Position{Line: 3, Character: 2}, End: protocol.Position{Line: 3, Character: 30}},
+			NewText: "example.com/app/pkg/new",
+		},
+		{
+			Range:   protocol.Range{Start: protocol.Position{Line: 4, Character: 6}, End: protocol.Position{Line: 4, Character: 34}},
+			NewText: "example.com/app/pkg/new",
+		},
+	}, edits)
+
+	// Unnamed imports of the package keep its old name
+	edits = goImportEdits("main.go", content, "example.com/app/internal/old", "example.com/app/pkg/new", "old")
+	assert.Equal(t, protocol.TextEdit{
+		Range:   protocol.Range{Start: protocol.Position{Line: 3, Character: 1}, End: protocol.Position{Line: 3, Character: 30}},
+		NewText: "old \"example.com/app/pkg/new",
+	}, edits[0])
+	assert.Equal(t, "example.com/app/pkg/new", edits[1].NewText)
+
+	// Files that do not parse are left alone
+	assert.Empty(t, goImportEdits("bad.go", "package", "example.com/app/internal/old", "example.com/app/pkg/new", ""))
+}
+
+func TestGoPackageClauseEdits(t *testing.T) {
+	edits := goPackageClauseEdits("a.go", "// Package old does things\npackage old\n", "old", "new")
+	assert.Equal(t, []protocol.TextEdit{{
+		Range:   protocol.Range{Start: protocol.Position{Line: 1, Character: 8}, End: protocol.Position{Line: 1, Character: 11}},
+		NewText: "new",
+	}}, edits)
+
+	edits = goPackageClauseEdits("a_test.go", "package old_test\n", "old", "new")
+	assert.Equal(t, "new_test", edits[0].NewText)
+
+	assert.Empty(t, goPackageClauseEdits("main.go", "package main\n", "old", "new"))
+}
@@ -12,16 +12,16 @@ import (
 )
 
 // RenameSymbol renames a symbol (variable, function, class, etc.) at the specified position
-// It uses the LSP rename functionality to handle all references across files
-func RenameSymbol(ctx context.Context, client *lsp.Client, filePath string, line, column int, newName string) (string, error) {
+// It uses the LSP rename functionality to handle all references across files.
+// With dryRun, the changes are returned as a diff without being written.
+func RenameSymbol(ctx context.Context, client *lsp.Client, filePath string, line, column int, newName string, dryRun bool) (string, error) {
 	// Open the file if not already open
-	err := client.OpenFile(ctx, filePath)
+	uri, err := openDocument(ctx, client, filePath)
 	if err != nil {
-		return "", fmt.Errorf("could not open file: %v", err)
+		return "", err
 	}
 
 	// Convert 1-indexed line/column to 0-indexed for LSP protocol
-	uri := protocol.DocumentUri("file://" + filePath)
 	position := protocol.Position{
 		Line:      uint32(line - 1),
 		Character: uint32(column - 1),
@@ -36,8 +36,17 @@ func RenameSymbol(ctx context.Context, client *lsp.Client, filePath string, line
 		NewName:  newName,
 	}
 
-	// Skip the PrepareRename check as it might not be supported by all language servers
-	// Execute the rename directly
+	// Check the position up front when the server supports it, rather than
+	// failing with a less helpful error or renaming the wrong symbol
+	if client.SupportsMethod("textDocument/prepareRename") {
+		target, reason, err := prepareRename(ctx, client, uri, position)
+		if err != nil {
+			return "", err
+		}
+		if target == nil {
+			return "", fmt.Errorf("failed to rename symbol: %s", reason)
+		}
+	}
 
 	// Execute the rename operation
 	workspaceEdit, err := client.Rename(ctx, params)
@@ -45,83 +54,112 @@ func RenameSymbol(ctx context.Context, client *lsp.Client, filePath string, line
 		return "", fmt.Errorf("failed to rename symbol: %v", err)
 	}
 
-	// Count the changes that will be made
-	changeCount := 0
-	fileCount := 0
-
-	// Build output
-	var locationsBuilder strings.Builder
-
-	// Create a slice to store all changes before sorting and writing
-	type FileChanges struct {
-		URI       string
-		Locations string
-	}
-	var allChanges []FileChanges
-
-	// Count changes in Changes field
-	if workspaceEdit.Changes != nil {
-		fileCount = len(workspaceEdit.Changes)
-		for uri, edits := range workspaceEdit.Changes {
-			changeCount += len(edits)
-			var locs strings.Builder
-			for i, change := range edits {
-				locs.WriteString(
-					fmt.Sprintf("L%d:C%d", change.Range.Start.Line+1, change.Range.Start.Character+1),
-				)
-				if i != len(edits)-1 {
-					locs.WriteString(", ")
-				}
-			}
-			allChanges = append(allChanges, FileChanges{
-				URI:       string(uri),
-				Locations: locs.String(),
-			})
-		}
+	if dryRun {
+		return previewWorkspaceEdit(workspaceEdit)
 	}
 
-	// Count changes in DocumentChanges field
-	for _, change := range workspaceEdit.DocumentChanges {
-		if change.TextDocumentEdit != nil {
-			var locs strings.Builder
-			for i, edit := range change.TextDocumentEdit.Edits {
-				textEdit, err := edit.AsTextEdit()
-				if err == nil {
-					locs.WriteString(fmt.Sprintf("L%d:C%d", textEdit.Range.Start.Line+1, textEdit.Range.Start.Character+1))
-					if i != len(change.TextDocumentEdit.Edits)-1 {
-						locs.WriteString(", ")
-					}
+	changes, fileOps := summarizeWorkspaceEdit(workspaceEdit)
+
+	// Apply the workspace edit to files
+	if err := utilities.ApplyWorkspaceEdit(workspaceEdit); err != nil {
+		return "", fmt.Errorf("failed to apply changes: %v", err)
+	}
+
+	// Let the server run its on-save actions
+	saved := ""
+	for _, change := range changes {
+		saved += saveFile(ctx, client, change.path)
+	}
+
+	locations, changeCount := formatFileEdits(changes, fileOps)
+	if changeCount == 0 {
+		return msg(MsgRenameNoOccurrences), nil
+	}
+
+	// Generate a summary of changes made
+	result := msg(MsgRenameSucceeded, newName, changeCount, len(changes), locations)
+	if saved != "" {
+		result += "\n" + saved
+	}
+	return result, nil
+}
+
+// fileEdits is the ranges of a file changed by a workspace edit, as they were
+// before the edit was applied
+type fileEdits struct {
+	path   string
+	ranges []protocol.Range
+}
+
+// summarizeWorkspaceEdit lists the ranges changed in each file by a workspace
+// edit, sorted by path and position, along with the files it creates, renames
+// or deletes
+func summarizeWorkspaceEdit(edit protocol.WorkspaceEdit) ([]fileEdits, []string) {
+	ranges := make(map[string][]protocol.Range)
+	var fileOps []string
+
+	for uri, textEdits := range edit.Changes {
+		path := lsp.DocumentPath(uri)
+		for _, textEdit := range textEdits {
+			ranges[path] = append(ranges[path], textEdit.Range)
+		}
+	}
+	for _, change := range edit.DocumentChanges {
+		switch {
+		case change.TextDocumentEdit != nil:
+			path := lsp.DocumentPath(change.TextDocumentEdit.TextDocument.URI)
+			for _, e := range change.TextDocumentEdit.Edits {
+				if textEdit, err := e.AsTextEdit(); err == nil {
+					ranges[path] = append(ranges[path], textEdit.Range)
 				}
 			}
-			allChanges = append(allChanges, FileChanges{
-				URI:       string(change.TextDocumentEdit.TextDocument.URI),
-				Locations: locs.String(),
-			})
-			fileCount++
-			changeCount += len(change.TextDocumentEdit.Edits)
+		case change.CreateFile != nil:
+			fileOps = append(fileOps, msg(MsgRenamePackageCreate, lsp.DocumentPath(change.CreateFile.URI)))
+		case change.RenameFile != nil:
+			fileOps = append(fileOps, msg(MsgRenamePackageMove,
+				lsp.DocumentPath(change.RenameFile.OldURI), lsp.DocumentPath(change.RenameFile.NewURI)))
+		case change.DeleteFile != nil:
+			fileOps = append(fileOps, msg(MsgRenamePackageDelete, lsp.DocumentPath(change.DeleteFile.URI)))
 		}
 	}
 
-	// Sort changes by filename for consistent output
-	sort.Slice(allChanges, func(i, j int) bool {
-		return allChanges[i].URI < allChanges[j].URI
+	changes := make([]fileEdits, 0, len(ranges))
+	for path, fileRanges := range ranges {
+		sort.Slice(fileRanges, func(i, j int) bool {
+			return positionBefore(fileRanges[i].Start, fileRanges[j].Start)
+		})
+		changes = append(changes, fileEdits{path: path, ranges: fileRanges})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].path < changes[j].path
 	})
 
-	// Write sorted changes to the output builder
-	for _, change := range allChanges {
-		locationsBuilder.WriteString(fmt.Sprintf("%s: %s\n", change.URI, change.Locations))
-	}
+	return changes, fileOps
+}
 
-	// Apply the workspace edit to files:workspaceEdit
-	if err := utilities.ApplyWorkspaceEdit(workspaceEdit); err != nil {
-		return "", fmt.Errorf("failed to apply changes: %v", err)
+// formatFileEdits lists the changed ranges of each file, one file per line,
+// followed by the file operations. It also returns the number of ranges.
+func formatFileEdits(changes []fileEdits, fileOps []string) (string, int) {
+	count := 0
+	var result strings.Builder
+	for _, change := range changes {
+		count += len(change.ranges)
+		ranges := make([]string, len(change.ranges))
+		for i, r := range change.ranges {
+			ranges[i] = formatRange(r)
+		}
+		result.WriteString(fmt.Sprintf("%s: %s\n", change.path, strings.Join(ranges, ", ")))
 	}
-
-	if fileCount == 0 || changeCount == 0 {
-		return "Failed to rename symbol. 0 occurrences found.", nil
+	for _, op := range fileOps {
+		result.WriteString(op + "\n")
 	}
+	return result.String(), count
+}
 
-	// Generate a summary of changes made
-	return fmt.Sprintf("Successfully renamed symbol to '%s'.\nUpdated %d occurrences across %d files:\n%s",
-		newName, changeCount, fileCount, locationsBuilder.String()), nil
+// formatRange renders a 1-indexed range
+func formatRange(r protocol.Range) string {
+	if r.Start.Line == r.End.Line {
+		return fmt.Sprintf("L%d:C%d-C%d", r.Start.Line+1, r.Start.Character+1, r.End.Character+1)
+	}
+	return fmt.Sprintf("L%d:C%d-L%d:C%d", r.Start.Line+1, r.Start.Character+1, r.End.Line+1, r.End.Character+1)
 }
@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeWorkspaceEdit(t *testing.T) {
+	edit := protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+			"file:///ws/b.go": {
+				{Range: protocol.Range{Start: protocol.Position{Line: 9, Character: 4}, End: protocol.Position{Line: 9, Character: 7}}, NewText: "New"},
+				{Range: protocol.Range{Start: protocol.Position{Line: 2, Character: 0}, End: protocol.Position{Line: 2, Character: 3}}, NewText: "New"},
+			},
+		},
+		DocumentChanges: []protocol.DocumentChange{
+			{TextDocumentEdit: &protocol.TextDocumentEdit{
+				TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+					TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: "file:///ws/a.go"},
+				},
+				Edits: []protocol.Or_TextDocumentEdit_edits_Elem{
+					{Value: protocol.TextEdit{Range: protocol.Range{Start: protocol.Position{Line: 0, Character: 5}, End: protocol.Position{Line: 1, Character: 2}}, NewText: "New"}},
+				},
+			}},
+			{RenameFile: &protocol.RenameFile{OldURI: "file:///ws/old.go", NewURI: "file:///ws/new.go"}},
+		},
+	}
+
+	changes, fileOps := summarizeWorkspaceEdit(edit)
+	assert.Equal(t, []fileEdits{
+		{path: "/ws/a.go", ranges: []protocol.Range{
+			{Start: protocol.Position{Line: 0, Character: 5}, End: protocol.Position{Line: 1, Character: 2}},
+		}},
+		{path: "/ws/b.go", ranges: []protocol.Range{
+			{Start: protocol.Position{Line: 2, Character: 0}, End: protocol.Position{Line: 2, Character: 3}},
+			{Start: protocol.Position{Line: 9, Character: 4}, End: protocol.Position{Line: 9, Character: 7}},
+		}},
+	}, changes)
+	assert.Equal(t, []string{"Move: /ws/old.go -> /ws/new.go"}, fileOps)
+}
+
+func TestFormatRange(t *testing.T) {
+	assert.Equal(t, "L3:C1-C4", formatRange(protocol.Range{Start: protocol.Position{Line: 2, Character: 0}, End: protocol.Position{Line: 2, Character: 3}}))
+	assert.Equal(t, "L1:C6-L2:C3", formatRange(protocol.Range{Start: protocol.Position{Line: 0, Character: 5}, End: protocol.Position{Line: 1, Character: 2}}))
+}
@@ -0,0 +1,133 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// Queries supported by QueryAtRevision
+const (
+	RevisionHover      = "hover"
+	RevisionDefinition = "definition"
+	RevisionReferences = "references"
+)
+
+// QueryAtRevision answers a hover, definition or references query about a
+// position in a file as it was at a git revision, to compare an API's past
+// and present shape. For the duration of the query the server is given the
+// file's content at the revision as an overlay in place of its current
+// content, which is restored afterwards; the file on disk is not touched and
+// other files are analyzed as they are now. Changes to the file and other
+// tools opening it wait for the query, and diagnostics for the overlay are
+// dropped. References are limited to the file itself.
+func QueryAtRevision(ctx context.Context, client *lsp.Client, filePath, revision, query string, line, column int) (string, error) {
+	historical, commit, err := GitShow(ctx, filePath, revision)
+	if err != nil {
+		return "", err
+	}
+
+	uri, err := openDocument(ctx, client, filePath)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(historical, "\n")
+	position := protocol.Position{
+		Line:      uint32(line - 1),
+		Character: uint32(column - 1),
+	}
+	textDocumentPosition := protocol.TextDocumentPositionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Position:     position,
+	}
+
+	var result string
+	err = client.WithOverlay(ctx, uri, historical, func() error {
+		switch query {
+		case RevisionHover:
+			var rawResult json.RawMessage
+			if err := client.Call(ctx, "textDocument/hover", protocol.HoverParams{TextDocumentPositionParams: textDocumentPosition}, &rawResult); err != nil {
+				return fmt.Errorf("failed to get hover information: %v", err)
+			}
+			var err error
+			result, err = hoverContents(rawResult)
+			if err != nil {
+				return fmt.Errorf("failed to parse hover information: %v", err)
+			}
+			if result == "" {
+				result = msg(MsgNoHoverInfo, lineAt(lines, position.Line))
+			}
+
+		case RevisionDefinition:
+			definition, err := client.Definition(ctx, protocol.DefinitionParams{TextDocumentPositionParams: textDocumentPosition})
+			if err != nil {
+				return fmt.Errorf("failed to get definition: %v", err)
+			}
+			locations := definitionLocations(definition.Value)
+			if len(locations) == 0 {
+				result = msg(MsgNoDefinitionAtPosition, filePath, line, column)
+				break
+			}
+			result = formatRevisionLocations(uri, lines, locations)
+
+		case RevisionReferences:
+			references, err := client.References(ctx, protocol.ReferenceParams{
+				TextDocumentPositionParams: textDocumentPosition,
+				Context:                    protocol.ReferenceContext{IncludeDeclaration: true},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to find references: %v", err)
+			}
+			var inFile []protocol.Location
+			for _, ref := range references {
+				if ref.URI == uri {
+					inFile = append(inFile, ref)
+				}
+			}
+			if len(inFile) == 0 {
+				result = msg(MsgNoReferencesAtPosition, filePath, line, column)
+				break
+			}
+			result = msg(MsgReferencesInFile, len(inFile)) + "\n" + formatRevisionLocations(uri, lines, inFile)
+
+		default:
+			return fmt.Errorf("unknown query: %s", query)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return msg(MsgAtRevision, filePath, revision, commit) + "\n\n" + result, nil
+}
+
+// formatRevisionLocations lists locations with the line they point to,
+// reading the overlaid document at the revision and other files from disk
+func formatRevisionLocations(uri protocol.DocumentUri, lines []string, locations []protocol.Location) string {
+	var result strings.Builder
+	for _, loc := range locations {
+		text := ""
+		if loc.URI == uri {
+			text = lineAt(lines, loc.Range.Start.Line)
+		} else if content, err := os.ReadFile(lsp.DocumentPath(loc.URI)); err == nil {
+			text = lineAt(strings.Split(string(content), "\n"), loc.Range.Start.Line) + " " + msg(MsgCurrentVersion)
+		}
+		result.WriteString(fmt.Sprintf("%s %s\n    %s\n", lsp.DocumentPath(loc.URI), formatRange(loc.Range), text))
+	}
+	return result.String()
+}
+
+// lineAt returns a line without surrounding whitespace, or an empty string if
+// it is out of range
+func lineAt(lines []string, line uint32) string {
+	if int(line) >= len(lines) {
+		return ""
+	}
+	return strings.TrimSpace(lines[line])
+}
@@ -0,0 +1,236 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+const (
+	// maxRunnableFiles is the number of candidate files whose code lenses
+	// are requested by GetRunnables
+	maxRunnableFiles = 200
+	// runnableConcurrency is the number of files whose code lenses are
+	// requested at once
+	runnableConcurrency = 8
+)
+
+// runnableMarker matches the tests, benchmarks and entry points of common
+// languages. Only files containing one are asked for their code lenses, since
+// opening every file of a large workspace would be slow.
+var runnableMarker = regexp.MustCompile(`func (Test|Benchmark|Fuzz|Example)\w*\(|func main\(|#\[(test|bench|tokio::test)|fn main\(|def test_|__name__ == .__main__.|@(Test|ParameterizedTest|Benchmark)\b|static void main\(|\b(describe|it|test)\(`)
+
+// documentLanguages are the languages of documentation and data files, which
+// are not searched for runnables even if they contain code samples
+var documentLanguages = map[protocol.LanguageKind]bool{
+	protocol.LangMarkdown: true, protocol.LangJSON: true, protocol.LangYAML: true, protocol.LangXML: true,
+	protocol.LangHTML: true, protocol.LangCSS: true, protocol.LangLaTeX: true, protocol.LangBibTeX: true,
+	protocol.LangIni: true, protocol.LangDiff: true,
+}
+
+// runnableKind is a category of runnable code lens
+type runnableKind struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// runnableKinds are the categories of runnable code lenses, matched against
+// their titles and commands in order
+var runnableKinds = []runnableKind{
+	{"Benchmarks", regexp.MustCompile(`(?i)bench`)},
+	{"Tests", regexp.MustCompile(`(?i)test`)},
+	{"Run", regexp.MustCompile(`(?i)\b(run|debug|exec|start|main)`)},
+}
+
+// runnable is a code lens that runs part of the project
+type runnable struct {
+	path string
+	// index is the 1-indexed position of the lens in the file, as used by
+	// ExecuteCodeLens
+	index int
+	line  int
+	title string
+	kind  string
+}
+
+// GetRunnables aggregates the code lenses that run part of the project, such
+// as tests, benchmarks and main entry points, across the workspace or a
+// directory in it, so they can be discovered without reading build files.
+// Each is listed with its file and index for ExecuteCodeLens. A relative dir
+// is resolved against the workspace directory, and an empty one is the whole
+// workspace.
+func GetRunnables(ctx context.Context, client *lsp.Client, workspaceDir, dir string) (string, error) {
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(workspaceDir, dir)
+	}
+	candidates, err := runnableCandidates(dir)
+	if err != nil {
+		return "", err
+	}
+	checked := candidates
+	if len(checked) > maxRunnableFiles {
+		checked = checked[:maxRunnableFiles]
+	}
+
+	var mu sync.Mutex
+	var runnables []runnable
+	semaphore := make(chan struct{}, runnableConcurrency)
+	fanOut(ctx, len(checked), func(ctx context.Context, i int) error {
+		semaphore <- struct{}{}
+		defer func() { <-semaphore }()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		found, err := fileRunnables(ctx, client, checked[i])
+		if err != nil {
+			toolsLogger.Debug("Failed to get code lenses of %s: %v", checked[i], err)
+			return err
+		}
+		mu.Lock()
+		runnables = append(runnables, found...)
+		mu.Unlock()
+		return nil
+	})
+
+	return formatRunnables(dir, runnables, len(checked), len(candidates)), nil
+}
+
+// runnableCandidates lists the files under dir that contain a test,
+// benchmark or entry point, in path order
+func runnableCandidates(dir string) ([]string, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("could not read directory: %v", err)
+	}
+
+	var candidates []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != dir && (skippedDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if language := lsp.DetectLanguageID(path); language == "" || documentLanguages[language] {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		if runnableMarker.Match(content) {
+			candidates = append(candidates, path)
+		}
+		return nil
+	})
+	return candidates, err
+}
+
+// fileRunnables lists the runnable code lenses of a file. Files that were
+// not open before are closed again.
+func fileRunnables(ctx context.Context, client *lsp.Client, path string) ([]runnable, error) {
+	wasOpen := client.IsFileOpen(path)
+	if err := client.OpenFile(ctx, path); err != nil {
+		return nil, err
+	}
+	if !wasOpen {
+		defer func() {
+			if err := client.CloseFile(ctx, path); err != nil {
+				toolsLogger.Debug("Error closing file: %v", err)
+			}
+		}()
+	}
+
+	lenses, err := codeLenses(ctx, client, protocol.DocumentUri("file://"+path))
+	if err != nil {
+		return nil, err
+	}
+
+	var runnables []runnable
+	for i, lens := range lenses {
+		if lens.Command == nil {
+			continue
+		}
+		kind := classifyRunnable(*lens.Command)
+		if kind == "" {
+			continue
+		}
+		runnables = append(runnables, runnable{
+			path:  path,
+			index: i + 1,
+			line:  int(lens.Range.Start.Line) + 1,
+			title: codeLensTitle(lens),
+			kind:  kind,
+		})
+	}
+	return runnables, nil
+}
+
+// classifyRunnable returns the kind of runnable a code lens command is, or
+// an empty string if it does not run anything, like "N references"
+func classifyRunnable(command protocol.Command) string {
+	text := command.Title + " " + command.Command
+	for _, kind := range runnableKinds {
+		if kind.pattern.MatchString(text) {
+			return kind.name
+		}
+	}
+	return ""
+}
+
+// formatRunnables lists runnables by kind, then file and line
+func formatRunnables(dir string, runnables []runnable, checked, candidates int) string {
+	var out strings.Builder
+	if len(runnables) == 0 {
+		out.WriteString(msg(MsgNoRunnables, checked, dir) + "\n")
+	} else {
+		sort.Slice(runnables, func(i, j int) bool {
+			if runnables[i].path != runnables[j].path {
+				return runnables[i].path < runnables[j].path
+			}
+			return runnables[i].index < runnables[j].index
+		})
+
+		files := make(map[string]bool)
+		for _, r := range runnables {
+			files[r.path] = true
+		}
+		out.WriteString(msg(MsgRunnablesHeader, len(runnables), dir, len(files)) + "\n")
+
+		for _, kind := range runnableKinds {
+			var lines []string
+			previousPath := ""
+			for _, r := range runnables {
+				if r.kind != kind.name {
+					continue
+				}
+				if r.path != previousPath {
+					lines = append(lines, r.path)
+					previousPath = r.path
+				}
+				lines = append(lines, fmt.Sprintf("  [%d] L%d: %s", r.index, r.line, r.title))
+			}
+			if len(lines) > 0 {
+				out.WriteString("\n" + msg(MsgRunnableKind, kind.name) + "\n" + strings.Join(lines, "\n") + "\n")
+			}
+		}
+		out.WriteString("\n" + msg(MsgRunnablesHint) + "\n")
+	}
+
+	if checked < candidates {
+		out.WriteString(msg(MsgRunnableFilesLimited, checked, candidates) + "\n")
+	}
+	return out.String()
+}
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyRunnable(t *testing.T) {
+	assert.Equal(t, "Tests", classifyRunnable(protocol.Command{Title: "run test", Command: "gopls.run_tests"}))
+	assert.Equal(t, "Benchmarks", classifyRunnable(protocol.Command{Title: "run file benchmarks", Command: "gopls.run_tests"}))
+	assert.Equal(t, "Run", classifyRunnable(protocol.Command{Title: "▶︎ Run", Command: "rust-analyzer.runSingle"}))
+	assert.Equal(t, "Run", classifyRunnable(protocol.Command{Title: "Debug", Command: "rust-analyzer.debugSingle"}))
+	assert.Empty(t, classifyRunnable(protocol.Command{Title: "3 references", Command: "rust-analyzer.showReferences"}))
+	assert.Empty(t, classifyRunnable(protocol.Command{Title: "tidy module", Command: "gopls.tidy"}))
+}
+
+func TestRunnableCandidates(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":              "package main\n\nfunc main() {}\n",
+		"util.go":              "package main\n\nfunc helper() {}\n",
+		"util_test.go":         "package main\n\nfunc TestHelper(t *testing.T) {}\n",
+		"README.md":            "func main() {}\n",
+		"vendor/dep/dep.go":    "package dep\n\nfunc main() {}\n",
+		"src/lib.rs":           "#[test]\nfn works() {}\n",
+		"scripts/tool_test.py": "def test_tool():\n    pass\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	candidates, err := runnableCandidates(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "main.go"),
+		filepath.Join(dir, "scripts/tool_test.py"),
+		filepath.Join(dir, "src/lib.rs"),
+		filepath.Join(dir, "util_test.go"),
+	}, candidates)
+}
+
+func TestFormatRunnables(t *testing.T) {
+	runnables := []runnable{
+		{path: "/ws/pkg/a_test.go", index: 2, line: 12, title: "run test (gopls.run_tests)", kind: "Tests"},
+		{path: "/ws/cmd/main.go", index: 1, line: 5, title: "run (gopls.run)", kind: "Run"},
+		{path: "/ws/pkg/a_test.go", index: 1, line: 3, title: "run file benchmarks (gopls.run_tests)", kind: "Benchmarks"},
+	}
+
+	assert.Equal(t, `3 runnables in /ws, in 2 files:
+
+Benchmarks:
+/ws/pkg/a_test.go
+  [1] L3: run file benchmarks (gopls.run_tests)
+
+Tests:
+/ws/pkg/a_test.go
+  [2] L12: run test (gopls.run_tests)
+
+Run:
+/ws/cmd/main.go
+  [1] L5: run (gopls.run)
+
+Run one with execute_codelens, passing its file and [index].
+`, formatRunnables("/ws", runnables, 2, 2))
+
+	assert.Equal(t, "No runnable code lenses found in 200 candidate files in /ws\nOnly the first 200 of 250 candidate files were checked; pass a subdirectory as path to see the rest.\n",
+		formatRunnables("/ws", nil, 200, 250))
+}
@@ -0,0 +1,179 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// DefaultSecurityWatchlist is used when no watchlist has been configured. It
+// covers common command execution, dynamic evaluation and memory safety escape
+// hatches for the languages we test against. Entries may contain * wildcards.
+var DefaultSecurityWatchlist = []string{
+	// Go
+	"exec.Command",
+	"exec.CommandContext",
+	"syscall.Exec",
+	"unsafe.Pointer",
+	"template.HTML",
+	"template.JS",
+	// Python
+	"eval",
+	"exec",
+	"os.system",
+	"subprocess.Popen",
+	"subprocess.run",
+	"pickle.loads",
+	"yaml.load",
+	// TypeScript / JavaScript
+	"Function",
+	"execSync",
+	"spawn",
+	// C / C++
+	"system",
+	"popen",
+	"strcpy",
+	"sprintf",
+}
+
+// FindWatchlistUsages reports every usage of the symbols in the watchlist,
+// grouped by watchlist entry and then by file, with surrounding context.
+func FindWatchlistUsages(ctx context.Context, client *lsp.Client, watchlist []string) (string, error) {
+	contextLines := ContextLines()
+
+	if len(watchlist) == 0 {
+		watchlist = DefaultSecurityWatchlist
+	}
+
+	lookup := func(entry string) ([]protocol.Location, error) {
+		return watchlistReferences(ctx, client, entry)
+	}
+	format := func(refs []protocol.Location) string {
+		return strings.Join(formatReferencesByFile(ctx, client, refs, contextLines), "\n")
+	}
+	return formatWatchlistUsages(watchlist, lookup, format), nil
+}
+
+// formatWatchlistUsages looks up the references to each watchlist entry and
+// reports them with format. Only entries with references count as having
+// usages; entries that failed are reported but not counted.
+func formatWatchlistUsages(watchlist []string, lookup func(string) ([]protocol.Location, error), format func([]protocol.Location) string) string {
+	var sections []string
+	var notFound []string
+	total, found := 0, 0
+
+	for _, entry := range watchlist {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		total++
+
+		refs, err := lookup(entry)
+		if err != nil {
+			sections = append(sections, fmt.Sprintf("=== %s ===\nError: %v\n", entry, err))
+			continue
+		}
+		if len(refs) == 0 {
+			notFound = append(notFound, entry)
+			continue
+		}
+
+		found++
+		header := msg(MsgWatchlistEntry, entry, len(refs)) + "\n"
+		sections = append(sections, header+format(refs))
+	}
+
+	var result strings.Builder
+	result.WriteString(msg(MsgWatchlistSummary, found, total) + "\n\n")
+	result.WriteString(strings.Join(sections, "\n"))
+	if len(notFound) > 0 {
+		if len(sections) > 0 {
+			result.WriteString("\n")
+		}
+		result.WriteString(msg(MsgWatchlistNotFound, strings.Join(notFound, ", ")) + "\n")
+	}
+
+	return result.String()
+}
+
+// watchlistSymbolMatches reports whether a workspace symbol is covered by a
+// watchlist entry, which may contain * wildcards
+func watchlistSymbolMatches(entry, name string) bool {
+	if strings.Contains(entry, "*") {
+		ok, _ := path.Match(entry, name)
+		return ok
+	}
+	return referenceSymbolMatches(entry, name)
+}
+
+// watchlistReferences resolves a watchlist entry to workspace symbols and
+// returns the de-duplicated references to all of them.
+func watchlistReferences(ctx context.Context, client *lsp.Client, entry string) ([]protocol.Location, error) {
+	// The server only understands fuzzy queries, so search for the literal
+	// prefix of a wildcard entry and filter the results ourselves
+	query := strings.TrimSuffix(strings.SplitN(entry, "*", 2)[0], ".")
+	if query == "" {
+		return nil, fmt.Errorf("watchlist entry must not start with a wildcard")
+	}
+
+	symbolResult, err := client.Symbol(ctx, protocol.WorkspaceSymbolParams{
+		Query: query,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch symbol: %v", err)
+	}
+
+	results, err := symbolResult.Results()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse results: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	var refs []protocol.Location
+
+	for _, symbol := range results {
+		if !watchlistSymbolMatches(entry, symbol.GetName()) {
+			continue
+		}
+
+		loc := symbol.GetLocation()
+
+		// File is likely to be opened already, but may not be.
+		if _, err := openDocument(ctx, client, lsp.DocumentPath(loc.URI)); err != nil {
+			toolsLogger.Error("Error opening file: %v", err)
+			continue
+		}
+
+		symbolRefs, err := client.References(ctx, protocol.ReferenceParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{
+					URI: loc.URI,
+				},
+				Position: loc.Range.Start,
+			},
+			Context: protocol.ReferenceContext{
+				IncludeDeclaration: false,
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get references: %v", err)
+		}
+
+		// Servers may report the same symbol more than once
+		for _, ref := range symbolRefs {
+			key := fmt.Sprintf("%s:%d:%d", ref.URI, ref.Range.Start.Line, ref.Range.Start.Character)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			refs = append(refs, ref)
+		}
+	}
+
+	return refs, nil
+}
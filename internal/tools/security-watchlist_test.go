@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatWatchlistUsages(t *testing.T) {
+	refs := map[string][]protocol.Location{
+		"exec.Command": {{URI: "file:///ws/a.go"}, {URI: "file:///ws/b.go"}},
+		"os.*":         {{URI: "file:///ws/c.go"}},
+	}
+	lookup := func(entry string) ([]protocol.Location, error) {
+		if entry == "*.Exec" {
+			return nil, fmt.Errorf("watchlist entry must not start with a wildcard")
+		}
+		return refs[entry], nil
+	}
+	format := func(refs []protocol.Location) string {
+		return fmt.Sprintf("%d refs\n", len(refs))
+	}
+
+	text := formatWatchlistUsages([]string{"exec.Command", "os.*", "eval", " ", "*.Exec"}, lookup, format)
+	assert.Equal(t, "Security watchlist: 2 of 4 entries have usages\n\n"+
+		"=== exec.Command (2 usages) ===\n2 refs\n\n"+
+		"=== os.* (1 usages) ===\n1 refs\n\n"+
+		"=== *.Exec ===\nError: watchlist entry must not start with a wildcard\n\n"+
+		"No usages found: eval\n", text)
+
+	t.Run("nothing found", func(t *testing.T) {
+		text := formatWatchlistUsages([]string{"eval"}, lookup, format)
+		assert.Equal(t, "Security watchlist: 0 of 1 entries have usages\n\nNo usages found: eval\n", text)
+	})
+
+	t.Run("only errors", func(t *testing.T) {
+		text := formatWatchlistUsages([]string{"*.Exec"}, lookup, format)
+		assert.Contains(t, text, "Security watchlist: 0 of 1 entries have usages\n")
+	})
+}
+
+func TestWatchlistSymbolMatches(t *testing.T) {
+	tests := []struct {
+		entry string
+		name  string
+		want  bool
+	}{
+		{"exec.Command", "exec.Command", true},
+		{"exec.Command", "Command", true},
+		{"exec.Command", "CommandContext", false},
+		{"eval", "eval", true},
+		{"eval", "evaluate", false},
+		{"exec.*", "exec.CommandContext", true},
+		{"exec.*", "os.Exec", false},
+		{"str*cpy", "strncpy", true},
+		{"str*cpy", "strcat", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, watchlistSymbolMatches(tt.entry, tt.name), "%s matching %s", tt.entry, tt.name)
+	}
+}
@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// GetSelectionRanges lists the nested syntactic ranges containing a position,
+// from the innermost, such as an identifier or expression, out to the
+// statement, function and file, as an editor's expand selection would. Each
+// range is given with 1-indexed positions that can be passed to edit tools.
+func GetSelectionRanges(ctx context.Context, client *lsp.Client, filePath string, line, column int) (string, error) {
+	uri, err := openDocument(ctx, client, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	ranges, err := client.SelectionRange(ctx, protocol.SelectionRangeParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Positions: []protocol.Position{{
+			Line:      uint32(line - 1),
+			Character: uint32(column - 1),
+		}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get selection ranges: %v", err)
+	}
+	if len(ranges) == 0 {
+		return msg(MsgNoSelectionRanges, filePath, line, column), nil
+	}
+
+	content, err := documentText(ctx, client, uri)
+	if err != nil {
+		return "", fmt.Errorf("could not read document: %v", err)
+	}
+
+	return formatSelectionRanges(filePath, line, column, strings.Split(content, "\n"), ranges[0]), nil
+}
+
+// formatSelectionRanges lists a selection range and its parents, innermost
+// first, each with a preview of its text
+func formatSelectionRanges(filePath string, line, column int, lines []string, selection protocol.SelectionRange) string {
+	var entries []string
+	var previous *protocol.Range
+	for r := &selection; r != nil; r = r.Parent {
+		// Servers may repeat a range for nodes that span the same text
+		if previous != nil && *previous == r.Range {
+			continue
+		}
+		previous = &r.Range
+
+		start, end := r.Range.Start, r.Range.End
+		entries = append(entries, fmt.Sprintf("%d. L%d:C%d-L%d:C%d (%d lines): %s",
+			len(entries)+1,
+			start.Line+1, start.Character+1, end.Line+1, end.Character+1,
+			end.Line-start.Line+1,
+			selectionPreview(lines, r.Range),
+		))
+	}
+	return msg(MsgSelectionRangesHeader, len(entries), filePath, line, column) + "\n" + strings.Join(entries, "\n") + "\n"
+}
+
+// selectionPreview shows the text of a range on one line: all of it if it
+// fits, and otherwise its start and end around an ellipsis
+func selectionPreview(lines []string, r protocol.Range) string {
+	if int(r.Start.Line) >= len(lines) {
+		return ""
+	}
+	first := lines[r.Start.Line]
+	if r.Start.Line == r.End.Line {
+		if text := rangeText(lines, r); text != "" {
+			first = text
+		}
+		return truncatePreview(strings.TrimSpace(first))
+	}
+
+	if int(r.Start.Character) <= len(first) {
+		first = first[r.Start.Character:]
+	}
+	last := ""
+	if int(r.End.Line) < len(lines) {
+		last = lines[r.End.Line]
+		if int(r.End.Character) <= len(last) {
+			last = last[:r.End.Character]
+		}
+	}
+	return truncatePreview(strings.TrimSpace(first)) + " ... " + truncatePreview(strings.TrimSpace(last))
+}
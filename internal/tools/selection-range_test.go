@@ -0,0 +1,33 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatSelectionRanges(t *testing.T) {
+	lines := strings.Split(`func add(a, b int) int {
+	return a + b
+}`, "\n")
+
+	span := func(startLine, startChar, endLine, endChar uint32) protocol.Range {
+		return protocol.Range{
+			Start: protocol.Position{Line: startLine, Character: startChar},
+			End:   protocol.Position{Line: endLine, Character: endChar},
+		}
+	}
+	function := &protocol.SelectionRange{Range: span(0, 0, 2, 1)}
+	statement := &protocol.SelectionRange{Range: span(1, 1, 1, 13), Parent: function}
+	expression := &protocol.SelectionRange{Range: span(1, 8, 1, 13), Parent: statement}
+	// Identifiers and the expressions made of them alone share a range
+	identifier := protocol.SelectionRange{Range: span(1, 8, 1, 13), Parent: expression}
+
+	assert.Equal(t, `3 nested ranges at /ws/add.go:2:10, innermost first:
+1. L2:C9-L2:C14 (1 lines): a + b
+2. L2:C2-L2:C14 (1 lines): return a + b
+3. L1:C1-L3:C2 (3 lines): func add(a, b int) int { ... }
+`, formatSelectionRanges("/ws/add.go", 2, 10, lines, identifier))
+}
@@ -0,0 +1,141 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// semanticToken is a decoded semantic token. Positions are 0-indexed and in
+// UTF-16 code units, like the protocol's.
+type semanticToken struct {
+	Line      uint32
+	Start     uint32
+	Length    uint32
+	Type      string
+	Modifiers []string
+}
+
+// GetSemanticTokens classifies the tokens in a range of lines of a file, e.g.
+// to tell a parameter from a field with the same name. Each line is shown
+// followed by its tokens as text:type, with modifiers such as declaration or
+// readonly appended after dots. Lines without tokens are left out.
+func GetSemanticTokens(ctx context.Context, client *lsp.Client, filePath string, startLine, endLine int) (string, error) {
+	result := client.InitializeResult()
+	if result == nil {
+		return "", fmt.Errorf("the language server is not initialized")
+	}
+	options, ok := lsp.SemanticTokensOptions(result.Capabilities)
+	if !ok {
+		return "", fmt.Errorf("the language server does not support semantic tokens")
+	}
+
+	uri, err := openDocument(ctx, client, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := documentText(ctx, client, uri)
+	if err != nil {
+		return "", fmt.Errorf("could not read document: %v", err)
+	}
+	lines := strings.Split(content, "\n")
+	if startLine < 1 || startLine > len(lines) || endLine < startLine {
+		return "", fmt.Errorf("invalid line range %d-%d for a file with %d lines", startLine, endLine, len(lines))
+	}
+	endLine = min(endLine, len(lines))
+
+	var tokens protocol.SemanticTokens
+	if client.SupportsMethod("textDocument/semanticTokens/range") {
+		tokens, err = client.SemanticTokensRange(ctx, protocol.SemanticTokensRangeParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Range: protocol.Range{
+				Start: protocol.Position{Line: uint32(startLine - 1)},
+				End: protocol.Position{
+					Line:      uint32(endLine - 1),
+					Character: uint32(len(utf16.Encode([]rune(lines[endLine-1])))),
+				},
+			},
+		})
+	} else {
+		tokens, err = client.SemanticTokensFull(ctx, protocol.SemanticTokensParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		})
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get semantic tokens: %v", err)
+	}
+
+	tokensByLine := make(map[uint32][]semanticToken)
+	count := 0
+	for _, token := range decodeSemanticTokens(tokens.Data, options.Legend) {
+		if token.Line < uint32(startLine-1) || token.Line >= uint32(endLine) {
+			continue
+		}
+		tokensByLine[token.Line] = append(tokensByLine[token.Line], token)
+		count++
+	}
+	if count == 0 {
+		return msg(MsgNoSemanticTokens, filePath, startLine, endLine), nil
+	}
+
+	var out strings.Builder
+	out.WriteString(msg(MsgSemanticTokensHeader, count, filePath, startLine, endLine) + "\n")
+	for line := startLine - 1; line < endLine; line++ {
+		lineTokens := tokensByLine[uint32(line)]
+		if len(lineTokens) == 0 {
+			continue
+		}
+		text := strings.TrimSuffix(lines[line], "\r")
+		out.WriteString(fmt.Sprintf("L%d: %s\n", line+1, strings.TrimSpace(text)))
+		out.WriteString("    " + formatSemanticTokens(text, lineTokens) + "\n")
+	}
+	return out.String(), nil
+}
+
+// decodeSemanticTokens decodes the relative encoding of semantic tokens: five
+// integers per token giving the line relative to the previous token, the
+// start character relative to the previous token if on the same line, the
+// length, the index of the type in the legend and a bit set of modifiers.
+func decodeSemanticTokens(data []uint32, legend protocol.SemanticTokensLegend) []semanticToken {
+	var tokens []semanticToken
+	var line, start uint32
+	for i := 0; i+4 < len(data); i += 5 {
+		if data[i] > 0 {
+			line += data[i]
+			start = 0
+		}
+		start += data[i+1]
+
+		token := semanticToken{Line: line, Start: start, Length: data[i+2]}
+		if typeIndex := int(data[i+3]); typeIndex < len(legend.TokenTypes) {
+			token.Type = legend.TokenTypes[typeIndex]
+		} else {
+			token.Type = fmt.Sprintf("type%d", typeIndex)
+		}
+		for bit, modifier := range legend.TokenModifiers {
+			if data[i+4]&(1<<bit) != 0 {
+				token.Modifiers = append(token.Modifiers, modifier)
+			}
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// formatSemanticTokens lists the tokens of a line as text:type.modifier
+func formatSemanticTokens(line string, tokens []semanticToken) string {
+	units := utf16.Encode([]rune(line))
+	parts := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		start := min(int(token.Start), len(units))
+		end := min(start+int(token.Length), len(units))
+		text := string(utf16.Decode(units[start:end]))
+		parts = append(parts, text+":"+strings.Join(append([]string{token.Type}, token.Modifiers...), "."))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeSemanticTokens(t *testing.T) {
+	legend := protocol.SemanticTokensLegend{
+		TokenTypes:     []string{"parameter", "property", "method"},
+		TokenModifiers: []string{"declaration", "readonly"},
+	}
+	data := []uint32{
+		2, 5, 4, 2, 1, // line 2, char 5
+		0, 6, 3, 0, 3, // line 2, char 11
+		1, 2, 3, 1, 0, // line 3, char 2
+		0, 4, 1, 7, 0, // type outside the legend
+	}
+
+	assert.Equal(t, []semanticToken{
+		{Line: 2, Start: 5, Length: 4, Type: "method", Modifiers: []string{"declaration"}},
+		{Line: 2, Start: 11, Length: 3, Type: "parameter", Modifiers: []string{"declaration", "readonly"}},
+		{Line: 3, Start: 2, Length: 3, Type: "property"},
+		{Line: 3, Start: 6, Length: 1, Type: "type7"},
+	}, decodeSemanticTokens(data, legend))
+}
+
+func TestFormatSemanticTokens(t *testing.T) {
+	tokens := []semanticToken{
+		{Start: 0, Length: 1, Type: "variable"},
+		{Start: 4, Length: 4, Type: "parameter", Modifiers: []string{"readonly"}},
+	}
+	assert.Equal(t, "x:variable name:parameter.readonly", formatSemanticTokens("x = name", tokens))
+
+	// Positions count UTF-16 code units
+	tokens = []semanticToken{{Start: 3, Length: 1, Type: "variable"}}
+	assert.Equal(t, "y:variable", formatSemanticTokens("🙂.y", tokens))
+}
@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// ServerInfo describes a language server: its name and version, the LSP
+// methods it supports and does not, the tools that rely on methods it does
+// not support, and the capabilities it returned from initialize. toolMethods
+// maps the available tools to the LSP methods they use.
+func ServerInfo(client *lsp.Client, label string, toolMethods map[string][]string) (string, error) {
+	result := client.InitializeResult()
+	if result == nil {
+		return "", fmt.Errorf("%s has not finished initializing", label)
+	}
+	return formatServerInfo(label, result, toolMethods)
+}
+
+// formatServerInfo describes a server from the result of its initialize
+// request
+func formatServerInfo(label string, result *protocol.InitializeResult, toolMethods map[string][]string) (string, error) {
+	name := label
+	if result.ServerInfo != nil {
+		name = strings.TrimSpace(result.ServerInfo.Name + " " + result.ServerInfo.Version)
+	}
+
+	var supported, unsupported []string
+	for _, method := range lsp.CapabilityMethods {
+		if lsp.MethodSupported(result.Capabilities, method) {
+			supported = append(supported, method)
+		} else {
+			unsupported = append(unsupported, method)
+		}
+	}
+
+	var unavailable []string
+	for tool, methods := range toolMethods {
+		var missing []string
+		for _, method := range methods {
+			if !lsp.MethodSupported(result.Capabilities, method) {
+				missing = append(missing, method)
+			}
+		}
+		if len(missing) > 0 {
+			unavailable = append(unavailable, fmt.Sprintf("%s (%s)", tool, strings.Join(missing, ", ")))
+		}
+	}
+	sort.Strings(unavailable)
+
+	capabilities, err := json.MarshalIndent(result.Capabilities, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal capabilities: %v", err)
+	}
+
+	var out strings.Builder
+	out.WriteString(msg(MsgServerInfoName, name, label) + "\n")
+	out.WriteString("\n" + msg(MsgServerInfoSupported, len(supported)) + "\n")
+	for _, method := range supported {
+		out.WriteString("  " + method + "\n")
+	}
+	if len(unsupported) > 0 {
+		out.WriteString("\n" + msg(MsgServerInfoUnsupported, len(unsupported)) + "\n")
+		for _, method := range unsupported {
+			out.WriteString("  " + method + "\n")
+		}
+	}
+	if len(unavailable) > 0 {
+		out.WriteString("\n" + msg(MsgServerInfoUnavailable) + "\n")
+		for _, tool := range unavailable {
+			out.WriteString("  " + tool + "\n")
+		}
+	}
+	out.WriteString("\n" + msg(MsgServerInfoCapabilities) + "\n")
+	out.Write(capabilities)
+	out.WriteString("\n")
+	return out.String(), nil
+}
@@ -0,0 +1,38 @@
+package tools
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatServerInfo(t *testing.T) {
+	var result protocol.InitializeResult
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"capabilities": {"hoverProvider": true, "definitionProvider": true, "renameProvider": false},
+		"serverInfo": {"name": "gopls", "version": "v0.16.0"}
+	}`), &result))
+
+	text, err := formatServerInfo("gopls, main", &result, map[string][]string{
+		"hover":         {"textDocument/hover"},
+		"rename_symbol": {"textDocument/rename"},
+		"call_path":     {"workspace/symbol", "textDocument/prepareCallHierarchy"},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(text, "Server: gopls v0.16.0 (gopls, main)\n"))
+	supported, rest, _ := strings.Cut(text, "Unsupported methods")
+	assert.Contains(t, supported, "  textDocument/hover\n")
+	assert.Contains(t, supported, "  textDocument/definition\n")
+	assert.NotContains(t, supported, "textDocument/rename")
+	assert.Contains(t, rest, "  textDocument/rename\n")
+	assert.Contains(t, rest, "Tools that will fail, with the methods they need that are not supported:\n"+
+		"  call_path (workspace/symbol, textDocument/prepareCallHierarchy)\n"+
+		"  rename_symbol (textDocument/rename)\n")
+	assert.Contains(t, rest, "Capabilities returned from initialize:\n{")
+	assert.Contains(t, rest, `"hoverProvider": true`)
+}
@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// logSeverities ranks the severities get_server_logs filters by, most severe
+// first. Traces rank below every log message.
+var logSeverities = map[string]int{
+	"error":   int(protocol.Error),
+	"warning": int(protocol.Warning),
+	"info":    int(protocol.Info),
+	"log":     int(protocol.Log),
+	"debug":   int(protocol.Debug),
+	"trace":   int(protocol.Debug) + 1,
+}
+
+// ServerLogs returns the last tail log and trace messages of a server at or
+// above a severity: error, warning, info, log, debug or trace
+func ServerLogs(client *lsp.Client, label, severity string, tail int) (string, error) {
+	return formatServerLogs(label, client.ServerLogs(), severity, tail)
+}
+
+// formatServerLogs filters log entries by severity and formats the last tail
+// of them
+func formatServerLogs(label string, entries []lsp.LogEntry, severity string, tail int) (string, error) {
+	threshold, ok := logSeverities[severity]
+	if !ok {
+		return "", fmt.Errorf("unknown severity %q, expected error, warning, info, log, debug or trace", severity)
+	}
+	if tail <= 0 {
+		return "", fmt.Errorf("tail must be positive")
+	}
+
+	var matched []lsp.LogEntry
+	for _, entry := range entries {
+		if logSeverities[logSeverity(entry)] <= threshold {
+			matched = append(matched, entry)
+		}
+	}
+	if len(matched) == 0 {
+		return msg(MsgServerLogsEmpty, label, severity), nil
+	}
+	total := len(matched)
+	if total > tail {
+		matched = matched[total-tail:]
+	}
+
+	var out strings.Builder
+	out.WriteString(msg(MsgServerLogsHeader, label, severity, len(matched), total) + "\n")
+	for _, entry := range matched {
+		out.WriteString(fmt.Sprintf("%s [%s] %s\n", entry.Time.Format("15:04:05.000"), logSeverity(entry), entry.Message))
+		for _, line := range strings.Split(strings.TrimRight(entry.Verbose, "\n"), "\n") {
+			if line != "" {
+				out.WriteString("    " + line + "\n")
+			}
+		}
+	}
+	return out.String(), nil
+}
+
+// logSeverity names the severity of a log entry
+func logSeverity(entry lsp.LogEntry) string {
+	if entry.Trace {
+		return "trace"
+	}
+	switch entry.Type {
+	case protocol.Error:
+		return "error"
+	case protocol.Warning:
+		return "warning"
+	case protocol.Info:
+		return "info"
+	case protocol.Debug:
+		return "debug"
+	default:
+		return "log"
+	}
+}
@@ -0,0 +1,42 @@
+package tools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatServerLogs(t *testing.T) {
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	entries := []lsp.LogEntry{
+		{Time: at, Type: protocol.Info, Message: "Loading packages"},
+		{Time: at.Add(time.Second), Type: protocol.Error, Message: "go list failed"},
+		{Time: at.Add(2 * time.Second), Trace: true, Message: "Sending request", Verbose: "params: {}\n"},
+		{Time: at.Add(3 * time.Second), Type: protocol.Warning, Message: "no module found"},
+	}
+
+	text, err := formatServerLogs("gopls", entries, "warning", 50)
+	require.NoError(t, err)
+	assert.Equal(t, "Log messages from gopls at warning severity or above (last 2 of 2):\n"+
+		"12:00:01.000 [error] go list failed\n"+
+		"12:00:03.000 [warning] no module found\n", text)
+
+	// Traces are only shown at trace severity, with their detail indented
+	text, err = formatServerLogs("gopls", entries, "trace", 2)
+	require.NoError(t, err)
+	assert.Equal(t, "Log messages from gopls at trace severity or above (last 2 of 4):\n"+
+		"12:00:02.000 [trace] Sending request\n"+
+		"    params: {}\n"+
+		"12:00:03.000 [warning] no module found\n", text)
+
+	text, err = formatServerLogs("gopls", entries[:1], "error", 50)
+	require.NoError(t, err)
+	assert.Equal(t, "No log messages from gopls at error severity or above.", text)
+
+	_, err = formatServerLogs("gopls", entries, "fatal", 50)
+	assert.ErrorContains(t, err, "unknown severity")
+}
@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+)
+
+const (
+	// minSnippetLines is the size below which snippets are always returned
+	// in full, as a reference would save little
+	minSnippetLines = 8
+	// maxSnippets is the number of snippets remembered, the oldest being
+	// forgotten first
+	maxSnippets = 2000
+)
+
+// SnippetStore remembers the code snippets returned in a session by content
+// hash, so that a snippet returned again can be replaced by its hash
+type SnippetStore struct {
+	mu       sync.Mutex
+	snippets map[string]string
+	// Hashes in the order they were added
+	order []string
+}
+
+// snippets is the store used to deduplicate output. It is nil unless
+// deduplication is enabled, and is only set at startup.
+var snippets *SnippetStore
+
+// EnableSnippetDedup makes definitions and references return a short
+// reference instead of a large snippet that was already returned in the
+// session. The snippet can be fetched again by its hash with FetchSnippet.
+func EnableSnippetDedup() {
+	snippets = &SnippetStore{snippets: make(map[string]string)}
+}
+
+// snippetHash returns a short content hash of a snippet
+func snippetHash(snippet string) string {
+	sum := sha256.Sum256([]byte(snippet))
+	return hex.EncodeToString(sum[:6])
+}
+
+// dedupe returns a snippet the first time it is seen and a reference to it
+// afterwards. Small snippets are always returned.
+func (s *SnippetStore) dedupe(snippet string) string {
+	if s == nil {
+		return snippet
+	}
+	lines := strings.Count(strings.TrimSuffix(snippet, "\n"), "\n") + 1
+	if lines < minSnippetLines {
+		return snippet
+	}
+
+	hash := snippetHash(snippet)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.snippets[hash]; ok {
+		return msg(MsgSnippetSeen, hash, lines) + "\n"
+	}
+	s.snippets[hash] = snippet
+	s.order = append(s.order, hash)
+	if len(s.order) > maxSnippets {
+		delete(s.snippets, s.order[0])
+		s.order = s.order[1:]
+	}
+	return snippet
+}
+
+// fetch returns a snippet by its hash
+func (s *SnippetStore) fetch(hash string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snippet, ok := s.snippets[strings.TrimSpace(hash)]
+	return snippet, ok
+}
+
+// FetchSnippet returns a snippet that an earlier result referred to by hash
+func FetchSnippet(hash string) (string, bool) {
+	return snippets.fetch(hash)
+}
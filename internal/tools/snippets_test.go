@@ -0,0 +1,30 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnippetStore(t *testing.T) {
+	store := &SnippetStore{snippets: make(map[string]string)}
+	large := strings.Repeat("line\n", minSnippetLines)
+	small := "func f() {}\n"
+
+	assert.Equal(t, large, store.dedupe(large))
+	assert.Equal(t, msg(MsgSnippetSeen, snippetHash(large), minSnippetLines)+"\n", store.dedupe(large))
+	assert.Equal(t, small, store.dedupe(small))
+	assert.Equal(t, small, store.dedupe(small))
+
+	snippet, ok := store.fetch(snippetHash(large))
+	assert.True(t, ok)
+	assert.Equal(t, large, snippet)
+	_, ok = store.fetch("000000000000")
+	assert.False(t, ok)
+
+	// Without a store, deduplication is disabled
+	var disabled *SnippetStore
+	assert.Equal(t, large, disabled.dedupe(large))
+	assert.Equal(t, large, disabled.dedupe(large))
+}
@@ -0,0 +1,111 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// templateData documents the data passed to each tool's output template
+var templateData = map[string]string{
+	"references":             "ReferencesOutput",
+	"references_at_position": "ReferencesOutput",
+	"diagnostics":            "DiagnosticsOutput",
+}
+
+// templateFuncs are available in output templates in addition to the text/template builtins
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+	"add":  func(a, b int) int { return a + b },
+}
+
+// outputTemplates holds the parsed output templates, keyed by tool name. It is
+// only modified at startup by SetOutputTemplates, before any tool is called.
+var outputTemplates = map[string]*template.Template{}
+
+// SetOutputTemplates replaces the default output format of tools with Go
+// text/template templates, keyed by tool name. Tools without a template keep
+// their default format.
+func SetOutputTemplates(templates map[string]string) error {
+	parsed := make(map[string]*template.Template, len(templates))
+	var unknown []string
+	for name, text := range templates {
+		if _, ok := templateData[name]; !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+
+		tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+		if err != nil {
+			return fmt.Errorf("invalid template for %s: %v", name, err)
+		}
+		parsed[name] = tmpl
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("templates are not supported for: %s", strings.Join(unknown, ", "))
+	}
+
+	outputTemplates = parsed
+	return nil
+}
+
+// outputFormatKey holds the format tools return their results in, if not
+// the default text
+type outputFormatKey struct{}
+
+// WithJSONOutput returns a context that makes tools with structured output,
+// those in templateData and the definition and symbol search tools, return
+// it as JSON rather than text
+func WithJSONOutput(ctx context.Context) context.Context {
+	return context.WithValue(ctx, outputFormatKey{}, "json")
+}
+
+// JSONOutput reports whether tools called with ctx return JSON
+func JSONOutput(ctx context.Context) bool {
+	format, _ := ctx.Value(outputFormatKey{}).(string)
+	return format == "json"
+}
+
+// WithMarkdownOutput returns a context that makes the tools in templateData
+// and the definition tool return Markdown, with a header per file and code
+// in fenced blocks, rather than text
+func WithMarkdownOutput(ctx context.Context) context.Context {
+	return context.WithValue(ctx, outputFormatKey{}, "markdown")
+}
+
+// MarkdownOutput reports whether tools called with ctx return Markdown
+func MarkdownOutput(ctx context.Context) bool {
+	format, _ := ctx.Value(outputFormatKey{}).(string)
+	return format == "markdown"
+}
+
+// renderTemplate renders data as JSON or Markdown if ctx asks for it, or
+// else with the output template of a tool. It reports false if none applies.
+func renderTemplate(ctx context.Context, name string, data any) (string, bool, error) {
+	if JSONOutput(ctx) {
+		result, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return "", true, fmt.Errorf("failed to marshal %s output: %v", name, err)
+		}
+		return string(result), true, nil
+	}
+	if MarkdownOutput(ctx) {
+		result, ok := renderMarkdown(data)
+		return result, ok, nil
+	}
+
+	tmpl, ok := outputTemplates[name]
+	if !ok {
+		return "", false, nil
+	}
+
+	var result strings.Builder
+	if err := tmpl.Execute(&result, data); err != nil {
+		return "", true, fmt.Errorf("failed to render %s template: %v", name, err)
+	}
+	return result.String(), true, nil
+}
@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetOutputTemplates(t *testing.T) {
+	defer func() { outputTemplates = map[string]*template.Template{} }()
+
+	err := SetOutputTemplates(map[string]string{
+		"references": `{{.Target}}:{{range .Files}} {{.Path}}({{len .References}}){{end}}`,
+	})
+	assert.NoError(t, err)
+
+	text, ok, err := renderTemplate(context.Background(), "references", ReferencesOutput{
+		Target: "Foo",
+		Files: []ReferenceFile{
+			{Path: "/ws/a.go", References: []SourcePosition{{Line: 1, Column: 2}}},
+			{Path: "/ws/b.go", References: []SourcePosition{{Line: 3, Column: 4}, {Line: 5, Column: 6}}},
+		},
+	})
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, "Foo: /ws/a.go(1) /ws/b.go(2)", text)
+
+	// Tools without a template use the default format
+	_, ok, _ = renderTemplate(context.Background(), "diagnostics", DiagnosticsOutput{})
+	assert.False(t, ok)
+}
+
+func TestSetOutputTemplatesErrors(t *testing.T) {
+	defer func() { outputTemplates = map[string]*template.Template{} }()
+
+	err := SetOutputTemplates(map[string]string{"hover": "{{.}}"})
+	assert.ErrorContains(t, err, "hover")
+
+	err = SetOutputTemplates(map[string]string{"diagnostics": "{{.Path"})
+	assert.ErrorContains(t, err, "invalid template for diagnostics")
+
+	// Execution errors are reported when rendering
+	err = SetOutputTemplates(map[string]string{"diagnostics": "{{.Missing}}"})
+	assert.NoError(t, err)
+	_, ok, err := renderTemplate(context.Background(), "diagnostics", DiagnosticsOutput{})
+	assert.True(t, ok)
+	assert.Error(t, err)
+}
+
+func TestJSONOutput(t *testing.T) {
+	ctx := WithJSONOutput(context.Background())
+	assert.True(t, JSONOutput(ctx))
+	assert.False(t, JSONOutput(context.Background()))
+
+	text, ok, err := renderTemplate(ctx, "references", ReferencesOutput{
+		Target: "Foo",
+		Files:  []ReferenceFile{{Path: "/ws/a.go", References: []SourcePosition{{Line: 1, Column: 2}}, Snippet: "1|foo"}},
+	})
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"target": "Foo", "files": [{"path": "/ws/a.go", "references": [{"line": 1, "column": 2}], "snippet": "1|foo"}]}`, text)
+}
@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SnippetTrimming configures the removal of lines that carry no executable
+// structure from the snippets returned by definitions, references and
+// diagnostics, for agents on a token budget
+type SnippetTrimming struct {
+	// StripComments removes lines that only hold a comment
+	StripComments bool `json:"stripComments"`
+	// CollapseBlankLines reduces runs of blank lines to one
+	CollapseBlankLines bool `json:"collapseBlankLines"`
+}
+
+// snippetTrimming is the active trimming configuration. It is only set at
+// startup by SetSnippetTrimming.
+var snippetTrimming SnippetTrimming
+
+// SetSnippetTrimming sets how snippets are trimmed
+func SetSnippetTrimming(trimming SnippetTrimming) {
+	snippetTrimming = trimming
+}
+
+// commentSyntax describes the comments of a language
+type commentSyntax struct {
+	line  []string
+	block bool
+}
+
+var (
+	cComments       = commentSyntax{line: []string{"//"}, block: true}
+	hashComments    = commentSyntax{line: []string{"#"}}
+	dashComments    = commentSyntax{line: []string{"--"}}
+	commentSyntaxes = map[string]commentSyntax{
+		".go": cComments, ".c": cComments, ".h": cComments, ".cc": cComments, ".cpp": cComments,
+		".hpp": cComments, ".java": cComments, ".kt": cComments, ".scala": cComments, ".cs": cComments,
+		".js": cComments, ".jsx": cComments, ".ts": cComments, ".tsx": cComments, ".rs": cComments,
+		".swift": cComments, ".dart": cComments, ".proto": cComments, ".zig": {line: []string{"//"}},
+		".php": {line: []string{"//", "#"}, block: true},
+		".py":  hashComments, ".rb": hashComments, ".sh": hashComments, ".bash": hashComments,
+		".yaml": hashComments, ".yml": hashComments, ".toml": hashComments, ".r": hashComments,
+		".ex": hashComments, ".exs": hashComments, ".nix": hashComments, ".pl": hashComments,
+		".sql": dashComments, ".lua": dashComments, ".hs": dashComments,
+		".el": {line: []string{";"}}, ".clj": {line: []string{";"}},
+	}
+)
+
+// numberedLine matches a line of a snippet with line numbers, capturing the
+// line number and the code
+var numberedLine = regexp.MustCompile(`^\s*(\d+)\|(.*)$`)
+
+// trimSnippet removes comment lines and extra blank lines from a snippet
+// with line numbers, as configured, and notes how many were removed. Lines
+// in keep, such as those with a reference or a diagnostic, are never
+// removed. Snippets of files whose comment syntax is unknown only have
+// their blank lines collapsed.
+func trimSnippet(filePath, snippet string, keep map[int]bool) string {
+	if !snippetTrimming.StripComments && !snippetTrimming.CollapseBlankLines {
+		return snippet
+	}
+	syntax, known := commentSyntaxes[strings.ToLower(filepath.Ext(filePath))]
+	stripComments := snippetTrimming.StripComments && known
+
+	var out strings.Builder
+	comments, blanks := 0, 0
+	inBlock, previousBlank := false, false
+	for _, line := range strings.SplitAfter(snippet, "\n") {
+		match := numberedLine.FindStringSubmatch(strings.TrimSuffix(line, "\n"))
+		if match == nil {
+			// Separators between ranges end any block comment
+			inBlock, previousBlank = false, false
+			out.WriteString(line)
+			continue
+		}
+		number, _ := strconv.Atoi(match[1])
+		code := strings.TrimSpace(match[2])
+
+		isComment := false
+		if stripComments {
+			isComment, inBlock = commentLine(code, syntax, inBlock)
+		}
+		isBlank := code == ""
+
+		switch {
+		case keep[number]:
+		case isComment:
+			comments++
+			continue
+		case isBlank && snippetTrimming.CollapseBlankLines && previousBlank:
+			blanks++
+			continue
+		}
+		previousBlank = isBlank
+		out.WriteString(line)
+	}
+
+	if comments == 0 && blanks == 0 {
+		return snippet
+	}
+	return out.String() + msg(MsgSnippetTrimmed, comments, blanks) + "\n"
+}
+
+// commentLine reports whether a trimmed line only holds a comment, and
+// whether a block comment is still open after it
+func commentLine(code string, syntax commentSyntax, inBlock bool) (bool, bool) {
+	if inBlock {
+		end := strings.Index(code, "*/")
+		if end < 0 {
+			return true, true
+		}
+		return strings.TrimSpace(code[end+2:]) == "", false
+	}
+	for _, prefix := range syntax.line {
+		if strings.HasPrefix(code, prefix) {
+			return true, false
+		}
+	}
+	if syntax.block && strings.HasPrefix(code, "/*") {
+		end := strings.Index(code[2:], "*/")
+		if end < 0 {
+			return true, true
+		}
+		return strings.TrimSpace(code[2+end+2:]) == "", false
+	}
+	return false, false
+}
@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrimSnippet(t *testing.T) {
+	snippet := `   1|// Package store holds users
+   2|package store
+   3|
+   4|
+   5|/*
+   6| * LoadUser loads a user
+   7| */
+   8|func LoadUser(id string) (*User, error) {
+   9|	// TODO: cache
+  10|	return load(id) // inline comments are kept
+  11|}
+...
+  20|// Deprecated: use LoadUser
+  21|func Load() {}
+`
+
+	defer SetSnippetTrimming(SnippetTrimming{})
+
+	SetSnippetTrimming(SnippetTrimming{})
+	assert.Equal(t, snippet, trimSnippet("store.go", snippet, nil))
+
+	SetSnippetTrimming(SnippetTrimming{StripComments: true, CollapseBlankLines: true})
+	assert.Equal(t, `   2|package store
+   3|
+   8|func LoadUser(id string) (*User, error) {
+  10|	return load(id) // inline comments are kept
+  11|}
+...
+  20|// Deprecated: use LoadUser
+  21|func Load() {}
+(5 comment lines and 1 blank lines removed)
+`, trimSnippet("store.go", snippet, map[int]bool{20: true}))
+
+	// Without a known comment syntax only blank lines are collapsed
+	SetSnippetTrimming(SnippetTrimming{StripComments: true, CollapseBlankLines: true})
+	trimmed := trimSnippet("store.unknown", snippet, nil)
+	assert.Contains(t, trimmed, "// Package store")
+	assert.Contains(t, trimmed, "(0 comment lines and 1 blank lines removed)")
+
+	// Nothing removed leaves the snippet unchanged
+	SetSnippetTrimming(SnippetTrimming{CollapseBlankLines: true})
+	assert.Equal(t, "   1|x := 1\n", trimSnippet("main.go", "   1|x := 1\n", nil))
+}
+
+func TestTrimSnippetHashComments(t *testing.T) {
+	defer SetSnippetTrimming(SnippetTrimming{})
+	SetSnippetTrimming(SnippetTrimming{StripComments: true})
+
+	assert.Equal(t, "   2|def load():\n   3|    return 1\n(1 comment lines and 0 blank lines removed)\n",
+		trimSnippet("store.py", "   1|# load a value\n   2|def load():\n   3|    return 1\n", nil))
+}
@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// GoToTypeDefinition finds the definition of the type of the symbol at the
+// given position, e.g. the struct a variable holds rather than the variable.
+// Line and column are 1-indexed (will be converted to 0-indexed for LSP protocol).
+func GoToTypeDefinition(ctx context.Context, client *lsp.Client, filePath string, line, column int) (string, error) {
+	contextLines := ContextLines()
+
+	// Open the document if not already open
+	uri, err := openDocument(ctx, client, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	// Convert 1-indexed line/column to 0-indexed for LSP protocol
+	result, err := client.TypeDefinition(ctx, protocol.TypeDefinitionParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{
+				URI: uri,
+			},
+			Position: protocol.Position{
+				Line:      uint32(line - 1),
+				Character: uint32(column - 1),
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get type definition: %v", err)
+	}
+
+	locations := definitionLocations(result.Value)
+	if len(locations) == 0 {
+		return msg(MsgNoTypeDefinition, filePath, line, column), nil
+	}
+
+	definitions := formatDefinitionLocations(ctx, client, locations, contextLines)
+	if len(definitions) == 0 {
+		return msg(MsgCouldNotReadDefinition, filePath, line, column), nil
+	}
+
+	return strings.Join(definitions, ""), nil
+}
@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// maxTypeTreeNodes caps the number of types listed in each direction by GetTypeHierarchy
+const maxTypeTreeNodes = 200
+
+// Type hierarchy directions accepted by GetTypeHierarchy
+const (
+	TypeHierarchySupertypes = "supertypes"
+	TypeHierarchySubtypes   = "subtypes"
+	TypeHierarchyBoth       = "both"
+)
+
+// GetTypeHierarchy lists the supertypes and/or subtypes of the type at the
+// given position, recursing up to depth levels in each direction.
+// Line and column are 1-indexed (will be converted to 0-indexed for LSP protocol).
+func GetTypeHierarchy(ctx context.Context, client *lsp.Client, filePath string, line, column int, direction string, depth int) (string, error) {
+	if direction != TypeHierarchySupertypes && direction != TypeHierarchySubtypes && direction != TypeHierarchyBoth {
+		return "", fmt.Errorf("unknown direction %q", direction)
+	}
+
+	// Open the document if not already open
+	uri, err := openDocument(ctx, client, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	// Convert 1-indexed line/column to 0-indexed for LSP protocol
+	items, err := client.PrepareTypeHierarchy(ctx, protocol.TypeHierarchyPrepareParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{
+				URI: uri,
+			},
+			Position: protocol.Position{
+				Line:      uint32(line - 1),
+				Character: uint32(column - 1),
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to prepare type hierarchy: %v", err)
+	}
+	if len(items) == 0 {
+		return msg(MsgNoTypeHierarchy, fmt.Sprintf("%s:%d:%d", filePath, line, column)), nil
+	}
+
+	var result strings.Builder
+	for i, item := range items {
+		if i > 0 {
+			result.WriteString("\n")
+		}
+		result.WriteString(msg(MsgTypeHierarchyHeader, item.Name, formatTypeHierarchyLocation(item)) + "\n")
+
+		for _, dir := range []string{TypeHierarchySupertypes, TypeHierarchySubtypes} {
+			if direction != TypeHierarchyBoth && direction != dir {
+				continue
+			}
+
+			tree := &typeTree{ctx: ctx, client: client, direction: dir}
+			var section strings.Builder
+			if err := tree.write(&section, item, []string{typeHierarchyItemKey(item)}, 1, depth); err != nil {
+				return "", err
+			}
+
+			result.WriteString("\n" + msg(MsgTypeHierarchySection, dir) + "\n")
+			if tree.nodes == 0 {
+				result.WriteString(msg(MsgNoRelatedTypes, dir, item.Name) + "\n")
+				continue
+			}
+			result.WriteString(section.String())
+			if tree.truncated {
+				result.WriteString(msg(MsgTypeTreeTruncated, maxTypeTreeNodes) + "\n")
+			}
+		}
+	}
+
+	return result.String(), nil
+}
+
+// typeTree renders the supertypes or subtypes of a type as an indented tree
+type typeTree struct {
+	ctx       context.Context
+	client    *lsp.Client
+	direction string
+	nodes     int
+	truncated bool
+}
+
+// write renders the related types of item at the given level, recursing until
+// maxDepth. branch holds the keys of the items on the path from the root to item.
+func (t *typeTree) write(result *strings.Builder, item protocol.TypeHierarchyItem, branch []string, level, maxDepth int) error {
+	var related []protocol.TypeHierarchyItem
+	var err error
+	if t.direction == TypeHierarchySupertypes {
+		related, err = t.client.Supertypes(t.ctx, protocol.TypeHierarchySupertypesParams{Item: item})
+	} else {
+		related, err = t.client.Subtypes(t.ctx, protocol.TypeHierarchySubtypesParams{Item: item})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get %s of %s: %v", t.direction, item.Name, err)
+	}
+
+	indent := strings.Repeat("  ", level-1)
+	for _, rel := range related {
+		if t.nodes >= maxTypeTreeNodes {
+			t.truncated = true
+			return nil
+		}
+		t.nodes++
+
+		result.WriteString(fmt.Sprintf("%s- %s %s (%s)", indent, protocol.TableKindMap[rel.Kind], rel.Name, formatTypeHierarchyLocation(rel)))
+		if rel.Detail != "" {
+			result.WriteString(" " + rel.Detail)
+		}
+
+		// Guard against cycles, which some servers report for invalid code
+		key := typeHierarchyItemKey(rel)
+		cycle := false
+		for _, k := range branch {
+			if k == key {
+				cycle = true
+				break
+			}
+		}
+		if cycle {
+			result.WriteString(" " + msg(MsgRecursiveCall) + "\n")
+			continue
+		}
+		result.WriteString("\n")
+
+		if level < maxDepth {
+			if err := t.write(result, rel, append(branch, key), level+1, maxDepth); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func typeHierarchyItemKey(item protocol.TypeHierarchyItem) string {
+	return fmt.Sprintf("%s:%d:%d", item.URI, item.SelectionRange.Start.Line, item.SelectionRange.Start.Character)
+}
+
+// formatTypeHierarchyLocation renders the location of a type hierarchy item
+func formatTypeHierarchyLocation(item protocol.TypeHierarchyItem) string {
+	return fmt.Sprintf("%s:L%d:C%d",
+		strings.TrimPrefix(string(item.URI), "file://"),
+		item.SelectionRange.Start.Line+1,
+		item.SelectionRange.Start.Character+1)
+}
@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/utilities"
+)
+
+// UndoLastEdit reverts the last edit made by a tool that changes files,
+// restoring every file it touched, and brings the view of the open files of
+// the language server that clientFor returns for each of them up to date.
+// Unless force is set, it refuses if any of the files changed after the edit.
+func UndoLastEdit(ctx context.Context, clientFor func(path string) *lsp.Client, force bool) (string, error) {
+	entry, err := utilities.UndoLastJournalEntry(force)
+	if err != nil {
+		return "", fmt.Errorf("%s", msg(MsgUndoConflict, err))
+	}
+	if entry == nil {
+		return msg(MsgNothingToUndo), nil
+	}
+
+	saved := ""
+	for _, file := range entry.Files {
+		client := clientFor(file.Path)
+		if !client.IsFileOpen(file.Path) {
+			continue
+		}
+		if file.Before == nil {
+			if err := client.CloseFile(ctx, file.Path); err != nil {
+				toolsLogger.Error("Error closing file: %v", err)
+			}
+			continue
+		}
+		if err := client.ChangeDocument(ctx, lsp.DocumentURI(file.Path), string(file.Before)); err != nil {
+			toolsLogger.Error("Error updating document: %v", err)
+		}
+		saved += saveFile(ctx, client, file.Path)
+	}
+
+	result := formatUndo(*entry, len(utilities.JournalEntries()))
+	if saved != "" {
+		result += "\n" + saved
+	}
+	return result, nil
+}
+
+// formatUndo lists the files restored by undoing an edit
+func formatUndo(entry utilities.JournalEntry, remaining int) string {
+	var result strings.Builder
+	result.WriteString(msg(MsgEditUndone, entry.Label, entry.Time.Format("15:04:05"), len(entry.Files)) + "\n")
+	for _, file := range entry.Files {
+		switch {
+		case file.Before == nil:
+			result.WriteString(msg(MsgUndoFileRemoved, file.Path) + "\n")
+		case file.After == nil:
+			result.WriteString(msg(MsgUndoFileRecreated, file.Path) + "\n")
+		default:
+			result.WriteString(file.Path + "\n")
+		}
+	}
+	if remaining > 0 {
+		result.WriteString(msg(MsgUndoRemaining, remaining) + "\n")
+	}
+	return result.String()
+}
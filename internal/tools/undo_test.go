@@ -0,0 +1,28 @@
+package tools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/koonwen/mcp-language-server/internal/utilities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatUndo(t *testing.T) {
+	entry := utilities.JournalEntry{
+		Label: "rename_package",
+		Time:  time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC),
+		Files: []utilities.FileSnapshot{
+			{Path: "/ws/new/a.go", After: []byte("package a\n")},
+			{Path: "/ws/old/a.go", Before: []byte("package a\n")},
+			{Path: "/ws/main.go", Before: []byte("package main\n"), After: []byte("package main\n\n")},
+		},
+	}
+
+	assert.Equal(t, `Undid rename_package from 15:04:05, restoring 3 files:
+/ws/new/a.go (removed)
+/ws/old/a.go (recreated)
+/ws/main.go
+2 earlier edits can be undone.
+`, formatUndo(entry, 2))
+}
@@ -81,6 +81,14 @@ func containsPosition(r protocol.Range, p protocol.Position) bool {
 	return true
 }
 
+// positionBefore reports whether a comes before b in a document
+func positionBefore(a, b protocol.Position) bool {
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Character < b.Character
+}
+
 // addLineNumbers adds line numbers to each line of text with proper padding, starting from startLine
 func addLineNumbers(text string, startLine int) string {
 	lines := strings.Split(text, "\n")
@@ -167,3 +175,48 @@ func FormatLinesWithRanges(lines []string, ranges []LineRange) string {
 
 	return result.String()
 }
+
+// rangeText returns the text of a single line range, or an empty string if the
+// range is outside of the given lines
+func rangeText(lines []string, r protocol.Range) string {
+	line := int(r.Start.Line)
+	if line >= len(lines) || r.End.Line != r.Start.Line {
+		return ""
+	}
+	start, end := int(r.Start.Character), int(r.End.Character)
+	if start > end || end > len(lines[line]) {
+		return ""
+	}
+	return lines[line][start:end]
+}
+
+// contextLinesConfig is the number of context lines set by the config file,
+// or -1 if it sets none. It is only set at startup by SetContextLines.
+var contextLinesConfig = -1
+
+// SetContextLines sets the number of lines shown around locations in tool
+// results, which takes precedence over LSP_CONTEXT_LINES
+func SetContextLines(lines int) {
+	contextLinesConfig = lines
+}
+
+// ContextLines returns the number of lines shown around locations in tool
+// results
+func ContextLines() int {
+	return configuredContextLines(5)
+}
+
+// configuredContextLines returns the number of context lines set by the
+// config file, or else by the LSP_CONTEXT_LINES environment variable, or def
+// if neither sets a valid one
+func configuredContextLines(def int) int {
+	if contextLinesConfig >= 0 {
+		return contextLinesConfig
+	}
+	if envLines := os.Getenv("LSP_CONTEXT_LINES"); envLines != "" {
+		if val, err := strconv.Atoi(envLines); err == nil && val >= 0 {
+			return val
+		}
+	}
+	return def
+}
@@ -454,3 +454,51 @@ func TestFormatLinesWithRanges(t *testing.T) {
 		})
 	}
 }
+
+func TestRangeText(t *testing.T) {
+	lines := []string{"func main() {", "\tx := compute(1)", "}"}
+
+	assert.Equal(t, "x", rangeText(lines, protocol.Range{
+		Start: protocol.Position{Line: 1, Character: 1},
+		End:   protocol.Position{Line: 1, Character: 2},
+	}))
+	assert.Equal(t, "compute(1)", rangeText(lines, protocol.Range{
+		Start: protocol.Position{Line: 1, Character: 6},
+		End:   protocol.Position{Line: 1, Character: 16},
+	}))
+	// Out of range and multi-line ranges are not read
+	assert.Equal(t, "", rangeText(lines, protocol.Range{
+		Start: protocol.Position{Line: 5},
+		End:   protocol.Position{Line: 5, Character: 1},
+	}))
+	assert.Equal(t, "", rangeText(lines, protocol.Range{
+		Start: protocol.Position{Line: 0},
+		End:   protocol.Position{Line: 1, Character: 1},
+	}))
+}
+
+func TestConfiguredContextLines(t *testing.T) {
+	tests := []struct {
+		env  string
+		want int
+	}{
+		{"", 5},
+		{"0", 0},
+		{"12", 12},
+		{"-1", 5},
+		{"many", 5},
+	}
+	for _, tt := range tests {
+		t.Setenv("LSP_CONTEXT_LINES", tt.env)
+		assert.Equal(t, tt.want, configuredContextLines(5), "LSP_CONTEXT_LINES=%q", tt.env)
+	}
+
+	// The config file takes precedence over the environment
+	t.Cleanup(func() { SetContextLines(-1) })
+	SetContextLines(2)
+	t.Setenv("LSP_CONTEXT_LINES", "12")
+	assert.Equal(t, 2, configuredContextLines(5))
+	assert.Equal(t, 2, ContextLines())
+	SetContextLines(0)
+	assert.Equal(t, 0, ContextLines())
+}
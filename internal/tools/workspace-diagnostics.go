@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// maxWorkspaceDiagnosticFiles is the number of files listed by
+// GetWorkspaceDiagnostics, the files with the most errors first
+const maxWorkspaceDiagnosticFiles = 100
+
+// severityOrder is the order severities are counted in, most severe first
+var severityOrder = []protocol.DiagnosticSeverity{
+	protocol.SeverityError,
+	protocol.SeverityWarning,
+	protocol.SeverityInformation,
+	protocol.SeverityHint,
+	0,
+}
+
+// fileDiagnosticCounts is the number of diagnostics of each severity in a file
+type fileDiagnosticCounts struct {
+	path   string
+	counts map[protocol.DiagnosticSeverity]int
+}
+
+// GetWorkspaceDiagnostics summarizes the diagnostics of the whole workspace,
+// with the number of diagnostics of each severity in each file, to see what
+// is broken after a refactor. Only diagnostics that pass filter are counted.
+// Diagnostics are pulled for the whole workspace if the server supports it.
+// Otherwise only the files the server has published diagnostics for are
+// included.
+func GetWorkspaceDiagnostics(ctx context.Context, client *lsp.Client, filter DiagnosticFilter) (string, error) {
+	pulled, err := client.PullWorkspaceDiagnostics(ctx)
+	if err != nil {
+		toolsLogger.Error("Failed to get workspace diagnostics: %v", err)
+		pulled = false
+	}
+
+	var files []fileDiagnosticCounts
+	hidden := 0
+	for uri, diagnostics := range client.GetAllDiagnostics() {
+		if !lsp.IsFileURI(uri) {
+			continue
+		}
+		diagnostics, filtered := filter.apply(diagnostics)
+		hidden += filtered
+		if len(diagnostics) == 0 {
+			continue
+		}
+		file := fileDiagnosticCounts{
+			path:   lsp.DocumentPath(uri),
+			counts: make(map[protocol.DiagnosticSeverity]int),
+		}
+		for _, diag := range diagnostics {
+			file.counts[diag.Severity]++
+		}
+		files = append(files, file)
+	}
+
+	return formatWorkspaceDiagnostics(files, pulled) + filteredNote(hidden), nil
+}
+
+// formatWorkspaceDiagnostics lists the files with diagnostics, those with the
+// most severe diagnostics first, after the total counts
+func formatWorkspaceDiagnostics(files []fileDiagnosticCounts, pulled bool) string {
+	var out strings.Builder
+	if len(files) == 0 {
+		out.WriteString(msg(MsgNoWorkspaceDiagnostics) + "\n")
+	} else {
+		sort.Slice(files, func(i, j int) bool {
+			for _, severity := range severityOrder {
+				if files[i].counts[severity] != files[j].counts[severity] {
+					return files[i].counts[severity] > files[j].counts[severity]
+				}
+			}
+			return files[i].path < files[j].path
+		})
+
+		totals := make(map[protocol.DiagnosticSeverity]int)
+		for _, file := range files {
+			for severity, count := range file.counts {
+				totals[severity] += count
+			}
+		}
+		out.WriteString(msg(MsgWorkspaceDiagnostics, formatSeverityCounts(totals), len(files)) + "\n\n")
+
+		for i, file := range files {
+			if i == maxWorkspaceDiagnosticFiles {
+				out.WriteString(msg(MsgMoreFiles, len(files)-i) + "\n")
+				break
+			}
+			out.WriteString(fmt.Sprintf("%s: %s\n", file.path, formatSeverityCounts(file.counts)))
+		}
+	}
+
+	if !pulled {
+		out.WriteString("\n" + msg(MsgPublishedOnly) + "\n")
+	}
+	return out.String()
+}
+
+// formatSeverityCounts renders the non-zero counts of each severity, most
+// severe first, e.g. "3 ERROR, 1 WARNING"
+func formatSeverityCounts(counts map[protocol.DiagnosticSeverity]int) string {
+	var parts []string
+	for _, severity := range severityOrder {
+		if counts[severity] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[severity], getSeverityString(severity)))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
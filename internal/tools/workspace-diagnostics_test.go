@@ -0,0 +1,27 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatWorkspaceDiagnostics(t *testing.T) {
+	files := []fileDiagnosticCounts{
+		{path: "/ws/util.go", counts: map[protocol.DiagnosticSeverity]int{protocol.SeverityWarning: 2, protocol.SeverityHint: 1}},
+		{path: "/ws/main.go", counts: map[protocol.DiagnosticSeverity]int{protocol.SeverityError: 1}},
+		{path: "/ws/api.go", counts: map[protocol.DiagnosticSeverity]int{protocol.SeverityError: 3, protocol.SeverityWarning: 1}},
+	}
+
+	assert.Equal(t, `Workspace diagnostics: 4 ERROR, 3 WARNING, 1 HINT in 3 files
+
+/ws/api.go: 3 ERROR, 1 WARNING
+/ws/main.go: 1 ERROR
+/ws/util.go: 2 WARNING, 1 HINT
+`, formatWorkspaceDiagnostics(files, true))
+
+	text := formatWorkspaceDiagnostics(nil, false)
+	assert.Contains(t, text, "No diagnostics in the workspace")
+	assert.Contains(t, text, "only files it has published diagnostics for")
+}
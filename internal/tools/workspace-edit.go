@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/koonwen/mcp-language-server/internal/utilities"
+)
+
+// previewWorkspaceEdit renders the changes a workspace edit would make
+// across files, without writing anything
+func previewWorkspaceEdit(edit protocol.WorkspaceEdit) (string, error) {
+	plan, err := utilities.PlanWorkspaceEdit(edit)
+	if err != nil {
+		return "", fmt.Errorf("failed to plan changes: %v", err)
+	}
+	return formatEditPlan(plan), nil
+}
+
+// formatEditPlan lists the file operations and change annotations of a
+// workspace edit, followed by a unified diff of every file it changes
+func formatEditPlan(plan *utilities.EditPlan) string {
+	if len(plan.Files) == 0 && len(plan.Operations) == 0 {
+		return msg(MsgWorkspaceEditEmpty) + "\n"
+	}
+
+	var result strings.Builder
+	result.WriteString(msg(MsgWorkspaceEditDryRun, len(plan.Files)) + "\n")
+
+	if len(plan.Operations) > 0 {
+		result.WriteString("\n")
+		for _, op := range plan.Operations {
+			switch op.Kind {
+			case protocol.Create:
+				result.WriteString(msg(MsgRenamePackageCreate, op.Path) + "\n")
+			case protocol.Rename:
+				result.WriteString(msg(MsgRenamePackageMove, op.Path, op.NewPath) + "\n")
+			case protocol.Delete:
+				result.WriteString(msg(MsgRenamePackageDelete, op.Path) + "\n")
+			}
+		}
+	}
+
+	if len(plan.Annotations) > 0 {
+		result.WriteString("\n" + msg(MsgChangeAnnotations) + "\n")
+		for _, annotation := range plan.Annotations {
+			line := fmt.Sprintf("- %s: %d", annotation.Label, annotation.Changes)
+			if annotation.NeedsConfirmation {
+				line += " " + msg(MsgNeedsConfirmation)
+			}
+			if annotation.Description != "" {
+				line += " - " + annotation.Description
+			}
+			result.WriteString(line + "\n")
+		}
+	}
+
+	for _, file := range plan.Files {
+		diff, _, _ := UnifiedDiff(file.Path, file.Before, file.After)
+		if diff == "" {
+			continue
+		}
+		result.WriteString("\n" + diff)
+	}
+
+	return result.String()
+}
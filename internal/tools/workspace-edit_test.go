@@ -0,0 +1,36 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/koonwen/mcp-language-server/internal/utilities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatEditPlan(t *testing.T) {
+	assert.Equal(t, "The edit makes no changes.\n", formatEditPlan(&utilities.EditPlan{}))
+
+	plan := &utilities.EditPlan{
+		Files: []utilities.FileChange{
+			{Path: "/ws/a.go", Before: "package a\n\nfunc Old() {}\n", After: "package a\n\nfunc New() {}\n"},
+			{Path: "/ws/doc.go", After: "package a\n", Created: true},
+		},
+		Operations: []utilities.FileOperation{
+			{Kind: protocol.Create, Path: "/ws/doc.go"},
+			{Kind: protocol.Rename, Path: "/ws/old.go", NewPath: "/ws/a.go"},
+		},
+		Annotations: []utilities.AnnotatedChange{
+			{ChangeAnnotation: protocol.ChangeAnnotation{Label: "Rename in strings", NeedsConfirmation: true, Description: "Textual matches"}, Changes: 2},
+		},
+	}
+
+	assert.Equal(t, "Dry run: no changes were made. Run again with dryRun set to false to apply these changes to 2 files.\n\n"+
+		"Create: /ws/doc.go\n"+
+		"Move: /ws/old.go -> /ws/a.go\n\n"+
+		"Labelled changes:\n"+
+		"- Rename in strings: 2 (needs confirmation) - Textual matches\n\n"+
+		"--- a/ws/a.go\n+++ b/ws/a.go\n@@ -1,4 +1,4 @@\n package a\n \n-func Old() {}\n+func New() {}\n \n\n"+
+		"--- a/ws/doc.go\n+++ b/ws/doc.go\n@@ -1 +1,2 @@\n+package a\n \n",
+		formatEditPlan(plan))
+}
@@ -0,0 +1,361 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// Ranking weights for workspace symbol matches
+const (
+	scoreExactName       = 100
+	scoreExactNameFold   = 80
+	scoreQualifiedName   = 60
+	scorePrefix          = 40
+	scoreSubstring       = 20
+	scorePreferredKind   = 25
+	scoreDeclarationKind = 10
+	scorePathComponent   = 5
+	maxPathProximity     = 30
+	scoreSameFile        = 10
+	penaltyTestFile      = -30
+	penaltyVendored      = -40
+)
+
+// SymbolMatch is a workspace symbol search result with its relevance score and
+// the signals that make up the score. Positions are 1-indexed.
+type SymbolMatch struct {
+	Name      string        `json:"name"`
+	Kind      string        `json:"kind"`
+	Container string        `json:"container,omitempty"`
+	Path      string        `json:"path"`
+	Line      int           `json:"line"`
+	Column    int           `json:"column"`
+	Score     int           `json:"score"`
+	Signals   []ScoreSignal `json:"signals"`
+	// Server and Language label the matches of a federated search
+	Server   string `json:"server,omitempty"`
+	Language string `json:"language,omitempty"`
+}
+
+// ScoreSignal is one contribution to the score of a SymbolMatch
+type ScoreSignal struct {
+	Signal string `json:"signal"`
+	Points int    `json:"points"`
+}
+
+// SymbolSearchOptions controls the filtering, ranking and output of SearchWorkspaceSymbols
+type SymbolSearchOptions struct {
+	// Exact only keeps symbols whose name or qualified name is the query,
+	// rather than everything the server's fuzzy search returns
+	Exact bool
+	// Kinds only keeps symbols of the given kinds, e.g. "Function" or "Struct"
+	Kinds []string
+	// PreferKinds ranks symbols of the given kinds higher
+	PreferKinds []string
+	// HintFile ranks symbols in nearby directories higher
+	HintFile string
+	// Limit caps the number of matches returned, if positive
+	Limit int
+	// JSON returns the matches with their scoring signals as JSON
+	JSON bool
+}
+
+// SearchWorkspaceSymbols runs a workspace symbol search, filters the results
+// and ranks them by exact name match, preferred kinds, proximity to the hint
+// file and whether they are in test or vendored code. Only the best matches
+// are returned, as text or as JSON with the scoring signals of each match.
+func SearchWorkspaceSymbols(ctx context.Context, client *lsp.Client, query string, opts SymbolSearchOptions) (string, error) {
+	matches, err := workspaceSymbolMatches(ctx, client, query, opts)
+	if err != nil {
+		return "", err
+	}
+	return formatSymbolMatches(query, matches, nil, opts)
+}
+
+// SymbolServer is a language server taking part in a federated search
+type SymbolServer struct {
+	Name   string
+	Client *lsp.Client
+}
+
+// SearchWorkspaceSymbolsFederated runs a workspace symbol search on several
+// language servers concurrently, and filters and ranks the merged results
+// like SearchWorkspaceSymbols. Each match is labeled with the server that
+// found it and the language of its file. Servers that fail are reported
+// along with the results of the others.
+func SearchWorkspaceSymbolsFederated(ctx context.Context, servers []SymbolServer, query string, opts SymbolSearchOptions) (string, error) {
+	results := make([][]SymbolMatch, len(servers))
+	errs := fanOut(ctx, len(servers), func(ctx context.Context, i int) error {
+		var err error
+		results[i], err = workspaceSymbolMatches(ctx, servers[i].Client, query, opts)
+		return err
+	})
+
+	names := make([]string, len(servers))
+	for i, server := range servers {
+		names[i] = server.Name
+	}
+	matches, failures := mergeSymbolMatches(names, results, errs)
+	if len(failures) == len(servers) {
+		return "", fmt.Errorf("every server failed: %s", strings.Join(failures, "; "))
+	}
+
+	return formatSymbolMatches(query, matches, failures, opts)
+}
+
+// mergeSymbolMatches combines the matches found by several servers, labeling
+// each with its server and language and dropping duplicates, and describes
+// the servers that failed
+func mergeSymbolMatches(names []string, results [][]SymbolMatch, errs []error) ([]SymbolMatch, []string) {
+	var matches []SymbolMatch
+	var failures []string
+	seen := make(map[string]bool)
+	for i, name := range names {
+		if errs[i] != nil {
+			failures = append(failures, msg(MsgServerFailed, name, errs[i]))
+			continue
+		}
+		for _, match := range results[i] {
+			// Servers for overlapping languages may report the same symbol
+			key := fmt.Sprintf("%s:%d:%d:%s", match.Path, match.Line, match.Column, match.Name)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			match.Server = name
+			match.Language = string(lsp.DetectLanguageID(match.Path))
+			matches = append(matches, match)
+		}
+	}
+	return matches, failures
+}
+
+// workspaceSymbolMatches runs a workspace symbol search on one server and
+// keeps the matches allowed by the options
+func workspaceSymbolMatches(ctx context.Context, client *lsp.Client, query string, opts SymbolSearchOptions) ([]SymbolMatch, error) {
+	symbolResult, err := client.Symbol(ctx, protocol.WorkspaceSymbolParams{
+		Query: query,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch symbol: %v", err)
+	}
+
+	results, err := symbolResult.Results()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse results: %v", err)
+	}
+
+	kinds := make(map[string]bool)
+	for _, kind := range opts.Kinds {
+		kinds[strings.ToLower(kind)] = true
+	}
+
+	var matches []SymbolMatch
+	for _, symbol := range results {
+		loc := symbol.GetLocation()
+		match := SymbolMatch{
+			Name:   symbol.GetName(),
+			Path:   lsp.DocumentPath(loc.URI),
+			Line:   int(loc.Range.Start.Line) + 1,
+			Column: int(loc.Range.Start.Character) + 1,
+		}
+		switch v := symbol.(type) {
+		case *protocol.SymbolInformation:
+			match.Kind = protocol.TableKindMap[v.Kind]
+			match.Container = v.ContainerName
+		case *protocol.WorkspaceSymbol:
+			match.Kind = protocol.TableKindMap[v.Kind]
+			match.Container = v.ContainerName
+		}
+
+		if len(kinds) > 0 && !kinds[strings.ToLower(match.Kind)] {
+			continue
+		}
+		if opts.Exact && !symbolNameMatches(query, match) {
+			continue
+		}
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+// formatSymbolMatches ranks matches, keeps the best and formats them along
+// with any notes about servers that failed
+func formatSymbolMatches(query string, matches []SymbolMatch, failures []string, opts SymbolSearchOptions) (string, error) {
+	matches = rankSymbols(query, opts.HintFile, opts.PreferKinds, matches)
+	total := len(matches)
+	if opts.Limit > 0 && len(matches) > opts.Limit {
+		matches = matches[:opts.Limit]
+	}
+
+	if opts.JSON {
+		data, err := json.MarshalIndent(struct {
+			Query    string        `json:"query"`
+			Total    int           `json:"total"`
+			Matches  []SymbolMatch `json:"matches"`
+			Failures []string      `json:"failures,omitempty"`
+		}{query, total, matches, failures}, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal results: %v", err)
+		}
+		return string(data), nil
+	}
+
+	var result strings.Builder
+	for _, failure := range failures {
+		result.WriteString(failure + "\n")
+	}
+
+	if total == 0 {
+		result.WriteString(msg(MsgSymbolNotFound, query))
+		return result.String(), nil
+	}
+
+	result.WriteString(msg(MsgSymbolSearchHeader, len(matches), total, query) + "\n")
+	for _, m := range matches {
+		result.WriteString(fmt.Sprintf("- %s %s (%s:L%d:C%d)", m.Kind, m.Name, m.Path, m.Line, m.Column))
+		if m.Container != "" {
+			result.WriteString(" " + msg(MsgSymbolContainer, m.Container))
+		}
+		if m.Server != "" {
+			result.WriteString(fmt.Sprintf(" [%s, %s]", m.Server, m.Language))
+		}
+		result.WriteString(fmt.Sprintf(" [score %d]\n", m.Score))
+	}
+	return result.String(), nil
+}
+
+// rankSymbols scores every match and sorts them by descending score. Ties keep
+// the order of the server, which usually reflects its own fuzzy ranking.
+func rankSymbols(query, hintFile string, kinds []string, matches []SymbolMatch) []SymbolMatch {
+	preferred := make(map[string]bool)
+	for _, kind := range kinds {
+		preferred[strings.ToLower(kind)] = true
+	}
+
+	for i := range matches {
+		m := &matches[i]
+		m.Signals = nil
+		add := func(signal string, points int) {
+			m.Signals = append(m.Signals, ScoreSignal{Signal: signal, Points: points})
+		}
+
+		qualified := m.Name
+		if m.Container != "" {
+			qualified = m.Container + "." + m.Name
+		}
+		switch {
+		case m.Name == query:
+			add("exact name", scoreExactName)
+		case strings.EqualFold(m.Name, query):
+			add("exact name ignoring case", scoreExactNameFold)
+		case qualified == query || strings.HasSuffix(qualified, "."+query):
+			add("qualified name", scoreQualifiedName)
+		case strings.HasPrefix(strings.ToLower(m.Name), strings.ToLower(query)):
+			add("name prefix", scorePrefix)
+		case strings.Contains(strings.ToLower(m.Name), strings.ToLower(query)):
+			add("name substring", scoreSubstring)
+		}
+
+		if preferred[strings.ToLower(m.Kind)] {
+			add("preferred kind", scorePreferredKind)
+		} else if len(preferred) == 0 && isDeclarationKind(m.Kind) {
+			add("declaration kind", scoreDeclarationKind)
+		}
+
+		if hintFile != "" {
+			if points := pathProximity(hintFile, m.Path); points > 0 {
+				add("near hint file", points)
+			}
+		}
+
+		if isVendoredPath(m.Path) {
+			add("vendored", penaltyVendored)
+		} else if isTestPath(m.Path) {
+			add("test file", penaltyTestFile)
+		}
+
+		m.Score = 0
+		for _, signal := range m.Signals {
+			m.Score += signal.Points
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches
+}
+
+// symbolNameMatches reports whether a match is exactly the queried symbol,
+// which may be qualified by its container, e.g. "Type.Method"
+func symbolNameMatches(query string, match SymbolMatch) bool {
+	if referenceSymbolMatches(query, match.Name) {
+		return true
+	}
+	return match.Container != "" && match.Container+"."+match.Name == query
+}
+
+// isDeclarationKind reports whether a symbol kind is a type or function,
+// which are usually what a search is looking for
+func isDeclarationKind(kind string) bool {
+	switch kind {
+	case "Class", "Interface", "Struct", "Enum", "Function", "Method", "Constructor", "Module", "Namespace", "Package":
+		return true
+	}
+	return false
+}
+
+// pathProximity scores how close path is to hintFile by the number of leading
+// directories they share, with the most points for the same directory and a
+// bonus for the same file
+func pathProximity(hintFile, path string) int {
+	if filepath.Clean(hintFile) == filepath.Clean(path) {
+		return maxPathProximity + scoreSameFile
+	}
+
+	hintDirs := strings.Split(strings.Trim(filepath.ToSlash(filepath.Dir(hintFile)), "/"), "/")
+	dirs := strings.Split(strings.Trim(filepath.ToSlash(filepath.Dir(path)), "/"), "/")
+
+	shared := 0
+	for shared < len(hintDirs) && shared < len(dirs) && hintDirs[shared] == dirs[shared] {
+		shared++
+	}
+	if shared == len(hintDirs) && shared == len(dirs) {
+		return maxPathProximity
+	}
+	return min(shared*scorePathComponent, maxPathProximity)
+}
+
+// isTestPath reports whether a path looks like test code
+func isTestPath(path string) bool {
+	slashed := filepath.ToSlash(path)
+	base := filepath.Base(slashed)
+	if strings.HasSuffix(base, "_test.go") || strings.HasPrefix(base, "test_") ||
+		strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") ||
+		strings.HasSuffix(strings.TrimSuffix(base, filepath.Ext(base)), "Test") {
+		return true
+	}
+	for _, dir := range strings.Split(filepath.Dir(slashed), "/") {
+		if dir == "test" || dir == "tests" || dir == "__tests__" || dir == "testdata" {
+			return true
+		}
+	}
+	return false
+}
+
+// isVendoredPath reports whether a path is in vendored or third party code
+func isVendoredPath(path string) bool {
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		switch dir {
+		case "vendor", "node_modules", "third_party", "site-packages", ".venv":
+			return true
+		}
+	}
+	return false
+}
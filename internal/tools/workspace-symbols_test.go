@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankSymbols(t *testing.T) {
+	matches := []SymbolMatch{
+		{Name: "NewServerConfig", Kind: "Function", Path: "/src/config/config.go"},
+		{Name: "Server", Kind: "Struct", Path: "/src/vendor/other/server.go"},
+		{Name: "server", Kind: "Variable", Path: "/src/main.go"},
+		{Name: "Server", Kind: "Struct", Path: "/src/server/server_test.go"},
+		{Name: "Server", Kind: "Struct", Path: "/src/server/server.go"},
+	}
+
+	ranked := rankSymbols("Server", "/src/server/handler.go", nil, matches)
+	assert.Equal(t, "/src/server/server.go", ranked[0].Path)
+	assert.Equal(t, []ScoreSignal{
+		{Signal: "exact name", Points: scoreExactName},
+		{Signal: "declaration kind", Points: scoreDeclarationKind},
+		{Signal: "near hint file", Points: maxPathProximity},
+	}, ranked[0].Signals)
+
+	var paths []string
+	for _, m := range ranked {
+		paths = append(paths, m.Path)
+	}
+	assert.Equal(t, []string{
+		"/src/server/server.go",
+		"/src/server/server_test.go",
+		"/src/main.go",
+		"/src/vendor/other/server.go",
+		"/src/config/config.go",
+	}, paths)
+
+	// A preferred kind outweighs the default preference for declarations
+	ranked = rankSymbols("server", "", []string{"variable"}, matches)
+	assert.Equal(t, "/src/main.go", ranked[0].Path)
+}
+
+func TestSymbolNameMatches(t *testing.T) {
+	assert.True(t, symbolNameMatches("Server", SymbolMatch{Name: "Server"}))
+	assert.False(t, symbolNameMatches("Server", SymbolMatch{Name: "NewServer"}))
+	assert.True(t, symbolNameMatches("Server.Start", SymbolMatch{Name: "Start", Container: "Server"}))
+	assert.True(t, symbolNameMatches("Server.Start", SymbolMatch{Name: "Server.Start"}))
+}
+
+func TestIsTestPath(t *testing.T) {
+	assert.True(t, isTestPath("/src/server/server_test.go"))
+	assert.True(t, isTestPath("/src/tests/helpers.py"))
+	assert.True(t, isTestPath("/src/app.spec.ts"))
+	assert.True(t, isTestPath("/src/main/java/ServerTest.java"))
+	assert.False(t, isTestPath("/src/server/server.go"))
+	assert.False(t, isTestPath("/src/contest/entry.go"))
+}
+
+func TestMergeSymbolMatches(t *testing.T) {
+	results := [][]SymbolMatch{
+		{{Name: "Handler", Path: "/src/server.go", Line: 3, Column: 6}},
+		{
+			{Name: "Handler", Path: "/src/server.go", Line: 3, Column: 6},
+			{Name: "handler", Path: "/web/app.ts", Line: 10, Column: 1},
+		},
+		nil,
+	}
+	errs := []error{nil, nil, errors.New("connection closed")}
+
+	matches, failures := mergeSymbolMatches([]string{"gopls", "tsserver", "pyright"}, results, errs)
+	assert.Equal(t, []SymbolMatch{
+		{Name: "Handler", Path: "/src/server.go", Line: 3, Column: 6, Server: "gopls", Language: "go"},
+		{Name: "handler", Path: "/web/app.ts", Line: 10, Column: 1, Server: "tsserver", Language: "typescript"},
+	}, matches)
+	assert.Equal(t, []string{"Server pyright failed: connection closed"}, failures)
+}
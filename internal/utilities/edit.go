@@ -7,7 +7,6 @@ import (
 	"sort"
 	"strings"
 
-	"github.com/davecgh/go-spew/spew"
 	"github.com/koonwen/mcp-language-server/internal/protocol"
 )
 
@@ -30,6 +29,21 @@ func ApplyTextEdits(uri protocol.DocumentUri, edits []protocol.TextEdit) error {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
+	newContent, err := EditContent(content, edits)
+	if err != nil {
+		return err
+	}
+
+	if err := WriteFile(path, newContent); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// EditContent returns the result of applying a sequence of text edits to the
+// content of a file, preserving its line endings
+func EditContent(content []byte, edits []protocol.TextEdit) ([]byte, error) {
 	// Detect line ending style
 	var lineEnding string
 	if bytes.Contains(content, []byte("\r\n")) {
@@ -48,7 +62,7 @@ func ApplyTextEdits(uri protocol.DocumentUri, edits []protocol.TextEdit) error {
 	for i, edit1 := range edits {
 		for j := i + 1; j < len(edits); j++ {
 			if RangesOverlap(edit1.Range, edits[j].Range) {
-				return fmt.Errorf("overlapping edits detected between edit %d and %d", i, j)
+				return nil, fmt.Errorf("overlapping edits detected between edit %d and %d", i, j)
 			}
 		}
 	}
@@ -67,7 +81,7 @@ func ApplyTextEdits(uri protocol.DocumentUri, edits []protocol.TextEdit) error {
 	for _, edit := range sortedEdits {
 		newLines, err := ApplyTextEdit(lines, edit, lineEnding)
 		if err != nil {
-			return fmt.Errorf("failed to apply edit: %w", err)
+			return nil, fmt.Errorf("failed to apply edit: %w", err)
 		}
 		lines = newLines
 	}
@@ -86,11 +100,7 @@ func ApplyTextEdits(uri protocol.DocumentUri, edits []protocol.TextEdit) error {
 		newContent.WriteString(lineEnding)
 	}
 
-	if err := osWriteFile(path, []byte(newContent.String()), 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
-	}
-
-	return nil
+	return []byte(newContent.String()), nil
 }
 
 // ApplyTextEdit applies a single text edit to a set of lines
@@ -183,7 +193,7 @@ func ApplyDocumentChange(change protocol.DocumentChange) error {
 				}
 			}
 		}
-		if err := osWriteFile(path, []byte(""), 0644); err != nil {
+		if err := WriteFile(path, []byte("")); err != nil {
 			return fmt.Errorf("failed to create file: %w", err)
 		}
 	}
@@ -191,11 +201,11 @@ func ApplyDocumentChange(change protocol.DocumentChange) error {
 	if change.DeleteFile != nil {
 		path := strings.TrimPrefix(string(change.DeleteFile.URI), "file://")
 		if change.DeleteFile.Options != nil && change.DeleteFile.Options.Recursive {
-			if err := osRemoveAll(path); err != nil {
+			if err := removePath(path, true); err != nil {
 				return fmt.Errorf("failed to delete directory recursively: %w", err)
 			}
 		} else {
-			if err := osRemove(path); err != nil {
+			if err := removePath(path, false); err != nil {
 				return fmt.Errorf("failed to delete file: %w", err)
 			}
 		}
@@ -211,7 +221,7 @@ func ApplyDocumentChange(change protocol.DocumentChange) error {
 				}
 			}
 		}
-		if err := osRename(oldPath, newPath); err != nil {
+		if err := RenamePath(oldPath, newPath); err != nil {
 			return fmt.Errorf("failed to rename file: %w", err)
 		}
 	}
@@ -231,26 +241,6 @@ func ApplyDocumentChange(change protocol.DocumentChange) error {
 	return nil
 }
 
-// ApplyWorkspaceEdit applies the given WorkspaceEdit to the filesystem
-func ApplyWorkspaceEdit(edit protocol.WorkspaceEdit) error {
-	// Handle Changes field
-	for uri, textEdits := range edit.Changes {
-		if err := ApplyTextEdits(uri, textEdits); err != nil {
-			return fmt.Errorf("failed to apply text edits: %w", err)
-		}
-	}
-
-	// Handle DocumentChanges field
-	for _, change := range edit.DocumentChanges {
-		coreLogger.Warn("Document change: %v", spew.Sdump(change))
-		if err := ApplyDocumentChange(change); err != nil {
-			return fmt.Errorf("failed to apply document change: %w", err)
-		}
-	}
-
-	return nil
-}
-
 // RangesOverlap checks if two ranges overlap in position
 func RangesOverlap(r1, r2 protocol.Range) bool {
 	if r1.Start.Line > r2.End.Line || r2.Start.Line > r1.End.Line {
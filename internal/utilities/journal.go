@@ -0,0 +1,279 @@
+package utilities
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxJournalEntries bounds the number of edits that can be undone. Older
+// entries are dropped.
+const maxJournalEntries = 20
+
+// JournalEntry is an edit made by a tool, with a snapshot of every file it
+// touched before and after, so it can be undone
+type JournalEntry struct {
+	Label string
+	Time  time.Time
+	Files []FileSnapshot
+
+	index map[string]int
+}
+
+// FileSnapshot is the content of a file before and after an edit. A nil
+// content means the file did not exist.
+type FileSnapshot struct {
+	Path   string
+	Before []byte
+	After  []byte
+}
+
+// editJournal records the edits made while an entry is open
+type editJournal struct {
+	mu      sync.Mutex
+	entries []*JournalEntry
+	active  *JournalEntry
+	// calls is the number of calls sharing the active entry
+	calls int
+	// dirs are directories created by the active entry, removed again when
+	// it is undone if they are left empty
+	dirs map[*JournalEntry][]string
+}
+
+var journal = &editJournal{dirs: make(map[*JournalEntry][]string)}
+
+// BeginJournalEntry opens a journal entry: every file written, renamed or
+// deleted through this package until the returned function is called is
+// recorded in it. The function returns the entry, or nil if no file changed.
+// Calls that overlap, such as tools running on different servers, share an
+// entry, which is returned to the last of them to end, so that their edits
+// are not mixed up and are undone together.
+func BeginJournalEntry(label string) func() *JournalEntry {
+	journal.mu.Lock()
+	entry := journal.active
+	if entry == nil {
+		entry = &JournalEntry{Label: label, Time: time.Now(), index: make(map[string]int)}
+		journal.active = entry
+	} else {
+		entry.Label += ", " + label
+	}
+	journal.calls++
+	journal.mu.Unlock()
+
+	return func() *JournalEntry {
+		journal.mu.Lock()
+		defer journal.mu.Unlock()
+		journal.calls--
+		if journal.calls > 0 {
+			return nil
+		}
+		journal.active = nil
+		// The content after the edit is read once it is done, so changes
+		// made to the same files by anything else during the call count as
+		// part of it
+		var changed []FileSnapshot
+		for _, file := range entry.Files {
+			file.After = readSnapshot(file.Path)
+			if !sameSnapshot(file.Before, file.After) {
+				changed = append(changed, file)
+			}
+		}
+		entry.Files = changed
+		if len(entry.Files) > 0 {
+			journal.entries = append(journal.entries, entry)
+			if len(journal.entries) > maxJournalEntries {
+				delete(journal.dirs, journal.entries[0])
+				journal.entries = journal.entries[1:]
+			}
+		} else {
+			delete(journal.dirs, entry)
+			entry = nil
+		}
+		return entry
+	}
+}
+
+// JournalEntries returns the edits that can be undone, oldest first
+func JournalEntries() []JournalEntry {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+	entries := make([]JournalEntry, len(journal.entries))
+	for i, entry := range journal.entries {
+		entries[i] = *entry
+	}
+	return entries
+}
+
+// UndoLastJournalEntry restores every file touched by the last edit to its
+// content before the edit, and removes it from the journal. Unless force is
+// set, it fails without changing anything if any of the files changed since.
+// It returns nil if there is nothing to undo.
+func UndoLastJournalEntry(force bool) (*JournalEntry, error) {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	if len(journal.entries) == 0 {
+		return nil, nil
+	}
+	entry := journal.entries[len(journal.entries)-1]
+
+	if !force {
+		var changed []string
+		for _, file := range entry.Files {
+			if !sameSnapshot(readSnapshot(file.Path), file.After) {
+				changed = append(changed, file.Path)
+			}
+		}
+		if len(changed) > 0 {
+			return nil, fmt.Errorf("files changed since the edit: %v", changed)
+		}
+	}
+
+	// Deletions go last, so that files moved out of a directory can be put
+	// back before it is removed
+	files := make([]FileSnapshot, len(entry.Files))
+	copy(files, entry.Files)
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Before != nil && files[j].Before == nil
+	})
+	for _, file := range files {
+		if file.Before == nil {
+			if err := osRemove(file.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("failed to remove %s: %w", file.Path, err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(file.Path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", file.Path, err)
+		}
+		if err := osWriteFile(file.Path, file.Before, 0644); err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", file.Path, err)
+		}
+	}
+	for _, dir := range journal.dirs[entry] {
+		removeEmptyDirs(dir)
+	}
+
+	journal.entries = journal.entries[:len(journal.entries)-1]
+	delete(journal.dirs, entry)
+	return entry, nil
+}
+
+// WriteFile writes a file, recording its previous content in the open
+// journal entry
+func WriteFile(path string, content []byte) error {
+	recordSnapshot(path)
+	return osWriteFile(path, content, 0644)
+}
+
+// RenamePath renames a file or directory, recording the files it moves in
+// the open journal entry
+func RenamePath(oldPath, newPath string) error {
+	if info, err := osStat(oldPath); err == nil && info.IsDir() {
+		recordTree(oldPath, newPath)
+		journal.mu.Lock()
+		if journal.active != nil {
+			journal.dirs[journal.active] = append(journal.dirs[journal.active], newPath)
+		}
+		journal.mu.Unlock()
+	} else {
+		recordSnapshot(oldPath)
+		recordSnapshot(newPath)
+	}
+	return osRename(oldPath, newPath)
+}
+
+// removePath deletes a file, or a directory with recursive, recording the
+// files it deletes in the open journal entry
+func removePath(path string, recursive bool) error {
+	if info, err := osStat(path); err == nil && info.IsDir() {
+		recordTree(path, "")
+	} else {
+		recordSnapshot(path)
+	}
+	if recursive {
+		return osRemoveAll(path)
+	}
+	return osRemove(path)
+}
+
+// recordSnapshot records the content of a file in the open journal entry the
+// first time the entry touches it
+func recordSnapshot(path string) {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+	entry := journal.active
+	if entry == nil {
+		return
+	}
+	if _, ok := entry.index[path]; ok {
+		return
+	}
+	entry.index[path] = len(entry.Files)
+	entry.Files = append(entry.Files, FileSnapshot{Path: path, Before: readSnapshot(path)})
+}
+
+// recordTree records the files in a directory, and where they move to if a
+// new path is given
+func recordTree(dir, newDir string) {
+	journal.mu.Lock()
+	active := journal.active != nil
+	journal.mu.Unlock()
+	if !active {
+		return
+	}
+
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		recordSnapshot(path)
+		if newDir != "" {
+			rel, err := filepath.Rel(dir, path)
+			if err == nil {
+				recordSnapshot(filepath.Join(newDir, rel))
+			}
+		}
+		return nil
+	})
+}
+
+// readSnapshot returns the content of a file, or nil if it does not exist
+func readSnapshot(path string) []byte {
+	content, err := osReadFile(path)
+	if err != nil {
+		return nil
+	}
+	if content == nil {
+		content = []byte{}
+	}
+	return content
+}
+
+func sameSnapshot(a, b []byte) bool {
+	if (a == nil) != (b == nil) {
+		return false
+	}
+	return bytes.Equal(a, b)
+}
+
+// removeEmptyDirs removes a directory and the directories in it if they
+// contain no files
+func removeEmptyDirs(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			removeEmptyDirs(filepath.Join(dir, entry.Name()))
+		}
+	}
+	_ = os.Remove(dir)
+}
@@ -0,0 +1,129 @@
+package utilities
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+func TestUndoLastJournalEntry(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(path, []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	read := func(path string) string {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "<missing>"
+		}
+		return string(content)
+	}
+
+	// Edits outside an entry are not recorded
+	if err := WriteFile(path, []byte("package a\n\n// unrecorded\n")); err != nil {
+		t.Fatal(err)
+	}
+	if entry, err := UndoLastJournalEntry(false); entry != nil || err != nil {
+		t.Fatalf("Expected nothing to undo, got %+v, %v", entry, err)
+	}
+
+	// An entry that changes nothing is not kept
+	end := BeginJournalEntry("noop")
+	if err := WriteFile(path, []byte("package a\n\n// unrecorded\n")); err != nil {
+		t.Fatal(err)
+	}
+	if entry := end(); entry != nil {
+		t.Fatalf("Expected no entry for an edit that changes nothing, got %+v", entry)
+	}
+
+	end = BeginJournalEntry("first")
+	if err := WriteFile(path, []byte("package b\n")); err != nil {
+		t.Fatal(err)
+	}
+	if entry := end(); entry == nil || entry.Label != "first" || len(entry.Files) != 1 {
+		t.Fatalf("Expected an entry for the edit, got %+v", entry)
+	}
+
+	end = BeginJournalEntry("second")
+	err := ApplyWorkspaceEdit(protocol.WorkspaceEdit{
+		DocumentChanges: []protocol.DocumentChange{
+			{RenameFile: &protocol.RenameFile{
+				OldURI: protocol.DocumentUri("file://" + path),
+				NewURI: protocol.DocumentUri("file://" + filepath.Join(dir, "b.go")),
+			}},
+		},
+	})
+	end()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := UndoLastJournalEntry(false)
+	if err != nil || entry == nil || entry.Label != "second" || len(entry.Files) != 2 {
+		t.Fatalf("Unexpected undo of the rename: %+v, %v", entry, err)
+	}
+	if read(path) != "package b\n" || read(filepath.Join(dir, "b.go")) != "<missing>" {
+		t.Errorf("Rename was not undone: %q, %q", read(path), read(filepath.Join(dir, "b.go")))
+	}
+
+	// Changes made after the edit are not discarded without force
+	if err := os.WriteFile(path, []byte("package c\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UndoLastJournalEntry(false); err == nil {
+		t.Errorf("Expected undo of a file changed since to fail")
+	}
+	if read(path) != "package c\n" {
+		t.Errorf("File changed by a failed undo: %q", read(path))
+	}
+	if entry, err := UndoLastJournalEntry(true); err != nil || entry.Label != "first" {
+		t.Fatalf("Unexpected forced undo: %+v, %v", entry, err)
+	}
+	if read(path) != "package a\n\n// unrecorded\n" {
+		t.Errorf("Edit was not undone: %q", read(path))
+	}
+	if len(JournalEntries()) != 0 {
+		t.Errorf("Expected an empty journal, got %d entries", len(JournalEntries()))
+	}
+}
+
+func TestOverlappingJournalEntries(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")
+
+	endFirst := BeginJournalEntry("edit_file a.go")
+	if err := WriteFile(a, []byte("package a\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// A call that starts while the first is running neither waits for it
+	// nor opens an entry of its own
+	done := make(chan *JournalEntry)
+	go func() {
+		endSecond := BeginJournalEntry("format_document b.go")
+		if err := WriteFile(b, []byte("package b\n")); err != nil {
+			t.Error(err)
+		}
+		done <- endSecond()
+	}()
+	if entry := <-done; entry != nil {
+		t.Fatalf("Expected the entry to stay open while the first call runs, got %+v", entry)
+	}
+
+	entry := endFirst()
+	if entry == nil || entry.Label != "edit_file a.go, format_document b.go" || len(entry.Files) != 2 {
+		t.Fatalf("Expected one entry for both calls, got %+v", entry)
+	}
+
+	if _, err := UndoLastJournalEntry(false); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{a, b} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed by the undo", path)
+		}
+	}
+}
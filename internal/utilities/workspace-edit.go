@@ -0,0 +1,429 @@
+package utilities
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+// EditPlan is the effect of a workspace edit, worked out without changing
+// anything so that it can be previewed or applied as a whole
+type EditPlan struct {
+	// Files are the files whose content the edit changes, including the
+	// files it creates and deletes, sorted by path
+	Files []FileChange
+	// Operations are the files and directories created, renamed and deleted,
+	// in the order they happen
+	Operations []FileOperation
+	// Annotations describe the changes the edit labels, sorted by label
+	Annotations []AnnotatedChange
+
+	steps []planStep
+}
+
+// FileChange is the content of a file before and after a workspace edit
+type FileChange struct {
+	// Path is where the file ends up, OldPath where it was if it is renamed
+	Path    string
+	OldPath string
+	Before  string
+	After   string
+	Created bool
+	Deleted bool
+}
+
+// FileOperation is a file or directory created, renamed or deleted by a
+// workspace edit
+type FileOperation struct {
+	Kind    protocol.ResourceOperationKind
+	Path    string
+	NewPath string
+}
+
+// AnnotatedChange is a change annotation of a workspace edit with the number
+// of text edits and file operations it labels
+type AnnotatedChange struct {
+	protocol.ChangeAnnotation
+	Changes int
+}
+
+// planStep is a file operation to perform when the plan is applied
+type planStep struct {
+	op        FileOperation
+	recursive bool
+}
+
+// plannedFile is the content of a file as the plan is worked out
+type plannedFile struct {
+	path    string
+	oldPath string
+	before  []byte
+	content []byte
+	created bool
+	deleted bool
+}
+
+// editPlanner replays a workspace edit over the file system in memory
+type editPlanner struct {
+	edit protocol.WorkspaceEdit
+	plan *EditPlan
+	// files maps the current path of each file read or created so far to
+	// its content
+	files map[string]*plannedFile
+	// order is every file planned, in the order they were first touched
+	order []*plannedFile
+	// movedDirs maps directories renamed so far from their new path to
+	// their path on disk
+	movedDirs map[string]string
+	// removedDirs are the directories deleted so far
+	removedDirs []string
+	annotations map[protocol.ChangeAnnotationIdentifier]int
+}
+
+// PlanWorkspaceEdit works out the effect of a workspace edit: its text edits,
+// including annotated ones, and the files it creates, renames and deletes.
+// Text edits in Changes are planned first, then DocumentChanges in order, so
+// edits see the files as the operations before them left them. Nothing is
+// written, and an edit that cannot be applied fails as a whole.
+func PlanWorkspaceEdit(edit protocol.WorkspaceEdit) (*EditPlan, error) {
+	p := &editPlanner{
+		edit:        edit,
+		plan:        &EditPlan{},
+		files:       make(map[string]*plannedFile),
+		movedDirs:   make(map[string]string),
+		annotations: make(map[protocol.ChangeAnnotationIdentifier]int),
+	}
+
+	uris := make([]string, 0, len(edit.Changes))
+	for uri := range edit.Changes {
+		uris = append(uris, string(uri))
+	}
+	sort.Strings(uris)
+	for _, uri := range uris {
+		if err := p.editFile(uriPath(protocol.DocumentUri(uri)), edit.Changes[protocol.DocumentUri(uri)]); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, change := range edit.DocumentChanges {
+		var err error
+		switch {
+		case change.TextDocumentEdit != nil:
+			err = p.textDocumentEdit(*change.TextDocumentEdit)
+		case change.CreateFile != nil:
+			p.annotate(change.CreateFile.AnnotationID)
+			err = p.createFile(*change.CreateFile)
+		case change.RenameFile != nil:
+			p.annotate(change.RenameFile.AnnotationID)
+			err = p.renameFile(*change.RenameFile)
+		case change.DeleteFile != nil:
+			p.annotate(change.DeleteFile.AnnotationID)
+			err = p.deleteFile(*change.DeleteFile)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p.finish(), nil
+}
+
+// Apply performs the file operations of the plan in order and then writes
+// the new content of every file it changes
+func (plan *EditPlan) Apply() error {
+	for _, step := range plan.steps {
+		var err error
+		switch step.op.Kind {
+		case protocol.Create:
+			err = WriteFile(step.op.Path, []byte(""))
+		case protocol.Rename:
+			err = RenamePath(step.op.Path, step.op.NewPath)
+		case protocol.Delete:
+			err = removePath(step.op.Path, step.recursive)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to %s %s: %w", step.op.Kind, step.op.Path, err)
+		}
+	}
+
+	for _, file := range plan.Files {
+		if file.Deleted || (file.Before == file.After && !file.Created) {
+			continue
+		}
+		if err := WriteFile(file.Path, []byte(file.After)); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+	}
+	return nil
+}
+
+// ApplyWorkspaceEdit applies the given WorkspaceEdit to the filesystem. The
+// whole edit is planned first, so an edit that cannot be applied changes
+// nothing.
+func ApplyWorkspaceEdit(edit protocol.WorkspaceEdit) error {
+	plan, err := PlanWorkspaceEdit(edit)
+	if err != nil {
+		return fmt.Errorf("failed to apply workspace edit: %w", err)
+	}
+	return plan.Apply()
+}
+
+func uriPath(uri protocol.DocumentUri) string {
+	return strings.TrimPrefix(string(uri), "file://")
+}
+
+func (p *editPlanner) annotate(id *protocol.ChangeAnnotationIdentifier) {
+	if id != nil {
+		p.annotations[*id]++
+	}
+}
+
+func (p *editPlanner) textDocumentEdit(change protocol.TextDocumentEdit) error {
+	edits := make([]protocol.TextEdit, len(change.Edits))
+	for i, e := range change.Edits {
+		if annotated, ok := e.Value.(protocol.AnnotatedTextEdit); ok {
+			p.annotate(annotated.AnnotationID)
+		}
+		var err error
+		edits[i], err = e.AsTextEdit()
+		if err != nil {
+			return fmt.Errorf("invalid edit type: %w", err)
+		}
+	}
+	return p.editFile(uriPath(change.TextDocument.URI), edits)
+}
+
+func (p *editPlanner) editFile(path string, edits []protocol.TextEdit) error {
+	file, err := p.file(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	content, err := EditContent(file.content, edits)
+	if err != nil {
+		return fmt.Errorf("failed to edit %s: %w", path, err)
+	}
+	file.content = content
+	return nil
+}
+
+func (p *editPlanner) createFile(change protocol.CreateFile) error {
+	path := uriPath(change.URI)
+	if p.exists(path) {
+		overwrite := change.Options != nil && change.Options.Overwrite
+		if !overwrite && change.Options != nil && change.Options.IgnoreIfExists {
+			return nil
+		}
+		// Otherwise an existing file is emptied
+		file, err := p.file(path)
+		if err != nil {
+			return fmt.Errorf("failed to create file: %w", err)
+		}
+		file.content = nil
+	} else {
+		p.track(&plannedFile{path: path, created: true})
+	}
+	p.step(planStep{op: FileOperation{Kind: protocol.Create, Path: path}})
+	return nil
+}
+
+func (p *editPlanner) renameFile(change protocol.RenameFile) error {
+	oldPath, newPath := uriPath(change.OldURI), uriPath(change.NewURI)
+	if p.exists(newPath) {
+		if change.Options != nil && change.Options.IgnoreIfExists {
+			return nil
+		}
+		if change.Options == nil || !change.Options.Overwrite {
+			return fmt.Errorf("target file already exists and overwrite is not allowed: %s", newPath)
+		}
+		p.remove(newPath)
+	}
+
+	if p.isDir(oldPath) {
+		for path, file := range p.files {
+			if file != nil && strings.HasPrefix(path, oldPath+"/") {
+				p.move(file, newPath+strings.TrimPrefix(path, oldPath))
+			}
+		}
+		p.movedDirs[newPath] = p.diskPath(oldPath)
+	} else {
+		file, err := p.file(oldPath)
+		if err != nil {
+			return fmt.Errorf("failed to rename file: %w", err)
+		}
+		p.move(file, newPath)
+	}
+
+	p.step(planStep{op: FileOperation{Kind: protocol.Rename, Path: oldPath, NewPath: newPath}})
+	return nil
+}
+
+func (p *editPlanner) deleteFile(change protocol.DeleteFile) error {
+	path := uriPath(change.URI)
+	recursive := change.Options != nil && change.Options.Recursive
+	if !p.exists(path) {
+		if change.Options != nil && change.Options.IgnoreIfNotExists {
+			return nil
+		}
+		return fmt.Errorf("failed to delete file: %s does not exist", path)
+	}
+	if p.isDir(path) && !recursive {
+		return fmt.Errorf("failed to delete directory %s: not recursive", path)
+	}
+
+	p.remove(path)
+	p.step(planStep{op: FileOperation{Kind: protocol.Delete, Path: path}, recursive: recursive})
+	return nil
+}
+
+func (p *editPlanner) step(step planStep) {
+	p.plan.steps = append(p.plan.steps, step)
+	p.plan.Operations = append(p.plan.Operations, step.op)
+}
+
+func (p *editPlanner) track(file *plannedFile) {
+	p.files[file.path] = file
+	p.order = append(p.order, file)
+}
+
+func (p *editPlanner) move(file *plannedFile, newPath string) {
+	p.files[file.path] = nil
+	file.path = newPath
+	p.files[newPath] = file
+}
+
+// remove marks a file, or a directory and the files in it, as deleted
+func (p *editPlanner) remove(path string) {
+	if file, err := p.file(path); err == nil {
+		file.deleted = true
+		p.files[path] = nil
+	} else {
+		p.removedDirs = append(p.removedDirs, path)
+	}
+	for filePath, file := range p.files {
+		if file != nil && strings.HasPrefix(filePath, path+"/") {
+			file.deleted = true
+			p.files[filePath] = nil
+		}
+	}
+	for newDir := range p.movedDirs {
+		if newDir == path || strings.HasPrefix(newDir, path+"/") {
+			delete(p.movedDirs, newDir)
+		}
+	}
+}
+
+// file returns the planned content of a file, reading it from disk the first
+// time it is needed
+func (p *editPlanner) file(path string) (*plannedFile, error) {
+	if file, ok := p.files[path]; ok {
+		if file == nil {
+			return nil, fmt.Errorf("%s: %w", path, fs.ErrNotExist)
+		}
+		return file, nil
+	}
+	if p.removed(path) {
+		return nil, fmt.Errorf("%s: %w", path, fs.ErrNotExist)
+	}
+
+	diskPath := p.diskPath(path)
+	content, err := osReadFile(diskPath)
+	if err != nil {
+		return nil, err
+	}
+	file := &plannedFile{path: path, oldPath: diskPath, before: content, content: content}
+	p.track(file)
+	return file, nil
+}
+
+// diskPath is where a path is on disk, before the directories renamed so
+// far are moved
+func (p *editPlanner) diskPath(path string) string {
+	for newDir, oldDir := range p.movedDirs {
+		if path == newDir || strings.HasPrefix(path, newDir+"/") {
+			return oldDir + strings.TrimPrefix(path, newDir)
+		}
+	}
+	return path
+}
+
+func (p *editPlanner) removed(path string) bool {
+	if p.diskPath(path) != path {
+		return false
+	}
+	for _, dir := range p.removedDirs {
+		if path == dir || strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *editPlanner) exists(path string) bool {
+	if file, ok := p.files[path]; ok {
+		return file != nil
+	}
+	if p.removed(path) {
+		return false
+	}
+	if _, err := osStat(p.diskPath(path)); err == nil {
+		return true
+	}
+	_, err := osReadFile(p.diskPath(path))
+	return err == nil || !errors.Is(err, fs.ErrNotExist)
+}
+
+func (p *editPlanner) isDir(path string) bool {
+	if _, ok := p.files[path]; ok {
+		return false
+	}
+	info, err := osStat(p.diskPath(path))
+	return err == nil && info.IsDir()
+}
+
+// finish lists the files changed and the annotations used
+func (p *editPlanner) finish() *EditPlan {
+	for _, file := range p.order {
+		if !file.created && !file.deleted && bytes.Equal(file.before, file.content) {
+			continue
+		}
+		change := FileChange{
+			Path:    file.path,
+			Before:  string(file.before),
+			After:   string(file.content),
+			Created: file.created,
+			Deleted: file.deleted,
+		}
+		if file.oldPath != "" && file.oldPath != file.path {
+			change.OldPath = file.oldPath
+		}
+		if file.deleted {
+			change.Path = file.oldPath
+			change.OldPath = ""
+			change.After = ""
+		}
+		if file.created && file.deleted {
+			continue
+		}
+		p.plan.Files = append(p.plan.Files, change)
+	}
+	sort.SliceStable(p.plan.Files, func(i, j int) bool {
+		return p.plan.Files[i].Path < p.plan.Files[j].Path
+	})
+
+	for id, count := range p.annotations {
+		annotation, ok := p.edit.ChangeAnnotations[id]
+		if !ok {
+			annotation = protocol.ChangeAnnotation{Label: id}
+		}
+		p.plan.Annotations = append(p.plan.Annotations, AnnotatedChange{ChangeAnnotation: annotation, Changes: count})
+	}
+	sort.Slice(p.plan.Annotations, func(i, j int) bool {
+		return p.plan.Annotations[i].Label < p.plan.Annotations[j].Label
+	})
+	return p.plan
+}
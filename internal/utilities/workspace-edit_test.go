@@ -0,0 +1,150 @@
+package utilities
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+func textEdit(line, start, end uint32, newText string) protocol.TextEdit {
+	return protocol.TextEdit{
+		Range: protocol.Range{
+			Start: protocol.Position{Line: line, Character: start},
+			End:   protocol.Position{Line: line, Character: end},
+		},
+		NewText: newText,
+	}
+}
+
+func TestPlanWorkspaceEdit(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.go", "package a\n\nfunc Old() {}\n")
+	write("b.go", "package a\n\nvar _ = Old\n")
+	write("stale.go", "package a\n")
+	uri := func(name string) protocol.DocumentUri {
+		return protocol.DocumentUri("file://" + filepath.Join(dir, name))
+	}
+
+	annotation := "rename"
+	edit := protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+			uri("b.go"): {textEdit(2, 8, 11, "New")},
+		},
+		DocumentChanges: []protocol.DocumentChange{
+			{TextDocumentEdit: &protocol.TextDocumentEdit{
+				TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+					TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: uri("a.go")},
+				},
+				Edits: []protocol.Or_TextDocumentEdit_edits_Elem{
+					{Value: protocol.AnnotatedTextEdit{AnnotationID: &annotation, TextEdit: textEdit(2, 5, 8, "New")}},
+				},
+			}},
+			// Edits after a rename refer to the file at its new path
+			{RenameFile: &protocol.RenameFile{OldURI: uri("a.go"), NewURI: uri("new.go")}},
+			{TextDocumentEdit: &protocol.TextDocumentEdit{
+				TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+					TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: uri("new.go")},
+				},
+				Edits: []protocol.Or_TextDocumentEdit_edits_Elem{
+					{Value: textEdit(0, 8, 9, "b")},
+				},
+			}},
+			{CreateFile: &protocol.CreateFile{URI: uri("doc.go")}},
+			{DeleteFile: &protocol.DeleteFile{URI: uri("stale.go"), ResourceOperation: protocol.ResourceOperation{AnnotationID: &annotation}}},
+		},
+		ChangeAnnotations: map[protocol.ChangeAnnotationIdentifier]protocol.ChangeAnnotation{
+			annotation: {Label: "Rename Old", NeedsConfirmation: true},
+		},
+	}
+
+	plan, err := PlanWorkspaceEdit(edit)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Planning writes nothing
+	if content, _ := os.ReadFile(filepath.Join(dir, "a.go")); string(content) != "package a\n\nfunc Old() {}\n" {
+		t.Errorf("a.go was changed by planning: %s", content)
+	}
+
+	if len(plan.Files) != 4 {
+		t.Fatalf("Expected 4 changed files, got %d: %+v", len(plan.Files), plan.Files)
+	}
+	byPath := make(map[string]FileChange)
+	for _, file := range plan.Files {
+		byPath[filepath.Base(file.Path)] = file
+	}
+	if got := byPath["new.go"]; got.After != "package b\n\nfunc New() {}\n" || got.OldPath != filepath.Join(dir, "a.go") {
+		t.Errorf("Unexpected change to the renamed file: %+v", got)
+	}
+	if got := byPath["b.go"]; got.After != "package a\n\nvar _ = New\n" {
+		t.Errorf("Unexpected change to b.go: %+v", got)
+	}
+	if got := byPath["doc.go"]; !got.Created {
+		t.Errorf("Expected doc.go to be created: %+v", got)
+	}
+	if got := byPath["stale.go"]; !got.Deleted || got.Before != "package a\n" {
+		t.Errorf("Expected stale.go to be deleted: %+v", got)
+	}
+	if len(plan.Operations) != 3 {
+		t.Errorf("Expected 3 file operations, got %+v", plan.Operations)
+	}
+	if len(plan.Annotations) != 1 || plan.Annotations[0].Label != "Rename Old" || plan.Annotations[0].Changes != 2 || !plan.Annotations[0].NeedsConfirmation {
+		t.Errorf("Unexpected annotations: %+v", plan.Annotations)
+	}
+
+	if err := plan.Apply(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for name, want := range map[string]string{
+		"new.go": "package b\n\nfunc New() {}\n",
+		"b.go":   "package a\n\nvar _ = New\n",
+		"doc.go": "",
+	} {
+		if content, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(content) != want {
+			t.Errorf("Unexpected content of %s: %q (%v)", name, content, err)
+		}
+	}
+	for _, name := range []string{"a.go", "stale.go"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be gone", name)
+		}
+	}
+}
+
+func TestPlanWorkspaceEditFailsAsAWhole(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(path, []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := ApplyWorkspaceEdit(protocol.WorkspaceEdit{
+		DocumentChanges: []protocol.DocumentChange{
+			{CreateFile: &protocol.CreateFile{URI: protocol.DocumentUri("file://" + filepath.Join(dir, "new.go"))}},
+			{RenameFile: &protocol.RenameFile{
+				OldURI: protocol.DocumentUri("file://" + filepath.Join(dir, "missing.go")),
+				NewURI: protocol.DocumentUri("file://" + path),
+				Options: &protocol.RenameFileOptions{
+					Overwrite: true,
+				},
+			}},
+		},
+	})
+	if err == nil {
+		t.Fatal("Expected error but got none")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new.go")); !os.IsNotExist(err) {
+		t.Errorf("new.go was created although the edit failed")
+	}
+	if content, _ := os.ReadFile(path); string(content) != "package a\n" {
+		t.Errorf("a.go was changed although the edit failed: %s", content)
+	}
+}
@@ -0,0 +1,85 @@
+package watcher
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// globCache holds the compiled form of each glob pattern, as the same few
+// patterns are matched against every file event
+var globCache sync.Map
+
+// compileGlob translates an LSP glob pattern to a regular expression that
+// matches whole slash-separated paths:
+//   - * matches zero or more characters in a path segment
+//   - ? matches one character in a path segment
+//   - ** matches any number of path segments, including none
+//   - {} groups alternatives, e.g. **/*.{go,mod}
+//   - [] matches a range of characters, [!...] any character not in it
+func compileGlob(pattern string) (*regexp.Regexp, error) {
+	if re, ok := globCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	groups := 0
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; ch {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// **/ also matches no directory at all
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '{':
+			groups++
+			b.WriteString("(?:")
+		case '}':
+			if groups == 0 {
+				b.WriteString(`\}`)
+				continue
+			}
+			groups--
+			b.WriteString(")")
+		case ',':
+			if groups == 0 {
+				b.WriteString(",")
+				continue
+			}
+			b.WriteString("|")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, err
+	}
+	globCache.Store(pattern, re)
+	return re, nil
+}
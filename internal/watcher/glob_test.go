@@ -0,0 +1,72 @@
+package watcher
+
+import (
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+)
+
+func TestCompileGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"**/*.go", "/ws/main.go", true},
+		{"**/*.go", "/ws/internal/lsp/client.go", true},
+		{"**/*.go", "/ws/go.mod", false},
+		{"**/go.mod", "/ws/go.mod", true},
+		{"**/go.mod", "/ws/main.go", false},
+		{"**/*.{go,mod,sum}", "/ws/go.sum", true},
+		{"**/*.{go,mod,sum}", "/ws/README.md", false},
+		{"src/**/*.ts", "src/index.ts", true},
+		{"src/**/*.ts", "src/a/b/index.ts", true},
+		{"src/**/*.ts", "lib/index.ts", false},
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"file?.txt", "file1.txt", true},
+		{"file?.txt", "file10.txt", false},
+		{"v[0-9].json", "v2.json", true},
+		{"v[!0-9].json", "v2.json", false},
+		{"**", "/any/path", true},
+	}
+	for _, tt := range tests {
+		re, err := compileGlob(tt.pattern)
+		if err != nil {
+			t.Fatalf("compileGlob(%q) failed: %v", tt.pattern, err)
+		}
+		if got := re.MatchString(tt.path); got != tt.want {
+			t.Errorf("compileGlob(%q) matching %q = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+
+	if _, err := compileGlob("**/*.{go,mod"); err == nil {
+		t.Errorf("expected an error for an unterminated group")
+	}
+}
+
+func TestMatchesPattern(t *testing.T) {
+	w := NewWorkspaceWatcher(nil)
+	relative := protocol.GlobPattern{Value: protocol.RelativePattern{
+		BaseURI: protocol.Or_RelativePattern_baseUri{Value: protocol.DocumentUri("file:///ws/web")},
+		Pattern: "**/*.ts",
+	}}
+
+	tests := []struct {
+		pattern protocol.GlobPattern
+		path    string
+		want    bool
+	}{
+		{protocol.GlobPattern{Value: "**/*.go"}, "/ws/main.go", true},
+		// Patterns that name no directory also match file names
+		{protocol.GlobPattern{Value: "*.go"}, "/ws/cmd/main.go", true},
+		{protocol.GlobPattern{Value: "**/go.mod"}, "/ws/main.go", false},
+		{relative, "/ws/web/src/index.ts", true},
+		{relative, "/ws/api/index.ts", false},
+	}
+	for _, tt := range tests {
+		if got := w.matchesPattern(tt.path, tt.pattern); got != tt.want {
+			t.Errorf("matchesPattern(%q, %v) = %v, want %v", tt.path, tt.pattern.Value, got, tt.want)
+		}
+	}
+}
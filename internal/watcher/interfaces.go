@@ -30,6 +30,10 @@ type WatcherConfig struct {
 	// ExcludedDirs are directory names that should be excluded from watching
 	ExcludedDirs map[string]bool
 
+	// ExcludedPaths are absolute paths of directories that should be excluded
+	// from watching
+	ExcludedPaths map[string]bool
+
 	// ExcludedFileExtensions are file extensions that should be excluded from watching
 	ExcludedFileExtensions map[string]bool
 
@@ -58,6 +62,7 @@ func DefaultWatcherConfig() *WatcherConfig {
 			"target":       true, // Rust build output
 			"vendor":       true, // Go vendor directory
 		},
+		ExcludedPaths: map[string]bool{},
 		ExcludedFileExtensions: map[string]bool{
 			".swp":   true,
 			".swo":   true,
@@ -27,14 +27,21 @@ type WorkspaceWatcher struct {
 	debounceMap map[string]*time.Timer
 	debounceMu  sync.Mutex
 
-	// File watchers registered by the server
-	registrations  []protocol.FileSystemWatcher
+	// File watchers registered by the server, by registration ID
+	registrations  map[string][]protocol.FileSystemWatcher
 	registrationMu sync.RWMutex
 
 	// Gitignore matcher
 	gitignore *GitignoreMatcher
+
+	// Called after the server has been told about a file change
+	changeHandler FileChangeHandler
 }
 
+// FileChangeHandler is called with the path of a changed, created or deleted
+// file after the language server has been notified of the change
+type FileChangeHandler func(ctx context.Context, path string, changeType protocol.FileChangeType)
+
 // NewWorkspaceWatcher creates a new workspace watcher with default configuration
 func NewWorkspaceWatcher(client LSPClient) *WorkspaceWatcher {
 	return NewWorkspaceWatcherWithConfig(client, DefaultWatcherConfig())
@@ -46,20 +53,41 @@ func NewWorkspaceWatcherWithConfig(client LSPClient, config *WatcherConfig) *Wor
 		client:        client,
 		config:        config,
 		debounceMap:   make(map[string]*time.Timer),
-		registrations: []protocol.FileSystemWatcher{},
+		registrations: make(map[string][]protocol.FileSystemWatcher),
 	}
 }
 
-// AddRegistrations adds file watchers to track
+// SetChangeHandler sets a handler for file changes. It must be called before
+// WatchWorkspace.
+func (w *WorkspaceWatcher) SetChangeHandler(handler FileChangeHandler) {
+	w.changeHandler = handler
+}
+
+// fileWatchRegistrar is implemented by clients that report the file
+// watchers the server registers and unregisters
+type fileWatchRegistrar interface {
+	AddFileWatchHandler(handler lsp.FileWatchHandler)
+}
+
+// RemoveRegistrations stops tracking the file watchers of a registration
+func (w *WorkspaceWatcher) RemoveRegistrations(id string) {
+	w.registrationMu.Lock()
+	defer w.registrationMu.Unlock()
+	delete(w.registrations, id)
+	watcherLogger.Info("Removed file watcher registration (id: %s), remaining: %d", id, len(w.registrations))
+}
+
+// AddRegistrations adds file watchers to track, replacing those registered
+// before with the same ID
 func (w *WorkspaceWatcher) AddRegistrations(ctx context.Context, id string, watchers []protocol.FileSystemWatcher) {
 	w.registrationMu.Lock()
 	defer w.registrationMu.Unlock()
 
 	// Add new watchers
-	w.registrations = append(w.registrations, watchers...)
+	w.registrations[id] = watchers
 
 	// Log registration information
-	watcherLogger.Info("Added %d file watcher registrations (id: %s), total: %d",
+	watcherLogger.Info("Added %d file watchers (id: %s), registrations: %d",
 		len(watchers), id, len(w.registrations))
 
 	// Detailed debug information about registrations
@@ -167,9 +195,19 @@ func (w *WorkspaceWatcher) WatchWorkspace(ctx context.Context, workspacePath str
 	}
 
 	// Register handler for file watcher registrations from the server
-	lsp.RegisterFileWatchHandler(func(id string, watchers []protocol.FileSystemWatcher) {
-		w.AddRegistrations(ctx, id, watchers)
-	})
+	if registrar, ok := w.client.(fileWatchRegistrar); ok {
+		registrar.AddFileWatchHandler(func(id string, watchers []protocol.FileSystemWatcher) {
+			if ctx.Err() != nil {
+				// The watcher has stopped
+				return
+			}
+			if watchers == nil {
+				w.RemoveRegistrations(id)
+				return
+			}
+			w.AddRegistrations(ctx, id, watchers)
+		})
+	}
 
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
@@ -324,124 +362,31 @@ func (w *WorkspaceWatcher) isPathWatched(path string) (bool, protocol.WatchKind)
 		return true, protocol.WatchKind(protocol.WatchChange | protocol.WatchCreate | protocol.WatchDelete)
 	}
 
-	// Check each registration
-	for _, reg := range w.registrations {
-		isMatch := w.matchesPattern(path, reg.GlobPattern)
-		if isMatch {
-			kind := protocol.WatchKind(protocol.WatchChange | protocol.WatchCreate | protocol.WatchDelete)
-			if reg.Kind != nil {
-				kind = *reg.Kind
-			}
-			return true, kind
-		}
-	}
-
-	return false, 0
-}
-
-// matchesGlob handles advanced glob patterns including ** and alternatives
-func matchesGlob(pattern, path string) bool {
-	// Handle file extension patterns with braces like *.{go,mod,sum}
-	if strings.Contains(pattern, "{") && strings.Contains(pattern, "}") {
-		// Extract extensions from pattern like "*.{go,mod,sum}"
-		parts := strings.SplitN(pattern, "{", 2)
-		if len(parts) == 2 {
-			prefix := parts[0]
-			extPart := strings.SplitN(parts[1], "}", 2)
-			if len(extPart) == 2 {
-				extensions := strings.Split(extPart[0], ",")
-				suffix := extPart[1]
-
-				// Check if the path matches any of the extensions
-				for _, ext := range extensions {
-					extPattern := prefix + ext + suffix
-					isMatch := matchesSimpleGlob(extPattern, path)
-					if isMatch {
-						return true
-					}
-				}
-				return false
+	// Check each registration. A path matched by several watchers is
+	// watched for the changes any of them asks for.
+	matched := false
+	var kind protocol.WatchKind
+	for _, watchers := range w.registrations {
+		for _, reg := range watchers {
+			if !w.matchesPattern(path, reg.GlobPattern) {
+				continue
 			}
-		}
-	}
-
-	return matchesSimpleGlob(pattern, path)
-}
-
-// matchesSimpleGlob handles glob patterns with ** wildcards
-func matchesSimpleGlob(pattern, path string) bool {
-	// Handle special case for **/*.ext pattern (common in LSP)
-	if strings.HasPrefix(pattern, "**/") {
-		rest := strings.TrimPrefix(pattern, "**/")
-
-		// If the rest is a simple file extension pattern like *.go
-		if strings.HasPrefix(rest, "*.") {
-			ext := strings.TrimPrefix(rest, "*")
-			isMatch := strings.HasSuffix(path, ext)
-			return isMatch
-		}
-
-		// Otherwise, try to check if the path ends with the rest part
-		isMatch := strings.HasSuffix(path, rest)
-
-		// If it matches directly, great!
-		if isMatch {
-			return true
-		}
-
-		// Otherwise, check if any path component matches
-		pathComponents := strings.Split(path, "/")
-		for i := range pathComponents {
-			subPath := strings.Join(pathComponents[i:], "/")
-			if strings.HasSuffix(subPath, rest) {
-				return true
+			matched = true
+			if reg.Kind != nil {
+				kind |= *reg.Kind
+			} else {
+				kind |= protocol.WatchKind(protocol.WatchChange | protocol.WatchCreate | protocol.WatchDelete)
 			}
 		}
-
-		return false
-	}
-
-	// Handle other ** wildcard pattern cases
-	if strings.Contains(pattern, "**") {
-		parts := strings.Split(pattern, "**")
-
-		// Validate the path starts with the first part
-		if !strings.HasPrefix(path, parts[0]) && parts[0] != "" {
-			return false
-		}
-
-		// For patterns like "**/*.go", just check the suffix
-		if len(parts) == 2 && parts[0] == "" {
-			isMatch := strings.HasSuffix(path, parts[1])
-			return isMatch
-		}
-
-		// For other patterns, handle middle part
-		remaining := strings.TrimPrefix(path, parts[0])
-		if len(parts) == 2 {
-			isMatch := strings.HasSuffix(remaining, parts[1])
-			return isMatch
-		}
-	}
-
-	// Handle simple * wildcard for file extension patterns (*.go, *.sum, etc)
-	if strings.HasPrefix(pattern, "*.") {
-		ext := strings.TrimPrefix(pattern, "*")
-		isMatch := strings.HasSuffix(path, ext)
-		return isMatch
-	}
-
-	// Fall back to simple matching for simpler patterns
-	matched, err := filepath.Match(pattern, path)
-	if err != nil {
-		watcherLogger.Error("Error matching pattern %s: %v", pattern, err)
-		return false
 	}
 
-	return matched
+	return matched, kind
 }
 
-// matchesPattern checks if a path matches the glob pattern
+// matchesPattern checks if a path matches the glob pattern of a file watcher.
+// Relative patterns are matched against the path relative to their base, and
+// other patterns against the absolute path or, if they name no directory,
+// the file name.
 func (w *WorkspaceWatcher) matchesPattern(path string, pattern protocol.GlobPattern) bool {
 	patternInfo, err := pattern.AsPattern()
 	if err != nil {
@@ -452,54 +397,34 @@ func (w *WorkspaceWatcher) matchesPattern(path string, pattern protocol.GlobPatt
 	basePath := patternInfo.GetBasePath()
 	patternText := patternInfo.GetPattern()
 
-	// watcherLogger.Debug("Matching path %s against pattern %s (base: %s)", path, patternText, basePath)
+	re, err := compileGlob(patternText)
+	if err != nil {
+		watcherLogger.Error("Error compiling pattern %s: %v", patternText, err)
+		return false
+	}
 
 	path = filepath.ToSlash(path)
 
-	// Special handling for wildcard patterns like "**/*"
-	if patternText == "**/*" {
-		// This should match any file
-		// watcherLogger.Debug("Using special matching for **/* pattern")
-		return true
-	}
-
-	// Special handling for wildcard patterns like "**/*.ext"
-	if strings.HasPrefix(patternText, "**/") {
-		if strings.HasPrefix(strings.TrimPrefix(patternText, "**/"), "*.") {
-			// Extension pattern like **/*.go
-			ext := strings.TrimPrefix(strings.TrimPrefix(patternText, "**/"), "*")
-			// watcherLogger.Debug("Using extension matching for **/*.ext pattern: checking if %s ends with %s", path, ext)
-			return strings.HasSuffix(path, ext)
-		} else {
-			// Any other pattern starting with **/ should match any path
-			// watcherLogger.Debug("Using path substring matching for **/ pattern")
+	if basePath == "" {
+		if re.MatchString(path) {
 			return true
 		}
+		return !strings.Contains(patternText, "/") && re.MatchString(filepath.Base(path))
 	}
 
-	// For simple patterns without base path
-	if basePath == "" {
-		// Check if the pattern matches the full path or just the file extension
-		fullPathMatch := matchesGlob(patternText, path)
-		baseNameMatch := matchesGlob(patternText, filepath.Base(path))
-
-		watcherLogger.Debug("No base path, fullPathMatch: %v, baseNameMatch: %v", fullPathMatch, baseNameMatch)
-		return fullPathMatch || baseNameMatch
-	}
-
-	// For relative patterns
-	basePath = strings.TrimPrefix(basePath, "file://")
-	basePath = filepath.ToSlash(basePath)
-
 	// Make path relative to basePath for matching
+	basePath = filepath.ToSlash(strings.TrimPrefix(basePath, "file://"))
 	relPath, err := filepath.Rel(basePath, path)
 	if err != nil {
 		watcherLogger.Error("Error getting relative path for %s: %v", path, err)
 		return false
 	}
 	relPath = filepath.ToSlash(relPath)
+	if relPath == ".." || strings.HasPrefix(relPath, "../") {
+		return false
+	}
 
-	isMatch := matchesGlob(patternText, relPath)
+	isMatch := re.MatchString(relPath)
 	watcherLogger.Debug("Relative path matching: %s against %s = %v", relPath, patternText, isMatch)
 
 	return isMatch
@@ -538,12 +463,13 @@ func (w *WorkspaceWatcher) handleFileEvent(ctx context.Context, uri string, chan
 		if err != nil {
 			watcherLogger.Error("Error notifying change: %v", err)
 		}
-		return
+	} else if err := w.notifyFileEvent(ctx, uri, changeType); err != nil {
+		// Notify LSP server about the file event using didChangeWatchedFiles
+		watcherLogger.Error("Error notifying LSP server about file event: %v", err)
 	}
 
-	// Notify LSP server about the file event using didChangeWatchedFiles
-	if err := w.notifyFileEvent(ctx, uri, changeType); err != nil {
-		watcherLogger.Error("Error notifying LSP server about file event: %v", err)
+	if w.changeHandler != nil {
+		w.changeHandler(ctx, filePath, changeType)
 	}
 }
 
@@ -573,7 +499,7 @@ func (w *WorkspaceWatcher) shouldExcludeDir(dirPath string) bool {
 	}
 
 	// Skip common excluded directories
-	if w.config.ExcludedDirs[dirName] {
+	if w.config.ExcludedDirs[dirName] || w.config.ExcludedPaths[dirPath] {
 		return true
 	}
 
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// macroConfig defines a tool that calls a sequence of existing tools
+type macroConfig struct {
+	Description string                `json:"description"`
+	Params      map[string]macroParam `json:"params"`
+	Steps       []macroStep           `json:"steps"`
+}
+
+// macroParam is a parameter of a macro, passed on to its steps through templates
+type macroParam struct {
+	// Type is string, number or boolean. Defaults to string.
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+}
+
+// macroStep is a single tool call in a macro. String arguments are Go
+// text/template templates executed with the macro's parameters.
+type macroStep struct {
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+// parameterReference matches a string argument that is nothing but a reference
+// to a parameter, which is passed through with its original type
+var parameterReference = regexp.MustCompile(`^\{\{\s*\.(\w+)\s*\}\}$`)
+
+// registerMacros registers each configured macro as a tool. Macros may only
+// call tools that are already registered, so they cannot call each other.
+func (s *mcpServer) registerMacros() error {
+	names := make([]string, 0, len(s.config.macros))
+	for name := range s.config.macros {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		macro := s.config.macros[name]
+		if _, ok := s.toolHandlers[name]; ok {
+			return fmt.Errorf("macro %s has the same name as a tool", name)
+		}
+
+		tool, err := macroTool(name, macro)
+		if err != nil {
+			return fmt.Errorf("invalid macro %s: %v", name, err)
+		}
+
+		templates, err := s.parseMacroSteps(macro)
+		if err != nil {
+			return fmt.Errorf("invalid macro %s: %v", name, err)
+		}
+
+		// The manifest lists the LSP methods of every step
+		seen := make(map[string]bool)
+		for _, step := range macro.Steps {
+			for _, method := range s.toolMethods[step.Tool] {
+				if !seen[method] {
+					seen[method] = true
+					s.toolMethods[name] = append(s.toolMethods[name], method)
+				}
+			}
+		}
+
+		s.addTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			coreLogger.Debug("Executing macro %s", name)
+			return s.runMacro(ctx, macro, templates, request.Params.Arguments)
+		})
+	}
+
+	return nil
+}
+
+// macroTool builds the tool definition of a macro from its parameters
+func macroTool(name string, macro macroConfig) (mcp.Tool, error) {
+	if len(macro.Steps) == 0 {
+		return mcp.Tool{}, fmt.Errorf("no steps")
+	}
+
+	description := macro.Description
+	if description == "" {
+		var tools []string
+		for _, step := range macro.Steps {
+			tools = append(tools, step.Tool)
+		}
+		description = "Run " + strings.Join(tools, ", then ")
+	}
+	options := []mcp.ToolOption{mcp.WithDescription(description)}
+
+	for paramName, param := range macro.Params {
+		var propertyOptions []mcp.PropertyOption
+		if param.Description != "" {
+			propertyOptions = append(propertyOptions, mcp.Description(param.Description))
+		}
+		if param.Required {
+			propertyOptions = append(propertyOptions, mcp.Required())
+		}
+
+		switch param.Type {
+		case "", "string":
+			options = append(options, mcp.WithString(paramName, propertyOptions...))
+		case "number":
+			options = append(options, mcp.WithNumber(paramName, propertyOptions...))
+		case "boolean":
+			options = append(options, mcp.WithBoolean(paramName, propertyOptions...))
+		default:
+			return mcp.Tool{}, fmt.Errorf("parameter %s has unsupported type %s", paramName, param.Type)
+		}
+	}
+
+	return mcp.NewTool(name, options...), nil
+}
+
+// parseMacroSteps checks that every step calls a registered tool and parses
+// the templates in its string arguments, keyed by step index and argument name
+func (s *mcpServer) parseMacroSteps(macro macroConfig) ([]map[string]*template.Template, error) {
+	templates := make([]map[string]*template.Template, len(macro.Steps))
+	for i, step := range macro.Steps {
+		if _, ok := s.toolHandlers[step.Tool]; !ok {
+			return nil, fmt.Errorf("step %d calls unknown tool %s", i+1, step.Tool)
+		}
+
+		templates[i] = make(map[string]*template.Template)
+		for argName, value := range step.Arguments {
+			text, ok := value.(string)
+			if !ok || parameterReference.MatchString(text) {
+				continue
+			}
+			tmpl, err := template.New(argName).Option("missingkey=zero").Parse(text)
+			if err != nil {
+				return nil, fmt.Errorf("step %d argument %s: %v", i+1, argName, err)
+			}
+			templates[i][argName] = tmpl
+		}
+	}
+	return templates, nil
+}
+
+// runMacro calls each step of a macro in turn and combines their output. It
+// stops at the first step that fails.
+func (s *mcpServer) runMacro(ctx context.Context, macro macroConfig, templates []map[string]*template.Template, params map[string]any) (*mcp.CallToolResult, error) {
+	var output strings.Builder
+	for i, step := range macro.Steps {
+		arguments, err := macroArguments(step, templates[i], macro.Params, params)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to prepare step %d (%s): %v", i+1, step.Tool, err)), nil
+		}
+
+		request := mcp.CallToolRequest{}
+		request.Params.Name = step.Tool
+		request.Params.Arguments = arguments
+
+		result, err := s.callToolHandler(ctx, step.Tool, s.toolHandlers[step.Tool], request)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("step %d (%s) failed: %v", i+1, step.Tool, err)), nil
+		}
+
+		if i > 0 {
+			output.WriteString("\n")
+		}
+		output.WriteString(fmt.Sprintf("=== Step %d: %s ===\n", i+1, step.Tool))
+		text := toolResultText(result)
+		output.WriteString(text)
+		if !strings.HasSuffix(text, "\n") {
+			output.WriteString("\n")
+		}
+
+		if result != nil && result.IsError {
+			return mcp.NewToolResultError(output.String()), nil
+		}
+	}
+	return mcp.NewToolResultText(output.String()), nil
+}
+
+// macroArguments fills in the arguments of a step from the macro's parameters.
+// Declared parameters that were not passed render as empty in templates.
+func macroArguments(step macroStep, templates map[string]*template.Template, declared map[string]macroParam, params map[string]any) (map[string]any, error) {
+	data := make(map[string]any, len(declared)+len(params))
+	for name := range declared {
+		data[name] = ""
+	}
+	maps.Copy(data, params)
+
+	arguments := make(map[string]any, len(step.Arguments))
+	for argName, value := range step.Arguments {
+		text, ok := value.(string)
+		if !ok {
+			arguments[argName] = value
+			continue
+		}
+
+		if match := parameterReference.FindStringSubmatch(text); match != nil {
+			// Omit arguments for parameters that were not passed, so the
+			// step's own defaults apply
+			if param, ok := params[match[1]]; ok {
+				arguments[argName] = param
+			}
+			continue
+		}
+
+		var rendered strings.Builder
+		if err := templates[argName].Execute(&rendered, data); err != nil {
+			return nil, fmt.Errorf("argument %s: %v", argName, err)
+		}
+		arguments[argName] = rendered.String()
+	}
+	return arguments, nil
+}
+
+// toolResultText concatenates the text content of a tool result
+func toolResultText(result *mcp.CallToolResult) string {
+	if result == nil {
+		return ""
+	}
+	var texts []string
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
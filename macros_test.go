@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMacroArguments(t *testing.T) {
+	s := &mcpServer{toolHandlers: map[string]server.ToolHandlerFunc{"references": nil}}
+	macro := macroConfig{
+		Params: map[string]macroParam{"name": {}, "line": {Type: "number"}},
+		Steps: []macroStep{{
+			Tool: "references",
+			Arguments: map[string]any{
+				"symbolName": "{{ .name }}",
+				"line":       "{{.line}}",
+				"pattern":    "Test{{.name}}*",
+				"dryRun":     true,
+				"limit":      float64(10),
+			},
+		}},
+	}
+	templates, err := s.parseMacroSteps(macro)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params map[string]any
+		want   map[string]any
+	}{
+		{
+			name:   "typed parameters are passed through",
+			params: map[string]any{"name": "Foo", "line": float64(12)},
+			want: map[string]any{
+				"symbolName": "Foo",
+				"line":       float64(12),
+				"pattern":    "TestFoo*",
+				"dryRun":     true,
+				"limit":      float64(10),
+			},
+		},
+		{
+			name:   "omitted parameters are omitted",
+			params: map[string]any{"name": "Foo"},
+			want: map[string]any{
+				"symbolName": "Foo",
+				"pattern":    "TestFoo*",
+				"dryRun":     true,
+				"limit":      float64(10),
+			},
+		},
+		{
+			name:   "templates render omitted parameters empty",
+			params: map[string]any{},
+			want: map[string]any{
+				"pattern": "Test*",
+				"dryRun":  true,
+				"limit":   float64(10),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arguments, err := macroArguments(macro.Steps[0], templates[0], macro.Params, tt.params)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, arguments)
+		})
+	}
+}
+
+func TestParseMacroStepsUnknownTool(t *testing.T) {
+	s := &mcpServer{toolHandlers: map[string]server.ToolHandlerFunc{}}
+	_, err := s.parseMacroSteps(macroConfig{Steps: []macroStep{{Tool: "missing"}}})
+	assert.EqualError(t, err, "step 1 calls unknown tool missing")
+}
+
+func TestRunMacroStopsAtFailingStep(t *testing.T) {
+	var called []string
+	handler := func(text string, isError bool) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			called = append(called, request.Params.Name)
+			if isError {
+				return mcp.NewToolResultError(text), nil
+			}
+			return mcp.NewToolResultText(text), nil
+		}
+	}
+	s := &mcpServer{
+		ctx: context.Background(),
+		toolHandlers: map[string]server.ToolHandlerFunc{
+			"format_document": handler("formatted", false),
+			"diagnostics":     handler("2 errors", true),
+			"edit_file":       handler("edited", false),
+		},
+	}
+	macro := macroConfig{Steps: []macroStep{{Tool: "format_document"}, {Tool: "diagnostics"}, {Tool: "edit_file"}}}
+	templates, err := s.parseMacroSteps(macro)
+	require.NoError(t, err)
+
+	result, err := s.runMacro(context.Background(), macro, templates, nil)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Equal(t, []string{"format_document", "diagnostics"}, called)
+	assert.Equal(t, "=== Step 1: format_document ===\nformatted\n\n=== Step 2: diagnostics ===\n2 errors\n", toolResultText(result))
+}
+
+func TestRegisterMacrosToolMethods(t *testing.T) {
+	global := maps.Clone(toolLSPMethods)
+	macros := map[string]macroConfig{
+		"check": {Steps: []macroStep{{Tool: "hover"}, {Tool: "diagnostics"}, {Tool: "hover"}}},
+	}
+
+	// Each server has its own methods, however many servers are built
+	for i := 0; i < 2; i++ {
+		s := &mcpServer{
+			config:       config{macros: macros},
+			mcpServer:    server.NewMCPServer("test", "1.0.0"),
+			toolHandlers: map[string]server.ToolHandlerFunc{"hover": nil, "diagnostics": nil},
+			toolMethods:  maps.Clone(toolLSPMethods),
+		}
+		require.NoError(t, s.registerMacros())
+		assert.Equal(t, []string{"textDocument/hover", "textDocument/publishDiagnostics"}, s.toolMethods["check"])
+	}
+	assert.Equal(t, global, toolLSPMethods)
+}
@@ -2,47 +2,220 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/koonwen/mcp-language-server/internal/buildsystem"
 	"github.com/koonwen/mcp-language-server/internal/logging"
 	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/tools"
 	"github.com/koonwen/mcp-language-server/internal/watcher"
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
 // Create a logger for the core component
 var coreLogger = logging.NewLogger(logging.Core)
 
+// serverVersion is the version reported to MCP clients and in crash bundles
+const serverVersion = "v0.0.2"
+
+// defaultSoftTimeout is used when the config file does not set softTimeout
+const defaultSoftTimeout = 30 * time.Second
+
 type config struct {
 	workspaceDir string
 	lspCommand   string
 	lspArgs      []string
+	configFile   string
+
+	initializationOptions map[string]any
+	settings              map[string]any
+	env                   map[string]string
+	toolToggles           map[string]bool
+
+	securityWatchlist []string
+	messages          map[string]string
+	templates         map[string]string
+	macros            map[string]macroConfig
+	aliases           map[string]string
+	debugDir          string
+	maxRestarts       int
+	messageRequests   messageRequestsConfig
+	softTimeout       time.Duration
+	concurrency       lsp.ConcurrencyLimits
+
+	workspaceFingerprint bool
+	dedupeSnippets       bool
+	prefetchDefinitions  bool
+	trimSnippets         tools.SnippetTrimming
+	servers              []serverConfig
+	embedded             map[string]string
+	hooks                hooksConfig
+	scope                []string
+	workspaceFolders     []string
+	largeResults         largeResultsConfig
+	oneIndexed           bool
+	relocateRadius       int
+	outputFormat         string
+	// contextLines is the number of lines shown around locations in tool
+	// results, or -1 to leave it to LSP_CONTEXT_LINES
+	contextLines int
 }
 
 type mcpServer struct {
-	config           config
-	lspClient        *lsp.Client
+	config    config
+	lspClient *lsp.Client
+	// router picks the server for the file a tool is called on
+	router           *lsp.Router
 	mcpServer        *server.MCPServer
 	ctx              context.Context
 	cancelFunc       context.CancelFunc
 	workspaceWatcher *watcher.WorkspaceWatcher
+	tools            []mcp.Tool
+	toolHandlers     map[string]server.ToolHandlerFunc
+	history          invocationHistory
+	transcript       transcript
+	resultSizes      resultSizeStats
+	heatmap          heatmap
+	events           eventJournal
+	progress         progressListeners
+	serverMessages   serverMessages
+	// toolMethods is toolLSPMethods with the methods of macros and aliases
+	toolMethods map[string][]string
+	// resultSink stores large results, nil if they are returned in full
+	resultSink resultSink
+
+	// Path of the forensic bundle written when the language server crashed,
+	// and the outcome of the last restart of a crashed server, not yet
+	// reported in a tool result
+	crashBundle string
+	restartNote string
+	crashMu     sync.Mutex
+
+	outlines outlineSubscriptions
+
+	// Language servers started in addition to the main one
+	additionalServers []additionalServer
+
+	// Workspace folders in addition to the workspace
+	folders workspaceFolders
+
+	started  time.Time
+	watchdog watchdog
+
+	// Build system of the workspace, nil unless one was detected
+	buildLayout *buildsystem.Layout
 }
 
 func parseConfig() (*config, error) {
-	cfg := &config{}
+	cfg := &config{
+		softTimeout:    defaultSoftTimeout,
+		concurrency:    (*concurrencyConfig)(nil).concurrencyLimits(),
+		oneIndexed:     true,
+		relocateRadius: defaultRelocateRadius,
+		outputFormat:   "text",
+		maxRestarts:    lsp.DefaultRestartPolicy.MaxRestarts,
+		contextLines:   -1,
+	}
 	flag.StringVar(&cfg.workspaceDir, "workspace", "", "Path to workspace directory")
 	flag.StringVar(&cfg.lspCommand, "lsp", "", "LSP command to run (args should be passed after --)")
+	flag.StringVar(&cfg.configFile, "config", "", "Path to an optional JSON or YAML configuration file")
+	initOptions := flag.String("init-options", "", "JSON object of initialization options for the language server, merged over those in the configuration file")
 	flag.Parse()
 
+	// Apply settings from the configuration file
+	var configArgs []string
+	if cfg.configFile != "" {
+		fc, err := loadConfigFile(cfg.configFile)
+		if err != nil {
+			return nil, err
+		}
+		// Flags take precedence
+		if cfg.workspaceDir == "" && fc.Workspace != "" {
+			cfg.workspaceDir = fc.Workspace
+			if !filepath.IsAbs(cfg.workspaceDir) {
+				cfg.workspaceDir = filepath.Join(filepath.Dir(cfg.configFile), cfg.workspaceDir)
+			}
+		}
+		if cfg.lspCommand == "" {
+			cfg.lspCommand = fc.Command
+			configArgs = fc.Args
+		}
+		cfg.initializationOptions = fc.InitializationOptions
+		cfg.settings = fc.Settings
+		cfg.env = fc.Env
+		cfg.toolToggles = fc.Tools
+		if fc.ContextLines != nil {
+			if *fc.ContextLines < 0 {
+				return nil, fmt.Errorf("contextLines in config file must not be negative")
+			}
+			cfg.contextLines = *fc.ContextLines
+		}
+		cfg.securityWatchlist = fc.SecurityWatchlist
+		cfg.messages = fc.Messages
+		cfg.templates = fc.Templates
+		cfg.macros = fc.Macros
+		cfg.aliases = fc.Aliases
+		cfg.debugDir = fc.DebugDir
+		if fc.MaxRestarts != nil {
+			cfg.maxRestarts = *fc.MaxRestarts
+		}
+		cfg.messageRequests = fc.MessageRequests
+		cfg.workspaceFingerprint = fc.WorkspaceFingerprint
+		cfg.dedupeSnippets = fc.DedupeSnippets
+		cfg.prefetchDefinitions = fc.PrefetchDefinitions
+		cfg.trimSnippets = fc.TrimSnippets
+		cfg.servers = fc.Servers
+		cfg.embedded = fc.Embedded
+		cfg.hooks = fc.Hooks
+		cfg.scope = fc.Scope
+		cfg.workspaceFolders = fc.WorkspaceFolders
+		cfg.largeResults = fc.LargeResults
+		if fc.OneIndexed != nil {
+			cfg.oneIndexed = *fc.OneIndexed
+		}
+		if fc.RelocateRadius != nil {
+			cfg.relocateRadius = *fc.RelocateRadius
+		}
+		if fc.OutputFormat != "" {
+			cfg.outputFormat = fc.OutputFormat
+		}
+		cfg.concurrency = fc.Concurrency.concurrencyLimits()
+		if fc.SoftTimeout != "" {
+			softTimeout, err := time.ParseDuration(fc.SoftTimeout)
+			if err != nil || softTimeout < 0 {
+				return nil, fmt.Errorf("invalid softTimeout in config file: %s", fc.SoftTimeout)
+			}
+			cfg.softTimeout = softTimeout
+		}
+	}
+
+	if *initOptions != "" {
+		var options map[string]any
+		if err := json.Unmarshal([]byte(*initOptions), &options); err != nil {
+			return nil, fmt.Errorf("invalid -init-options, expected a JSON object: %v", err)
+		}
+		if cfg.initializationOptions == nil {
+			cfg.initializationOptions = make(map[string]any)
+		}
+		lsp.MergeOptions(cfg.initializationOptions, options)
+	}
+
 	// Get remaining args after -- as LSP arguments
 	cfg.lspArgs = flag.Args()
+	if len(cfg.lspArgs) == 0 {
+		cfg.lspArgs = configArgs
+	}
 
 	// Validate workspace directory
 	if cfg.workspaceDir == "" {
@@ -68,15 +241,104 @@ func parseConfig() (*config, error) {
 		return nil, fmt.Errorf("LSP command not found: %s", cfg.lspCommand)
 	}
 
+	// Validate additional servers
+	names := map[string]bool{filepath.Base(cfg.lspCommand): true}
+	for i := range cfg.servers {
+		server := &cfg.servers[i]
+		if server.Command == "" {
+			return nil, fmt.Errorf("server %d in config file has no command", i+1)
+		}
+		if server.Name == "" {
+			server.Name = filepath.Base(server.Command)
+		}
+		if names[server.Name] {
+			return nil, fmt.Errorf("duplicate server name in config file: %s", server.Name)
+		}
+		names[server.Name] = true
+	}
+	for language, name := range cfg.embedded {
+		if !names[name] {
+			return nil, fmt.Errorf("embedded %s in config file refers to unknown server: %s", language, name)
+		}
+	}
+
+	// Validate edit hooks
+	for _, hook := range append(cfg.hooks.PreEdit, cfg.hooks.PostEdit...) {
+		if hook.Command == "" {
+			return nil, fmt.Errorf("edit hook in config file has no command")
+		}
+		if hook.Timeout != "" {
+			if timeout, err := time.ParseDuration(hook.Timeout); err != nil || timeout <= 0 {
+				return nil, fmt.Errorf("invalid timeout for edit hook %s in config file: %s", hook.Command, hook.Timeout)
+			}
+		}
+	}
+
+	scope, err := parseScope(cfg.workspaceDir, cfg.scope)
+	if err != nil {
+		return nil, err
+	}
+	cfg.scope = scope
+
+	folders, err := parseWorkspaceFolders(cfg.workspaceDir, cfg.workspaceFolders)
+	if err != nil {
+		return nil, err
+	}
+	cfg.workspaceFolders = folders
+
+	// Validate the large result sink
+	switch cfg.largeResults.Sink {
+	case "", "file", "resource":
+	default:
+		return nil, fmt.Errorf("unknown largeResults sink in config file: %s", cfg.largeResults.Sink)
+	}
+	if cfg.largeResults.Threshold < 0 || cfg.largeResults.PreviewLines < 0 {
+		return nil, fmt.Errorf("largeResults threshold and previewLines in config file must not be negative")
+	}
+	if dir := cfg.largeResults.Dir; dir != "" && (filepath.IsAbs(dir) || strings.HasPrefix(filepath.Clean(dir), "..")) {
+		return nil, fmt.Errorf("largeResults dir in config file must be inside the workspace: %s", dir)
+	}
+	if cfg.relocateRadius < 0 {
+		return nil, fmt.Errorf("relocateRadius in config file must not be negative")
+	}
+	if cfg.outputFormat != "text" && cfg.outputFormat != "json" && cfg.outputFormat != "markdown" {
+		return nil, fmt.Errorf("outputFormat in config file must be text, json or markdown: %s", cfg.outputFormat)
+	}
+	if cfg.maxRestarts < 0 {
+		return nil, fmt.Errorf("maxRestarts in config file must not be negative")
+	}
+	if err := cfg.messageRequests.validate(); err != nil {
+		return nil, fmt.Errorf("invalid messageRequests in config file: %v", err)
+	}
+
 	return cfg, nil
 }
 
 func newServer(config *config) (*mcpServer, error) {
+	if err := tools.SetMessages(config.messages); err != nil {
+		return nil, fmt.Errorf("invalid messages in config file: %v", err)
+	}
+	if err := tools.SetOutputTemplates(config.templates); err != nil {
+		return nil, fmt.Errorf("invalid templates in config file: %v", err)
+	}
+	if config.dedupeSnippets {
+		tools.EnableSnippetDedup()
+	}
+	tools.SetSnippetTrimming(config.trimSnippets)
+	tools.SetContextLines(config.contextLines)
+
 	ctx, cancel := context.WithCancel(context.Background())
+	if config.prefetchDefinitions {
+		tools.EnableDefinitionPrefetch(ctx)
+	}
 	return &mcpServer{
-		config:     *config,
-		ctx:        ctx,
-		cancelFunc: cancel,
+		config:       *config,
+		ctx:          ctx,
+		cancelFunc:   cancel,
+		toolHandlers: make(map[string]server.ToolHandlerFunc),
+		started:      time.Now(),
+		resultSink:   newResultSink(config.workspaceDir, config.largeResults),
+		folders:      workspaceFolders{dirs: config.workspaceFolders},
 	}, nil
 }
 
@@ -85,12 +347,32 @@ func (s *mcpServer) initializeLSP() error {
 		return fmt.Errorf("failed to change to workspace directory: %v", err)
 	}
 
-	client, err := lsp.NewClient(s.config.lspCommand, s.config.lspArgs...)
+	owners, err := tools.LoadCodeOwners(s.config.workspaceDir)
+	if err != nil {
+		coreLogger.Warn("Failed to load CODEOWNERS: %v", err)
+	}
+	tools.SetCodeOwners(owners)
+	s.detectBuildLayout()
+
+	client, err := lsp.NewClientWithEnv(s.config.lspCommand, s.config.env, s.config.lspArgs...)
 	if err != nil {
 		return fmt.Errorf("failed to create LSP client: %v", err)
 	}
 	s.lspClient = client
-	s.workspaceWatcher = watcher.NewWorkspaceWatcher(client)
+	s.router = lsp.NewRouter(client)
+
+	client.SetConcurrencyLimits(s.config.concurrency)
+	client.SetCrashHandler(s.handleCrash)
+	client.SetRestartPolicy(s.restartPolicy(), s.restartHandler(filepath.Base(s.config.lspCommand)))
+	client.SetProgressHandler(s.forwardProgress(filepath.Base(s.config.lspCommand)))
+	client.SetMessageHandler(s.messageHandler(filepath.Base(s.config.lspCommand)))
+	client.SetMessageRequestPolicy(s.config.messageRequests.policy())
+	client.SetDiagnosticsHandler(s.events.recordDiagnostics)
+	s.configureBuildLayout(client)
+	client.MergeInitializationOptions(s.config.initializationOptions)
+	client.SetSettings(s.config.settings)
+	s.workspaceWatcher = watcher.NewWorkspaceWatcherWithConfig(client, s.watcherConfig())
+	s.workspaceWatcher.SetChangeHandler(s.handleFileChange)
 
 	initResult, err := client.InitializeLSPClient(s.ctx, s.config.workspaceDir)
 	if err != nil {
@@ -100,19 +382,28 @@ func (s *mcpServer) initializeLSP() error {
 	coreLogger.Debug("Server capabilities: %+v", initResult.Capabilities)
 
 	go s.workspaceWatcher.WatchWorkspace(s.ctx, s.config.workspaceDir)
-	return client.WaitForServerReady(s.ctx)
+	s.watchWorkspaceFolders(client, s.handleFileChange)
+	if err := client.WaitForServerReady(s.ctx); err != nil {
+		return err
+	}
+
+	s.startAdditionalServers()
+	return nil
 }
 
 func (s *mcpServer) start() error {
+	go s.watchdog.run(s.ctx)
+
 	if err := s.initializeLSP(); err != nil {
 		return err
 	}
 
 	s.mcpServer = server.NewMCPServer(
 		"MCP Language Server",
-		"v0.0.2",
+		serverVersion,
 		server.WithLogging(),
 		server.WithRecovery(),
+		server.WithResourceCapabilities(false, false),
 	)
 
 	err := s.registerTools()
@@ -120,6 +411,11 @@ func (s *mcpServer) start() error {
 		return fmt.Errorf("tool registration failed: %v", err)
 	}
 
+	err = s.registerResources()
+	if err != nil {
+		return fmt.Errorf("resource registration failed: %v", err)
+	}
+
 	return server.ServeStdio(s.mcpServer)
 }
 
@@ -193,45 +489,18 @@ func main() {
 func cleanup(s *mcpServer, done chan struct{}) {
 	coreLogger.Info("Cleanup initiated for PID: %d", os.Getpid())
 
+	// Stop tool calls in flight and the workspace watchers
+	s.cancelFunc()
+
 	// Create a context with timeout for shutdown operations
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if s.lspClient != nil {
-		coreLogger.Info("Closing open files")
-		s.lspClient.CloseAllFiles(ctx)
-
-		// Create a shorter timeout context for the shutdown request
-		shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 500*time.Millisecond)
-		defer shutdownCancel()
-
-		// Run shutdown in a goroutine with timeout to avoid blocking if LSP doesn't respond
-		shutdownDone := make(chan struct{})
-		go func() {
-			coreLogger.Info("Sending shutdown request")
-			if err := s.lspClient.Shutdown(shutdownCtx); err != nil {
-				coreLogger.Error("Shutdown request failed: %v", err)
-			}
-			close(shutdownDone)
-		}()
-
-		// Wait for shutdown with timeout
-		select {
-		case <-shutdownDone:
-			coreLogger.Info("Shutdown request completed")
-		case <-time.After(1 * time.Second):
-			coreLogger.Warn("Shutdown request timed out, proceeding with exit")
-		}
-
-		coreLogger.Info("Sending exit notification")
-		if err := s.lspClient.Exit(ctx); err != nil {
-			coreLogger.Error("Exit notification failed: %v", err)
-		}
-
-		coreLogger.Info("Closing LSP client")
-		if err := s.lspClient.Close(); err != nil {
-			coreLogger.Error("Failed to close LSP client: %v", err)
-		}
+		shutdownClient(ctx, s.lspClient)
+	}
+	for _, server := range s.additionalServers {
+		shutdownClient(ctx, server.client)
 	}
 
 	// Send signal to the done channel
@@ -243,3 +512,42 @@ func cleanup(s *mcpServer, done chan struct{}) {
 
 	coreLogger.Info("Cleanup completed for PID: %d", os.Getpid())
 }
+
+// shutdownClient closes the files open in a language server, asks it to shut
+// down and closes the connection
+func shutdownClient(ctx context.Context, client *lsp.Client) {
+	coreLogger.Info("Closing open files")
+	client.CloseAllFiles(ctx)
+
+	// Create a shorter timeout context for the shutdown request
+	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer shutdownCancel()
+
+	// Run shutdown in a goroutine with timeout to avoid blocking if LSP doesn't respond
+	shutdownDone := make(chan struct{})
+	go func() {
+		coreLogger.Info("Sending shutdown request")
+		if err := client.Shutdown(shutdownCtx); err != nil {
+			coreLogger.Error("Shutdown request failed: %v", err)
+		}
+		close(shutdownDone)
+	}()
+
+	// Wait for shutdown with timeout
+	select {
+	case <-shutdownDone:
+		coreLogger.Info("Shutdown request completed")
+	case <-time.After(1 * time.Second):
+		coreLogger.Warn("Shutdown request timed out, proceeding with exit")
+	}
+
+	coreLogger.Info("Sending exit notification")
+	if err := client.Exit(ctx); err != nil {
+		coreLogger.Error("Exit notification failed: %v", err)
+	}
+
+	coreLogger.Info("Closing LSP client")
+	if err := client.Close(); err != nil {
+		coreLogger.Error("Failed to close LSP client: %v", err)
+	}
+}
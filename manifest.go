@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/koonwen/mcp-language-server/internal/fingerprint"
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/koonwen/mcp-language-server/internal/watcher"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// manifestURI is the URI of the capability manifest resource
+const manifestURI = "mcp-language-server://manifest"
+
+// toolLSPMethods lists the LSP methods that back each tool. Tools that work
+// without the language server have no entry.
+var toolLSPMethods = map[string][]string{
+	"definition":              {"workspace/symbol", "textDocument/documentSymbol"},
+	"go_to_definition":        {"textDocument/definition", "textDocument/documentSymbol"},
+	"go_to_type_definition":   {"textDocument/typeDefinition", "textDocument/documentSymbol"},
+	"go_to_declaration":       {"textDocument/declaration", "textDocument/documentSymbol"},
+	"search_symbols":          {"workspace/symbol"},
+	"references":              {"workspace/symbol", "textDocument/references"},
+	"references_at_position":  {"textDocument/references"},
+	"document_symbols":        {"textDocument/documentSymbol"},
+	"diagnostics":             {"textDocument/publishDiagnostics"},
+	"hover":                   {"textDocument/hover"},
+	"rename_symbol":           {"textDocument/rename"},
+	"prepare_rename":          {"textDocument/prepareRename"},
+	"code_actions":            {"textDocument/codeAction"},
+	"apply_code_action":       {"textDocument/codeAction", "workspace/executeCommand"},
+	"format_document":         {"textDocument/formatting"},
+	"format_range":            {"textDocument/rangeFormatting"},
+	"add_import":              {"textDocument/completion", "textDocument/codeAction"},
+	"completion":              {"textDocument/completion"},
+	"inlay_hints":             {"textDocument/inlayHint"},
+	"semantic_tokens":         {"textDocument/semanticTokens/full"},
+	"folding_ranges":          {"textDocument/foldingRange"},
+	"document_highlights":     {"textDocument/documentHighlight"},
+	"idl_references":          {"workspace/symbol", "textDocument/references"},
+	"selection_range":         {"textDocument/selectionRange"},
+	"document_links":          {"textDocument/documentLink", "documentLink/resolve"},
+	"embedded_query":          {"textDocument/hover", "textDocument/diagnostic"},
+	"workspace_diagnostics":   {"workspace/diagnostic", "textDocument/publishDiagnostics"},
+	"runnables":               {"textDocument/codeLens", "codeLens/resolve"},
+	"get_codelens":            {"textDocument/codeLens"},
+	"execute_codelens":        {"textDocument/codeLens", "workspace/executeCommand"},
+	"query_at_revision":       {"textDocument/hover", "textDocument/definition", "textDocument/references"},
+	"security_watchlist":      {"workspace/symbol", "textDocument/references"},
+	"call_path":               {"workspace/symbol", "textDocument/prepareCallHierarchy", "callHierarchy/outgoingCalls"},
+	"diff_diagnostics":        {"textDocument/publishDiagnostics"},
+	"incoming_calls":          {"textDocument/prepareCallHierarchy", "callHierarchy/incomingCalls"},
+	"rename_package":          {"workspace/willRenameFiles"},
+	"rename_file":             {"workspace/willRenameFiles", "workspace/didRenameFiles"},
+	"add_workspace_folder":    {"workspace/didChangeWorkspaceFolders"},
+	"remove_workspace_folder": {"workspace/didChangeWorkspaceFolders"},
+	"outgoing_calls":          {"textDocument/prepareCallHierarchy", "callHierarchy/outgoingCalls"},
+	"implementations":         {"textDocument/implementation"},
+	"inline_values":           {"textDocument/inlineValue"},
+	"type_hierarchy":          {"textDocument/prepareTypeHierarchy", "typeHierarchy/supertypes", "typeHierarchy/subtypes"},
+}
+
+// toolManifest describes a tool for orchestrators planning tool use
+type toolManifest struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	InputSchema mcp.ToolInputSchema `json:"inputSchema"`
+	LSPMethods  []lspMethodManifest `json:"lspMethods"`
+	Supported   bool                `json:"supported"`
+	// Servers are the language servers that support every LSP method of
+	// the tool
+	Servers []string `json:"servers,omitempty"`
+
+	// AliasOf is the tool an alias stands for, and Aliases the aliases
+	// configured for a tool
+	AliasOf string   `json:"aliasOf,omitempty"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+type lspMethodManifest struct {
+	Method    string   `json:"method"`
+	Supported bool     `json:"supported"`
+	Servers   []string `json:"servers,omitempty"`
+}
+
+// serverCapabilities are the capabilities of a language server, nil until it
+// is initialized
+type serverCapabilities struct {
+	name         string
+	capabilities *protocol.ServerCapabilities
+}
+
+type manifest struct {
+	Server struct {
+		Name    string `json:"name,omitempty"`
+		Version string `json:"version,omitempty"`
+	} `json:"server"`
+	Tools []toolManifest `json:"tools"`
+
+	// Workspace identifies the checkout for keying caches, if enabled
+	Workspace *workspaceManifest `json:"workspace,omitempty"`
+}
+
+type workspaceManifest struct {
+	// Key is stable for the same workspace at the same commit
+	Key string `json:"key"`
+	fingerprint.Fingerprint
+}
+
+// addTool registers a tool with the MCP server and records it for the
+// manifest. Calls to the tool are recorded so they can be replayed, in the
+// session transcript with the edits they made, and the size of their results
+// is recorded for usage recommendations. The files and symbols they query
+// are added to the heatmap. The edit hooks run around tools that change
+// files, whose edits are journaled so they can be undone, and queries
+// outside the scope are warned about. Tools that take positions accept
+// 0-indexed ones with oneIndexed set to false, and stale ones are relocated
+// to their lineText. Large results are stored in the result sink. Calls that
+// ask for progress get that of the language servers while they run, and
+// results carry the messages the servers showed since the last one. Each
+// call runs in isolation, see callToolHandler.
+func (s *mcpServer) addTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	if mutatingTools[tool.Name] && tool.Name != undoToolName {
+		handler = s.withEditJournal(tool.Name, s.withEditHooks(tool.Name, handler))
+	}
+	tool = s.withOutputFormatOption(s.withLineTextOption(s.withOneIndexedOption(tool)))
+	s.tools = append(s.tools, tool)
+	s.toolHandlers[tool.Name] = handler
+	s.mcpServer.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		s.heatmap.record(tool.Name, request.Params.Arguments, time.Now())
+		var trace *lsp.Trace
+		filePath, _ := request.Params.Arguments["filePath"].(string)
+		traced := s.clientFor(filePath)
+		if tool.Name != transcriptToolName {
+			trace = traced.StartTrace()
+		}
+		if meta := request.Params.Meta; meta != nil && meta.ProgressToken != nil {
+			defer s.progress.listen(ctx, meta.ProgressToken)()
+		}
+		start := time.Now()
+		relocated, note := s.relocatePositions(s.normalizePositions(request))
+		if tool.Name != replayToolName {
+			s.history.add(request, relocated)
+		}
+		result, err := s.callToolHandler(s.withOutputFormat(ctx, tool.Name, request), tool.Name, handler, relocated)
+		result = withPositionNote(s.withWorkspaceFolder(request, s.warnOutOfScope(request, result)), note)
+		if trace != nil {
+			traced.StopTrace(trace)
+			s.transcript.add(start, request, result, trace.Entries())
+		}
+		if tool.Name != recommendationsToolName {
+			s.resultSizes.record(tool.Name, result)
+		}
+		return s.withServerMessages(s.withCrashNote(s.spillResult(tool.Name, result))), err
+	})
+}
+
+// disableTools removes the tools set to false in the config file from the
+// server and the manifest. Macros and aliases may still call them.
+func (s *mcpServer) disableTools() error {
+	var disabled []string
+	for name, enabled := range s.config.toolToggles {
+		if _, ok := s.toolHandlers[name]; !ok {
+			return fmt.Errorf("tools in config file refers to unknown tool %s", name)
+		}
+		if !enabled {
+			disabled = append(disabled, name)
+		}
+	}
+	if len(disabled) == 0 {
+		return nil
+	}
+
+	s.mcpServer.DeleteTools(disabled...)
+	tools := s.tools[:0]
+	for _, tool := range s.tools {
+		if enabled, ok := s.config.toolToggles[tool.Name]; !ok || enabled {
+			tools = append(tools, tool)
+		}
+	}
+	s.tools = tools
+	coreLogger.Info("Disabled tools: %v", disabled)
+	return nil
+}
+
+// callToolHandler runs a tool handler with a context that ends with the call
+// or when the server shuts down, so that the requests and goroutines started
+// for the call do not outlive it. A panic in the handler, e.g. on a malformed
+// response from the language server, is logged with its stack and returned
+// as an error result instead of crashing the server.
+func (s *mcpServer) callToolHandler(ctx context.Context, name string, handler server.ToolHandlerFunc, request mcp.CallToolRequest) (result *mcp.CallToolResult, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(s.ctx, cancel)
+	defer stop()
+
+	defer func() {
+		if r := recover(); r != nil {
+			coreLogger.Error("Panic in tool %s: %v\n%s", name, r, debug.Stack())
+			result, err = mcp.NewToolResultError(fmt.Sprintf("internal error in %s: %v", name, r)), nil
+		}
+	}()
+
+	return handler(ctx, request)
+}
+
+// buildManifest describes every registered tool and which of the connected
+// language servers support the LSP methods it relies on
+func (s *mcpServer) buildManifest() manifest {
+	var m manifest
+	if result := s.lspClient.InitializeResult(); result != nil && result.ServerInfo != nil {
+		m.Server.Name = result.ServerInfo.Name
+		m.Server.Version = result.ServerInfo.Version
+	}
+
+	var servers []serverCapabilities
+	for _, server := range s.symbolServers() {
+		sc := serverCapabilities{name: server.Name}
+		if result := server.Client.InitializeResult(); result != nil {
+			sc.capabilities = &result.Capabilities
+		}
+		servers = append(servers, sc)
+	}
+
+	for _, tool := range s.tools {
+		methods := s.toolMethods[tool.Name]
+		lspMethods, toolServers := toolSupport(methods, servers)
+		m.Tools = append(m.Tools, toolManifest{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: tool.InputSchema,
+			LSPMethods:  lspMethods,
+			Supported:   len(methods) == 0 || len(toolServers) > 0,
+			Servers:     toolServers,
+			AliasOf:     s.config.aliases[tool.Name],
+			Aliases:     s.toolAliases(tool.Name),
+		})
+	}
+
+	if s.config.workspaceFingerprint {
+		f, err := fingerprint.Compute(s.config.workspaceDir, watcher.DefaultWatcherConfig().ExcludedDirs)
+		if err != nil {
+			coreLogger.Error("Failed to fingerprint workspace: %v", err)
+		} else {
+			m.Workspace = &workspaceManifest{Key: f.Key(), Fingerprint: f}
+		}
+	}
+
+	return m
+}
+
+// toolSupport reports which servers support each LSP method of a tool, and
+// which support all of them. A call goes to a single server, so a tool is
+// only usable on a server that supports every method it relies on.
+func toolSupport(methods []string, servers []serverCapabilities) ([]lspMethodManifest, []string) {
+	lspMethods := []lspMethodManifest{}
+	supportsAll := make(map[string]bool)
+	for _, server := range servers {
+		supportsAll[server.name] = server.capabilities != nil
+	}
+
+	for _, method := range methods {
+		m := lspMethodManifest{Method: method}
+		for _, server := range servers {
+			if server.capabilities != nil && lsp.MethodSupported(*server.capabilities, method) {
+				m.Servers = append(m.Servers, server.name)
+			} else {
+				supportsAll[server.name] = false
+			}
+		}
+		m.Supported = len(m.Servers) > 0
+		lspMethods = append(lspMethods, m)
+	}
+
+	var toolServers []string
+	if len(methods) > 0 {
+		for _, server := range servers {
+			if supportsAll[server.name] {
+				toolServers = append(toolServers, server.name)
+			}
+		}
+	}
+	return lspMethods, toolServers
+}
+
+func (s *mcpServer) registerResources() error {
+	coreLogger.Debug("Registering MCP resources")
+
+	manifestResource := mcp.NewResource(manifestURI, "Capability manifest",
+		mcp.WithResourceDescription("Machine-readable description of every tool, its parameters, the LSP methods backing it and whether the connected language server supports them."),
+		mcp.WithMIMEType("application/json"),
+	)
+
+	s.mcpServer.AddResource(manifestResource, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		data, err := json.MarshalIndent(s.buildManifest(), "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal manifest: %v", err)
+		}
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      manifestURI,
+				MIMEType: "application/json",
+				Text:     string(data),
+			},
+		}, nil
+	})
+
+	s.registerOutlineResource()
+	s.registerHeatmapResource()
+	s.registerReportResource()
+	s.registerEventsResource()
+
+	coreLogger.Info("Successfully registered all MCP resources")
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func capabilities(t *testing.T, data string) *protocol.ServerCapabilities {
+	var caps protocol.ServerCapabilities
+	require.NoError(t, json.Unmarshal([]byte(data), &caps))
+	return &caps
+}
+
+func TestToolSupport(t *testing.T) {
+	servers := []serverCapabilities{
+		{name: "gopls", capabilities: capabilities(t, `{"hoverProvider":true,"definitionProvider":true}`)},
+		{name: "pyright", capabilities: capabilities(t, `{"hoverProvider":true,"referencesProvider":true,"definitionProvider":true}`)},
+		{name: "clangd"},
+	}
+
+	tests := []struct {
+		name        string
+		methods     []string
+		wantMethods []lspMethodManifest
+		wantServers []string
+	}{
+		{
+			name:        "no methods",
+			wantMethods: []lspMethodManifest{},
+		},
+		{
+			name:    "supported by every initialized server",
+			methods: []string{"textDocument/hover"},
+			wantMethods: []lspMethodManifest{
+				{Method: "textDocument/hover", Supported: true, Servers: []string{"gopls", "pyright"}},
+			},
+			wantServers: []string{"gopls", "pyright"},
+		},
+		{
+			name:    "supported by an additional server only",
+			methods: []string{"textDocument/references"},
+			wantMethods: []lspMethodManifest{
+				{Method: "textDocument/references", Supported: true, Servers: []string{"pyright"}},
+			},
+			wantServers: []string{"pyright"},
+		},
+		{
+			name:    "methods split across servers",
+			methods: []string{"textDocument/definition", "textDocument/references"},
+			wantMethods: []lspMethodManifest{
+				{Method: "textDocument/definition", Supported: true, Servers: []string{"gopls", "pyright"}},
+				{Method: "textDocument/references", Supported: true, Servers: []string{"pyright"}},
+			},
+			wantServers: []string{"pyright"},
+		},
+		{
+			name:    "unsupported",
+			methods: []string{"textDocument/hover", "textDocument/codeLens"},
+			wantMethods: []lspMethodManifest{
+				{Method: "textDocument/hover", Supported: true, Servers: []string{"gopls", "pyright"}},
+				{Method: "textDocument/codeLens", Supported: false},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			methods, toolServers := toolSupport(tt.methods, servers)
+			assert.Equal(t, tt.wantMethods, methods)
+			assert.Equal(t, tt.wantServers, toolServers)
+		})
+	}
+}
+
+func TestDisableTools(t *testing.T) {
+	newServer := func(toggles map[string]bool) *mcpServer {
+		s := &mcpServer{
+			config:       config{toolToggles: toggles},
+			mcpServer:    server.NewMCPServer("test", "1.0.0"),
+			toolHandlers: map[string]server.ToolHandlerFunc{},
+		}
+		for _, name := range []string{"hover", "references", "edit_file"} {
+			tool := mcp.NewTool(name)
+			s.tools = append(s.tools, tool)
+			s.toolHandlers[name] = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultText(name), nil
+			}
+			s.mcpServer.AddTool(tool, s.toolHandlers[name])
+		}
+		return s
+	}
+	toolNames := func(s *mcpServer) []string {
+		var names []string
+		for _, tool := range s.tools {
+			names = append(names, tool.Name)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name    string
+		toggles map[string]bool
+		want    []string
+		err     string
+	}{
+		{name: "no toggles", want: []string{"hover", "references", "edit_file"}},
+		{name: "enabled", toggles: map[string]bool{"hover": true}, want: []string{"hover", "references", "edit_file"}},
+		{name: "disabled", toggles: map[string]bool{"edit_file": false, "hover": false, "references": true}, want: []string{"references"}},
+		{name: "unknown tool", toggles: map[string]bool{"missing": false}, err: "tools in config file refers to unknown tool missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newServer(tt.toggles)
+			err := s.disableTools()
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, toolNames(s))
+			// Macros and aliases may still call disabled tools
+			assert.Len(t, s.toolHandlers, 3)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/koonwen/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const outlineURIPrefix = "mcp-language-server://outline"
+
+// outlineSubscriptions remembers the outlines clients have read, so that
+// they can be told when a file change alters one
+type outlineSubscriptions struct {
+	// Last outline text sent for each file path
+	outlines map[string]string
+	mu       sync.Mutex
+}
+
+// update records the outline of a file and reports whether it differs from
+// the one recorded before
+func (o *outlineSubscriptions) update(path, outline string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.outlines == nil {
+		o.outlines = make(map[string]string)
+	}
+	previous, ok := o.outlines[path]
+	o.outlines[path] = outline
+	return !ok || previous != outline
+}
+
+func (o *outlineSubscriptions) tracked(path string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	_, ok := o.outlines[path]
+	return ok
+}
+
+func (o *outlineSubscriptions) forget(path string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.outlines, path)
+}
+
+func outlineURI(path string) string {
+	return outlineURIPrefix + filepath.ToSlash(path)
+}
+
+// registerOutlineResource registers the per-file outline resources
+func (s *mcpServer) registerOutlineResource() {
+	template := mcp.NewResourceTemplate(outlineURIPrefix+"{+path}", "Document outline",
+		mcp.WithTemplateDescription("The symbols defined in a file, as listed by the document_symbols tool. The path is absolute, e.g. mcp-language-server://outline/home/user/project/main.go. A resources/updated notification is sent when a file change alters an outline that has been read."),
+		mcp.WithTemplateMIMEType("text/plain"),
+	)
+
+	s.mcpServer.AddResourceTemplate(template, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		path := filepath.FromSlash(strings.TrimPrefix(request.Params.URI, outlineURIPrefix))
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(s.config.workspaceDir, path)
+		}
+
+		outline, err := tools.GetDocumentSymbols(s.ctx, s.lspClient, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get outline: %v", err)
+		}
+		s.outlines.update(path, outline)
+
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: "text/plain",
+				Text:     outline,
+			},
+		}, nil
+	})
+}
+
+// handleFileChange records a file change in the workspace events and tells
+// clients when it alters an outline they have read. It is called by the
+// workspace watchers of every language server once the server knows about
+// the change, so a change seen by several watchers is handled once.
+func (s *mcpServer) handleFileChange(ctx context.Context, path string, changeType protocol.FileChangeType) {
+	if !s.events.recordFileChange(path, changeType) {
+		return
+	}
+	if !s.outlines.tracked(path) {
+		return
+	}
+
+	if changeType == protocol.Deleted {
+		s.outlines.forget(path)
+	} else {
+		outline, err := tools.GetDocumentSymbols(ctx, s.clientFor(path), path)
+		if err != nil {
+			coreLogger.Error("Failed to recompute outline of %s: %v", path, err)
+			return
+		}
+		if !s.outlines.update(path, outline) {
+			return
+		}
+	}
+
+	coreLogger.Debug("Outline of %s changed, notifying clients", path)
+	s.mcpServer.SendNotificationToAllClients(mcp.MethodNotificationResourceUpdated, map[string]any{
+		"uri": outlineURI(path),
+	})
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"slices"
+
+	"github.com/koonwen/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// outputFormatParam is the argument choosing the format of a tool's results
+const outputFormatParam = "outputFormat"
+
+// outputFormats are the formats a tool can return its results in besides
+// text: json, with locations, ranges and snippets in separate fields, and
+// markdown, with a header per file and code in fenced blocks
+var outputFormats = map[string][]string{
+	"definition":             {"json", "markdown"},
+	"search_symbols":         {"json"},
+	"references":             {"json", "markdown"},
+	"references_at_position": {"json", "markdown"},
+	"diagnostics":            {"json", "markdown"},
+}
+
+// withOutputFormatOption adds the outputFormat argument to a tool that has
+// output formats other than text
+func (s *mcpServer) withOutputFormatOption(tool mcp.Tool) mcp.Tool {
+	formats, ok := outputFormats[tool.Name]
+	if !ok {
+		return tool
+	}
+	description := "text for the default output, or json for machine-readable output with locations, ranges and snippets in separate fields"
+	if slices.Contains(formats, "markdown") {
+		description = "text for the default output, json for machine-readable output with locations, ranges and snippets in separate fields, or markdown for a header per file and code in fenced blocks tagged with its language"
+	}
+	return withToolProperty(tool, outputFormatParam, map[string]any{
+		"type":        "string",
+		"description": description,
+		"enum":        append([]string{"text"}, formats...),
+		"default":     s.outputFormat(tool.Name),
+	})
+}
+
+// outputFormat returns the format a tool returns its results in when a call
+// does not say: that of the config file if the tool supports it, or else text
+func (s *mcpServer) outputFormat(toolName string) string {
+	if slices.Contains(outputFormats[toolName], s.config.outputFormat) {
+		return s.config.outputFormat
+	}
+	return "text"
+}
+
+// withOutputFormat returns the context to call a tool with, asking for the
+// format of the call or, when it does not say, of the config file
+func (s *mcpServer) withOutputFormat(ctx context.Context, toolName string, request mcp.CallToolRequest) context.Context {
+	format := s.outputFormat(toolName)
+	if value, ok := request.Params.Arguments[outputFormatParam].(string); ok {
+		format = value
+	}
+	if !slices.Contains(outputFormats[toolName], format) {
+		return ctx
+	}
+	switch format {
+	case "json":
+		return tools.WithJSONOutput(ctx)
+	case "markdown":
+		return tools.WithMarkdownOutput(ctx)
+	}
+	return ctx
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithOutputFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		tool     string
+		config   string
+		argument any
+		want     string
+	}{
+		{name: "text by default", tool: "references", config: "text", want: "text"},
+		{name: "config", tool: "references", config: "json", want: "json"},
+		{name: "call overrides the config", tool: "references", config: "json", argument: "markdown", want: "markdown"},
+		{name: "call asks for text", tool: "diagnostics", config: "markdown", argument: "text", want: "text"},
+		{name: "config format the tool lacks", tool: "search_symbols", config: "markdown", want: "text"},
+		{name: "call format the tool lacks", tool: "search_symbols", config: "text", argument: "markdown", want: "text"},
+		{name: "tool without formats", tool: "hover", config: "json", argument: "json", want: "text"},
+		{name: "invalid argument", tool: "references", config: "json", argument: 1, want: "json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &mcpServer{config: config{outputFormat: tt.config}}
+			request := mcp.CallToolRequest{}
+			if tt.argument != nil {
+				request.Params.Arguments = map[string]any{outputFormatParam: tt.argument}
+			}
+			ctx := s.withOutputFormat(context.Background(), tt.tool, request)
+
+			format := "text"
+			if tools.JSONOutput(ctx) {
+				format = "json"
+			} else if tools.MarkdownOutput(ctx) {
+				format = "markdown"
+			}
+			assert.Equal(t, tt.want, format)
+		})
+	}
+}
+
+func TestWithOutputFormatOption(t *testing.T) {
+	s := &mcpServer{config: config{outputFormat: "markdown"}}
+
+	tool := s.withOutputFormatOption(mcp.NewTool("references"))
+	property := tool.InputSchema.Properties[outputFormatParam].(map[string]any)
+	assert.Equal(t, []string{"text", "json", "markdown"}, property["enum"])
+	assert.Equal(t, "markdown", property["default"])
+
+	tool = s.withOutputFormatOption(mcp.NewTool("search_symbols"))
+	property = tool.InputSchema.Properties[outputFormatParam].(map[string]any)
+	assert.Equal(t, []string{"text", "json"}, property["enum"])
+	assert.Equal(t, "text", property["default"])
+
+	tool = s.withOutputFormatOption(mcp.NewTool("hover"))
+	assert.NotContains(t, tool.InputSchema.Properties, outputFormatParam)
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// oneIndexedParam is the argument that says whether the positions passed
+	// to a tool are 1-indexed
+	oneIndexedParam = "oneIndexed"
+	// lineTextParam is the argument holding the expected content of the
+	// lines at a position, used to relocate stale positions
+	lineTextParam = "lineText"
+	// defaultRelocateRadius is how many lines above and below a stale
+	// position are searched for its lineText
+	defaultRelocateRadius = 10
+)
+
+// anchorLineArguments are the arguments that a lineText is matched against,
+// in order of preference. Lines shift together with the anchor.
+var anchorLineArguments = []string{"line", "startLine"}
+
+// relocatedLineArguments are the arguments shifted when a position is
+// relocated
+var relocatedLineArguments = []string{"line", "startLine", "endLine"}
+
+// positionArguments are the tool arguments that hold 1-indexed lines and
+// columns, at the top level or in the items of edits
+var positionArguments = []string{"line", "column", "startLine", "endLine", "startColumn", "endColumn", "stoppedLine"}
+
+// queryArguments returns the arguments of a call that identify its query, to
+// bind continuation tokens to: all but the token itself and the position
+// options, which have been applied to the positions by normalizePositions
+// and relocatePositions
+func queryArguments(request mcp.CallToolRequest) map[string]any {
+	query := make(map[string]any, len(request.Params.Arguments))
+	for name, value := range request.Params.Arguments {
+		switch name {
+		case "continuation", oneIndexedParam, lineTextParam:
+			continue
+		}
+		query[name] = value
+	}
+	return query
+}
+
+// hasPositionArguments reports whether a tool takes lines or columns
+func hasPositionArguments(tool mcp.Tool) bool {
+	if _, ok := tool.InputSchema.Properties["edits"]; ok {
+		return true
+	}
+	for _, name := range positionArguments {
+		if _, ok := tool.InputSchema.Properties[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// withOneIndexedOption adds the oneIndexed argument to a tool that takes
+// lines or columns
+func (s *mcpServer) withOneIndexedOption(tool mcp.Tool) mcp.Tool {
+	if !hasPositionArguments(tool) {
+		return tool
+	}
+	return withToolProperty(tool, oneIndexedParam, map[string]any{
+		"type":        "boolean",
+		"description": "If false, the lines and columns passed are 0-indexed, as in LSP, rather than 1-indexed. Positions in the result are always 1-indexed.",
+		"default":     s.config.oneIndexed,
+	})
+}
+
+// withLineTextOption adds the lineText argument to a tool that takes a file
+// and a line
+func (s *mcpServer) withLineTextOption(tool mcp.Tool) mcp.Tool {
+	if _, ok := tool.InputSchema.Properties["filePath"]; !ok || anchorLine(tool.InputSchema.Properties) == "" {
+		return tool
+	}
+	return withToolProperty(tool, lineTextParam, map[string]any{
+		"type": "string",
+		"description": fmt.Sprintf("Optional expected content of the line at the position, or of several lines separated by newlines. "+
+			"If the file has changed and it does not match, ignoring whitespace and line endings, the nearest match within %d lines is used instead and the adjustment is reported.", s.config.relocateRadius),
+	})
+}
+
+// withToolProperty returns a tool with an argument added to its schema
+func withToolProperty(tool mcp.Tool, name string, property map[string]any) mcp.Tool {
+	properties := make(map[string]any, len(tool.InputSchema.Properties)+1)
+	for name, property := range tool.InputSchema.Properties {
+		properties[name] = property
+	}
+	properties[name] = property
+	tool.InputSchema.Properties = properties
+	return tool
+}
+
+// anchorLine returns the argument a lineText is matched against, or "" if
+// there is none
+func anchorLine(arguments map[string]any) string {
+	for _, name := range anchorLineArguments {
+		if _, ok := arguments[name]; ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// normalizePositions returns the request with its lines and columns
+// 1-indexed, converting them if the call or the configuration says they are
+// 0-indexed
+func (s *mcpServer) normalizePositions(request mcp.CallToolRequest) mcp.CallToolRequest {
+	oneIndexed := s.config.oneIndexed
+	if value, ok := request.Params.Arguments[oneIndexedParam].(bool); ok {
+		oneIndexed = value
+	}
+	if oneIndexed || request.Params.Arguments == nil {
+		return request
+	}
+
+	arguments := shiftPositions(request.Params.Arguments)
+	if edits, ok := arguments["edits"].([]any); ok {
+		shifted := make([]any, len(edits))
+		for i, edit := range edits {
+			if item, ok := edit.(map[string]any); ok {
+				shifted[i] = shiftPositions(item)
+			} else {
+				shifted[i] = edit
+			}
+		}
+		arguments["edits"] = shifted
+	}
+	request.Params.Arguments = arguments
+	return request
+}
+
+// shiftPositions returns a copy of arguments with the numeric lines and
+// columns incremented
+func shiftPositions(arguments map[string]any) map[string]any {
+	shifted := make(map[string]any, len(arguments))
+	for name, value := range arguments {
+		shifted[name] = value
+	}
+	for _, name := range positionArguments {
+		switch v := shifted[name].(type) {
+		case float64:
+			shifted[name] = v + 1
+		case int:
+			shifted[name] = v + 1
+		}
+	}
+	return shifted
+}
+
+// relocatePositions checks the lineText of a request against the file, as
+// its language server sees it if it is open. If the lines at the position do
+// not match, the position is moved to the
+// nearest lines within the relocate radius that do. It returns the request
+// and a note on the adjustment made, or on the failure to find a match. The
+// lines of the request must be 1-indexed.
+func (s *mcpServer) relocatePositions(request mcp.CallToolRequest) (mcp.CallToolRequest, string) {
+	arguments := request.Params.Arguments
+	lineText, _ := arguments[lineTextParam].(string)
+	filePath, _ := arguments["filePath"].(string)
+	anchor := anchorLine(arguments)
+	if strings.TrimSpace(lineText) == "" || filePath == "" || anchor == "" {
+		return request, ""
+	}
+	line, ok := arguments[anchor].(float64)
+	if !ok {
+		return request, ""
+	}
+
+	path := filePath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.config.workspaceDir, path)
+	}
+	content, err := s.readLines(path)
+	if err != nil {
+		// The tool reports the missing file
+		return request, ""
+	}
+
+	found, ok := matchLines(strings.Split(content, "\n"), strings.Split(strings.TrimRight(lineText, "\r\n"), "\n"), int(line), s.config.relocateRadius)
+	if !ok {
+		return request, fmt.Sprintf("Warning: %s does not match lineText at line %d or within %d lines of it. The position was used as given, but the file may have changed since it was read.", filePath, int(line), s.config.relocateRadius)
+	}
+	delta := found - int(line)
+	if delta == 0 {
+		return request, ""
+	}
+
+	relocated := make(map[string]any, len(arguments))
+	for name, value := range arguments {
+		relocated[name] = value
+	}
+	for _, name := range relocatedLineArguments {
+		if value, ok := relocated[name].(float64); ok {
+			relocated[name] = value + float64(delta)
+		}
+	}
+	request.Params.Arguments = relocated
+	return request, fmt.Sprintf("Note: line %d of %s did not match lineText, so the nearest match at line %d (%+d) was used instead.", int(line), filePath, found, delta)
+}
+
+// readLines returns the text of a file that lineText is checked against:
+// the text last sent to the language server if the file is open, as the
+// positions refer to it, or the file on disk
+func (s *mcpServer) readLines(path string) (string, error) {
+	if client := s.clientFor(path); client != nil {
+		return client.ReadDocument(lsp.DocumentURI(path))
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// matchLines returns the 1-indexed line nearest to line, at most radius
+// lines away, where the expected lines start. Lines are compared ignoring
+// whitespace and line endings. The line itself is preferred, then lines
+// below before lines above at the same distance.
+func matchLines(lines, expected []string, line, radius int) (int, bool) {
+	want := make([]string, len(expected))
+	for i, text := range expected {
+		want[i] = stripWhitespace(text)
+	}
+
+	matchesAt := func(start int) bool {
+		if start < 1 || start-1+len(want) > len(lines) {
+			return false
+		}
+		for i, text := range want {
+			if stripWhitespace(lines[start-1+i]) != text {
+				return false
+			}
+		}
+		return true
+	}
+
+	for distance := 0; distance <= radius; distance++ {
+		if matchesAt(line + distance) {
+			return line + distance, true
+		}
+		if distance > 0 && matchesAt(line-distance) {
+			return line - distance, true
+		}
+	}
+	return 0, false
+}
+
+// stripWhitespace removes all whitespace from a line, including a trailing
+// carriage return
+func stripWhitespace(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// withPositionNote adds a note on a relocated position to a tool result
+func withPositionNote(result *mcp.CallToolResult, note string) *mcp.CallToolResult {
+	if result == nil || note == "" {
+		return result
+	}
+	result.Content = append(result.Content, mcp.NewTextContent(note))
+	return result
+}
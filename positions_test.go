@@ -0,0 +1,181 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryArguments(t *testing.T) {
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{
+		"filePath":     "main.go",
+		"line":         float64(5),
+		"continuation": "token",
+		"oneIndexed":   false,
+		"lineText":     "func main() {",
+	}
+	assert.Equal(t, map[string]any{"filePath": "main.go", "line": float64(5)}, queryArguments(request))
+}
+
+func TestNormalizePositions(t *testing.T) {
+	tests := []struct {
+		name       string
+		oneIndexed bool
+		arguments  map[string]any
+		want       map[string]any
+	}{
+		{
+			name:       "1-indexed by default",
+			oneIndexed: true,
+			arguments:  map[string]any{"filePath": "main.go", "line": float64(3), "column": float64(0)},
+			want:       map[string]any{"filePath": "main.go", "line": float64(3), "column": float64(0)},
+		},
+		{
+			name:       "0-indexed call",
+			oneIndexed: true,
+			arguments:  map[string]any{"filePath": "main.go", "line": float64(3), "column": float64(0), "oneIndexed": false},
+			want:       map[string]any{"filePath": "main.go", "line": float64(4), "column": float64(1), "oneIndexed": false},
+		},
+		{
+			name:       "0-indexed config",
+			oneIndexed: false,
+			arguments:  map[string]any{"startLine": float64(0), "endLine": 2, "newText": "x"},
+			want:       map[string]any{"startLine": float64(1), "endLine": 3, "newText": "x"},
+		},
+		{
+			name:       "1-indexed call overrides the config",
+			oneIndexed: false,
+			arguments:  map[string]any{"line": float64(3), "oneIndexed": true},
+			want:       map[string]any{"line": float64(3), "oneIndexed": true},
+		},
+		{
+			name:       "edits",
+			oneIndexed: false,
+			arguments: map[string]any{"edits": []any{
+				map[string]any{"startLine": float64(0), "endLine": float64(1), "newText": "x"},
+				"not an edit",
+			}},
+			want: map[string]any{"edits": []any{
+				map[string]any{"startLine": float64(1), "endLine": float64(2), "newText": "x"},
+				"not an edit",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &mcpServer{config: config{oneIndexed: tt.oneIndexed}}
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tt.arguments
+			assert.Equal(t, tt.want, s.normalizePositions(request).Params.Arguments)
+		})
+	}
+
+	t.Run("does not change the call's arguments", func(t *testing.T) {
+		s := &mcpServer{config: config{oneIndexed: false}}
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"line": float64(3)}
+		s.normalizePositions(request)
+		assert.Equal(t, float64(3), request.Params.Arguments["line"])
+	})
+}
+
+func TestRelocatePositions(t *testing.T) {
+	dir := t.TempDir()
+	content := "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(content), 0644))
+	s := &mcpServer{config: config{workspaceDir: dir, relocateRadius: 2}}
+
+	tests := []struct {
+		name      string
+		arguments map[string]any
+		want      map[string]any
+		note      string
+	}{
+		{
+			name:      "no lineText",
+			arguments: map[string]any{"filePath": "main.go", "line": float64(1)},
+			want:      map[string]any{"filePath": "main.go", "line": float64(1)},
+		},
+		{
+			name:      "matching line",
+			arguments: map[string]any{"filePath": "main.go", "line": float64(5), "lineText": "func main() {"},
+			want:      map[string]any{"filePath": "main.go", "line": float64(5), "lineText": "func main() {"},
+		},
+		{
+			name:      "moved line",
+			arguments: map[string]any{"filePath": "main.go", "line": float64(3), "column": float64(2), "lineText": "  func main(){"},
+			want:      map[string]any{"filePath": "main.go", "line": float64(5), "column": float64(2), "lineText": "  func main(){"},
+			note:      "Note: line 3 of main.go did not match lineText, so the nearest match at line 5 (+2) was used instead.",
+		},
+		{
+			name:      "moved range",
+			arguments: map[string]any{"filePath": "main.go", "startLine": float64(7), "endLine": float64(8), "lineText": "func main() {\n\tfmt.Println(\"hi\")"},
+			want:      map[string]any{"filePath": "main.go", "startLine": float64(5), "endLine": float64(6), "lineText": "func main() {\n\tfmt.Println(\"hi\")"},
+			note:      "Note: line 7 of main.go did not match lineText, so the nearest match at line 5 (-2) was used instead.",
+		},
+		{
+			name:      "outside the radius",
+			arguments: map[string]any{"filePath": "main.go", "line": float64(1), "lineText": "func main() {"},
+			want:      map[string]any{"filePath": "main.go", "line": float64(1), "lineText": "func main() {"},
+			note:      "Warning: main.go does not match lineText at line 1 or within 2 lines of it. The position was used as given, but the file may have changed since it was read.",
+		},
+		{
+			name:      "missing file",
+			arguments: map[string]any{"filePath": "missing.go", "line": float64(1), "lineText": "x"},
+			want:      map[string]any{"filePath": "missing.go", "line": float64(1), "lineText": "x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tt.arguments
+			relocated, note := s.relocatePositions(request)
+			assert.Equal(t, tt.want, relocated.Params.Arguments)
+			assert.Equal(t, tt.note, note)
+		})
+	}
+}
+
+func TestRelocatePositionsReadsFilesThroughServer(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {\n}\n"), 0644))
+	// Files the server does not have open are read from disk
+	s := &mcpServer{config: config{workspaceDir: dir, relocateRadius: 2}, lspClient: &lsp.Client{}}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"filePath": "main.go", "line": float64(1), "lineText": "func main() {"}
+	relocated, note := s.relocatePositions(request)
+	assert.Equal(t, float64(3), relocated.Params.Arguments["line"])
+	assert.Equal(t, "Note: line 1 of main.go did not match lineText, so the nearest match at line 3 (+2) was used instead.", note)
+}
+
+func TestMatchLines(t *testing.T) {
+	lines := []string{"a", "b", "a", "c", "b"}
+	tests := []struct {
+		expected []string
+		line     int
+		radius   int
+		want     int
+		ok       bool
+	}{
+		{[]string{"a"}, 1, 0, 1, true},
+		{[]string{"b"}, 3, 2, 2, true},
+		// Below is preferred to above at the same distance
+		{[]string{"a"}, 2, 1, 3, true},
+		{[]string{"b"}, 4, 1, 5, true},
+		{[]string{"a", "c"}, 1, 2, 3, true},
+		{[]string{"c"}, 1, 2, 0, false},
+		{[]string{"b", "x"}, 2, 5, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := matchLines(lines, tt.expected, tt.line, tt.radius)
+		assert.Equal(t, tt.ok, ok, "%v at line %d", tt.expected, tt.line)
+		assert.Equal(t, tt.want, got, "%v at line %d", tt.expected, tt.line)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// progressListeners are the tool calls in flight that asked for progress
+// notifications. The work done progress of the language servers, such as
+// indexing, is forwarded to them so that a slow call does not look hung.
+type progressListeners struct {
+	mu     sync.Mutex
+	nextID int
+	calls  map[int]*progressListener
+}
+
+type progressListener struct {
+	ctx   context.Context
+	token mcp.ProgressToken
+	// progress counts the notifications sent, as it must increase with each
+	progress float64
+}
+
+// listen forwards progress to a tool call until the returned function is
+// called
+func (l *progressListeners) listen(ctx context.Context, token mcp.ProgressToken) func() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.calls == nil {
+		l.calls = make(map[int]*progressListener)
+	}
+	id := l.nextID
+	l.nextID++
+	l.calls[id] = &progressListener{ctx: ctx, token: token}
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.calls, id)
+	}
+}
+
+// forwardProgress returns the function that sends the work done progress of
+// a language server to the listening tool calls
+func (s *mcpServer) forwardProgress(name string) lsp.ProgressHandler {
+	return func(progress lsp.Progress) {
+		if s.mcpServer == nil {
+			return
+		}
+		message := formatProgress(name, progress)
+
+		s.progress.mu.Lock()
+		defer s.progress.mu.Unlock()
+		for _, call := range s.progress.calls {
+			call.progress++
+			err := s.mcpServer.SendNotificationToClient(call.ctx, "notifications/progress", map[string]any{
+				"progressToken": call.token,
+				"progress":      call.progress,
+				"message":       message,
+			})
+			if err != nil {
+				coreLogger.Debug("Failed to send progress notification: %v", err)
+			}
+		}
+	}
+}
+
+// formatProgress describes a progress report, e.g. "gopls: Loading
+// packages (50%): 10/20"
+func formatProgress(name string, progress lsp.Progress) string {
+	message := name + ": " + progress.Title
+	if progress.Kind == "end" {
+		message += " done"
+	} else if progress.Percentage != nil {
+		message += fmt.Sprintf(" (%d%%)", *progress.Percentage)
+	}
+	if progress.Message != "" {
+		message += ": " + progress.Message
+	}
+	return message
+}
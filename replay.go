@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// maxInvocationHistory is the number of recent tool calls kept for replay
+	maxInvocationHistory = 20
+	// replayToolName is the name of the replay tool, which is not itself recorded
+	replayToolName = "replay_tool_call"
+)
+
+// invocation is a tool call recorded for replay: the request as it was
+// made, and as passed to the handler, with its positions made 1-indexed and
+// relocated to the lines they were read from
+type invocation struct {
+	time     time.Time
+	request  mcp.CallToolRequest
+	resolved mcp.CallToolRequest
+}
+
+// invocationHistory is a ring buffer of recent tool calls
+type invocationHistory struct {
+	entries []invocation
+	next    int
+	mu      sync.Mutex
+}
+
+func (h *invocationHistory) add(request, resolved mcp.CallToolRequest) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry := invocation{time: time.Now(), request: request, resolved: resolved}
+	if len(h.entries) < maxInvocationHistory {
+		h.entries = append(h.entries, entry)
+		return
+	}
+	h.entries[h.next] = entry
+	h.next = (h.next + 1) % maxInvocationHistory
+}
+
+// recent returns the recorded invocations, most recent first
+func (h *invocationHistory) recent() []invocation {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	result := make([]invocation, 0, len(h.entries))
+	for i := 0; i < len(h.entries); i++ {
+		idx := (h.next - 1 - i + 2*len(h.entries)) % len(h.entries)
+		result = append(result, h.entries[idx])
+	}
+	return result
+}
+
+// formatInvocation renders a tool call as its name and JSON arguments
+func formatInvocation(inv invocation) string {
+	args, err := json.Marshal(inv.request.Params.Arguments)
+	if err != nil {
+		args = []byte(fmt.Sprintf("%v", inv.request.Params.Arguments))
+	}
+	return fmt.Sprintf("%s %s (at %s)", inv.request.Params.Name, args, inv.time.Format(time.RFC3339))
+}
+
+// replayInvocation re-executes a recent tool call while capturing the JSON-RPC
+// traffic with the language server. index 1 is the most recent call. The
+// call is made at the positions the original call resolved to. Tools
+// that change files are not replayed, since that would make their edits
+// again.
+func (s *mcpServer) replayInvocation(ctx context.Context, index int) (string, error) {
+	recent := s.history.recent()
+	if len(recent) == 0 {
+		return "", fmt.Errorf("no tool calls have been recorded")
+	}
+	if index < 1 || index > len(recent) {
+		return "", fmt.Errorf("invalid index: %d. Available range: 1-%d", index, len(recent))
+	}
+
+	inv := recent[index-1]
+	if s.changesFiles(inv.request.Params.Name) {
+		return "", fmt.Errorf("%s changes files and is not replayed, call it again to repeat its edits", inv.request.Params.Name)
+	}
+	handler, ok := s.toolHandlers[inv.request.Params.Name]
+	if !ok {
+		return "", fmt.Errorf("no handler for tool: %s", inv.request.Params.Name)
+	}
+
+	trace := s.lspClient.StartTrace()
+	start := time.Now()
+	name := inv.request.Params.Name
+	result, err := s.callToolHandler(s.withOutputFormat(ctx, name, inv.request), name, handler, inv.resolved)
+	elapsed := time.Since(start)
+	s.lspClient.StopTrace(trace)
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("Replayed: %s\nDuration: %s\n\n", formatInvocation(inv), elapsed.Round(time.Millisecond)))
+
+	output.WriteString("=== Result ===\n")
+	switch {
+	case err != nil:
+		output.WriteString(fmt.Sprintf("Handler error: %v\n", err))
+	case result == nil:
+		output.WriteString("No result\n")
+	default:
+		if result.IsError {
+			output.WriteString("Tool returned an error:\n")
+		}
+		for _, content := range result.Content {
+			if text, ok := content.(mcp.TextContent); ok {
+				output.WriteString(text.Text + "\n")
+			}
+		}
+	}
+
+	output.WriteString("\n=== JSON-RPC trace ===\n")
+	output.WriteString(trace.String())
+
+	return output.String(), nil
+}
+
+// changesFiles reports whether a tool changes files, following an alias to
+// the tool it stands for and a macro to each of its steps
+func (s *mcpServer) changesFiles(name string) bool {
+	if target, ok := s.config.aliases[name]; ok {
+		name = target
+	}
+	if macro, ok := s.config.macros[name]; ok {
+		for _, step := range macro.Steps {
+			if s.changesFiles(step.Tool) {
+				return true
+			}
+		}
+		return false
+	}
+	return mutatingTools[name]
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayInvocationSkipsMutatingTools(t *testing.T) {
+	applied := 0
+	s := &mcpServer{
+		toolHandlers: map[string]server.ToolHandlerFunc{
+			"edit_file": func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				applied++
+				return mcp.NewToolResultText("edited"), nil
+			},
+		},
+	}
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "edit_file"
+	request.Params.Arguments = map[string]any{"filePath": "main.go"}
+	s.history.add(request, request)
+
+	_, err := s.replayInvocation(context.Background(), 1)
+	assert.ErrorContains(t, err, "edit_file changes files and is not replayed")
+	assert.Equal(t, 0, applied)
+}
+
+func TestReplayInvocationIndex(t *testing.T) {
+	s := &mcpServer{}
+	_, err := s.replayInvocation(context.Background(), 1)
+	assert.ErrorContains(t, err, "no tool calls have been recorded")
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "hover"
+	s.history.add(request, request)
+	_, err = s.replayInvocation(context.Background(), 2)
+	assert.ErrorContains(t, err, "invalid index: 2")
+}
+
+func TestReplayInvocationUsesResolvedRequest(t *testing.T) {
+	var replayed map[string]any
+	s := &mcpServer{
+		config:    config{oneIndexed: true, outputFormat: "text"},
+		lspClient: &lsp.Client{},
+		ctx:       context.Background(),
+		toolHandlers: map[string]server.ToolHandlerFunc{
+			"hover": func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				replayed = request.Params.Arguments
+				return mcp.NewToolResultText("hover"), nil
+			},
+		},
+	}
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "hover"
+	request.Params.Arguments = map[string]any{"filePath": "main.go", "line": float64(4), "column": float64(0), "oneIndexed": false}
+	resolved, _ := s.relocatePositions(s.normalizePositions(request))
+	s.history.add(request, resolved)
+
+	output, err := s.replayInvocation(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Contains(t, output, `"line":4`)
+	assert.Equal(t, float64(5), replayed["line"])
+	assert.Equal(t, float64(1), replayed["column"])
+}
+
+func TestReplayInvocationSkipsToolsThatChangeFiles(t *testing.T) {
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("done"), nil
+	}
+	s := &mcpServer{
+		config: config{
+			aliases: map[string]string{"gd": "definition", "rn": "rename_symbol", "check": "tidy"},
+			macros: map[string]macroConfig{
+				"tidy":    {Steps: []macroStep{{Tool: "diagnostics"}, {Tool: "format_document"}}},
+				"inspect": {Steps: []macroStep{{Tool: "hover"}, {Tool: "diagnostics"}}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		changesFiles bool
+	}{
+		{"definition", false},
+		{"gd", false},
+		{"inspect", false},
+		{"edit_file", true},
+		{"undo_last_edit", true},
+		{"rn", true},
+		{"tidy", true},
+		{"check", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.changesFiles, s.changesFiles(tt.name), tt.name)
+	}
+
+	s.toolHandlers = map[string]server.ToolHandlerFunc{"tidy": handler}
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "tidy"
+	s.history.add(request, request)
+	_, err := s.replayInvocation(context.Background(), 1)
+	assert.ErrorContains(t, err, "tidy changes files and is not replayed")
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// scopedPathArguments are the tool arguments that name a file or directory
+// checked against the scope
+var scopedPathArguments = []string{"filePath", "path", "oldPath"}
+
+// parseScope validates the scope directories of the config file and returns
+// them relative to the workspace, with forward slashes
+func parseScope(workspaceDir string, scope []string) ([]string, error) {
+	var dirs []string
+	for _, dir := range scope {
+		path := dir
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workspaceDir, path)
+		}
+		rel, err := filepath.Rel(workspaceDir, filepath.Clean(path))
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("scope directory in config file is outside the workspace: %s", dir)
+		}
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("scope directory in config file does not exist: %s", dir)
+		}
+		if rel == "." {
+			// The whole workspace is in scope
+			return nil, nil
+		}
+		dirs = append(dirs, filepath.ToSlash(rel))
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// scopeDirs returns the absolute paths of the directories in scope
+func (s *mcpServer) scopeDirs() []string {
+	dirs := make([]string, len(s.config.scope))
+	for i, dir := range s.config.scope {
+		dirs[i] = filepath.Join(s.config.workspaceDir, filepath.FromSlash(dir))
+	}
+	return dirs
+}
+
+// inScope reports whether a path, absolute or relative to the workspace, is
+// in one of the scope directories or extra workspace folders, or no scope is
+// configured
+func (s *mcpServer) inScope(path string) bool {
+	if len(s.config.scope) == 0 {
+		return true
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.config.workspaceDir, path)
+	}
+	path = filepath.Clean(path)
+	for _, dir := range append(s.scopeDirs(), s.folders.list()...) {
+		if inDir(dir, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// outOfScopeDirs returns the directories, relative to the workspace, that
+// hold nothing in scope: the siblings of the scope directories and of each
+// of their parents
+func (s *mcpServer) outOfScopeDirs() []string {
+	if len(s.config.scope) == 0 {
+		return nil
+	}
+
+	// Directories on the way to a scope directory, and the scope directories
+	onPath := map[string]bool{}
+	scoped := map[string]bool{}
+	for _, dir := range s.config.scope {
+		scoped[dir] = true
+		for parent := dir; parent != "."; parent = filepath.ToSlash(filepath.Dir(parent)) {
+			onPath[parent] = true
+		}
+	}
+
+	var excluded []string
+	var walk func(dir string)
+	walk = func(dir string) {
+		entries, err := os.ReadDir(filepath.Join(s.config.workspaceDir, filepath.FromSlash(dir)))
+		if err != nil {
+			return
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			rel := entry.Name()
+			if dir != "." {
+				rel = dir + "/" + entry.Name()
+			}
+			switch {
+			case scoped[rel]:
+			case onPath[rel]:
+				walk(rel)
+			default:
+				excluded = append(excluded, rel)
+			}
+		}
+	}
+	walk(".")
+	return excluded
+}
+
+// configureScope restricts what a language server indexes to the scope
+// directories: they are passed as the workspace folders by
+// configureWorkspaceFolders, which most servers index separately, and as
+// gopls directoryFilters and rust-analyzer excludeDirs. Other servers ignore
+// settings they do not know.
+func (s *mcpServer) configureScope(client *lsp.Client) {
+	if len(s.config.scope) == 0 {
+		return
+	}
+	coreLogger.Info("Restricting indexing to %v", s.config.scope)
+
+	excluded := s.outOfScopeDirs()
+	client.SetInitializationOption("files", map[string]any{"excludeDirs": excluded})
+}
+
+// scopeFilters returns the gopls directoryFilters that leave out everything
+// but the scope directories
+func (s *mcpServer) scopeFilters() []string {
+	if len(s.config.scope) == 0 {
+		return nil
+	}
+	filters := []string{"-"}
+	for _, dir := range s.config.scope {
+		filters = append(filters, "+"+dir)
+	}
+	return filters
+}
+
+// warnOutOfScope adds a warning to the result of a tool asked about a path
+// outside the scope, where the language server has not indexed anything and
+// results may be missing
+func (s *mcpServer) warnOutOfScope(request mcp.CallToolRequest, result *mcp.CallToolResult) *mcp.CallToolResult {
+	if len(s.config.scope) == 0 || result == nil {
+		return result
+	}
+	for _, key := range scopedPathArguments {
+		path, _ := request.Params.Arguments[key].(string)
+		if path != "" && !s.inScope(path) {
+			result.Content = append(result.Content, mcp.NewTextContent(fmt.Sprintf(
+				"Warning: %s is outside the indexed scope (%s). The language server has not indexed it, so results may be missing or incomplete.",
+				path, strings.Join(s.config.scope, ", "))))
+			break
+		}
+	}
+	return result
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scopeWorkspace creates a workspace with the given directories
+func scopeWorkspace(t *testing.T, dirs ...string) string {
+	workspace := t.TempDir()
+	for _, dir := range dirs {
+		require.NoError(t, os.MkdirAll(filepath.Join(workspace, filepath.FromSlash(dir)), 0755))
+	}
+	return workspace
+}
+
+func TestParseScope(t *testing.T) {
+	workspace := scopeWorkspace(t, "services/api", "libs/core", "web")
+
+	tests := []struct {
+		name  string
+		scope []string
+		want  []string
+		err   string
+	}{
+		{name: "relative", scope: []string{"web", "services/api/"}, want: []string{"services/api", "web"}},
+		{name: "absolute", scope: []string{filepath.Join(workspace, "libs", "core")}, want: []string{"libs/core"}},
+		{name: "whole workspace", scope: []string{"web", "."}, want: nil},
+		{name: "outside the workspace", scope: []string{"../other"}, err: "scope directory in config file is outside the workspace: ../other"},
+		{name: "missing", scope: []string{"docs"}, err: "scope directory in config file does not exist: docs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dirs, err := parseScope(workspace, tt.scope)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, dirs)
+		})
+	}
+}
+
+func TestInScope(t *testing.T) {
+	workspace := scopeWorkspace(t, "services/api", "services/billing", "web")
+	s := &mcpServer{config: config{workspaceDir: workspace, scope: []string{"services/api"}}}
+	s.folders.dirs = []string{filepath.Join(t.TempDir(), "shared")}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"services/api/main.go", true},
+		{"services/api", true},
+		{filepath.Join(workspace, "services", "api", "handlers", "user.go"), true},
+		{"services/api-v2/main.go", false},
+		{"services/billing/main.go", false},
+		{"services/api/../billing/main.go", false},
+		{"web/index.ts", false},
+		{filepath.Join(s.folders.dirs[0], "lib.go"), true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, s.inScope(tt.path), tt.path)
+	}
+
+	s.config.scope = nil
+	assert.True(t, s.inScope("web/index.ts"))
+}
+
+func TestOutOfScopeDirs(t *testing.T) {
+	workspace := scopeWorkspace(t, "services/api/handlers", "services/billing", "libs/core", "libs/ui", "web")
+	s := &mcpServer{config: config{workspaceDir: workspace, scope: []string{"libs/core", "services/api"}}}
+
+	assert.Equal(t, []string{"libs/ui", "services/billing", "web"}, s.outOfScopeDirs())
+	assert.Equal(t, []string{"-", "+libs/core", "+services/api"}, s.scopeFilters())
+
+	s.config.scope = nil
+	assert.Nil(t, s.outOfScopeDirs())
+	assert.Nil(t, s.scopeFilters())
+}
+
+func TestWatcherConfigExcludesReportDir(t *testing.T) {
+	workspace := scopeWorkspace(t)
+	s := &mcpServer{
+		config:     config{workspaceDir: workspace},
+		resultSink: newResultSink(workspace, largeResultsConfig{Threshold: 1000}),
+	}
+	assert.True(t, s.watcherConfig().ExcludedPaths[filepath.Join(workspace, ".mcp-language-server", "reports")])
+
+	s.resultSink = &memorySink{}
+	assert.Empty(t, s.watcherConfig().ExcludedPaths)
+}
+
+func TestWarnOutOfScope(t *testing.T) {
+	workspace := scopeWorkspace(t, "services/api", "web")
+	s := &mcpServer{config: config{workspaceDir: workspace, scope: []string{"services/api"}}}
+
+	tests := []struct {
+		name      string
+		arguments map[string]any
+		warned    bool
+	}{
+		{name: "in scope", arguments: map[string]any{"filePath": "services/api/main.go"}},
+		{name: "no path", arguments: map[string]any{"symbolName": "Handler"}},
+		{name: "file out of scope", arguments: map[string]any{"filePath": "web/index.ts"}, warned: true},
+		{name: "directory out of scope", arguments: map[string]any{"path": "web"}, warned: true},
+		{name: "old path out of scope", arguments: map[string]any{"oldPath": "web/a.ts", "newPath": "services/api/a.ts"}, warned: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tt.arguments
+			result := s.warnOutOfScope(request, mcp.NewToolResultText("result"))
+			if !tt.warned {
+				assert.Len(t, result.Content, 1)
+				return
+			}
+			assert.Len(t, result.Content, 2)
+			assert.Contains(t, toolResultText(result), "is outside the indexed scope (services/api)")
+		})
+	}
+
+	assert.Nil(t, s.warnOutOfScope(mcp.CallToolRequest{}, nil))
+}
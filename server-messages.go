@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxServerMessages bounds the messages kept until the next tool result. The
+// oldest are dropped.
+const maxServerMessages = 20
+
+// messageRequestsConfig is how prompts of the language servers, sent with
+// window/showMessageRequest, are answered since there is no user to ask
+type messageRequestsConfig struct {
+	// Answers choose the action for prompts whose message matches, in order
+	Answers []messageAnswerConfig `json:"answers"`
+	// Default is "dismiss", the default, to answer other prompts with no
+	// action, or "first" to choose their first action
+	Default string `json:"default"`
+}
+
+// messageAnswerConfig chooses the action titled Action for prompts whose
+// message matches the regular expression Match
+type messageAnswerConfig struct {
+	Match  string `json:"match"`
+	Action string `json:"action"`
+}
+
+// validate checks the regular expressions and the default
+func (c messageRequestsConfig) validate() error {
+	for _, answer := range c.Answers {
+		if _, err := regexp.Compile(answer.Match); err != nil {
+			return fmt.Errorf("invalid match %q: %v", answer.Match, err)
+		}
+	}
+	if c.Default != "" && c.Default != "dismiss" && c.Default != "first" {
+		return fmt.Errorf("default must be dismiss or first: %s", c.Default)
+	}
+	return nil
+}
+
+// policy answers a prompt with the action of the first answer that matches
+// its message and that it offers, or else by the default
+func (c messageRequestsConfig) policy() lsp.MessageRequestPolicy {
+	type answer struct {
+		match  *regexp.Regexp
+		action string
+	}
+	var answers []answer
+	for _, a := range c.Answers {
+		answers = append(answers, answer{regexp.MustCompile(a.Match), a.Action})
+	}
+
+	return func(params protocol.ShowMessageRequestParams) *protocol.MessageActionItem {
+		for _, a := range answers {
+			if !a.match.MatchString(params.Message) {
+				continue
+			}
+			for _, action := range params.Actions {
+				if action.Title == a.action {
+					return &action
+				}
+			}
+		}
+		if c.Default == "first" && len(params.Actions) > 0 {
+			return &params.Actions[0]
+		}
+		return nil
+	}
+}
+
+// serverMessages are the messages shown by the language servers since the
+// last tool result, which they are attached to
+type serverMessages struct {
+	mu      sync.Mutex
+	pending []string
+}
+
+// messageHandler returns the function that keeps the messages of a server
+// for the next tool result
+func (s *mcpServer) messageHandler(name string) lsp.MessageHandler {
+	return func(msg lsp.ServerMessage) {
+		s.serverMessages.mu.Lock()
+		defer s.serverMessages.mu.Unlock()
+		s.serverMessages.pending = append(s.serverMessages.pending, formatServerMessage(name, msg))
+		if len(s.serverMessages.pending) > maxServerMessages {
+			s.serverMessages.pending = s.serverMessages.pending[len(s.serverMessages.pending)-maxServerMessages:]
+		}
+	}
+}
+
+// formatServerMessage describes a message or a prompt and how it was
+// answered
+func formatServerMessage(name string, msg lsp.ServerMessage) string {
+	severity := "info"
+	switch msg.Type {
+	case protocol.Error:
+		severity = "error"
+	case protocol.Warning:
+		severity = "warning"
+	}
+	text := fmt.Sprintf("Message from %s (%s): %s", name, severity, msg.Message)
+	if len(msg.Actions) > 0 {
+		answer := "dismissed"
+		if msg.Answer != "" {
+			answer = "answered " + msg.Answer
+		}
+		text += fmt.Sprintf(" [%s] (%s)", strings.Join(msg.Actions, ", "), answer)
+	}
+	return text
+}
+
+// withServerMessages adds the messages shown by the language servers since
+// the last tool result to a tool result
+func (s *mcpServer) withServerMessages(result *mcp.CallToolResult) *mcp.CallToolResult {
+	if result == nil {
+		return result
+	}
+
+	s.serverMessages.mu.Lock()
+	pending := s.serverMessages.pending
+	s.serverMessages.pending = nil
+	s.serverMessages.mu.Unlock()
+
+	if len(pending) > 0 {
+		result.Content = append(result.Content, mcp.NewTextContent(strings.Join(pending, "\n")))
+	}
+	return result
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/tools"
+	"github.com/koonwen/mcp-language-server/internal/watcher"
+)
+
+// additionalServer is a language server started alongside the main one
+type additionalServer struct {
+	name   string
+	client *lsp.Client
+}
+
+// startAdditionalServers starts the additional language servers from the
+// config file concurrently. A server that fails to start is logged and left
+// out rather than stopping the main server.
+func (s *mcpServer) startAdditionalServers() {
+	started := make([]*additionalServer, len(s.config.servers))
+
+	var wg sync.WaitGroup
+	for i, cfg := range s.config.servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			server, err := s.startServer(cfg)
+			if err != nil {
+				coreLogger.Warn("Failed to start language server %s: %v", cfg.Name, err)
+				return
+			}
+			started[i] = server
+		}()
+	}
+	wg.Wait()
+
+	for i, server := range started {
+		if server == nil {
+			continue
+		}
+		s.additionalServers = append(s.additionalServers, *server)
+		cfg := s.config.servers[i]
+		if len(cfg.Extensions) > 0 || len(cfg.Languages) > 0 {
+			s.router.Add(server.name, server.client, cfg.Extensions, cfg.Languages)
+		}
+	}
+}
+
+// serverByName returns the main or an additional language server by name,
+// and a label for it. An empty name is the main server.
+func (s *mcpServer) serverByName(name string) (*lsp.Client, string, error) {
+	mainName := filepath.Base(s.config.lspCommand)
+	if name == "" || name == mainName {
+		return s.lspClient, mainName + ", main", nil
+	}
+	names := []string{mainName}
+	for _, server := range s.additionalServers {
+		if server.name == name {
+			return server.client, server.name, nil
+		}
+		names = append(names, server.name)
+	}
+	return nil, "", fmt.Errorf("unknown server %s, expected one of: %s", name, strings.Join(names, ", "))
+}
+
+// clientFor returns the language server for the file a tool is called on:
+// the additional server routed to its extension or language, or the main
+// server
+func (s *mcpServer) clientFor(path string) *lsp.Client {
+	if s.router == nil {
+		return s.lspClient
+	}
+	return s.router.ClientFor(path)
+}
+
+// startServer starts and initializes an additional language server with its
+// own workspace watcher
+func (s *mcpServer) startServer(cfg serverConfig) (*additionalServer, error) {
+	client, err := lsp.NewClientWithEnv(cfg.Command, cfg.Env, cfg.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LSP client: %v", err)
+	}
+	client.SetConcurrencyLimits(s.config.concurrency)
+	client.SetCrashHandler(s.handleCrash)
+	client.SetRestartPolicy(s.restartPolicy(), s.restartHandler(cfg.Name))
+	client.SetProgressHandler(s.forwardProgress(cfg.Name))
+	client.SetMessageHandler(s.messageHandler(cfg.Name))
+	client.SetMessageRequestPolicy(s.config.messageRequests.policy())
+	client.SetDiagnosticsHandler(s.events.recordDiagnostics)
+	s.configureBuildLayout(client)
+	client.MergeInitializationOptions(cfg.InitializationOptions)
+	client.SetSettings(cfg.Settings)
+
+	if _, err := client.InitializeLSPClient(s.ctx, s.config.workspaceDir); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("initialize failed: %v", err)
+	}
+
+	workspaceWatcher := watcher.NewWorkspaceWatcherWithConfig(client, s.watcherConfig())
+	workspaceWatcher.SetChangeHandler(s.handleFileChange)
+	go workspaceWatcher.WatchWorkspace(s.ctx, s.config.workspaceDir)
+	s.watchWorkspaceFolders(client, s.handleFileChange)
+
+	if err := client.WaitForServerReady(s.ctx); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	coreLogger.Info("Started language server %s", cfg.Name)
+	return &additionalServer{name: cfg.Name, client: client}, nil
+}
+
+// embeddedServers maps the languages of embedded code to the servers
+// configured to analyze them. Servers that failed to start are left out.
+func (s *mcpServer) embeddedServers() map[string]*lsp.Client {
+	servers := make(map[string]*lsp.Client)
+	for language, name := range s.config.embedded {
+		if name == filepath.Base(s.config.lspCommand) {
+			servers[language] = s.lspClient
+		}
+		for _, server := range s.additionalServers {
+			if server.name == name {
+				servers[language] = server.client
+			}
+		}
+	}
+	return servers
+}
+
+// symbolServers lists the main and additional language servers for a
+// federated search
+func (s *mcpServer) symbolServers() []tools.SymbolServer {
+	servers := []tools.SymbolServer{{Name: filepath.Base(s.config.lspCommand), Client: s.lspClient}}
+	for _, server := range s.additionalServers {
+		servers = append(servers, tools.SymbolServer{Name: server.name, Client: server.client})
+	}
+	return servers
+}
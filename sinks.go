@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	reportURIPrefix = "mcp-language-server://reports/"
+	// defaultReportDir is where the file sink writes reports, relative to
+	// the workspace
+	defaultReportDir = ".mcp-language-server/reports"
+	// defaultPreviewLines is the number of lines of a large result returned
+	// along with the location of the full result
+	defaultPreviewLines = 40
+	// maxMemoryReports bounds the number of reports kept by the resource
+	// sink. The oldest are dropped.
+	maxMemoryReports = 50
+)
+
+// largeResultsConfig configures where results above a size threshold are
+// stored instead of being returned in full
+type largeResultsConfig struct {
+	// Threshold is the size in bytes of the text of a result above which it
+	// is stored in the sink. Zero, the default, returns all results in full.
+	Threshold int `json:"threshold"`
+	// Sink is "file", the default, to write reports to files in Dir, or
+	// "resource" to keep them in memory
+	Sink string `json:"sink"`
+	// Dir is the directory of report files, relative to the workspace
+	// (default: .mcp-language-server/reports). It is git-ignored.
+	Dir string `json:"dir"`
+	// PreviewLines is the number of lines of the result returned (default: 40)
+	PreviewLines int `json:"previewLines"`
+}
+
+// resultSink stores the full text of large results, which can be read back
+// as resources
+type resultSink interface {
+	// store keeps a report under a name and returns the path of the file it
+	// was written to, if any
+	store(name, text string) (string, error)
+	// load returns the text of a report
+	load(name string) (string, error)
+}
+
+// fileSink writes reports to a git-ignored directory in the workspace
+type fileSink struct {
+	dir string
+}
+
+func (f *fileSink) store(name, text string) (string, error) {
+	if err := os.MkdirAll(f.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create report directory: %v", err)
+	}
+	// Keep reports out of version control
+	gitignore := filepath.Join(f.dir, ".gitignore")
+	if _, err := os.Stat(gitignore); os.IsNotExist(err) {
+		if err := os.WriteFile(gitignore, []byte("*\n"), 0644); err != nil {
+			return "", fmt.Errorf("failed to write %s: %v", gitignore, err)
+		}
+	}
+
+	path := filepath.Join(f.dir, name)
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		return "", fmt.Errorf("failed to write report: %v", err)
+	}
+	return path, nil
+}
+
+func (f *fileSink) load(name string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(f.dir, name))
+	if err != nil {
+		return "", fmt.Errorf("failed to read report: %v", err)
+	}
+	return string(content), nil
+}
+
+// memorySink keeps the latest reports in memory
+type memorySink struct {
+	mu      sync.Mutex
+	reports map[string]string
+	order   []string
+}
+
+func (m *memorySink) store(name, text string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.reports == nil {
+		m.reports = make(map[string]string)
+	}
+	m.reports[name] = text
+	m.order = append(m.order, name)
+	if len(m.order) > maxMemoryReports {
+		delete(m.reports, m.order[0])
+		m.order = m.order[1:]
+	}
+	return "", nil
+}
+
+func (m *memorySink) load(name string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	text, ok := m.reports[name]
+	if !ok {
+		return "", fmt.Errorf("no report named %s, it may have been dropped", name)
+	}
+	return text, nil
+}
+
+// newResultSink returns the sink configured for large results, or nil if
+// results are always returned in full
+func newResultSink(workspaceDir string, config largeResultsConfig) resultSink {
+	if config.Threshold <= 0 {
+		return nil
+	}
+	if config.Sink == "resource" {
+		return &memorySink{}
+	}
+	dir := config.Dir
+	if dir == "" {
+		dir = defaultReportDir
+	}
+	return &fileSink{dir: filepath.Join(workspaceDir, dir)}
+}
+
+// reportSeq numbers reports so that names are unique within the session
+var reportSeq atomic.Int64
+
+// spillResult stores a result whose text exceeds the threshold in the sink
+// and returns its first lines instead, with where to find the rest
+func (s *mcpServer) spillResult(toolName string, result *mcp.CallToolResult) *mcp.CallToolResult {
+	if s.resultSink == nil || result == nil || result.IsError {
+		return result
+	}
+
+	var texts []string
+	size := 0
+	for _, content := range result.Content {
+		text, ok := content.(mcp.TextContent)
+		if !ok {
+			return result
+		}
+		texts = append(texts, text.Text)
+		size += len(text.Text)
+	}
+	if size <= s.config.largeResults.Threshold {
+		return result
+	}
+
+	full := strings.Join(texts, "\n")
+	name := fmt.Sprintf("%s-%s-%d.txt", toolName, time.Now().Format("20060102-150405"), reportSeq.Add(1))
+	path, err := s.resultSink.store(name, full)
+	if err != nil {
+		coreLogger.Error("Failed to store large result of %s: %v", toolName, err)
+		return result
+	}
+
+	previewLines := s.config.largeResults.PreviewLines
+	if previewLines <= 0 {
+		previewLines = defaultPreviewLines
+	}
+	lines := strings.Split(strings.TrimRight(full, "\n"), "\n")
+	shown := min(previewLines, len(lines))
+
+	location := "the resource " + reportURIPrefix + name
+	if path != "" {
+		location = path + " and " + location
+	}
+	note := fmt.Sprintf("Result truncated: showing %d of %d lines. The full result (%d bytes) is in %s.", shown, len(lines), len(full), location)
+
+	return &mcp.CallToolResult{
+		Result:  result.Result,
+		Content: []mcp.Content{mcp.NewTextContent(strings.Join(lines[:shown], "\n") + "\n\n" + note)},
+	}
+}
+
+// registerReportResource registers the resources serving the full text of
+// large results
+func (s *mcpServer) registerReportResource() {
+	if s.resultSink == nil {
+		return
+	}
+	template := mcp.NewResourceTemplate(reportURIPrefix+"{name}", "Full tool result",
+		mcp.WithTemplateDescription("The full text of a tool result that was too large to return, by the name given in the truncated result."),
+		mcp.WithTemplateMIMEType("text/plain"),
+	)
+
+	s.mcpServer.AddResourceTemplate(template, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		name := strings.TrimPrefix(request.Params.URI, reportURIPrefix)
+		if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+			return nil, fmt.Errorf("invalid report name: %s", name)
+		}
+		text, err := s.resultSink.load(name)
+		if err != nil {
+			return nil, err
+		}
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: "text/plain",
+				Text:     text,
+			},
+		}, nil
+	})
+}
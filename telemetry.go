@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/koonwen/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// maxResultSamples is the number of recent result sizes kept per tool
+	maxResultSamples = 1000
+	// largeResultLines is the 90th percentile result size, in lines, above
+	// which a tool is recommended to be called differently
+	largeResultLines = 500
+	// recommendationsToolName is the name of the recommendations tool, which
+	// is not itself recorded
+	recommendationsToolName = "usage_recommendations"
+)
+
+// resultSizeAdvice suggests how to get smaller results from tools with
+// parameters that control their size. Other tools are advised to use fewer
+// context lines.
+var resultSizeAdvice = map[string]string{
+	"references":         "call it with countOnly first to find the files that matter, or lower contextLines in the config file",
+	"security_watchlist": "narrow the watchlist in the config file, or lower its contextLines",
+	"outgoing_calls":     "use a smaller depth",
+	"type_hierarchy":     "use a smaller depth or a single direction",
+	"call_path":          "use a smaller maxDepth or maxBreadth",
+	"diagnostics":        "set contextLines to false",
+	"rename_package":     "review the dry run per directory rather than for the whole package tree",
+}
+
+// resultSizeStats records the size of the results returned by each tool in
+// this session
+type resultSizeStats struct {
+	mu     sync.Mutex
+	sizes  map[string][]int
+	errors map[string]int
+}
+
+// toolResultSummary describes the distribution of a tool's result sizes in lines
+type toolResultSummary struct {
+	tool   string
+	calls  int
+	errors int
+	p50    int
+	p90    int
+	max    int
+}
+
+func (r *resultSizeStats) record(tool string, result *mcp.CallToolResult) {
+	if result == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sizes == nil {
+		r.sizes = make(map[string][]int)
+		r.errors = make(map[string]int)
+	}
+	if result.IsError {
+		r.errors[tool]++
+	}
+
+	sizes := append(r.sizes[tool], resultLines(result))
+	if len(sizes) > maxResultSamples {
+		sizes = sizes[1:]
+	}
+	r.sizes[tool] = sizes
+}
+
+// summaries returns the result size distribution of every tool that has been
+// called, sorted by tool name
+func (r *resultSizeStats) summaries() []toolResultSummary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []toolResultSummary
+	for tool, sizes := range r.sizes {
+		sorted := append([]int(nil), sizes...)
+		sort.Ints(sorted)
+		result = append(result, toolResultSummary{
+			tool:   tool,
+			calls:  len(sorted),
+			errors: r.errors[tool],
+			p50:    percentile(sorted, 50),
+			p90:    percentile(sorted, 90),
+			max:    sorted[len(sorted)-1],
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].tool < result[j].tool })
+	return result
+}
+
+// resultLines counts the lines of text in a tool result
+func resultLines(result *mcp.CallToolResult) int {
+	lines := 0
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok && text.Text != "" {
+			lines += strings.Count(strings.TrimSuffix(text.Text, "\n"), "\n") + 1
+		}
+	}
+	return lines
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []int, p int) int {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// formatRecommendations renders the result size distribution of each tool and
+// suggests changes for tools whose results are large or often fail
+func formatRecommendations(summaries []toolResultSummary) string {
+	if len(summaries) == 0 {
+		return "No tool calls have been recorded yet."
+	}
+
+	var stats, recommendations strings.Builder
+	stats.WriteString("Result sizes in lines by tool:\n")
+	for _, s := range summaries {
+		stats.WriteString(fmt.Sprintf("- %s: %d calls, p50 %d, p90 %d, max %d", s.tool, s.calls, s.p50, s.p90, s.max))
+		if s.errors > 0 {
+			stats.WriteString(fmt.Sprintf(", %d errors", s.errors))
+		}
+		stats.WriteString("\n")
+
+		if s.p90 > largeResultLines {
+			advice, ok := resultSizeAdvice[s.tool]
+			if !ok {
+				advice = fmt.Sprintf("lower contextLines in the config file (currently %d)", tools.ContextLines())
+			}
+			recommendations.WriteString(fmt.Sprintf("- %s: 90th percentile is %d lines; %s.\n", s.tool, s.p90, advice))
+		}
+		if s.calls >= 4 && s.errors*2 >= s.calls {
+			recommendations.WriteString(fmt.Sprintf("- %s: %d of %d calls failed; check the %s resource for LSP methods the server does not support.\n", s.tool, s.errors, s.calls, manifestURI))
+		}
+	}
+
+	if recommendations.Len() == 0 {
+		return stats.String() + "\nNo changes recommended."
+	}
+	return stats.String() + "\nRecommendations:\n" + recommendations.String()
+}
@@ -3,16 +3,20 @@ package main
 import (
 	"context"
 	"fmt"
+	"maps"
+	"strings"
 
+	"github.com/koonwen/mcp-language-server/internal/protocol"
 	"github.com/koonwen/mcp-language-server/internal/tools"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
 func (s *mcpServer) registerTools() error {
 	coreLogger.Debug("Registering MCP tools")
+	s.toolMethods = maps.Clone(toolLSPMethods)
 
 	applyTextEditTool := mcp.NewTool("edit_file",
-		mcp.WithDescription("Apply multiple text edits to a file."),
+		mcp.WithDescription("Apply multiple text edits to a file, keeping the language server's view of the file in sync. Edits replace whole lines, or exact character ranges when startColumn is given. With dryRun, returns the changes as a unified diff without writing them."),
 		mcp.WithArray("edits",
 			mcp.Required(),
 			mcp.Description("List of edits to apply"),
@@ -27,6 +31,14 @@ func (s *mcpServer) registerTools() error {
 						"type":        "number",
 						"description": "End line to replace, inclusive, one-indexed",
 					},
+					"startColumn": map[string]any{
+						"type":        "number",
+						"description": "Optional start column to replace from, inclusive, one-indexed. If given, only the characters from startLine:startColumn to endLine:endColumn are replaced instead of whole lines",
+					},
+					"endColumn": map[string]any{
+						"type":        "number",
+						"description": "End column to replace up to, exclusive, one-indexed (default: startColumn, to insert)",
+					},
 					"newText": map[string]any{
 						"type":        "string",
 						"description": "Replacement text. Replace with the new text. Leave blank to remove lines.",
@@ -39,9 +51,13 @@ func (s *mcpServer) registerTools() error {
 			mcp.Required(),
 			mcp.Description("Path to the file to edit"),
 		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("If true, return the changes as a unified diff without writing them (default: false)"),
+			mcp.DefaultBool(false),
+		),
 	)
 
-	s.mcpServer.AddTool(applyTextEditTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.addTool(applyTextEditTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract arguments
 		filePath, ok := request.Params.Arguments["filePath"].(string)
 		if !ok {
@@ -54,40 +70,15 @@ func (s *mcpServer) registerTools() error {
 			return mcp.NewToolResultError("edits is required"), nil
 		}
 
-		// Type assert and convert the edits
-		editsArray, ok := editsArg.([]any)
-		if !ok {
-			return mcp.NewToolResultError("edits must be an array"), nil
+		edits, errMsg := parseTextEdits(editsArg)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
 		}
 
-		var edits []tools.TextEdit
-		for _, editItem := range editsArray {
-			editMap, ok := editItem.(map[string]any)
-			if !ok {
-				return mcp.NewToolResultError("each edit must be an object"), nil
-			}
-
-			startLine, ok := editMap["startLine"].(float64)
-			if !ok {
-				return mcp.NewToolResultError("startLine must be a number"), nil
-			}
-
-			endLine, ok := editMap["endLine"].(float64)
-			if !ok {
-				return mcp.NewToolResultError("endLine must be a number"), nil
-			}
-
-			newText, _ := editMap["newText"].(string) // newText can be empty
-
-			edits = append(edits, tools.TextEdit{
-				StartLine: int(startLine),
-				EndLine:   int(endLine),
-				NewText:   newText,
-			})
-		}
+		dryRun, _ := request.Params.Arguments["dryRun"].(bool)
 
 		coreLogger.Debug("Executing edit_file for file: %s", filePath)
-		response, err := tools.ApplyTextEdits(s.ctx, s.lspClient, filePath, edits)
+		response, err := tools.ApplyTextEdits(ctx, s.clientFor(filePath), filePath, edits, dryRun)
 		if err != nil {
 			coreLogger.Error("Failed to apply edits: %v", err)
 			return mcp.NewToolResultError(fmt.Sprintf("failed to apply edits: %v", err)), nil
@@ -101,17 +92,42 @@ func (s *mcpServer) registerTools() error {
 			mcp.Required(),
 			mcp.Description("The name of the symbol whose definition you want to find (e.g. 'mypackage.MyFunction', 'MyType.MyMethod')"),
 		),
+		mcp.WithString("format",
+			mcp.Description("Output format: text, json with the documentation of each definition (doc comments, docstrings) in a separate field from its code, or markdown with the code in fenced blocks (default: text)"),
+			mcp.DefaultString("text"),
+			mcp.Enum("text", "json", "markdown"),
+		),
 	)
 
-	s.mcpServer.AddTool(readDefinitionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.addTool(readDefinitionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract arguments
 		symbolName, ok := request.Params.Arguments["symbolName"].(string)
 		if !ok {
 			return mcp.NewToolResultError("symbolName must be a string"), nil
 		}
 
+		readDefinition := tools.ReadDefinition
+		switch {
+		case tools.JSONOutput(ctx):
+			readDefinition = tools.ReadDefinitionJSON
+		case tools.MarkdownOutput(ctx):
+			readDefinition = tools.ReadDefinitionMarkdown
+		}
+		if format, ok := request.Params.Arguments["format"].(string); ok {
+			switch format {
+			case "text":
+				readDefinition = tools.ReadDefinition
+			case "json":
+				readDefinition = tools.ReadDefinitionJSON
+			case "markdown":
+				readDefinition = tools.ReadDefinitionMarkdown
+			default:
+				return mcp.NewToolResultError("format must be text, json or markdown"), nil
+			}
+		}
+
 		coreLogger.Debug("Executing definition for symbol: %s", symbolName)
-		text, err := tools.ReadDefinition(s.ctx, s.lspClient, symbolName)
+		text, err := readDefinition(ctx, s.lspClient, symbolName)
 		if err != nil {
 			coreLogger.Error("Failed to get definition: %v", err)
 			return mcp.NewToolResultError(fmt.Sprintf("failed to get definition: %v", err)), nil
@@ -119,6 +135,122 @@ func (s *mcpServer) registerTools() error {
 		return mcp.NewToolResultText(text), nil
 	})
 
+	searchSymbolsTool := mcp.NewTool("search_symbols",
+		mcp.WithDescription("Search the workspace for symbols by name, optionally only exact matches or only certain kinds. Results are ranked by exact name match, preferred kinds, proximity to a hint file, and whether they are in test or vendored code. Use the json format to see how each match was scored."),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("The symbol name to search for. Qualified names such as 'Type.Method' are accepted"),
+		),
+		mcp.WithString("match",
+			mcp.Description("fuzzy to keep everything the server's fuzzy search returns, or exact to only keep symbols named exactly like the query (default: fuzzy)"),
+			mcp.DefaultString("fuzzy"),
+			mcp.Enum("fuzzy", "exact"),
+		),
+		mcp.WithArray("kinds",
+			mcp.Description("Optional symbol kinds to keep, e.g. 'Function', 'Struct', 'Class', 'Method', 'Interface'"),
+			mcp.Items(map[string]any{
+				"type": "string",
+			}),
+		),
+		mcp.WithArray("preferKinds",
+			mcp.Description("Optional symbol kinds to rank higher without filtering out others"),
+			mcp.Items(map[string]any{
+				"type": "string",
+			}),
+		),
+		mcp.WithString("hintFile",
+			mcp.Description("Optional path of the file being worked on; symbols in nearby directories rank higher"),
+		),
+		mcp.WithNumber("maxResults",
+			mcp.Description("Maximum number of matches to return (default: 20)"),
+			mcp.DefaultNumber(20),
+		),
+		mcp.WithString("format",
+			mcp.Description("Output format: text, or json with the scoring signals of each match (default: text)"),
+			mcp.DefaultString("text"),
+			mcp.Enum("text", "json"),
+		),
+		mcp.WithBoolean("federated",
+			mcp.Description("Search every language server configured for the workspace at once and label each match with its server and language (default: false)"),
+			mcp.DefaultBool(false),
+		),
+	)
+
+	s.addTool(searchSymbolsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		query, ok := request.Params.Arguments["query"].(string)
+		if !ok {
+			return mcp.NewToolResultError("query must be a string"), nil
+		}
+
+		opts := tools.SymbolSearchOptions{}
+		opts.HintFile, _ = request.Params.Arguments["hintFile"].(string)
+
+		if match, ok := request.Params.Arguments["match"].(string); ok {
+			switch match {
+			case "fuzzy":
+			case "exact":
+				opts.Exact = true
+			default:
+				return mcp.NewToolResultError("match must be fuzzy or exact"), nil
+			}
+		}
+
+		for _, param := range []struct {
+			name  string
+			kinds *[]string
+		}{{"kinds", &opts.Kinds}, {"preferKinds", &opts.PreferKinds}} {
+			kindsArg, ok := request.Params.Arguments[param.name]
+			if !ok {
+				continue
+			}
+			kindsArray, ok := kindsArg.([]any)
+			if !ok {
+				return mcp.NewToolResultError(param.name + " must be an array"), nil
+			}
+			for _, kind := range kindsArray {
+				kindName, ok := kind.(string)
+				if !ok {
+					return mcp.NewToolResultError("each kind must be a string"), nil
+				}
+				*param.kinds = append(*param.kinds, kindName)
+			}
+		}
+
+		opts.Limit = intArg(request.Params.Arguments, "maxResults", 20)
+		if opts.Limit < 1 {
+			return mcp.NewToolResultError("maxResults must be at least 1"), nil
+		}
+
+		opts.JSON = tools.JSONOutput(ctx)
+		if format, ok := request.Params.Arguments["format"].(string); ok {
+			switch format {
+			case "text":
+				opts.JSON = false
+			case "json":
+				opts.JSON = true
+			default:
+				return mcp.NewToolResultError("format must be text or json"), nil
+			}
+		}
+
+		federated, _ := request.Params.Arguments["federated"].(bool)
+
+		coreLogger.Debug("Executing search_symbols for query: %s", query)
+		var text string
+		var err error
+		if federated {
+			text, err = tools.SearchWorkspaceSymbolsFederated(ctx, s.symbolServers(), query, opts)
+		} else {
+			text, err = tools.SearchWorkspaceSymbols(ctx, s.lspClient, query, opts)
+		}
+		if err != nil {
+			coreLogger.Error("Failed to search symbols: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to search symbols: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
 	goToDefinitionTool := mcp.NewTool("go_to_definition",
 		mcp.WithDescription("Go to the definition of the symbol at the specified position. This uses the LSP textDocument/definition request directly with file position, providing context-aware navigation to the exact definition."),
 		mcp.WithString("filePath",
@@ -135,35 +267,20 @@ func (s *mcpServer) registerTools() error {
 		),
 	)
 
-	s.mcpServer.AddTool(goToDefinitionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.addTool(goToDefinitionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract arguments
 		filePath, ok := request.Params.Arguments["filePath"].(string)
 		if !ok {
 			return mcp.NewToolResultError("filePath must be a string"), nil
 		}
 
-		// Handle both float64 and int for line and column due to JSON parsing
-		var line, column int
-		switch v := request.Params.Arguments["line"].(type) {
-		case float64:
-			line = int(v)
-		case int:
-			line = v
-		default:
-			return mcp.NewToolResultError("line must be a number"), nil
-		}
-
-		switch v := request.Params.Arguments["column"].(type) {
-		case float64:
-			column = int(v)
-		case int:
-			column = v
-		default:
-			return mcp.NewToolResultError("column must be a number"), nil
+		line, column, errMsg := positionArgs(request.Params.Arguments)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
 		}
 
 		coreLogger.Debug("Executing go_to_definition for file: %s line: %d column: %d", filePath, line, column)
-		text, err := tools.GoToDefinition(s.ctx, s.lspClient, filePath, line, column)
+		text, err := tools.GoToDefinition(ctx, s.clientFor(filePath), filePath, line, column)
 		if err != nil {
 			coreLogger.Error("Failed to go to definition: %v", err)
 			return mcp.NewToolResultError(fmt.Sprintf("failed to go to definition: %v", err)), nil
@@ -171,23 +288,120 @@ func (s *mcpServer) registerTools() error {
 		return mcp.NewToolResultText(text), nil
 	})
 
+	goToTypeDefinitionTool := mcp.NewTool("go_to_type_definition",
+		mcp.WithDescription("Go to the definition of the type of the symbol at the specified position, using the LSP textDocument/typeDefinition request. For a variable, parameter or field this returns the definition of its type rather than of the symbol itself."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file containing the symbol"),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number where the symbol is located (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Required(),
+			mcp.Description("The column number where the symbol is located (1-indexed)"),
+		),
+	)
+
+	s.addTool(goToTypeDefinitionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+
+		line, column, errMsg := positionArgs(request.Params.Arguments)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		coreLogger.Debug("Executing go_to_type_definition for file: %s line: %d column: %d", filePath, line, column)
+		text, err := tools.GoToTypeDefinition(ctx, s.clientFor(filePath), filePath, line, column)
+		if err != nil {
+			coreLogger.Error("Failed to go to type definition: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to go to type definition: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	goToDeclarationTool := mcp.NewTool("go_to_declaration",
+		mcp.WithDescription("Go to the declaration of the symbol at the specified position, using the LSP textDocument/declaration request. In languages such as C and C++ the declaration (e.g. in a header) differs from the definition returned by go_to_definition."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file containing the symbol"),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number where the symbol is located (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Required(),
+			mcp.Description("The column number where the symbol is located (1-indexed)"),
+		),
+	)
+
+	s.addTool(goToDeclarationTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+
+		line, column, errMsg := positionArgs(request.Params.Arguments)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		coreLogger.Debug("Executing go_to_declaration for file: %s line: %d column: %d", filePath, line, column)
+		text, err := tools.GoToDeclaration(ctx, s.clientFor(filePath), filePath, line, column)
+		if err != nil {
+			coreLogger.Error("Failed to go to declaration: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to go to declaration: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
 	findReferencesTool := mcp.NewTool("references",
 		mcp.WithDescription("Find all usages and references of a symbol throughout the codebase. Returns a list of all files and locations where the symbol appears."),
 		mcp.WithString("symbolName",
 			mcp.Required(),
 			mcp.Description("The name of the symbol to search for (e.g. 'mypackage.MyFunction', 'MyType')"),
 		),
+		mcp.WithBoolean("countOnly",
+			mcp.Description("If true, only return the number of references in each file, without code snippets. Much faster for widely used symbols (default: false)"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithString("continuation",
+			mcp.Description("Continuation token from a previous result that was truncated due to timeout, to fetch the remaining results"),
+		),
 	)
 
-	s.mcpServer.AddTool(findReferencesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.addTool(findReferencesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract arguments
 		symbolName, ok := request.Params.Arguments["symbolName"].(string)
 		if !ok {
 			return mcp.NewToolResultError("symbolName must be a string"), nil
 		}
 
+		if countOnly, ok := request.Params.Arguments["countOnly"].(bool); ok && countOnly {
+			coreLogger.Debug("Executing references count for symbol: %s", symbolName)
+			text, err := tools.CountReferences(ctx, s.lspClient, symbolName)
+			if err != nil {
+				coreLogger.Error("Failed to count references: %v", err)
+				return mcp.NewToolResultError(fmt.Sprintf("failed to count references: %v", err)), nil
+			}
+			return mcp.NewToolResultText(text), nil
+		}
+
+		continuation, _ := request.Params.Arguments["continuation"].(string)
+		toolCtx, err := tools.WithPartialResults(ctx, s.config.softTimeout, continuation, queryArguments(request))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
 		coreLogger.Debug("Executing references for symbol: %s", symbolName)
-		text, err := tools.FindReferences(s.ctx, s.lspClient, symbolName)
+		text, err := tools.FindReferences(toolCtx, s.lspClient, symbolName)
 		if err != nil {
 			coreLogger.Error("Failed to find references: %v", err)
 			return mcp.NewToolResultError(fmt.Sprintf("failed to find references: %v", err)), nil
@@ -213,33 +427,21 @@ func (s *mcpServer) registerTools() error {
 			mcp.Description("Whether to include the declaration in the results (default: true)"),
 			mcp.DefaultBool(true),
 		),
+		mcp.WithString("continuation",
+			mcp.Description("Continuation token from a previous result that was truncated due to timeout, to fetch the remaining results"),
+		),
 	)
 
-	s.mcpServer.AddTool(findReferencesAtPositionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.addTool(findReferencesAtPositionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract arguments
 		filePath, ok := request.Params.Arguments["filePath"].(string)
 		if !ok {
 			return mcp.NewToolResultError("filePath must be a string"), nil
 		}
 
-		// Handle both float64 and int for line and column due to JSON parsing
-		var line, column int
-		switch v := request.Params.Arguments["line"].(type) {
-		case float64:
-			line = int(v)
-		case int:
-			line = v
-		default:
-			return mcp.NewToolResultError("line must be a number"), nil
-		}
-
-		switch v := request.Params.Arguments["column"].(type) {
-		case float64:
-			column = int(v)
-		case int:
-			column = v
-		default:
-			return mcp.NewToolResultError("column must be a number"), nil
+		line, column, errMsg := positionArgs(request.Params.Arguments)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
 		}
 
 		includeDeclaration := true // default value
@@ -247,8 +449,14 @@ func (s *mcpServer) registerTools() error {
 			includeDeclaration = includeDeclarationArg
 		}
 
+		continuation, _ := request.Params.Arguments["continuation"].(string)
+		toolCtx, err := tools.WithPartialResults(ctx, s.config.softTimeout, continuation, queryArguments(request))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
 		coreLogger.Debug("Executing references_at_position for file: %s line: %d column: %d includeDeclaration: %v", filePath, line, column, includeDeclaration)
-		text, err := tools.FindReferencesAtPosition(s.ctx, s.lspClient, filePath, line, column, includeDeclaration)
+		text, err := tools.FindReferencesAtPosition(toolCtx, s.clientFor(filePath), filePath, line, column, includeDeclaration)
 		if err != nil {
 			coreLogger.Error("Failed to find references at position: %v", err)
 			return mcp.NewToolResultError(fmt.Sprintf("failed to find references at position: %v", err)), nil
@@ -256,170 +464,94 @@ func (s *mcpServer) registerTools() error {
 		return mcp.NewToolResultText(text), nil
 	})
 
-	getDiagnosticsTool := mcp.NewTool("diagnostics",
-		mcp.WithDescription("Get diagnostic information for a specific file from the language server."),
+	incomingCallsTool := mcp.NewTool("incoming_calls",
+		mcp.WithDescription("Find the callers of the function or method at the specified position, using the call hierarchy. Returns the callers grouped by file, with each call site shown in context."),
 		mcp.WithString("filePath",
 			mcp.Required(),
-			mcp.Description("The path to the file to get diagnostics for"),
+			mcp.Description("The path to the file containing the function"),
 		),
-		mcp.WithBoolean("contextLines",
-			mcp.Description("Lines to include around each diagnostic."),
-			mcp.DefaultBool(false),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number where the function is located (1-indexed)"),
 		),
-		mcp.WithBoolean("showLineNumbers",
-			mcp.Description("If true, adds line numbers to the output"),
-			mcp.DefaultBool(true),
+		mcp.WithNumber("column",
+			mcp.Required(),
+			mcp.Description("The column number where the function is located (1-indexed)"),
 		),
 	)
 
-	s.mcpServer.AddTool(getDiagnosticsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.addTool(incomingCallsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract arguments
 		filePath, ok := request.Params.Arguments["filePath"].(string)
 		if !ok {
 			return mcp.NewToolResultError("filePath must be a string"), nil
 		}
 
-		contextLines := 5 // default value
-		if contextLinesArg, ok := request.Params.Arguments["contextLines"].(int); ok {
-			contextLines = contextLinesArg
-		}
-
-		showLineNumbers := true // default value
-		if showLineNumbersArg, ok := request.Params.Arguments["showLineNumbers"].(bool); ok {
-			showLineNumbers = showLineNumbersArg
+		line, column, errMsg := positionArgs(request.Params.Arguments)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
 		}
 
-		coreLogger.Debug("Executing diagnostics for file: %s", filePath)
-		text, err := tools.GetDiagnosticsForFile(s.ctx, s.lspClient, filePath, contextLines, showLineNumbers)
+		coreLogger.Debug("Executing incoming_calls for file: %s line: %d column: %d", filePath, line, column)
+		text, err := tools.FindIncomingCalls(ctx, s.clientFor(filePath), filePath, line, column)
 		if err != nil {
-			coreLogger.Error("Failed to get diagnostics: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("failed to get diagnostics: %v", err)), nil
+			coreLogger.Error("Failed to find incoming calls: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to find incoming calls: %v", err)), nil
 		}
 		return mcp.NewToolResultText(text), nil
 	})
 
-	// Uncomment to add codelens tools
-	//
-	// getCodeLensTool := mcp.NewTool("get_codelens",
-	// 	mcp.WithDescription("Get code lens hints for a given file from the language server."),
-	// 	mcp.WithString("filePath",
-	// 		mcp.Required(),
-	// 		mcp.Description("The path to the file to get code lens information for"),
-	// 	),
-	// )
-	//
-	// s.mcpServer.AddTool(getCodeLensTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// 	// Extract arguments
-	// 	filePath, ok := request.Params.Arguments["filePath"].(string)
-	// 	if !ok {
-	// 		return mcp.NewToolResultError("filePath must be a string"), nil
-	// 	}
-	//
-	// 	coreLogger.Debug("Executing get_codelens for file: %s", filePath)
-	// 	text, err := tools.GetCodeLens(s.ctx, s.lspClient, filePath)
-	// 	if err != nil {
-	// 		coreLogger.Error("Failed to get code lens: %v", err)
-	// 		return mcp.NewToolResultError(fmt.Sprintf("failed to get code lens: %v", err)), nil
-	// 	}
-	// 	return mcp.NewToolResultText(text), nil
-	// })
-	//
-	// executeCodeLensTool := mcp.NewTool("execute_codelens",
-	// 	mcp.WithDescription("Execute a code lens command for a given file and lens index."),
-	// 	mcp.WithString("filePath",
-	// 		mcp.Required(),
-	// 		mcp.Description("The path to the file containing the code lens to execute"),
-	// 	),
-	// 	mcp.WithNumber("index",
-	// 		mcp.Required(),
-	// 		mcp.Description("The index of the code lens to execute (from get_codelens output), 1 indexed"),
-	// 	),
-	// )
-	//
-	// s.mcpServer.AddTool(executeCodeLensTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// 	// Extract arguments
-	// 	filePath, ok := request.Params.Arguments["filePath"].(string)
-	// 	if !ok {
-	// 		return mcp.NewToolResultError("filePath must be a string"), nil
-	// 	}
-	//
-	// 	// Handle both float64 and int for index due to JSON parsing
-	// 	var index int
-	// 	switch v := request.Params.Arguments["index"].(type) {
-	// 	case float64:
-	// 		index = int(v)
-	// 	case int:
-	// 		index = v
-	// 	default:
-	// 		return mcp.NewToolResultError("index must be a number"), nil
-	// 	}
-	//
-	// 	coreLogger.Debug("Executing execute_codelens for file: %s index: %d", filePath, index)
-	// 	text, err := tools.ExecuteCodeLens(s.ctx, s.lspClient, filePath, index)
-	// 	if err != nil {
-	// 		coreLogger.Error("Failed to execute code lens: %v", err)
-	// 		return mcp.NewToolResultError(fmt.Sprintf("failed to execute code lens: %v", err)), nil
-	// 	}
-	// 	return mcp.NewToolResultText(text), nil
-	// })
-
-	hoverTool := mcp.NewTool("hover",
-		mcp.WithDescription("Get hover information (type, documentation) for a symbol at the specified position."),
+	outgoingCallsTool := mcp.NewTool("outgoing_calls",
+		mcp.WithDescription("List the functions called by the function or method at the specified position, using the call hierarchy. Recurses into callees up to the given depth to show a call tree in one request."),
 		mcp.WithString("filePath",
 			mcp.Required(),
-			mcp.Description("The path to the file to get hover information for"),
+			mcp.Description("The path to the file containing the function"),
 		),
 		mcp.WithNumber("line",
 			mcp.Required(),
-			mcp.Description("The line number where the hover is requested (1-indexed)"),
+			mcp.Description("The line number where the function is located (1-indexed)"),
 		),
 		mcp.WithNumber("column",
 			mcp.Required(),
-			mcp.Description("The column number where the hover is requested (1-indexed)"),
+			mcp.Description("The column number where the function is located (1-indexed)"),
+		),
+		mcp.WithNumber("depth",
+			mcp.Description("How many levels of calls to follow (default: 1, maximum: 5)"),
+			mcp.DefaultNumber(1),
 		),
 	)
 
-	s.mcpServer.AddTool(hoverTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.addTool(outgoingCallsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract arguments
 		filePath, ok := request.Params.Arguments["filePath"].(string)
 		if !ok {
 			return mcp.NewToolResultError("filePath must be a string"), nil
 		}
 
-		// Handle both float64 and int for line and column due to JSON parsing
-		var line, column int
-		switch v := request.Params.Arguments["line"].(type) {
-		case float64:
-			line = int(v)
-		case int:
-			line = v
-		default:
-			return mcp.NewToolResultError("line must be a number"), nil
+		line, column, errMsg := positionArgs(request.Params.Arguments)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
 		}
 
-		switch v := request.Params.Arguments["column"].(type) {
-		case float64:
-			column = int(v)
-		case int:
-			column = v
-		default:
-			return mcp.NewToolResultError("column must be a number"), nil
+		depth := intArg(request.Params.Arguments, "depth", 1)
+		if depth < 1 || depth > 5 {
+			return mcp.NewToolResultError("depth must be between 1 and 5"), nil
 		}
 
-		coreLogger.Debug("Executing hover for file: %s line: %d column: %d", filePath, line, column)
-		text, err := tools.GetHoverInfo(s.ctx, s.lspClient, filePath, line, column)
+		coreLogger.Debug("Executing outgoing_calls for file: %s line: %d column: %d depth: %d", filePath, line, column, depth)
+		text, err := tools.FindOutgoingCalls(ctx, s.clientFor(filePath), filePath, line, column, depth)
 		if err != nil {
-			coreLogger.Error("Failed to get hover information: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("failed to get hover information: %v", err)), nil
+			coreLogger.Error("Failed to find outgoing calls: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to find outgoing calls: %v", err)), nil
 		}
 		return mcp.NewToolResultText(text), nil
 	})
 
-	renameSymbolTool := mcp.NewTool("rename_symbol",
-		mcp.WithDescription("Rename a symbol (variable, function, class, etc.) at the specified position and update all references throughout the codebase."),
+	implementationsTool := mcp.NewTool("implementations",
+		mcp.WithDescription("Find the concrete implementations of the interface, abstract method or other symbol at the specified position, using textDocument/implementation. Unlike references, this only returns implementations, with the full definition of each."),
 		mcp.WithString("filePath",
 			mcp.Required(),
-			mcp.Description("The path to the file containing the symbol to rename"),
+			mcp.Description("The path to the file containing the symbol"),
 		),
 		mcp.WithNumber("line",
 			mcp.Required(),
@@ -429,53 +561,1758 @@ func (s *mcpServer) registerTools() error {
 			mcp.Required(),
 			mcp.Description("The column number where the symbol is located (1-indexed)"),
 		),
-		mcp.WithString("newName",
+	)
+
+	s.addTool(implementationsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+
+		line, column, errMsg := positionArgs(request.Params.Arguments)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		coreLogger.Debug("Executing implementations for file: %s line: %d column: %d", filePath, line, column)
+		text, err := tools.FindImplementations(ctx, s.clientFor(filePath), filePath, line, column)
+		if err != nil {
+			coreLogger.Error("Failed to find implementations: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to find implementations: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	typeHierarchyTool := mcp.NewTool("type_hierarchy",
+		mcp.WithDescription("List the supertypes and/or subtypes of the class, interface or other type at the specified position, using the type hierarchy. Useful for reasoning about inheritance and interface implementations."),
+		mcp.WithString("filePath",
 			mcp.Required(),
-			mcp.Description("The new name for the symbol"),
+			mcp.Description("The path to the file containing the type"),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number where the type is located (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Required(),
+			mcp.Description("The column number where the type is located (1-indexed)"),
+		),
+		mcp.WithString("direction",
+			mcp.Description("Which related types to list: supertypes, subtypes or both (default: both)"),
+			mcp.DefaultString(tools.TypeHierarchyBoth),
+			mcp.Enum(tools.TypeHierarchySupertypes, tools.TypeHierarchySubtypes, tools.TypeHierarchyBoth),
+		),
+		mcp.WithNumber("depth",
+			mcp.Description("How many levels of the hierarchy to follow (default: 1, maximum: 5)"),
+			mcp.DefaultNumber(1),
 		),
 	)
 
-	s.mcpServer.AddTool(renameSymbolTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.addTool(typeHierarchyTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract arguments
 		filePath, ok := request.Params.Arguments["filePath"].(string)
 		if !ok {
 			return mcp.NewToolResultError("filePath must be a string"), nil
 		}
 
-		newName, ok := request.Params.Arguments["newName"].(string)
+		line, column, errMsg := positionArgs(request.Params.Arguments)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		direction := tools.TypeHierarchyBoth // default value
+		if v, ok := request.Params.Arguments["direction"].(string); ok && v != "" {
+			direction = v
+		}
+
+		depth := intArg(request.Params.Arguments, "depth", 1)
+		if depth < 1 || depth > 5 {
+			return mcp.NewToolResultError("depth must be between 1 and 5"), nil
+		}
+
+		coreLogger.Debug("Executing type_hierarchy for file: %s line: %d column: %d direction: %s depth: %d", filePath, line, column, direction, depth)
+		text, err := tools.GetTypeHierarchy(ctx, s.clientFor(filePath), filePath, line, column, direction, depth)
+		if err != nil {
+			coreLogger.Error("Failed to get type hierarchy: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get type hierarchy: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	inlineValuesTool := mcp.NewTool("inline_values",
+		mcp.WithDescription("List the variables and expressions that are relevant in a range of lines when execution is stopped at a given line, using textDocument/inlineValue. Useful when reasoning about runtime behavior alongside a debugger. Only some servers support this."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file"),
+		),
+		mcp.WithNumber("startLine",
+			mcp.Required(),
+			mcp.Description("The first line of the range (1-indexed)"),
+		),
+		mcp.WithNumber("endLine",
+			mcp.Required(),
+			mcp.Description("The last line of the range (1-indexed)"),
+		),
+		mcp.WithNumber("stoppedLine",
+			mcp.Description("The line where execution is stopped (1-indexed, default: endLine)"),
+		),
+		mcp.WithNumber("frameId",
+			mcp.Description("The debugger stack frame the values are requested for (default: 0)"),
+			mcp.DefaultNumber(0),
+		),
+	)
+
+	s.addTool(inlineValuesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
 		if !ok {
-			return mcp.NewToolResultError("newName must be a string"), nil
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+
+		startLine, errMsg := requiredIntArg(request.Params.Arguments, "startLine")
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+		endLine, errMsg := requiredIntArg(request.Params.Arguments, "endLine")
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+		if startLine < 1 || endLine < startLine {
+			return mcp.NewToolResultError("startLine must be at least 1 and endLine must not be before startLine"), nil
 		}
 
-		// Handle both float64 and int for line and column due to JSON parsing
-		var line, column int
-		switch v := request.Params.Arguments["line"].(type) {
-		case float64:
-			line = int(v)
-		case int:
-			line = v
-		default:
-			return mcp.NewToolResultError("line must be a number"), nil
+		stoppedLine := intArg(request.Params.Arguments, "stoppedLine", endLine)
+		frameID := intArg(request.Params.Arguments, "frameId", 0)
+
+		coreLogger.Debug("Executing inline_values for file: %s lines: %d-%d stopped: %d", filePath, startLine, endLine, stoppedLine)
+		text, err := tools.GetInlineValues(ctx, s.clientFor(filePath), filePath, startLine, endLine, stoppedLine, frameID)
+		if err != nil {
+			coreLogger.Error("Failed to get inline values: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get inline values: %v", err)), nil
 		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	documentSymbolsTool := mcp.NewTool("document_symbols",
+		mcp.WithDescription("Get an outline of a file: its types, functions, methods and other symbols with their line ranges, nested by containment. Use this to understand the structure of a file without reading every line."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file to outline"),
+		),
+	)
 
-		switch v := request.Params.Arguments["column"].(type) {
-		case float64:
-			column = int(v)
-		case int:
-			column = v
-		default:
-			return mcp.NewToolResultError("column must be a number"), nil
+	s.addTool(documentSymbolsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
 		}
 
-		coreLogger.Debug("Executing rename_symbol for file: %s line: %d column: %d newName: %s", filePath, line, column, newName)
-		text, err := tools.RenameSymbol(s.ctx, s.lspClient, filePath, line, column, newName)
+		coreLogger.Debug("Executing document_symbols for file: %s", filePath)
+		text, err := tools.GetDocumentSymbols(ctx, s.clientFor(filePath), filePath)
 		if err != nil {
-			coreLogger.Error("Failed to rename symbol: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("failed to rename symbol: %v", err)), nil
+			coreLogger.Error("Failed to get document symbols: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get document symbols: %v", err)), nil
 		}
 		return mcp.NewToolResultText(text), nil
 	})
 
-	coreLogger.Info("Successfully registered all MCP tools")
-	return nil
+	getDiagnosticsTool := mcp.NewTool("diagnostics", append([]mcp.ToolOption{
+		mcp.WithDescription("Get diagnostic information for a specific file from the language server."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file to get diagnostics for"),
+		),
+		mcp.WithBoolean("contextLines",
+			mcp.Description("Lines to include around each diagnostic."),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithBoolean("showLineNumbers",
+			mcp.Description("If true, adds line numbers to the output"),
+			mcp.DefaultBool(true),
+		),
+		mcp.WithBoolean("refresh",
+			mcp.Description("If true, asks servers that support pull diagnostics for a full report instead of reusing their previous result, e.g. after editing files the file depends on"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithBoolean("waitForAnalysis",
+			mcp.Description("If true, waits until the language server has finished re-analyzing after a change (up to 30 seconds) before returning diagnostics, so they are not stale results from before the edit"),
+			mcp.DefaultBool(false),
+		),
+	}, diagnosticFilterOptions()...)...)
+
+	s.addTool(getDiagnosticsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+
+		contextLines := 5 // default value
+		if contextLinesArg, ok := request.Params.Arguments["contextLines"].(int); ok {
+			contextLines = contextLinesArg
+		}
+
+		showLineNumbers := true // default value
+		if showLineNumbersArg, ok := request.Params.Arguments["showLineNumbers"].(bool); ok {
+			showLineNumbers = showLineNumbersArg
+		}
+
+		filter, errMsg := parseDiagnosticFilterArgs(request.Params.Arguments)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		if refresh, ok := request.Params.Arguments["refresh"].(bool); ok && refresh {
+			s.clientFor(filePath).ForgetDiagnosticResults(protocol.DocumentUri("file://" + filePath))
+		}
+
+		coreLogger.Debug("Executing diagnostics for file: %s", filePath)
+		note := ""
+		if wait, ok := request.Params.Arguments["waitForAnalysis"].(bool); ok && wait {
+			note = tools.WaitForAnalysis(ctx, s.clientFor(filePath), filePath)
+		}
+		text, err := tools.GetDiagnosticsForFile(ctx, s.clientFor(filePath), filePath, contextLines, showLineNumbers, filter)
+		if err != nil {
+			coreLogger.Error("Failed to get diagnostics: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get diagnostics: %v", err)), nil
+		}
+		if note != "" {
+			text += "\n" + note
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	diffDiagnosticsTool := mcp.NewTool("diff_diagnostics", append([]mcp.ToolOption{
+		mcp.WithDescription("Get only the diagnostics on lines added or modified by a change, for reviewing a patch or pull request. Provide either a unified diff or a git revision range."),
+		mcp.WithString("diff",
+			mcp.Description("A unified diff, e.g. the output of 'git diff'. Paths are relative to the workspace"),
+		),
+		mcp.WithString("gitRange",
+			mcp.Description("A git revision range to diff in the workspace instead of passing a diff (e.g. 'main...HEAD', 'HEAD~1')"),
+		),
+	}, diagnosticFilterOptions()...)...)
+
+	s.addTool(diffDiagnosticsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		diff, _ := request.Params.Arguments["diff"].(string)
+		gitRange, _ := request.Params.Arguments["gitRange"].(string)
+		if (diff == "") == (gitRange == "") {
+			return mcp.NewToolResultError("exactly one of diff or gitRange must be provided"), nil
+		}
+		filter, errMsg := parseDiagnosticFilterArgs(request.Params.Arguments)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		if gitRange != "" {
+			var err error
+			diff, err = tools.GitDiff(ctx, s.config.workspaceDir, gitRange)
+			if err != nil {
+				coreLogger.Error("Failed to get git diff: %v", err)
+				return mcp.NewToolResultError(fmt.Sprintf("failed to get git diff: %v", err)), nil
+			}
+		}
+
+		coreLogger.Debug("Executing diff_diagnostics")
+		text, err := tools.GetDiffDiagnostics(ctx, s.lspClient, s.config.workspaceDir, diff, filter)
+		if err != nil {
+			coreLogger.Error("Failed to get diff diagnostics: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get diff diagnostics: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	getCodeLensTool := mcp.NewTool("get_codelens",
+		mcp.WithDescription("List the code lenses of a file from the language server, such as 'run test', 'run benchmark' or 'N references', numbered for execute_codelens, with the line each applies to and the command it runs."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file to get code lens information for"),
+		),
+	)
+
+	s.addTool(getCodeLensTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+
+		coreLogger.Debug("Executing get_codelens for file: %s", filePath)
+		text, err := tools.GetCodeLens(ctx, s.clientFor(filePath), filePath)
+		if err != nil {
+			coreLogger.Error("Failed to get code lens: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get code lens: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	executeCodeLensTool := mcp.NewTool("execute_codelens",
+		mcp.WithDescription("Run the command of a code lens of a file, chosen by its index in the get_codelens output or by its title, e.g. to run a test through gopls or rust-analyzer. Waits for commands that report progress to finish and returns the messages the server showed, such as test results, and any changes the command made."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file containing the code lens to execute"),
+		),
+		mcp.WithNumber("index",
+			mcp.Description("The index of the code lens to execute (from get_codelens output), 1 indexed"),
+		),
+		mcp.WithString("title",
+			mcp.Description("The title of the code lens to execute, or a unique part of it, instead of its index, e.g. 'run test'"),
+		),
+	)
+
+	s.addTool(executeCodeLensTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+
+		title, _ := request.Params.Arguments["title"].(string)
+
+		index, ok := numberArg(request.Params.Arguments, "index")
+		if _, present := request.Params.Arguments["index"]; present && !ok {
+			return mcp.NewToolResultError("index must be a number"), nil
+		}
+		if !ok && title == "" {
+			return mcp.NewToolResultError("either index or title is required"), nil
+		}
+
+		coreLogger.Debug("Executing execute_codelens for file: %s index: %d", filePath, index)
+		text, err := tools.ExecuteCodeLens(ctx, s.clientFor(filePath), filePath, index, title)
+		if err != nil {
+			coreLogger.Error("Failed to execute code lens: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to execute code lens: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	hoverTool := mcp.NewTool("hover",
+		mcp.WithDescription("Get hover information (type, documentation) for a symbol at the specified position."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file to get hover information for"),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number where the hover is requested (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Required(),
+			mcp.Description("The column number where the hover is requested (1-indexed)"),
+		),
+	)
+
+	s.addTool(hoverTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+
+		line, column, errMsg := positionArgs(request.Params.Arguments)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		coreLogger.Debug("Executing hover for file: %s line: %d column: %d", filePath, line, column)
+		text, err := tools.GetHoverInfo(ctx, s.clientFor(filePath), filePath, line, column)
+		if err != nil {
+			coreLogger.Error("Failed to get hover information: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get hover information: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	codeActionsTool := mcp.NewTool("code_actions", append([]mcp.ToolOption{
+		mcp.WithDescription("List the quick fixes and refactorings available at a position or range, with their kinds, titles and the diagnostics they fix. Use kinds to only list certain actions, e.g. 'quickfix' or 'refactor' (which also matches 'refactor.extract'). Apply one with apply_code_action."),
+	}, codeActionRangeOptions()...)...)
+
+	s.addTool(codeActionsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, errMsg := parseCodeActionArgs(request.Params.Arguments)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		coreLogger.Debug("Executing code_actions for file: %s L%d:C%d-L%d:C%d", args.filePath, args.line, args.column, args.endLine, args.endColumn)
+		text, err := tools.GetCodeActions(ctx, s.clientFor(args.filePath), args.filePath, args.line, args.column, args.endLine, args.endColumn, args.kinds)
+		if err != nil {
+			coreLogger.Error("Failed to get code actions: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get code actions: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	applyCodeActionTool := mcp.NewTool("apply_code_action", append([]mcp.ToolOption{
+		mcp.WithDescription("Apply one of the code actions listed by code_actions for the same range and kinds, chosen by index or title. The action's edits are written to disk and its command is executed, and every changed file and range is reported. With dryRun, the edits are returned as a multi-file diff and nothing is written or executed."),
+		mcp.WithNumber("index",
+			mcp.Description("The 1-indexed position of the action in the code_actions list"),
+		),
+		mcp.WithString("title",
+			mcp.Description("The title of the action, or a unique part of it, instead of an index"),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("If true, preview the files created, renamed and deleted and the diff of every changed file without applying anything (default: false)"),
+		),
+	}, codeActionRangeOptions()...)...)
+
+	s.addTool(applyCodeActionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, errMsg := parseCodeActionArgs(request.Params.Arguments)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		title, _ := request.Params.Arguments["title"].(string)
+
+		index, ok := numberArg(request.Params.Arguments, "index")
+		if !ok && title == "" {
+			return mcp.NewToolResultError("either index or title is required"), nil
+		}
+
+		dryRun, _ := request.Params.Arguments["dryRun"].(bool)
+
+		coreLogger.Debug("Executing apply_code_action for file: %s L%d:C%d-L%d:C%d index: %d title: %s dryRun: %v", args.filePath, args.line, args.column, args.endLine, args.endColumn, index, title, dryRun)
+		text, err := tools.ApplyCodeAction(ctx, s.clientFor(args.filePath), args.filePath, args.line, args.column, args.endLine, args.endColumn, args.kinds, index, title, dryRun)
+		if err != nil {
+			coreLogger.Error("Failed to apply code action: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to apply code action: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	formatDocumentTool := mcp.NewTool("format_document", append([]mcp.ToolOption{
+		mcp.WithDescription("Format a file with the language server's formatter and write the result back. With dryRun, the changes are returned as a unified diff without being written."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file to format"),
+		),
+	}, formattingToolOptions()...)...)
+
+	s.addTool(formatDocumentTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+
+		options, dryRun := parseFormattingArgs(request.Params.Arguments)
+
+		coreLogger.Debug("Executing format_document for file: %s dryRun: %v", filePath, dryRun)
+		text, err := tools.FormatDocument(ctx, s.clientFor(filePath), filePath, options, dryRun)
+		if err != nil {
+			coreLogger.Error("Failed to format document: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to format document: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	formatRangeTool := mcp.NewTool("format_range", append([]mcp.ToolOption{
+		mcp.WithDescription("Format only a range of lines in a file with the language server's formatter, leaving the rest of the file untouched. Useful in large files where formatting the whole file would produce a noisy diff. With dryRun, the changes are returned as a unified diff without being written."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file to format"),
+		),
+		mcp.WithNumber("startLine",
+			mcp.Required(),
+			mcp.Description("The first line to format (1-indexed)"),
+		),
+		mcp.WithNumber("endLine",
+			mcp.Required(),
+			mcp.Description("The last line to format, inclusive (1-indexed)"),
+		),
+	}, formattingToolOptions()...)...)
+
+	s.addTool(formatRangeTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+
+		startLine, errMsg := requiredIntArg(request.Params.Arguments, "startLine")
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+		endLine, errMsg := requiredIntArg(request.Params.Arguments, "endLine")
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		options, dryRun := parseFormattingArgs(request.Params.Arguments)
+
+		coreLogger.Debug("Executing format_range for file: %s lines: %d-%d dryRun: %v", filePath, startLine, endLine, dryRun)
+		text, err := tools.FormatRange(ctx, s.clientFor(filePath), filePath, startLine, endLine, options, dryRun)
+		if err != nil {
+			coreLogger.Error("Failed to format range: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to format range: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	queryAtRevisionTool := mcp.NewTool("query_at_revision",
+		mcp.WithDescription("Answer a hover, definition or references query about a position in a file as it was at a git revision, to compare an API's past and present shape. The file's content at the revision is analyzed in place of the current content; other files are analyzed as they are now, and references are limited to the file. The file on disk is not changed."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file, as it is named at the revision"),
+		),
+		mcp.WithString("revision",
+			mcp.Required(),
+			mcp.Description("The git revision to query, e.g. 'HEAD~3', 'v1.2.0' or a commit hash"),
+		),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("What to ask about the position"),
+			mcp.Enum(tools.RevisionHover, tools.RevisionDefinition, tools.RevisionReferences),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number in the file at the revision (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Required(),
+			mcp.Description("The column number in the file at the revision (1-indexed)"),
+		),
+	)
+
+	s.addTool(queryAtRevisionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+
+		revision, ok := request.Params.Arguments["revision"].(string)
+		if !ok {
+			return mcp.NewToolResultError("revision must be a string"), nil
+		}
+
+		query, ok := request.Params.Arguments["query"].(string)
+		if !ok {
+			return mcp.NewToolResultError("query must be a string"), nil
+		}
+
+		line, column, errMsg := positionArgs(request.Params.Arguments)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		coreLogger.Debug("Executing query_at_revision %s for file: %s revision: %s line: %d column: %d", query, filePath, revision, line, column)
+		text, err := tools.QueryAtRevision(ctx, s.clientFor(filePath), filePath, revision, query, line, column)
+		if err != nil {
+			coreLogger.Error("Failed to query revision: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to query revision: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	inlayHintsTool := mcp.NewTool("inlay_hints",
+		mcp.WithDescription("Show a range of lines of a file with the language server's inlay hints inserted inline, such as the inferred types of variables and the parameter names of calls. Useful for reading dynamically typed or heavily inferred code. Hints are marked with « and »."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file"),
+		),
+		mcp.WithNumber("startLine",
+			mcp.Required(),
+			mcp.Description("The first line to show (1-indexed)"),
+		),
+		mcp.WithNumber("endLine",
+			mcp.Required(),
+			mcp.Description("The last line to show, inclusive (1-indexed)"),
+		),
+	)
+
+	s.addTool(inlayHintsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+
+		startLine, errMsg := requiredIntArg(request.Params.Arguments, "startLine")
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+		endLine, errMsg := requiredIntArg(request.Params.Arguments, "endLine")
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		coreLogger.Debug("Executing inlay_hints for file: %s lines: %d-%d", filePath, startLine, endLine)
+		text, err := tools.GetInlayHints(ctx, s.clientFor(filePath), filePath, startLine, endLine)
+		if err != nil {
+			coreLogger.Error("Failed to get inlay hints: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get inlay hints: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	semanticTokensTool := mcp.NewTool("semantic_tokens",
+		mcp.WithDescription("Classify every token in a range of lines of a file using the language server's semantic tokens, e.g. to tell a parameter from a field or a local variable with the same name when planning edits. Each line is followed by its tokens as text:type with modifiers such as declaration or readonly."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file"),
+		),
+		mcp.WithNumber("startLine",
+			mcp.Required(),
+			mcp.Description("The first line to classify (1-indexed)"),
+		),
+		mcp.WithNumber("endLine",
+			mcp.Required(),
+			mcp.Description("The last line to classify, inclusive (1-indexed)"),
+		),
+	)
+
+	s.addTool(semanticTokensTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+
+		startLine, errMsg := requiredIntArg(request.Params.Arguments, "startLine")
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+		endLine, errMsg := requiredIntArg(request.Params.Arguments, "endLine")
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		coreLogger.Debug("Executing semantic_tokens for file: %s lines: %d-%d", filePath, startLine, endLine)
+		text, err := tools.GetSemanticTokens(ctx, s.clientFor(filePath), filePath, startLine, endLine)
+		if err != nil {
+			coreLogger.Error("Failed to get semantic tokens: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get semantic tokens: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	foldingRangesTool := mcp.NewTool("folding_ranges",
+		mcp.WithDescription("Get a map of the logical blocks of a file, such as functions, import groups, comments and regions, from the language server's folding ranges. Each block is shown with its line range and first line, nested by depth. Use it on large files to decide which line ranges to read instead of reading the whole file."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file"),
+		),
+		mcp.WithNumber("minLines",
+			mcp.Description("Leave out blocks shorter than this many lines (default: 3)"),
+		),
+	)
+
+	s.addTool(foldingRangesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+
+		minLines := intArg(request.Params.Arguments, "minLines", 3)
+
+		coreLogger.Debug("Executing folding_ranges for file: %s", filePath)
+		text, err := tools.GetFoldingRanges(ctx, s.clientFor(filePath), filePath, minLines)
+		if err != nil {
+			coreLogger.Error("Failed to get folding ranges: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get folding ranges: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	documentHighlightsTool := mcp.NewTool("document_highlights",
+		mcp.WithDescription("List the occurrences within a file of the symbol at a position, each classified by the language server as a write or a read of it. Unlike references, this tells where a variable is assigned versus only used."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file containing the symbol"),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number of the symbol (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Required(),
+			mcp.Description("The column number of the symbol (1-indexed)"),
+		),
+	)
+
+	s.addTool(documentHighlightsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+
+		line, column, errMsg := positionArgs(request.Params.Arguments)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		coreLogger.Debug("Executing document_highlights for file: %s line: %d column: %d", filePath, line, column)
+		text, err := tools.GetDocumentHighlights(ctx, s.clientFor(filePath), filePath, line, column)
+		if err != nil {
+			coreLogger.Error("Failed to get document highlights: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get document highlights: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	idlReferencesTool := mcp.NewTool("idl_references",
+		mcp.WithDescription("Find who uses a protobuf or Thrift message, enum, service or RPC across languages. Searches every configured language server for the symbols generated from it, such as UserServiceClient.GetUser in Go and UserServiceStub.GetUser in Python, and aggregates the references to them outside of generated code in one result."),
+		mcp.WithString("symbol",
+			mcp.Required(),
+			mcp.Description("The name of the message, enum, service or RPC as declared in the IDL, e.g. 'GetUserRequest' or 'UserService.GetUser' to qualify an RPC with its service"),
+		),
+		mcp.WithString("idlFile",
+			mcp.Description("The .proto or .thrift file declaring the symbol, to only consider code generated from it"),
+		),
+	)
+
+	s.addTool(idlReferencesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		symbol, ok := request.Params.Arguments["symbol"].(string)
+		if !ok {
+			return mcp.NewToolResultError("symbol must be a string"), nil
+		}
+		idlFile, _ := request.Params.Arguments["idlFile"].(string)
+
+		coreLogger.Debug("Executing idl_references for symbol: %s", symbol)
+		text, err := tools.IDLReferences(ctx, s.symbolServers(), symbol, idlFile)
+		if err != nil {
+			coreLogger.Error("Failed to find IDL references: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to find IDL references: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	selectionRangeTool := mcp.NewTool("selection_range",
+		mcp.WithDescription("List the nested syntactic ranges containing a position, innermost first: expression, statement, block, function and so on out to the file, as an editor's expand selection would. Use it to pick a range that covers exactly one syntactic unit before replacing it with an edit."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file"),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number of the position (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Required(),
+			mcp.Description("The column number of the position (1-indexed)"),
+		),
+	)
+
+	s.addTool(selectionRangeTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+
+		line, column, errMsg := positionArgs(request.Params.Arguments)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		coreLogger.Debug("Executing selection_range for file: %s line: %d column: %d", filePath, line, column)
+		text, err := tools.GetSelectionRanges(ctx, s.clientFor(filePath), filePath, line, column)
+		if err != nil {
+			coreLogger.Error("Failed to get selection ranges: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get selection ranges: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	documentLinksTool := mcp.NewTool("document_links",
+		mcp.WithDescription("List the links in a file as the language server sees them: the files or packages imports refer to, URLs in comments and similar references, with the text each link is attached to and its resolved target. File targets are given as paths that can be passed to other tools."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file"),
+		),
+	)
+
+	s.addTool(documentLinksTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+
+		coreLogger.Debug("Executing document_links for file: %s", filePath)
+		text, err := tools.GetDocumentLinks(ctx, s.clientFor(filePath), filePath)
+		if err != nil {
+			coreLogger.Error("Failed to get document links: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get document links: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	embeddedQueryTool := mcp.NewTool("embedded_query",
+		mcp.WithDescription("Analyze a SQL query embedded in a string literal of Go, Python, Java, JavaScript or other source with the SQL language server configured in the configuration file. Literals are detected as SQL if they start like a statement, e.g. 'SELECT ... FROM', or follow a 'language=sql' comment. Positions are those in the host file."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file containing the query"),
+		),
+		mcp.WithString("action",
+			mcp.Required(),
+			mcp.Description("'hover' to describe the code at the position, or 'diagnostics' to check the query at the position, or every query in the file if no position is given"),
+			mcp.Enum("hover", "diagnostics"),
+		),
+		mcp.WithNumber("line",
+			mcp.Description("The line number of the position in the file (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Description("The column number of the position in the file (1-indexed)"),
+		),
+	)
+
+	s.addTool(embeddedQueryTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+		action, ok := request.Params.Arguments["action"].(string)
+		if !ok {
+			return mcp.NewToolResultError("action must be a string"), nil
+		}
+
+		// The line and column are optional, but must be numbers if given
+		for _, name := range []string{"line", "column"} {
+			if _, ok := numberArg(request.Params.Arguments, name); !ok && request.Params.Arguments[name] != nil {
+				return mcp.NewToolResultError(name + " must be a number"), nil
+			}
+		}
+		line := intArg(request.Params.Arguments, "line", 0)
+		column := intArg(request.Params.Arguments, "column", 1)
+
+		coreLogger.Debug("Executing embedded_query %s for file: %s line: %d column: %d", action, filePath, line, column)
+		text, err := tools.EmbeddedQuery(ctx, s.embeddedServers(), filePath, line, column, action)
+		if err != nil {
+			coreLogger.Error("Failed to query embedded code: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to query embedded code: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	workspaceDiagnosticsTool := mcp.NewTool("workspace_diagnostics", append([]mcp.ToolOption{
+		mcp.WithDescription("Summarize the diagnostics of the whole workspace: each file with diagnostics and its number of errors, warnings, infos and hints, the most broken files first. Use it to see what is broken after a refactor, then the diagnostics tool for the details of a file."),
+		mcp.WithBoolean("waitForAnalysis",
+			mcp.Description("If true, waits until the language server has finished re-analyzing after a change (up to 30 seconds) before summarizing, so results are not stale"),
+			mcp.DefaultBool(false),
+		),
+	}, diagnosticFilterOptions()...)...)
+
+	s.addTool(workspaceDiagnosticsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filter, errMsg := parseDiagnosticFilterArgs(request.Params.Arguments)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		coreLogger.Debug("Executing workspace_diagnostics")
+		note := ""
+		if wait, ok := request.Params.Arguments["waitForAnalysis"].(bool); ok && wait {
+			note = tools.WaitForAnalysis(ctx, s.lspClient, "")
+		}
+		text, err := tools.GetWorkspaceDiagnostics(ctx, s.lspClient, filter)
+		if err != nil {
+			coreLogger.Error("Failed to get workspace diagnostics: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get workspace diagnostics: %v", err)), nil
+		}
+		if note != "" {
+			text += "\n" + note
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	runnablesTool := mcp.NewTool("runnables",
+		mcp.WithDescription("Catalog the tests, benchmarks and main entry points that the language server can run through code lenses, across the workspace or a directory, so you can discover how to run parts of the project without reading build files. Each is listed with its file and the index to pass to execute_codelens."),
+		mcp.WithString("path",
+			mcp.Description("Optional directory to search, absolute or relative to the workspace (default: the whole workspace)"),
+		),
+	)
+
+	s.addTool(runnablesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		dir, _ := request.Params.Arguments["path"].(string)
+
+		coreLogger.Debug("Executing runnables for directory: %s", dir)
+		text, err := tools.GetRunnables(ctx, s.lspClient, s.config.workspaceDir, dir)
+		if err != nil {
+			coreLogger.Error("Failed to get runnables: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get runnables: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	undoLastEditTool := mcp.NewTool(undoToolName,
+		mcp.WithDescription("Undo the last edit made by a tool that changes files, such as rename_symbol, apply_code_action, format_document or edit_file, restoring every file it touched, including files it created, moved or deleted. Call it repeatedly to undo earlier edits. Refuses if the files were changed after the edit, unless force is set."),
+		mcp.WithBoolean("force",
+			mcp.Description("If true, undo the edit even if the files were changed after it, discarding those changes (default: false)"),
+		),
+	)
+
+	s.addTool(undoLastEditTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		force, _ := request.Params.Arguments["force"].(bool)
+
+		coreLogger.Debug("Executing undo_last_edit force: %v", force)
+		text, err := tools.UndoLastEdit(ctx, s.clientFor, force)
+		if err != nil {
+			coreLogger.Error("Failed to undo edit: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to undo edit: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	serverInfoTool := mcp.NewTool("server_info",
+		mcp.WithDescription("Show the name and version of a language server, which LSP methods it supports, which tools will fail because it does not support a method they need, and the full capabilities it returned from initialize. Use it to check what a server can do before relying on a tool, or to debug a setup."),
+		mcp.WithString("server",
+			mcp.Description("The name of the server, as listed by the status tool (default: the main server)"),
+		),
+	)
+
+	s.addTool(serverInfoTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		name, _ := request.Params.Arguments["server"].(string)
+
+		coreLogger.Debug("Executing server_info for server: %s", name)
+		client, label, err := s.serverByName(name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		toolMethods := make(map[string][]string)
+		for _, tool := range s.tools {
+			if methods := s.toolMethods[tool.Name]; len(methods) > 0 {
+				toolMethods[tool.Name] = methods
+			}
+		}
+		text, err := tools.ServerInfo(client, label, toolMethods)
+		if err != nil {
+			coreLogger.Error("Failed to get server info: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get server info: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	healthTool := mcp.NewTool("health",
+		mcp.WithDescription("Check whether each language server is running and initialized, how many documents it has open and requests it has yet to answer, and when a request to it last succeeded. It does not contact the servers, so it answers even when one is hung. Call it when tools fail or time out, before retrying."),
+	)
+
+	s.addTool(healthTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		coreLogger.Debug("Executing health")
+		return mcp.NewToolResultText(tools.Health(s.symbolServers())), nil
+	})
+
+	serverLogsTool := mcp.NewTool("get_server_logs",
+		mcp.WithDescription("Show the recent log messages of a language server (window/logMessage, and $/logTrace when the server traces), oldest first. Use it to find out why a server is misbehaving, e.g. a failed build or missing module behind empty results."),
+		mcp.WithString("server",
+			mcp.Description("The name of the server, as listed by the status tool (default: the main server)"),
+		),
+		mcp.WithString("severity",
+			mcp.Description("The least severe messages to show: error, warning, info, log, debug or trace (default: trace, which shows everything)"),
+			mcp.DefaultString("trace"),
+			mcp.Enum("error", "warning", "info", "log", "debug", "trace"),
+		),
+		mcp.WithNumber("tail",
+			mcp.Description("Maximum number of the most recent messages to show (default: 50)"),
+			mcp.DefaultNumber(50),
+		),
+	)
+
+	s.addTool(serverLogsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		name, _ := request.Params.Arguments["server"].(string)
+		severity, _ := request.Params.Arguments["severity"].(string)
+		if severity == "" {
+			severity = "trace"
+		}
+		tail := intArg(request.Params.Arguments, "tail", 50)
+
+		coreLogger.Debug("Executing get_server_logs for server: %s", name)
+		client, label, err := s.serverByName(name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		text, err := tools.ServerLogs(client, label, severity, tail)
+		if err != nil {
+			coreLogger.Error("Failed to get server logs: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get server logs: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	addWorkspaceFolderTool := mcp.NewTool("add_workspace_folder",
+		mcp.WithDescription("Add a directory, such as a submodule or sibling repository, to the workspace folders of the running language servers with workspace/didChangeWorkspaceFolders, so that they index it without a restart. Files in it are watched for changes. Servers that do not support changing workspace folders are reported."),
+		mcp.WithString("folder",
+			mcp.Required(),
+			mcp.Description("The directory to add, absolute or relative to the workspace"),
+		),
+	)
+
+	s.addTool(addWorkspaceFolderTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		folder, ok := request.Params.Arguments["folder"].(string)
+		if !ok {
+			return mcp.NewToolResultError("folder must be a string"), nil
+		}
+
+		coreLogger.Debug("Executing add_workspace_folder for folder: %s", folder)
+		text, err := s.addWorkspaceFolder(ctx, folder)
+		if err != nil {
+			coreLogger.Error("Failed to add workspace folder: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to add workspace folder: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	removeWorkspaceFolderTool := mcp.NewTool("remove_workspace_folder",
+		mcp.WithDescription("Remove a directory added with add_workspace_folder or the workspaceFolders config from the workspace folders of the running language servers, and stop watching it."),
+		mcp.WithString("folder",
+			mcp.Required(),
+			mcp.Description("The directory to remove, absolute or relative to the workspace"),
+		),
+	)
+
+	s.addTool(removeWorkspaceFolderTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		folder, ok := request.Params.Arguments["folder"].(string)
+		if !ok {
+			return mcp.NewToolResultError("folder must be a string"), nil
+		}
+
+		coreLogger.Debug("Executing remove_workspace_folder for folder: %s", folder)
+		text, err := s.removeWorkspaceFolder(ctx, folder)
+		if err != nil {
+			coreLogger.Error("Failed to remove workspace folder: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to remove workspace folder: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	completionTool := mcp.NewTool("completion",
+		mcp.WithDescription("List the completions the language server offers at a position, e.g. the fields and methods available after 'foo.'. Useful for discovering an API without reading its source. Results are ranked and capped, with their kinds, signatures or types, and optionally the start of their documentation."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file to complete in"),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number to complete at (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Required(),
+			mcp.Description("The column number to complete at (1-indexed), e.g. just after the '.' of 'foo.' or after a partial name"),
+		),
+		mcp.WithNumber("maxResults",
+			mcp.Description("Maximum number of completions to return (default: 30)"),
+			mcp.DefaultNumber(30),
+		),
+		mcp.WithBoolean("documentation",
+			mcp.Description("Include the first lines of each completion's documentation, resolving completions where the server supports it (default: true)"),
+			mcp.DefaultBool(true),
+		),
+	)
+
+	s.addTool(completionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+
+		line, column, errMsg := positionArgs(request.Params.Arguments)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		limit := intArg(request.Params.Arguments, "maxResults", 30)
+		if limit < 1 {
+			return mcp.NewToolResultError("maxResults must be at least 1"), nil
+		}
+
+		documentation := true
+		if v, ok := request.Params.Arguments["documentation"].(bool); ok {
+			documentation = v
+		}
+
+		coreLogger.Debug("Executing completion for file: %s line: %d column: %d", filePath, line, column)
+		text, err := tools.GetCompletions(ctx, s.clientFor(filePath), filePath, line, column, limit, documentation)
+		if err != nil {
+			coreLogger.Error("Failed to get completions: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get completions: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	addImportTool := mcp.NewTool("add_import",
+		mcp.WithDescription("Add an import of a module to a file using the language server's auto-import support (quick fixes or completion import edits) rather than inserting text, so import blocks stay sorted and free of duplicates. Reports the change as a unified diff."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file to add the import to"),
+		),
+		mcp.WithString("module",
+			mcp.Required(),
+			mcp.Description("The module, package or header to import (e.g. 'strings', 'pathlib', 'fs/promises', 'std::collections')"),
+		),
+		mcp.WithString("symbol",
+			mcp.Description("Optional name to import from the module, for languages that import names (e.g. 'Path' from 'pathlib'). Leave empty to import the module itself"),
+		),
+	)
+
+	s.addTool(addImportTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+
+		module, ok := request.Params.Arguments["module"].(string)
+		if !ok || module == "" {
+			return mcp.NewToolResultError("module must be a non-empty string"), nil
+		}
+
+		symbol, _ := request.Params.Arguments["symbol"].(string)
+
+		coreLogger.Debug("Executing add_import for file: %s module: %s symbol: %s", filePath, module, symbol)
+		text, err := tools.AddImport(ctx, s.clientFor(filePath), filePath, module, symbol)
+		if err != nil {
+			coreLogger.Error("Failed to add import: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to add import: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	prepareRenameTool := mcp.NewTool("prepare_rename",
+		mcp.WithDescription("Check whether the symbol at the specified position can be renamed before calling rename_symbol. Reports the exact range and current text of the identifier that would be renamed, or why it cannot be renamed."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file containing the symbol"),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number where the symbol is located (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Required(),
+			mcp.Description("The column number where the symbol is located (1-indexed)"),
+		),
+	)
+
+	s.addTool(prepareRenameTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+
+		line, column, errMsg := positionArgs(request.Params.Arguments)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		coreLogger.Debug("Executing prepare_rename for file: %s line: %d column: %d", filePath, line, column)
+		text, err := tools.PrepareRename(ctx, s.clientFor(filePath), filePath, line, column)
+		if err != nil {
+			coreLogger.Error("Failed to prepare rename: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to prepare rename: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	renameSymbolTool := mcp.NewTool("rename_symbol",
+		mcp.WithDescription("Rename a symbol (variable, function, class, etc.) at the specified position and update all references throughout the codebase. The changes are written to disk, and every changed file and range is reported. With dryRun, the changes are returned as a multi-file diff without being written."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file containing the symbol to rename"),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number where the symbol is located (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Required(),
+			mcp.Description("The column number where the symbol is located (1-indexed)"),
+		),
+		mcp.WithString("newName",
+			mcp.Required(),
+			mcp.Description("The new name for the symbol"),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("If true, preview the files created, renamed and deleted and the diff of every changed file without applying anything (default: false)"),
+		),
+	)
+
+	s.addTool(renameSymbolTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+
+		newName, ok := request.Params.Arguments["newName"].(string)
+		if !ok {
+			return mcp.NewToolResultError("newName must be a string"), nil
+		}
+
+		line, column, errMsg := positionArgs(request.Params.Arguments)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		dryRun, _ := request.Params.Arguments["dryRun"].(bool)
+
+		coreLogger.Debug("Executing rename_symbol for file: %s line: %d column: %d newName: %s dryRun: %v", filePath, line, column, newName, dryRun)
+		text, err := tools.RenameSymbol(ctx, s.clientFor(filePath), filePath, line, column, newName, dryRun)
+		if err != nil {
+			coreLogger.Error("Failed to rename symbol: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to rename symbol: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	renamePackageTool := mcp.NewTool("rename_package",
+		mcp.WithDescription("Move a package or module directory and update everything that refers to it: edits requested by the language server, Go import paths, and relative paths in the root go.mod and package.json. Defaults to a dry run that reports every change without making it."),
+		mcp.WithString("oldPath",
+			mcp.Required(),
+			mcp.Description("The directory to move, absolute or relative to the workspace"),
+		),
+		mcp.WithString("newPath",
+			mcp.Required(),
+			mcp.Description("The new location of the directory, absolute or relative to the workspace"),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("If true, only report the changes that would be made (default: true)"),
+			mcp.DefaultBool(true),
+		),
+	)
+
+	s.addTool(renamePackageTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		oldPath, ok := request.Params.Arguments["oldPath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("oldPath must be a string"), nil
+		}
+
+		newPath, ok := request.Params.Arguments["newPath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("newPath must be a string"), nil
+		}
+
+		dryRun := true // default value
+		if dryRunArg, ok := request.Params.Arguments["dryRun"].(bool); ok {
+			dryRun = dryRunArg
+		}
+
+		coreLogger.Debug("Executing rename_package from: %s to: %s dryRun: %v", oldPath, newPath, dryRun)
+		text, err := tools.RenamePackage(ctx, s.lspClient, s.config.workspaceDir, oldPath, newPath, dryRun)
+		if err != nil {
+			coreLogger.Error("Failed to rename package: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to rename package: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	renameFileTool := mcp.NewTool("rename_file",
+		mcp.WithDescription("Rename or move a file and update everything that refers to it, such as imports, using the edits the language server returns for workspace/willRenameFiles. Defaults to a dry run that reports every change without making it."),
+		mcp.WithString("oldPath",
+			mcp.Required(),
+			mcp.Description("The file to move, absolute or relative to the workspace"),
+		),
+		mcp.WithString("newPath",
+			mcp.Required(),
+			mcp.Description("The new location of the file, absolute or relative to the workspace"),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("If true, only report the changes that would be made (default: true)"),
+			mcp.DefaultBool(true),
+		),
+	)
+
+	s.addTool(renameFileTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		oldPath, ok := request.Params.Arguments["oldPath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("oldPath must be a string"), nil
+		}
+
+		newPath, ok := request.Params.Arguments["newPath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("newPath must be a string"), nil
+		}
+
+		dryRun := true // default value
+		if dryRunArg, ok := request.Params.Arguments["dryRun"].(bool); ok {
+			dryRun = dryRunArg
+		}
+
+		coreLogger.Debug("Executing rename_file from: %s to: %s dryRun: %v", oldPath, newPath, dryRun)
+		text, err := tools.RenameFile(ctx, s.clientFor(oldPath), s.config.workspaceDir, oldPath, newPath, dryRun)
+		if err != nil {
+			coreLogger.Error("Failed to rename file: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to rename file: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	securityWatchlistTool := mcp.NewTool("security_watchlist",
+		mcp.WithDescription("Report all current usages of security-sensitive symbols (command execution, dynamic evaluation, unsafe memory access, etc.) with surrounding context. Uses the configured watchlist unless symbols are given."),
+		mcp.WithArray("symbols",
+			mcp.Description("Optional list of symbols to report instead of the configured watchlist (e.g. 'exec.Command', 'eval'). A * wildcard matches any characters (e.g. 'os.exec*')"),
+			mcp.Items(map[string]any{
+				"type": "string",
+			}),
+		),
+	)
+
+	s.addTool(securityWatchlistTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		watchlist := s.config.securityWatchlist
+		if symbolsArg, ok := request.Params.Arguments["symbols"]; ok {
+			symbolsArray, ok := symbolsArg.([]any)
+			if !ok {
+				return mcp.NewToolResultError("symbols must be an array"), nil
+			}
+
+			watchlist = nil
+			for _, symbol := range symbolsArray {
+				symbolName, ok := symbol.(string)
+				if !ok {
+					return mcp.NewToolResultError("each symbol must be a string"), nil
+				}
+				watchlist = append(watchlist, symbolName)
+			}
+		}
+
+		coreLogger.Debug("Executing security_watchlist for %d symbols", len(watchlist))
+		text, err := tools.FindWatchlistUsages(ctx, s.lspClient, watchlist)
+		if err != nil {
+			coreLogger.Error("Failed to find watchlist usages: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to find watchlist usages: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	callPathTool := mcp.NewTool("call_path",
+		mcp.WithDescription("Search the call hierarchy for call paths from one symbol to another, e.g. to check whether user input handled by one function can reach a sensitive sink. Reports each path with the location of every call. Results are approximate: dynamic dispatch may not be followed."),
+		mcp.WithString("fromSymbol",
+			mcp.Required(),
+			mcp.Description("The name of the symbol where paths start (e.g. 'handleRequest', 'Server.ServeHTTP')"),
+		),
+		mcp.WithString("toSymbol",
+			mcp.Required(),
+			mcp.Description("The name of the symbol where paths end (e.g. 'exec.Command', 'DB.Exec')"),
+		),
+		mcp.WithNumber("maxDepth",
+			mcp.Description("Maximum number of calls in a path (default: 5)"),
+			mcp.DefaultNumber(5),
+		),
+		mcp.WithNumber("maxBreadth",
+			mcp.Description("Maximum number of callees followed from each function (default: 25)"),
+			mcp.DefaultNumber(25),
+		),
+	)
+
+	s.addTool(callPathTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		fromSymbol, ok := request.Params.Arguments["fromSymbol"].(string)
+		if !ok {
+			return mcp.NewToolResultError("fromSymbol must be a string"), nil
+		}
+
+		toSymbol, ok := request.Params.Arguments["toSymbol"].(string)
+		if !ok {
+			return mcp.NewToolResultError("toSymbol must be a string"), nil
+		}
+
+		maxDepth := intArg(request.Params.Arguments, "maxDepth", 5)
+		maxBreadth := intArg(request.Params.Arguments, "maxBreadth", 25)
+
+		if maxDepth < 1 || maxBreadth < 1 {
+			return mcp.NewToolResultError("maxDepth and maxBreadth must be at least 1"), nil
+		}
+
+		coreLogger.Debug("Executing call_path from: %s to: %s maxDepth: %d maxBreadth: %d", fromSymbol, toSymbol, maxDepth, maxBreadth)
+		text, err := tools.FindCallPaths(ctx, s.lspClient, fromSymbol, toSymbol, maxDepth, maxBreadth)
+		if err != nil {
+			coreLogger.Error("Failed to find call paths: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to find call paths: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	replayTool := mcp.NewTool(replayToolName,
+		mcp.WithDescription("Debugging aid: re-execute a recent tool call and return its result along with the full JSON-RPC trace of the exchange with the language server. Use listOnly to see the recent calls."),
+		mcp.WithNumber("index",
+			mcp.Description("Which recent tool call to replay, 1 being the most recent (default: 1)"),
+			mcp.DefaultNumber(1),
+		),
+		mcp.WithBoolean("listOnly",
+			mcp.Description("If true, list the recent tool calls without replaying any of them"),
+			mcp.DefaultBool(false),
+		),
+	)
+
+	s.addTool(replayTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		index := intArg(request.Params.Arguments, "index", 1)
+
+		if listOnly, ok := request.Params.Arguments["listOnly"].(bool); ok && listOnly {
+			var list strings.Builder
+			for i, inv := range s.history.recent() {
+				list.WriteString(fmt.Sprintf("[%d] %s\n", i+1, formatInvocation(inv)))
+			}
+			if list.Len() == 0 {
+				return mcp.NewToolResultText("No tool calls have been recorded."), nil
+			}
+			return mcp.NewToolResultText(list.String()), nil
+		}
+
+		coreLogger.Debug("Executing %s for index: %d", replayToolName, index)
+		text, err := s.replayInvocation(ctx, index)
+		if err != nil {
+			coreLogger.Error("Failed to replay tool call: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to replay tool call: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	transcriptTool := mcp.NewTool(transcriptToolName,
+		mcp.WithDescription("Export the transcript of this session as a JSON file: every tool call in order with its arguments, duration, a digest of its result and the file edits it made, along with the git commit of the workspace, so the session can be reviewed or replayed against a fresh checkout."),
+		mcp.WithString("path",
+			mcp.Description("Where to write the transcript, a new file in the workspace or the debug directory (default: a new file in the debug directory)"),
+		),
+	)
+
+	s.addTool(transcriptTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		path, _ := request.Params.Arguments["path"].(string)
+
+		coreLogger.Debug("Executing %s to: %s", transcriptToolName, path)
+		text, err := s.exportTranscript(path)
+		if err != nil {
+			coreLogger.Error("Failed to export transcript: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to export transcript: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	recommendationsTool := mcp.NewTool(recommendationsToolName,
+		mcp.WithDescription("Show the distribution of result sizes of each tool called in this session, with recommendations for calling tools or configuring the server to get smaller results."),
+	)
+
+	s.addTool(recommendationsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		coreLogger.Debug("Executing %s", recommendationsToolName)
+		return mcp.NewToolResultText(formatRecommendations(s.resultSizes.summaries())), nil
+	})
+
+	statusTool := mcp.NewTool(statusToolName,
+		mcp.WithDescription("Show the state of the server for operators of long-lived sessions: uptime, the language servers in use, any crash, and the goroutines, heap and open files of the process against their startup and peak values, with anomalies such as steady growth that suggest a leak."),
+	)
+
+	s.addTool(statusTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		coreLogger.Debug("Executing %s", statusToolName)
+		return mcp.NewToolResultText(s.status()), nil
+	})
+
+	if s.config.dedupeSnippets {
+		fetchSnippetTool := mcp.NewTool("fetch_snippet",
+			mcp.WithDescription("Fetch a code snippet that an earlier definition or references result replaced by its hash because it had already been returned in this session."),
+			mcp.WithString("hash",
+				mcp.Required(),
+				mcp.Description("The hash of the snippet, as shown in the result that referred to it"),
+			),
+		)
+
+		s.addTool(fetchSnippetTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			hash, ok := request.Params.Arguments["hash"].(string)
+			if !ok {
+				return mcp.NewToolResultError("hash must be a string"), nil
+			}
+
+			coreLogger.Debug("Executing fetch_snippet for hash: %s", hash)
+			snippet, ok := tools.FetchSnippet(hash)
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("unknown snippet: %s. It may have been forgotten; request the definition or references again", hash)), nil
+			}
+			return mcp.NewToolResultText(snippet), nil
+		})
+	}
+
+	if err := s.registerMacros(); err != nil {
+		return err
+	}
+	if err := s.registerAliases(); err != nil {
+		return err
+	}
+	if err := s.disableTools(); err != nil {
+		return err
+	}
+
+	coreLogger.Info("Successfully registered all MCP tools")
+	return nil
+}
+
+// numberArg returns a numeric argument as an int, and whether it is a
+// number. JSON numbers arrive as float64, but macros and replays may pass ints.
+func numberArg(arguments map[string]any, name string) (int, bool) {
+	switch v := arguments[name].(type) {
+	case float64:
+		return int(v), true
+	case int:
+		return v, true
+	}
+	return 0, false
+}
+
+// intArg returns a numeric argument as an int, or def if it is missing or
+// not a number
+func intArg(arguments map[string]any, name string, def int) int {
+	if v, ok := numberArg(arguments, name); ok {
+		return v
+	}
+	return def
+}
+
+// requiredIntArg returns a numeric argument as an int, returning an error
+// message if it is missing or not a number
+func requiredIntArg(arguments map[string]any, name string) (int, string) {
+	if v, ok := numberArg(arguments, name); ok {
+		return v, ""
+	}
+	return 0, name + " must be a number"
+}
+
+// positionArgs reads the line and column of the tools that take a position,
+// returning an error message if they are invalid
+func positionArgs(arguments map[string]any) (int, int, string) {
+	line, errMsg := requiredIntArg(arguments, "line")
+	if errMsg != "" {
+		return 0, 0, errMsg
+	}
+	column, errMsg := requiredIntArg(arguments, "column")
+	return line, column, errMsg
+}
+
+// parseTextEdits reads the edits of edit_file, returning an error message if
+// they are invalid
+func parseTextEdits(editsArg any) ([]tools.TextEdit, string) {
+	// Type assert and convert the edits
+	editsArray, ok := editsArg.([]any)
+	if !ok {
+		return nil, "edits must be an array"
+	}
+
+	var edits []tools.TextEdit
+	for _, editItem := range editsArray {
+		editMap, ok := editItem.(map[string]any)
+		if !ok {
+			return nil, "each edit must be an object"
+		}
+
+		startLine, errMsg := requiredIntArg(editMap, "startLine")
+		if errMsg != "" {
+			return nil, errMsg
+		}
+
+		endLine, errMsg := requiredIntArg(editMap, "endLine")
+		if errMsg != "" {
+			return nil, errMsg
+		}
+
+		newText, _ := editMap["newText"].(string) // newText can be empty
+
+		edits = append(edits, tools.TextEdit{
+			StartLine: startLine,
+			EndLine:   endLine,
+			// Columns are optional
+			StartColumn: intArg(editMap, "startColumn", 0),
+			EndColumn:   intArg(editMap, "endColumn", 0),
+			NewText:     newText,
+		})
+	}
+	return edits, ""
+}
+
+// codeActionRangeOptions are the parameters shared by the code action tools
+func codeActionRangeOptions() []mcp.ToolOption {
+	return []mcp.ToolOption{
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file"),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number where the range starts (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Description("The column number where the range starts (1-indexed, default: 1)"),
+			mcp.DefaultNumber(1),
+		),
+		mcp.WithNumber("endLine",
+			mcp.Description("The line number where the range ends (1-indexed, default: line)"),
+		),
+		mcp.WithNumber("endColumn",
+			mcp.Description("The column number where the range ends (1-indexed, default: column, or the end of the line when endLine is given)"),
+		),
+		mcp.WithArray("kinds",
+			mcp.Description("Optional code action kinds to list, e.g. 'quickfix', 'refactor', 'refactor.extract', 'source.organizeImports'"),
+			mcp.Items(map[string]any{
+				"type": "string",
+			}),
+		),
+	}
+}
+
+// codeActionArgs are the arguments shared by the code action tools
+type codeActionArgs struct {
+	filePath                         string
+	line, column, endLine, endColumn int
+	kinds                            []string
+}
+
+// parseCodeActionArgs reads the arguments shared by the code action tools,
+// returning an error message if they are invalid
+func parseCodeActionArgs(arguments map[string]any) (codeActionArgs, string) {
+	var args codeActionArgs
+
+	filePath, ok := arguments["filePath"].(string)
+	if !ok {
+		return args, "filePath must be a string"
+	}
+	args.filePath = filePath
+
+	line, errMsg := requiredIntArg(arguments, "line")
+	if errMsg != "" {
+		return args, errMsg
+	}
+	args.line = line
+
+	args.column = intArg(arguments, "column", 1)
+
+	args.endLine, args.endColumn = args.line, args.column
+	_, hasEndLine := arguments["endLine"]
+	args.endLine = intArg(arguments, "endLine", args.endLine)
+	if endColumn, ok := numberArg(arguments, "endColumn"); ok {
+		args.endColumn = endColumn
+	} else if hasEndLine {
+		// Cover the whole of the last line
+		args.endLine, args.endColumn = args.endLine+1, 1
+	}
+
+	if args.line < 1 || args.column < 1 || args.endLine < args.line || (args.endLine == args.line && args.endColumn < args.column) {
+		return args, "the range must start at or after L1:C1 and end at or after its start"
+	}
+
+	if kindsArg, ok := arguments["kinds"]; ok {
+		kindsArray, ok := kindsArg.([]any)
+		if !ok {
+			return args, "kinds must be an array"
+		}
+		for _, kind := range kindsArray {
+			kindName, ok := kind.(string)
+			if !ok {
+				return args, "each kind must be a string"
+			}
+			args.kinds = append(args.kinds, kindName)
+		}
+	}
+
+	return args, ""
+}
+
+// diagnosticFilterOptions are the parameters shared by the diagnostics tools
+// to filter the diagnostics they report
+func diagnosticFilterOptions() []mcp.ToolOption {
+	return []mcp.ToolOption{
+		mcp.WithString("severity",
+			mcp.Description("Only report diagnostics at least this severe, e.g. 'error' for compile errors only or 'warning' for errors and warnings (default: all)"),
+			mcp.Enum("error", "warning", "info", "hint"),
+		),
+		mcp.WithArray("sources",
+			mcp.Description("Only report diagnostics from these sources, e.g. 'compiler' or 'go vet'"),
+			mcp.Items(map[string]any{
+				"type": "string",
+			}),
+		),
+		mcp.WithArray("codes",
+			mcp.Description("Only report diagnostics with these codes, e.g. 'UnusedVariable' or '2304'"),
+			mcp.Items(map[string]any{
+				"type": "string",
+			}),
+		),
+	}
+}
+
+// parseDiagnosticFilterArgs reads the diagnostic filter of a diagnostics
+// tool, returning an error message if it is invalid
+func parseDiagnosticFilterArgs(arguments map[string]any) (tools.DiagnosticFilter, string) {
+	var filter tools.DiagnosticFilter
+
+	if severity, ok := arguments["severity"].(string); ok && severity != "" {
+		minSeverity, err := tools.ParseSeverity(severity)
+		if err != nil {
+			return filter, err.Error()
+		}
+		filter.MinSeverity = minSeverity
+	}
+
+	for _, param := range []struct {
+		name   string
+		values *[]string
+	}{{"sources", &filter.Sources}, {"codes", &filter.Codes}} {
+		arg, ok := arguments[param.name]
+		if !ok {
+			continue
+		}
+		array, ok := arg.([]any)
+		if !ok {
+			return filter, param.name + " must be an array"
+		}
+		for _, value := range array {
+			switch v := value.(type) {
+			case string:
+				*param.values = append(*param.values, v)
+			case float64:
+				// Numeric codes, as sent by some servers
+				*param.values = append(*param.values, fmt.Sprintf("%v", v))
+			default:
+				return filter, "each of " + param.name + " must be a string"
+			}
+		}
+	}
+
+	return filter, ""
+}
+
+// formattingToolOptions are the parameters shared by the formatting tools
+func formattingToolOptions() []mcp.ToolOption {
+	return []mcp.ToolOption{
+		mcp.WithNumber("tabSize",
+			mcp.Description("The size of a tab in spaces (default: 4)"),
+			mcp.DefaultNumber(4),
+		),
+		mcp.WithBoolean("insertSpaces",
+			mcp.Description("Whether to indent with spaces rather than tabs (default: true). Many formatters follow the language's conventions instead."),
+			mcp.DefaultBool(true),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("If true, return the changes as a unified diff without writing them (default: false)"),
+			mcp.DefaultBool(false),
+		),
+	}
+}
+
+// parseFormattingArgs extracts the formatting options and dry run flag from
+// the arguments of a formatting tool
+func parseFormattingArgs(arguments map[string]any) (protocol.FormattingOptions, bool) {
+	options := protocol.FormattingOptions{
+		TabSize:      4,
+		InsertSpaces: true,
+	}
+
+	if tabSize := intArg(arguments, "tabSize", 0); tabSize >= 1 {
+		options.TabSize = uint32(tabSize)
+	}
+
+	if insertSpaces, ok := arguments["insertSpaces"].(bool); ok {
+		options.InsertSpaces = insertSpaces
+	}
+
+	dryRun, _ := arguments["dryRun"].(bool)
+	return options, dryRun
 }
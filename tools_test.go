@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/koonwen/mcp-language-server/internal/tools"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntArgs(t *testing.T) {
+	arguments := map[string]any{
+		"line":      float64(12),
+		"column":    3,
+		"filePath":  "main.go",
+		"maxDepth":  nil,
+		"startLine": float64(2.7),
+	}
+
+	assert.Equal(t, 12, intArg(arguments, "line", 1))
+	assert.Equal(t, 3, intArg(arguments, "column", 1))
+	assert.Equal(t, 2, intArg(arguments, "startLine", 1))
+	assert.Equal(t, 5, intArg(arguments, "maxDepth", 5))
+	assert.Equal(t, 5, intArg(arguments, "filePath", 5))
+	assert.Equal(t, 25, intArg(arguments, "maxBreadth", 25))
+
+	_, errMsg := requiredIntArg(arguments, "filePath")
+	assert.Equal(t, "filePath must be a number", errMsg)
+	_, errMsg = requiredIntArg(arguments, "endLine")
+	assert.Equal(t, "endLine must be a number", errMsg)
+
+	line, column, errMsg := positionArgs(arguments)
+	assert.Equal(t, "", errMsg)
+	assert.Equal(t, []int{12, 3}, []int{line, column})
+
+	_, _, errMsg = positionArgs(map[string]any{"line": float64(1)})
+	assert.Equal(t, "column must be a number", errMsg)
+}
+
+func TestParseTextEdits(t *testing.T) {
+	// Clients send JSON numbers, macros and replays pass ints
+	edits, errMsg := parseTextEdits([]any{
+		map[string]any{"startLine": float64(3), "endLine": float64(4), "newText": "x"},
+		map[string]any{"startLine": 7, "endLine": 7, "startColumn": 2, "endColumn": float64(5), "newText": "y"},
+	})
+	assert.Equal(t, "", errMsg)
+	assert.Equal(t, []tools.TextEdit{
+		{StartLine: 3, EndLine: 4, NewText: "x"},
+		{StartLine: 7, EndLine: 7, StartColumn: 2, EndColumn: 5, NewText: "y"},
+	}, edits)
+
+	tests := []struct {
+		edits  any
+		errMsg string
+	}{
+		{map[string]any{}, "edits must be an array"},
+		{[]any{"x"}, "each edit must be an object"},
+		{[]any{map[string]any{"endLine": 1}}, "startLine must be a number"},
+		{[]any{map[string]any{"startLine": 1, "endLine": "2"}}, "endLine must be a number"},
+	}
+	for _, tt := range tests {
+		_, errMsg := parseTextEdits(tt.edits)
+		assert.Equal(t, tt.errMsg, errMsg)
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/koonwen/mcp-language-server/internal/fingerprint"
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/protocol"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// transcriptToolName is the name of the transcript export tool, which is
+	// not itself recorded
+	transcriptToolName = "export_transcript"
+	// maxTranscriptCalls bounds the number of calls kept in the transcript.
+	// Older calls are dropped and counted.
+	maxTranscriptCalls = 10000
+)
+
+// transcript records every tool call of the session, with a digest of its
+// result and the edits it made, so the session can be reviewed or replayed
+// against a fresh checkout
+type transcript struct {
+	mu      sync.Mutex
+	calls   []transcriptCall
+	dropped int
+}
+
+// transcriptCall is a tool call in an exported transcript
+type transcriptCall struct {
+	Time       time.Time      `json:"time"`
+	Tool       string         `json:"tool"`
+	Arguments  map[string]any `json:"arguments"`
+	DurationMs int64          `json:"durationMs"`
+	IsError    bool           `json:"isError,omitempty"`
+	// ResultDigest is the SHA-256 of the text of the result, to check a
+	// replay returns the same result without storing it
+	ResultDigest string `json:"resultDigest,omitempty"`
+	ResultLines  int    `json:"resultLines"`
+	// Edits are the changes to files on disk sent to the language server
+	// during the call, in order
+	Edits []transcriptEdit `json:"edits,omitempty"`
+}
+
+// transcriptEdit is a textDocument/didChange notification for a file
+type transcriptEdit struct {
+	Path    string          `json:"path"`
+	Version int32           `json:"version"`
+	Changes json.RawMessage `json:"changes"`
+}
+
+// transcriptExport is the JSON file written by export_transcript
+type transcriptExport struct {
+	Version   string    `json:"serverVersion"`
+	Exported  time.Time `json:"exported"`
+	Workspace string    `json:"workspace"`
+	// Commit is the git commit checked out in the workspace when exported.
+	// Replays should start from a checkout of it.
+	Commit       string           `json:"commit,omitempty"`
+	LSPCommand   []string         `json:"lspCommand"`
+	DroppedCalls int              `json:"droppedCalls,omitempty"`
+	Calls        []transcriptCall `json:"calls"`
+}
+
+// add records a tool call with the JSON-RPC messages exchanged with the
+// language server while it ran. Messages of calls running concurrently are
+// mixed in the trace, so their edits may be attributed to either call.
+func (t *transcript) add(start time.Time, request mcp.CallToolRequest, result *mcp.CallToolResult, entries []lsp.TraceEntry) {
+	call := transcriptCall{
+		Time:       start,
+		Tool:       request.Params.Name,
+		Arguments:  request.Params.Arguments,
+		DurationMs: time.Since(start).Milliseconds(),
+		Edits:      traceEdits(entries),
+	}
+	if result != nil {
+		call.IsError = result.IsError
+		call.ResultDigest = resultDigest(result)
+		call.ResultLines = resultLines(result)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.calls = append(t.calls, call)
+	if len(t.calls) > maxTranscriptCalls {
+		t.calls = t.calls[1:]
+		t.dropped++
+	}
+}
+
+// resultDigest hashes the text content of a tool result
+func resultDigest(result *mcp.CallToolResult) string {
+	h := sha256.New()
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			h.Write([]byte(text.Text))
+		}
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// traceEdits extracts the changes to files on disk sent to the language
+// server in a trace
+func traceEdits(entries []lsp.TraceEntry) []transcriptEdit {
+	var edits []transcriptEdit
+	for _, entry := range entries {
+		if !entry.Sent {
+			continue
+		}
+		var notification struct {
+			Method string `json:"method"`
+			Params struct {
+				TextDocument   protocol.VersionedTextDocumentIdentifier `json:"textDocument"`
+				ContentChanges json.RawMessage                          `json:"contentChanges"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal(entry.Message, &notification); err != nil || notification.Method != "textDocument/didChange" {
+			continue
+		}
+		uri := notification.Params.TextDocument.URI
+		if !lsp.IsFileURI(uri) {
+			continue
+		}
+		edits = append(edits, transcriptEdit{
+			Path:    lsp.DocumentPath(uri),
+			Version: notification.Params.TextDocument.Version,
+			Changes: notification.Params.ContentChanges,
+		})
+	}
+	return edits
+}
+
+// exportTranscript writes the transcript to a JSON file and describes what
+// was written. If path is empty the file is written to the debug directory.
+// Otherwise it must be in the debug directory or the workspace, relative
+// paths being relative to the workspace, and must not exist yet.
+func (s *mcpServer) exportTranscript(path string) (string, error) {
+	s.transcript.mu.Lock()
+	export := transcriptExport{
+		Version:      serverVersion,
+		Exported:     time.Now(),
+		Workspace:    s.config.workspaceDir,
+		Commit:       fingerprint.GitCommit(s.config.workspaceDir),
+		LSPCommand:   append([]string{s.config.lspCommand}, s.config.lspArgs...),
+		DroppedCalls: s.transcript.dropped,
+		Calls:        append([]transcriptCall{}, s.transcript.calls...),
+	}
+	s.transcript.mu.Unlock()
+
+	debugDir := s.config.debugDir
+	if debugDir == "" {
+		debugDir = defaultDebugDir
+	}
+	if path == "" {
+		if err := os.MkdirAll(debugDir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create debug directory: %v", err)
+		}
+		path = filepath.Join(debugDir, fmt.Sprintf("transcript-%s-%d.json", export.Exported.Format("20060102-150405"), os.Getpid()))
+	} else {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(s.config.workspaceDir, path)
+		}
+		path = filepath.Clean(path)
+		if !isWithinDir(debugDir, path) && !isWithinDir(s.config.workspaceDir, path) {
+			return "", fmt.Errorf("path is outside the workspace and the debug directory: %s", path)
+		}
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal transcript: %v", err)
+	}
+	// Never overwrite an existing file
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to write transcript: %v", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to write transcript: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write transcript: %v", err)
+	}
+	return formatTranscriptSummary(path, export.Calls), nil
+}
+
+// isWithinDir reports whether the clean absolute path is dir or inside it
+func isWithinDir(dir, path string) bool {
+	if dir == "" {
+		return false
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// formatTranscriptSummary describes an exported transcript
+func formatTranscriptSummary(path string, calls []transcriptCall) string {
+	edited := make(map[string]bool)
+	for _, call := range calls {
+		for _, edit := range call.Edits {
+			edited[edit.Path] = true
+		}
+	}
+	return fmt.Sprintf("Exported %d tool calls, editing %d files, to %s", len(calls), len(edited), path)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportTranscriptPath(t *testing.T) {
+	workspace := t.TempDir()
+	debugDir := t.TempDir()
+	s := &mcpServer{config: config{workspaceDir: workspace, debugDir: debugDir}}
+
+	_, err := s.exportTranscript("session.json")
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(workspace, "session.json"))
+
+	_, err = s.exportTranscript(filepath.Join(debugDir, "session.json"))
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(debugDir, "session.json"))
+
+	_, err = s.exportTranscript("session.json")
+	assert.ErrorContains(t, err, "failed to write transcript")
+
+	outside := filepath.Join(t.TempDir(), "session.json")
+	_, err = s.exportTranscript(outside)
+	assert.EqualError(t, err, "path is outside the workspace and the debug directory: "+outside)
+	_, err = s.exportTranscript("../session.json")
+	assert.ErrorContains(t, err, "path is outside the workspace and the debug directory")
+	_, statErr := os.Stat(outside)
+	assert.True(t, os.IsNotExist(statErr))
+}
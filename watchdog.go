@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// watchdogInterval is how often the watchdog takes a snapshot
+	watchdogInterval = time.Minute
+	// watchdogSamples is the number of recent snapshots kept, an hour's worth
+	watchdogSamples = 60
+	// watchdogTrend is the number of consecutive increases over which a
+	// resource is reported as growing steadily
+	watchdogTrend = 10
+	// maxAnomalies is the number of recent anomalies kept for the status tool
+	maxAnomalies = 20
+	// statusToolName is the name of the status tool
+	statusToolName = "status"
+)
+
+// runtimeSnapshot records the resources used by the process at a point in time
+type runtimeSnapshot struct {
+	time       time.Time
+	goroutines int
+	heapBytes  uint64
+	// Open file descriptors, or -1 where they cannot be counted
+	openFiles int
+}
+
+// watchdogResource describes a resource tracked by the watchdog and how much
+// growth over the first snapshot is considered an anomaly
+type watchdogResource struct {
+	name  string
+	value func(runtimeSnapshot) float64
+	// Growth is an anomaly when the value is both factor times and minDelta
+	// more than at startup
+	factor   float64
+	minDelta float64
+	format   func(float64) string
+}
+
+var watchdogResources = []watchdogResource{
+	{"goroutines", func(s runtimeSnapshot) float64 { return float64(s.goroutines) }, 2, 100, formatCount},
+	{"heap", func(s runtimeSnapshot) float64 { return float64(s.heapBytes) }, 2, 256 << 20, formatBytes},
+	{"open files", func(s runtimeSnapshot) float64 { return float64(s.openFiles) }, 2, 200, formatCount},
+}
+
+// watchdog periodically snapshots the goroutines, heap and open files of the
+// process and logs anomalies, so that leaks in long-lived sessions show up
+// before they take the server down
+type watchdog struct {
+	mu        sync.Mutex
+	baseline  *runtimeSnapshot
+	samples   []runtimeSnapshot
+	anomalies []string
+	// Value of each resource when an anomaly was last reported, to avoid
+	// repeating the report on every snapshot
+	reported map[string]float64
+}
+
+// run takes a snapshot every watchdogInterval until ctx is done
+func (w *watchdog) run(ctx context.Context) {
+	w.record(takeSnapshot())
+
+	ticker := time.NewTicker(watchdogInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, anomaly := range w.record(takeSnapshot()) {
+				coreLogger.Warn("Watchdog: %s", anomaly)
+			}
+		}
+	}
+}
+
+func takeSnapshot() runtimeSnapshot {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return runtimeSnapshot{
+		time:       time.Now(),
+		goroutines: runtime.NumGoroutine(),
+		heapBytes:  mem.HeapAlloc,
+		openFiles:  countOpenFiles(),
+	}
+}
+
+// countOpenFiles counts the file descriptors of the process on systems that
+// list them under /proc or /dev/fd
+func countOpenFiles() int {
+	for _, dir := range []string{"/proc/self/fd", "/dev/fd"} {
+		if entries, err := os.ReadDir(dir); err == nil {
+			// Reading the directory opens one more
+			return len(entries) - 1
+		}
+	}
+	return -1
+}
+
+// record adds a snapshot and returns the anomalies it reveals
+func (w *watchdog) record(snapshot runtimeSnapshot) []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.baseline == nil {
+		w.baseline = &snapshot
+		w.reported = make(map[string]float64)
+	}
+	w.samples = append(w.samples, snapshot)
+	if len(w.samples) > watchdogSamples {
+		w.samples = w.samples[1:]
+	}
+
+	var anomalies []string
+	for _, resource := range watchdogResources {
+		base, current := resource.value(*w.baseline), resource.value(snapshot)
+		if base < 0 || current < 0 {
+			continue
+		}
+
+		var anomaly string
+		switch {
+		case current >= base*resource.factor && current-base >= resource.minDelta:
+			anomaly = fmt.Sprintf("%s at %s, up from %s at startup", resource.name, resource.format(current), resource.format(base))
+		case w.growing(resource):
+			anomaly = fmt.Sprintf("%s grew in each of the last %d snapshots to %s", resource.name, watchdogTrend, resource.format(current))
+		default:
+			// Report the resource again if it grows anomalous once more
+			delete(w.reported, resource.name)
+			continue
+		}
+
+		// Only report again once the resource has grown by half since the
+		// last report
+		if last, ok := w.reported[resource.name]; ok && current < last*1.5 {
+			continue
+		}
+		w.reported[resource.name] = current
+		anomalies = append(anomalies, anomaly)
+	}
+
+	for _, anomaly := range anomalies {
+		w.anomalies = append(w.anomalies, snapshot.time.Format(time.TimeOnly)+" "+anomaly)
+	}
+	if len(w.anomalies) > maxAnomalies {
+		w.anomalies = w.anomalies[len(w.anomalies)-maxAnomalies:]
+	}
+	return anomalies
+}
+
+// growing reports whether a resource increased in each of the last
+// watchdogTrend snapshots
+func (w *watchdog) growing(resource watchdogResource) bool {
+	if len(w.samples) <= watchdogTrend {
+		return false
+	}
+	recent := w.samples[len(w.samples)-watchdogTrend-1:]
+	for i := 1; i < len(recent); i++ {
+		if resource.value(recent[i]) <= resource.value(recent[i-1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// report describes the current resource usage of the process against its
+// startup and peak usage, along with recent anomalies
+func (w *watchdog) report() string {
+	current := takeSnapshot()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var out strings.Builder
+	out.WriteString("Resources (current / at startup / peak of the last hour):\n")
+	for _, resource := range watchdogResources {
+		value := resource.value(current)
+		if value < 0 {
+			out.WriteString(fmt.Sprintf("  %s: unavailable\n", resource.name))
+			continue
+		}
+		base, peak := value, value
+		if w.baseline != nil && resource.value(*w.baseline) >= 0 {
+			base = resource.value(*w.baseline)
+		}
+		for _, sample := range w.samples {
+			peak = max(peak, resource.value(sample))
+		}
+		out.WriteString(fmt.Sprintf("  %s: %s / %s / %s\n", resource.name, resource.format(value), resource.format(base), resource.format(peak)))
+	}
+
+	if len(w.anomalies) == 0 {
+		out.WriteString("No anomalies detected.\n")
+	} else {
+		out.WriteString("Recent anomalies:\n")
+		for _, anomaly := range w.anomalies {
+			out.WriteString("  " + anomaly + "\n")
+		}
+	}
+	return out.String()
+}
+
+// status describes the server for operators: uptime, the language servers in
+// use, any crash and the watchdog's report
+func (s *mcpServer) status() string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("PID: %d\n", os.Getpid()))
+	out.WriteString(fmt.Sprintf("Uptime: %s\n", time.Since(s.started).Round(time.Second)))
+	out.WriteString(fmt.Sprintf("Workspace: %s\n", s.config.workspaceDir))
+
+	out.WriteString("Language servers:\n")
+	out.WriteString(fmt.Sprintf("  %s (main)\n", filepath.Base(s.config.lspCommand)))
+	for _, server := range s.additionalServers {
+		out.WriteString(fmt.Sprintf("  %s\n", server.name))
+	}
+
+	s.crashMu.Lock()
+	crashBundle := s.crashBundle
+	s.crashMu.Unlock()
+	if crashBundle != "" {
+		out.WriteString(crashNote(crashBundle) + "\n")
+	}
+
+	out.WriteString("\n" + s.watchdog.report())
+	return out.String()
+}
+
+func formatCount(v float64) string {
+	return fmt.Sprintf("%d", int64(v))
+}
+
+func formatBytes(v float64) string {
+	return fmt.Sprintf("%.1f MiB", v/(1<<20))
+}
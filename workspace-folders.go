@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/koonwen/mcp-language-server/internal/lsp"
+	"github.com/koonwen/mcp-language-server/internal/watcher"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// workspaceFolders are the workspace folders passed to the language servers
+// in addition to the workspace, from the config file or added with the
+// add_workspace_folder tool, and the watchers of those outside the workspace
+type workspaceFolders struct {
+	mu       sync.Mutex
+	dirs     []string
+	watchers map[string][]context.CancelFunc
+}
+
+// list returns the extra workspace folders
+func (f *workspaceFolders) list() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.dirs)
+}
+
+// resolveFolder returns the absolute path of a directory, absolute or
+// relative to the workspace, and whether it exists
+func resolveFolder(workspaceDir, folder string) (string, bool) {
+	path := folder
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workspaceDir, path)
+	}
+	path = filepath.Clean(path)
+	info, err := os.Stat(path)
+	return path, err == nil && info.IsDir()
+}
+
+// parseWorkspaceFolders validates the extra workspace folders of the config
+// file, absolute or relative to the workspace, and returns their absolute
+// paths
+func parseWorkspaceFolders(workspaceDir string, folders []string) ([]string, error) {
+	var dirs []string
+	seen := map[string]bool{workspaceDir: true}
+	for _, folder := range folders {
+		path, ok := resolveFolder(workspaceDir, folder)
+		if !ok {
+			return nil, fmt.Errorf("workspace folder in config file does not exist: %s", folder)
+		}
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		dirs = append(dirs, path)
+	}
+	return dirs, nil
+}
+
+// workspaceFolders returns the directories passed to language servers as
+// their workspace folders: the workspace, or its scope directories, followed
+// by the extra folders
+func (s *mcpServer) workspaceFolders() []string {
+	folders := []string{s.config.workspaceDir}
+	if len(s.config.scope) > 0 {
+		folders = s.scopeDirs()
+	}
+	return append(folders, s.folders.list()...)
+}
+
+// configureWorkspaceFolders passes the workspace folders to a language
+// server, so that a single server covers sibling repositories or the roots
+// of a monorepo
+func (s *mcpServer) configureWorkspaceFolders(client *lsp.Client) {
+	if len(s.config.scope) == 0 && len(s.folders.list()) == 0 {
+		return
+	}
+	client.SetWorkspaceFolders(s.workspaceFolders())
+}
+
+// watchWorkspaceFolders starts a watcher for each extra workspace folder
+func (s *mcpServer) watchWorkspaceFolders(client *lsp.Client, handler watcher.FileChangeHandler) {
+	for _, dir := range s.folders.list() {
+		s.watchFolder(client, dir, handler)
+	}
+}
+
+// watchFolder starts a watcher for an extra workspace folder outside the
+// workspace, which the workspace watcher does not cover. It stops when the
+// folder is removed.
+func (s *mcpServer) watchFolder(client *lsp.Client, dir string, handler watcher.FileChangeHandler) {
+	if inDir(s.config.workspaceDir, dir) {
+		return
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.folders.mu.Lock()
+	if s.folders.watchers == nil {
+		s.folders.watchers = make(map[string][]context.CancelFunc)
+	}
+	s.folders.watchers[dir] = append(s.folders.watchers[dir], cancel)
+	s.folders.mu.Unlock()
+
+	folderWatcher := watcher.NewWorkspaceWatcherWithConfig(client, s.watcherConfig())
+	folderWatcher.SetChangeHandler(handler)
+	go folderWatcher.WatchWorkspace(ctx, dir)
+}
+
+// addWorkspaceFolder adds a directory, absolute or relative to the
+// workspace, to the workspace folders of the running language servers, and
+// reports the outcome for each server
+func (s *mcpServer) addWorkspaceFolder(ctx context.Context, folder string) (string, error) {
+	dir, ok := resolveFolder(s.config.workspaceDir, folder)
+	if !ok {
+		return "", fmt.Errorf("directory does not exist: %s", folder)
+	}
+	if slices.Contains(s.workspaceFolders(), dir) {
+		return fmt.Sprintf("%s is already a workspace folder", dir), nil
+	}
+
+	var report strings.Builder
+	added := false
+	for _, server := range s.symbolServers() {
+		if err := server.Client.AddWorkspaceFolder(ctx, dir); err != nil {
+			report.WriteString(fmt.Sprintf("%s: not added: %v\n", server.Name, err))
+			continue
+		}
+		report.WriteString(fmt.Sprintf("%s: added\n", server.Name))
+		s.watchFolder(server.Client, dir, s.handleFileChange)
+		added = true
+	}
+	if !added {
+		return "", fmt.Errorf("no language server accepted %s:\n%s", dir, strings.TrimSpace(report.String()))
+	}
+
+	s.folders.mu.Lock()
+	s.folders.dirs = append(s.folders.dirs, dir)
+	s.folders.mu.Unlock()
+	return fmt.Sprintf("Added workspace folder %s\n%s", dir, report.String()), nil
+}
+
+// removeWorkspaceFolder removes an extra workspace folder from the running
+// language servers and stops watching it
+func (s *mcpServer) removeWorkspaceFolder(ctx context.Context, folder string) (string, error) {
+	dir, _ := resolveFolder(s.config.workspaceDir, folder)
+	s.folders.mu.Lock()
+	index := slices.Index(s.folders.dirs, dir)
+	if index < 0 {
+		s.folders.mu.Unlock()
+		return "", fmt.Errorf("%s is not an extra workspace folder", dir)
+	}
+	s.folders.dirs = slices.Delete(s.folders.dirs, index, index+1)
+	for _, cancel := range s.folders.watchers[dir] {
+		cancel()
+	}
+	delete(s.folders.watchers, dir)
+	s.folders.mu.Unlock()
+
+	var report strings.Builder
+	for _, server := range s.symbolServers() {
+		if err := server.Client.RemoveWorkspaceFolder(ctx, dir); err != nil {
+			report.WriteString(fmt.Sprintf("%s: not removed: %v\n", server.Name, err))
+			continue
+		}
+		report.WriteString(fmt.Sprintf("%s: removed\n", server.Name))
+	}
+	return fmt.Sprintf("Removed workspace folder %s\n%s", dir, report.String()), nil
+}
+
+// workspaceFolder returns the workspace folder a path, absolute or relative
+// to the workspace, is in: the innermost of the extra folders containing
+// it, or else the workspace. It returns false if the path is in none.
+func (s *mcpServer) workspaceFolder(path string) (string, bool) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.config.workspaceDir, path)
+	}
+	path = filepath.Clean(path)
+	folder := ""
+	for _, dir := range s.folders.list() {
+		if inDir(dir, path) && len(dir) > len(folder) {
+			folder = dir
+		}
+	}
+	if folder == "" && inDir(s.config.workspaceDir, path) {
+		folder = s.config.workspaceDir
+	}
+	return folder, folder != ""
+}
+
+// withWorkspaceFolder names the workspace folder of the path a tool was
+// asked about when several folders are configured, so that results from
+// repositories with similar layouts can be told apart
+func (s *mcpServer) withWorkspaceFolder(request mcp.CallToolRequest, result *mcp.CallToolResult) *mcp.CallToolResult {
+	if len(s.folders.list()) == 0 || result == nil {
+		return result
+	}
+	for _, key := range scopedPathArguments {
+		path, _ := request.Params.Arguments[key].(string)
+		if path == "" {
+			continue
+		}
+		if folder, ok := s.workspaceFolder(path); ok {
+			result.Content = append(result.Content, mcp.NewTextContent(fmt.Sprintf(
+				"Workspace folder: %s (%s)", filepath.Base(folder), folder)))
+		}
+		break
+	}
+	return result
+}
+
+// inDir reports whether path is dir or inside it
+func inDir(dir, path string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}